@@ -1,23 +1,94 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
-	"github.com/yantrio/mcp-gopls/internal/server"
+	"github.com/yantrio/mcp-gopls/pkg/mcpgopls"
 )
 
 func main() {
 	var (
-		goplsPath     string
-		workspaceRoot string
-		version       bool
+		goplsPath      string
+		remote         string
+		mock           bool
+		recordLSP      string
+		replayLSP      string
+		workspaceRoot  string
+		autoAddFolders bool
+		includeVendor  bool
+		dirFilters     string
+		memoryMode     string
+		maxMemory      string
+		memoryInterval time.Duration
+		maxFileSize    string
+		fileHeader     string
+		markGenerated  bool
+		toolTimeout    time.Duration
+		toolNames      string
+		toolPrefix     string
+		toolNameMax    int
+		toolAliases    string
+		logToolCalls   bool
+		telemetryOn    bool
+		telemetryFile  string
+		exportTelem    bool
+		dryRun         bool
+		relativePaths  bool
+		structured     bool
+		tokenBudget    int
+		instructions   string
+		extraInstr     string
+		fd             int
+		listenFD       int
+		unixSocket     string
+		idleExit       time.Duration
+		version        bool
 	)
 
 	flag.StringVar(&goplsPath, "gopls", "", "Path to gopls binary (defaults to 'gopls' in PATH)")
+	flag.StringVar(&remote, "remote", "", "Share a gopls daemon: 'auto', or the address of one started with gopls -listen (host:port or unix;/path)")
+	flag.BoolVar(&mock, "mock", false, "Answer with a built-in fake gopls that needs neither gopls nor Go installed, for tests and demos")
+	flag.StringVar(&recordLSP, "record-lsp", "", "Record the LSP messages exchanged with gopls to this file, for bug reports")
+	flag.StringVar(&replayLSP, "replay-lsp", "", "Answer LSP requests from a file written with -record-lsp instead of starting gopls")
 	flag.StringVar(&workspaceRoot, "workspace", "", "Workspace root directory (defaults to current directory)")
+	flag.BoolVar(&autoAddFolders, "auto-add-folders", false, "Add the module of files outside the workspace as extra gopls workspace folders")
+	flag.BoolVar(&includeVendor, "include-vendor", false, "Include vendor directories in symbol search, references and diagnostics")
+	flag.StringVar(&dirFilters, "directory-filters", "", "Comma-separated gopls directoryFilters, e.g. '-,+services/api' to analyze only services/api")
+	flag.StringVar(&memoryMode, "memory-mode", "", "gopls memory mode: 'default' or 'low' (fewer features, less memory)")
+	flag.StringVar(&maxMemory, "max-gopls-memory", "", "Restart gopls when its resident memory exceeds this size, e.g. 2GiB")
+	flag.DurationVar(&memoryInterval, "memory-check-interval", 30*time.Second, "How often to check gopls memory against -max-gopls-memory")
+	flag.StringVar(&maxFileSize, "max-file-size", "", "Refuse to open files larger than this size in gopls, e.g. 16MiB (default 4MiB, 0 for no limit)")
+	flag.StringVar(&fileHeader, "file-header", "", "File holding a license or copyright header template stamped on the Go files tools create")
+	flag.BoolVar(&markGenerated, "generated-marker", false, "Stamp '// Code generated by mcp-gopls.' on the Go files tools create")
+	flag.DurationVar(&toolTimeout, "tool-timeout", 5*time.Minute, "Maximum duration of a single tool call (0 for no limit)")
+	flag.StringVar(&toolNames, "tool-names", "", "Case of the tool names clients see: 'pascal' (default), 'snake', 'camel' or 'kebab'")
+	flag.StringVar(&toolPrefix, "tool-prefix", "", "Prefix put before every tool name, e.g. 'go_'")
+	flag.IntVar(&toolNameMax, "tool-name-max-length", 0, "Shorten longer tool names to this many characters (0 for no limit)")
+	flag.StringVar(&toolAliases, "tool-aliases", "", "JSON file mapping built-in tool names to the names clients see, e.g. {\"GoToDefinition\": \"definition\"}")
+	flag.BoolVar(&logToolCalls, "log-tool-calls", false, "Log every tool call with its duration and outcome to stderr")
+	flag.BoolVar(&telemetryOn, "telemetry", false, "Record the name, duration and error category of every tool call to a local file; nothing else is recorded or sent")
+	flag.StringVar(&telemetryFile, "telemetry-file", "", "File -telemetry records to (defaults to telemetry.jsonl in the user cache directory's mcp-gopls folder)")
+	flag.BoolVar(&exportTelem, "export-telemetry", false, "Print a JSON summary of the recorded telemetry per tool and exit")
+	flag.BoolVar(&dryRun, "dry-run", false, "Make refactoring tools preview their changes unless a call sets dryRun to false")
+	flag.BoolVar(&relativePaths, "relative-paths", false, "Report file paths in tool results relative to the workspace root")
+	flag.BoolVar(&structured, "structured-content", false, "Return diffs and code blocks in tool results as embedded resources with file URIs and MIME types")
+	flag.IntVar(&tokenBudget, "token-budget", 0, "Summarize tool results estimated to take more tokens than this, with a cursor for the rest (0 for no limit)")
+	flag.StringVar(&instructions, "instructions", "", "File holding instructions for agents that replace the built-in ones; the enabled tools are still listed")
+	flag.StringVar(&extraInstr, "extra-instructions", "", "File holding instructions appended to the built-in ones, e.g. project conventions")
+	flag.IntVar(&fd, "fd", -1, "Serve MCP over this inherited file descriptor, already connected to the client, instead of stdio")
+	flag.IntVar(&listenFD, "listen-fd", -1, "Accept MCP clients on this inherited listening socket (3 with systemd socket activation)")
+	flag.StringVar(&unixSocket, "unix", "", "Accept MCP clients on a unix socket at this path")
+	flag.DurationVar(&idleExit, "idle-exit", 0, "Exit after this long without MCP requests (0 to never exit)")
 	flag.BoolVar(&version, "version", false, "Print version and exit")
 	flag.Parse()
 
@@ -27,21 +98,241 @@ func main() {
 	}
 
 	// Use environment variables if flags not provided
+	if !telemetryOn {
+		telemetryOn = os.Getenv("MCP_GOPLS_TELEMETRY") == "1"
+	}
+	if telemetryFile == "" {
+		telemetryFile = os.Getenv("MCP_GOPLS_TELEMETRY_FILE")
+	}
+	if telemetryFile == "" && (telemetryOn || exportTelem) {
+		var err error
+		if telemetryFile, err = mcpgopls.DefaultTelemetryFile(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if exportTelem {
+		if err := mcpgopls.ExportTelemetry(telemetryFile, os.Stdout); err != nil {
+			log.Fatalf("Failed to export telemetry: %v", err)
+		}
+		os.Exit(0)
+	}
+	if !telemetryOn {
+		telemetryFile = ""
+	}
 	if goplsPath == "" {
 		goplsPath = os.Getenv("GOPLS_PATH")
 	}
+	if remote == "" {
+		remote = os.Getenv("MCP_GOPLS_REMOTE")
+	}
+	if !mock {
+		mock = os.Getenv("MCP_GOPLS_MOCK") == "1"
+	}
+	if recordLSP == "" {
+		recordLSP = os.Getenv("MCP_GOPLS_RECORD_LSP")
+	}
+	if replayLSP == "" {
+		replayLSP = os.Getenv("MCP_GOPLS_REPLAY_LSP")
+	}
 	if workspaceRoot == "" {
 		workspaceRoot = os.Getenv("MCP_GOPLS_WORKSPACE")
 	}
+	if !autoAddFolders {
+		autoAddFolders = os.Getenv("MCP_GOPLS_AUTO_ADD_FOLDERS") == "1"
+	}
+	if !includeVendor {
+		includeVendor = os.Getenv("MCP_GOPLS_INCLUDE_VENDOR") == "1"
+	}
+	if !relativePaths {
+		relativePaths = os.Getenv("MCP_GOPLS_RELATIVE_PATHS") == "1"
+	}
+	if !structured {
+		structured = os.Getenv("MCP_GOPLS_STRUCTURED_CONTENT") == "1"
+	}
+	if tokenBudget == 0 {
+		if value := os.Getenv("MCP_GOPLS_TOKEN_BUDGET"); value != "" {
+			var err error
+			if tokenBudget, err = strconv.Atoi(value); err != nil {
+				log.Fatalf("Invalid MCP_GOPLS_TOKEN_BUDGET: %v", err)
+			}
+		}
+	}
+	if dirFilters == "" {
+		dirFilters = os.Getenv("MCP_GOPLS_DIRECTORY_FILTERS")
+	}
+	if memoryMode == "" {
+		memoryMode = os.Getenv("MCP_GOPLS_MEMORY_MODE")
+	}
+	if maxMemory == "" {
+		maxMemory = os.Getenv("MCP_GOPLS_MAX_GOPLS_MEMORY")
+	}
+	var maxMemoryBytes uint64
+	if maxMemory != "" {
+		var err error
+		if maxMemoryBytes, err = mcpgopls.ParseMemorySize(maxMemory); err != nil {
+			log.Fatalf("Invalid -max-gopls-memory: %v", err)
+		}
+	}
+	if maxFileSize == "" {
+		maxFileSize = os.Getenv("MCP_GOPLS_MAX_FILE_SIZE")
+	}
+	var maxFileBytes int64
+	if maxFileSize != "" {
+		size, err := mcpgopls.ParseMemorySize(maxFileSize)
+		if err != nil {
+			log.Fatalf("Invalid -max-file-size: %v", err)
+		}
+		maxFileBytes = int64(size)
+		if size == 0 {
+			maxFileBytes = -1
+		}
+	}
+	if fileHeader == "" {
+		fileHeader = os.Getenv("MCP_GOPLS_FILE_HEADER")
+	}
+	var headerTemplate string
+	if fileHeader != "" {
+		content, err := os.ReadFile(fileHeader)
+		if err != nil {
+			log.Fatalf("Invalid -file-header: %v", err)
+		}
+		headerTemplate = string(content)
+	}
+	if !markGenerated {
+		markGenerated = os.Getenv("MCP_GOPLS_GENERATED_MARKER") == "1"
+	}
+	if toolNames == "" {
+		toolNames = os.Getenv("MCP_GOPLS_TOOL_NAMES")
+	}
+	if toolPrefix == "" {
+		toolPrefix = os.Getenv("MCP_GOPLS_TOOL_PREFIX")
+	}
+	if toolNameMax == 0 {
+		if value := os.Getenv("MCP_GOPLS_TOOL_NAME_MAX_LENGTH"); value != "" {
+			var err error
+			if toolNameMax, err = strconv.Atoi(value); err != nil {
+				log.Fatalf("Invalid MCP_GOPLS_TOOL_NAME_MAX_LENGTH: %v", err)
+			}
+		}
+	}
+	if toolAliases == "" {
+		toolAliases = os.Getenv("MCP_GOPLS_TOOL_ALIASES")
+	}
+	var aliases map[string]string
+	if toolAliases != "" {
+		content, err := os.ReadFile(toolAliases)
+		if err != nil {
+			log.Fatalf("Invalid -tool-aliases: %v", err)
+		}
+		if err := json.Unmarshal(content, &aliases); err != nil {
+			log.Fatalf("Invalid -tool-aliases %s: %v", toolAliases, err)
+		}
+	}
+	if instructions == "" {
+		instructions = os.Getenv("MCP_GOPLS_INSTRUCTIONS")
+	}
+	var instructionText string
+	if instructions != "" {
+		content, err := os.ReadFile(instructions)
+		if err != nil {
+			log.Fatalf("Invalid -instructions: %v", err)
+		}
+		instructionText = string(content)
+	}
+	if extraInstr == "" {
+		extraInstr = os.Getenv("MCP_GOPLS_EXTRA_INSTRUCTIONS")
+	}
+	var extraText string
+	if extraInstr != "" {
+		content, err := os.ReadFile(extraInstr)
+		if err != nil {
+			log.Fatalf("Invalid -extra-instructions: %v", err)
+		}
+		extraText = string(content)
+	}
+	var filters []string
+	for _, filter := range strings.Split(dirFilters, ",") {
+		if filter = strings.TrimSpace(filter); filter != "" {
+			filters = append(filters, filter)
+		}
+	}
+
+	if idleExit == 0 {
+		if value := os.Getenv("MCP_GOPLS_IDLE_EXIT"); value != "" {
+			var err error
+			if idleExit, err = time.ParseDuration(value); err != nil {
+				log.Fatalf("Invalid MCP_GOPLS_IDLE_EXIT: %v", err)
+			}
+		}
+	}
+	transport, err := selectTransport(fd, listenFD, unixSocket)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Create and start server
-	srv, err := server.New(goplsPath, workspaceRoot)
+	srv, err := mcpgopls.New(
+		mcpgopls.WithGoplsPath(goplsPath),
+		mcpgopls.WithGoplsRemote(remote),
+		mcpgopls.WithMock(mock),
+		mcpgopls.WithLSPRecording(recordLSP),
+		mcpgopls.WithLSPReplay(replayLSP),
+		mcpgopls.WithWorkspace(workspaceRoot),
+		mcpgopls.WithAutoAddWorkspaceFolders(autoAddFolders),
+		mcpgopls.WithIncludeVendor(includeVendor),
+		mcpgopls.WithDirectoryFilters(filters...),
+		mcpgopls.WithMemoryMode(memoryMode),
+		mcpgopls.WithMaxGoplsMemory(maxMemoryBytes, memoryInterval),
+		mcpgopls.WithMaxFileSize(maxFileBytes),
+		mcpgopls.WithFileHeader(headerTemplate),
+		mcpgopls.WithGeneratedMarker(markGenerated),
+		mcpgopls.WithToolTimeout(toolTimeout),
+		mcpgopls.WithToolNaming(toolNames, toolPrefix),
+		mcpgopls.WithMaxToolNameLength(toolNameMax),
+		mcpgopls.WithToolAliases(aliases),
+		mcpgopls.WithToolCallLogging(logToolCalls),
+		mcpgopls.WithTelemetry(telemetryFile),
+		mcpgopls.WithDryRunByDefault(dryRun),
+		mcpgopls.WithRelativePaths(relativePaths),
+		mcpgopls.WithStructuredContent(structured),
+		mcpgopls.WithTokenBudget(tokenBudget),
+		mcpgopls.WithInstructions(instructionText),
+		mcpgopls.WithExtraInstructions(extraText),
+		mcpgopls.WithTransport(transport),
+		mcpgopls.WithIdleExit(idleExit),
+	)
 	if err != nil {
 		log.Fatalf("Failed to create server: %v", err)
 	}
+	defer srv.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	log.Println("Starting mcp-gopls server...")
-	if err := srv.Start(); err != nil {
+	if err := srv.Serve(ctx); err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
 }
+
+// selectTransport returns the transport chosen by the -fd, -listen-fd and
+// -unix flags, defaulting to stdio
+func selectTransport(fd, listenFD int, unixSocket string) (mcpgopls.Transport, error) {
+	var transports []mcpgopls.Transport
+	if fd >= 0 {
+		transports = append(transports, mcpgopls.FileDescriptor(uintptr(fd)))
+	}
+	if listenFD >= 0 {
+		transports = append(transports, mcpgopls.ListenerFileDescriptor(uintptr(listenFD)))
+	}
+	if unixSocket != "" {
+		transports = append(transports, mcpgopls.UnixSocket(unixSocket))
+	}
+	switch len(transports) {
+	case 0:
+		return mcpgopls.Stdio(), nil
+	case 1:
+		return transports[0], nil
+	}
+	return nil, fmt.Errorf("only one of -fd, -listen-fd and -unix can be set")
+}
@@ -0,0 +1,241 @@
+package analysis
+
+import (
+	"context"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+)
+
+// FileStats describes one Go source file
+type FileStats struct {
+	Path      string   `json:"path"`
+	Package   string   `json:"package"`
+	Test      bool     `json:"test,omitempty"`
+	Lines     int      `json:"lines"`
+	CodeLines int      `json:"codeLines"`
+	Imports   []string `json:"-"`
+}
+
+// PackageStats aggregates the files in one directory
+type PackageStats struct {
+	Dir           string  `json:"dir"`
+	ImportPath    string  `json:"importPath,omitempty"`
+	Name          string  `json:"name"`
+	Files         int     `json:"files"`
+	TestFiles     int     `json:"testFiles"`
+	CodeLines     int     `json:"codeLines"`
+	TestCodeLines int     `json:"testCodeLines"`
+	TestRatio     float64 `json:"testRatio"`
+	FanIn         int     `json:"fanIn"`
+	FanOut        int     `json:"fanOut"`
+	// Imports are the workspace packages this package imports, excluding
+	// imports only made by its tests
+	Imports []string `json:"-"`
+}
+
+// Report summarizes the size and shape of the packages under a directory
+type Report struct {
+	Root          string          `json:"root"`
+	PackageCount  int             `json:"packageCount"`
+	FileCount     int             `json:"fileCount"`
+	TestFileCount int             `json:"testFileCount"`
+	CodeLines     int             `json:"codeLines"`
+	TestCodeLines int             `json:"testCodeLines"`
+	Packages      []*PackageStats `json:"packages"`
+	LargestFiles  []*FileStats    `json:"largestFiles"`
+}
+
+// cachedFile is a file's stats together with the file attributes they were
+// computed from
+type cachedFile struct {
+	size    int64
+	modTime time.Time
+	stats   *FileStats
+}
+
+// fileCache keeps per-file stats between reports so that only files changed
+// since the last report are read and scanned again
+var fileCache = struct {
+	sync.Mutex
+	files map[string]cachedFile
+}{files: make(map[string]cachedFile)}
+
+// BuildReport scans the Go files under root. Packages are sorted by code size,
+// largest first, and LargestFiles holds up to maxFiles entries.
+func BuildReport(ctx context.Context, root string, opts astscan.Options, maxFiles int) (*Report, error) {
+	opts.IncludeTests = true
+	paths, err := astscan.Paths(root, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Root: root}
+	byDir := make(map[string]*PackageStats)
+	var files []*FileStats
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		stats, err := statFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if stats == nil {
+			continue
+		}
+		files = append(files, stats)
+
+		dir := filepath.Dir(path)
+		pkg := byDir[dir]
+		if pkg == nil {
+			pkg = &PackageStats{Dir: dir}
+			pkg.ImportPath, _ = codegen.ImportPath(dir)
+			byDir[dir] = pkg
+		}
+		if stats.Test {
+			if pkg.Name == "" {
+				pkg.Name = strings.TrimSuffix(stats.Package, "_test")
+			}
+			pkg.TestFiles++
+			pkg.TestCodeLines += stats.CodeLines
+			report.TestFileCount++
+			report.TestCodeLines += stats.CodeLines
+			continue
+		}
+		pkg.Name = stats.Package
+		pkg.Files++
+		pkg.CodeLines += stats.CodeLines
+		pkg.Imports = append(pkg.Imports, stats.Imports...)
+		report.FileCount++
+		report.CodeLines += stats.CodeLines
+	}
+
+	byPath := make(map[string]*PackageStats)
+	for _, pkg := range byDir {
+		if pkg.ImportPath != "" {
+			byPath[pkg.ImportPath] = pkg
+		}
+	}
+	for _, pkg := range byDir {
+		seen := make(map[string]bool)
+		var imports []string
+		for _, path := range pkg.Imports {
+			if dep := byPath[path]; dep != nil && dep != pkg && !seen[path] {
+				seen[path] = true
+				imports = append(imports, path)
+				dep.FanIn++
+			}
+		}
+		sort.Strings(imports)
+		pkg.Imports = imports
+		pkg.FanOut = len(imports)
+		if pkg.CodeLines > 0 {
+			pkg.TestRatio = float64(pkg.TestCodeLines) / float64(pkg.CodeLines)
+		}
+		report.Packages = append(report.Packages, pkg)
+	}
+	report.PackageCount = len(report.Packages)
+
+	sort.Slice(report.Packages, func(i, j int) bool {
+		a, b := report.Packages[i], report.Packages[j]
+		if a.CodeLines != b.CodeLines {
+			return a.CodeLines > b.CodeLines
+		}
+		return a.Dir < b.Dir
+	})
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].CodeLines != files[j].CodeLines {
+			return files[i].CodeLines > files[j].CodeLines
+		}
+		return files[i].Path < files[j].Path
+	})
+	if maxFiles >= 0 && len(files) > maxFiles {
+		files = files[:maxFiles]
+	}
+	report.LargestFiles = files
+	return report, nil
+}
+
+// statFile returns the stats for path, from the cache when the file has not
+// changed. Files that do not parse are reported as nil.
+func statFile(path string) (*FileStats, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fileCache.Lock()
+	cached, ok := fileCache.files[path]
+	fileCache.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.stats, nil
+	}
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	stats := scanFile(path, src)
+
+	fileCache.Lock()
+	fileCache.files[path] = cachedFile{size: info.Size(), modTime: info.ModTime(), stats: stats}
+	fileCache.Unlock()
+	return stats, nil
+}
+
+// scanFile counts a file's lines and lines holding code, and reads its
+// package clause and imports
+func scanFile(path string, src []byte) *FileStats {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+
+	stats := &FileStats{
+		Path:    path,
+		Package: file.Name.Name,
+		Test:    strings.HasSuffix(path, "_test.go"),
+		Lines:   strings.Count(string(src), "\n"),
+	}
+	if len(src) > 0 && src[len(src)-1] != '\n' {
+		stats.Lines++
+	}
+	for _, imp := range file.Imports {
+		if importPath, err := strconv.Unquote(imp.Path.Value); err == nil {
+			stats.Imports = append(stats.Imports, importPath)
+		}
+	}
+
+	// A line holds code if any token other than a comment starts on it;
+	// semicolons inserted at line ends do not count
+	var s scanner.Scanner
+	tokFile := fset.AddFile(path, -1, len(src))
+	s.Init(tokFile, src, nil, 0)
+	lastLine := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		if line := tokFile.Line(pos); line != lastLine {
+			stats.CodeLines++
+			lastLine = line
+		}
+	}
+	return stats
+}
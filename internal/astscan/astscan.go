@@ -0,0 +1,150 @@
+package astscan
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File is a parsed Go source file found while scanning a directory tree
+type File struct {
+	Path string
+	Fset *token.FileSet
+	AST  *ast.File
+	Src  []byte
+}
+
+// Options controls which files are scanned
+type Options struct {
+	// IncludeTests includes _test.go files
+	IncludeTests bool
+	// IncludeVendor descends into vendor directories
+	IncludeVendor bool
+	// SingleDir scans only the files directly inside root
+	SingleDir bool
+}
+
+// Walk parses every Go file under root and calls fn for each one. Hidden
+// directories, testdata and directories starting with "_" are skipped, as
+// the go tool ignores them. Files that fail to parse are skipped.
+func Walk(root string, opts Options, fn func(*File) error) error {
+	paths, err := Paths(root, opts)
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		file, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		if err := fn(&File{Path: path, Fset: fset, AST: file, Src: src}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Paths returns the Go files under root that Walk would visit, in lexical
+// order, without reading them
+func Paths(root string, opts Options) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (opts.SingleDir || skipDir(name, opts)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.HasSuffix(name, ".go") {
+			return nil
+		}
+		if !opts.IncludeTests && strings.HasSuffix(name, "_test.go") {
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	return paths, err
+}
+
+func skipDir(name string, opts Options) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" {
+		return true
+	}
+	return name == "vendor" && !opts.IncludeVendor
+}
+
+// Position returns the 1-indexed line and column of pos
+func (f *File) Position(pos token.Pos) (line, column int) {
+	p := f.Fset.Position(pos)
+	return p.Line, p.Column
+}
+
+// EnclosingFunc returns the name of the function or method declaration
+// containing pos, formatted as "Func" or "Recv.Method", or "" at package level
+func (f *File) EnclosingFunc(pos token.Pos) string {
+	for _, decl := range f.AST.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || pos < fn.Pos() || pos > fn.End() {
+			continue
+		}
+		return FuncName(fn)
+	}
+	return ""
+}
+
+// FuncName formats a function declaration's name, prefixing methods with
+// their receiver type
+func FuncName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	return ReceiverType(fn.Recv.List[0].Type) + "." + fn.Name.Name
+}
+
+// ReceiverType returns the base type name of a method receiver expression
+func ReceiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return ReceiverType(t.X)
+	case *ast.IndexExpr:
+		return ReceiverType(t.X)
+	case *ast.IndexListExpr:
+		return ReceiverType(t.X)
+	case *ast.Ident:
+		return t.Name
+	default:
+		return ""
+	}
+}
+
+// ImportName returns the local name a file uses for the import path, or ""
+// if the file does not import it
+func (f *File) ImportName(path string) string {
+	for _, imp := range f.AST.Imports {
+		if strings.Trim(imp.Path.Value, `"`) != path {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return path[strings.LastIndex(path, "/")+1:]
+	}
+	return ""
+}
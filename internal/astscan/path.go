@@ -0,0 +1,56 @@
+package astscan
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// PathTo returns the chain of nodes enclosing the identifier at pos
+func PathTo(file *ast.File, pos token.Pos) []ast.Node {
+	var stack, found []ast.Node
+	ast.Inspect(file, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		if pos < n.Pos() || pos >= n.End() {
+			return false
+		}
+		stack = append(stack, n)
+		if ident, ok := n.(*ast.Ident); ok && ident.Pos() == pos {
+			found = append([]ast.Node(nil), stack...)
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// CalleeOf returns the call whose function is the identifier at the end of
+// path, either directly or as the selector of a qualified or method call
+func CalleeOf(path []ast.Node) *ast.CallExpr {
+	node := path[len(path)-1]
+	for i := len(path) - 2; i >= 0; i-- {
+		parent := path[i]
+		switch p := parent.(type) {
+		case *ast.SelectorExpr:
+			if p.Sel != node {
+				return nil
+			}
+		case *ast.IndexExpr, *ast.IndexListExpr, *ast.ParenExpr:
+			// Instantiated generic functions and parenthesised callees
+		case *ast.CallExpr:
+			if p.Fun == node {
+				return p
+			}
+			return nil
+		default:
+			return nil
+		}
+		node = parent
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Package buildtags reads the build constraints of Go files: their
+// //go:build lines and GOOS/GOARCH file name suffixes
+package buildtags
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"strings"
+)
+
+// FileConstraint returns the build constraint of a file's header. As in the
+// go command, a //go:build line takes precedence over // +build lines.
+func FileConstraint(file *ast.File) constraint.Expr {
+	var plus []constraint.Expr
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if constraint.IsGoBuild(comment.Text) {
+				if expr, err := constraint.Parse(comment.Text); err == nil {
+					return expr
+				}
+			}
+			if constraint.IsPlusBuild(comment.Text) {
+				if expr, err := constraint.Parse(comment.Text); err == nil {
+					plus = append(plus, expr)
+				}
+			}
+		}
+	}
+	if len(plus) == 0 {
+		return nil
+	}
+	expr := plus[0]
+	for _, next := range plus[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: next}
+	}
+	return expr
+}
+
+// NameSuffix returns the GOOS and GOARCH a file name restricts the file to,
+// following the *_GOOS, *_GOARCH and *_GOOS_GOARCH forms of go/build
+func NameSuffix(name string) (goos, goarch string) {
+	name = strings.TrimSuffix(name, ".go")
+	name = strings.TrimSuffix(name, "_test")
+	if i := strings.Index(name, "_"); i >= 0 {
+		name = name[i:]
+	} else {
+		return "", ""
+	}
+	parts := strings.Split(name, "_")
+	n := len(parts)
+	if n >= 2 && KnownOS[parts[n-2]] && KnownArch[parts[n-1]] {
+		return parts[n-2], parts[n-1]
+	}
+	if n >= 1 && KnownOS[parts[n-1]] {
+		return parts[n-1], ""
+	}
+	if n >= 1 && KnownArch[parts[n-1]] {
+		return "", parts[n-1]
+	}
+	return "", ""
+}
+
+// KnownOS, UnixOS and KnownArch mirror the lists in go/build, which are not
+// exported
+var KnownOS = setOf("aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios", "js",
+	"linux", "nacl", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows", "zos")
+
+var UnixOS = setOf("aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios",
+	"linux", "netbsd", "openbsd", "solaris")
+
+var KnownArch = setOf("386", "amd64", "amd64p32", "arm", "armbe", "arm64", "arm64be", "loong64",
+	"mips", "mipsle", "mips64", "mips64le", "mips64p32", "mips64p32le", "ppc", "ppc64", "ppc64le",
+	"riscv", "riscv64", "s390", "s390x", "sparc", "sparc64", "wasm")
+
+func setOf(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
@@ -0,0 +1,203 @@
+package codegen
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+)
+
+// Target is a named type declaration that code is generated for
+type Target struct {
+	Pkg  *typecheck.Package
+	Path string
+	File *ast.File
+	Decl *ast.GenDecl
+	Spec *ast.TypeSpec
+	Obj  *types.TypeName
+}
+
+// FindType loads the package containing file and locates the declaration of
+// typeName in it
+func FindType(ctx context.Context, file, typeName string) (*Target, error) {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs, err := typecheck.Load(ctx, filepath.Dir(path), ".")
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) == 0 || pkgs[0].Types == nil {
+		return nil, fmt.Errorf("no package found for %s", path)
+	}
+	pkg := pkgs[0]
+
+	astFile := pkg.File(path)
+	if astFile == nil {
+		return nil, fmt.Errorf("%s is not part of package %s for the current build configuration", path, pkg.ImportPath)
+	}
+
+	for _, decl := range astFile.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if ts.Name.Name != typeName {
+				continue
+			}
+			obj, ok := pkg.Info.Defs[ts.Name].(*types.TypeName)
+			if !ok {
+				return nil, fmt.Errorf("failed to resolve type %s", typeName)
+			}
+			return &Target{Pkg: pkg, Path: path, File: astFile, Decl: gen, Spec: ts, Obj: obj}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("type %s is not declared in %s", typeName, path)
+}
+
+// Struct returns the underlying struct type of the target
+func (t *Target) Struct() (*types.Struct, error) {
+	st, ok := t.Obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct type", t.Obj.Name())
+	}
+	return st, nil
+}
+
+// Interface returns the underlying interface type of the target
+func (t *Target) Interface() (*types.Interface, error) {
+	iface, ok := t.Obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an interface type", t.Obj.Name())
+	}
+	return iface, nil
+}
+
+// Qualifier renders package names as the target file refers to them
+func (t *Target) Qualifier() types.Qualifier {
+	return func(pkg *types.Package) string {
+		if pkg == t.Pkg.Types {
+			return ""
+		}
+		for _, imp := range t.File.Imports {
+			if strings.Trim(imp.Path.Value, `"`) == pkg.Path() && imp.Name != nil {
+				return imp.Name.Name
+			}
+		}
+		return pkg.Name()
+	}
+}
+
+// TypeString renders typ as it would be written in the target file
+func (t *Target) TypeString(typ types.Type) string {
+	return types.TypeString(typ, t.Qualifier())
+}
+
+// TypeParams returns the type parameter list of a generic target for use in
+// declarations ("[K comparable, V any]") and instantiations ("[K, V]"), or
+// empty strings for non-generic types
+func (t *Target) TypeParams() (decl, inst string) {
+	if t.Spec.TypeParams == nil || len(t.Spec.TypeParams.List) == 0 {
+		return "", ""
+	}
+	var decls, names []string
+	for _, field := range t.Spec.TypeParams.List {
+		var fieldNames []string
+		for _, name := range field.Names {
+			fieldNames = append(fieldNames, name.Name)
+		}
+		decls = append(decls, strings.Join(fieldNames, ", ")+" "+types.ExprString(field.Type))
+		names = append(names, fieldNames...)
+	}
+	return "[" + strings.Join(decls, ", ") + "]", "[" + strings.Join(names, ", ") + "]"
+}
+
+// Exists reports whether name is already declared at package level
+func (t *Target) Exists(name string) bool {
+	return t.Pkg.Types.Scope().Lookup(name) != nil
+}
+
+// HasMethod reports whether the target type already has a method called name
+func (t *Target) HasMethod(name string) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t.Obj.Type()), true, t.Pkg.Types, name)
+	_, isFunc := obj.(*types.Func)
+	return isFunc
+}
+
+// ReceiverName returns the receiver name used by the target's existing
+// methods, falling back to the lowercased first letter of the type name
+func (t *Target) ReceiverName() string {
+	for _, file := range t.Pkg.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 || len(fn.Recv.List[0].Names) == 0 {
+				continue
+			}
+			if astscan.ReceiverType(fn.Recv.List[0].Type) == t.Obj.Name() && fn.Recv.List[0].Names[0].Name != "_" {
+				return fn.Recv.List[0].Names[0].Name
+			}
+		}
+	}
+	return strings.ToLower(t.Obj.Name()[:1])
+}
+
+// InsertAfterDecl inserts code after the target's declaration and formats
+// the resulting file
+func (t *Target) InsertAfterDecl(code string) error {
+	end := t.Pkg.Fset.Position(t.Decl.End())
+	// Insert at the start of the line after the declaration
+	pos := lsp.Position{Line: end.Line, Character: 0}
+	edit := lsp.TextEdit{
+		Range:   lsp.Range{Start: pos, End: pos},
+		NewText: "\n" + strings.TrimRight(code, "\n") + "\n",
+	}
+
+	content, err := os.ReadFile(t.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if !strings.HasSuffix(string(content), "\n") {
+		content = append(content, '\n')
+	}
+
+	updated, err := edits.Apply(string(content), []lsp.TextEdit{edit})
+	if err != nil {
+		return err
+	}
+	return writeFormatted(t.Path, []byte(updated))
+}
+
+// WriteFile formats src as Go code and writes it to path, stamping the
+// header of stamper on new files
+func WriteFile(stamper *Stamper, path string, src []byte) error {
+	return writeFormatted(path, stamper.Stamp(path, src))
+}
+
+// writeFormatted gofmts src before writing it, writing it unformatted with
+// an error if it does not parse so the problem can be inspected. An existing
+// file keeps its permissions, line endings and byte order mark.
+func writeFormatted(path string, src []byte) error {
+	formatted, err := format.Source(src)
+	if err != nil {
+		if writeErr := edits.WriteFiles(map[string][]byte{path: src}); writeErr != nil {
+			return writeErr
+		}
+		return fmt.Errorf("generated code in %s does not parse: %w", path, err)
+	}
+	return edits.WriteFiles(map[string][]byte{path: formatted})
+}
@@ -0,0 +1,43 @@
+package codegen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStampersAreIndependent(t *testing.T) {
+	apache, err := NewStamper(Header{Template: "Copyright {{.Year}} Apache"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	marker, err := NewStamper(Header{Marker: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	none, err := NewStamper(Header{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if none != nil {
+		t.Errorf("NewStamper of the zero Header = %v, want nil", none)
+	}
+
+	path := filepath.Join(t.TempDir(), "new.go")
+	src := []byte("package p\n")
+	if got := string(apache.Stamp(path, src)); !strings.HasPrefix(got, "// Copyright ") || strings.Contains(got, GeneratedMarker) {
+		t.Errorf("template stamper gave:\n%s", got)
+	}
+	if got := string(marker.Stamp(path, src)); !strings.HasPrefix(got, GeneratedMarker+"\n\n") {
+		t.Errorf("marker stamper gave:\n%s", got)
+	}
+	if got := string(none.Stamp(path, src)); got != string(src) {
+		t.Errorf("nil stamper gave:\n%s", got)
+	}
+}
+
+func TestNewStamperRejectsInvalidTemplate(t *testing.T) {
+	if _, err := NewStamper(Header{Template: "{{.Missing}}"}); err == nil {
+		t.Error("NewStamper accepted a template referring to an unknown field")
+	}
+}
@@ -0,0 +1,314 @@
+package codegen
+
+import (
+	"bufio"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Param is a parameter or result rendered for generated code
+type Param struct {
+	Name string
+	Type string
+}
+
+// Method is an interface method prepared for generated code
+type Method struct {
+	Name     string
+	Params   []Param
+	Results  []Param
+	Variadic bool
+	Exported bool
+}
+
+// InterfaceMethods enumerates every method of iface, including those of
+// embedded interfaces, in name order. Types are rendered with qualifier and
+// unnamed or clashing parameters are given placeholder names; reserved
+// names (such as a receiver) are never used for parameters.
+func InterfaceMethods(iface *types.Interface, qualifier types.Qualifier, reserved ...string) []Method {
+	methods := make([]Method, 0, iface.NumMethods())
+	for i := 0; i < iface.NumMethods(); i++ {
+		fn := iface.Method(i)
+		sig := fn.Type().(*types.Signature)
+
+		taken := make(map[string]bool)
+		for _, name := range reserved {
+			taken[name] = true
+		}
+
+		method := Method{Name: fn.Name(), Variadic: sig.Variadic(), Exported: fn.Exported()}
+		for j := 0; j < sig.Params().Len(); j++ {
+			v := sig.Params().At(j)
+			typ := types.TypeString(v.Type(), qualifier)
+			if method.Variadic && j == sig.Params().Len()-1 {
+				typ = "..." + types.TypeString(v.Type().(*types.Slice).Elem(), qualifier)
+			}
+			method.Params = append(method.Params, Param{Name: paramName(v.Name(), j, taken), Type: typ})
+		}
+		for j := 0; j < sig.Results().Len(); j++ {
+			method.Results = append(method.Results, Param{Type: types.TypeString(sig.Results().At(j).Type(), qualifier)})
+		}
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	return methods
+}
+
+// paramName returns name unless it is blank or already taken, in which case
+// a positional placeholder is used
+func paramName(name string, i int, taken map[string]bool) string {
+	if name == "" || name == "_" || taken[name] {
+		name = fmt.Sprintf("p%d", i)
+		for taken[name] {
+			name += "_"
+		}
+	}
+	taken[name] = true
+	return name
+}
+
+// Signature renders the parameter and result lists, as in
+// "(ctx context.Context, ids ...string) (int, error)"
+func (m Method) Signature() string {
+	params := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		params[i] = p.Name + " " + p.Type
+	}
+	sig := "(" + strings.Join(params, ", ") + ")"
+
+	switch len(m.Results) {
+	case 0:
+	case 1:
+		sig += " " + m.Results[0].Type
+	default:
+		results := make([]string, len(m.Results))
+		for i, r := range m.Results {
+			results[i] = r.Type
+		}
+		sig += " (" + strings.Join(results, ", ") + ")"
+	}
+	return sig
+}
+
+// Args renders the parameters as call arguments, spreading a variadic one
+func (m Method) Args() string {
+	args := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		args[i] = p.Name
+	}
+	if m.Variadic && len(args) > 0 {
+		args[len(args)-1] += "..."
+	}
+	return strings.Join(args, ", ")
+}
+
+// TypeParamList renders the type parameters of a generic named type for use
+// in declarations ("[K comparable, V any]") and instantiations ("[K, V]"),
+// or returns empty strings for non-generic types
+func TypeParamList(named *types.Named, qualifier types.Qualifier) (decl, inst string) {
+	tparams := named.TypeParams()
+	if tparams.Len() == 0 {
+		return "", ""
+	}
+	decls := make([]string, tparams.Len())
+	names := make([]string, tparams.Len())
+	for i := 0; i < tparams.Len(); i++ {
+		tp := tparams.At(i)
+		names[i] = tp.Obj().Name()
+		decls[i] = names[i] + " " + types.TypeString(tp.Constraint(), qualifier)
+	}
+	return "[" + strings.Join(decls, ", ") + "]", "[" + strings.Join(names, ", ") + "]"
+}
+
+// Imports collects the packages referenced by code generated into the
+// package with import path pkgPath, giving each a unique name
+type Imports struct {
+	pkgPath string
+	names   map[string]string
+	taken   map[string]bool
+}
+
+func NewImports(pkgPath string) *Imports {
+	return &Imports{
+		pkgPath: pkgPath,
+		names:   make(map[string]string),
+		taken:   make(map[string]bool),
+	}
+}
+
+// Qualifier returns the name code should use to refer to pkg, recording the
+// import
+func (im *Imports) Qualifier(pkg *types.Package) string {
+	if pkg.Path() == im.pkgPath {
+		return ""
+	}
+	return im.Add(pkg.Path(), pkg.Name())
+}
+
+// Add records an import of importPath, preferring name, and returns the name
+// it was assigned
+func (im *Imports) Add(importPath, name string) string {
+	if existing, ok := im.names[importPath]; ok {
+		return existing
+	}
+	unique := name
+	for i := 2; im.taken[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	im.names[importPath] = unique
+	im.taken[unique] = true
+	return unique
+}
+
+// Names returns the recorded imports, mapping each import path to the name
+// it was assigned
+func (im *Imports) Names() map[string]string {
+	names := make(map[string]string, len(im.names))
+	for importPath, name := range im.names {
+		names[importPath] = name
+	}
+	return names
+}
+
+// Decl renders the import declaration, or an empty string when nothing is
+// imported
+func (im *Imports) Decl() string {
+	if len(im.names) == 0 {
+		return ""
+	}
+	paths := make([]string, 0, len(im.names))
+	for importPath := range im.names {
+		paths = append(paths, importPath)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, importPath := range paths {
+		name := im.names[importPath]
+		if name == path.Base(importPath) {
+			fmt.Fprintf(&b, "\t%q\n", importPath)
+		} else {
+			fmt.Fprintf(&b, "\t%s %q\n", name, importPath)
+		}
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// ImportPath derives the import path of the package in dir from the module
+// path in the nearest go.mod, so it works for directories with no Go files yet
+func ImportPath(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for root := dir; ; root = filepath.Dir(root) {
+		modulePath, err := readModulePath(filepath.Join(root, "go.mod"))
+		if err == nil {
+			rel, err := filepath.Rel(root, dir)
+			if err != nil {
+				return "", err
+			}
+			if rel == "." {
+				return modulePath, nil
+			}
+			return modulePath + "/" + filepath.ToSlash(rel), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if filepath.Dir(root) == root {
+			return "", fmt.Errorf("%s is not inside a Go module", dir)
+		}
+	}
+}
+
+// readModulePath returns the module path declared in a go.mod file
+func readModulePath(gomod string) (string, error) {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(rest), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no module directive in %s", gomod)
+}
+
+// PackageName returns the package name declared by the Go files in dir,
+// falling back to fallback when there are none
+func PackageName(dir, fallback string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fallback
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.PackageClauseOnly)
+		if err == nil {
+			return file.Name.Name
+		}
+	}
+	return fallback
+}
+
+// OutputPackage prepares dir to receive generated code for target, creating
+// it if needed, and returns the import path and name of the package there
+func OutputPackage(dir string, target *Target) (pkgPath, pkgName string, err error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	pkgPath, err = ImportPath(dir)
+	if err != nil {
+		return "", "", err
+	}
+	if pkgPath == target.Pkg.ImportPath {
+		return pkgPath, target.Pkg.Name, nil
+	}
+	return pkgPath, PackageName(dir, SanitizePackageName(filepath.Base(dir))), nil
+}
+
+// SanitizePackageName turns a directory name into a valid package name
+func SanitizePackageName(name string) string {
+	name = strings.ToLower(strings.NewReplacer("-", "", ".", "", " ", "").Replace(name))
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "pkg" + name
+	}
+	return name
+}
+
+// NewFile assembles a Go source file from an optional leading comment, the
+// package clause, the collected imports and body
+func NewFile(header, pkgName string, imports *Imports, body string) []byte {
+	var b strings.Builder
+	if header != "" {
+		b.WriteString(header + "\n\n")
+	}
+	fmt.Fprintf(&b, "package %s\n\n", pkgName)
+	if decl := imports.Decl(); decl != "" {
+		b.WriteString(decl + "\n")
+	}
+	b.WriteString(body)
+	return []byte(b.String())
+}
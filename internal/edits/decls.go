@@ -0,0 +1,366 @@
+package edits
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// Decls are top-level declarations selected by name from one parsed file,
+// to move them to another file or delete them
+type Decls struct {
+	fset    *token.FileSet
+	file    *ast.File
+	content []byte
+
+	// decls are the whole declarations selected, and specs the individual
+	// specs selected from grouped declarations that are otherwise kept
+	decls []ast.Decl
+	specs map[*ast.GenDecl][]ast.Spec
+
+	// Selected names the selected declarations, with the names of a spec
+	// declaring several joined by commas
+	Selected []string
+}
+
+// SelectDecls resolves names to the top-level declarations of a parsed
+// file, along with the methods of selected types when includeMethods is set.
+// Methods are named Type.Method. Grouped declarations whose specs are only
+// partly selected keep the rest.
+func SelectDecls(fset *token.FileSet, file *ast.File, content []byte, symbols []string, includeMethods bool) (*Decls, error) {
+	s := &Decls{fset: fset, file: file, content: content}
+	wanted := make(map[string]bool)
+	for _, name := range symbols {
+		wanted[name] = true
+	}
+	found := make(map[string]bool)
+	movedTypes := make(map[string]bool)
+	s.specs = make(map[*ast.GenDecl][]ast.Spec)
+
+	for _, decl := range s.file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok == token.IMPORT {
+			continue
+		}
+		var selected []ast.Spec
+		for _, spec := range gen.Specs {
+			names := specNames(spec)
+			match := false
+			for _, name := range names {
+				if wanted[name] {
+					match = true
+					found[name] = true
+				}
+			}
+			if !match {
+				continue
+			}
+			selected = append(selected, spec)
+			if ts, ok := spec.(*ast.TypeSpec); ok {
+				movedTypes[ts.Name.Name] = true
+			}
+			s.Selected = append(s.Selected, strings.Join(names, ", "))
+		}
+		if len(selected) == len(gen.Specs) {
+			s.decls = append(s.decls, gen)
+		} else if len(selected) > 0 {
+			s.specs[gen] = selected
+		}
+	}
+
+	for _, decl := range s.file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		name := astscan.FuncName(fn)
+		recv := ""
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			recv = astscan.ReceiverType(fn.Recv.List[0].Type)
+		}
+		if wanted[name] || (includeMethods && movedTypes[recv]) {
+			found[name] = true
+			s.decls = append(s.decls, fn)
+			s.Selected = append(s.Selected, name)
+		}
+	}
+
+	var missing []string
+	for name := range wanted {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("no top-level declaration named %s in %s", strings.Join(missing, ", "), s.fset.Position(s.file.Pos()).Filename)
+	}
+	return s, nil
+}
+
+func specNames(spec ast.Spec) []string {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		return []string{spec.Name.Name}
+	case *ast.ValueSpec:
+		names := make([]string, len(spec.Names))
+		for i, name := range spec.Names {
+			names[i] = name.Name
+		}
+		return names
+	}
+	return nil
+}
+
+// span returns the byte range of node including its doc comment
+func (s *Decls) span(node ast.Node, doc *ast.CommentGroup) (int, int) {
+	start := node.Pos()
+	if doc != nil {
+		start = doc.Pos()
+	}
+	end := node.End()
+	// Keep a trailing comment on the last line with the declaration
+	for _, group := range s.file.Comments {
+		if group.Pos() >= end && s.fset.Position(group.Pos()).Line == s.fset.Position(end).Line {
+			end = group.End()
+		}
+	}
+	return s.fset.Position(start).Offset, s.fset.Position(end).Offset
+}
+
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	}
+	return nil
+}
+
+func specDoc(spec ast.Spec) *ast.CommentGroup {
+	switch sp := spec.(type) {
+	case *ast.TypeSpec:
+		return sp.Doc
+	case *ast.ValueSpec:
+		return sp.Doc
+	}
+	return nil
+}
+
+// Source returns the source of the selected declarations in file order,
+// with the specs selected from a group wrapped in a group of their own
+func (s *Decls) Source() []string {
+	type piece struct {
+		start int
+		text  string
+	}
+	var pieces []piece
+	for _, decl := range s.decls {
+		start, end := s.span(decl, declDoc(decl))
+		pieces = append(pieces, piece{start, string(s.content[start:end])})
+	}
+	for gen, specs := range s.specs {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s (\n", gen.Tok)
+		for _, spec := range specs {
+			start, end := s.span(spec, specDoc(spec))
+			b.WriteString("\t" + string(s.content[start:end]) + "\n")
+		}
+		b.WriteString(")")
+		start, _ := s.span(specs[0], specDoc(specs[0]))
+		pieces = append(pieces, piece{start, b.String()})
+	}
+	sort.Slice(pieces, func(i, j int) bool { return pieces[i].start < pieces[j].start })
+
+	texts := make([]string, len(pieces))
+	for i, p := range pieces {
+		texts[i] = p.text
+	}
+	return texts
+}
+
+// Imports returns the source of the imports the selected declarations use,
+// one per import spec with its doc and trailing comments
+func (s *Decls) Imports() []string {
+	used := s.usedImports(s.nodes())
+	var imports []string
+	for _, imp := range s.file.Imports {
+		if used[imp] {
+			start, end := s.span(imp, imp.Doc)
+			imports = append(imports, string(s.content[start:end]))
+		}
+	}
+	return imports
+}
+
+// usedImports reports which imports are referenced from nodes. Dot imports
+// are assumed to be used; blank imports are only needed by the original file.
+func (s *Decls) usedImports(nodes []ast.Node) map[*ast.ImportSpec]bool {
+	byName := make(map[string]*ast.ImportSpec)
+	used := make(map[*ast.ImportSpec]bool)
+	for _, imp := range s.file.Imports {
+		path := strings.Trim(imp.Path.Value, `"`)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		switch name {
+		case "_":
+		case ".":
+			used[imp] = true
+		default:
+			byName[name] = imp
+		}
+	}
+	for _, node := range nodes {
+		ast.Inspect(node, func(n ast.Node) bool {
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			// Package qualifiers are the only unresolved selector operands
+			if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil {
+				if imp := byName[x.Name]; imp != nil {
+					used[imp] = true
+				}
+			}
+			return true
+		})
+	}
+	return used
+}
+
+// RemovalEdits delete the selected declarations from the file along with
+// the imports only they used
+func (s *Decls) RemovalEdits() []lsp.TextEdit {
+	var result []lsp.TextEdit
+	remove := func(node ast.Node, doc *ast.CommentGroup) {
+		start, end := s.span(node, doc)
+		// Take the rest of the line so no empty line is left behind
+		for end < len(s.content) && s.content[end] != '\n' {
+			end++
+		}
+		if end < len(s.content) {
+			end++
+		}
+		from, _ := utils.OffsetToPosition(string(s.content), start)
+		to, _ := utils.OffsetToPosition(string(s.content), end)
+		result = append(result, lsp.TextEdit{Range: lsp.Range{Start: from, End: to}})
+	}
+
+	removed := make(map[ast.Node]bool)
+	for _, decl := range s.decls {
+		remove(decl, declDoc(decl))
+		removed[decl] = true
+	}
+	for _, specs := range s.specs {
+		for _, spec := range specs {
+			remove(spec, specDoc(spec))
+			removed[spec] = true
+		}
+	}
+
+	// Work out which imports the remaining code still needs
+	var remaining []ast.Node
+	for _, decl := range s.file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok {
+			if gen.Tok == token.IMPORT || removed[gen] {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				if !removed[spec] {
+					remaining = append(remaining, spec)
+				}
+			}
+			continue
+		}
+		if !removed[decl] {
+			remaining = append(remaining, decl)
+		}
+	}
+	stillUsed := s.usedImports(remaining)
+	movedUses := s.usedImports(s.nodes())
+	for _, imp := range s.file.Imports {
+		if movedUses[imp] && !stillUsed[imp] && (imp.Name == nil || imp.Name.Name != ".") {
+			if edit, ok := RemoveImport(s.fset, s.file, strings.Trim(imp.Path.Value, `"`)); ok {
+				result = append(result, edit)
+			}
+		}
+	}
+	return result
+}
+
+// nodes lists every selected declaration and spec
+func (s *Decls) nodes() []ast.Node {
+	var nodes []ast.Node
+	for _, decl := range s.decls {
+		nodes = append(nodes, decl)
+	}
+	for _, specs := range s.specs {
+		for _, spec := range specs {
+			nodes = append(nodes, spec)
+		}
+	}
+	return nodes
+}
+
+// Deletion describes removing top-level declarations from a file
+type Deletion struct {
+	// Edits delete the declarations and the imports only they used
+	Edits []lsp.TextEdit
+	// Removed names the deleted declarations
+	Removed []string
+	// Names are the identifiers the deleted declarations declare
+	Names []*ast.Ident
+	// Spans are the byte ranges of the deleted declarations, doc comments
+	// included
+	Spans [][2]int
+}
+
+// Delete works out how to remove the named top-level declarations from a
+// parsed file, selected as SelectDecls does
+func Delete(fset *token.FileSet, file *ast.File, content []byte, symbols []string, includeMethods bool) (*Deletion, error) {
+	s, err := SelectDecls(fset, file, content, symbols, includeMethods)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Deletion{Edits: s.RemovalEdits(), Removed: s.Selected}
+	for _, decl := range s.decls {
+		start, end := s.span(decl, declDoc(decl))
+		d.Spans = append(d.Spans, [2]int{start, end})
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			d.Names = append(d.Names, decl.Name)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				d.Names = append(d.Names, specIdents(spec)...)
+			}
+		}
+	}
+	for _, specs := range s.specs {
+		for _, spec := range specs {
+			start, end := s.span(spec, specDoc(spec))
+			d.Spans = append(d.Spans, [2]int{start, end})
+			d.Names = append(d.Names, specIdents(spec)...)
+		}
+	}
+	return d, nil
+}
+
+func specIdents(spec ast.Spec) []*ast.Ident {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		return []*ast.Ident{spec.Name}
+	case *ast.ValueSpec:
+		return spec.Names
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package edits
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const declsSource = `package p
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Greeting is shown first
+const (
+	Greeting = "hello"
+	Farewell = "bye"
+)
+
+// Shout greets loudly
+func Shout() string { return strings.ToUpper(Greeting) }
+
+func Print() { fmt.Println(Farewell) }
+`
+
+func TestDelete(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", declsSource, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := Delete(fset, file, []byte(declsSource), []string{"Shout", "Greeting"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Greeting", "Shout"}; !reflect.DeepEqual(d.Removed, want) {
+		t.Errorf("Removed = %v, want %v", d.Removed, want)
+	}
+	updated, err := Apply(declsSource, d.Edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, gone := range []string{"Shout", `Greeting = "hello"`, `"strings"`} {
+		if strings.Contains(updated, gone) {
+			t.Errorf("deleting left %s behind:\n%s", gone, updated)
+		}
+	}
+	for _, kept := range []string{`Farewell = "bye"`, `"fmt"`, "func Print"} {
+		if !strings.Contains(updated, kept) {
+			t.Errorf("deleting removed %s:\n%s", kept, updated)
+		}
+	}
+}
+
+func TestSelectDecls(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", declsSource, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := SelectDecls(fset, file, []byte(declsSource), []string{"Shout"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Imports(), []string{`"strings"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Imports() = %q, want %q", got, want)
+	}
+	if got := s.Source(); len(got) != 1 || !strings.HasPrefix(got[0], "// Shout greets loudly\nfunc Shout()") {
+		t.Errorf("Source() = %q", got)
+	}
+	if _, err := SelectDecls(fset, file, []byte(declsSource), []string{"Missing"}, false); err == nil {
+		t.Error("SelectDecls accepted a name the file does not declare")
+	}
+}
@@ -0,0 +1,193 @@
+package edits
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// lineOp is one line of an edit script: kept, deleted from before or
+// inserted from after
+type lineOp struct {
+	kind byte
+	text string
+}
+
+// Label returns the name of path in the headers of a diff: its path
+// relative to root with forward slashes, like git's, or path itself when it
+// is outside root
+func Label(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Unified returns a unified diff between before and after labelled with
+// path, or an empty string when they are identical. after is given before's
+// line endings and byte order mark first, as writing it would.
+func Unified(path, before, after string) string {
+	after = string(Preserve([]byte(before), []byte(after)))
+	if before == after {
+		return ""
+	}
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+
+	// Walk the script, emitting a hunk for each run of changes together with
+	// the surrounding context
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		start := i
+		for start > 0 && i-start < diffContext && ops[start-1].kind == ' ' {
+			start--
+		}
+		oldStart, newStart := oldLine-(i-start), newLine-(i-start)
+
+		// Extend the hunk until diffContext*2 unchanged lines separate it
+		// from the next change
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > diffContext*2 {
+				end += min(run-end, diffContext)
+				break
+			}
+			end = run
+		}
+
+		var oldCount, newCount int
+		var body strings.Builder
+		for _, op := range ops[start:end] {
+			body.WriteByte(op.kind)
+			body.WriteString(op.text)
+			body.WriteByte('\n')
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n%s", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount), body.String())
+
+		for _, op := range ops[i:end] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+	return b.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range refers to the line before the change
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines computes a shortest edit script between two sets of lines using
+// Myers' algorithm
+func diffLines(a, b []string) []lineOp {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Backtrack through the recorded frontiers to recover the script
+	var ops []lineOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, lineOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, lineOp{'+', b[y-1]})
+			y--
+		} else {
+			ops = append(ops, lineOp{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, lineOp{' ', a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,215 @@
+package edits
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// Apply applies LSP text edits to text. Edits are applied from the end of the
+// document to the beginning so earlier offsets stay valid.
+func Apply(text string, edits []lsp.TextEdit) (string, error) {
+	sorted := make([]lsp.TextEdit, len(edits))
+	copy(sorted, edits)
+	if strings.HasPrefix(text, bom) {
+		// gopls counts a byte order mark as one character of the first line,
+		// but it takes three bytes
+		for i := range sorted {
+			sorted[i].Range.Start = skipBOM(sorted[i].Range.Start)
+			sorted[i].Range.End = skipBOM(sorted[i].Range.End)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Range.Start, sorted[j].Range.Start
+		if a.Line != b.Line {
+			return a.Line > b.Line
+		}
+		if a.Character != b.Character {
+			return a.Character > b.Character
+		}
+		// At the same start, apply replacements before insertions so an
+		// insertion is not swallowed by the range that follows it
+		c, d := sorted[i].Range.End, sorted[j].Range.End
+		if c.Line != d.Line {
+			return c.Line > d.Line
+		}
+		return c.Character > d.Character
+	})
+
+	for _, edit := range sorted {
+		startOffset, err := utils.CalculateOffset(text, edit.Range.Start)
+		if err != nil {
+			return "", fmt.Errorf("failed to calculate start offset: %w", err)
+		}
+
+		endOffset, err := utils.CalculateOffset(text, edit.Range.End)
+		if err != nil {
+			return "", fmt.Errorf("failed to calculate end offset: %w", err)
+		}
+
+		if endOffset < startOffset {
+			return "", fmt.Errorf("invalid edit range: end %d:%d is before start %d:%d",
+				edit.Range.End.Line, edit.Range.End.Character, edit.Range.Start.Line, edit.Range.Start.Character)
+		}
+
+		text = text[:startOffset] + edit.NewText + text[endOffset:]
+	}
+
+	return text, nil
+}
+
+func skipBOM(pos lsp.Position) lsp.Position {
+	if pos.Line == 0 && pos.Character > 0 {
+		pos.Character += len(bom) - 1
+	}
+	return pos
+}
+
+// ApplyToFile applies LSP text edits to the file at path, keeping its line
+// endings and byte order mark
+func ApplyToFile(path string, edits []lsp.TextEdit) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	text, err := Apply(string(content), edits)
+	if err != nil {
+		return err
+	}
+
+	return WriteFiles(map[string][]byte{path: []byte(text)})
+}
+
+// FileEdits returns the edits in a workspace edit grouped by file path,
+// accepting both the changes and documentChanges forms. toPath converts the
+// URIs, such as Manager.URIToPath, which gives the paths the user knows.
+func FileEdits(edit *lsp.WorkspaceEdit, toPath func(uri string) (string, error)) (map[string][]lsp.TextEdit, error) {
+	result := make(map[string][]lsp.TextEdit)
+	if edit == nil {
+		return result, nil
+	}
+
+	if len(edit.DocumentChanges) > 0 {
+		for _, docEdit := range edit.DocumentChanges {
+			path, err := toPath(docEdit.TextDocument.URI)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse URI %s: %w", docEdit.TextDocument.URI, err)
+			}
+			result[path] = append(result[path], docEdit.Edits...)
+		}
+		return result, nil
+	}
+
+	for uri, fileEdits := range edit.Changes {
+		path, err := toPath(uri)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse URI %s: %w", uri, err)
+		}
+		result[path] = append(result[path], fileEdits...)
+	}
+	return result, nil
+}
+
+// WriteFiles writes several files as one change: every file is first written
+// to a temporary file beside it, and the originals are only replaced once all
+// of them were written successfully. If replacing one fails, the files
+// already replaced get their original content back. Files that already exist
+// keep their permissions, line endings and byte order mark (see Preserve),
+// and a symbolic link is followed so its target is written and the link
+// stays. Nothing is written if any file is read-only; the error is a
+// *PermissionDeniedError.
+func WriteFiles(files map[string][]byte) error {
+	for path := range files {
+		if err := CheckWritable(path); err != nil {
+			return err
+		}
+	}
+
+	temps := make(map[string]string, len(files))
+	// originals holds the content of the files that existed, by resolved path
+	originals := make(map[string][]byte, len(files))
+	cleanup := func() {
+		for _, temp := range temps {
+			os.Remove(temp)
+		}
+	}
+
+	for path, content := range files {
+		target := resolveLink(path)
+		mode := os.FileMode(0644)
+		if info, err := os.Stat(target); err == nil {
+			mode = info.Mode().Perm()
+		}
+		temp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp*")
+		if err != nil {
+			cleanup()
+			if errors.Is(err, fs.ErrPermission) {
+				return &PermissionDeniedError{Path: path, Reason: "its directory is not writable", Err: err}
+			}
+			return fmt.Errorf("failed to create temporary file for %s: %w", path, err)
+		}
+		temps[target] = temp.Name()
+		if original, readErr := os.ReadFile(target); readErr == nil {
+			originals[target] = original
+			content = Preserve(original, content)
+		}
+		_, err = temp.Write(content)
+		if closeErr := temp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(temp.Name(), mode)
+		}
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	var replaced []string
+	for target, temp := range temps {
+		if err := os.Rename(temp, target); err != nil {
+			cleanup()
+			restore(replaced, originals)
+			return fmt.Errorf("failed to replace %s: %w", target, err)
+		}
+		delete(temps, target)
+		replaced = append(replaced, target)
+	}
+	return nil
+}
+
+// resolveLink returns path with symbolic links resolved, so writing to it
+// changes the file a link points to rather than replacing the link. A path
+// that does not exist yet is returned as is.
+func resolveLink(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// restore undoes the replacement of files by WriteFiles, putting back the
+// original content of the files that existed and removing the others
+func restore(replaced []string, originals map[string][]byte) {
+	for _, path := range replaced {
+		original, existed := originals[path]
+		if !existed {
+			os.Remove(path)
+			continue
+		}
+		mode := os.FileMode(0644)
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		os.WriteFile(path, original, mode)
+	}
+}
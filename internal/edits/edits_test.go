@@ -0,0 +1,73 @@
+package edits
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFilesFollowsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real", "a.go")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "a.go")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symbolic links not supported: %v", err)
+	}
+
+	if err := WriteFiles(map[string][]byte{link: []byte("package b\n")}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("%s is no longer a symbolic link", link)
+	}
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "package b\n" {
+		t.Errorf("target content = %q, want %q", content, "package b\n")
+	}
+}
+
+func TestWriteFilesCreatesNewFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new.go")
+	if err := WriteFiles(map[string][]byte{path: []byte("package a\n")}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "package a\n" {
+		t.Errorf("content = %q, want %q", content, "package a\n")
+	}
+}
+
+func TestLabel(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator)+"ws", "app")
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(root, "pkg", "a.go"), "pkg/a.go"},
+		{filepath.Join(root, "a.go"), "a.go"},
+		{filepath.Join(root, "..", "other", "b.go"), filepath.Join(string(filepath.Separator)+"ws", "other", "b.go")},
+		{filepath.Join(root, "..foo", "c.go"), "..foo/c.go"},
+	}
+	for _, tt := range tests {
+		if got := Label(root, tt.path); got != tt.want {
+			t.Errorf("Label(%q, %q) = %q, want %q", root, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package edits
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+)
+
+// ImportName returns the name file uses for the package at path, or an
+// empty string when it is not imported by name
+func ImportName(file *ast.File, path string) string {
+	for _, imp := range file.Imports {
+		if strings.Trim(imp.Path.Value, `"`) != path {
+			continue
+		}
+		if imp.Name == nil {
+			return filepath.Base(path)
+		}
+		if imp.Name.Name != "_" && imp.Name.Name != "." {
+			return imp.Name.Name
+		}
+	}
+	return ""
+}
+
+// AddImport returns an edit importing path, placed in sorted order within
+// the file's first import block. A file with only single-line import
+// declarations has the first of them turned into a block, leaving import
+// "C" alone since cgo needs it on its own; a file without imports gets one
+// after the package clause.
+func AddImport(fset *token.FileSet, file *ast.File, path string) lsp.TextEdit {
+	insertAt := func(pos token.Pos, text string) lsp.TextEdit {
+		line := fset.Position(pos).Line
+		p := lsp.Position{Line: line - 1, Character: 0}
+		return lsp.TextEdit{Range: lsp.Range{Start: p, End: p}, NewText: text}
+	}
+
+	var single, last *ast.GenDecl
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		last = gen
+		if !gen.Lparen.IsValid() {
+			if spec, ok := gen.Specs[0].(*ast.ImportSpec); ok && single == nil && spec.Path.Value != `"C"` {
+				single = gen
+			}
+			continue
+		}
+
+		specs := make([]*ast.ImportSpec, 0, len(gen.Specs))
+		for _, spec := range gen.Specs {
+			specs = append(specs, spec.(*ast.ImportSpec))
+		}
+		sort.SliceStable(specs, func(i, j int) bool { return specs[i].Pos() < specs[j].Pos() })
+		for _, spec := range specs {
+			if strings.Trim(spec.Path.Value, `"`) > path {
+				return insertAt(spec.Pos(), "\t"+strconv.Quote(path)+"\n")
+			}
+		}
+		return insertAt(gen.Rparen, "\t"+strconv.Quote(path)+"\n")
+	}
+
+	if single != nil {
+		return groupImport(fset, single, path)
+	}
+	if last != nil {
+		// Only import "C", whose preamble must stay right above it
+		end := fset.Position(last.End())
+		p := lsp.Position{Line: end.Line, Character: 0}
+		return lsp.TextEdit{Range: lsp.Range{Start: p, End: p}, NewText: "import " + strconv.Quote(path) + "\n"}
+	}
+
+	end := fset.Position(file.Name.End())
+	p := lsp.Position{Line: end.Line, Character: 0}
+	return lsp.TextEdit{Range: lsp.Range{Start: p, End: p}, NewText: "\nimport " + strconv.Quote(path) + "\n"}
+}
+
+// groupImport returns an edit replacing the single-line import declaration
+// gen with an import block holding its spec and path, in sorted order
+func groupImport(fset *token.FileSet, gen *ast.GenDecl, path string) lsp.TextEdit {
+	spec := gen.Specs[0].(*ast.ImportSpec)
+	existing := spec.Path.Value
+	if spec.Name != nil {
+		existing = spec.Name.Name + " " + existing
+	}
+	end := gen.End()
+	if spec.Comment != nil {
+		for _, c := range spec.Comment.List {
+			existing += " " + c.Text
+		}
+		end = spec.Comment.End()
+	}
+
+	lines := []string{existing, strconv.Quote(path)}
+	if strings.Trim(spec.Path.Value, `"`) > path {
+		lines[0], lines[1] = lines[1], lines[0]
+	}
+	from := lsp.Position{Line: fset.Position(gen.Pos()).Line - 1, Character: 0}
+	to := lsp.Position{Line: fset.Position(end).Line, Character: 0}
+	return lsp.TextEdit{
+		Range:   lsp.Range{Start: from, End: to},
+		NewText: "import (\n\t" + strings.Join(lines, "\n\t") + "\n)\n",
+	}
+}
+
+// RemoveImport returns an edit deleting the line that imports path, along
+// with its import declaration when it is the only spec in it. ok is false
+// when the file does not import path.
+func RemoveImport(fset *token.FileSet, file *ast.File, path string) (edit lsp.TextEdit, ok bool) {
+	deleteLines := func(start, end token.Pos) lsp.TextEdit {
+		from := lsp.Position{Line: fset.Position(start).Line - 1, Character: 0}
+		to := lsp.Position{Line: fset.Position(end).Line, Character: 0}
+		return lsp.TextEdit{Range: lsp.Range{Start: from, End: to}}
+	}
+
+	for _, decl := range file.Decls {
+		gen, isGen := decl.(*ast.GenDecl)
+		if !isGen || gen.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			imp := spec.(*ast.ImportSpec)
+			if strings.Trim(imp.Path.Value, `"`) != path {
+				continue
+			}
+			if len(gen.Specs) == 1 {
+				return deleteLines(gen.Pos(), gen.End()), true
+			}
+			return deleteLines(imp.Pos(), imp.End()), true
+		}
+	}
+	return lsp.TextEdit{}, false
+}
@@ -0,0 +1,74 @@
+package edits
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+)
+
+func TestAddImport(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "no imports",
+			src:  "package a\n\nfunc F() {}\n",
+			want: "package a\n\nimport \"context\"\n\nfunc F() {}\n",
+		},
+		{
+			name: "block",
+			src:  "package a\n\nimport (\n\t\"errors\"\n\t\"fmt\"\n)\n",
+			want: "package a\n\nimport (\n\t\"context\"\n\t\"errors\"\n\t\"fmt\"\n)\n",
+		},
+		{
+			name: "block end",
+			src:  "package a\n\nimport (\n\t\"bytes\"\n)\n",
+			want: "package a\n\nimport (\n\t\"bytes\"\n\t\"context\"\n)\n",
+		},
+		{
+			name: "single after",
+			src:  "package a\n\nimport \"fmt\"\n\nfunc F() { fmt.Println() }\n",
+			want: "package a\n\nimport (\n\t\"context\"\n\t\"fmt\"\n)\n\nfunc F() { fmt.Println() }\n",
+		},
+		{
+			name: "single before",
+			src:  "package a\n\nimport \"bytes\"\n",
+			want: "package a\n\nimport (\n\t\"bytes\"\n\t\"context\"\n)\n",
+		},
+		{
+			name: "single named with comment",
+			src:  "package a\n\nimport f \"fmt\" // printing\n",
+			want: "package a\n\nimport (\n\t\"context\"\n\tf \"fmt\" // printing\n)\n",
+		},
+		{
+			name: "block after single",
+			src:  "package a\n\nimport \"C\"\n\nimport (\n\t\"fmt\"\n)\n",
+			want: "package a\n\nimport \"C\"\n\nimport (\n\t\"context\"\n\t\"fmt\"\n)\n",
+		},
+		{
+			name: "cgo only",
+			src:  "package a\n\n// #include <stdio.h>\nimport \"C\"\n",
+			want: "package a\n\n// #include <stdio.h>\nimport \"C\"\nimport \"context\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "a.go", tt.src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Apply(tt.src, []lsp.TextEdit{AddImport(fset, file, "context")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("AddImport gave\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
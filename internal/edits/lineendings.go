@@ -0,0 +1,93 @@
+package edits
+
+import (
+	"bytes"
+	"strings"
+)
+
+// bom is the UTF-8 byte order mark some editors put at the start of a file
+const bom = "\ufeff"
+
+// Preserve gives updated, a new version of original produced by tools that
+// write LF line endings and drop byte order marks (go/format, gofmt style
+// edits from gopls, strings.Split and Join), the line endings and BOM of
+// original. Lines that exist in both keep the ending they had in original,
+// so a file with mixed endings stays mixed; new lines get the ending most of
+// original's lines use.
+func Preserve(original, updated []byte) []byte {
+	hasBOM := bytes.HasPrefix(original, []byte(bom))
+	updated = bytes.TrimPrefix(updated, []byte(bom))
+	if !bytes.Contains(original, []byte("\r\n")) {
+		if hasBOM {
+			return append([]byte(bom), updated...)
+		}
+		return updated
+	}
+
+	oldLines, oldEndings := splitEndings(strings.TrimPrefix(string(original), bom))
+	newLines, newEndings := splitEndings(string(updated))
+
+	crlf, lf := 0, 0
+	for _, ending := range oldEndings {
+		switch ending {
+		case "\r\n":
+			crlf++
+		case "\n":
+			lf++
+		}
+	}
+	dominant := "\n"
+	if crlf > lf {
+		dominant = "\r\n"
+	}
+
+	var b strings.Builder
+	b.Grow(len(updated) + len(newLines))
+	if hasBOM {
+		b.WriteString(bom)
+	}
+	oldIndex, newIndex := 0, 0
+	for _, op := range diffLines(oldLines, newLines) {
+		if op.kind == '-' {
+			oldIndex++
+			continue
+		}
+		// An unchanged line keeps its ending, unless it had none because it
+		// was the last line
+		ending := newEndings[newIndex]
+		if ending == "\n" {
+			ending = dominant
+			if op.kind == ' ' && oldEndings[oldIndex] != "" {
+				ending = oldEndings[oldIndex]
+			}
+		}
+		if op.kind == ' ' {
+			oldIndex++
+		}
+		b.WriteString(newLines[newIndex])
+		b.WriteString(ending)
+		newIndex++
+	}
+	return []byte(b.String())
+}
+
+// splitEndings splits text into lines without their endings and the ending
+// of each line: "\r\n", "\n", or "" for a last line without one
+func splitEndings(text string) (lines, endings []string) {
+	for text != "" {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			lines = append(lines, text)
+			endings = append(endings, "")
+			break
+		}
+		line, ending := text[:i], "\n"
+		if strings.HasSuffix(line, "\r") {
+			line, ending = line[:len(line)-1], "\r\n"
+		}
+		lines = append(lines, line)
+		endings = append(endings, ending)
+		text = text[i+1:]
+	}
+	return lines, endings
+}
@@ -0,0 +1,180 @@
+package edits
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+)
+
+func TestSplitEndings(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		lines   []string
+		endings []string
+	}{
+		{"empty", "", nil, nil},
+		{"lf", "a\nb\n", []string{"a", "b"}, []string{"\n", "\n"}},
+		{"crlf", "a\r\nb\r\n", []string{"a", "b"}, []string{"\r\n", "\r\n"}},
+		{"mixed", "a\r\nb\nc\r\n", []string{"a", "b", "c"}, []string{"\r\n", "\n", "\r\n"}},
+		{"no final newline", "a\r\nb", []string{"a", "b"}, []string{"\r\n", ""}},
+		{"blank lines", "\r\n\n", []string{"", ""}, []string{"\r\n", "\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, endings := splitEndings(tt.text)
+			if !reflect.DeepEqual(lines, tt.lines) || !reflect.DeepEqual(endings, tt.endings) {
+				t.Errorf("splitEndings(%q) = %q, %q, want %q, %q", tt.text, lines, endings, tt.lines, tt.endings)
+			}
+		})
+	}
+}
+
+func TestPreserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		updated  string
+		want     string
+	}{
+		{
+			name:     "lf",
+			original: "a\nb\n",
+			updated:  "a\nx\nb\n",
+			want:     "a\nx\nb\n",
+		},
+		{
+			name:     "crlf",
+			original: "a\r\nb\r\n",
+			updated:  "a\nx\nb\n",
+			want:     "a\r\nx\r\nb\r\n",
+		},
+		{
+			name:     "crlf updated already crlf",
+			original: "a\r\nb\r\n",
+			updated:  "a\r\nb\r\nc\r\n",
+			want:     "a\r\nb\r\nc\r\n",
+		},
+		{
+			// Unchanged lines keep their ending, new lines get the one most
+			// lines use
+			name:     "mixed mostly crlf",
+			original: "a\r\nb\nc\r\n",
+			updated:  "a\nb\nnew\nc\n",
+			want:     "a\r\nb\nnew\r\nc\r\n",
+		},
+		{
+			name:     "mixed mostly lf",
+			original: "a\nb\r\nc\n",
+			updated:  "a\nb\nnew\nc\n",
+			want:     "a\nb\r\nnew\nc\n",
+		},
+		{
+			name:     "mixed with removed line",
+			original: "a\r\nb\nc\r\n",
+			updated:  "a\nc\n",
+			want:     "a\r\nc\r\n",
+		},
+		{
+			name:     "no final newline",
+			original: "a\r\nb",
+			updated:  "a\nb\nc",
+			want:     "a\r\nb\r\nc",
+		},
+		{
+			name:     "bom lf",
+			original: bom + "a\nb\n",
+			updated:  "a\nx\nb\n",
+			want:     bom + "a\nx\nb\n",
+		},
+		{
+			name:     "bom crlf",
+			original: bom + "a\r\nb\r\n",
+			updated:  "a\nx\nb\n",
+			want:     bom + "a\r\nx\r\nb\r\n",
+		},
+		{
+			name:     "bom kept once",
+			original: bom + "a\r\n",
+			updated:  bom + "a\nb\n",
+			want:     bom + "a\r\nb\r\n",
+		},
+		{
+			name:     "no bom in original",
+			original: "a\n",
+			updated:  bom + "a\n",
+			want:     "a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(Preserve([]byte(tt.original), []byte(tt.updated)))
+			if got != tt.want {
+				t.Errorf("Preserve(%q, %q) = %q, want %q", tt.original, tt.updated, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyWithBOM(t *testing.T) {
+	edit := func(startLine, startChar, endLine, endChar int, text string) lsp.TextEdit {
+		return lsp.TextEdit{
+			Range: lsp.Range{
+				Start: lsp.Position{Line: startLine, Character: startChar},
+				End:   lsp.Position{Line: endLine, Character: endChar},
+			},
+			NewText: text,
+		}
+	}
+
+	tests := []struct {
+		name  string
+		text  string
+		edits []lsp.TextEdit
+		want  string
+	}{
+		{
+			// gopls counts the BOM as the first character of line 0
+			name:  "first line",
+			text:  bom + "package a\n",
+			edits: []lsp.TextEdit{edit(0, 9, 0, 10, "b")},
+			want:  bom + "package b\n",
+		},
+		{
+			name:  "start of file",
+			text:  bom + "package a\n",
+			edits: []lsp.TextEdit{edit(0, 0, 0, 0, "// doc\n")},
+			want:  "// doc\n" + bom + "package a\n",
+		},
+		{
+			name:  "later line",
+			text:  bom + "package a\n\nvar x = 1\n",
+			edits: []lsp.TextEdit{edit(2, 4, 2, 5, "y")},
+			want:  bom + "package a\n\nvar y = 1\n",
+		},
+		{
+			name:  "crlf",
+			text:  bom + "package a\r\n\r\nvar x = 1\r\n",
+			edits: []lsp.TextEdit{edit(0, 9, 0, 10, "b"), edit(2, 4, 2, 5, "y")},
+			want:  bom + "package b\r\n\r\nvar y = 1\r\n",
+		},
+		{
+			name:  "without bom",
+			text:  "package a\n",
+			edits: []lsp.TextEdit{edit(0, 8, 0, 9, "b")},
+			want:  "package b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tt.text, tt.edits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Apply(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package edits
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// PermissionDeniedError reports a file a tool was asked to change but may
+// not write
+type PermissionDeniedError struct {
+	Path   string
+	Reason string
+	Err    error
+}
+
+func (e *PermissionDeniedError) Error() string {
+	return fmt.Sprintf("permission denied: cannot write %s: %s", e.Path, e.Reason)
+}
+
+func (e *PermissionDeniedError) Unwrap() error {
+	if e.Err != nil {
+		return e.Err
+	}
+	return fs.ErrPermission
+}
+
+// CheckWritable returns a *PermissionDeniedError if path exists and is
+// read-only. Write permission bits are honored even where the process could
+// override them, such as when running as root, since clearing them marks a
+// file as not to be edited. Files that do not exist yet are not checked.
+func CheckWritable(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	if info.Mode().Perm()&0222 == 0 {
+		return &PermissionDeniedError{Path: path, Reason: fmt.Sprintf("the file is read-only (mode %s)", info.Mode().Perm())}
+	}
+
+	// Opening for writing also catches files the process does not own and
+	// files the file system refuses to change, such as immutable ones
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return &PermissionDeniedError{Path: path, Reason: "the file is not writable by this process", Err: err}
+		}
+		return fmt.Errorf("failed to open %s for writing: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,36 @@
+package gocmd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BuildError is an error the compiler reported at a source position
+type BuildError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+	// Text is the line as the go command printed it
+	Text string
+}
+
+// buildErrorLine matches an error line printed by go build
+var buildErrorLine = regexp.MustCompile(`^(.+?):(\d+):(\d+): (.*)$`)
+
+// BuildErrors returns the positioned errors in the output of go build,
+// skipping package headers and errors without a position
+func BuildErrors(stderr string) []BuildError {
+	var errs []BuildError
+	for _, line := range strings.Split(stderr, "\n") {
+		m := buildErrorLine.FindStringSubmatch(strings.TrimSuffix(line, "\r"))
+		if m == nil {
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		errs = append(errs, BuildError{File: m[1], Line: lineNo, Column: column, Message: m[4], Text: m[0]})
+	}
+	return errs
+}
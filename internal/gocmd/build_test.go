@@ -0,0 +1,25 @@
+package gocmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildErrors(t *testing.T) {
+	stderr := "# example.com/p\n" +
+		"./main.go:4:2: declared and not used: x\n" +
+		`C:\src\p\main.go:7:10: undefined: y` + "\r\n" +
+		"main.go:3:8: package nope is not in std\n" +
+		"no required module provides package nope\n"
+	want := []BuildError{
+		{File: "./main.go", Line: 4, Column: 2, Message: "declared and not used: x", Text: "./main.go:4:2: declared and not used: x"},
+		{File: `C:\src\p\main.go`, Line: 7, Column: 10, Message: "undefined: y", Text: `C:\src\p\main.go:7:10: undefined: y`},
+		{File: "main.go", Line: 3, Column: 8, Message: "package nope is not in std", Text: "main.go:3:8: package nope is not in std"},
+	}
+	if got := BuildErrors(stderr); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildErrors() = %+v, want %+v", got, want)
+	}
+	if got := BuildErrors(""); got != nil {
+		t.Errorf("BuildErrors(\"\") = %+v, want nil", got)
+	}
+}
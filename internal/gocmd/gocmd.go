@@ -0,0 +1,59 @@
+package gocmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Result holds the output of a go command invocation
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Run executes the go command with args in dir. Extra environment variables
+// in env are appended to the current environment. A non-zero exit status is
+// reported through Result.ExitCode rather than as an error so callers can
+// surface the command's own output.
+func Run(ctx context.Context, dir string, env []string, args ...string) (*Result, error) {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	result := &Result{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+	}
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return nil, fmt.Errorf("failed to run go %s: %w", strings.Join(args, " "), err)
+		}
+		result.ExitCode = exitErr.ExitCode()
+	}
+
+	return result, nil
+}
+
+// Output runs the go command and returns its trimmed stdout, treating a
+// non-zero exit status as an error that includes stderr
+func Output(ctx context.Context, dir string, args ...string) (string, error) {
+	result, err := Run(ctx, dir, nil, args...)
+	if err != nil {
+		return "", err
+	}
+	if result.ExitCode != 0 {
+		return "", fmt.Errorf("go %s failed: %s", strings.Join(args, " "), strings.TrimSpace(result.Stderr))
+	}
+	return strings.TrimSpace(result.Stdout), nil
+}
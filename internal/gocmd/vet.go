@@ -0,0 +1,133 @@
+package gocmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VetFinding is a single diagnostic reported by go vet
+type VetFinding struct {
+	Package  string `json:"package"`
+	Analyzer string `json:"analyzer"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+}
+
+// vetDiagnostic is the per-diagnostic object in go vet -json output
+type vetDiagnostic struct {
+	Posn    string `json:"posn"`
+	Message string `json:"message"`
+}
+
+// Vet runs go vet with the given analyzers enabled (all analyzers when
+// empty) over patterns and returns the parsed findings along with the raw
+// command result, whose stderr carries any build errors
+func Vet(ctx context.Context, dir string, analyzers []string, patterns ...string) ([]VetFinding, *Result, error) {
+	args := []string{"vet", "-json"}
+	for _, analyzer := range analyzers {
+		args = append(args, "-"+analyzer)
+	}
+	args = append(args, patterns...)
+
+	result, err := Run(ctx, dir, nil, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	findings, err := parseVetJSON(result.Stdout + "\n" + result.Stderr)
+	if err != nil {
+		return nil, result, err
+	}
+	return findings, result, nil
+}
+
+// parseVetJSON decodes the stream of {"pkg": {"analyzer": [...]}} objects go
+// vet prints, ignoring the "# pkg" header lines interleaved with them
+func parseVetJSON(output string) ([]VetFinding, error) {
+	var jsonLines []string
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		// Anything that is not part of a JSON object is a build error line
+		if !strings.HasPrefix(line, "{") && !strings.HasPrefix(line, "}") && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, " ") {
+			continue
+		}
+		jsonLines = append(jsonLines, line)
+	}
+
+	findings := make([]VetFinding, 0)
+	decoder := json.NewDecoder(strings.NewReader(strings.Join(jsonLines, "\n")))
+	for {
+		var report map[string]map[string]json.RawMessage
+		if err := decoder.Decode(&report); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go vet output: %w", err)
+		}
+
+		for pkg, analyzers := range report {
+			for analyzer, raw := range analyzers {
+				var diagnostics []vetDiagnostic
+				// Analyzer failures are reported as an object rather than a list
+				if err := json.Unmarshal(raw, &diagnostics); err != nil {
+					continue
+				}
+				for _, d := range diagnostics {
+					file, line, column := SplitPosition(d.Posn)
+					findings = append(findings, VetFinding{
+						Package:  pkg,
+						Analyzer: analyzer,
+						File:     file,
+						Line:     line,
+						Column:   column,
+						Message:  d.Message,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+	return findings, nil
+}
+
+// SplitPosition splits a "file:line:column" position as printed by the go
+// tool, tolerating a missing column
+func SplitPosition(posn string) (file string, line, column int) {
+	parts := strings.Split(posn, ":")
+	// Walk back over trailing numeric fields so Windows drive letters survive
+	numbers := make([]int, 0, 2)
+	for len(parts) > 1 && len(numbers) < 2 {
+		n, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			break
+		}
+		numbers = append([]int{n}, numbers...)
+		parts = parts[:len(parts)-1]
+	}
+	file = strings.Join(parts, ":")
+	if len(numbers) > 0 {
+		line = numbers[0]
+	}
+	if len(numbers) > 1 {
+		column = numbers[1]
+	}
+	return file, line, column
+}
@@ -0,0 +1,73 @@
+package gopls
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// DefaultMaxFileSize is the largest file tools send to gopls when
+// Config.MaxFileSize is not set. Handwritten Go files are far smaller; files
+// above it are usually generated code or embedded data, which slow gopls
+// down and flood results.
+const DefaultMaxFileSize = 4 << 20
+
+// FileTooLargeError is returned for a file larger than the limit on files
+// sent to gopls
+type FileTooLargeError struct {
+	Path  string
+	Size  int64
+	Limit int64
+}
+
+func (e *FileTooLargeError) Error() string {
+	return fmt.Sprintf("%s is %s, more than the %s limit on files sent to gopls; start the server with a larger -max-file-size (or MCP_GOPLS_MAX_FILE_SIZE) to analyze it",
+		e.Path, formatBytes(uint64(e.Size)), formatBytes(uint64(e.Limit)))
+}
+
+// NotTextError is returned for a file that is binary or not valid UTF-8,
+// which gopls cannot represent as a document
+type NotTextError struct {
+	Path string
+	// Offset is the byte offset of the first NUL byte or invalid UTF-8
+	// sequence
+	Offset int
+	// Binary is set when the file contains NUL bytes, rather than only
+	// invalid UTF-8
+	Binary bool
+}
+
+func (e *NotTextError) Error() string {
+	if e.Binary {
+		return fmt.Sprintf("%s is a binary file (NUL byte at offset %d), not Go source", e.Path, e.Offset)
+	}
+	return fmt.Sprintf("%s is not valid UTF-8 (invalid byte at offset %d); Go source files must be UTF-8 encoded", e.Path, e.Offset)
+}
+
+// ReadFile reads a file to open in gopls, refusing files larger than the
+// configured limit before reading them and files that are not UTF-8 text,
+// which would corrupt the positions gopls and the tools exchange. Staged
+// content is returned in place of the file's.
+func (m *Manager) ReadFile(path string) ([]byte, error) {
+	if content, ok := m.Staged(path); ok {
+		return content, nil
+	}
+	if m.maxFileSize > 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() > m.maxFileSize {
+			return nil, &FileTooLargeError{Path: path, Size: info.Size(), Limit: m.maxFileSize}
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if offset, binary := utils.FindNonText(content); offset >= 0 {
+		return nil, &NotTextError{Path: path, Offset: offset, Binary: binary}
+	}
+	return content, nil
+}
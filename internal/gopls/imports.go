@@ -0,0 +1,80 @@
+package gopls
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+)
+
+// OrganizeImports runs gopls's organize imports action over pending file
+// contents before they are written, replacing each entry in files with the
+// organized content. Every file is opened with its new content first so
+// gopls sees the whole change at once rather than a mix of old and new files.
+func (m *Manager) OrganizeImports(ctx context.Context, files map[string][]byte) error {
+	client, err := m.GetClient()
+	if err != nil {
+		return err
+	}
+
+	uris := make(map[string]string, len(files))
+	for path, content := range files {
+		if !strings.HasSuffix(path, ".go") {
+			continue
+		}
+		uri := pathToURI(path)
+		// A document opened earlier, or staged, holds other content, so
+		// change it too
+		if err := client.OpenDocument(ctx, uri, string(content)); err != nil {
+			return err
+		}
+		if err := client.ChangeDocument(ctx, uri, string(content)); err != nil {
+			return err
+		}
+		uris[path] = uri
+	}
+	defer func() {
+		for _, uri := range uris {
+			client.CloseDocument(ctx, uri)
+		}
+	}()
+
+	for path, uri := range uris {
+		content := string(files[path])
+		actions, err := client.CodeActionForRange(ctx, uri, lsp.Range{
+			Start: lsp.Position{Line: 0, Character: 0},
+			End:   lsp.Position{Line: strings.Count(content, "\n"), Character: 0},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to organize imports in %s: %w", path, err)
+		}
+
+		for _, action := range actions {
+			if action.Kind != lsp.CodeActionKindSourceOrganizeImports {
+				continue
+			}
+			action, err := client.ResolveCodeAction(ctx, action)
+			if err != nil {
+				return fmt.Errorf("failed to organize imports in %s: %w", path, err)
+			}
+			if action.Edit == nil {
+				continue
+			}
+			fileEdits, err := edits.FileEdits(action.Edit, m.URIToPath)
+			if err != nil {
+				return err
+			}
+			if textEdits := fileEdits[path]; len(textEdits) > 0 {
+				organized, err := edits.Apply(content, textEdits)
+				if err != nil {
+					return fmt.Errorf("failed to organize imports in %s: %w", path, err)
+				}
+				files[path] = []byte(organized)
+			}
+			break
+		}
+	}
+	return nil
+}
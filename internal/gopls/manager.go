@@ -3,23 +3,129 @@ package gopls
 import (
 	"context"
 	"fmt"
+	"log"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/yantrio/mcp-gopls/internal/codegen"
 	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/lsp/fake"
+	"github.com/yantrio/mcp-gopls/internal/lsp/trace"
+	"github.com/yantrio/mcp-gopls/internal/utils"
 )
 
+// Config holds the settings used to start and manage gopls
+type Config struct {
+	// GoplsPath is the gopls binary to run, defaults to "gopls" in PATH
+	GoplsPath string
+	// WorkspaceRoot is the root directory gopls is initialized with,
+	// defaults to the current directory
+	WorkspaceRoot string
+	// AutoAddWorkspaceFolders adds the module containing a file outside the
+	// workspace root as an extra workspace folder instead of rejecting it
+	AutoAddWorkspaceFolders bool
+	// IncludeVendor keeps vendor directories in gopls's workspace and in
+	// tool results, which leave them out by default
+	IncludeVendor bool
+	// DirectoryFilters are gopls directoryFilters, such as "-" followed by
+	// "+services/api" to analyze only one subtree of a large repository
+	DirectoryFilters []string
+	// Remote connects to a shared gopls daemon instead of starting a
+	// private gopls: "auto" starts gopls as a forwarder with -remote=auto,
+	// which starts the daemon if needed, and an address such as
+	// localhost:37374 or unix;/tmp/gopls.sock is dialed directly
+	Remote string
+	// Mock answers LSP requests with the in-process fake gopls of package
+	// fake instead of gopls, which needs neither gopls nor Go installed
+	Mock bool
+	// RecordTrace writes the LSP messages exchanged with gopls to this file,
+	// see package trace
+	RecordTrace string
+	// ReplayTrace answers LSP requests from a file written with RecordTrace
+	// instead of gopls
+	ReplayTrace string
+	// MemoryMode is MemoryModeDefault or MemoryModeLow
+	MemoryMode string
+	// MaxRSS recycles gopls when its resident memory exceeds this many
+	// bytes, zero means never
+	MaxRSS uint64
+	// MemoryCheckInterval is how often the memory of gopls is checked
+	// against MaxRSS, defaults to DefaultMemoryCheckInterval
+	MemoryCheckInterval time.Duration
+	// MaxFileSize is the largest file in bytes that tools send to gopls,
+	// defaults to DefaultMaxFileSize; negative means no limit
+	MaxFileSize int64
+	// Logger receives watchdog messages, which are dropped when it is nil
+	Logger *log.Logger
+	// FileHeader stamps the Go files tools create, nil stamps nothing
+	FileHeader *codegen.Stamper
+}
+
 type Manager struct {
-	client        *lsp.Client
-	goplsPath     string
-	workspaceRoot string
+	client         *lsp.Client
+	goplsPath      string
+	workspaceRoot  string
+	autoAddFolders bool
+	includeVendor  bool
+	remote         string
+	mock           bool
+	recorder       *trace.Recorder
+	replayer       *trace.Replayer
+	memoryMode     string
+	maxRSS         uint64
+	checkInterval  time.Duration
+	maxFileSize    int64
+	logger         *log.Logger
+	fileHeader     *codegen.Stamper
+	// aliases map the paths gopls reports back to the paths the user gave
+	aliases *utils.PathAliases
 
 	mu          sync.RWMutex
 	initialized bool
+	folders     []string
+	problem     string
+	filters     []string
+	stopWatch   chan struct{}
+	replay      *replayState
+	recycles    int
+	lastRecycle time.Time
+
+	// staged holds the content of files staged in gopls's overlay, see Stage
+	stagedMu           sync.Mutex
+	staged             map[string][]byte
+	overlayCheckpoints map[string]OverlayCheckpoint
+}
+
+// Status describes the workspace gopls was started in
+type Status struct {
+	WorkspaceRoot string `json:"workspaceRoot"`
+	Initialized   bool   `json:"initialized"`
+	// Remote is the shared gopls daemon connected to, if any, and Mock is
+	// set when the fake gopls answers instead
+	Remote       string   `json:"remote,omitempty"`
+	Mock         bool     `json:"mock,omitempty"`
+	ModuleRoot   string   `json:"moduleRoot,omitempty"`
+	GoWork       string   `json:"goWork,omitempty"`
+	ExtraFolders []string `json:"extraFolders,omitempty"`
+	// DirectoryFilters are the filters gopls was given, including the
+	// built-in exclusions of node_modules and vendor
+	DirectoryFilters []string `json:"directoryFilters,omitempty"`
+	MemoryMode       string   `json:"memoryMode"`
+	// GoplsRSS is the resident memory of gopls in bytes, if it is running
+	GoplsRSS uint64 `json:"goplsRSS,omitempty"`
+	// MaxRSS is the memory limit at which gopls is recycled
+	MaxRSS      uint64     `json:"maxRSS,omitempty"`
+	Recycles    int        `json:"recycles,omitempty"`
+	LastRecycle *time.Time `json:"lastRecycle,omitempty"`
+	Problem     string     `json:"problem,omitempty"`
 }
 
-func NewManager(goplsPath, workspaceRoot string) (*Manager, error) {
+func NewManager(cfg Config) (*Manager, error) {
+	workspaceRoot := cfg.WorkspaceRoot
 	if workspaceRoot == "" {
 		cwd, err := os.Getwd()
 		if err != nil {
@@ -32,10 +138,70 @@ func NewManager(goplsPath, workspaceRoot string) (*Manager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get absolute path: %w", err)
 	}
+	if err := validateFilters(cfg.DirectoryFilters); err != nil {
+		return nil, err
+	}
+	if err := validateMemoryMode(cfg.MemoryMode); err != nil {
+		return nil, err
+	}
+	// gopls reports files with symbolic links resolved; show them under the
+	// workspace root as given
+	aliases := &utils.PathAliases{}
+	aliases.Add(absWorkspace)
+	if cfg.Mock && cfg.Remote != "" {
+		return nil, fmt.Errorf("the fake gopls cannot be used with a remote gopls; remove one of them")
+	}
+	if cfg.ReplayTrace != "" && (cfg.Mock || cfg.Remote != "" || cfg.RecordTrace != "") {
+		return nil, fmt.Errorf("a replayed LSP trace cannot be combined with the fake gopls, a remote gopls or recording")
+	}
+	if cfg.Remote != "" && cfg.MaxRSS > 0 {
+		return nil, fmt.Errorf("a memory limit cannot be enforced on a shared gopls daemon; remove the remote or the limit")
+	}
+	checkInterval := cfg.MemoryCheckInterval
+	if checkInterval <= 0 {
+		checkInterval = DefaultMemoryCheckInterval
+	}
+	memoryMode := cfg.MemoryMode
+	if memoryMode == "" {
+		memoryMode = MemoryModeDefault
+	}
+	maxFileSize := cfg.MaxFileSize
+	if maxFileSize == 0 {
+		maxFileSize = DefaultMaxFileSize
+	}
+
+	var recorder *trace.Recorder
+	if cfg.RecordTrace != "" {
+		if recorder, err = trace.NewRecorder(cfg.RecordTrace); err != nil {
+			return nil, err
+		}
+	}
+	var replayer *trace.Replayer
+	if cfg.ReplayTrace != "" {
+		entries, err := trace.Read(cfg.ReplayTrace)
+		if err != nil {
+			return nil, err
+		}
+		replayer = trace.NewReplayer(entries)
+	}
 
 	return &Manager{
-		goplsPath:     goplsPath,
-		workspaceRoot: absWorkspace,
+		goplsPath:      cfg.GoplsPath,
+		workspaceRoot:  absWorkspace,
+		autoAddFolders: cfg.AutoAddWorkspaceFolders,
+		includeVendor:  cfg.IncludeVendor,
+		remote:         cfg.Remote,
+		mock:           cfg.Mock,
+		recorder:       recorder,
+		replayer:       replayer,
+		memoryMode:     memoryMode,
+		maxRSS:         cfg.MaxRSS,
+		checkInterval:  checkInterval,
+		maxFileSize:    maxFileSize,
+		logger:         cfg.Logger,
+		fileHeader:     cfg.FileHeader,
+		aliases:        aliases,
+		filters:        append([]string(nil), cfg.DirectoryFilters...),
 	}, nil
 }
 
@@ -47,19 +213,121 @@ func (m *Manager) Initialize(ctx context.Context) error {
 		return nil
 	}
 
-	client, err := lsp.NewClient(m.goplsPath)
+	// gopls still starts without a module, but most requests come back
+	// empty, so remember why for ServerStatus and tool errors
+	m.problem = workspaceProblem(m.workspaceRoot)
+
+	client, err := m.start(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create LSP client: %w", err)
+		return err
+	}
+
+	m.client = client
+	m.initialized = true
+	if m.maxRSS > 0 {
+		m.stopWatch = make(chan struct{})
+		go m.watch(m.checkInterval, m.stopWatch)
+	}
+	return nil
+}
+
+// start starts and initializes a gopls process, or a session with the
+// remote daemon. The caller must hold m.mu.
+func (m *Manager) start(ctx context.Context) (*lsp.Client, error) {
+	var client *lsp.Client
+	var err error
+	switch network, _ := lsp.ParseAddr(m.remote); {
+	case m.replayer != nil:
+		serverConn, clientConn := net.Pipe()
+		m.replayer.Serve(serverConn)
+		client = lsp.NewStreamClient(clientConn)
+	case m.mock:
+		serverConn, clientConn := net.Pipe()
+		fake.Serve(serverConn)
+		client = lsp.NewStreamClient(clientConn, m.recorder.ConnOpts()...)
+	case m.remote == "":
+		client, err = lsp.NewClient(m.goplsPath, nil, memoryEnv(m.memoryMode), m.recorder.ConnOpts()...)
+	case network == "auto":
+		// gopls finds or starts the daemon and forwards our stdio to it
+		client, err = lsp.NewClient(m.goplsPath, []string{"-remote=" + m.remote}, memoryEnv(m.memoryMode), m.recorder.ConnOpts()...)
+	default:
+		client, err = lsp.DialClient(ctx, m.remote, m.recorder.ConnOpts()...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LSP client: %w", err)
 	}
 
 	rootURI := pathToURI(m.workspaceRoot)
-	if err := client.Initialize(ctx, rootURI); err != nil {
-		_ = client.Shutdown(ctx)
-		return fmt.Errorf("failed to initialize LSP client: %w", err)
+	if err := client.Initialize(ctx, rootURI, m.settings()); err != nil {
+		_ = client.Kill()
+		return nil, fmt.Errorf("failed to initialize LSP client: %w", err)
 	}
+	return client, nil
+}
 
-	m.client = client
-	m.initialized = true
+// settings returns the gopls settings. The caller must hold m.mu.
+func (m *Manager) settings() map[string]interface{} {
+	settings := memorySettings(m.memoryMode)
+	if filters := m.directoryFilters(); len(filters) > 1 {
+		// More than gopls's own default
+		if settings == nil {
+			settings = make(map[string]interface{})
+		}
+		settings["directoryFilters"] = filters
+	}
+	return settings
+}
+
+// directoryFilters returns the filters gopls is given: its default, which
+// setting directoryFilters replaces, the vendor exclusion and the configured
+// filters, which come last so they can override the others. The caller must
+// hold m.mu.
+func (m *Manager) directoryFilters() []string {
+	filters := []string{"-**/node_modules"}
+	if !m.includeVendor {
+		filters = append(filters, "-**/vendor")
+	}
+	return append(filters, m.filters...)
+}
+
+// SetDirectoryFilters replaces the configured directoryFilters and has gopls
+// reload the workspace with them
+func (m *Manager) SetDirectoryFilters(ctx context.Context, filters []string) ([]string, error) {
+	if err := validateFilters(filters); err != nil {
+		return nil, err
+	}
+	client, err := m.GetClient()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.filters = append([]string(nil), filters...)
+	settings := m.settings()
+	effective := m.directoryFilters()
+	m.mu.Unlock()
+
+	if settings == nil {
+		// An empty object resets every setting to gopls's default
+		settings = map[string]interface{}{}
+	}
+	if err := client.DidChangeConfiguration(ctx, settings); err != nil {
+		return nil, err
+	}
+	return effective, nil
+}
+
+// validateFilters checks that each filter is a gopls directory filter: + or
+// - followed by a path relative to the workspace root
+func validateFilters(filters []string) error {
+	for _, filter := range filters {
+		if !strings.HasPrefix(filter, "+") && !strings.HasPrefix(filter, "-") {
+			return fmt.Errorf("invalid directory filter %q: must start with + to include or - to exclude a directory", filter)
+		}
+		if filepath.IsAbs(filter[1:]) {
+			return fmt.Errorf("invalid directory filter %q: paths are relative to the workspace root", filter)
+		}
+	}
 	return nil
 }
 
@@ -67,13 +335,21 @@ func (m *Manager) Shutdown(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.stopWatch != nil {
+		close(m.stopWatch)
+		m.stopWatch = nil
+	}
+	m.replay = nil
 	if !m.initialized || m.client == nil {
-		return nil
+		return m.recorder.Close()
 	}
 
 	err := m.client.Shutdown(ctx)
 	m.client = nil
 	m.initialized = false
+	if closeErr := m.recorder.Close(); err == nil {
+		err = closeErr
+	}
 	return err
 }
 
@@ -98,7 +374,217 @@ func (m *Manager) WorkspaceRoot() string {
 	return m.workspaceRoot
 }
 
+// FileHeader returns the header to stamp on the Go files tools create
+func (m *Manager) FileHeader() *codegen.Stamper {
+	return m.fileHeader
+}
+
+// URIToPath converts a file URI from gopls to a path, under the workspace
+// root and folders as the user gave them rather than with symbolic links
+// resolved
+func (m *Manager) URIToPath(uri string) (string, error) {
+	return m.aliases.URIToPath(uri)
+}
+
+// IncludeVendor reports whether tools include vendored code in their
+// results unless a call says otherwise
+func (m *Manager) IncludeVendor() bool {
+	return m.includeVendor
+}
+
+// WorkspaceProblem explains why gopls cannot analyze the workspace properly,
+// such as a missing go.mod, or returns an empty string when it can
+func (m *Manager) WorkspaceProblem() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.problem
+}
+
+// RecheckWorkspace detects the workspace's module again after it changes,
+// for example after go mod init, and returns the remaining problem if any
+func (m *Manager) RecheckWorkspace() string {
+	problem := workspaceProblem(m.workspaceRoot)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.problem = problem
+	return problem
+}
+
+// Status reports the workspace gopls was started in and any problem with it
+func (m *Manager) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status := Status{
+		WorkspaceRoot:    m.workspaceRoot,
+		Initialized:      m.initialized,
+		Remote:           m.remote,
+		Mock:             m.mock,
+		ModuleRoot:       findModuleRoot(m.workspaceRoot),
+		GoWork:           findUp(m.workspaceRoot, "go.work"),
+		ExtraFolders:     append([]string(nil), m.folders...),
+		DirectoryFilters: m.directoryFilters(),
+		MemoryMode:       m.memoryMode,
+		MaxRSS:           m.maxRSS,
+		Recycles:         m.recycles,
+		Problem:          m.problem,
+	}
+	// With a remote, the process we started is at most a forwarder
+	if m.client != nil && m.remote == "" {
+		status.GoplsRSS, _ = processRSS(m.client.PID())
+	}
+	if !m.lastRecycle.IsZero() {
+		lastRecycle := m.lastRecycle
+		status.LastRecycle = &lastRecycle
+	}
+	return status
+}
+
+func (m *Manager) logf(format string, args ...interface{}) {
+	if m.logger != nil {
+		m.logger.Printf(format, args...)
+	}
+}
+
+// ResolvePath makes path absolute, treating relative paths as relative to
+// the workspace root. An empty path resolves to the workspace root. A path
+// that reaches the workspace through a different symbolic link, such as
+// /private/tmp/app for a workspace at /tmp/app, is given in the workspace's
+// form so it matches the paths in tool results.
+func (m *Manager) ResolvePath(path string) string {
+	if path == "" {
+		return m.workspaceRoot
+	}
+	if !filepath.IsAbs(path) {
+		return filepath.Join(m.workspaceRoot, path)
+	}
+	path = filepath.Clean(path)
+	canonical := utils.CanonicalPath(path)
+	if visible := m.aliases.Visible(canonical); visible != canonical {
+		return visible
+	}
+	return path
+}
+
+// ValidateFile checks that path lies inside a folder gopls knows about.
+// gopls silently returns empty results for files outside its workspace, so
+// this either adds the file's module as a workspace folder (when enabled) or
+// returns an error explaining the mismatch.
+func (m *Manager) ValidateFile(ctx context.Context, path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	// Compare with symbolic links resolved, as gopls sees the files
+	canonical := utils.CanonicalPath(absPath)
+	m.mu.RLock()
+	inside := isWithin(canonical, utils.CanonicalPath(m.workspaceRoot))
+	for _, folder := range m.folders {
+		inside = inside || isWithin(canonical, utils.CanonicalPath(folder))
+	}
+	m.mu.RUnlock()
+
+	if inside {
+		return nil
+	}
+
+	if !m.autoAddFolders {
+		return fmt.Errorf("%s is outside the workspace root %s, so gopls cannot analyze it; "+
+			"restart mcp-gopls with -workspace set to the module containing this file, "+
+			"or enable -auto-add-folders to add it automatically", absPath, m.workspaceRoot)
+	}
+
+	moduleRoot := findModuleRoot(filepath.Dir(absPath))
+	if moduleRoot == "" {
+		return fmt.Errorf("%s is outside the workspace root %s and is not inside a Go module", absPath, m.workspaceRoot)
+	}
+
+	client, err := m.GetClient()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Another call may have added the folder while we were unlocked
+	for _, folder := range m.folders {
+		if folder == moduleRoot {
+			return nil
+		}
+	}
+
+	if err := client.AddWorkspaceFolder(ctx, pathToURI(moduleRoot), filepath.Base(moduleRoot)); err != nil {
+		return fmt.Errorf("failed to add workspace folder %s: %w", moduleRoot, err)
+	}
+	m.folders = append(m.folders, moduleRoot)
+	m.aliases.Add(moduleRoot)
+	return nil
+}
+
+// NotifyFileWritten tells gopls that a tool created or changed path on disk,
+// so files it has not seen before become part of its view
+func (m *Manager) NotifyFileWritten(ctx context.Context, path string, created bool) error {
+	client, err := m.GetClient()
+	if err != nil {
+		return err
+	}
+
+	changeType := lsp.FileChangeChanged
+	if created {
+		changeType = lsp.FileChangeCreated
+	}
+	return client.DidChangeWatchedFiles(ctx, []lsp.FileEvent{{URI: pathToURI(path), Type: changeType}})
+}
+
+// isWithin reports whether path is dir or a descendant of it
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// findModuleRoot walks up from dir looking for a go.mod file
+func findModuleRoot(dir string) string {
+	if goMod := findUp(dir, "go.mod"); goMod != "" {
+		return filepath.Dir(goMod)
+	}
+	return ""
+}
+
+// findUp returns the path of the file called name in dir or its nearest
+// ancestor that has one
+func findUp(dir, name string) string {
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// workspaceProblem reports a workspace root that is not inside a module or
+// go.work workspace, which leaves gopls without a build to analyze
+func workspaceProblem(root string) string {
+	if findModuleRoot(root) != "" || findUp(root, "go.work") != "" {
+		return ""
+	}
+	return fmt.Sprintf("no go.mod or go.work found in %s or its parent directories, so gopls cannot load "+
+		"packages and most tools will return empty or incomplete results; "+
+		"create a module with the InitModule tool (go mod init <module path>)", root)
+}
+
 func pathToURI(path string) string {
-	absPath, _ := filepath.Abs(path)
-	return "file://" + filepath.ToSlash(absPath)
+	uri, err := utils.PathToURI(path)
+	if err != nil {
+		return "file://" + filepath.ToSlash(path)
+	}
+	return uri
 }
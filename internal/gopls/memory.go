@@ -0,0 +1,248 @@
+package gopls
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+)
+
+// Memory modes for Config.MemoryMode
+const (
+	// MemoryModeDefault runs gopls with its own defaults
+	MemoryModeDefault = "default"
+	// MemoryModeLow turns off gopls features that keep large indexes in
+	// memory and makes its garbage collector run more often
+	MemoryModeLow = "low"
+)
+
+// DefaultMemoryCheckInterval is how often the watchdog samples the memory of
+// gopls when Config.MemoryCheckInterval is not set
+const DefaultMemoryCheckInterval = 30 * time.Second
+
+// recycleTimeout bounds the shutdown and restart of gopls when recycling it
+const recycleTimeout = time.Minute
+
+func validateMemoryMode(mode string) error {
+	switch mode {
+	case "", MemoryModeDefault, MemoryModeLow:
+		return nil
+	}
+	return fmt.Errorf("invalid memory mode %q: must be %s or %s", mode, MemoryModeDefault, MemoryModeLow)
+}
+
+// memorySettings returns the gopls settings of a memory mode
+func memorySettings(mode string) map[string]interface{} {
+	if mode != MemoryModeLow {
+		return nil
+	}
+	return map[string]interface{}{
+		// Index only workspace packages for symbol search, not dependencies
+		"symbolScope": "workspace",
+		// Completion of unimported packages scans the module cache
+		"completeUnimported": false,
+		"staticcheck":        false,
+	}
+}
+
+// memoryEnv returns the environment gopls is started with in a memory mode
+func memoryEnv(mode string) []string {
+	if mode != MemoryModeLow {
+		return nil
+	}
+	return []string{"GOGC=50"}
+}
+
+// ParseMemorySize parses a size such as 1536MiB, 2GB or 2147483648 into
+// bytes. Units are binary (1KB is 1024 bytes); a number without a unit is a
+// number of bytes.
+func ParseMemorySize(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	units := []struct {
+		suffix string
+		shift  uint
+	}{
+		{"GiB", 30}, {"GB", 30}, {"G", 30},
+		{"MiB", 20}, {"MB", 20}, {"M", 20},
+		{"KiB", 10}, {"KB", 10}, {"K", 10},
+		{"B", 0},
+	}
+	shift := uint(0)
+	for _, unit := range units {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, shift = strings.TrimSpace(number), unit.shift
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid memory size %q", s)
+	}
+	return uint64(n * float64(uint64(1)<<shift)), nil
+}
+
+// processRSS returns the resident set size of a process in bytes, from
+// /proc where it exists and from ps elsewhere
+func processRSS(pid int) (uint64, error) {
+	if f, err := os.Open(filepath.Join("/proc", strconv.Itoa(pid), "status")); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if value, ok := strings.CutPrefix(scanner.Text(), "VmRSS:"); ok {
+				kb, err := strconv.ParseUint(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "kB")), 10, 64)
+				if err != nil {
+					return 0, fmt.Errorf("failed to parse VmRSS %q: %w", value, err)
+				}
+				return kb << 10, nil
+			}
+		}
+		return 0, fmt.Errorf("no VmRSS in /proc/%d/status", pid)
+	}
+
+	out, err := exec.Command("ps", "-o", "rss=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read memory of process %d: %w", pid, err)
+	}
+	kb, err := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse ps output %q: %w", out, err)
+	}
+	return kb << 10, nil
+}
+
+// formatBytes prints a size in MiB, the unit gopls memory is usually
+// discussed in, or KiB for sizes below a MiB
+func formatBytes(n uint64) string {
+	if n < 1<<20 {
+		return fmt.Sprintf("%.0fKiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%.0fMiB", float64(n)/(1<<20))
+}
+
+// replayState is what a restarted gopls is told about that it cannot read
+// from disk or the manager's settings
+type replayState struct {
+	documents   []string
+	checkpoints map[string]lsp.DiagnosticsSnapshot
+}
+
+// GoplsRSS returns the resident memory of the running gopls process in bytes
+func (m *Manager) GoplsRSS() (uint64, error) {
+	if m.remote != "" {
+		return 0, fmt.Errorf("gopls runs as a shared daemon, not a child process")
+	}
+	client, err := m.GetClient()
+	if err != nil {
+		return 0, err
+	}
+	return processRSS(client.PID())
+}
+
+// watch samples the memory of gopls every interval until stop is closed,
+// recycling gopls when it uses more than m.maxRSS. When a restart failed it
+// retries on the next tick.
+func (m *Manager) watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if !m.IsInitialized() {
+			m.logf("restarting gopls after a failed recycle")
+			if err := m.Recycle(context.Background()); err != nil {
+				m.logf("failed to restart gopls: %v", err)
+			}
+			continue
+		}
+
+		rss, err := m.GoplsRSS()
+		if err != nil {
+			m.logf("failed to read gopls memory: %v", err)
+			continue
+		}
+		if rss <= m.maxRSS {
+			continue
+		}
+		m.logf("gopls uses %s, more than the limit of %s; restarting it", formatBytes(rss), formatBytes(m.maxRSS))
+		if err := m.Recycle(context.Background()); err != nil {
+			m.logf("failed to restart gopls: %v", err)
+		}
+	}
+}
+
+// Recycle restarts gopls to release its memory, then replays the state the
+// old process had: settings, extra workspace folders, open documents, staged
+// files and diagnostics checkpoints. Requests already sent to the old
+// process finish first; tool calls that obtained the old client and make
+// further requests afterwards fail with "client not initialized".
+func (m *Manager) Recycle(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, recycleTimeout)
+	defer cancel()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.client == nil && m.replay == nil {
+		// Never started, or shut down
+		return fmt.Errorf("manager not initialized")
+	}
+
+	// After a failed restart there is no old process, only the state saved
+	// from the one before it
+	state := m.replay
+	if old := m.client; old != nil {
+		state = &replayState{
+			documents:   old.OpenDocuments(),
+			checkpoints: old.DiagnosticsCheckpoints(),
+		}
+		if err := old.Shutdown(ctx); err != nil {
+			_ = old.Kill()
+		}
+		m.client = nil
+		m.initialized = false
+	}
+
+	client, err := m.start(ctx)
+	if err != nil {
+		m.replay = state
+		return err
+	}
+	m.client = client
+	m.initialized = true
+	m.replay = nil
+	m.recycles++
+	m.lastRecycle = time.Now()
+
+	for _, folder := range m.folders {
+		if err := client.AddWorkspaceFolder(ctx, pathToURI(folder), filepath.Base(folder)); err != nil {
+			m.logf("failed to add workspace folder %s after restart: %v", folder, err)
+		}
+	}
+	client.RestoreDiagnosticsCheckpoints(state.checkpoints)
+	for _, uri := range state.documents {
+		path, err := m.URIToPath(uri)
+		if err != nil {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue // Deleted since it was opened
+		}
+		if err := client.OpenDocument(ctx, uri, string(content)); err != nil {
+			m.logf("failed to reopen %s after restart: %v", path, err)
+		}
+	}
+	m.restage(ctx)
+	return nil
+}
@@ -0,0 +1,284 @@
+package gopls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+)
+
+// OverlayCheckpoint is the staged content of every file, and the
+// diagnostics gopls published for it, at a point in time
+type OverlayCheckpoint struct {
+	Name        string
+	Files       map[string][]byte
+	Diagnostics lsp.DiagnosticsSnapshot
+	Taken       time.Time
+}
+
+// Stage sets the content of path as gopls sees it, without writing the
+// file: the document is kept open in gopls with the content as an overlay
+// of the file on disk, which may not exist yet. Every tool that asks gopls,
+// and ReadFile, sees the staged content until it is committed or
+// discarded.
+func (m *Manager) Stage(ctx context.Context, path string, content []byte) error {
+	client, err := m.GetClient()
+	if err != nil {
+		return err
+	}
+	m.stagedMu.Lock()
+	defer m.stagedMu.Unlock()
+
+	if err := client.PinDocument(ctx, pathToURI(path), string(content)); err != nil {
+		return err
+	}
+	if m.staged == nil {
+		m.staged = make(map[string][]byte)
+	}
+	m.staged[path] = append([]byte(nil), content...)
+	return nil
+}
+
+// Staged returns the content staged for path
+func (m *Manager) Staged(path string) ([]byte, bool) {
+	m.stagedMu.Lock()
+	defer m.stagedMu.Unlock()
+
+	content, ok := m.staged[path]
+	return content, ok
+}
+
+// StagedFileError is returned for a write to a file with staged content:
+// gopls computes edits against the staged text, which applied to the file
+// on disk would corrupt it
+type StagedFileError struct {
+	Path string
+}
+
+func (e *StagedFileError) Error() string {
+	return fmt.Sprintf("%s has staged changes; commit or discard them before editing it on disk", e.Path)
+}
+
+// CheckNotStaged returns a *StagedFileError for the first of paths with
+// staged content
+func (m *Manager) CheckNotStaged(paths ...string) error {
+	m.stagedMu.Lock()
+	defer m.stagedMu.Unlock()
+
+	for _, path := range paths {
+		if _, ok := m.staged[path]; ok {
+			return &StagedFileError{Path: path}
+		}
+	}
+	return nil
+}
+
+// WriteFiles writes files as one change (see edits.WriteFiles), refusing
+// any with staged content
+func (m *Manager) WriteFiles(files map[string][]byte) error {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	if err := m.CheckNotStaged(paths...); err != nil {
+		return err
+	}
+	return edits.WriteFiles(files)
+}
+
+// ApplyToFile applies gopls edits to the file on disk (see
+// edits.ApplyToFile), refusing a file with staged content
+func (m *Manager) ApplyToFile(path string, textEdits []lsp.TextEdit) error {
+	if err := m.CheckNotStaged(path); err != nil {
+		return err
+	}
+	return edits.ApplyToFile(path, textEdits)
+}
+
+// StagedFiles returns the paths with staged content, sorted
+func (m *Manager) StagedFiles() []string {
+	m.stagedMu.Lock()
+	defer m.stagedMu.Unlock()
+
+	paths := make([]string, 0, len(m.staged))
+	for path := range m.staged {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Discard drops the staged content of paths, or of every staged file when
+// none are given, so gopls reads them from disk again. It returns the
+// paths discarded.
+func (m *Manager) Discard(ctx context.Context, paths ...string) ([]string, error) {
+	m.stagedMu.Lock()
+	defer m.stagedMu.Unlock()
+
+	selected, err := m.selectStaged(paths)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.unpin(ctx, selected); err != nil {
+		return nil, err
+	}
+	return selected, nil
+}
+
+// Commit writes the staged content of paths, or of every staged file when
+// none are given, to disk as one change (see edits.WriteFiles) and drops
+// it from the overlay. Nothing is written if any file cannot be. It returns
+// the paths written.
+func (m *Manager) Commit(ctx context.Context, paths ...string) ([]string, error) {
+	m.stagedMu.Lock()
+	defer m.stagedMu.Unlock()
+
+	selected, err := m.selectStaged(paths)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte, len(selected))
+	created := make(map[string]bool, len(selected))
+	for _, path := range selected {
+		files[path] = m.staged[path]
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			created[path] = true
+		}
+	}
+	if err := edits.WriteFiles(files); err != nil {
+		return nil, err
+	}
+	if err := m.unpin(ctx, selected); err != nil {
+		return nil, err
+	}
+	for _, path := range selected {
+		if err := m.NotifyFileWritten(ctx, path, created[path]); err != nil {
+			return nil, err
+		}
+	}
+	return selected, nil
+}
+
+// SaveOverlayCheckpoint records the staged files under name with the
+// diagnostics of that state, replacing any earlier checkpoint with the name
+func (m *Manager) SaveOverlayCheckpoint(name string, diagnostics lsp.DiagnosticsSnapshot) OverlayCheckpoint {
+	m.stagedMu.Lock()
+	defer m.stagedMu.Unlock()
+
+	checkpoint := OverlayCheckpoint{
+		Name:        name,
+		Files:       make(map[string][]byte, len(m.staged)),
+		Diagnostics: diagnostics,
+		Taken:       time.Now(),
+	}
+	for path, content := range m.staged {
+		checkpoint.Files[path] = content
+	}
+	if m.overlayCheckpoints == nil {
+		m.overlayCheckpoints = make(map[string]OverlayCheckpoint)
+	}
+	m.overlayCheckpoints[name] = checkpoint
+	return checkpoint
+}
+
+// GetOverlayCheckpoint returns the checkpoint saved under name
+func (m *Manager) GetOverlayCheckpoint(name string) (OverlayCheckpoint, error) {
+	m.stagedMu.Lock()
+	defer m.stagedMu.Unlock()
+
+	checkpoint, ok := m.overlayCheckpoints[name]
+	if !ok {
+		names := make([]string, 0, len(m.overlayCheckpoints))
+		for existing := range m.overlayCheckpoints {
+			names = append(names, existing)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return OverlayCheckpoint{}, fmt.Errorf("no overlay checkpoint named %q; none have been created", name)
+		}
+		return OverlayCheckpoint{}, fmt.Errorf("no overlay checkpoint named %q; checkpoints: %v", name, names)
+	}
+	return checkpoint, nil
+}
+
+// RestoreOverlay makes the staged files exactly those of checkpoint:
+// files staged since are discarded and the checkpoint's files staged again
+// with their content then
+func (m *Manager) RestoreOverlay(ctx context.Context, checkpoint OverlayCheckpoint) error {
+	client, err := m.GetClient()
+	if err != nil {
+		return err
+	}
+	m.stagedMu.Lock()
+	defer m.stagedMu.Unlock()
+
+	var extra []string
+	for path := range m.staged {
+		if _, ok := checkpoint.Files[path]; !ok {
+			extra = append(extra, path)
+		}
+	}
+	if err := m.unpin(ctx, extra); err != nil {
+		return err
+	}
+	for path, content := range checkpoint.Files {
+		if err := client.PinDocument(ctx, pathToURI(path), string(content)); err != nil {
+			return err
+		}
+		if m.staged == nil {
+			m.staged = make(map[string][]byte)
+		}
+		m.staged[path] = content
+	}
+	return nil
+}
+
+// selectStaged returns the given paths, which must all be staged, or every
+// staged path when none are given. stagedMu must be held.
+func (m *Manager) selectStaged(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		for path := range m.staged {
+			paths = append(paths, path)
+		}
+	}
+	for _, path := range paths {
+		if _, ok := m.staged[path]; !ok {
+			return nil, fmt.Errorf("%s has no staged changes", path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// unpin drops paths from the overlay. stagedMu must be held.
+func (m *Manager) unpin(ctx context.Context, paths []string) error {
+	client, err := m.GetClient()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := client.UnpinDocument(ctx, pathToURI(path)); err != nil {
+			return err
+		}
+		delete(m.staged, path)
+	}
+	return nil
+}
+
+// restage pins the staged files in a restarted gopls
+func (m *Manager) restage(ctx context.Context) {
+	m.stagedMu.Lock()
+	defer m.stagedMu.Unlock()
+
+	for path, content := range m.staged {
+		if err := m.client.PinDocument(ctx, pathToURI(path), string(content)); err != nil {
+			m.logf("failed to restage %s after restart: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Package index builds snapshots of a workspace's packages, symbols and
+// diagnostics that can be saved to a JSON file and loaded again without
+// gopls, for offline tooling and to get oriented in a huge repository before
+// gopls has finished loading it.
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// Version is the snapshot format written by Build; Read rejects others
+const Version = 1
+
+// Snapshot is an exported workspace index. Paths are slash-separated and
+// relative to the workspace root, so a snapshot made on one machine can be
+// imported in a checkout of the same repository elsewhere.
+type Snapshot struct {
+	Version     int                `json:"version"`
+	Root        string             `json:"root"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	Packages    []*Package         `json:"packages"`
+	Symbols     []Symbol           `json:"symbols"`
+	Diagnostics *DiagnosticsReport `json:"diagnostics,omitempty"`
+}
+
+// Package is a node of the package graph
+type Package struct {
+	Dir        string   `json:"dir"`
+	ImportPath string   `json:"importPath,omitempty"`
+	Name       string   `json:"name"`
+	Files      []string `json:"files"`
+	// Imports are the packages the package's non-test files import
+	Imports []string `json:"imports,omitempty"`
+}
+
+// Symbol is a package-level declaration
+type Symbol struct {
+	Name string `json:"name"`
+	// Kind is function, method, struct, interface, type, constant or
+	// variable
+	Kind string `json:"kind"`
+	// Receiver is the receiver type of a method
+	Receiver string `json:"receiver,omitempty"`
+	Package  string `json:"package"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// DiagnosticsReport is the diagnostics gopls had published when the
+// snapshot was made
+type DiagnosticsReport struct {
+	Errors   int               `json:"errors"`
+	Warnings int               `json:"warnings"`
+	Files    []FileDiagnostics `json:"files"`
+}
+
+// FileDiagnostics are the diagnostics of one file
+type FileDiagnostics struct {
+	File        string           `json:"file"`
+	Diagnostics []lsp.Diagnostic `json:"diagnostics"`
+}
+
+// Build scans the Go files under root into a snapshot of its packages and
+// package-level symbols. Files that fail to parse are left out.
+func Build(ctx context.Context, root string, opts astscan.Options) (*Snapshot, error) {
+	snapshot := &Snapshot{Version: Version, Root: root, CreatedAt: time.Now().UTC()}
+	byDir := make(map[string]*Package)
+	imports := make(map[string]map[string]bool)
+
+	err := astscan.Walk(root, opts, func(f *astscan.File) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel := relative(root, f.Path)
+		dir := filepath.Dir(f.Path)
+		pkg := byDir[dir]
+		if pkg == nil {
+			pkg = &Package{Dir: relative(root, dir)}
+			pkg.ImportPath, _ = codegen.ImportPath(dir)
+			byDir[dir] = pkg
+			imports[dir] = make(map[string]bool)
+		}
+		pkg.Files = append(pkg.Files, rel)
+
+		test := strings.HasSuffix(f.Path, "_test.go")
+		if !test {
+			pkg.Name = f.AST.Name.Name
+			for _, spec := range f.AST.Imports {
+				imports[dir][strings.Trim(spec.Path.Value, `"`)] = true
+			}
+		} else if pkg.Name == "" {
+			pkg.Name = strings.TrimSuffix(f.AST.Name.Name, "_test")
+		}
+		snapshot.Symbols = append(snapshot.Symbols, symbols(f, rel)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for dir, pkg := range byDir {
+		for path := range imports[dir] {
+			pkg.Imports = append(pkg.Imports, path)
+		}
+		sort.Strings(pkg.Imports)
+		snapshot.Packages = append(snapshot.Packages, pkg)
+	}
+	sort.Slice(snapshot.Packages, func(i, j int) bool {
+		return snapshot.Packages[i].Dir < snapshot.Packages[j].Dir
+	})
+	return snapshot, nil
+}
+
+// symbols lists the package-level declarations of a file
+func symbols(f *astscan.File, rel string) []Symbol {
+	var result []Symbol
+	add := func(name *ast.Ident, kind, receiver string) {
+		if name.Name == "_" {
+			return
+		}
+		line, column := f.Position(name.Pos())
+		result = append(result, Symbol{
+			Name:     name.Name,
+			Kind:     kind,
+			Receiver: receiver,
+			Package:  f.AST.Name.Name,
+			File:     rel,
+			Line:     line,
+			Column:   column,
+		})
+	}
+
+	for _, decl := range f.AST.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				add(decl.Name, "method", astscan.ReceiverType(decl.Recv.List[0].Type))
+			} else {
+				add(decl.Name, "function", "")
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					kind := "type"
+					switch spec.Type.(type) {
+					case *ast.StructType:
+						kind = "struct"
+					case *ast.InterfaceType:
+						kind = "interface"
+					}
+					add(spec.Name, kind, "")
+				case *ast.ValueSpec:
+					kind := "variable"
+					if decl.Tok == token.CONST {
+						kind = "constant"
+					}
+					for _, name := range spec.Names {
+						add(name, kind, "")
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// SetDiagnostics records diagnostics published by gopls, keyed by file
+// path, keeping files under the snapshot's root
+func (s *Snapshot) SetDiagnostics(byFile map[string][]lsp.Diagnostic) {
+	report := &DiagnosticsReport{Files: make([]FileDiagnostics, 0)}
+	for path, diagnostics := range byFile {
+		rel := relative(s.Root, path)
+		if len(diagnostics) == 0 || strings.HasPrefix(rel, "../") {
+			continue
+		}
+		for _, diag := range diagnostics {
+			switch diag.Severity {
+			case lsp.DiagnosticSeverityError, 0:
+				report.Errors++
+			case lsp.DiagnosticSeverityWarning:
+				report.Warnings++
+			}
+		}
+		report.Files = append(report.Files, FileDiagnostics{File: rel, Diagnostics: diagnostics})
+	}
+	sort.Slice(report.Files, func(i, j int) bool { return report.Files[i].File < report.Files[j].File })
+	s.Diagnostics = report
+}
+
+// Checkpoint returns the snapshot's diagnostics as a diagnostics checkpoint
+// for the files under root, for comparing the current diagnostics with them
+func (s *Snapshot) Checkpoint(root string) lsp.DiagnosticsSnapshot {
+	checkpoint := lsp.DiagnosticsSnapshot{
+		Diagnostics: make(map[string][]lsp.Diagnostic),
+		Versions:    make(map[string]int),
+		Taken:       s.CreatedAt,
+	}
+	if s.Diagnostics == nil {
+		return checkpoint
+	}
+	for _, file := range s.Diagnostics.Files {
+		uri, err := utils.PathToURI(filepath.Join(root, filepath.FromSlash(file.File)))
+		if err != nil {
+			continue
+		}
+		checkpoint.Diagnostics[uri] = file.Diagnostics
+	}
+	return checkpoint
+}
+
+// Read loads a snapshot saved as JSON
+func Read(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("%s is not a workspace index: %w", path, err)
+	}
+	if snapshot.Version != Version {
+		return nil, fmt.Errorf("%s has index format version %d, this server reads version %d; export it again", path, snapshot.Version, Version)
+	}
+	return &snapshot, nil
+}
+
+// Stale returns the files of the snapshot that were changed or deleted
+// under root since it was made. Files added since are not detected.
+func (s *Snapshot) Stale(root string) []string {
+	var stale []string
+	for _, pkg := range s.Packages {
+		for _, file := range pkg.Files {
+			info, err := os.Stat(filepath.Join(root, filepath.FromSlash(file)))
+			if err != nil || info.ModTime().After(s.CreatedAt) {
+				stale = append(stale, file)
+			}
+		}
+	}
+	return stale
+}
+
+func relative(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strings"
 	"sync"
 
 	"github.com/sourcegraph/jsonrpc2"
@@ -19,23 +21,28 @@ type Client struct {
 
 	mu          sync.Mutex
 	initialized bool
-	openDocs    map[string]bool
+	openDocs    map[string]int // version of each open document
+	pinned      map[string]pin
 	rootURI     string
 }
 
-func NewClient(goplsPath string) (*Client, error) {
+// NewClient starts gopls. flags, such as -remote=auto, are passed before the
+// serve command and env, if not empty, is added to the environment gopls
+// inherits. opts configure the connection, for example to record it.
+func NewClient(goplsPath string, flags, env []string, opts ...jsonrpc2.ConnOpt) (*Client, error) {
 	if goplsPath == "" {
 		goplsPath = "gopls"
 	}
 
-	cmd := exec.Command(goplsPath, "serve")
+	cmd := exec.Command(goplsPath, append(flags, "serve")...)
 	cmd.Stderr = os.Stderr
-
-	handler := &serverHandler{
-		diagnostics: make(map[string][]Diagnostic),
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
 	}
 
-	conn, err := newProcessConnection(cmd)
+	handler := newServerHandler()
+
+	conn, err := newProcessConnection(cmd, handler, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create connection: %w", err)
 	}
@@ -44,13 +51,60 @@ func NewClient(goplsPath string) (*Client, error) {
 		process:  cmd,
 		conn:     conn,
 		handler:  handler,
-		openDocs: make(map[string]bool),
+		openDocs: make(map[string]int),
+		pinned:   make(map[string]pin),
 	}
 
 	return client, nil
 }
 
-func (c *Client) Initialize(ctx context.Context, rootURI string) error {
+// DialClient connects to a gopls daemon started with gopls -listen instead
+// of starting a private gopls. addr uses gopls's syntax: host:port for TCP or
+// unix;/path/to/socket for a unix socket.
+func DialClient(ctx context.Context, addr string, opts ...jsonrpc2.ConnOpt) (*Client, error) {
+	handler := newServerHandler()
+
+	network, address := ParseAddr(addr)
+	conn, err := newNetConnection(ctx, network, address, handler, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to gopls at %s: %w", addr, err)
+	}
+
+	return &Client{
+		conn:     conn,
+		handler:  handler,
+		openDocs: make(map[string]int),
+		pinned:   make(map[string]pin),
+	}, nil
+}
+
+// NewStreamClient talks to an LSP server over rwc, such as one end of a
+// net.Pipe whose other end an in-process server reads. Like a daemon
+// session, the server is not asked to exit on shutdown.
+func NewStreamClient(rwc io.ReadWriteCloser, opts ...jsonrpc2.ConnOpt) *Client {
+	handler := newServerHandler()
+
+	return &Client{
+		conn:     newConnection(rwc, handler, opts...),
+		handler:  handler,
+		openDocs: make(map[string]int),
+		pinned:   make(map[string]pin),
+	}
+}
+
+// ParseAddr splits a gopls daemon address into a network and an address
+// for net.Dial: unix;/tmp/gopls.sock is a unix socket, anything without a
+// network prefix is TCP
+func ParseAddr(addr string) (network, address string) {
+	if network, address, ok := strings.Cut(addr, ";"); ok {
+		return network, address
+	}
+	return "tcp", addr
+}
+
+// Initialize starts the LSP session. options are sent as gopls settings in
+// initializationOptions.
+func (c *Client) Initialize(ctx context.Context, rootURI string, options map[string]interface{}) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -66,23 +120,56 @@ func (c *Client) Initialize(ctx context.Context, rootURI string) error {
 				Synchronization: TextDocumentSyncClientCapabilities{
 					DidSave: true,
 				},
-				Definition: DefinitionClientCapabilities{},
-				References: ReferenceClientCapabilities{},
-				Hover:      HoverClientCapabilities{},
-				Rename:     RenameClientCapabilities{
+				// Links carry the whole declaration as well as its name
+				Definition:     DefinitionClientCapabilities{LinkSupport: true},
+				TypeDefinition: TypeDefinitionClientCapabilities{LinkSupport: true},
+				Implementation: ImplementationClientCapabilities{LinkSupport: true},
+				References:     ReferenceClientCapabilities{},
+				Hover:          HoverClientCapabilities{},
+				Rename: RenameClientCapabilities{
 					PrepareSupport: true,
 				},
+				CodeAction: CodeActionClientCapabilities{
+					CodeActionLiteralSupport: &CodeActionLiteralSupport{
+						CodeActionKind: CodeActionKindValueSet{
+							ValueSet: []CodeActionKind{CodeActionKindEmpty, CodeActionKindQuickFix, CodeActionKindRefactor, CodeActionKindSource, CodeActionKindSourceOrganizeImports},
+						},
+					},
+					// Edits that are expensive to compute may be left out
+					// of code actions until they are resolved
+					DataSupport:    true,
+					ResolveSupport: &CodeActionResolveSupport{Properties: []string{"edit"}},
+				},
+				PublishDiagnostics: PublishDiagnosticsClientCapabilities{
+					RelatedInformation: true,
+					TagSupport: &DiagnosticTagSupport{
+						ValueSet: []DiagnosticTag{DiagnosticTagUnnecessary, DiagnosticTagDeprecated},
+					},
+					CodeDescriptionSupport: true,
+				},
 			},
 			Workspace: WorkspaceClientCapabilities{
-				ApplyEdit: true,
+				ApplyEdit:        true,
+				WorkspaceFolders: true,
+				// gopls pulls its settings again after didChangeConfiguration
+				Configuration: true,
 				WorkspaceEdit: WorkspaceEditClientCapabilities{
 					DocumentChanges: true,
 				},
-				Symbol: WorkspaceSymbolClientCapabilities{},
+				DidChangeWatchedFiles: DidChangeWatchedFilesClientCapabilities{},
+				Symbol:                WorkspaceSymbolClientCapabilities{},
+			},
+			Window: WindowClientCapabilities{
+				WorkDoneProgress: true,
 			},
 		},
 	}
 
+	if len(options) > 0 {
+		params.InitializationOptions = options
+	}
+	c.handler.setSettings(options)
+
 	var result InitializeResult
 	if err := c.conn.Call(ctx, "initialize", params, &result); err != nil {
 		return fmt.Errorf("initialize failed: %w", err)
@@ -108,7 +195,8 @@ func (c *Client) Shutdown(ctx context.Context) error {
 		return fmt.Errorf("client not initialized")
 	}
 
-	// Close all open documents
+	// Close all open documents, pinned ones too
+	c.pinned = make(map[string]pin)
 	for uri := range c.openDocs {
 		_ = c.closeDocument(ctx, uri)
 	}
@@ -118,6 +206,16 @@ func (c *Client) Shutdown(ctx context.Context) error {
 		return fmt.Errorf("shutdown failed: %w", err)
 	}
 
+	// A shared daemon serves other clients, so end only this session
+	// without asking it to exit
+	if c.process == nil {
+		c.initialized = false
+		if err := c.conn.Close(); err != nil {
+			return fmt.Errorf("failed to close connection: %w", err)
+		}
+		return nil
+	}
+
 	// Send exit notification
 	if err := c.conn.Notify(ctx, "exit", nil); err != nil {
 		return fmt.Errorf("exit notification failed: %w", err)
@@ -140,15 +238,170 @@ func (c *Client) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Kill stops gopls without the shutdown handshake, for when it no longer
+// responds. A connection to a shared daemon is only closed.
+func (c *Client) Kill() error {
+	// Kill before taking c.mu, which a request stuck waiting on gopls holds
+	var err error
+	if c.process != nil && c.process.Process != nil {
+		err = c.process.Process.Kill()
+	}
+	_ = c.conn.Close()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.initialized = false
+	if c.process != nil {
+		_ = c.process.Wait()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to kill gopls: %w", err)
+	}
+	return nil
+}
+
+// CanPrepareRename reports whether the server answers
+// textDocument/prepareRename, as it announced in its capabilities
+func (c *Client) CanPrepareRename() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.capabilities.RenameProvider.PrepareProvider
+}
+
+// CanResolveCodeActions reports whether the server may return code actions
+// without their edit, to be filled in with codeAction/resolve
+func (c *Client) CanResolveCodeActions() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.capabilities.CodeActionProvider.ResolveProvider
+}
+
+// PID returns the process ID of gopls, or 0 when connected to a daemon
+func (c *Client) PID() int {
+	if c.process == nil || c.process.Process == nil {
+		return 0
+	}
+	return c.process.Process.Pid
+}
+
+// OpenDocuments returns the URIs of the documents opened with OpenDocument
+// and not closed since
+func (c *Client) OpenDocuments() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	uris := make([]string, 0, len(c.openDocs))
+	for uri := range c.openDocs {
+		uris = append(uris, uri)
+	}
+	return uris
+}
+
+// AddWorkspaceFolder tells gopls about an additional workspace folder
+func (c *Client) AddWorkspaceFolder(ctx context.Context, uri, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.initialized {
+		return fmt.Errorf("client not initialized")
+	}
+
+	params := DidChangeWorkspaceFoldersParams{
+		Event: WorkspaceFoldersChangeEvent{
+			Added:   []WorkspaceFolder{{URI: uri, Name: name}},
+			Removed: []WorkspaceFolder{},
+		},
+	}
+
+	if err := c.conn.Notify(ctx, "workspace/didChangeWorkspaceFolders", params); err != nil {
+		return fmt.Errorf("didChangeWorkspaceFolders notification failed: %w", err)
+	}
+
+	return nil
+}
+
+// DidChangeWatchedFiles tells gopls about files created, changed or deleted
+// on disk outside of open documents
+func (c *Client) DidChangeWatchedFiles(ctx context.Context, changes []FileEvent) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.initialized {
+		return fmt.Errorf("client not initialized")
+	}
+
+	params := DidChangeWatchedFilesParams{Changes: changes}
+	if err := c.conn.Notify(ctx, "workspace/didChangeWatchedFiles", params); err != nil {
+		return fmt.Errorf("didChangeWatchedFiles notification failed: %w", err)
+	}
+
+	return nil
+}
+
+// WillRenameFiles asks gopls for the edits needed before files or
+// directories are renamed, such as updated import paths and package clauses
+func (c *Client) WillRenameFiles(ctx context.Context, files []FileRename) (*WorkspaceEdit, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.initialized {
+		return nil, fmt.Errorf("client not initialized")
+	}
+
+	var edit *WorkspaceEdit
+	if err := c.conn.Call(ctx, "workspace/willRenameFiles", RenameFilesParams{Files: files}, &edit); err != nil {
+		return nil, fmt.Errorf("willRenameFiles request failed: %w", err)
+	}
+
+	return edit, nil
+}
+
+// DidRenameFiles tells gopls that files or directories were renamed
+func (c *Client) DidRenameFiles(ctx context.Context, files []FileRename) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.initialized {
+		return fmt.Errorf("client not initialized")
+	}
+
+	if err := c.conn.Notify(ctx, "workspace/didRenameFiles", RenameFilesParams{Files: files}); err != nil {
+		return fmt.Errorf("didRenameFiles notification failed: %w", err)
+	}
+
+	return nil
+}
+
+// Call sends an arbitrary request to gopls, for methods the client has no
+// dedicated wrapper for
+func (c *Client) Call(ctx context.Context, method string, params, result interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.initialized {
+		return fmt.Errorf("client not initialized")
+	}
+
+	if err := c.conn.Call(ctx, method, params, result); err != nil {
+		return fmt.Errorf("%s request failed: %w", method, err)
+	}
+
+	return nil
+}
+
 func (c *Client) OpenDocument(ctx context.Context, uri string, content string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	return c.openDocument(ctx, uri, content)
+}
+
+func (c *Client) openDocument(ctx context.Context, uri string, content string) error {
 	if !c.initialized {
 		return fmt.Errorf("client not initialized")
 	}
 
-	if c.openDocs[uri] {
+	if c.openDocs[uri] > 0 {
 		return nil // Already open
 	}
 
@@ -165,10 +418,77 @@ func (c *Client) OpenDocument(ctx context.Context, uri string, content string) e
 		return fmt.Errorf("didOpen notification failed: %w", err)
 	}
 
-	c.openDocs[uri] = true
+	c.openDocs[uri] = 1
 	return nil
 }
 
+// ChangeDocument replaces the content of an open document without saving
+// it, so gopls analyzes the new content as an overlay of the file on disk
+func (c *Client) ChangeDocument(ctx context.Context, uri string, content string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.changeDocument(ctx, uri, content)
+}
+
+func (c *Client) changeDocument(ctx context.Context, uri string, content string) error {
+	version := c.openDocs[uri]
+	if version == 0 {
+		return fmt.Errorf("cannot change %s: it is not open", uri)
+	}
+	params := DidChangeTextDocumentParams{
+		TextDocument: VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: TextDocumentIdentifier{URI: uri},
+			Version:                version + 1,
+		},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: content}},
+	}
+	if err := c.conn.Notify(ctx, "textDocument/didChange", params); err != nil {
+		return fmt.Errorf("didChange notification failed: %w", err)
+	}
+
+	c.openDocs[uri] = version + 1
+	return nil
+}
+
+// pin is the content a pinned document is kept open with
+type pin struct {
+	content string
+	version int
+}
+
+// PinDocument opens uri with content, or changes it to content if it is
+// open, and keeps it open with that content until UnpinDocument: closing it
+// restores the content instead. gopls analyzes the content as an overlay
+// of the file on disk, which is left untouched.
+func (c *Client) PinDocument(ctx context.Context, uri string, content string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.openDocs[uri] == 0 {
+		if err := c.openDocument(ctx, uri, content); err != nil {
+			return err
+		}
+	} else if err := c.changeDocument(ctx, uri, content); err != nil {
+		return err
+	}
+	c.pinned[uri] = pin{content: content, version: c.openDocs[uri]}
+	return nil
+}
+
+// UnpinDocument closes a document pinned with PinDocument, so gopls reads
+// the file from disk again
+func (c *Client) UnpinDocument(ctx context.Context, uri string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.pinned[uri]; !ok {
+		return nil
+	}
+	delete(c.pinned, uri)
+	return c.closeDocument(ctx, uri)
+}
+
 func (c *Client) CloseDocument(ctx context.Context, uri string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -177,9 +497,21 @@ func (c *Client) CloseDocument(ctx context.Context, uri string) error {
 }
 
 func (c *Client) closeDocument(ctx context.Context, uri string) error {
-	if !c.openDocs[uri] {
+	if c.openDocs[uri] == 0 {
 		return nil // Not open
 	}
+	if p, ok := c.pinned[uri]; ok {
+		// Keep the document open, with the pinned content back if a tool
+		// changed it
+		if c.openDocs[uri] == p.version {
+			return nil
+		}
+		if err := c.changeDocument(ctx, uri, p.content); err != nil {
+			return err
+		}
+		c.pinned[uri] = pin{content: p.content, version: c.openDocs[uri]}
+		return nil
+	}
 
 	params := DidCloseTextDocumentParams{
 		TextDocument: TextDocumentIdentifier{
@@ -215,14 +547,39 @@ func (c *Client) Definition(ctx context.Context, uri string, position Position)
 		return nil, fmt.Errorf("definition request failed: %w", err)
 	}
 
-	// Handle both single Location and []Location responses
-	var locations []Location
-	if err := json.Unmarshal(result, &locations); err != nil {
-		var singleLocation Location
-		if err := json.Unmarshal(result, &singleLocation); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal definition result: %w", err)
-		}
-		locations = []Location{singleLocation}
+	locations, err := parseLocations(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal definition result: %w", err)
+	}
+
+	return locations, nil
+}
+
+// TypeDefinition returns the definition of the type of the symbol at a
+// position
+func (c *Client) TypeDefinition(ctx context.Context, uri string, position Position) ([]Location, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.initialized {
+		return nil, fmt.Errorf("client not initialized")
+	}
+
+	params := TypeDefinitionParams{
+		TextDocumentPositionParams: TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     position,
+		},
+	}
+
+	var result json.RawMessage
+	if err := c.conn.Call(ctx, "textDocument/typeDefinition", params, &result); err != nil {
+		return nil, fmt.Errorf("typeDefinition request failed: %w", err)
+	}
+
+	locations, err := parseLocations(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal typeDefinition result: %w", err)
 	}
 
 	return locations, nil
@@ -339,14 +696,7 @@ func (c *Client) Rename(ctx context.Context, uri string, position Position, newN
 }
 
 func (c *Client) GetDiagnostics(uri string) []Diagnostic {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if c.handler.diagnostics == nil {
-		return nil
-	}
-
-	return c.handler.diagnostics[uri]
+	return c.handler.get(uri)
 }
 
 func (c *Client) Implementation(ctx context.Context, uri string, position Position) ([]Location, error) {
@@ -369,24 +719,22 @@ func (c *Client) Implementation(ctx context.Context, uri string, position Positi
 		return nil, fmt.Errorf("implementation request failed: %w", err)
 	}
 
-	// Handle both single Location and []Location responses
-	var locations []Location
-	if err := json.Unmarshal(result, &locations); err != nil {
-		var singleLocation Location
-		if err := json.Unmarshal(result, &singleLocation); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal implementation result: %w", err)
-		}
-		locations = []Location{singleLocation}
+	locations, err := parseLocations(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal implementation result: %w", err)
 	}
 
 	return locations, nil
 }
 
 func (c *Client) DocumentSymbols(ctx context.Context, uri string) ([]DocumentSymbol, error) {
+	// Only hold the lock for the state check so that symbol requests for
+	// several files can be in flight at once
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	initialized := c.initialized
+	c.mu.Unlock()
 
-	if !c.initialized {
+	if !initialized {
 		return nil, fmt.Errorf("client not initialized")
 	}
 
@@ -426,7 +774,7 @@ func (c *Client) DocumentSymbols(ctx context.Context, uri string) ([]DocumentSym
 	return result, nil
 }
 
-func (c *Client) DocumentFormatting(ctx context.Context, uri string) ([]TextEdit, error) {
+func (c *Client) DocumentFormatting(ctx context.Context, uri string, options FormattingOptions) ([]TextEdit, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -436,10 +784,7 @@ func (c *Client) DocumentFormatting(ctx context.Context, uri string) ([]TextEdit
 
 	params := DocumentFormattingParams{
 		TextDocument: TextDocumentIdentifier{URI: uri},
-		Options: FormattingOptions{
-			TabSize:      4,
-			InsertSpaces: false, // Use tabs for Go
-		},
+		Options:      options,
 	}
 
 	var edits []TextEdit
@@ -475,6 +820,32 @@ func (c *Client) CodeActionForRange(ctx context.Context, uri string, r Range) ([
 	return actions, nil
 }
 
+// QuickFixes returns the quick fix code actions gopls offers for a diagnostic
+func (c *Client) QuickFixes(ctx context.Context, uri string, diagnostic Diagnostic) ([]CodeAction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.initialized {
+		return nil, fmt.Errorf("client not initialized")
+	}
+
+	params := CodeActionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Range:        diagnostic.Range,
+		Context: CodeActionContext{
+			Diagnostics: []Diagnostic{diagnostic},
+			Only:        []CodeActionKind{CodeActionKindQuickFix},
+		},
+	}
+
+	var actions []CodeAction
+	if err := c.conn.Call(ctx, "textDocument/codeAction", params, &actions); err != nil {
+		return nil, fmt.Errorf("code action request failed: %w", err)
+	}
+
+	return actions, nil
+}
+
 func (c *Client) WorkspaceSymbol(ctx context.Context, query string) ([]SymbolInformation, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -545,7 +916,14 @@ func (c *Client) OrganizeImports(ctx context.Context, uri string) ([]TextEdit, e
 
 	// Extract edits from the first organize imports action
 	for _, action := range result {
-		if action.Kind == CodeActionKindSourceOrganizeImports && action.Edit != nil {
+		if action.Kind != CodeActionKindSourceOrganizeImports {
+			continue
+		}
+		action, err := c.resolveCodeAction(ctx, action)
+		if err != nil {
+			return nil, err
+		}
+		if action.Edit != nil {
 			for _, edits := range action.Edit.Changes {
 				return edits, nil
 			}
@@ -554,3 +932,30 @@ func (c *Client) OrganizeImports(ctx context.Context, uri string) ([]TextEdit, e
 
 	return nil, nil
 }
+
+// ResolveCodeAction fills in the edit of a code action the server returned
+// without one, if it supports codeAction/resolve. Actions that have an
+// edit, or cannot be resolved, are returned as they are.
+func (c *Client) ResolveCodeAction(ctx context.Context, action CodeAction) (CodeAction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.initialized {
+		return action, fmt.Errorf("client not initialized")
+	}
+
+	return c.resolveCodeAction(ctx, action)
+}
+
+func (c *Client) resolveCodeAction(ctx context.Context, action CodeAction) (CodeAction, error) {
+	if action.Edit != nil || len(action.Data) == 0 || !c.capabilities.CodeActionProvider.ResolveProvider {
+		return action, nil
+	}
+
+	var resolved CodeAction
+	if err := c.conn.Call(ctx, "codeAction/resolve", action, &resolved); err != nil {
+		return action, fmt.Errorf("codeAction/resolve request failed for %q: %w", action.Title, err)
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,60 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+)
+
+// ConfigurationParams is the request gopls sends to pull its settings
+type ConfigurationParams struct {
+	Items []ConfigurationItem `json:"items"`
+}
+
+type ConfigurationItem struct {
+	ScopeURI string `json:"scopeUri,omitempty"`
+	Section  string `json:"section,omitempty"`
+}
+
+type DidChangeConfigurationParams struct {
+	Settings interface{} `json:"settings"`
+}
+
+func (h *serverHandler) setSettings(settings map[string]interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.settings = settings
+}
+
+// configuration answers a workspace/configuration request. gopls asks for
+// the "gopls" section once per workspace folder; every folder gets the same
+// settings.
+func (h *serverHandler) configuration(params ConfigurationParams) []interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make([]interface{}, len(params.Items))
+	for i, item := range params.Items {
+		if item.Section == "gopls" && h.settings != nil {
+			result[i] = h.settings
+		}
+	}
+	return result
+}
+
+// DidChangeConfiguration replaces the gopls settings sent at initialization
+// and tells gopls to pull them again, which reloads the workspace
+func (c *Client) DidChangeConfiguration(ctx context.Context, settings map[string]interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.initialized {
+		return fmt.Errorf("client not initialized")
+	}
+
+	c.handler.setSettings(settings)
+	params := DidChangeConfigurationParams{Settings: settings}
+	if err := c.conn.Notify(ctx, "workspace/didChangeConfiguration", params); err != nil {
+		return fmt.Errorf("didChangeConfiguration notification failed: %w", err)
+	}
+	return nil
+}
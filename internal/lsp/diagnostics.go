@@ -0,0 +1,203 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// serverHandler handles notifications from gopls, keeping the latest
+// diagnostics published for each URI along with a version that counts the
+// publications, and the work gopls reports as in progress
+type serverHandler struct {
+	mu          sync.Mutex
+	diagnostics map[string][]Diagnostic
+	versions    map[string]int
+	updated     time.Time
+	checkpoints map[string]DiagnosticsSnapshot
+	work        map[string]string
+	settings    map[string]interface{}
+}
+
+func newServerHandler() *serverHandler {
+	return &serverHandler{
+		diagnostics: make(map[string][]Diagnostic),
+		versions:    make(map[string]int),
+		checkpoints: make(map[string]DiagnosticsSnapshot),
+		work:        make(map[string]string),
+	}
+}
+
+func (h *serverHandler) progress(params ProgressParams) {
+	var value WorkDoneProgress
+	if json.Unmarshal(params.Value, &value) != nil {
+		return
+	}
+	token := fmt.Sprint(params.Token)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch value.Kind {
+	case "begin":
+		h.work[token] = value.Title
+	case "end":
+		delete(h.work, token)
+	}
+	h.updated = time.Now()
+}
+
+func (h *serverHandler) publish(params PublishDiagnosticsParams) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(params.Diagnostics) == 0 {
+		delete(h.diagnostics, params.URI)
+	} else {
+		h.diagnostics[params.URI] = params.Diagnostics
+	}
+	h.versions[params.URI]++
+	h.updated = time.Now()
+}
+
+func (h *serverHandler) get(uri string) []Diagnostic {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.diagnostics[uri]
+}
+
+func (h *serverHandler) snapshot() DiagnosticsSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snapshot := DiagnosticsSnapshot{
+		Diagnostics: make(map[string][]Diagnostic, len(h.diagnostics)),
+		Versions:    make(map[string]int, len(h.versions)),
+		Taken:       time.Now(),
+	}
+	for uri, diagnostics := range h.diagnostics {
+		snapshot.Diagnostics[uri] = append([]Diagnostic(nil), diagnostics...)
+	}
+	for uri, version := range h.versions {
+		snapshot.Versions[uri] = version
+	}
+	return snapshot
+}
+
+// DiagnosticsSnapshot is a copy of the diagnostics gopls had published for
+// every URI at a point in time
+type DiagnosticsSnapshot struct {
+	Diagnostics map[string][]Diagnostic
+	// Versions counts the diagnostics publications received for each URI
+	Versions map[string]int
+	Taken    time.Time
+}
+
+// DiagnosticsVersion returns how many times gopls has published diagnostics
+// for uri, so callers can tell whether they changed since they last looked
+func (c *Client) DiagnosticsVersion(uri string) int {
+	c.handler.mu.Lock()
+	defer c.handler.mu.Unlock()
+
+	return c.handler.versions[uri]
+}
+
+// AllDiagnostics returns the current diagnostics of every URI
+func (c *Client) AllDiagnostics() DiagnosticsSnapshot {
+	return c.handler.snapshot()
+}
+
+// SaveDiagnosticsCheckpoint records the current diagnostics under name,
+// replacing any earlier checkpoint with that name
+func (c *Client) SaveDiagnosticsCheckpoint(name string) DiagnosticsSnapshot {
+	snapshot := c.handler.snapshot()
+
+	c.handler.mu.Lock()
+	defer c.handler.mu.Unlock()
+	c.handler.checkpoints[name] = snapshot
+	return snapshot
+}
+
+// DiagnosticsCheckpoint returns the diagnostics saved under name
+func (c *Client) DiagnosticsCheckpoint(name string) (DiagnosticsSnapshot, error) {
+	c.handler.mu.Lock()
+	defer c.handler.mu.Unlock()
+
+	snapshot, ok := c.handler.checkpoints[name]
+	if !ok {
+		return DiagnosticsSnapshot{}, fmt.Errorf("no diagnostics checkpoint named %q", name)
+	}
+	return snapshot, nil
+}
+
+// DiagnosticsCheckpoints returns every saved checkpoint by name
+func (c *Client) DiagnosticsCheckpoints() map[string]DiagnosticsSnapshot {
+	c.handler.mu.Lock()
+	defer c.handler.mu.Unlock()
+
+	checkpoints := make(map[string]DiagnosticsSnapshot, len(c.handler.checkpoints))
+	for name, snapshot := range c.handler.checkpoints {
+		checkpoints[name] = snapshot
+	}
+	return checkpoints
+}
+
+// RestoreDiagnosticsCheckpoints adds checkpoints saved by another client, so
+// they survive a restart of gopls
+func (c *Client) RestoreDiagnosticsCheckpoints(checkpoints map[string]DiagnosticsSnapshot) {
+	c.handler.mu.Lock()
+	defer c.handler.mu.Unlock()
+
+	for name, snapshot := range checkpoints {
+		c.handler.checkpoints[name] = snapshot
+	}
+}
+
+// WorkInProgress returns the titles of the work gopls has reported as begun
+// but not yet ended, such as loading packages
+func (c *Client) WorkInProgress() []string {
+	c.handler.mu.Lock()
+	defer c.handler.mu.Unlock()
+
+	titles := make([]string, 0, len(c.handler.work))
+	for _, title := range c.handler.work {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+// WaitForDiagnostics waits until gopls has no work in progress and has
+// published no diagnostics or progress for the quiet period, or until ctx is
+// done
+func (c *Client) WaitForDiagnostics(ctx context.Context, quiet time.Duration) error {
+	if quiet <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(quiet / 4)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		c.handler.mu.Lock()
+		last := c.handler.updated
+		busy := len(c.handler.work) > 0
+		c.handler.mu.Unlock()
+		if last.Before(start) {
+			last = start
+		}
+		if !busy && time.Since(last) >= quiet {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,326 @@
+// Package fake is an in-process stand-in for gopls that answers the subset
+// of LSP the client uses from the syntax of the workspace alone. It needs
+// neither gopls nor a Go toolchain, so it backs end-to-end tests of the
+// tool handlers and the -mock mode for demos. Identifiers are resolved
+// without type checking: within a package by scope and name, across
+// packages of the workspace module by import path.
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// Server is a fake gopls session
+type Server struct {
+	mu       sync.Mutex
+	root     string
+	module   string
+	overlays map[string]string
+	shutdown bool
+}
+
+// Serve answers LSP requests read from rwc until it is closed, such as one
+// end of a net.Pipe whose other end is given to lsp.NewStreamClient
+func Serve(rwc io.ReadWriteCloser) *jsonrpc2.Conn {
+	s := &Server{overlays: make(map[string]string)}
+	stream := jsonrpc2.NewBufferedStream(rwc, jsonrpc2.VSCodeObjectCodec{})
+	return jsonrpc2.NewConn(context.Background(), stream, jsonrpc2.HandlerWithError(s.handle).SuppressErrClosed())
+}
+
+func (s *Server) handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+	s.mu.Lock()
+	shutdown := s.shutdown
+	s.mu.Unlock()
+	if shutdown && req.Method != "exit" {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidRequest, Message: "server is shut down"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		var params lsp.InitializeParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		return s.initialize(params)
+	case "initialized":
+		// Like gopls after loading the workspace, report the files with
+		// errors
+		s.mu.Lock()
+		root := s.root
+		s.mu.Unlock()
+		paths, _ := astscan.Paths(root, astscan.Options{IncludeTests: true})
+		for _, path := range paths {
+			if diagnostics := s.diagnostics(path); len(diagnostics) > 0 {
+				uri, _ := utils.PathToURI(path)
+				params := lsp.PublishDiagnosticsParams{URI: uri, Diagnostics: diagnostics}
+				if err := conn.Notify(ctx, "textDocument/publishDiagnostics", params); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return nil, nil
+	case "$/cancelRequest", "workspace/didChangeConfiguration",
+		"workspace/didChangeWorkspaceFolders", "workspace/didRenameFiles", "textDocument/didSave":
+		return nil, nil
+	case "shutdown":
+		s.mu.Lock()
+		s.shutdown = true
+		s.mu.Unlock()
+		return nil, nil
+	case "exit":
+		return nil, conn.Close()
+
+	case "textDocument/didOpen":
+		var params lsp.DidOpenTextDocumentParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		s.setOverlay(params.TextDocument.URI, params.TextDocument.Text, true)
+		return nil, s.publish(ctx, conn, params.TextDocument.URI)
+	case "textDocument/didChange":
+		var params lsp.DidChangeTextDocumentParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		// Only full content changes are advertised, so the last wins
+		if n := len(params.ContentChanges); n > 0 {
+			s.setOverlay(params.TextDocument.URI, params.ContentChanges[n-1].Text, true)
+		}
+		return nil, s.publish(ctx, conn, params.TextDocument.URI)
+	case "textDocument/didClose":
+		var params lsp.DidCloseTextDocumentParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		s.setOverlay(params.TextDocument.URI, "", false)
+		return nil, s.publish(ctx, conn, params.TextDocument.URI)
+	case "workspace/didChangeWatchedFiles":
+		var params lsp.DidChangeWatchedFilesParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		for _, change := range params.Changes {
+			if err := s.publish(ctx, conn, change.URI); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+
+	case "textDocument/definition":
+		var params lsp.DefinitionParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(params.TextDocumentPositionParams)
+	case "textDocument/references":
+		var params lsp.ReferenceParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		return s.references(params.TextDocumentPositionParams, params.Context.IncludeDeclaration)
+	case "textDocument/hover":
+		var params lsp.HoverParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(params.TextDocumentPositionParams)
+	case "textDocument/prepareRename":
+		var params lsp.PrepareRenameParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		return s.prepareRename(params.TextDocumentPositionParams)
+	case "textDocument/rename":
+		var params lsp.RenameParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		return s.rename(params.TextDocumentPositionParams, params.NewName)
+	case "textDocument/implementation":
+		var params lsp.ImplementationParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		return s.implementation(params.TextDocumentPositionParams)
+	case "textDocument/documentSymbol":
+		var params lsp.DocumentSymbolParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		return s.documentSymbols(params.TextDocument.URI)
+	case "workspace/symbol":
+		var params lsp.WorkspaceSymbolParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		return s.workspaceSymbols(params.Query)
+	case "textDocument/formatting":
+		var params lsp.DocumentFormattingParams
+		if err := decode(req, &params); err != nil {
+			return nil, err
+		}
+		return s.format(params.TextDocument.URI)
+	case "textDocument/codeAction":
+		// Organizing imports and quick fixes need type information
+		return []lsp.CodeAction{}, nil
+	case "workspace/willRenameFiles":
+		return nil, nil
+	}
+
+	if req.Notif {
+		return nil, nil
+	}
+	return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: fmt.Sprintf("method not supported by the fake gopls: %s", req.Method)}
+}
+
+// decode unmarshals the params of req into v
+func decode(req *jsonrpc2.Request, v interface{}) error {
+	if req.Params == nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: fmt.Sprintf("%s: missing params", req.Method)}
+	}
+	if err := json.Unmarshal(*req.Params, v); err != nil {
+		return &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: fmt.Sprintf("%s: %v", req.Method, err)}
+	}
+	return nil
+}
+
+func (s *Server) initialize(params lsp.InitializeParams) (lsp.InitializeResult, error) {
+	root, err := utils.URIToPath(params.RootURI)
+	if err != nil {
+		return lsp.InitializeResult{}, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: fmt.Sprintf("invalid rootUri: %v", err)}
+	}
+
+	s.mu.Lock()
+	s.root = root
+	s.module = modulePath(root)
+	s.mu.Unlock()
+
+	return lsp.InitializeResult{Capabilities: lsp.ServerCapabilities{
+		TextDocumentSync:           lsp.TextDocumentSyncOptions{OpenClose: true, Change: lsp.TDSKFull},
+		HoverProvider:              true,
+		DefinitionProvider:         true,
+		ReferencesProvider:         true,
+		DocumentSymbolProvider:     true,
+		WorkspaceSymbolProvider:    true,
+		CodeActionProvider:         lsp.CodeActionProvider{Enabled: true},
+		DocumentFormattingProvider: true,
+		RenameProvider:             lsp.RenameProvider{Enabled: true, RenameOptions: lsp.RenameOptions{PrepareProvider: true}},
+		ImplementationProvider:     true,
+	}}, nil
+}
+
+// modulePath returns the module path declared by root/go.mod, if any
+func modulePath(root string) string {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// setOverlay sets the content of an open document, or drops it when open
+// is false
+func (s *Server) setOverlay(uri, content string, open bool) {
+	path, err := utils.URIToPath(uri)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if open {
+		s.overlays[path] = content
+	} else {
+		delete(s.overlays, path)
+	}
+}
+
+// read returns the content of path: the open document's, or the file's on
+// disk
+func (s *Server) read(path string) ([]byte, error) {
+	s.mu.Lock()
+	content, ok := s.overlays[path]
+	s.mu.Unlock()
+	if ok {
+		return []byte(content), nil
+	}
+	return os.ReadFile(path)
+}
+
+// publish sends the syntax errors of the file at uri as its diagnostics,
+// or none when the file is gone
+func (s *Server) publish(ctx context.Context, conn *jsonrpc2.Conn, uri string) error {
+	path, err := utils.URIToPath(uri)
+	if err != nil || !strings.HasSuffix(path, ".go") {
+		return nil
+	}
+	params := lsp.PublishDiagnosticsParams{URI: uri, Diagnostics: s.diagnostics(path)}
+	return conn.Notify(ctx, "textDocument/publishDiagnostics", params)
+}
+
+// diagnostics returns the syntax errors of the file at path
+func (s *Server) diagnostics(path string) []lsp.Diagnostic {
+	diagnostics := []lsp.Diagnostic{}
+	src, err := s.read(path)
+	if err != nil {
+		return diagnostics
+	}
+	_, err = parser.ParseFile(token.NewFileSet(), path, src, parser.SkipObjectResolution)
+	if list, ok := err.(scanner.ErrorList); ok {
+		for _, e := range list {
+			pos := lsp.Position{Line: e.Pos.Line - 1, Character: e.Pos.Column - 1}
+			diagnostics = append(diagnostics, lsp.Diagnostic{
+				Range:    lsp.Range{Start: pos, End: pos},
+				Severity: lsp.DiagnosticSeverityError,
+				Source:   "syntax",
+				Message:  e.Msg,
+			})
+		}
+	}
+	return diagnostics
+}
+
+// format returns the edit replacing the document with its gofmt'ed content,
+// or no edits when it is formatted already
+func (s *Server) format(uri string) ([]lsp.TextEdit, error) {
+	path, err := utils.URIToPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	src, err := s.read(path)
+	if err != nil {
+		return nil, err
+	}
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("cannot format %s: %w", filepath.Base(path), err)
+	}
+	if string(formatted) == string(src) {
+		return []lsp.TextEdit{}, nil
+	}
+	end, err := utils.OffsetToPosition(string(src), len(src))
+	if err != nil {
+		return nil, err
+	}
+	return []lsp.TextEdit{{Range: lsp.Range{End: end}, NewText: string(formatted)}}, nil
+}
@@ -0,0 +1,64 @@
+package fake
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func TestDefinitionAcrossPackages(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module example.com/app\n\ngo 1.21\n",
+		"util/util.go": "package util\n\nfunc Double(n int) int { return 2 * n }\n",
+		"main.go":      "package main\n\nimport \"example.com/app/util\"\n\nfunc main() { util.Double(1) }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	serverConn, clientConn := net.Pipe()
+	Serve(serverConn)
+	client := lsp.NewStreamClient(clientConn)
+	ctx := context.Background()
+	rootURI, _ := utils.PathToURI(root)
+	if err := client.Initialize(ctx, rootURI, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Shutdown(ctx)
+
+	main := filepath.Join(root, "main.go")
+	uri, _ := utils.PathToURI(main)
+	if err := client.OpenDocument(ctx, uri, files["main.go"]); err != nil {
+		t.Fatal(err)
+	}
+	// Double in util.Double
+	locations, err := client.Definition(ctx, uri, lsp.Position{Line: 4, Character: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locations) != 1 {
+		t.Fatalf("got %d locations, want 1: %+v", len(locations), locations)
+	}
+	path, err := utils.URIToPath(locations[0].URI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(root, "util", "util.go"); path != want {
+		t.Errorf("definition in %s, want %s", path, want)
+	}
+	if start := locations[0].Range.Start; start.Line != 2 || start.Character != 5 {
+		t.Errorf("definition at %d:%d, want 2:5", start.Line, start.Character)
+	}
+}
@@ -0,0 +1,827 @@
+package fake
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// maxWorkspaceSymbols caps workspace/symbol results, as gopls does
+const maxWorkspaceSymbols = 100
+
+// file is a parsed Go file, with the content of its open document if any
+type file struct {
+	path string
+	src  []byte
+	ast  *ast.File
+}
+
+// decl is the identifier declaring a name, in the file it appears in
+type decl struct {
+	file  *file
+	ident *ast.Ident
+}
+
+func (d decl) key() string {
+	return d.file.path + ":" + strconv.Itoa(int(d.ident.Pos()))
+}
+
+// view is the parsed workspace as one request sees it. Files are parsed
+// on first use, into one file set so positions are comparable.
+type view struct {
+	s    *Server
+	fset *token.FileSet
+	dirs map[string][]*file
+}
+
+func (s *Server) newView() *view {
+	return &view{s: s, fset: token.NewFileSet(), dirs: make(map[string][]*file)}
+}
+
+// dir returns the Go files directly in dir that parse
+func (v *view) dir(dir string) []*file {
+	if files, ok := v.dirs[dir]; ok {
+		return files
+	}
+	paths, _ := astscan.Paths(dir, astscan.Options{IncludeTests: true, SingleDir: true, IncludeVendor: true})
+	v.s.mu.Lock()
+	for path := range v.s.overlays {
+		if filepath.Dir(path) == dir && strings.HasSuffix(path, ".go") {
+			if _, err := os.Stat(path); err != nil {
+				paths = append(paths, path) // staged, not on disk yet
+			}
+		}
+	}
+	v.s.mu.Unlock()
+	sort.Strings(paths)
+
+	var files []*file
+	for _, path := range paths {
+		src, err := v.s.read(path)
+		if err != nil {
+			continue
+		}
+		parsed, err := parser.ParseFile(v.fset, path, src, parser.ParseComments|parser.AllErrors)
+		if parsed == nil || (err != nil && parsed.Name == nil) {
+			continue
+		}
+		files = append(files, &file{path: path, src: src, ast: parsed})
+	}
+	v.dirs[dir] = files
+	return files
+}
+
+// file returns the parsed file at uri
+func (v *view) file(uri string) (*file, error) {
+	path, err := utils.URIToPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range v.dir(filepath.Dir(path)) {
+		if f.path == path {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("no Go file %s", path)
+}
+
+// packageFiles returns the files of the package f belongs to
+func (v *view) packageFiles(f *file) []*file {
+	var files []*file
+	for _, other := range v.dir(filepath.Dir(f.path)) {
+		if other.ast.Name.Name == f.ast.Name.Name {
+			files = append(files, other)
+		}
+	}
+	return files
+}
+
+// importedFiles returns the non-test files of the workspace package with
+// importPath, or nil if it is not one
+func (v *view) importedFiles(importPath string) []*file {
+	v.s.mu.Lock()
+	root, module := v.s.root, v.s.module
+	v.s.mu.Unlock()
+	if module == "" || (importPath != module && !strings.HasPrefix(importPath, module+"/")) {
+		return nil
+	}
+	dir := filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(strings.TrimPrefix(importPath, module), "/")))
+	var files []*file
+	for _, f := range v.dir(dir) {
+		if !strings.HasSuffix(f.path, "_test.go") {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// importPath returns the import path of the package in the directory of f
+func (v *view) importPath(f *file) string {
+	v.s.mu.Lock()
+	root, module := v.s.root, v.s.module
+	v.s.mu.Unlock()
+	rel, err := filepath.Rel(root, filepath.Dir(f.path))
+	if module == "" || err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	if rel == "." {
+		return module
+	}
+	return module + "/" + filepath.ToSlash(rel)
+}
+
+// workspaceFiles returns every Go file under the workspace root
+func (v *view) workspaceFiles() []*file {
+	v.s.mu.Lock()
+	root := v.s.root
+	v.s.mu.Unlock()
+	paths, _ := astscan.Paths(root, astscan.Options{IncludeTests: true})
+	seen := make(map[string]bool)
+	var files []*file
+	for _, path := range paths {
+		if dir := filepath.Dir(path); !seen[dir] {
+			seen[dir] = true
+			files = append(files, v.dir(dir)...)
+		}
+	}
+	return files
+}
+
+// offset converts an LSP position in f to a token.Pos
+func (v *view) offset(f *file, position lsp.Position) (token.Pos, error) {
+	offset, err := utils.CalculateOffset(string(f.src), position)
+	if err != nil {
+		return token.NoPos, err
+	}
+	return v.fset.File(f.ast.Pos()).Pos(offset), nil
+}
+
+// location returns the LSP location of node in f
+func (v *view) location(f *file, node ast.Node) lsp.Location {
+	uri, _ := utils.PathToURI(f.path)
+	return lsp.Location{URI: uri, Range: v.span(node)}
+}
+
+func (v *view) span(node ast.Node) lsp.Range {
+	start, end := v.fset.Position(node.Pos()), v.fset.Position(node.End())
+	return lsp.Range{
+		Start: lsp.Position{Line: start.Line - 1, Character: start.Column - 1},
+		End:   lsp.Position{Line: end.Line - 1, Character: end.Column - 1},
+	}
+}
+
+// identAt returns the identifier at, or ending at, position in the file at
+// uri
+func (v *view) identAt(params lsp.TextDocumentPositionParams) (*file, *ast.Ident, error) {
+	f, err := v.file(params.TextDocument.URI)
+	if err != nil {
+		return nil, nil, err
+	}
+	pos, err := v.offset(f, params.Position)
+	if err != nil {
+		return nil, nil, err
+	}
+	var found *ast.Ident
+	ast.Inspect(f.ast, func(n ast.Node) bool {
+		if found != nil || n == nil || pos < n.Pos() || pos > n.End() {
+			return false
+		}
+		if ident, ok := n.(*ast.Ident); ok {
+			found = ident
+		}
+		return true
+	})
+	if found == nil {
+		return nil, nil, fmt.Errorf("no identifier found")
+	}
+	return f, found, nil
+}
+
+// resolve returns the declaration of the name ident refers to. Without
+// types, a selector of a value resolves to any field or method of the
+// package with its name.
+func (v *view) resolve(f *file, ident *ast.Ident) (decl, bool) {
+	path := astscan.PathTo(f.ast, ident.Pos())
+	var parent ast.Node
+	if len(path) > 1 {
+		parent = path[len(path)-2]
+	}
+
+	switch parent := parent.(type) {
+	case *ast.FuncDecl:
+		if parent.Name == ident {
+			return decl{f, ident}, true
+		}
+	case *ast.Field:
+		if len(path) > 3 {
+			switch path[len(path)-4].(type) {
+			case *ast.StructType, *ast.InterfaceType:
+				return decl{f, ident}, true
+			}
+		}
+	case *ast.SelectorExpr:
+		if parent.Sel == ident {
+			if x, ok := parent.X.(*ast.Ident); ok && x.Obj == nil {
+				if importPath := importOf(f, x.Name); importPath != "" {
+					return packageLevel(v.importedFiles(importPath), ident.Name)
+				}
+			}
+			return v.member(f, ident.Name)
+		}
+	}
+
+	if ident.Obj != nil {
+		if node, ok := ident.Obj.Decl.(ast.Node); ok {
+			var found *ast.Ident
+			ast.Inspect(node, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && found == nil && id.Name == ident.Name && id.Obj == ident.Obj {
+					found = id
+				}
+				return found == nil
+			})
+			if found != nil {
+				return decl{f, found}, true
+			}
+		}
+	}
+	if d, ok := packageLevel(v.packageFiles(f), ident.Name); ok {
+		return d, true
+	}
+	return v.member(f, ident.Name)
+}
+
+// member finds a field or method named name in the package of f, or else
+// in the workspace packages f imports
+func (v *view) member(f *file, name string) (decl, bool) {
+	if d, ok := member(v.packageFiles(f), name); ok {
+		return d, true
+	}
+	var imported []*file
+	for _, spec := range f.ast.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		imported = append(imported, v.importedFiles(path)...)
+	}
+	return member(imported, name)
+}
+
+// importOf returns the path f imports under name
+func importOf(f *file, name string) string {
+	for _, spec := range f.ast.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		local := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			local = spec.Name.Name
+		}
+		if local == name {
+			return path
+		}
+	}
+	return ""
+}
+
+// packageLevel finds the package-level declaration of name in files
+func packageLevel(files []*file, name string) (decl, bool) {
+	for _, f := range files {
+		for _, d := range f.ast.Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil && d.Name.Name == name {
+					return decl{f, d.Name}, true
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						if spec.Name.Name == name {
+							return decl{f, spec.Name}, true
+						}
+					case *ast.ValueSpec:
+						for _, id := range spec.Names {
+							if id.Name == name {
+								return decl{f, id}, true
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return decl{}, false
+}
+
+// member finds a method, struct field or interface method named name in
+// files
+func member(files []*file, name string) (decl, bool) {
+	for _, f := range files {
+		for _, d := range f.ast.Decls {
+			if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv != nil && fn.Name.Name == name {
+				return decl{f, fn.Name}, true
+			}
+		}
+	}
+	for _, f := range files {
+		var found *ast.Ident
+		ast.Inspect(f.ast, func(n ast.Node) bool {
+			var fields *ast.FieldList
+			switch n := n.(type) {
+			case *ast.StructType:
+				fields = n.Fields
+			case *ast.InterfaceType:
+				fields = n.Methods
+			}
+			if fields != nil {
+				for _, field := range fields.List {
+					for _, id := range field.Names {
+						if id.Name == name && found == nil {
+							found = id
+						}
+					}
+				}
+			}
+			return found == nil
+		})
+		if found != nil {
+			return decl{f, found}, true
+		}
+	}
+	return decl{}, false
+}
+
+func (s *Server) definition(params lsp.TextDocumentPositionParams) ([]lsp.Location, error) {
+	v := s.newView()
+	f, ident, err := v.identAt(params)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := v.resolve(f, ident)
+	if !ok {
+		return []lsp.Location{}, nil
+	}
+	return []lsp.Location{v.location(d.file, d.ident)}, nil
+}
+
+// referencesTo returns the identifiers that refer to target, in file order
+func (v *view) referencesTo(target decl, includeDeclaration bool) []decl {
+	files := v.packageFiles(target.file)
+	if isLocal(target) {
+		files = []*file{target.file}
+	} else if target.ident.IsExported() {
+		if importPath := v.importPath(target.file); importPath != "" {
+			for _, f := range v.workspaceFiles() {
+				if f.ast.Name.Name != target.file.ast.Name.Name || filepath.Dir(f.path) != filepath.Dir(target.file.path) {
+					for _, spec := range f.ast.Imports {
+						if path, _ := strconv.Unquote(spec.Path.Value); path == importPath {
+							files = append(files, f)
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+
+	var refs []decl
+	for _, f := range files {
+		ast.Inspect(f.ast, func(n ast.Node) bool {
+			ident, ok := n.(*ast.Ident)
+			if !ok || ident.Name != target.ident.Name {
+				return true
+			}
+			if !includeDeclaration && ident == target.ident {
+				return true
+			}
+			if d, ok := v.resolve(f, ident); ok && d.key() == target.key() {
+				refs = append(refs, decl{f, ident})
+			}
+			return true
+		})
+	}
+	return refs
+}
+
+// isLocal reports whether d is declared inside a function
+func isLocal(d decl) bool {
+	for _, n := range astscan.PathTo(d.file.ast, d.ident.Pos()) {
+		if fn, ok := n.(*ast.FuncDecl); ok {
+			// Receivers, parameters and everything in the body
+			return fn.Name != d.ident
+		}
+	}
+	return false
+}
+
+func (s *Server) references(params lsp.TextDocumentPositionParams, includeDeclaration bool) ([]lsp.Location, error) {
+	v := s.newView()
+	f, ident, err := v.identAt(params)
+	if err != nil {
+		return nil, err
+	}
+	target, ok := v.resolve(f, ident)
+	if !ok {
+		return []lsp.Location{}, nil
+	}
+	locations := []lsp.Location{}
+	for _, ref := range v.referencesTo(target, includeDeclaration) {
+		locations = append(locations, v.location(ref.file, ref.ident))
+	}
+	return locations, nil
+}
+
+func (s *Server) prepareRename(params lsp.TextDocumentPositionParams) (*lsp.PrepareRenameResult, error) {
+	v := s.newView()
+	f, ident, err := v.identAt(params)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.resolve(f, ident); !ok {
+		return nil, fmt.Errorf("cannot rename %s: no declaration found in the workspace", ident.Name)
+	}
+	return &lsp.PrepareRenameResult{Range: v.span(ident), Placeholder: ident.Name}, nil
+}
+
+func (s *Server) rename(params lsp.TextDocumentPositionParams, newName string) (*lsp.WorkspaceEdit, error) {
+	if !token.IsIdentifier(newName) {
+		return nil, fmt.Errorf("invalid identifier to rename: %q", newName)
+	}
+	v := s.newView()
+	f, ident, err := v.identAt(params)
+	if err != nil {
+		return nil, err
+	}
+	target, ok := v.resolve(f, ident)
+	if !ok {
+		return nil, fmt.Errorf("cannot rename %s: no declaration found in the workspace", ident.Name)
+	}
+	edit := &lsp.WorkspaceEdit{Changes: make(map[string][]lsp.TextEdit)}
+	for _, ref := range v.referencesTo(target, true) {
+		location := v.location(ref.file, ref.ident)
+		edit.Changes[location.URI] = append(edit.Changes[location.URI], lsp.TextEdit{Range: location.Range, NewText: newName})
+	}
+	return edit, nil
+}
+
+func (s *Server) hover(params lsp.TextDocumentPositionParams) (*lsp.Hover, error) {
+	v := s.newView()
+	f, ident, err := v.identAt(params)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := v.resolve(f, ident)
+	if !ok {
+		return nil, nil
+	}
+	signature, doc := v.describe(d)
+	value := "```go\n" + signature + "\n```"
+	if doc != "" {
+		value += "\n\n" + strings.TrimSpace(doc)
+	}
+	r := v.span(ident)
+	return &lsp.Hover{Contents: lsp.MarkupContent{Kind: "markdown", Value: value}, Range: &r}, nil
+}
+
+// describe returns the declaration of d as Go source, without function
+// bodies, and its doc comment
+func (v *view) describe(d decl) (string, string) {
+	path := astscan.PathTo(d.file.ast, d.ident.Pos())
+	for i := len(path) - 1; i >= 0; i-- {
+		switch n := path[i].(type) {
+		case *ast.FuncDecl:
+			fn := *n
+			fn.Body, fn.Doc = nil, nil
+			return v.print(&fn), n.Doc.Text()
+		case *ast.TypeSpec:
+			doc := n.Doc.Text()
+			if gen, ok := path[i-1].(*ast.GenDecl); ok && doc == "" {
+				doc = gen.Doc.Text()
+			}
+			return "type " + v.print(n), doc
+		case *ast.ValueSpec:
+			gen, _ := path[i-1].(*ast.GenDecl)
+			doc := n.Doc.Text()
+			keyword := "var"
+			if gen != nil {
+				keyword = gen.Tok.String()
+				if doc == "" {
+					doc = gen.Doc.Text()
+				}
+			}
+			return keyword + " " + v.print(n), doc
+		case *ast.Field:
+			if fn, ok := n.Type.(*ast.FuncType); ok {
+				return "func " + d.ident.Name + strings.TrimPrefix(v.print(fn), "func"), n.Doc.Text()
+			}
+			if i >= 2 {
+				if _, ok := path[i-2].(*ast.StructType); ok {
+					return "field " + d.ident.Name + " " + v.print(n.Type), n.Doc.Text()
+				}
+			}
+			return "var " + d.ident.Name + " " + v.print(n.Type), ""
+		case *ast.AssignStmt:
+			for j, lhs := range n.Lhs {
+				if lhs == d.ident && len(n.Lhs) == len(n.Rhs) {
+					return "var " + d.ident.Name + " = " + v.print(n.Rhs[j]), ""
+				}
+			}
+			return "var " + d.ident.Name, ""
+		}
+	}
+	return d.ident.Name, ""
+}
+
+func (v *view) print(node ast.Node) string {
+	var b bytes.Buffer
+	if err := printer.Fprint(&b, v.fset, node); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+func (s *Server) implementation(params lsp.TextDocumentPositionParams) ([]lsp.Location, error) {
+	v := s.newView()
+	f, ident, err := v.identAt(params)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := v.resolve(f, ident)
+	if !ok {
+		return []lsp.Location{}, nil
+	}
+	spec, ok := parentOf(d).(*ast.TypeSpec)
+	if !ok {
+		return []lsp.Location{}, nil
+	}
+	_, isInterface := spec.Type.(*ast.InterfaceType)
+	wanted := v.methodSet(d.file, spec)
+
+	// Without types, a type implements an interface if it declares methods
+	// with the names of the interface's
+	locations := []lsp.Location{}
+	for _, other := range v.workspaceFiles() {
+		for _, gen := range other.ast.Decls {
+			gen, ok := gen.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, candidate := range gen.Specs {
+				candidate := candidate.(*ast.TypeSpec)
+				if candidate == spec {
+					continue
+				}
+				if _, ok := candidate.Type.(*ast.InterfaceType); ok == isInterface {
+					continue
+				}
+				have := v.methodSet(other, candidate)
+				required, provided := wanted, have
+				if !isInterface {
+					required, provided = have, wanted
+				}
+				if len(required) > 0 && subset(required, provided) {
+					locations = append(locations, v.location(other, candidate.Name))
+				}
+			}
+		}
+	}
+	return locations, nil
+}
+
+// parentOf returns the node declaring d.ident
+func parentOf(d decl) ast.Node {
+	path := astscan.PathTo(d.file.ast, d.ident.Pos())
+	if len(path) < 2 {
+		return nil
+	}
+	return path[len(path)-2]
+}
+
+// methodSet returns the names of the methods of the type declared by spec
+// in f: an interface's declared methods or the methods declared in its
+// package with it as receiver
+func (v *view) methodSet(f *file, spec *ast.TypeSpec) map[string]bool {
+	methods := make(map[string]bool)
+	if iface, ok := spec.Type.(*ast.InterfaceType); ok {
+		for _, field := range iface.Methods.List {
+			for _, name := range field.Names {
+				methods[name.Name] = true
+			}
+		}
+		return methods
+	}
+	for _, other := range v.packageFiles(f) {
+		for _, d := range other.ast.Decls {
+			if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv != nil && len(fn.Recv.List) > 0 &&
+				astscan.ReceiverType(fn.Recv.List[0].Type) == spec.Name.Name {
+				methods[fn.Name.Name] = true
+			}
+		}
+	}
+	return methods
+}
+
+func subset(a, b map[string]bool) bool {
+	for name := range a {
+		if !b[name] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) documentSymbols(uri string) ([]lsp.DocumentSymbol, error) {
+	v := s.newView()
+	f, err := v.file(uri)
+	if err != nil {
+		return nil, err
+	}
+	symbols := []lsp.DocumentSymbol{}
+	for _, d := range f.ast.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			name, kind := d.Name.Name, lsp.SymbolKindFunction
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name, kind = "("+v.print(d.Recv.List[0].Type)+")."+name, lsp.SymbolKindMethod
+			}
+			symbols = append(symbols, lsp.DocumentSymbol{
+				Name:           name,
+				Detail:         strings.TrimPrefix(v.print(d.Type), "func"),
+				Kind:           kind,
+				Range:          v.span(d),
+				SelectionRange: v.span(d.Name),
+			})
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					symbols = append(symbols, v.typeSymbol(spec))
+				case *ast.ValueSpec:
+					kind := lsp.SymbolKindVariable
+					if d.Tok == token.CONST {
+						kind = lsp.SymbolKindConstant
+					}
+					for _, name := range spec.Names {
+						symbol := lsp.DocumentSymbol{Name: name.Name, Kind: kind, Range: v.span(spec), SelectionRange: v.span(name)}
+						if spec.Type != nil {
+							symbol.Detail = v.print(spec.Type)
+						}
+						symbols = append(symbols, symbol)
+					}
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// typeSymbol returns the symbol of a type declaration, with its fields or
+// interface methods as children
+func (v *view) typeSymbol(spec *ast.TypeSpec) lsp.DocumentSymbol {
+	symbol := lsp.DocumentSymbol{Name: spec.Name.Name, Kind: lsp.SymbolKindClass, Range: v.span(spec), SelectionRange: v.span(spec.Name)}
+	var fields *ast.FieldList
+	childKind := lsp.SymbolKindField
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		symbol.Kind, symbol.Detail, fields = lsp.SymbolKindStruct, "struct{...}", t.Fields
+	case *ast.InterfaceType:
+		symbol.Kind, symbol.Detail, fields, childKind = lsp.SymbolKindInterface, "interface{...}", t.Methods, lsp.SymbolKindMethod
+	default:
+		symbol.Detail = v.print(spec.Type)
+	}
+	if fields == nil {
+		return symbol
+	}
+	for _, field := range fields.List {
+		names := field.Names
+		if len(names) == 0 {
+			// An embedded field or interface is named after its type
+			if id, ok := embeddedName(field.Type); ok {
+				names = []*ast.Ident{id}
+			}
+		}
+		for _, name := range names {
+			symbol.Children = append(symbol.Children, lsp.DocumentSymbol{
+				Name:           name.Name,
+				Detail:         v.print(field.Type),
+				Kind:           childKind,
+				Range:          v.span(field),
+				SelectionRange: v.span(name),
+			})
+		}
+	}
+	return symbol
+}
+
+func embeddedName(expr ast.Expr) (*ast.Ident, bool) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t, true
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel, true
+	}
+	return nil, false
+}
+
+func (s *Server) workspaceSymbols(query string) ([]lsp.SymbolInformation, error) {
+	v := s.newView()
+	type match struct {
+		symbol lsp.SymbolInformation
+		score  int
+	}
+	var matches []match
+	add := func(f *file, name string, ident *ast.Ident, kind lsp.SymbolKind) {
+		if score := matchScore(name, query); score > 0 {
+			matches = append(matches, match{lsp.SymbolInformation{
+				Name:          name,
+				Kind:          kind,
+				Location:      v.location(f, ident),
+				ContainerName: v.importPath(f),
+			}, score})
+		}
+	}
+
+	for _, f := range v.workspaceFiles() {
+		for _, d := range f.ast.Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				if d.Recv != nil && len(d.Recv.List) > 0 {
+					add(f, astscan.FuncName(d), d.Name, lsp.SymbolKindMethod)
+				} else {
+					add(f, d.Name.Name, d.Name, lsp.SymbolKindFunction)
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						symbol := v.typeSymbol(spec)
+						add(f, spec.Name.Name, spec.Name, symbol.Kind)
+						if st, ok := spec.Type.(*ast.StructType); ok {
+							for _, field := range st.Fields.List {
+								for _, name := range field.Names {
+									add(f, spec.Name.Name+"."+name.Name, name, lsp.SymbolKindField)
+								}
+							}
+						}
+					case *ast.ValueSpec:
+						kind := lsp.SymbolKindVariable
+						if d.Tok == token.CONST {
+							kind = lsp.SymbolKindConstant
+						}
+						for _, name := range spec.Names {
+							add(f, name.Name, name, kind)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	if len(matches) > maxWorkspaceSymbols {
+		matches = matches[:maxWorkspaceSymbols]
+	}
+	symbols := make([]lsp.SymbolInformation, len(matches))
+	for i, m := range matches {
+		symbols[i] = m.symbol
+	}
+	return symbols, nil
+}
+
+// matchScore ranks how well name matches query, ignoring case: 4 for the
+// same name, 3 for a prefix, 2 for a substring, 1 for the letters of query
+// in order and 0 for no match. The last part of a qualified name counts.
+func matchScore(name, query string) int {
+	name, query = strings.ToLower(name), strings.ToLower(query)
+	last := name[strings.LastIndex(name, ".")+1:]
+	switch {
+	case query == "":
+		return 1
+	case last == query || name == query:
+		return 4
+	case strings.HasPrefix(last, query):
+		return 3
+	case strings.Contains(name, query):
+		return 2
+	}
+	i := 0
+	for _, r := range name {
+		if i < len(query) && r == rune(query[i]) {
+			i++
+		}
+	}
+	if i == len(query) {
+		return 1
+	}
+	return 0
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"net"
 	"os/exec"
 
 	"github.com/sourcegraph/jsonrpc2"
@@ -23,7 +24,7 @@ func (rwc readWriteCloser) Close() error {
 	return err2
 }
 
-func newProcessConnection(cmd *exec.Cmd) (*jsonrpc2.Conn, error) {
+func newProcessConnection(cmd *exec.Cmd, handler *serverHandler, opts ...jsonrpc2.ConnOpt) (*jsonrpc2.Conn, error) {
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, err
@@ -38,23 +39,32 @@ func newProcessConnection(cmd *exec.Cmd) (*jsonrpc2.Conn, error) {
 		return nil, err
 	}
 
+	return newConnection(readWriteCloser{stdout, stdin}, handler, opts...), nil
+}
+
+// newNetConnection connects to a gopls daemon listening on a TCP or unix
+// socket
+func newNetConnection(ctx context.Context, network, address string, handler *serverHandler, opts ...jsonrpc2.ConnOpt) (*jsonrpc2.Conn, error) {
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	return newConnection(netConn, handler, opts...), nil
+}
+
+func newConnection(rwc io.ReadWriteCloser, handler *serverHandler, opts ...jsonrpc2.ConnOpt) *jsonrpc2.Conn {
 	stream := jsonrpc2.NewBufferedStream(
-		readWriteCloser{stdout, stdin},
+		rwc,
 		jsonrpc2.VSCodeObjectCodec{},
 	)
 
-	handler := &serverHandler{}
-	conn := jsonrpc2.NewConn(
+	return jsonrpc2.NewConn(
 		context.Background(),
 		stream,
 		handler,
+		opts...,
 	)
-
-	return conn, nil
-}
-
-type serverHandler struct {
-	diagnostics map[string][]Diagnostic
 }
 
 func (h *serverHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
@@ -62,15 +72,25 @@ func (h *serverHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *js
 	case "textDocument/publishDiagnostics":
 		var params PublishDiagnosticsParams
 		if req.Params != nil && json.Unmarshal(*req.Params, &params) == nil {
-			if h.diagnostics == nil {
-				h.diagnostics = make(map[string][]Diagnostic)
-			}
-			h.diagnostics[params.URI] = params.Diagnostics
+			h.publish(params)
 		}
 	case "window/logMessage":
 		// Ignore log messages for now
 	case "$/progress":
-		// Ignore progress notifications
+		var params ProgressParams
+		if req.Params != nil && json.Unmarshal(*req.Params, &params) == nil {
+			h.progress(params)
+		}
+	case "workspace/configuration":
+		var params ConfigurationParams
+		if req.Params == nil || json.Unmarshal(*req.Params, &params) != nil {
+			conn.ReplyWithError(ctx, req.ID, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: "invalid configuration params"})
+			return
+		}
+		conn.Reply(ctx, req.ID, h.configuration(params))
+	case "window/workDoneProgress/create":
+		// gopls waits for the token to be accepted before reporting progress
+		conn.Reply(ctx, req.ID, nil)
 	case "window/showMessage":
 		// Ignore show message notifications
 	default:
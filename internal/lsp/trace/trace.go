@@ -0,0 +1,331 @@
+// Package trace records the LSP messages exchanged with gopls to a JSON
+// lines file and serves a recorded session back, so a problem seen with
+// one gopls can be reproduced without it: attached to a bug report, or
+// replayed as a regression test.
+package trace
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// Entry is one message of a session. From is "client" for what mcp-gopls
+// sent and "server" for what gopls sent. Requests and notifications have a
+// method and params, responses the id of their request, with the method of
+// the request for readability, and a result or error.
+type Entry struct {
+	Time   time.Time        `json:"time"`
+	From   string           `json:"from"`
+	ID     *jsonrpc2.ID     `json:"id,omitempty"`
+	Method string           `json:"method,omitempty"`
+	Params *json.RawMessage `json:"params,omitempty"`
+	Result *json.RawMessage `json:"result,omitempty"`
+	Error  *jsonrpc2.Error  `json:"error,omitempty"`
+}
+
+// isResponse reports whether e is a response rather than a request or
+// notification
+func (e Entry) isResponse() bool {
+	return e.Result != nil || e.Error != nil
+}
+
+// Recorder appends the messages of LSP connections to a trace file
+type Recorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewRecorder creates, or truncates, the trace file at path
+func NewRecorder(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LSP trace: %w", err)
+	}
+	return &Recorder{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// ConnOpts returns the options that make a connection record its
+// messages, none for a nil Recorder
+func (r *Recorder) ConnOpts() []jsonrpc2.ConnOpt {
+	if r == nil {
+		return nil
+	}
+	return []jsonrpc2.ConnOpt{
+		jsonrpc2.OnSend(func(req *jsonrpc2.Request, resp *jsonrpc2.Response) { r.record("client", req, resp) }),
+		jsonrpc2.OnRecv(func(req *jsonrpc2.Request, resp *jsonrpc2.Response) { r.record("server", req, resp) }),
+	}
+}
+
+func (r *Recorder) record(from string, req *jsonrpc2.Request, resp *jsonrpc2.Response) {
+	entry := Entry{Time: time.Now(), From: from}
+	switch {
+	case resp != nil:
+		id := resp.ID
+		entry.ID, entry.Result, entry.Error = &id, resp.Result, resp.Error
+		if req != nil {
+			entry.Method = req.Method
+		}
+		if entry.Result == nil && entry.Error == nil {
+			null := json.RawMessage("null")
+			entry.Result = &null
+		}
+	case req != nil:
+		entry.Method, entry.Params = req.Method, req.Params
+		if !req.Notif {
+			id := req.ID
+			entry.ID = &id
+		}
+	default:
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return
+	}
+	if err := r.enc.Encode(entry); err != nil {
+		log.Printf("failed to record LSP trace: %v", err)
+	}
+}
+
+// Close closes the trace file; messages sent after are not recorded
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// Read reads a trace file written by a Recorder
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open LSP trace: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read LSP trace: %w", err)
+	}
+	return entries, nil
+}
+
+// Replayer serves a recorded session: each request gets the response gopls
+// gave to the same request, and each message is followed by the
+// notifications gopls sent after it, such as diagnostics.
+type Replayer struct {
+	mu       sync.Mutex
+	entries  []Entry
+	consumed []bool
+	root     string // the recorded workspace root URI
+}
+
+// NewReplayer replays entries, typically read with Read
+func NewReplayer(entries []Entry) *Replayer {
+	r := &Replayer{entries: entries, consumed: make([]bool, len(entries))}
+	for _, entry := range entries {
+		if entry.From == "client" && entry.Method == "initialize" && entry.Params != nil {
+			var params struct {
+				RootURI string `json:"rootUri"`
+			}
+			if json.Unmarshal(*entry.Params, &params) == nil {
+				r.root = params.RootURI
+			}
+			break
+		}
+	}
+	return r
+}
+
+// Serve answers LSP requests read from rwc from the recording until rwc is
+// closed, such as one end of a net.Pipe whose other end is given to
+// lsp.NewStreamClient
+func (r *Replayer) Serve(rwc io.ReadWriteCloser) *jsonrpc2.Conn {
+	stream := jsonrpc2.NewBufferedStream(rwc, jsonrpc2.VSCodeObjectCodec{})
+	return jsonrpc2.NewConn(context.Background(), stream, r)
+}
+
+// Handle implements jsonrpc2.Handler
+func (r *Replayer) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	if req.Method == "initialize" {
+		r.relocate(req)
+	}
+	if req.Method == "exit" {
+		conn.Close()
+		return
+	}
+
+	r.mu.Lock()
+	i := r.match(req)
+	var response *Entry
+	var before, after []Entry
+	if i >= 0 {
+		r.consumed[i] = true
+		for j := i + 1; j < len(r.entries); j++ {
+			entry := r.entries[j]
+			if entry.From == "client" {
+				if entry.isResponse() {
+					continue // to a request from gopls
+				}
+				break
+			}
+			switch {
+			case entry.isResponse() && entry.ID != nil && r.entries[i].ID != nil && *entry.ID == *r.entries[i].ID:
+				response = &r.entries[j]
+			case !entry.isResponse() && entry.ID == nil && response == nil:
+				before = append(before, entry)
+			case !entry.isResponse() && entry.ID == nil:
+				after = append(after, entry)
+			}
+		}
+		if response == nil && r.entries[i].ID != nil {
+			// The response came after other client messages
+			for j := i + 1; j < len(r.entries); j++ {
+				if entry := r.entries[j]; entry.From == "server" && entry.isResponse() && entry.ID != nil && *entry.ID == *r.entries[i].ID {
+					response = &r.entries[j]
+					break
+				}
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	notify(ctx, conn, before)
+	if !req.Notif {
+		switch {
+		case i < 0:
+			conn.ReplyWithError(ctx, req.ID, &jsonrpc2.Error{
+				Code:    jsonrpc2.CodeInternalError,
+				Message: fmt.Sprintf("the LSP trace has no recorded response to %s", req.Method),
+			})
+		case response == nil:
+			conn.ReplyWithError(ctx, req.ID, &jsonrpc2.Error{
+				Code:    jsonrpc2.CodeInternalError,
+				Message: fmt.Sprintf("the LSP trace ends before gopls responded to %s", req.Method),
+			})
+		case response.Error != nil:
+			conn.ReplyWithError(ctx, req.ID, response.Error)
+		default:
+			conn.SendResponse(ctx, &jsonrpc2.Response{ID: req.ID, Result: response.Result})
+		}
+	}
+	notify(ctx, conn, after)
+}
+
+// notify sends recorded notifications from gopls. Requests gopls made,
+// such as workspace/configuration, are not replayed, since their responses
+// cannot change the recording.
+func notify(ctx context.Context, conn *jsonrpc2.Conn, entries []Entry) {
+	for _, entry := range entries {
+		var params interface{}
+		if entry.Params != nil {
+			params = entry.Params
+		}
+		if err := conn.Notify(ctx, entry.Method, params); err != nil {
+			return
+		}
+	}
+}
+
+// match returns the index of the first unreplayed client message with the
+// method and params of req, or failing that with its method, or -1. The
+// caller must hold r.mu.
+func (r *Replayer) match(req *jsonrpc2.Request) int {
+	params := canonical(req.Params)
+	fallback := -1
+	for i, entry := range r.entries {
+		if r.consumed[i] || entry.From != "client" || entry.Method != req.Method || entry.isResponse() ||
+			(entry.ID == nil) != req.Notif {
+			continue
+		}
+		if canonical(entry.Params) == params {
+			return i
+		}
+		if fallback < 0 {
+			fallback = i
+		}
+	}
+	return fallback
+}
+
+// canonical returns params as JSON with sorted keys, for comparison
+func canonical(params *json.RawMessage) string {
+	if params == nil {
+		return "null"
+	}
+	var v interface{}
+	if json.Unmarshal(*params, &v) != nil {
+		return string(*params)
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// relocate rewrites the recording, when it was made in another workspace
+// root than the one req initializes, so that its URIs and paths are under
+// the new root
+func (r *Replayer) relocate(req *jsonrpc2.Request) {
+	if req.Params == nil {
+		return
+	}
+	var params struct {
+		RootURI string `json:"rootUri"`
+	}
+	if json.Unmarshal(*req.Params, &params) != nil || params.RootURI == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.root == "" || r.root == params.RootURI {
+		return
+	}
+	replacer := strings.NewReplacer(
+		r.root, params.RootURI,
+		strings.TrimPrefix(r.root, "file://"), strings.TrimPrefix(params.RootURI, "file://"),
+	)
+	rewrite := func(raw *json.RawMessage) *json.RawMessage {
+		if raw == nil {
+			return nil
+		}
+		rewritten := json.RawMessage(replacer.Replace(string(*raw)))
+		return &rewritten
+	}
+	for i := range r.entries {
+		r.entries[i].Params = rewrite(r.entries[i].Params)
+		r.entries[i].Result = rewrite(r.entries[i].Result)
+	}
+	r.root = params.RootURI
+}
@@ -0,0 +1,103 @@
+package trace_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp/trace"
+	"github.com/yantrio/mcp-gopls/internal/tools/goto_definition"
+	"github.com/yantrio/mcp-gopls/internal/tools/hover"
+)
+
+const source = `package calc
+
+// Add returns the sum of a and b
+func Add(a, b int) int { return a + b }
+
+func Twice(n int) int { return Add(n, n) }
+`
+
+// session starts a manager with cfg in root, runs GoToDefinition and Hover
+// on the call to Add, and returns their results
+func session(t *testing.T, cfg gopls.Config) []string {
+	t.Helper()
+	ctx := context.Background()
+	manager, err := gopls.NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Initialize(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer manager.Shutdown(ctx)
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]interface{}{
+		"file":   filepath.Join(cfg.WorkspaceRoot, "calc.go"),
+		"line":   6,
+		"column": 32,
+	}
+	var results []string
+	for name, handler := range map[string]func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error){
+		"GoToDefinition": goto_definition.NewHandler(manager),
+		"Hover":          hover.NewHandler(manager),
+	} {
+		result, err := handler(ctx, request)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, content := range result.Content {
+			if c, ok := content.(mcp.TextContent); ok {
+				results = append(results, name+": "+c.Text)
+			}
+		}
+	}
+	return results
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/calc\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "calc.go"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tracePath := filepath.Join(t.TempDir(), "session.jsonl")
+
+	recorded := session(t, gopls.Config{WorkspaceRoot: root, Mock: true, RecordTrace: tracePath})
+
+	entries, err := trace.Read(tracePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	methods := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.From == "client" {
+			methods[entry.Method] = true
+		}
+	}
+	for _, method := range []string{"initialize", "textDocument/definition", "textDocument/hover"} {
+		if !methods[method] {
+			t.Errorf("the trace has no %s request", method)
+		}
+	}
+
+	replayed := session(t, gopls.Config{WorkspaceRoot: root, ReplayTrace: tracePath})
+	if len(replayed) != len(recorded) {
+		t.Fatalf("replay gave %d results, recording gave %d:\n%q\n%q", len(replayed), len(recorded), replayed, recorded)
+	}
+	got := make(map[string]bool)
+	for _, result := range replayed {
+		got[result] = true
+	}
+	for _, result := range recorded {
+		if !got[result] {
+			t.Errorf("replay did not reproduce %q; replayed:\n%q", result, replayed)
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package lsp
 
-import "encoding/json"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
 
 type Position struct {
 	Line      int `json:"line"`
@@ -15,6 +20,63 @@ type Range struct {
 type Location struct {
 	URI   string `json:"uri"`
 	Range Range  `json:"range"`
+	// DeclarationRange is the whole declaration, such as a function with its
+	// body, when the server answered with a LocationLink. Range is then the
+	// name of the declaration.
+	DeclarationRange *Range `json:"-"`
+}
+
+// LocationLink is what servers answer definition-like requests with when
+// the client has linkSupport
+type LocationLink struct {
+	OriginSelectionRange *Range `json:"originSelectionRange,omitempty"`
+	TargetURI            string `json:"targetUri"`
+	TargetRange          Range  `json:"targetRange"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+// parseLocations decodes the result of a definition-like request, which is
+// null, a Location, a Location[] or a LocationLink[]. Links become locations
+// of their selection range.
+func parseLocations(result json.RawMessage) ([]Location, error) {
+	trimmed := bytes.TrimSpace(result)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, nil
+	}
+	if trimmed[0] != '[' {
+		trimmed = append(append([]byte("["), trimmed...), ']')
+	}
+
+	var raws []json.RawMessage
+	if err := json.Unmarshal(trimmed, &raws); err != nil {
+		return nil, err
+	}
+	locations := make([]Location, 0, len(raws))
+	for _, raw := range raws {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, err
+		}
+		if _, ok := fields["targetUri"]; !ok {
+			var location Location
+			if err := json.Unmarshal(raw, &location); err != nil {
+				return nil, err
+			}
+			locations = append(locations, location)
+			continue
+		}
+		var link LocationLink
+		if err := json.Unmarshal(raw, &link); err != nil {
+			return nil, err
+		}
+		declaration := link.TargetRange
+		locations = append(locations, Location{
+			URI:              link.TargetURI,
+			Range:            link.TargetSelectionRange,
+			DeclarationRange: &declaration,
+		})
+	}
+	return locations, nil
 }
 
 type TextDocumentIdentifier struct {
@@ -37,14 +99,44 @@ type InitializeParams struct {
 type ClientCapabilities struct {
 	TextDocument TextDocumentClientCapabilities `json:"textDocument,omitempty"`
 	Workspace    WorkspaceClientCapabilities    `json:"workspace,omitempty"`
+	Window       WindowClientCapabilities       `json:"window,omitempty"`
+}
+
+type WindowClientCapabilities struct {
+	WorkDoneProgress bool `json:"workDoneProgress,omitempty"`
 }
 
 type TextDocumentClientCapabilities struct {
-	Synchronization TextDocumentSyncClientCapabilities `json:"synchronization,omitempty"`
-	Definition      DefinitionClientCapabilities       `json:"definition,omitempty"`
-	References      ReferenceClientCapabilities        `json:"references,omitempty"`
-	Hover           HoverClientCapabilities            `json:"hover,omitempty"`
-	Rename          RenameClientCapabilities           `json:"rename,omitempty"`
+	Synchronization    TextDocumentSyncClientCapabilities   `json:"synchronization,omitempty"`
+	Definition         DefinitionClientCapabilities         `json:"definition,omitempty"`
+	TypeDefinition     TypeDefinitionClientCapabilities     `json:"typeDefinition,omitempty"`
+	Implementation     ImplementationClientCapabilities     `json:"implementation,omitempty"`
+	References         ReferenceClientCapabilities          `json:"references,omitempty"`
+	Hover              HoverClientCapabilities              `json:"hover,omitempty"`
+	Rename             RenameClientCapabilities             `json:"rename,omitempty"`
+	PublishDiagnostics PublishDiagnosticsClientCapabilities `json:"publishDiagnostics,omitempty"`
+	CodeAction         CodeActionClientCapabilities         `json:"codeAction,omitempty"`
+}
+
+type CodeActionClientCapabilities struct {
+	CodeActionLiteralSupport *CodeActionLiteralSupport `json:"codeActionLiteralSupport,omitempty"`
+	// DataSupport keeps the data field of code actions for codeAction/resolve
+	DataSupport    bool                      `json:"dataSupport,omitempty"`
+	ResolveSupport *CodeActionResolveSupport `json:"resolveSupport,omitempty"`
+}
+
+type CodeActionLiteralSupport struct {
+	CodeActionKind CodeActionKindValueSet `json:"codeActionKind"`
+}
+
+type CodeActionKindValueSet struct {
+	ValueSet []CodeActionKind `json:"valueSet"`
+}
+
+// CodeActionResolveSupport lists the properties of a code action the
+// server may leave out until codeAction/resolve
+type CodeActionResolveSupport struct {
+	Properties []string `json:"properties"`
 }
 
 type TextDocumentSyncClientCapabilities struct {
@@ -59,6 +151,16 @@ type DefinitionClientCapabilities struct {
 	LinkSupport         bool `json:"linkSupport,omitempty"`
 }
 
+type TypeDefinitionClientCapabilities struct {
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+	LinkSupport         bool `json:"linkSupport,omitempty"`
+}
+
+type ImplementationClientCapabilities struct {
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+	LinkSupport         bool `json:"linkSupport,omitempty"`
+}
+
 type ReferenceClientCapabilities struct {
 	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 }
@@ -67,6 +169,16 @@ type HoverClientCapabilities struct {
 	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 }
 
+type PublishDiagnosticsClientCapabilities struct {
+	RelatedInformation     bool                  `json:"relatedInformation,omitempty"`
+	TagSupport             *DiagnosticTagSupport `json:"tagSupport,omitempty"`
+	CodeDescriptionSupport bool                  `json:"codeDescriptionSupport,omitempty"`
+}
+
+type DiagnosticTagSupport struct {
+	ValueSet []DiagnosticTag `json:"valueSet"`
+}
+
 type RenameClientCapabilities struct {
 	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 	PrepareSupport      bool `json:"prepareSupport,omitempty"`
@@ -74,8 +186,11 @@ type RenameClientCapabilities struct {
 
 type WorkspaceClientCapabilities struct {
 	ApplyEdit              bool                                     `json:"applyEdit,omitempty"`
+	WorkspaceFolders       bool                                     `json:"workspaceFolders,omitempty"`
+	Configuration          bool                                     `json:"configuration,omitempty"`
 	WorkspaceEdit          WorkspaceEditClientCapabilities          `json:"workspaceEdit,omitempty"`
 	DidChangeConfiguration DidChangeConfigurationClientCapabilities `json:"didChangeConfiguration,omitempty"`
+	DidChangeWatchedFiles  DidChangeWatchedFilesClientCapabilities  `json:"didChangeWatchedFiles,omitempty"`
 	Symbol                 WorkspaceSymbolClientCapabilities        `json:"symbol,omitempty"`
 }
 
@@ -87,6 +202,10 @@ type DidChangeConfigurationClientCapabilities struct {
 	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 }
 
+type DidChangeWatchedFilesClientCapabilities struct {
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+}
+
 type WorkspaceSymbolClientCapabilities struct {
 	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 }
@@ -102,10 +221,76 @@ type ServerCapabilities struct {
 	ReferencesProvider         bool                    `json:"referencesProvider,omitempty"`
 	DocumentSymbolProvider     bool                    `json:"documentSymbolProvider,omitempty"`
 	WorkspaceSymbolProvider    bool                    `json:"workspaceSymbolProvider,omitempty"`
-	CodeActionProvider         bool                    `json:"codeActionProvider,omitempty"`
+	CodeActionProvider         CodeActionProvider      `json:"codeActionProvider,omitempty"`
 	DocumentFormattingProvider bool                    `json:"documentFormattingProvider,omitempty"`
-	RenameProvider             interface{}             `json:"renameProvider,omitempty"`
+	RenameProvider             RenameProvider          `json:"renameProvider,omitempty"`
 	ImplementationProvider     bool                    `json:"implementationProvider,omitempty"`
+	TypeDefinitionProvider     bool                    `json:"typeDefinitionProvider,omitempty"`
+}
+
+// CodeActionProvider is the codeActionProvider capability, which servers
+// send as a bool or as CodeActionOptions, which imply true
+type CodeActionProvider struct {
+	Enabled bool
+	CodeActionOptions
+}
+
+type CodeActionOptions struct {
+	// CodeActionKinds are the kinds the server may return, all when empty
+	CodeActionKinds []CodeActionKind `json:"codeActionKinds,omitempty"`
+	// ResolveProvider is set when actions may come without their edit,
+	// which codeAction/resolve fills in
+	ResolveProvider bool `json:"resolveProvider,omitempty"`
+}
+
+func (p *CodeActionProvider) UnmarshalJSON(data []byte) error {
+	*p = CodeActionProvider{}
+	return unmarshalProvider(data, &p.Enabled, &p.CodeActionOptions)
+}
+
+func (p CodeActionProvider) MarshalJSON() ([]byte, error) {
+	if !p.Enabled || (len(p.CodeActionKinds) == 0 && !p.ResolveProvider) {
+		return json.Marshal(p.Enabled)
+	}
+	return json.Marshal(p.CodeActionOptions)
+}
+
+// RenameProvider is the renameProvider capability, which servers send as a
+// bool or as RenameOptions, which imply true
+type RenameProvider struct {
+	Enabled bool
+	RenameOptions
+}
+
+type RenameOptions struct {
+	// PrepareProvider is set when the server answers prepareRename
+	PrepareProvider bool `json:"prepareProvider,omitempty"`
+}
+
+func (p *RenameProvider) UnmarshalJSON(data []byte) error {
+	*p = RenameProvider{}
+	return unmarshalProvider(data, &p.Enabled, &p.RenameOptions)
+}
+
+func (p RenameProvider) MarshalJSON() ([]byte, error) {
+	if !p.Enabled || !p.PrepareProvider {
+		return json.Marshal(p.Enabled)
+	}
+	return json.Marshal(p.RenameOptions)
+}
+
+// unmarshalProvider decodes a capability that is either a bool, into
+// enabled, or an options object, into options, which enables it
+func unmarshalProvider(data []byte, enabled *bool, options interface{}) error {
+	switch trimmed := bytes.TrimSpace(data); {
+	case bytes.Equal(trimmed, []byte("null")):
+		return nil
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		*enabled = true
+		return json.Unmarshal(trimmed, options)
+	default:
+		return json.Unmarshal(trimmed, enabled)
+	}
 }
 
 type TextDocumentSyncOptions struct {
@@ -132,6 +317,17 @@ type DidOpenTextDocumentParams struct {
 	TextDocument TextDocumentItem `json:"textDocument"`
 }
 
+type DidChangeTextDocumentParams struct {
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+// TextDocumentContentChangeEvent replaces the whole content of a document,
+// the only kind of change the client sends
+type TextDocumentContentChangeEvent struct {
+	Text string `json:"text"`
+}
+
 type TextDocumentItem struct {
 	URI        string `json:"uri"`
 	LanguageID string `json:"languageId"`
@@ -170,6 +366,76 @@ type MarkupContent struct {
 	Value string `json:"value"`
 }
 
+// MarkedString is the deprecated predecessor of MarkupContent: markdown, or
+// a code block in Language
+type MarkedString struct {
+	Language string `json:"language"`
+	Value    string `json:"value"`
+}
+
+// markdown returns s as markdown, fencing code blocks
+func (s MarkedString) markdown() string {
+	if s.Language == "" {
+		return s.Value
+	}
+	return "```" + s.Language + "\n" + s.Value + "\n```"
+}
+
+func (s *MarkedString) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '"' {
+		*s = MarkedString{}
+		return json.Unmarshal(trimmed, &s.Value)
+	}
+	type markedString MarkedString
+	return json.Unmarshal(data, (*markedString)(s))
+}
+
+// UnmarshalJSON also accepts the MarkedString and MarkedString[] forms of
+// hover contents older servers send, converting them to markdown
+func (c *MarkupContent) UnmarshalJSON(data []byte) error {
+	*c = MarkupContent{}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(trimmed, []byte("null")):
+		return nil
+	case len(trimmed) > 0 && trimmed[0] == '[':
+		var strs []MarkedString
+		if err := json.Unmarshal(trimmed, &strs); err != nil {
+			return err
+		}
+		parts := make([]string, 0, len(strs))
+		for _, s := range strs {
+			if s.Value != "" {
+				parts = append(parts, s.markdown())
+			}
+		}
+		c.Kind, c.Value = "markdown", strings.Join(parts, "\n\n")
+		return nil
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		var fields struct {
+			Kind     string `json:"kind"`
+			Language string `json:"language"`
+			Value    string `json:"value"`
+		}
+		if err := json.Unmarshal(trimmed, &fields); err != nil {
+			return err
+		}
+		if fields.Kind != "" {
+			c.Kind, c.Value = fields.Kind, fields.Value
+			return nil
+		}
+		c.Kind, c.Value = "markdown", MarkedString{Language: fields.Language, Value: fields.Value}.markdown()
+		return nil
+	default:
+		var s MarkedString
+		if err := json.Unmarshal(trimmed, &s); err != nil {
+			return err
+		}
+		c.Kind, c.Value = "markdown", s.markdown()
+		return nil
+	}
+}
+
 type RenameParams struct {
 	TextDocumentPositionParams
 	NewName string `json:"newName"`
@@ -205,11 +471,40 @@ type VersionedTextDocumentIdentifier struct {
 }
 
 type Diagnostic struct {
-	Range    Range              `json:"range"`
-	Severity DiagnosticSeverity `json:"severity,omitempty"`
-	Code     interface{}        `json:"code,omitempty"`
-	Source   string             `json:"source,omitempty"`
-	Message  string             `json:"message"`
+	Range              Range                          `json:"range"`
+	Severity           DiagnosticSeverity             `json:"severity,omitempty"`
+	Code               interface{}                    `json:"code,omitempty"`
+	CodeDescription    *CodeDescription               `json:"codeDescription,omitempty"`
+	Source             string                         `json:"source,omitempty"`
+	Message            string                         `json:"message"`
+	Tags               []DiagnosticTag                `json:"tags,omitempty"`
+	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+}
+
+type CodeDescription struct {
+	Href string `json:"href"`
+}
+
+type DiagnosticRelatedInformation struct {
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+type DiagnosticTag int
+
+const (
+	DiagnosticTagUnnecessary DiagnosticTag = 1
+	DiagnosticTagDeprecated  DiagnosticTag = 2
+)
+
+func (t DiagnosticTag) String() string {
+	switch t {
+	case DiagnosticTagUnnecessary:
+		return "unnecessary"
+	case DiagnosticTagDeprecated:
+		return "deprecated"
+	}
+	return fmt.Sprintf("tag(%d)", int(t))
 }
 
 type DiagnosticSeverity int
@@ -221,6 +516,20 @@ const (
 	DiagnosticSeverityHint        DiagnosticSeverity = 4
 )
 
+type ProgressParams struct {
+	Token interface{}     `json:"token"`
+	Value json.RawMessage `json:"value"`
+}
+
+// WorkDoneProgress is the value of a $/progress notification reporting the
+// begin, an update or the end of a piece of work
+type WorkDoneProgress struct {
+	Kind       string `json:"kind"`
+	Title      string `json:"title,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Percentage int    `json:"percentage,omitempty"`
+}
+
 type PublishDiagnosticsParams struct {
 	URI         string       `json:"uri"`
 	Diagnostics []Diagnostic `json:"diagnostics"`
@@ -232,8 +541,11 @@ type DocumentFormattingParams struct {
 }
 
 type FormattingOptions struct {
-	TabSize      int  `json:"tabSize"`
-	InsertSpaces bool `json:"insertSpaces"`
+	TabSize                int  `json:"tabSize"`
+	InsertSpaces           bool `json:"insertSpaces"`
+	TrimTrailingWhitespace bool `json:"trimTrailingWhitespace,omitempty"`
+	InsertFinalNewline     bool `json:"insertFinalNewline,omitempty"`
+	TrimFinalNewlines      bool `json:"trimFinalNewlines,omitempty"`
 }
 
 type CodeActionParams struct {
@@ -263,6 +575,8 @@ type CodeAction struct {
 	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
 	Edit        *WorkspaceEdit `json:"edit,omitempty"`
 	Command     *Command       `json:"command,omitempty"`
+	// Data is kept for codeAction/resolve
+	Data json.RawMessage `json:"data,omitempty"`
 }
 
 type Command struct {
@@ -330,6 +644,50 @@ type ImplementationParams struct {
 	TextDocumentPositionParams
 }
 
+type TypeDefinitionParams struct {
+	TextDocumentPositionParams
+}
+
+type WorkspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+type DidChangeWorkspaceFoldersParams struct {
+	Event WorkspaceFoldersChangeEvent `json:"event"`
+}
+
+type WorkspaceFoldersChangeEvent struct {
+	Added   []WorkspaceFolder `json:"added"`
+	Removed []WorkspaceFolder `json:"removed"`
+}
+
+type FileChangeType int
+
+const (
+	FileChangeCreated FileChangeType = 1
+	FileChangeChanged FileChangeType = 2
+	FileChangeDeleted FileChangeType = 3
+)
+
+type FileEvent struct {
+	URI  string         `json:"uri"`
+	Type FileChangeType `json:"type"`
+}
+
+type FileRename struct {
+	OldURI string `json:"oldUri"`
+	NewURI string `json:"newUri"`
+}
+
+type RenameFilesParams struct {
+	Files []FileRename `json:"files"`
+}
+
+type DidChangeWatchedFilesParams struct {
+	Changes []FileEvent `json:"changes"`
+}
+
 type ShutdownParams struct{}
 
 type ExitParams struct{}
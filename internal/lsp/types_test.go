@@ -0,0 +1,96 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHoverContentsUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     MarkupContent
+	}{
+		{
+			name:     "plain string",
+			contents: `"Add returns the sum"`,
+			want:     MarkupContent{Kind: "markdown", Value: "Add returns the sum"},
+		},
+		{
+			name:     "markup content markdown",
+			contents: `{"kind": "markdown", "value": "**Add**"}`,
+			want:     MarkupContent{Kind: "markdown", Value: "**Add**"},
+		},
+		{
+			name:     "markup content plaintext",
+			contents: `{"kind": "plaintext", "value": "func Add(a, b int) int"}`,
+			want:     MarkupContent{Kind: "plaintext", Value: "func Add(a, b int) int"},
+		},
+		{
+			name:     "marked string object",
+			contents: `{"language": "go", "value": "func Add(a, b int) int"}`,
+			want:     MarkupContent{Kind: "markdown", Value: "```go\nfunc Add(a, b int) int\n```"},
+		},
+		{
+			name:     "marked string object without language",
+			contents: `{"language": "", "value": "text"}`,
+			want:     MarkupContent{Kind: "markdown", Value: "text"},
+		},
+		{
+			name:     "mixed array",
+			contents: `[{"language": "go", "value": "func Add(a, b int) int"}, "Add returns the sum", ""]`,
+			want:     MarkupContent{Kind: "markdown", Value: "```go\nfunc Add(a, b int) int\n```\n\nAdd returns the sum"},
+		},
+		{
+			name:     "empty array",
+			contents: `[]`,
+			want:     MarkupContent{Kind: "markdown", Value: ""},
+		},
+		{
+			name:     "null",
+			contents: `null`,
+			want:     MarkupContent{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hover Hover
+			if err := json.Unmarshal([]byte(`{"contents": `+tt.contents+`}`), &hover); err != nil {
+				t.Fatal(err)
+			}
+			if hover.Contents != tt.want {
+				t.Errorf("contents %s = %+v, want %+v", tt.contents, hover.Contents, tt.want)
+			}
+		})
+	}
+}
+
+func TestHoverContentsUnmarshalInvalid(t *testing.T) {
+	for _, contents := range []string{`42`, `[42]`, `{"value": 42}`} {
+		var hover Hover
+		if err := json.Unmarshal([]byte(`{"contents": `+contents+`}`), &hover); err == nil {
+			t.Errorf("contents %s unmarshaled without error: %+v", contents, hover.Contents)
+		}
+	}
+}
+
+func TestMarkedStringUnmarshal(t *testing.T) {
+	tests := []struct {
+		data string
+		want MarkedString
+	}{
+		{`"text"`, MarkedString{Value: "text"}},
+		{` "text" `, MarkedString{Value: "text"}},
+		{`{"language": "go", "value": "var x int"}`, MarkedString{Language: "go", Value: "var x int"}},
+	}
+	for _, tt := range tests {
+		var s MarkedString
+		if err := json.Unmarshal([]byte(tt.data), &s); err != nil {
+			t.Errorf("unmarshal %s: %v", tt.data, err)
+			continue
+		}
+		if s != tt.want {
+			t.Errorf("unmarshal %s = %+v, want %+v", tt.data, s, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package positions lets position-based tools answer several positions in
+// one call, through a positions argument that stands in for file, line and
+// column
+package positions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// Property is the schema of the positions argument
+func Property() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": "Several positions to query in one call, in the same or different files, instead of file, line and column. Results are keyed by \"file:line:column\".",
+		"items": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"file":   map[string]interface{}{"type": "string", "description": "Path to the Go source file"},
+				"line":   map[string]interface{}{"type": "number", "description": "Line number (1-indexed)"},
+				"column": map[string]interface{}{"type": "number", "description": "Column number (1-indexed)"},
+			},
+			"required": []string{"file", "line", "column"},
+		},
+	}
+}
+
+// Position is a queried position in a file, 1-indexed
+type Position struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// Key identifies the position in results
+func (p Position) Key() string {
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// LSP returns the position in gopls's 0-indexed form
+func (p Position) LSP() lsp.Position {
+	return utils.ConvertPosition(p.Line, p.Column)
+}
+
+// FromRequest returns the positions a call asks about and whether they came
+// from the positions argument. Without it, the file, line and column
+// arguments give a single position.
+func FromRequest(request mcp.CallToolRequest) ([]Position, bool, error) {
+	raw, ok := request.GetArguments()["positions"]
+	if !ok || raw == nil {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, false, fmt.Errorf("file, line and column are required unless positions is given")
+		}
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return nil, false, err
+		}
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return nil, false, err
+		}
+		return []Position{{File: file, Line: line, Column: column}}, false, nil
+	}
+
+	// Decode through JSON to accept numbers as floats or strings alike
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid positions: %w", err)
+	}
+	var items []struct {
+		File   string      `json:"file"`
+		Line   json.Number `json:"line"`
+		Column json.Number `json:"column"`
+	}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, false, fmt.Errorf("invalid positions: must be an array of {file, line, column} objects")
+	}
+	if len(items) == 0 {
+		return nil, false, fmt.Errorf("positions is empty")
+	}
+
+	result := make([]Position, 0, len(items))
+	for i, item := range items {
+		line, lineErr := item.Line.Float64()
+		column, columnErr := item.Column.Float64()
+		if item.File == "" || lineErr != nil || columnErr != nil {
+			return nil, false, fmt.Errorf("invalid positions[%d]: file, line and column are required", i)
+		}
+		result = append(result, Position{File: item.File, Line: int(line), Column: int(column)})
+	}
+	return result, true, nil
+}
+
+// Open opens each distinct file of positions in gopls once and returns the
+// URI of each file, and a function that closes them again
+func Open(ctx context.Context, manager *gopls.Manager, client *lsp.Client, positions []Position) (map[string]string, func(), error) {
+	uris := make(map[string]string)
+	closeAll := func() {
+		for _, uri := range uris {
+			client.CloseDocument(ctx, uri)
+		}
+	}
+	for _, p := range positions {
+		if _, ok := uris[p.File]; ok {
+			continue
+		}
+		uri, err := utils.PathToURI(p.File)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		content, err := manager.ReadFile(p.File)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		if err := client.OpenDocument(ctx, uri, string(content)); err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		uris[p.File] = uri
+	}
+	return uris, closeAll, nil
+}
+
+// Result is the answer for one position of a multi-position call: the
+// tool's result, or the error that position failed with
+type Result struct {
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// Keyed renders the results of a multi-position call as a JSON object keyed
+// by Position.Key
+func Keyed(positions []Position, results []Result) *mcp.CallToolResult {
+	keyed := make(map[string]Result, len(positions))
+	for i, p := range positions {
+		keyed[p.Key()] = results[i]
+	}
+	output, _ := json.MarshalIndent(keyed, "", "  ")
+	return mcp.NewToolResultText(fmt.Sprintf("Results for %d position(s):\n%s", len(positions), output))
+}
@@ -0,0 +1,64 @@
+// Package resultfilter decides which files tool results may mention, so the
+// tools that report locations from gopls leave out the same code
+package resultfilter
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+// IncludeVendorProperty is the schema of the includeVendor argument that
+// filtered tools accept
+func IncludeVendorProperty(manager *gopls.Manager) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": "Include results in vendor directories",
+		"default":     manager.IncludeVendor(),
+	}
+}
+
+// Filter keeps or drops result locations by path
+type Filter struct {
+	manager       *gopls.Manager
+	root          string
+	includeVendor bool
+}
+
+// FromRequest returns the filter for a tool call: the server's settings,
+// overridden by the call's includeVendor argument
+func FromRequest(manager *gopls.Manager, request mcp.CallToolRequest) Filter {
+	return Filter{
+		manager:       manager,
+		root:          manager.WorkspaceRoot(),
+		includeVendor: request.GetBool("includeVendor", manager.IncludeVendor()),
+	}
+}
+
+// Keep reports whether a result in the file at path should be reported
+func (f Filter) Keep(path string) bool {
+	return f.includeVendor || !f.vendored(path)
+}
+
+// KeepURI is Keep for a document URI. URIs that are not files are kept.
+func (f Filter) KeepURI(uri string) bool {
+	path, err := f.manager.URIToPath(uri)
+	return err != nil || f.Keep(path)
+}
+
+// vendored reports whether path is inside a vendor directory. Only the part
+// below the workspace root is checked, so a workspace that itself lives
+// under a directory called vendor is not filtered out entirely.
+func (f Filter) vendored(path string) bool {
+	if rel, err := filepath.Rel(f.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = rel
+	}
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if part == "vendor" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package server
+
+import "strings"
+
+// defaultInstructions is the guidance given to clients unless
+// Config.Instructions replaces it. The capabilities are listed after it.
+const defaultInstructions = "Go language server integration via gopls. " +
+	"Use these tools to interact with Go code for accurate, context-aware analysis and refactoring. " +
+	"\n\n" +
+	"gopls is the official Go language server that understands your entire codebase, making it far more reliable than grep/search for:"
+
+// closingInstructions follows the capabilities in the default instructions
+const closingInstructions = "For Go code tasks, always prefer these tools over generic file search/edit operations."
+
+// capability is a bullet of the default instructions, listed when one of
+// its tools is enabled
+type capability struct {
+	text  string
+	tools []string
+}
+
+var capabilities = []capability{
+	{"Finding references - gopls understands Go semantics, not just text matching", []string{"FindReferences", "FindImplementers"}},
+	{"Renaming symbols - safely renames across packages with type awareness", []string{"RenameSymbol"}},
+	{"Navigation - jumps to actual definitions, not just similar names", []string{"GoToDefinition", "SearchSymbol"}},
+	{"Code analysis - provides real compiler errors and type information", []string{"GetDiagnostics", "CheckWorkspace", "Hover"}},
+}
+
+// instructions builds the instructions sent to clients: the default or
+// custom guidance, the enabled tools under the names clients see, and the
+// extra instructions
+func (s *Server) instructions(custom, extra string) string {
+	var b strings.Builder
+	if strings.TrimSpace(custom) == "" {
+		b.WriteString(defaultInstructions + "\n")
+		for _, c := range capabilities {
+			var names []string
+			for _, tool := range c.tools {
+				if s.enabled(tool) {
+					names = append(names, s.toolNames[tool])
+				}
+			}
+			if len(names) > 0 {
+				b.WriteString("• " + strings.Replace(c.text, " - ", " ("+strings.Join(names, ", ")+") - ", 1) + "\n")
+			}
+		}
+		b.WriteString("\n" + closingInstructions)
+	} else {
+		b.WriteString(strings.TrimSpace(custom))
+	}
+
+	var enabled, disabled []string
+	for _, tool := range s.registry.Tools() {
+		if s.enabled(tool.Name) {
+			enabled = append(enabled, s.toolNames[tool.Name])
+		} else {
+			disabled = append(disabled, s.toolNames[tool.Name])
+		}
+	}
+	b.WriteString("\n\nAvailable tools: " + strings.Join(enabled, ", ") + ".")
+	if s.enabled("ExplainTool") {
+		b.WriteString(" Call " + s.toolNames["ExplainTool"] + " to learn the arguments and see examples of any of them.")
+	}
+	if len(disabled) > 0 {
+		b.WriteString("\nDisabled on this server: " + strings.Join(disabled, ", ") + ".")
+	}
+
+	if extra = strings.TrimSpace(extra); extra != "" {
+		b.WriteString("\n\n" + extra)
+	}
+	return b.String()
+}
@@ -3,75 +3,335 @@ package server
 import (
 	"context"
 	"fmt"
+	"log"
+	"os"
+	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
 	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/telemetry"
 	"github.com/yantrio/mcp-gopls/internal/tools"
+	"github.com/yantrio/mcp-gopls/internal/tools/explain_tool"
 )
 
+// Config holds the settings for the MCP server
+type Config struct {
+	// GoplsPath is the gopls binary to run, defaults to "gopls" in PATH
+	GoplsPath string
+	// WorkspaceRoot is the workspace directory, defaults to the current directory
+	WorkspaceRoot string
+	// AutoAddWorkspaceFolders adds modules outside the workspace root as
+	// extra gopls workspace folders when tools are called on their files
+	AutoAddWorkspaceFolders bool
+	// IncludeVendor keeps vendor directories in symbol search, references
+	// and diagnostics, which leave them out by default
+	IncludeVendor bool
+	// DirectoryFilters are gopls directoryFilters, for focusing gopls on
+	// part of a large repository
+	DirectoryFilters []string
+	// GoplsRemote connects to a shared gopls daemon: "auto" or an address
+	// such as localhost:37374 or unix;/tmp/gopls.sock
+	GoplsRemote string
+	// Mock answers LSP requests with a fake gopls built into the server,
+	// for tests and demos without gopls or Go installed
+	Mock bool
+	// RecordLSP writes the LSP messages exchanged with gopls to this file
+	RecordLSP string
+	// ReplayLSP answers LSP requests from a file written with RecordLSP
+	// instead of starting gopls
+	ReplayLSP string
+	// MemoryMode is "default" or "low", which trades gopls features for
+	// lower memory use
+	MemoryMode string
+	// MaxGoplsMemory restarts gopls when its resident memory exceeds this
+	// many bytes, zero means never
+	MaxGoplsMemory uint64
+	// MemoryCheckInterval is how often gopls's memory is checked against
+	// MaxGoplsMemory
+	MemoryCheckInterval time.Duration
+	// MaxFileSize is the largest file in bytes that tools send to gopls,
+	// zero means gopls.DefaultMaxFileSize and a negative value no limit
+	MaxFileSize int64
+	// FileHeader is a text/template of the license or copyright comment
+	// stamped on the Go files tools create, see codegen.HeaderData
+	FileHeader string
+	// GeneratedMarker stamps codegen.GeneratedMarker on the Go files tools
+	// create
+	GeneratedMarker bool
+	// RelativePaths reports file paths in tool results relative to the
+	// workspace root unless a call sets relativePaths to false
+	RelativePaths bool
+	// StructuredContent returns the diffs and code blocks in tool results as
+	// embedded resources with file URIs and MIME types instead of text
+	StructuredContent bool
+	// TokenBudget is the estimated tokens a tool result may take before it
+	// is summarized with a cursor for the rest, unless a call sets
+	// tokenBudget. Zero means no limit.
+	TokenBudget int
+	// ToolTimeout bounds each tool call, zero means no limit
+	ToolTimeout time.Duration
+	// LogToolCalls logs every tool call with its duration and outcome
+	LogToolCalls bool
+	// TelemetryFile records the name, duration and error category of every
+	// tool call to this JSONL file, see the telemetry package. Empty, the
+	// default, records nothing.
+	TelemetryFile string
+	// DryRunByDefault makes tools that support dryRun preview their changes
+	// unless a call sets dryRun to false
+	DryRunByDefault bool
+	// Instructions replace the built-in guidance the server gives clients
+	// on connecting. The list of enabled tools is appended either way.
+	Instructions string
+	// ExtraInstructions are appended to the instructions, e.g. to describe
+	// project conventions
+	ExtraInstructions string
+	// ToolFilter selects the tools to register by name, nil registers all
+	ToolFilter func(name string) bool
+	// ToolNaming sets the names tools are exposed under, for clients that
+	// expect another case or limit name length. ToolFilter, logs and
+	// metrics use the built-in names.
+	ToolNaming tools.Naming
+	// Logger receives tool call and transport logs, defaults to stderr
+	Logger *log.Logger
+	// ExtraTools are registered after the built-in tools and wrapped in the
+	// same middleware
+	ExtraTools []ExtraTool
+}
+
+// ExtraTool is a tool supplied by an embedding program or plugin
+type ExtraTool struct {
+	Tool    mcp.Tool
+	Handler func(manager *gopls.Manager) server.ToolHandlerFunc
+}
+
 type Server struct {
-	mcpServer *server.MCPServer
-	manager   *gopls.Manager
+	mcpServer  *server.MCPServer
+	manager    *gopls.Manager
+	registry   *tools.Registry
+	metrics    *tools.Metrics
+	activity   *tools.Activity
+	logger     *log.Logger
+	toolFilter func(name string) bool
+	// toolNames maps built-in tool names to the names clients see
+	toolNames map[string]string
 }
 
-func New(goplsPath, workspaceRoot string) (*Server, error) {
-	manager, err := gopls.NewManager(goplsPath, workspaceRoot)
+func New(cfg Config) (*Server, error) {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = log.New(os.Stderr, "mcp-gopls: ", log.LstdFlags)
+	}
+
+	fileHeader, err := codegen.NewStamper(codegen.Header{Template: cfg.FileHeader, Marker: cfg.GeneratedMarker})
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := gopls.NewManager(gopls.Config{
+		GoplsPath:               cfg.GoplsPath,
+		WorkspaceRoot:           cfg.WorkspaceRoot,
+		AutoAddWorkspaceFolders: cfg.AutoAddWorkspaceFolders,
+		IncludeVendor:           cfg.IncludeVendor,
+		DirectoryFilters:        cfg.DirectoryFilters,
+		Remote:                  cfg.GoplsRemote,
+		Mock:                    cfg.Mock,
+		RecordTrace:             cfg.RecordLSP,
+		ReplayTrace:             cfg.ReplayLSP,
+		MemoryMode:              cfg.MemoryMode,
+		MaxRSS:                  cfg.MaxGoplsMemory,
+		MemoryCheckInterval:     cfg.MemoryCheckInterval,
+		MaxFileSize:             cfg.MaxFileSize,
+		Logger:                  logger,
+		FileHeader:              fileHeader,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create gopls manager: %w", err)
 	}
 
-	mcpServer := server.NewMCPServer(
-		"mcp-gopls",
-		"1.0.0",
-		server.WithInstructions(
-			"Go language server integration via gopls. "+
-				"Use these tools to interact with Go code for accurate, context-aware analysis and refactoring. "+
-				"\n\n"+
-				"gopls is the official Go language server that understands your entire codebase, making it far more reliable than grep/search for:\n"+
-				"• Finding references - gopls understands Go semantics, not just text matching\n"+
-				"• Renaming symbols - safely renames across packages with type awareness\n"+
-				"• Navigation - jumps to actual definitions, not just similar names\n"+
-				"• Code analysis - provides real compiler errors and type information\n"+
-				"\n"+
-				"For Go code tasks, always prefer these tools over generic file search/edit operations.",
-		),
-	)
+	// Every request counts as activity, not only tool calls, so a client
+	// that only lists tools or pings keeps the server alive
+	activity := tools.NewActivity()
+	hooks := &server.Hooks{}
+	hooks.AddOnRequestInitialization(func(ctx context.Context, id any, message any) error {
+		activity.Touch()
+		return nil
+	})
 
 	s := &Server{
-		mcpServer: mcpServer,
-		manager:   manager,
+		manager:    manager,
+		registry:   tools.NewRegistry(),
+		metrics:    tools.NewMetrics(),
+		activity:   activity,
+		logger:     logger,
+		toolFilter: cfg.ToolFilter,
+	}
+
+	if err := tools.RegisterBuiltins(s.registry, manager); err != nil {
+		return nil, err
+	}
+	for _, extra := range cfg.ExtraTools {
+		if err := s.registry.Register(extra.Tool, extra.Handler(manager)); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.registry.Register(explain_tool.NewTool(), explain_tool.NewHandler(s.explainEntries)); err != nil {
+		return nil, err
+	}
+
+	s.registry.AddProperty("relativePaths", map[string]interface{}{
+		"type":        "boolean",
+		"description": "Report file paths relative to the workspace root instead of absolute",
+		"default":     cfg.RelativePaths,
+	})
+	for name, schema := range tools.BudgetProperties(cfg.TokenBudget) {
+		s.registry.AddProperty(name, schema)
+	}
+	s.registry.AdaptSchemas(tools.SearchTextSchema)
+
+	// Middleware listed first runs outermost, so logging and metrics also see
+	// calls rejected by argument validation
+	if cfg.LogToolCalls {
+		s.registry.Use(tools.Logging(s.logger))
+	}
+	if cfg.TelemetryFile != "" {
+		s.registry.Use(telemetry.NewRecorder(cfg.TelemetryFile).Middleware())
+	}
+	s.registry.Use(
+		s.metrics.Middleware(),
+		s.activity.Middleware(),
+		tools.Timeout(cfg.ToolTimeout),
+		tools.NormalizeArguments(),
+		tools.StructuredContent(manager, cfg.StructuredContent),
+		tools.TokenBudget(cfg.TokenBudget),
+		tools.RelativePaths(manager, cfg.RelativePaths),
+		tools.SandboxPaths(manager),
+		tools.SearchTextPositions(manager),
+		tools.WorkspaceGuidance(manager, "ServerStatus", "InitModule"),
+	)
+	if cfg.DryRunByDefault {
+		s.registry.Use(tools.DryRunByDefault())
 	}
 
-	// Register all tools
+	if err := s.nameTools(cfg.ToolNaming); err != nil {
+		return nil, err
+	}
+	s.mcpServer = server.NewMCPServer(
+		"mcp-gopls",
+		"1.0.0",
+		server.WithHooks(hooks),
+		server.WithInstructions(s.instructions(cfg.Instructions, cfg.ExtraInstructions)),
+	)
 	s.registerTools()
 
 	return s, nil
 }
 
-func (s *Server) Start() error {
-	// Initialize gopls when server starts
-	ctx := context.Background()
+// Initialize starts gopls. It must be called before serving MCPServer over
+// any transport.
+func (s *Server) Initialize(ctx context.Context) error {
 	if err := s.manager.Initialize(ctx); err != nil {
 		return fmt.Errorf("failed to initialize gopls: %w", err)
 	}
+	if problem := s.manager.WorkspaceProblem(); problem != "" {
+		s.logger.Printf("warning: %s", problem)
+	}
+	return nil
+}
 
-	// Start the MCP server
-	return server.ServeStdio(s.mcpServer)
+// MCPServer returns the underlying MCP server for use with other transports
+func (s *Server) MCPServer() *server.MCPServer {
+	return s.mcpServer
 }
 
-func (s *Server) registerTools() {
-	// Get all tools and handlers
-	toolList := tools.GetTools(s.manager)
-	handlers := tools.GetToolHandlers(s.manager)
+// Logger returns the logger the server was configured with
+func (s *Server) Logger() *log.Logger {
+	return s.logger
+}
+
+// ToolNames returns the names clients see for the registered tools
+func (s *Server) ToolNames() []string {
+	var names []string
+	for _, tool := range s.registry.Tools() {
+		if s.toolFilter == nil || s.toolFilter(tool.Name) {
+			names = append(names, s.toolNames[tool.Name])
+		}
+	}
+	return names
+}
+
+// nameTools sets the names the registered tools are exposed under
+func (s *Server) nameTools(naming tools.Naming) error {
+	registered := s.registry.Tools()
+	builtin := make([]string, len(registered))
+	for i, tool := range registered {
+		builtin[i] = tool.Name
+	}
+	names, err := naming.Names(builtin)
+	if err != nil {
+		return err
+	}
+	s.toolNames = names
+	return nil
+}
 
-	// Register each tool with its handler
-	for _, tool := range toolList {
-		if handler, ok := handlers[tool.Name]; ok {
+// enabled reports whether the tool with the built-in name is exposed
+func (s *Server) enabled(name string) bool {
+	_, registered := s.toolNames[name]
+	return registered && (s.toolFilter == nil || s.toolFilter(name))
+}
+
+func (s *Server) registerTools() {
+	explainer := ""
+	if s.enabled("ExplainTool") {
+		explainer = s.toolNames["ExplainTool"]
+	}
+	for _, tool := range s.registry.Tools() {
+		if !s.enabled(tool.Name) {
+			continue
+		}
+		if handler, ok := s.registry.Handler(tool.Name); ok {
+			tool = tools.WithGuide(tool, explainer)
+			tool.Name = s.toolNames[tool.Name]
 			s.mcpServer.AddTool(tool, handler)
 		}
 	}
 }
 
+// explainEntries returns the exposed tools with their guidance for
+// ExplainTool, named as clients see them
+func (s *Server) explainEntries() []explain_tool.Entry {
+	var entries []explain_tool.Entry
+	for _, tool := range s.registry.Tools() {
+		if s.toolFilter != nil && !s.toolFilter(tool.Name) {
+			continue
+		}
+		guide := tools.GuideFor(tool)
+		var related []string
+		for _, name := range guide.Related {
+			if s.enabled(name) {
+				related = append(related, s.toolNames[name])
+			}
+		}
+		guide.Related = related
+		tool.Name = s.toolNames[tool.Name]
+		entries = append(entries, explain_tool.Entry{Tool: tool, Guide: guide})
+	}
+	return entries
+}
+
+// Metrics returns the call statistics of every tool used so far
+func (s *Server) Metrics() map[string]tools.ToolStats {
+	return s.metrics.Snapshot()
+}
+
+// Idle returns how long the server has gone without an MCP request. It is
+// zero while a tool call is running.
+func (s *Server) Idle() time.Duration {
+	return s.activity.Idle()
+}
+
 func (s *Server) Shutdown() error {
 	ctx := context.Background()
 	return s.manager.Shutdown(ctx)
@@ -0,0 +1,90 @@
+// Package state keeps small JSON files that tools persist per workspace,
+// such as bookmarks, outside the workspace so they never show up in its
+// version control
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// mu serializes updates, so concurrent tool calls do not lose each other's
+// changes
+var mu sync.Mutex
+
+// Dir returns the directory holding the state of the workspace at root, in
+// the user's cache directory. It is named after the workspace and a hash of
+// its path, so workspaces with the same base name stay apart.
+func Dir(root string) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no directory to keep workspace state in: %w", err)
+	}
+	root = utils.CanonicalPath(root)
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(cache, "mcp-gopls", "workspaces", filepath.Base(root)+"-"+hex.EncodeToString(sum[:6])), nil
+}
+
+// Load reads the state file name of the workspace into v. A missing file
+// leaves v as it is.
+func Load(root, name string, v interface{}) error {
+	return Update(root, name, v, func() (bool, error) { return false, nil })
+}
+
+// Update loads the state file name of the workspace into v, calls fn and,
+// if fn returns true, saves v back. A missing file leaves v as it is.
+func Update(root, name string, v interface{}, fn func() (bool, error)) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	dir, err := Dir(root)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, name)
+	content, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(content, v); err != nil {
+			return fmt.Errorf("invalid state file %s: %w", path, err)
+		}
+	}
+
+	save, err := fn()
+	if err != nil || !save {
+		return err
+	}
+	content, err = json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Write and rename, so a crash never leaves a truncated file
+	tmp, err := os.CreateTemp(dir, name+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(content, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,233 @@
+// Package telemetry records which tools are called, how long they take and
+// how they fail, to a local JSONL file, for finding slow and failing tools.
+// Nothing is recorded unless a file is configured, and nothing is sent
+// anywhere: events hold no arguments, paths or results.
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/tools"
+)
+
+// maxFileSize is the size at which the telemetry file is moved to
+// <file>.1, replacing the previous one, and a new file started
+const maxFileSize = 8 << 20
+
+// Event is one tool call
+type Event struct {
+	Time     time.Time `json:"time"`
+	Tool     string    `json:"tool"`
+	Duration float64   `json:"durationMs"`
+	// Error is the category of the failure, see Categorize, or empty for a
+	// call that succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// DefaultFile returns the file telemetry is recorded to when enabled
+// without one, in the user's cache directory
+func DefaultFile() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no directory to record telemetry in: %w", err)
+	}
+	return filepath.Join(cache, "mcp-gopls", "telemetry.jsonl"), nil
+}
+
+// Recorder appends events to a JSONL file
+type Recorder struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewRecorder returns a recorder appending to path, which is created when
+// the first event is recorded
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// Middleware records every call made through it. Failing to record never
+// fails the call.
+func (r *Recorder) Middleware() tools.Middleware {
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+			r.Record(Event{
+				Time:     start.UTC().Truncate(time.Second),
+				Tool:     tool.Name,
+				Duration: float64(time.Since(start).Microseconds()) / 1000,
+				Error:    Categorize(result, err),
+			})
+			return result, err
+		}
+	}
+}
+
+// Record appends event to the file
+func (r *Recorder) Record(event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	if info, err := os.Stat(r.path); err == nil && info.Size() >= maxFileSize {
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Categorize names the way a call failed without recording its message,
+// which may hold code or paths: timeout, canceled, invalid_arguments,
+// not_found, gopls, tool_error for an error result, or error. It returns
+// an empty string for a call that succeeded.
+func Categorize(result *mcp.CallToolResult, err error) string {
+	if err == nil {
+		if result != nil && result.IsError {
+			return "tool_error"
+		}
+		return ""
+	}
+	message := strings.ToLower(err.Error())
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), strings.Contains(message, "timed out"):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case strings.Contains(message, "argument"), strings.Contains(message, "invalid"), strings.Contains(message, "must be"):
+		return "invalid_arguments"
+	case errors.Is(err, os.ErrNotExist), strings.Contains(message, "not found"), strings.Contains(message, "no such file"):
+		return "not_found"
+	case strings.Contains(message, "gopls"):
+		return "gopls"
+	default:
+		return "error"
+	}
+}
+
+// ToolReport summarizes the recorded calls of one tool
+type ToolReport struct {
+	Tool   string         `json:"tool"`
+	Calls  int            `json:"calls"`
+	Errors map[string]int `json:"errors,omitempty"`
+	P50    float64        `json:"p50Ms"`
+	P95    float64        `json:"p95Ms"`
+	Max    float64        `json:"maxMs"`
+}
+
+// Report summarizes a telemetry file
+type Report struct {
+	File  string       `json:"file"`
+	From  time.Time    `json:"from"`
+	To    time.Time    `json:"to"`
+	Calls int          `json:"calls"`
+	Tools []ToolReport `json:"tools"`
+}
+
+// Summarize reads the events recorded in path and reports the calls,
+// failures and latency of each tool, slowest first by 95th percentile
+func Summarize(path string) (Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Report{}, err
+	}
+	defer f.Close()
+
+	report := Report{File: path, Tools: make([]ToolReport, 0)}
+	durations := make(map[string][]float64)
+	byTool := make(map[string]*ToolReport)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event Event
+		if json.Unmarshal(scanner.Bytes(), &event) != nil || event.Tool == "" {
+			continue
+		}
+		if report.From.IsZero() || event.Time.Before(report.From) {
+			report.From = event.Time
+		}
+		if event.Time.After(report.To) {
+			report.To = event.Time
+		}
+		report.Calls++
+		tool := byTool[event.Tool]
+		if tool == nil {
+			tool = &ToolReport{Tool: event.Tool}
+			byTool[event.Tool] = tool
+		}
+		tool.Calls++
+		if event.Error != "" {
+			if tool.Errors == nil {
+				tool.Errors = make(map[string]int)
+			}
+			tool.Errors[event.Error]++
+		}
+		durations[event.Tool] = append(durations[event.Tool], event.Duration)
+	}
+	if err := scanner.Err(); err != nil {
+		return Report{}, err
+	}
+
+	for name, tool := range byTool {
+		d := durations[name]
+		sort.Float64s(d)
+		tool.P50 = percentile(d, 50)
+		tool.P95 = percentile(d, 95)
+		tool.Max = d[len(d)-1]
+		report.Tools = append(report.Tools, *tool)
+	}
+	sort.Slice(report.Tools, func(i, j int) bool {
+		if report.Tools[i].P95 != report.Tools[j].P95 {
+			return report.Tools[i].P95 > report.Tools[j].P95
+		}
+		return report.Tools[i].Tool < report.Tools[j].Tool
+	})
+	return report, nil
+}
+
+// Export writes the summary of the events recorded in path to w as JSON
+func Export(path string, w io.Writer) error {
+	report, err := Summarize(path)
+	if err != nil {
+		return err
+	}
+	output, _ := json.MarshalIndent(report, "", "  ")
+	_, err = fmt.Fprintf(w, "%s\n", output)
+	return err
+}
+
+// percentile returns the nearest-rank percentile p of sorted
+func percentile(sorted []float64, p int) float64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Activity tracks when the server last handled an MCP request and how many
+// tool calls are running, so an idle server can exit
+type Activity struct {
+	mu      sync.Mutex
+	last    time.Time
+	running int
+}
+
+// NewActivity returns a tracker that counts the server as active now
+func NewActivity() *Activity {
+	return &Activity{last: time.Now()}
+}
+
+// Touch records activity
+func (a *Activity) Touch() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.last = time.Now()
+}
+
+// Idle returns how long the server has gone without activity. It is zero
+// while a tool call is running.
+func (a *Activity) Idle() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.running > 0 {
+		return 0
+	}
+	return time.Since(a.last)
+}
+
+// Middleware counts a tool call as activity for as long as it runs
+func (a *Activity) Middleware() Middleware {
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			a.mu.Lock()
+			a.running++
+			a.mu.Unlock()
+			defer func() {
+				a.mu.Lock()
+				a.running--
+				a.last = time.Now()
+				a.mu.Unlock()
+			}()
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package add_test_case
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "AddTableTestCase",
+		Description: "Append a case to a table-driven test, writing the fields in the order the case struct declares them and in the layout of the existing cases. Works with slice tables ([]struct{...}{...} or []testCase{...}) and map tables keyed by case name.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the _test.go file",
+				},
+				"test": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the test function containing the table, e.g. TestParse",
+				},
+				"fields": map[string]interface{}{
+					"type":                 "object",
+					"description":          "Field values of the new case as Go expressions, e.g. {\"input\": \"\\\"a,b\\\"\", \"want\": \"[]string{\\\"a\\\", \\\"b\\\"}\", \"wantErr\": \"false\"}",
+					"additionalProperties": map[string]interface{}{"type": "string"},
+				},
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Case name. It is the key of a map table, or sets the name, desc or description field of a slice table when that field is not given in fields.",
+				},
+				"table": map[string]interface{}{
+					"type":        "string",
+					"description": "Variable holding the table, when the test has more than one",
+				},
+				"dryRun": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Report the change as a unified diff without modifying the file",
+					"default":     false,
+				},
+			},
+			Required: []string{"file", "test", "fields"},
+		},
+	}
+}
+
+// nameFields are the fields a case name is written to when the table is a
+// slice, in order of preference
+var nameFields = []string{"name", "desc", "description", "title", "scenario"}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		testName, err := request.RequireString("test")
+		if err != nil {
+			return nil, err
+		}
+		fields := make(map[string]string)
+		if raw, ok := request.GetArguments()["fields"].(map[string]interface{}); ok {
+			for name, value := range raw {
+				text, ok := value.(string)
+				if !ok {
+					text = fmt.Sprint(value)
+				}
+				if _, err := parser.ParseExpr(text); err != nil {
+					return nil, fmt.Errorf("value of field %s is not a Go expression: %w", name, err)
+				}
+				fields[name] = text
+			}
+		}
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("fields cannot be empty")
+		}
+		caseName := request.GetString("name", "")
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fset := token.NewFileSet()
+		astFile, err := parser.ParseFile(fset, file, content, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		fn := findFunc(astFile, testName)
+		if fn == nil {
+			return nil, fmt.Errorf("no function %s in %s", testName, file)
+		}
+		table, err := findTable(fn, request.GetString("table", ""))
+		if err != nil {
+			return nil, err
+		}
+
+		structFields, err := caseFields(fset, file, table.elem)
+		if err != nil {
+			return nil, err
+		}
+		if table.isMap {
+			if caseName == "" {
+				return nil, fmt.Errorf("the table is a map keyed by case name, so name is required")
+			}
+		} else if caseName != "" {
+			field := ""
+			for _, candidate := range nameFields {
+				for _, f := range structFields {
+					if strings.EqualFold(f, candidate) && field == "" {
+						field = f
+					}
+				}
+			}
+			if field == "" {
+				return nil, fmt.Errorf("the case struct has no name field to hold %q; pass it in fields instead", caseName)
+			}
+			if _, ok := fields[field]; !ok {
+				fields[field] = fmt.Sprintf("%q", caseName)
+			}
+		}
+
+		ordered, err := orderFields(fields, structFields)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := insertCase(fset, content, table, ordered, caseName)
+		if err != nil {
+			return nil, err
+		}
+		// Keep a gofmt'd file gofmt'd, which also aligns the new fields
+		if formatted, err := format.Source(content); err == nil && bytes.Equal(formatted, content) {
+			if formatted, err := format.Source(updated); err == nil {
+				updated = formatted
+			}
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), file, updated, parser.SkipObjectResolution); err != nil {
+			return nil, fmt.Errorf("the new case does not parse: %w", err)
+		}
+
+		diff := edits.Unified(edits.Label(manager.WorkspaceRoot(), file), string(content), string(updated))
+		if request.GetBool("dryRun", false) {
+			return mcp.NewToolResultText(fmt.Sprintf("Would add a case to %s:\n%s", testName, diff)), nil
+		}
+		if err := manager.WriteFiles(map[string][]byte{file: updated}); err != nil {
+			return nil, err
+		}
+		msg := fmt.Sprintf("Added a case to %s:\n%s", testName, diff)
+		if err := manager.NotifyFileWritten(ctx, file, false); err != nil {
+			msg += fmt.Sprintf("\nNote: gopls was not notified of the change: %v", err)
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+func findFunc(file *ast.File, name string) *ast.FuncDecl {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == name && fn.Body != nil {
+			return fn
+		}
+	}
+	return nil
+}
+
+// table is a composite literal of test cases
+type table struct {
+	name  string
+	lit   *ast.CompositeLit
+	elem  ast.Expr // the case type
+	isMap bool
+}
+
+// findTable finds the slice or map literal of struct cases in fn, choosing
+// the one assigned to name when it is set
+func findTable(fn *ast.FuncDecl, name string) (*table, error) {
+	var tables []*table
+	seen := make(map[*ast.CompositeLit]bool)
+	consider := func(varName string, expr ast.Expr) {
+		lit, ok := expr.(*ast.CompositeLit)
+		if !ok || seen[lit] {
+			return
+		}
+		t := &table{name: varName, lit: lit}
+		switch typ := lit.Type.(type) {
+		case *ast.ArrayType:
+			t.elem = typ.Elt
+		case *ast.MapType:
+			t.elem, t.isMap = typ.Value, true
+		default:
+			return
+		}
+		if _, ok := t.elem.(*ast.StructType); !ok {
+			// A named case type must have cases written as composite
+			// literals, which rules out tables like []string{...}
+			if _, ok := t.elem.(*ast.Ident); !ok || len(lit.Elts) == 0 {
+				return
+			}
+			for _, elt := range lit.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					elt = kv.Value
+				}
+				if _, ok := elt.(*ast.CompositeLit); !ok {
+					return
+				}
+			}
+		}
+		seen[lit] = true
+		tables = append(tables, t)
+	}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) == 1 && len(n.Rhs) == 1 {
+				if ident, ok := n.Lhs[0].(*ast.Ident); ok {
+					consider(ident.Name, n.Rhs[0])
+				}
+			}
+		case *ast.ValueSpec:
+			for i, value := range n.Values {
+				if i < len(n.Names) {
+					consider(n.Names[i].Name, value)
+				}
+			}
+		case *ast.RangeStmt:
+			consider("", n.X)
+		}
+		return true
+	})
+
+	if name != "" {
+		for _, t := range tables {
+			if t.name == name {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("no table of test cases named %s in %s", name, fn.Name.Name)
+	}
+	switch len(tables) {
+	case 0:
+		return nil, fmt.Errorf("no table of test cases found in %s", fn.Name.Name)
+	case 1:
+		return tables[0], nil
+	}
+	var names []string
+	for _, t := range tables {
+		if t.name != "" {
+			names = append(names, t.name)
+		}
+	}
+	return nil, fmt.Errorf("%s has %d tables of test cases; choose one with table: %s", fn.Name.Name, len(tables), strings.Join(names, ", "))
+}
+
+// caseFields returns the field names of the case type in declaration order.
+// Named types are looked up in the files of the test's directory.
+func caseFields(fset *token.FileSet, file string, elem ast.Expr) ([]string, error) {
+	if st, ok := elem.(*ast.StructType); ok {
+		return structFieldNames(st), nil
+	}
+	name := elem.(*ast.Ident).Name
+	paths, _ := filepath.Glob(filepath.Join(filepath.Dir(file), "*.go"))
+	for _, path := range paths {
+		f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != name {
+					continue
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					return nil, fmt.Errorf("case type %s is not a struct", name)
+				}
+				return structFieldNames(st), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("case type %s not found in %s", name, filepath.Dir(file))
+}
+
+func structFieldNames(st *ast.StructType) []string {
+	var names []string
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			// Embedded fields are named after their type
+			typ := field.Type
+			if star, ok := typ.(*ast.StarExpr); ok {
+				typ = star.X
+			}
+			if sel, ok := typ.(*ast.SelectorExpr); ok {
+				typ = sel.Sel
+			}
+			if ident, ok := typ.(*ast.Ident); ok {
+				names = append(names, ident.Name)
+			}
+			continue
+		}
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+type fieldValue struct {
+	name  string
+	value string
+}
+
+// orderFields puts the given values in the struct's field order
+func orderFields(values map[string]string, order []string) ([]fieldValue, error) {
+	position := make(map[string]int, len(order))
+	for i, name := range order {
+		position[name] = i
+	}
+	var unknown []string
+	ordered := make([]fieldValue, 0, len(values))
+	for name, value := range values {
+		if _, ok := position[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		ordered = append(ordered, fieldValue{name, value})
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("the case struct has no field %s; its fields are %s", strings.Join(unknown, ", "), strings.Join(order, ", "))
+	}
+	sort.Slice(ordered, func(i, j int) bool { return position[ordered[i].name] < position[ordered[j].name] })
+	return ordered, nil
+}
+
+// insertCase appends a case to the table, copying the layout of the last
+// existing case: one field per line or all on one line
+func insertCase(fset *token.FileSet, content []byte, t *table, fields []fieldValue, name string) ([]byte, error) {
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	rbrace := offset(t.lit.Rbrace)
+
+	indent := lineIndent(content, offset(t.lit.Pos())) + "\t"
+	multiline := true
+	if n := len(t.lit.Elts); n > 0 {
+		last := t.lit.Elts[n-1]
+		indent = lineIndent(content, offset(last.Pos()))
+		if kv, ok := last.(*ast.KeyValueExpr); ok {
+			last = kv.Value
+		}
+		if lit, ok := last.(*ast.CompositeLit); ok {
+			multiline = fset.Position(lit.Lbrace).Line != fset.Position(lit.Rbrace).Line
+		}
+	}
+
+	var elem strings.Builder
+	if t.isMap {
+		fmt.Fprintf(&elem, "%q: ", name)
+	}
+	elem.WriteString("{")
+	for i, f := range fields {
+		if multiline {
+			fmt.Fprintf(&elem, "\n%s\t%s: %s,", indent, f.name, f.value)
+		} else {
+			if i > 0 {
+				elem.WriteString(", ")
+			}
+			fmt.Fprintf(&elem, "%s: %s", f.name, f.value)
+		}
+	}
+	if multiline {
+		elem.WriteString("\n" + indent)
+	}
+	elem.WriteString("}")
+
+	var out bytes.Buffer
+	closingOwnLine := strings.TrimSpace(string(content[lineStart(content, rbrace):rbrace])) == ""
+	switch {
+	case closingOwnLine:
+		// Insert before the line holding the closing brace
+		at := lineStart(content, rbrace)
+		out.Write(content[:at])
+		fmt.Fprintf(&out, "%s%s,\n", indent, elem.String())
+		out.Write(content[at:])
+	case len(t.lit.Elts) == 0:
+		out.Write(content[:rbrace])
+		fmt.Fprintf(&out, "\n%s%s,\n%s", indent, elem.String(), strings.TrimSuffix(indent, "\t"))
+		out.Write(content[rbrace:])
+	default:
+		// The table is on one line, so keep the case on it too
+		at := offset(t.lit.Elts[len(t.lit.Elts)-1].End())
+		out.Write(content[:at])
+		switch rest := strings.TrimSpace(string(content[at:rbrace])); rest {
+		case "", ",":
+			out.WriteString(",")
+		default:
+			return nil, fmt.Errorf("unexpected text %q after the last case", rest)
+		}
+		fmt.Fprintf(&out, " %s", elem.String())
+		out.Write(content[rbrace:])
+	}
+	return out.Bytes(), nil
+}
+
+func lineStart(content []byte, offset int) int {
+	return bytes.LastIndexByte(content[:offset], '\n') + 1
+}
+
+func lineIndent(content []byte, offset int) string {
+	start := lineStart(content, offset)
+	end := start
+	for end < len(content) && (content[end] == '\t' || content[end] == ' ') {
+		end++
+	}
+	return string(content[start:end])
+}
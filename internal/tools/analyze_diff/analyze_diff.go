@@ -0,0 +1,448 @@
+package analyze_diff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/resultfilter"
+	"github.com/yantrio/mcp-gopls/internal/unidiff"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "AnalyzeDiff",
+		Description: "Analyze a change for code review: map a unified diff, or the diff of a git revision range, onto the Go declarations it touches, report the diagnostics gopls gives on the changed lines, and find references from code outside the diff to the changed symbols, which may need updating too",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"diff": map[string]interface{}{
+					"type":        "string",
+					"description": "Unified diff to analyze, e.g. the output of git diff, with paths relative to the workspace root or the repository root. The new side must match the files on disk.",
+				},
+				"revisions": map[string]interface{}{
+					"type":        "string",
+					"description": "Git revision range to diff when no diff is given: 'main...HEAD' for a branch's changes, 'HEAD~1' to compare the working tree with a commit. Defaults to the uncommitted changes against HEAD.",
+				},
+				"includeReferences": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Find references from outside the diff to the changed symbols",
+					"default":     true,
+				},
+				"maxSymbols": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum number of changed symbols to look up references for",
+					"default":     30,
+				},
+				"maxReferences": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum number of outside references listed per symbol; all are counted",
+					"default":     10,
+				},
+				"settleMs": map[string]interface{}{
+					"type":        "number",
+					"description": "Time gopls must go without publishing diagnostics before they are considered complete",
+					"default":     1000,
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"description": "'report' for the summary and JSON report, 'rdjson' for reviewdog's diagnostic format, or 'github' for GitHub check run annotations. The annotation formats list the diagnostics on changed lines and, as notices, the changed symbols referenced from outside the diff, with paths relative to the repository root.",
+					"enum":        []string{formatReport, formatRDJSON, formatGitHub},
+					"default":     formatReport,
+				},
+				"includeVendor": resultfilter.IncludeVendorProperty(manager),
+			},
+		},
+	}
+}
+
+type symbol struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	// Added is set when the whole declaration is new
+	Added        bool `json:"added,omitempty"`
+	ChangedLines int  `json:"changedLines"`
+	// OutsideReferences counts the references from code the diff does not
+	// touch
+	OutsideReferences *int        `json:"outsideReferences,omitempty"`
+	References        []reference `json:"references,omitempty"`
+
+	uri      string
+	position lsp.Position
+}
+
+type reference struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Preview string `json:"preview,omitempty"`
+}
+
+type diagnostic struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	Severity  string `json:"severity"`
+	Source    string `json:"source,omitempty"`
+	Code      string `json:"code,omitempty"`
+	CodeURL   string `json:"codeDescription,omitempty"`
+	Message   string `json:"message"`
+}
+
+type report struct {
+	Files       []string     `json:"files"`
+	Skipped     []string     `json:"skipped,omitempty"`
+	Symbols     []*symbol    `json:"symbols"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+	Busy        bool         `json:"busy,omitempty"`
+}
+
+// changedFile is a Go file of the diff with the new-file lines it changes
+type changedFile struct {
+	path  string
+	uri   string
+	lines map[int]bool
+	added map[int]bool
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		format := request.GetString("format", formatReport)
+		if format != formatReport && format != formatRDJSON && format != formatGitHub {
+			return nil, fmt.Errorf("invalid format %q: must be '%s', '%s' or '%s'", format, formatReport, formatRDJSON, formatGitHub)
+		}
+
+		root := manager.WorkspaceRoot()
+		diff := request.GetString("diff", "")
+		bases := []string{root}
+		if diff == "" {
+			gitDiff, repoRoot, err := unidiff.Git(ctx, root, request.GetString("revisions", ""))
+			if err != nil {
+				return nil, err
+			}
+			diff, bases = gitDiff, []string{repoRoot}
+		} else if repoRoot, err := unidiff.GitRoot(ctx, root); err == nil && repoRoot != root {
+			// Paths in a pasted diff may be relative to either root
+			bases = append(bases, repoRoot)
+		}
+
+		diffFiles, err := unidiff.Parse(diff)
+		if err != nil {
+			return nil, err
+		}
+
+		result := report{Files: make([]string, 0), Symbols: make([]*symbol, 0), Diagnostics: make([]diagnostic, 0)}
+		filter := resultfilter.FromRequest(manager, request)
+		var files []*changedFile
+		for _, f := range diffFiles {
+			if f.NewPath == "" || !strings.HasSuffix(f.NewPath, ".go") {
+				continue
+			}
+			path := locate(bases, f.NewPath)
+			if path != "" {
+				path = manager.ResolvePath(path)
+			}
+			if path == "" || manager.ValidateFile(ctx, path) != nil || !filter.Keep(path) {
+				result.Skipped = append(result.Skipped, f.NewPath)
+				continue
+			}
+			uri, err := utils.PathToURI(path)
+			if err != nil {
+				return nil, err
+			}
+			cf := &changedFile{path: path, uri: uri, lines: f.Lines(), added: make(map[int]bool)}
+			for _, hunk := range f.Hunks {
+				for _, line := range hunk.Added {
+					cf.added[line] = true
+				}
+			}
+			files = append(files, cf)
+			result.Files = append(result.Files, path)
+		}
+		// Annotation paths are relative to the repository root when the diff's
+		// paths may be
+		annotationRoot := bases[len(bases)-1]
+		if len(files) == 0 {
+			if format != formatReport {
+				return annotate(result, format, annotationRoot), nil
+			}
+			return mcp.NewToolResultText("The diff changes no Go files in the workspace"), nil
+		}
+
+		for _, cf := range files {
+			symbols, err := touchedSymbols(cf)
+			if err != nil {
+				return nil, err
+			}
+			result.Symbols = append(result.Symbols, symbols...)
+		}
+
+		client, err := manager.GetClient()
+		if err != nil {
+			return nil, err
+		}
+		for _, cf := range files {
+			content, err := manager.ReadFile(cf.path)
+			if err != nil {
+				return nil, err
+			}
+			if err := client.OpenDocument(ctx, cf.uri, string(content)); err != nil {
+				return nil, err
+			}
+			defer client.CloseDocument(ctx, cf.uri)
+		}
+
+		settle := time.Duration(request.GetInt("settleMs", 1000)) * time.Millisecond
+		waitCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		err = client.WaitForDiagnostics(waitCtx, settle)
+		cancel()
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		result.Busy = err != nil
+		result.Diagnostics = changedDiagnostics(client, files)
+
+		if request.GetBool("includeReferences", true) {
+			maxSymbols := request.GetInt("maxSymbols", 30)
+			maxReferences := request.GetInt("maxReferences", 10)
+			byPath := make(map[string]*changedFile, len(files))
+			for _, cf := range files {
+				byPath[cf.path] = cf
+			}
+			for i, sym := range result.Symbols {
+				if i >= maxSymbols {
+					break
+				}
+				if err := outsideReferences(ctx, manager, client, sym, byPath, filter, maxReferences); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if format != formatReport {
+			return annotate(result, format, annotationRoot), nil
+		}
+		output, _ := json.MarshalIndent(result, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("%s\n%s", summary(result), output)), nil
+	}
+}
+
+// locate finds a path from the diff under one of the roots it may be
+// relative to
+func locate(bases []string, rel string) string {
+	for _, base := range bases {
+		path := filepath.Join(base, filepath.FromSlash(rel))
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// touchedSymbols returns the package-level declarations of a file that
+// contain changed lines
+func touchedSymbols(cf *changedFile) ([]*symbol, error) {
+	src, err := os.ReadFile(cf.path)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, cf.path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil && file == nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", cf.path, err)
+	}
+
+	var symbols []*symbol
+	add := func(name *ast.Ident, kind string, node ast.Node, doc *ast.CommentGroup) {
+		start, end := fset.Position(node.Pos()).Line, fset.Position(node.End()).Line
+		if doc != nil {
+			start = fset.Position(doc.Pos()).Line
+		}
+		changed, added := 0, true
+		for line := start; line <= end; line++ {
+			if cf.lines[line] {
+				changed++
+			}
+			added = added && cf.added[line]
+		}
+		if changed == 0 || name.Name == "_" {
+			return
+		}
+		pos := fset.Position(name.Pos())
+		symbols = append(symbols, &symbol{
+			Name:         name.Name,
+			Kind:         kind,
+			File:         cf.path,
+			Line:         pos.Line,
+			Column:       pos.Column,
+			Added:        added,
+			ChangedLines: changed,
+			uri:          cf.uri,
+			position:     utils.ConvertPosition(pos.Line, pos.Column),
+		})
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			kind := "function"
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				kind = "method of " + astscan.ReceiverType(decl.Recv.List[0].Type)
+			}
+			add(decl.Name, kind, decl, decl.Doc)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				doc := decl.Doc
+				if len(decl.Specs) > 1 || decl.Lparen.IsValid() {
+					doc = nil
+				}
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					kind := "type"
+					switch spec.Type.(type) {
+					case *ast.StructType:
+						kind = "struct"
+					case *ast.InterfaceType:
+						kind = "interface"
+					}
+					add(spec.Name, kind, spec, doc)
+				case *ast.ValueSpec:
+					kind := "variable"
+					if decl.Tok == token.CONST {
+						kind = "constant"
+					}
+					for _, name := range spec.Names {
+						add(name, kind, spec, doc)
+					}
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// changedDiagnostics returns the diagnostics of the changed files that
+// overlap changed lines
+func changedDiagnostics(client *lsp.Client, files []*changedFile) []diagnostic {
+	all := client.AllDiagnostics().Diagnostics
+	result := make([]diagnostic, 0)
+	for _, cf := range files {
+		for _, diag := range all[cf.uri] {
+			overlaps := false
+			for line := diag.Range.Start.Line + 1; line <= diag.Range.End.Line+1; line++ {
+				overlaps = overlaps || cf.lines[line]
+			}
+			if !overlaps {
+				continue
+			}
+			line, column := utils.ConvertToUserPosition(diag.Range.Start)
+			endLine, endColumn := utils.ConvertToUserPosition(diag.Range.End)
+			d := diagnostic{
+				File:      cf.path,
+				Line:      line,
+				Column:    column,
+				EndLine:   endLine,
+				EndColumn: endColumn,
+				Severity:  severity(diag.Severity),
+				Source:    diag.Source,
+				Message:   diag.Message,
+			}
+			if diag.Code != nil {
+				d.Code = fmt.Sprint(diag.Code)
+			}
+			if diag.CodeDescription != nil {
+				d.CodeURL = diag.CodeDescription.Href
+			}
+			result = append(result, d)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].File != result[j].File {
+			return result[i].File < result[j].File
+		}
+		return result[i].Line < result[j].Line
+	})
+	return result
+}
+
+func severity(s lsp.DiagnosticSeverity) string {
+	switch s {
+	case lsp.DiagnosticSeverityWarning:
+		return "warning"
+	case lsp.DiagnosticSeverityInformation:
+		return "information"
+	case lsp.DiagnosticSeverityHint:
+		return "hint"
+	}
+	return "error"
+}
+
+// outsideReferences records the references to sym from lines the diff does
+// not change
+func outsideReferences(ctx context.Context, manager *gopls.Manager, client *lsp.Client, sym *symbol, changed map[string]*changedFile, filter resultfilter.Filter, max int) error {
+	locations, err := client.References(ctx, sym.uri, sym.position, false)
+	if err != nil {
+		return fmt.Errorf("failed to find references to %s: %w", sym.Name, err)
+	}
+	count := 0
+	sym.References = make([]reference, 0)
+	for _, loc := range locations {
+		path, err := manager.URIToPath(loc.URI)
+		if err != nil || !filter.Keep(path) {
+			continue
+		}
+		line, column := utils.ConvertToUserPosition(loc.Range.Start)
+		if cf := changed[path]; cf != nil && cf.lines[line] {
+			continue
+		}
+		count++
+		if len(sym.References) >= max {
+			continue
+		}
+		preview, _ := utils.ReadLine(path, line)
+		sym.References = append(sym.References, reference{File: path, Line: line, Column: column, Preview: strings.TrimSpace(preview)})
+	}
+	sym.OutsideReferences = &count
+	return nil
+}
+
+func summary(r report) string {
+	errorCount := 0
+	for _, diag := range r.Diagnostics {
+		if diag.Severity == "error" {
+			errorCount++
+		}
+	}
+	referenced := 0
+	for _, sym := range r.Symbols {
+		if sym.OutsideReferences != nil && *sym.OutsideReferences > 0 {
+			referenced++
+		}
+	}
+	text := fmt.Sprintf("The diff touches %d symbol(s) in %d Go file(s); %d diagnostic(s) (%d error(s)) on changed lines; %d changed symbol(s) are referenced from outside the diff",
+		len(r.Symbols), len(r.Files), len(r.Diagnostics), errorCount, referenced)
+	if r.Busy {
+		text += "; gopls had not finished diagnosing, so diagnostics may be incomplete"
+	}
+	return text
+}
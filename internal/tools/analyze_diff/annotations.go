@@ -0,0 +1,226 @@
+package analyze_diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+const (
+	formatReport = "report"
+	formatRDJSON = "rdjson"
+	formatGitHub = "github"
+)
+
+// rdjson is reviewdog's Diagnostic Format, read with reviewdog -f=rdjson
+type rdjson struct {
+	Source      rdSource       `json:"source"`
+	Diagnostics []rdDiagnostic `json:"diagnostics"`
+}
+
+type rdSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type rdDiagnostic struct {
+	Message  string     `json:"message"`
+	Location rdLocation `json:"location"`
+	Severity string     `json:"severity"`
+	Source   *rdSource  `json:"source,omitempty"`
+	Code     *rdCode    `json:"code,omitempty"`
+}
+
+type rdLocation struct {
+	Path  string  `json:"path"`
+	Range rdRange `json:"range"`
+}
+
+type rdRange struct {
+	Start rdPosition  `json:"start"`
+	End   *rdPosition `json:"end,omitempty"`
+}
+
+type rdPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column,omitempty"`
+}
+
+type rdCode struct {
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+// githubAnnotation is an annotation of the GitHub checks API, as accepted in
+// the output.annotations of a check run
+type githubAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	StartColumn     int    `json:"start_column,omitempty"`
+	EndColumn       int    `json:"end_column,omitempty"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title,omitempty"`
+	Message         string `json:"message"`
+	RawDetails      string `json:"raw_details,omitempty"`
+}
+
+// finding is one annotation, before it is rendered in a format
+type finding struct {
+	path               string
+	line, column       int
+	endLine, endColumn int
+	// severity is "error", "warning" or "info"
+	severity string
+	source   string
+	code     string
+	codeURL  string
+	title    string
+	message  string
+	details  string
+}
+
+// annotate renders the diagnostics on changed lines and the changed symbols
+// referenced from outside the diff as review annotations
+func annotate(r report, format, root string) *mcp.CallToolResult {
+	var findings []finding
+	for _, diag := range r.Diagnostics {
+		sev := diag.Severity
+		if sev != "error" && sev != "warning" {
+			sev = "info"
+		}
+		source := diag.Source
+		if source == "" {
+			source = "gopls"
+		}
+		title := source
+		if diag.Code != "" {
+			title = fmt.Sprintf("%s (%s)", source, diag.Code)
+		}
+		findings = append(findings, finding{
+			path:      annotationPath(root, diag.File),
+			line:      diag.Line,
+			column:    diag.Column,
+			endLine:   diag.EndLine,
+			endColumn: diag.EndColumn,
+			severity:  sev,
+			source:    source,
+			code:      diag.Code,
+			codeURL:   diag.CodeURL,
+			title:     title,
+			message:   diag.Message,
+		})
+	}
+	for _, sym := range r.Symbols {
+		if sym.OutsideReferences == nil || *sym.OutsideReferences == 0 {
+			continue
+		}
+		var details []string
+		for _, ref := range sym.References {
+			details = append(details, fmt.Sprintf("%s:%d:%d: %s", annotationPath(root, ref.File), ref.Line, ref.Column, ref.Preview))
+		}
+		if more := *sym.OutsideReferences - len(sym.References); more > 0 {
+			details = append(details, fmt.Sprintf("... and %d more", more))
+		}
+		findings = append(findings, finding{
+			path:     annotationPath(root, sym.File),
+			line:     sym.Line,
+			column:   sym.Column,
+			severity: "info",
+			source:   "AnalyzeDiff",
+			title:    fmt.Sprintf("%s is used outside this change", sym.Name),
+			message:  fmt.Sprintf("%s %s is changed and referenced from %d place(s) outside the diff; check that they still work with the change", sym.Kind, sym.Name, *sym.OutsideReferences),
+			details:  strings.Join(details, "\n"),
+		})
+	}
+
+	var output interface{}
+	if format == formatRDJSON {
+		output = toRDJSON(findings)
+	} else {
+		output = toGitHub(findings)
+	}
+	result, _ := json.MarshalIndent(output, "", "  ")
+	return mcp.NewToolResultText(string(result))
+}
+
+func toRDJSON(findings []finding) rdjson {
+	out := rdjson{
+		Source:      rdSource{Name: "gopls", URL: "https://pkg.go.dev/golang.org/x/tools/gopls"},
+		Diagnostics: make([]rdDiagnostic, 0, len(findings)),
+	}
+	for _, f := range findings {
+		d := rdDiagnostic{
+			Message:  f.message,
+			Severity: strings.ToUpper(f.severity),
+			Source:   &rdSource{Name: f.source},
+			Location: rdLocation{
+				Path:  f.path,
+				Range: rdRange{Start: rdPosition{Line: f.line, Column: f.column}},
+			},
+		}
+		if f.endLine > 0 {
+			d.Location.Range.End = &rdPosition{Line: f.endLine, Column: f.endColumn}
+		}
+		if f.code != "" {
+			d.Code = &rdCode{Value: f.code, URL: f.codeURL}
+		}
+		if f.details != "" {
+			d.Message += "\n" + f.details
+		}
+		out.Diagnostics = append(out.Diagnostics, d)
+	}
+	return out
+}
+
+func toGitHub(findings []finding) []githubAnnotation {
+	out := make([]githubAnnotation, 0, len(findings))
+	for _, f := range findings {
+		level := "notice"
+		switch f.severity {
+		case "error":
+			level = "failure"
+		case "warning":
+			level = "warning"
+		}
+		a := githubAnnotation{
+			Path:            f.path,
+			StartLine:       f.line,
+			EndLine:         f.line,
+			AnnotationLevel: level,
+			Title:           f.title,
+			Message:         f.message,
+			RawDetails:      f.details,
+		}
+		if f.endLine > f.line {
+			a.EndLine = f.endLine
+		}
+		// GitHub only accepts columns on single-line annotations
+		if a.EndLine == a.StartLine {
+			a.StartColumn = f.column
+			a.EndColumn = f.column
+			if f.endLine == f.line && f.endColumn > f.column {
+				a.EndColumn = f.endColumn
+			}
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// annotationPath returns a path relative to the repository root with
+// forward slashes, as review tools expect
+func annotationPath(root, path string) string {
+	for _, base := range []string{root, utils.CanonicalPath(root)} {
+		for _, p := range []string{path, utils.CanonicalPath(path)} {
+			if rel, err := filepath.Rel(base, p); err == nil && !strings.HasPrefix(rel, "..") {
+				return filepath.ToSlash(rel)
+			}
+		}
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,271 @@
+package audit_struct_tags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "AuditStructTags",
+		Description: "Audit json/yaml struct tags in a package for missing tags, duplicate names and naming-convention mismatches, optionally fixing them",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Package directory, absolute or relative to the workspace root",
+				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"description": "Tag keys to audit (defaults to json and yaml)",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"caseStyle": map[string]interface{}{
+					"type":        "string",
+					"description": "Expected naming convention for tag names",
+					"enum":        []string{"camel", "snake", "kebab", "pascal", "lower"},
+					"default":     "camel",
+				},
+				"fix": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Add missing tags, derived from field names in the chosen case style",
+					"default":     false,
+				},
+				"renameMismatched": map[string]interface{}{
+					"type":        "boolean",
+					"description": "When fixing, also rename tags that do not match the case style (changes the serialized format)",
+					"default":     false,
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+type issue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Struct   string `json:"struct"`
+	Field    string `json:"field"`
+	Tag      string `json:"tag"`
+	Kind     string `json:"issue"`
+	Current  string `json:"current,omitempty"`
+	Expected string `json:"expected,omitempty"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, err := request.RequireString("path")
+		if err != nil {
+			return nil, err
+		}
+		dir := manager.ResolvePath(path)
+
+		keys := request.GetStringSlice("tags", []string{"json", "yaml"})
+		style := request.GetString("caseStyle", "camel")
+		convert, ok := caseStyles[style]
+		if !ok {
+			return nil, fmt.Errorf("invalid caseStyle %q", style)
+		}
+		fix := request.GetBool("fix", false)
+		renameMismatched := request.GetBool("renameMismatched", false)
+
+		issues := make([]issue, 0)
+		fileEdits := make(map[string][]lsp.TextEdit)
+		err = astscan.Walk(dir, astscan.Options{SingleDir: true}, func(f *astscan.File) error {
+			ast.Inspect(f.AST, func(n ast.Node) bool {
+				spec, ok := n.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+				st, ok := spec.Type.(*ast.StructType)
+				if !ok {
+					return true
+				}
+				found, structEdits := auditStruct(f, spec.Name.Name, st, keys, convert, renameMismatched)
+				issues = append(issues, found...)
+				fileEdits[f.Path] = append(fileEdits[f.Path], structEdits...)
+				return true
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var fixed []string
+		if fix {
+			for file, textEdits := range fileEdits {
+				if len(textEdits) == 0 {
+					continue
+				}
+				if err := manager.ApplyToFile(file, textEdits); err != nil {
+					return nil, fmt.Errorf("failed to fix tags in %s: %w", file, err)
+				}
+				fixed = append(fixed, file)
+			}
+		}
+
+		if len(issues) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No struct tag issues found in %s", dir)), nil
+		}
+
+		result, _ := json.MarshalIndent(issues, "", "  ")
+		msg := fmt.Sprintf("Found %d struct tag issue(s):\n%s", len(issues), string(result))
+		if len(fixed) > 0 {
+			msg += fmt.Sprintf("\n\nFixed tags in %d file(s):\n  - %s", len(fixed), strings.Join(fixed, "\n  - "))
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+var caseStyles = map[string]func(string) string{
+	"camel":  utils.ToCamel,
+	"snake":  utils.ToSnake,
+	"kebab":  utils.ToKebab,
+	"pascal": utils.ToPascal,
+	"lower":  strings.ToLower,
+}
+
+// auditStruct reports tag issues for one struct and returns the edits that
+// would fix them. A tag key is only audited in structs where at least one
+// field already uses it, since other structs are presumably not serialized.
+func auditStruct(f *astscan.File, name string, st *ast.StructType, keys []string, convert func(string) string, renameMismatched bool) ([]issue, []lsp.TextEdit) {
+	var issues []issue
+	desired := make(map[*ast.Field]reflect.StructTag)
+
+	for _, key := range keys {
+		used := false
+		for _, field := range st.Fields.List {
+			if _, ok := fieldTag(field).Lookup(key); ok {
+				used = true
+				break
+			}
+		}
+		if !used {
+			continue
+		}
+
+		seen := make(map[string]string)
+		for _, field := range st.Fields.List {
+			if len(field.Names) != 1 || !field.Names[0].IsExported() {
+				continue
+			}
+			fieldName := field.Names[0].Name
+			line, _ := f.Position(field.Pos())
+			report := func(kind, current, expected string) {
+				issues = append(issues, issue{
+					File: f.Path, Line: line, Struct: name, Field: fieldName,
+					Tag: key, Kind: kind, Current: current, Expected: expected,
+				})
+			}
+
+			tag := desiredTag(desired, field)
+			value, ok := tag.Lookup(key)
+			expected := convert(fieldName)
+			if !ok {
+				report("missing", "", expected)
+				desired[field] = withTagName(tag, key, expected)
+				continue
+			}
+
+			tagName, _, _ := strings.Cut(value, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName == "" {
+				// An empty name means the field name is used as-is
+				tagName = fieldName
+			}
+			if other, dup := seen[tagName]; dup {
+				report("duplicate", tagName, fmt.Sprintf("unique name (also used by %s)", other))
+			}
+			seen[tagName] = fieldName
+
+			if tagName != expected {
+				report("mismatch", tagName, expected)
+				if renameMismatched {
+					desired[field] = withTagName(tag, key, expected)
+				}
+			}
+		}
+	}
+
+	var textEdits []lsp.TextEdit
+	for _, field := range st.Fields.List {
+		tag, ok := desired[field]
+		if !ok {
+			continue
+		}
+		literal := "`" + string(tag) + "`"
+		if field.Tag == nil {
+			line, column := f.Position(field.Type.End())
+			pos := lsp.Position{Line: line - 1, Character: column - 1}
+			textEdits = append(textEdits, lsp.TextEdit{Range: lsp.Range{Start: pos, End: pos}, NewText: " " + literal})
+			continue
+		}
+		startLine, startColumn := f.Position(field.Tag.Pos())
+		endLine, endColumn := f.Position(field.Tag.End())
+		textEdits = append(textEdits, lsp.TextEdit{
+			Range: lsp.Range{
+				Start: lsp.Position{Line: startLine - 1, Character: startColumn - 1},
+				End:   lsp.Position{Line: endLine - 1, Character: endColumn - 1},
+			},
+			NewText: literal,
+		})
+	}
+
+	return issues, textEdits
+}
+
+// fieldTag returns the unquoted struct tag of a field
+func fieldTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag)
+}
+
+// desiredTag returns the tag a field should end up with so far
+func desiredTag(desired map[*ast.Field]reflect.StructTag, field *ast.Field) reflect.StructTag {
+	if tag, ok := desired[field]; ok {
+		return tag
+	}
+	return fieldTag(field)
+}
+
+// withTagName sets the name part of key in tag, keeping its options and the
+// other keys, or appends the key when it is not present
+func withTagName(tag reflect.StructTag, key, name string) reflect.StructTag {
+	value, ok := tag.Lookup(key)
+	if !ok {
+		if tag == "" {
+			return reflect.StructTag(fmt.Sprintf(`%s:"%s"`, key, name))
+		}
+		return reflect.StructTag(fmt.Sprintf(`%s %s:"%s"`, tag, key, name))
+	}
+	_, options, hasOptions := strings.Cut(value, ",")
+	newValue := name
+	if hasOptions {
+		newValue += "," + options
+	}
+	old := fmt.Sprintf(`%s:%s`, key, strconv.Quote(value))
+	return reflect.StructTag(strings.Replace(string(tag), old, fmt.Sprintf(`%s:%s`, key, strconv.Quote(newValue)), 1))
+}
@@ -0,0 +1,184 @@
+package audit_unsafe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+const (
+	categoryUnsafe   = "unsafe"
+	categoryLinkname = "linkname"
+	categoryReflect  = "reflect"
+	categoryCgo      = "cgo"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "AuditUnsafe",
+		Description: "Find uses of unsafe, //go:linkname, reflection-based memory tricks and cgo in the workspace, with locations and enclosing functions",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory to scan, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"categories": map[string]interface{}{
+					"type":        "array",
+					"description": "Only report these categories: 'unsafe', 'linkname', 'reflect', 'cgo'",
+					"items": map[string]interface{}{
+						"type": "string",
+						"enum": []string{categoryUnsafe, categoryLinkname, categoryReflect, categoryCgo},
+					},
+				},
+				"includeTests": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also scan _test.go files",
+					"default":     false,
+				},
+			},
+		},
+	}
+}
+
+type finding struct {
+	Category string `json:"category"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Function string `json:"function,omitempty"`
+	Detail   string `json:"detail"`
+}
+
+// reflectTricks are reflect package members that expose raw memory
+var reflectTricks = map[string]bool{
+	"SliceHeader":  true,
+	"StringHeader": true,
+	"NewAt":        true,
+}
+
+// unsafeMethods are reflect.Value methods that expose raw pointers
+var unsafeMethods = map[string]bool{
+	"UnsafeAddr":    true,
+	"UnsafePointer": true,
+	"SetPointer":    true,
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		root := manager.ResolvePath(request.GetString("path", ""))
+		wanted := make(map[string]bool)
+		for _, category := range request.GetStringSlice("categories", nil) {
+			switch category {
+			case categoryUnsafe, categoryLinkname, categoryReflect, categoryCgo:
+				wanted[category] = true
+			default:
+				return nil, fmt.Errorf("invalid category %q", category)
+			}
+		}
+
+		findings := make([]finding, 0)
+		err := astscan.Walk(root, astscan.Options{IncludeTests: request.GetBool("includeTests", false)}, func(f *astscan.File) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			for _, fd := range auditFile(f) {
+				if len(wanted) == 0 || wanted[fd.Category] {
+					findings = append(findings, fd)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(findings) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No unsafe, linkname, reflection or cgo usage found in %s", root)), nil
+		}
+
+		counts := make(map[string]int)
+		for _, fd := range findings {
+			counts[fd.Category]++
+		}
+		summary := make([]string, 0, len(counts))
+		for _, category := range []string{categoryUnsafe, categoryLinkname, categoryReflect, categoryCgo} {
+			if counts[category] > 0 {
+				summary = append(summary, fmt.Sprintf("%s: %d", category, counts[category]))
+			}
+		}
+
+		result, _ := json.MarshalIndent(findings, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d finding(s) (%s):\n%s", len(findings), strings.Join(summary, ", "), string(result))), nil
+	}
+}
+
+// auditFile collects all findings in a single file
+func auditFile(f *astscan.File) []finding {
+	var findings []finding
+	add := func(category string, node ast.Node, detail string) {
+		line, column := f.Position(node.Pos())
+		findings = append(findings, finding{
+			Category: category,
+			File:     filepath.Clean(f.Path),
+			Line:     line,
+			Column:   column,
+			Function: f.EnclosingFunc(node.Pos()),
+			Detail:   detail,
+		})
+	}
+
+	for _, imp := range f.AST.Imports {
+		switch strings.Trim(imp.Path.Value, `"`) {
+		case "C":
+			add(categoryCgo, imp, `import "C"`)
+		case "unsafe":
+			add(categoryUnsafe, imp, `import "unsafe"`)
+		}
+	}
+
+	for _, group := range f.AST.Comments {
+		for _, comment := range group.List {
+			switch {
+			case strings.HasPrefix(comment.Text, "//go:linkname"):
+				add(categoryLinkname, comment, comment.Text)
+			case strings.HasPrefix(comment.Text, "//export "):
+				add(categoryCgo, comment, comment.Text)
+			}
+		}
+	}
+
+	unsafeName := f.ImportName("unsafe")
+	reflectName := f.ImportName("reflect")
+	ast.Inspect(f.AST, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if pkg, ok := sel.X.(*ast.Ident); ok {
+			switch {
+			case unsafeName != "" && pkg.Name == unsafeName:
+				add(categoryUnsafe, sel, "unsafe."+sel.Sel.Name)
+				return true
+			case reflectName != "" && pkg.Name == reflectName && reflectTricks[sel.Sel.Name]:
+				add(categoryReflect, sel, "reflect."+sel.Sel.Name)
+				return true
+			}
+		}
+		if reflectName != "" && unsafeMethods[sel.Sel.Name] {
+			add(categoryReflect, sel.Sel, "."+sel.Sel.Name+"()")
+		}
+		return true
+	})
+
+	return findings
+}
@@ -0,0 +1,326 @@
+package bookmarks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/state"
+)
+
+// stateFile is the name of the workspace state file bookmarks are kept in
+const stateFile = "bookmarks.json"
+
+// Location is a bookmarked position. File is relative to the workspace
+// root so bookmarks survive moving the workspace; Text is the trimmed
+// content of the line, used to follow the position when lines are added or
+// removed above it.
+type Location struct {
+	Name    string    `json:"name"`
+	File    string    `json:"file"`
+	Line    int       `json:"line"`
+	Column  int       `json:"column,omitempty"`
+	Symbol  string    `json:"symbol,omitempty"`
+	Note    string    `json:"note,omitempty"`
+	Text    string    `json:"text"`
+	Saved   time.Time `json:"saved"`
+	Status  string    `json:"status,omitempty"`
+	OldLine int       `json:"previousLine,omitempty"`
+}
+
+type bookmarks struct {
+	Locations []Location `json:"locations"`
+}
+
+func NewSaveLocationTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "SaveLocation",
+		Description: "Bookmark an important position under a name, with the symbol there and a note on why it matters, so it can be recalled with ListLocations later in the session or in another one instead of being searched for again. Bookmarks are kept per workspace outside of it.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the bookmark, e.g. 'request dispatch'. Saving under an existing name replaces that bookmark.",
+				},
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "File of the position, absolute or relative to the workspace root (required unless remove is set)",
+				},
+				"line": map[string]interface{}{
+					"type":        "number",
+					"description": "Line number (1-indexed, required unless remove is set)",
+				},
+				"column": map[string]interface{}{
+					"type":        "number",
+					"description": "Column number (1-indexed); the identifier there becomes the symbol when none is given",
+				},
+				"symbol": map[string]interface{}{
+					"type":        "string",
+					"description": "Symbol at the position, e.g. Server.handle",
+				},
+				"note": map[string]interface{}{
+					"type":        "string",
+					"description": "Why the position matters",
+				},
+				"remove": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Delete the bookmark with this name instead of saving one",
+					"default":     false,
+				},
+			},
+			Required: []string{"name"},
+		},
+	}
+}
+
+func NewSaveLocationHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := request.RequireString("name")
+		if err != nil {
+			return nil, err
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("name cannot be empty")
+		}
+		root := manager.WorkspaceRoot()
+
+		if request.GetBool("remove", false) {
+			var saved bookmarks
+			found := false
+			err := state.Update(root, stateFile, &saved, func() (bool, error) {
+				kept := saved.Locations[:0]
+				for _, location := range saved.Locations {
+					if location.Name == name {
+						found = true
+						continue
+					}
+					kept = append(kept, location)
+				}
+				saved.Locations = kept
+				return found, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				return mcp.NewToolResultError(fmt.Sprintf("No bookmark named %q", name)), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Removed bookmark %q", name)), nil
+		}
+
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return nil, err
+		}
+		path := manager.ResolvePath(file)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		lines := strings.Split(string(content), "\n")
+		if line < 1 || line > len(lines) {
+			return nil, fmt.Errorf("line %d is outside %s, which has %d lines", line, file, len(lines))
+		}
+		text := strings.TrimRight(lines[line-1], "\r")
+
+		location := Location{
+			Name:   name,
+			File:   relative(root, path),
+			Line:   line,
+			Column: request.GetInt("column", 0),
+			Symbol: strings.TrimSpace(request.GetString("symbol", "")),
+			Note:   strings.TrimSpace(request.GetString("note", "")),
+			Text:   strings.TrimSpace(text),
+			Saved:  time.Now().UTC().Truncate(time.Second),
+		}
+		if location.Symbol == "" && location.Column > 0 {
+			location.Symbol = identifierAt(text, location.Column)
+		}
+
+		var saved bookmarks
+		replaced := false
+		err = state.Update(root, stateFile, &saved, func() (bool, error) {
+			for i, existing := range saved.Locations {
+				if existing.Name == name {
+					saved.Locations[i] = location
+					replaced = true
+					return true, nil
+				}
+			}
+			saved.Locations = append(saved.Locations, location)
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		verb := "Saved"
+		if replaced {
+			verb = "Replaced"
+		}
+		output, _ := json.MarshalIndent(withPath(root, location), "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("%s bookmark %q\n%s", verb, name, output)), nil
+	}
+}
+
+func NewListLocationsTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "ListLocations",
+		Description: "List the positions bookmarked with SaveLocation in this workspace. Bookmarks follow their line when code above it changes; ones whose line is gone are reported as changed.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "Only list bookmarks whose name, symbol, note or file contains this text, ignoring case",
+				},
+			},
+		},
+	}
+}
+
+func NewListLocationsHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query := strings.ToLower(strings.TrimSpace(request.GetString("query", "")))
+		root := manager.WorkspaceRoot()
+
+		var saved bookmarks
+		var listed []Location
+		err := state.Update(root, stateFile, &saved, func() (bool, error) {
+			moved := false
+			for i := range saved.Locations {
+				location := &saved.Locations[i]
+				if query != "" && !matches(*location, query) {
+					continue
+				}
+				current := follow(root, *location)
+				if current.Status == "moved" {
+					location.Line = current.Line
+					moved = true
+				}
+				listed = append(listed, withPath(root, current))
+			}
+			return moved, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(listed) == 0 {
+			if query != "" {
+				return mcp.NewToolResultText(fmt.Sprintf("No bookmarks match %q", query)), nil
+			}
+			return mcp.NewToolResultText("No bookmarks saved in this workspace. Save one with SaveLocation."), nil
+		}
+		sort.SliceStable(listed, func(i, j int) bool { return listed[i].Name < listed[j].Name })
+		output, _ := json.MarshalIndent(listed, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("%d bookmark(s):\n%s", len(listed), output)), nil
+	}
+}
+
+// follow finds where a bookmark is now: its line if the text is still
+// there, else the nearest line with the same text
+func follow(root string, location Location) Location {
+	content, err := os.ReadFile(withPath(root, location).File)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			location.Status = "missing"
+		} else {
+			location.Status = "unreadable"
+		}
+		return location
+	}
+	lines := strings.Split(string(content), "\n")
+	if location.Line <= len(lines) && strings.TrimSpace(lines[location.Line-1]) == location.Text {
+		return location
+	}
+	best := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) != location.Text {
+			continue
+		}
+		if best < 0 || distance(i+1, location.Line) < distance(best, location.Line) {
+			best = i + 1
+		}
+	}
+	if best < 0 {
+		location.Status = "changed"
+		return location
+	}
+	location.Status = "moved"
+	location.OldLine = location.Line
+	location.Line = best
+	return location
+}
+
+func distance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func matches(location Location, query string) bool {
+	for _, field := range []string{location.Name, location.Symbol, location.Note, location.File} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// identifierAt returns the identifier that the 1-indexed column of line
+// falls in
+func identifierAt(line string, column int) string {
+	runes := []rune(line)
+	i := column - 1
+	if i < 0 || i >= len(runes) || !isIdentRune(runes[i]) {
+		return ""
+	}
+	start, end := i, i
+	for start > 0 && isIdentRune(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && isIdentRune(runes[end]) {
+		end++
+	}
+	return string(runes[start:end])
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// relative returns path relative to root with forward slashes, or path
+// itself when it is outside root
+func relative(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// withPath returns the location with its file as an absolute path, as
+// other tools take it
+func withPath(root string, location Location) Location {
+	if !filepath.IsAbs(location.File) {
+		location.File = filepath.Join(root, filepath.FromSlash(location.File))
+	}
+	return location
+}
@@ -0,0 +1,272 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// summaryItems is the most items a summarized result lists per page
+const summaryItems = 20
+
+// maxSummaryString is the longest string field a summary keeps whole
+const maxSummaryString = 200
+
+// BudgetProperties are the schemas of the tokenBudget and cursor arguments
+// TokenBudget reads. budget is the server's default.
+func BudgetProperties(budget int) map[string]map[string]interface{} {
+	return map[string]map[string]interface{}{
+		"tokenBudget": {
+			"type":        "integer",
+			"description": "Estimated tokens the result may take. Larger results are summarized to counts and the first items, with a cursor for the rest. 0 for no limit.",
+			"default":     budget,
+		},
+		"cursor": {
+			"type":        "string",
+			"description": "nextCursor of a summarized result, to get the next items. Repeat the other arguments of the call unchanged.",
+		},
+	}
+}
+
+// cursor is the position of a page in a summarized result. It names the
+// tool so a cursor cannot be passed to another tool by mistake.
+type cursor struct {
+	Tool   string `json:"t"`
+	Field  string `json:"f,omitempty"`
+	Offset int    `json:"o"`
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s, tool string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(s))
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.Offset < 0 {
+		return cursor{}, fmt.Errorf("invalid cursor %q: pass the nextCursor of a previous result unchanged", s)
+	}
+	if c.Tool != tool {
+		return cursor{}, fmt.Errorf("the cursor is for %s, not %s", c.Tool, tool)
+	}
+	return c, nil
+}
+
+// EstimateTokens estimates the tokens text takes in a model's context, at
+// about four bytes a token
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// TokenBudget reports the estimated token count of every result and, when a
+// result exceeds the caller's tokenBudget, replaces it with a summary: the
+// size of each list in it and the first items of the largest, with a
+// cursor that pages through the rest by calling the tool again. budget is
+// the default for calls without a tokenBudget argument; zero only
+// summarizes calls that set one.
+func TokenBudget(budget int) Middleware {
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			limit := request.GetInt("tokenBudget", budget)
+			var page *cursor
+			if s := request.GetString("cursor", ""); s != "" {
+				c, err := decodeCursor(s, tool.Name)
+				if err != nil {
+					return nil, err
+				}
+				page = &c
+			}
+			if limit <= 0 && page == nil {
+				return next(ctx, request)
+			}
+
+			result, err := next(ctx, request)
+			if err != nil || result == nil || result.IsError {
+				return result, err
+			}
+			tokens := 0
+			for _, content := range result.Content {
+				if text, ok := content.(mcp.TextContent); ok {
+					tokens += EstimateTokens(text.Text)
+				}
+			}
+			if page == nil && tokens <= limit {
+				result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf("Estimated tokens: %d", tokens)))
+				return result, nil
+			}
+			if page == nil {
+				page = &cursor{Tool: tool.Name}
+			}
+			summarize(result, *page, tokens, limit)
+			return result, nil
+		}
+	}
+}
+
+// summary is a result cut down to a page of its largest list
+type summary struct {
+	Summarized      bool                   `json:"summarized"`
+	EstimatedTokens int                    `json:"estimatedTokens"`
+	TokenBudget     int                    `json:"tokenBudget,omitempty"`
+	Counts          map[string]int         `json:"counts"`
+	Fields          map[string]interface{} `json:"fields,omitempty"`
+	List            string                 `json:"list,omitempty"`
+	Offset          int                    `json:"offset"`
+	Items           []json.RawMessage      `json:"items"`
+	NextCursor      string                 `json:"nextCursor,omitempty"`
+}
+
+// summarize replaces the largest text content of result with the page of
+// it at c. JSON results are paged by the items of their largest array,
+// other text by lines. A limit of zero pages by item count alone.
+func summarize(result *mcp.CallToolResult, c cursor, tokens, limit int) {
+	largest := -1
+	for i, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if ok && (largest < 0 || len(text.Text) > len(result.Content[largest].(mcp.TextContent).Text)) {
+			largest = i
+		}
+	}
+	if largest < 0 {
+		return
+	}
+	text := result.Content[largest].(mcp.TextContent).Text
+
+	prefix, value, ok := splitJSON(text)
+	if !ok {
+		result.Content[largest] = mcp.NewTextContent(summarizeLines(text, c, tokens, limit))
+		return
+	}
+
+	s := summary{Summarized: true, EstimatedTokens: tokens, TokenBudget: limit, Counts: map[string]int{}, Offset: c.Offset}
+	var items []json.RawMessage
+	switch v := value.(type) {
+	case []interface{}:
+		items = rawItems(v)
+		s.Counts["items"] = len(v)
+	case map[string]interface{}:
+		if c.Field == "" {
+			c.Field = largestList(v)
+		}
+		s.Fields = make(map[string]interface{})
+		for name, field := range v {
+			switch field := field.(type) {
+			case []interface{}:
+				s.Counts[name] = len(field)
+			case map[string]interface{}:
+				s.Counts[name] = len(field)
+			case string:
+				// Long strings, such as diffs, would defeat the summary
+				if len(field) > maxSummaryString {
+					field = field[:maxSummaryString] + "..."
+				}
+				s.Fields[name] = field
+			default:
+				s.Fields[name] = field
+			}
+		}
+		if list, ok := v[c.Field].([]interface{}); ok {
+			items = rawItems(list)
+			s.List = c.Field
+		}
+	}
+
+	s.Items = []json.RawMessage{}
+	used := 0
+	for i := c.Offset; i < len(items) && len(s.Items) < summaryItems; i++ {
+		size := EstimateTokens(string(items[i]))
+		if limit > 0 && len(s.Items) > 0 && used+size > limit {
+			break
+		}
+		s.Items = append(s.Items, items[i])
+		used += size
+	}
+	if next := c.Offset + len(s.Items); next < len(items) {
+		c.Offset = next
+		s.NextCursor = c.encode()
+	}
+	// Compact, as the items were measured compact
+	output, _ := json.Marshal(s)
+	result.Content[largest] = mcp.NewTextContent(prefix + string(output))
+}
+
+// summarizeLines pages plain text by lines
+func summarizeLines(text string, c cursor, tokens, limit int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	var b strings.Builder
+	used, end := 0, c.Offset
+	for ; end < len(lines); end++ {
+		size := EstimateTokens(lines[end]) + 1
+		if end > c.Offset && (limit > 0 && used+size > limit || limit <= 0 && end-c.Offset >= summaryItems*5) {
+			break
+		}
+		b.WriteString(lines[end] + "\n")
+		used += size
+	}
+	header := fmt.Sprintf("Summarized: lines %d-%d of %d (about %d tokens, budget %d)", c.Offset+1, end, len(lines), tokens, limit)
+	if end < len(lines) {
+		c.Offset = end
+		header += fmt.Sprintf(". Pass cursor %q for the next lines.", c.encode())
+	}
+	return header + "\n" + b.String()
+}
+
+// splitJSON parses text that is a JSON value, or a line of prose followed by
+// one as many tools return, into the prose and the value
+func splitJSON(text string) (string, interface{}, bool) {
+	start := len(text) - len(strings.TrimLeft(text, " \t\r\n"))
+	if start == len(text) || text[start] != '{' && text[start] != '[' {
+		start = -1
+		for _, marker := range []string{"\n{", "\n["} {
+			if i := strings.Index(text, marker); i >= 0 && (start < 0 || i+1 < start) {
+				start = i + 1
+			}
+		}
+		if start < 0 {
+			return "", nil, false
+		}
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(text[start:]), &value); err != nil {
+		return "", nil, false
+	}
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+		return text[:start], value, true
+	}
+	return "", nil, false
+}
+
+// largestList returns the name of the array field of v that takes the most
+// space
+func largestList(v map[string]interface{}) string {
+	name, size := "", -1
+	for field, value := range v {
+		list, ok := value.([]interface{})
+		if !ok {
+			continue
+		}
+		data, _ := json.Marshal(list)
+		if len(data) > size || len(data) == size && field < name {
+			name, size = field, len(data)
+		}
+	}
+	return name
+}
+
+func rawItems(list []interface{}) []json.RawMessage {
+	items := make([]json.RawMessage, len(list))
+	for i, item := range list {
+		items[i], _ = json.Marshal(item)
+	}
+	return items
+}
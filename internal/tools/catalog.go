@@ -0,0 +1,234 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Guide is usage guidance for a tool beyond its description and schema:
+// example calls, mistakes agents commonly make with it, and the tools to
+// use instead or next. Descriptions show the first example and the
+// mistakes; ExplainTool returns all of it.
+type Guide struct {
+	Title    string    `json:"title,omitempty"`
+	Examples []Example `json:"examples,omitempty"`
+	Mistakes []string  `json:"commonMistakes,omitempty"`
+	Related  []string  `json:"related,omitempty"`
+}
+
+// Example is a call of a tool and what it does
+type Example struct {
+	Description string                 `json:"description"`
+	Arguments   map[string]interface{} `json:"arguments"`
+}
+
+// catalog holds the hand-written guidance, keyed by built-in tool name.
+// Mistakes that follow from a tool's schema, such as 1-indexed positions,
+// are added by GuideFor.
+var catalog = map[string]Guide{
+	"GoToDefinition": {
+		Title: "Go to definition",
+		Examples: []Example{
+			{"Find where the function called at line 42 is declared", map[string]interface{}{"file": "/src/app/server.go", "line": 42, "column": 15}},
+			{"Resolve several identifiers in one call", map[string]interface{}{"positions": []interface{}{
+				map[string]interface{}{"file": "/src/app/server.go", "line": 42, "column": 15},
+				map[string]interface{}{"file": "/src/app/handler.go", "line": 10, "column": 8},
+			}}},
+		},
+		Mistakes: []string{"The column must point into the identifier, not at the whitespace or dot before it"},
+		Related:  []string{"Hover", "FindReferences", "FindImplementers"},
+	},
+	"FindReferences": {
+		Title: "Find references",
+		Examples: []Example{
+			{"List the callers of the function declared at line 30, grouped by package", map[string]interface{}{"file": "/src/app/store.go", "line": 30, "column": 6, "aggregate": "summary"}},
+			{"Find only the places that assign to a field", map[string]interface{}{"file": "/src/app/config.go", "line": 12, "column": 2, "accessKind": "write"}},
+		},
+		Mistakes: []string{
+			"Searching for a name with grep finds unrelated identifiers with the same name; FindReferences follows the type checker",
+			"The declaration itself is left out unless includeDeclaration is true",
+		},
+		Related: []string{"RenameSymbol", "FindImplementers"},
+	},
+	"Hover": {
+		Title: "Hover",
+		Examples: []Example{
+			{"Show the signature and documentation of the identifier at a position", map[string]interface{}{"file": "/src/app/server.go", "line": 42, "column": 15}},
+			{"Get only the signature, as JSON", map[string]interface{}{"file": "/src/app/server.go", "line": 42, "column": 15, "concise": true, "format": "json"}},
+		},
+		Related: []string{"GoToDefinition", "StdlibDoc"},
+	},
+	"RenameSymbol": {
+		Title: "Rename symbol",
+		Examples: []Example{
+			{"Rename the type declared at line 8 everywhere it is used", map[string]interface{}{"file": "/src/app/user.go", "line": 8, "column": 6, "newName": "Account"}},
+		},
+		Mistakes: []string{
+			"Editing every occurrence with text replacement misses references in other packages and renames unrelated identifiers; use RenameSymbol",
+			"Exported names must stay capitalized to remain exported",
+		},
+		Related: []string{"FindReferences", "ReplaceText"},
+	},
+	"GetDiagnostics": {
+		Title: "Get diagnostics",
+		Examples: []Example{
+			{"List the errors in a file after editing it", map[string]interface{}{"file": "/src/app/server.go", "severity": "error"}},
+		},
+		Mistakes: []string{"Diagnostics are per file; use CheckWorkspace for the whole workspace"},
+		Related:  []string{"CheckWorkspace", "ExplainDiagnostic", "DiffDiagnostics"},
+	},
+	"SearchSymbol": {
+		Title: "Search symbols",
+		Examples: []Example{
+			{"Find declarations whose name contains Handler", map[string]interface{}{"query": "Handler"}},
+		},
+		Mistakes: []string{"query is a symbol name, not a regular expression or a file path"},
+		Related:  []string{"ListDocumentSymbols", "GoToDefinition"},
+	},
+	"ListDocumentSymbols": {
+		Title: "List file symbols",
+		Examples: []Example{
+			{"Outline a file", map[string]interface{}{"file": "/src/app/server.go"}},
+		},
+		Related: []string{"SummarizePackage", "SearchSymbol"},
+	},
+	"FindImplementers": {
+		Title: "Find implementations",
+		Examples: []Example{
+			{"Find the types implementing the interface declared at line 5", map[string]interface{}{"file": "/src/app/store.go", "line": 5, "column": 6}},
+		},
+		Mistakes: []string{"The position must be on the interface or method name, not on the interface keyword"},
+		Related:  []string{"GenerateMock", "GoToDefinition"},
+	},
+	"FormatCode": {
+		Title: "Format code",
+		Examples: []Example{
+			{"gofmt a file in place", map[string]interface{}{"file": "/src/app/server.go"}},
+		},
+		Related: []string{"OrganizeImports"},
+	},
+	"OrganizeImports": {
+		Title: "Organize imports",
+		Examples: []Example{
+			{"Add missing and remove unused imports after an edit", map[string]interface{}{"file": "/src/app/server.go"}},
+		},
+		Related: []string{"FormatCode", "GetDiagnostics"},
+	},
+	"ReplaceText": {
+		Title: "Replace text",
+		Examples: []Example{
+			{"Preview replacing a deprecated call across the workspace", map[string]interface{}{"pattern": `ioutil\.ReadFile\(`, "replacement": "os.ReadFile(", "dryRun": true}},
+		},
+		Mistakes: []string{
+			"pattern is a regular expression, so dots, parentheses and brackets must be escaped",
+			"To rename an identifier use RenameSymbol, which does not touch unrelated text",
+		},
+		Related: []string{"RewritePattern", "RenameSymbol"},
+	},
+	"RewritePattern": {
+		Title: "Rewrite pattern",
+		Examples: []Example{
+			{"Rewrite a call pattern structurally", map[string]interface{}{"rule": "errors.Wrap($err, $msg) -> fmt.Errorf($msg+\": %w\", $err)", "dryRun": true}},
+		},
+		Mistakes: []string{"Both sides of the rule must be Go expressions, not statements"},
+		Related:  []string{"ReplaceText"},
+	},
+	"CheckSnippet": {
+		Title: "Type-check snippet",
+		Examples: []Example{
+			{"Check that an expression compiles before adding it to a file", map[string]interface{}{"code": "strings.Cut(s, \"=\")", "imports": []interface{}{"strings"}}},
+		},
+		Related: []string{"RunSnippet"},
+	},
+	"RunSnippet": {
+		Title: "Run snippet",
+		Examples: []Example{
+			{"Run a few statements and see their output", map[string]interface{}{"code": "fmt.Println(strings.Fields(\" a b \"))", "imports": []interface{}{"fmt", "strings"}}},
+		},
+		Related: []string{"CheckSnippet"},
+	},
+	"AnalyzeDiff": {
+		Title: "Analyze diff",
+		Examples: []Example{
+			{"Review the commits of the current branch", map[string]interface{}{"revisions": "main...HEAD"}},
+			{"Produce GitHub check annotations for uncommitted changes", map[string]interface{}{"format": "github"}},
+		},
+		Related: []string{"DiffDiagnostics", "FindReferences"},
+	},
+	"FindImportCycles": {
+		Title: "Find import cycles",
+		Examples: []Example{
+			{"Check whether a package may import another before writing the import", map[string]interface{}{"from": "internal/storage", "to": "internal/api"}},
+		},
+		Related: []string{"CheckImportBoundaries"},
+	},
+	"SplitFile": {
+		Title: "Split file",
+		Examples: []Example{
+			{"Move two declarations into a new file of the same package", map[string]interface{}{"file": "/src/app/server.go", "symbols": []interface{}{"Config", "LoadConfig"}, "newFile": "config.go"}},
+		},
+		Mistakes: []string{"newFile is a file name in the same directory, not a path"},
+		Related:  []string{"MoveFile"},
+	},
+	"DeleteSymbol": {
+		Title: "Delete symbol",
+		Examples: []Example{
+			{"Delete a method and an unused helper", map[string]interface{}{"file": "/src/app/server.go", "symbols": []interface{}{"Server.legacyHandler", "parseOld"}, "dryRun": true}},
+		},
+		Mistakes: []string{"Methods are written as Type.Method"},
+		Related:  []string{"FindReferences"},
+	},
+}
+
+// GuideFor returns the guidance for a tool: its catalog entry, if any, with
+// the mistakes that follow from its input schema
+func GuideFor(tool mcp.Tool) Guide {
+	guide := catalog[tool.Name]
+	props := tool.InputSchema.Properties
+	_, hasLine := props["line"]
+	_, hasColumn := props["column"]
+	var generated []string
+	if hasLine && hasColumn {
+		generated = append(generated, "line and column are 1-indexed, as editors show them; do not pass the 0-indexed positions of LSP")
+	}
+	if _, ok := props["searchText"]; ok {
+		generated = append(generated, "After editing a file, remembered line numbers may be off; pass searchText instead of line and column")
+	}
+	if _, ok := props["positions"]; ok {
+		generated = append(generated, "To query several places, pass positions in one call instead of calling the tool repeatedly")
+	}
+	if _, ok := props["dryRun"]; ok {
+		generated = append(generated, "Pass dryRun: true first to review the change as a diff before files are written")
+	}
+	if len(generated) > 0 {
+		guide.Mistakes = append(append([]string(nil), generated...), guide.Mistakes...)
+	}
+	return guide
+}
+
+// WithGuide adds a tool's title and, to its description, its first example
+// and the common mistakes, so agents see them when picking a tool. explainer
+// is the name ExplainTool is exposed under, empty when it is not.
+func WithGuide(tool mcp.Tool, explainer string) mcp.Tool {
+	guide := GuideFor(tool)
+	if guide.Title != "" && tool.Annotations.Title == "" {
+		tool.Annotations.Title = guide.Title
+	}
+	var b strings.Builder
+	b.WriteString(tool.Description)
+	if len(guide.Examples) > 0 {
+		example := guide.Examples[0]
+		args, _ := json.Marshal(example.Arguments)
+		b.WriteString("\n\nExample (" + strings.ToLower(example.Description[:1]) + example.Description[1:] + "): " + string(args))
+	}
+	if len(guide.Mistakes) > 0 {
+		b.WriteString("\n\nCommon mistakes: " + strings.Join(guide.Mistakes, ". ") + ".")
+	}
+	if explainer != "" && (len(guide.Examples) > 1 || len(guide.Related) > 0) {
+		b.WriteString(" " + explainer + " has more examples and related tools.")
+	}
+	tool.Description = strings.TrimSpace(b.String())
+	return tool
+}
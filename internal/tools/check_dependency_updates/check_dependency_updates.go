@@ -0,0 +1,237 @@
+package check_dependency_updates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name: "CheckDependencyUpdates",
+		Description: "Report the modules of the workspace that have newer versions available, with the current and latest version of each, and optionally upgrade selected modules with go get followed by go mod tidy. " +
+			"Use PreviewUpgrade first to see which call sites an upgrade would break.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"directOnly": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Only check the modules required directly by the main module",
+					"default":     true,
+				},
+				"filter": map[string]interface{}{
+					"type":        "string",
+					"description": "Only check modules whose path contains this text",
+				},
+				"apply": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Modules to upgrade, e.g. github.com/spf13/cobra for its latest version or github.com/spf13/cobra@v1.8.1 for a given one, or 'all' for every update found. go.mod and go.sum are changed.",
+				},
+				"tidy": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Run go mod tidy after applying upgrades",
+					"default":     true,
+				},
+			},
+		},
+	}
+}
+
+// listedModule is the JSON object printed by 'go list -m -u -json'
+type listedModule struct {
+	Path     string
+	Version  string
+	Main     bool
+	Indirect bool
+	Update   *struct{ Version string }
+	Replace  *struct{ Path, Version string }
+	Error    *struct{ Err string }
+}
+
+type update struct {
+	Module  string `json:"module"`
+	Current string `json:"current"`
+	Latest  string `json:"latest"`
+	Direct  bool   `json:"direct"`
+	Major   bool   `json:"majorVersionChange,omitempty"`
+	Replace string `json:"replacedBy,omitempty"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		directOnly := request.GetBool("directOnly", true)
+		filter := request.GetString("filter", "")
+		apply := request.GetStringSlice("apply", nil)
+		tidy := request.GetBool("tidy", true)
+		root := manager.WorkspaceRoot()
+
+		updates, checked, errs, err := listUpdates(ctx, root, directOnly, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		report := map[string]interface{}{
+			"checked": checked,
+			"updates": updates,
+		}
+		if len(errs) > 0 {
+			report["errors"] = errs
+		}
+		output, _ := json.MarshalIndent(report, "", "  ")
+		summary := fmt.Sprintf("%d of %d module(s) have updates available:\n%s", len(updates), checked, output)
+		if len(apply) == 0 {
+			return mcp.NewToolResultText(summary), nil
+		}
+
+		targets, err := selectUpgrades(apply, updates)
+		if err != nil {
+			return nil, err
+		}
+		if len(targets) == 0 {
+			return mcp.NewToolResultText(summary + "\n\nNothing to upgrade"), nil
+		}
+		applied, err := upgrade(ctx, manager, targets, tidy)
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(summary + "\n\n" + applied), nil
+	}
+}
+
+// listUpdates runs 'go list -m -u' and returns the modules with a newer
+// version, the number of modules checked and the modules that failed
+func listUpdates(ctx context.Context, root string, directOnly bool, filter string) ([]update, int, []string, error) {
+	result, err := gocmd.Run(ctx, root, nil, "list", "-m", "-u", "-e", "-json", "all")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if result.ExitCode != 0 && strings.TrimSpace(result.Stdout) == "" {
+		return nil, 0, nil, fmt.Errorf("go list -m -u failed: %s", strings.TrimSpace(result.Stderr))
+	}
+
+	updates := make([]update, 0)
+	var errs []string
+	checked := 0
+	decoder := json.NewDecoder(strings.NewReader(result.Stdout))
+	for {
+		var mod listedModule
+		if err := decoder.Decode(&mod); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, 0, nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		if mod.Main || (directOnly && mod.Indirect) || !strings.Contains(mod.Path, filter) {
+			continue
+		}
+		checked++
+		if mod.Error != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", mod.Path, mod.Error.Err))
+			continue
+		}
+		if mod.Update == nil {
+			continue
+		}
+		u := update{
+			Module:  mod.Path,
+			Current: mod.Version,
+			Latest:  mod.Update.Version,
+			Direct:  !mod.Indirect,
+			Major:   major(mod.Version) != major(mod.Update.Version),
+		}
+		if mod.Replace != nil {
+			u.Replace = strings.TrimSuffix(mod.Replace.Path+"@"+mod.Replace.Version, "@")
+		}
+		updates = append(updates, u)
+	}
+	sort.Slice(updates, func(i, j int) bool { return updates[i].Module < updates[j].Module })
+	return updates, checked, errs, nil
+}
+
+// selectUpgrades turns the apply argument into go get arguments. A module
+// without a version is upgraded to the latest version found.
+func selectUpgrades(apply []string, updates []update) ([]string, error) {
+	latest := make(map[string]string, len(updates))
+	for _, u := range updates {
+		latest[u.Module] = u.Latest
+	}
+
+	var targets []string
+	for _, item := range apply {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+		case item == "all":
+			for _, u := range updates {
+				targets = append(targets, u.Module+"@"+u.Latest)
+			}
+		case strings.Contains(item, "@"):
+			targets = append(targets, item)
+		case latest[item] != "":
+			targets = append(targets, item+"@"+latest[item])
+		default:
+			return nil, fmt.Errorf("no update found for %s; give a version as %s@<version> to upgrade it anyway", item, item)
+		}
+	}
+	return targets, nil
+}
+
+// upgrade runs go get for targets, then go mod tidy, and tells gopls that
+// go.mod and go.sum changed
+func upgrade(ctx context.Context, manager *gopls.Manager, targets []string, tidy bool) (string, error) {
+	root := manager.WorkspaceRoot()
+	goMod, err := gocmd.Output(ctx, root, "env", "GOMOD")
+	if err != nil {
+		return "", err
+	}
+	if goMod == "" || goMod == os.DevNull {
+		return "", fmt.Errorf("the workspace is not in a module")
+	}
+
+	get, err := gocmd.Run(ctx, root, nil, append([]string{"get"}, targets...)...)
+	if err != nil {
+		return "", err
+	}
+	if get.ExitCode != 0 {
+		return "", fmt.Errorf("go get %s failed, go.mod was not changed: %s", strings.Join(targets, " "), strings.TrimSpace(get.Stderr))
+	}
+	msg := fmt.Sprintf("Upgraded %d module(s) with go get:\n  - %s", len(targets), strings.Join(targets, "\n  - "))
+	if tidy {
+		result, err := gocmd.Run(ctx, root, nil, "mod", "tidy")
+		switch {
+		case err != nil:
+			msg += fmt.Sprintf("\nNote: go mod tidy did not run: %v", err)
+		case result.ExitCode != 0:
+			msg += fmt.Sprintf("\nNote: go mod tidy failed: %s", strings.TrimSpace(result.Stderr))
+		default:
+			msg += "\nRan go mod tidy"
+		}
+	}
+
+	for _, path := range []string{goMod, filepath.Join(filepath.Dir(goMod), "go.sum")} {
+		if err := manager.NotifyFileWritten(ctx, path, false); err != nil {
+			msg += fmt.Sprintf("\nNote: gopls was not notified of the change to %s: %v", filepath.Base(path), err)
+		}
+	}
+	return msg + "\nRun CheckWorkspace to find code the upgrades broke.", nil
+}
+
+// major returns the major version of a module version, counting v0 as v1
+// since both share the module's import path
+func major(version string) string {
+	m, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	if m == "0" {
+		m = "1"
+	}
+	return m
+}
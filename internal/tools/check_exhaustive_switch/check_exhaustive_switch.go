@@ -0,0 +1,263 @@
+package check_exhaustive_switch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "CheckExhaustiveSwitch",
+		Description: "Check a switch over an enum-like type for missing cases, optionally inserting stubs for the missing cases",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file",
+				},
+				"line": map[string]interface{}{
+					"type":        "number",
+					"description": "Line number (1-indexed) of, or inside, the switch statement",
+				},
+				"column": map[string]interface{}{
+					"type":        "number",
+					"description": "Column number (1-indexed)",
+				},
+				"fix": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Insert case stubs for the missing values into the file",
+					"default":     false,
+				},
+			},
+			Required: []string{"file", "line", "column"},
+		},
+	}
+}
+
+type switchReport struct {
+	Type       string   `json:"type"`
+	Covered    []string `json:"covered"`
+	Missing    []string `json:"missing"`
+	HasDefault bool     `json:"hasDefault"`
+	Exhaustive bool     `json:"exhaustive"`
+	Fixed      bool     `json:"fixed,omitempty"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return nil, err
+		}
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return nil, err
+		}
+		fix := request.GetBool("fix", false)
+
+		file, err = filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+
+		pkgs, err := typecheck.Load(ctx, filepath.Dir(file), ".")
+		if err != nil {
+			return nil, err
+		}
+		if len(pkgs) == 0 {
+			return nil, fmt.Errorf("no package found for %s", file)
+		}
+		pkg := pkgs[0]
+
+		astFile := pkg.File(file)
+		if astFile == nil {
+			return nil, fmt.Errorf("%s is not part of package %s for the current build configuration", file, pkg.ImportPath)
+		}
+
+		tokFile := pkg.Fset.File(astFile.Pos())
+		if line < 1 || line > tokFile.LineCount() {
+			return nil, fmt.Errorf("line %d out of range", line)
+		}
+		pos := tokFile.LineStart(line) + token.Pos(column-1)
+
+		sw := enclosingSwitch(astFile, pos)
+		if sw == nil {
+			return nil, fmt.Errorf("no switch statement at %s:%d:%d", file, line, column)
+		}
+		if sw.Tag == nil {
+			return nil, fmt.Errorf("switch has no tag expression to check")
+		}
+
+		named, ok := pkg.Info.TypeOf(sw.Tag).(*types.Named)
+		if !ok {
+			return nil, fmt.Errorf("switch tag has type %s, which is not a named type", pkg.Info.TypeOf(sw.Tag))
+		}
+
+		typePkg, err := pkg.Import(named.Obj().Pkg().Path())
+		if err != nil {
+			return nil, err
+		}
+
+		report, missing := checkSwitch(pkg, sw, named, typePkg)
+
+		if fix && len(missing) > 0 {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			edit := caseStubs(pkg, astFile, content, sw, named, missing)
+			if err := manager.ApplyToFile(file, []lsp.TextEdit{edit}); err != nil {
+				return nil, fmt.Errorf("failed to insert missing cases: %w", err)
+			}
+			report.Fixed = true
+		}
+
+		result, _ := json.MarshalIndent(report, "", "  ")
+		return mcp.NewToolResultText(string(result)), nil
+	}
+}
+
+// enclosingSwitch returns the innermost expression switch containing pos
+func enclosingSwitch(file *ast.File, pos token.Pos) *ast.SwitchStmt {
+	var found *ast.SwitchStmt
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || pos < n.Pos() || pos > n.End() {
+			return false
+		}
+		if sw, ok := n.(*ast.SwitchStmt); ok {
+			found = sw
+		}
+		return true
+	})
+	return found
+}
+
+// checkSwitch compares the case values of sw with the constants of named.
+// Constants sharing a value count as one case, so aliases are not reported.
+func checkSwitch(pkg *typecheck.Package, sw *ast.SwitchStmt, named *types.Named, typePkg *types.Package) (switchReport, []*types.Const) {
+	report := switchReport{
+		Type:    named.Obj().Pkg().Path() + "." + named.Obj().Name(),
+		Covered: make([]string, 0),
+		Missing: make([]string, 0),
+	}
+
+	covered := make(map[string]bool)
+	for _, stmt := range sw.Body.List {
+		clause := stmt.(*ast.CaseClause)
+		if clause.List == nil {
+			report.HasDefault = true
+			continue
+		}
+		for _, expr := range clause.List {
+			if tv, ok := pkg.Info.Types[expr]; ok && tv.Value != nil {
+				covered[tv.Value.ExactString()] = true
+				report.Covered = append(report.Covered, types.ExprString(expr))
+			}
+		}
+	}
+
+	var consts []*types.Const
+	scope := typePkg.Scope()
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if !ok || !types.Identical(c.Type(), named) {
+			continue
+		}
+		if typePkg != pkg.Types && !c.Exported() {
+			continue
+		}
+		consts = append(consts, c)
+	}
+	// Report missing cases in value order, as they are usually declared
+	sort.SliceStable(consts, func(i, j int) bool {
+		a, b := consts[i].Val(), consts[j].Val()
+		if a.Kind() == b.Kind() && a.Kind() != constant.Bool && a.Kind() != constant.Unknown {
+			return constant.Compare(a, token.LSS, b)
+		}
+		return false
+	})
+
+	var missing []*types.Const
+	seen := make(map[string]bool)
+	for _, c := range consts {
+		value := c.Val().ExactString()
+		if covered[value] || seen[value] {
+			continue
+		}
+		seen[value] = true
+		missing = append(missing, c)
+		report.Missing = append(report.Missing, c.Name())
+	}
+
+	report.Exhaustive = len(missing) == 0
+	return report, missing
+}
+
+// caseStubs builds an edit inserting a case for each missing constant before
+// the default clause, or before the closing brace when there is none
+func caseStubs(pkg *typecheck.Package, file *ast.File, content []byte, sw *ast.SwitchStmt, named *types.Named, missing []*types.Const) lsp.TextEdit {
+	indent := lineIndent(content, pkg.Fset.Position(sw.Pos()).Line)
+
+	qualifier := ""
+	if named.Obj().Pkg() != pkg.Types {
+		qualifier = importName(file, named.Obj().Pkg()) + "."
+	}
+
+	var b strings.Builder
+	for _, c := range missing {
+		fmt.Fprintf(&b, "%scase %s%s:\n%s\t// TODO: handle %s\n", indent, qualifier, c.Name(), indent, c.Name())
+	}
+
+	insertLine := pkg.Fset.Position(sw.Body.Rbrace).Line
+	for _, stmt := range sw.Body.List {
+		if clause := stmt.(*ast.CaseClause); clause.List == nil {
+			insertLine = pkg.Fset.Position(clause.Pos()).Line
+		}
+	}
+
+	position := lsp.Position{Line: insertLine - 1, Character: 0}
+	return lsp.TextEdit{
+		Range:   lsp.Range{Start: position, End: position},
+		NewText: b.String(),
+	}
+}
+
+// lineIndent returns the leading whitespace of a 1-indexed line
+func lineIndent(content []byte, line int) string {
+	lines := strings.Split(string(content), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	text := lines[line-1]
+	return text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+}
+
+// importName returns the name a file uses to refer to pkg
+func importName(file *ast.File, pkg *types.Package) string {
+	for _, imp := range file.Imports {
+		if strings.Trim(imp.Path.Value, `"`) == pkg.Path() && imp.Name != nil {
+			return imp.Name.Name
+		}
+	}
+	return pkg.Name()
+}
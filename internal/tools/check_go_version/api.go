@@ -0,0 +1,134 @@
+package check_go_version
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+)
+
+// stdlibAPI records the Go release that introduced each standard library
+// package and symbol, as listed in $GOROOT/api/go1.*.txt
+type stdlibAPI struct {
+	packages map[string]string // import path -> version
+	symbols  map[string]string // "path.Name", "path.Type.Member" -> version
+}
+
+var (
+	apiMu    sync.Mutex
+	apiCache = make(map[string]*stdlibAPI)
+)
+
+// loadAPI reads the API files of the Go installation used in dir, caching the
+// result per GOROOT
+func loadAPI(ctx context.Context, dir string) (*stdlibAPI, error) {
+	goroot, err := gocmd.Output(ctx, dir, "env", "GOROOT")
+	if err != nil {
+		return nil, err
+	}
+
+	apiMu.Lock()
+	defer apiMu.Unlock()
+	if api, ok := apiCache[goroot]; ok {
+		return api, nil
+	}
+
+	files, _ := filepath.Glob(filepath.Join(goroot, "api", "go1*.txt"))
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no API files found in %s", filepath.Join(goroot, "api"))
+	}
+	api := &stdlibAPI{packages: make(map[string]string), symbols: make(map[string]string)}
+	for _, file := range files {
+		version := strings.TrimSuffix(filepath.Base(file), ".txt")
+		if err := api.read(file, version); err != nil {
+			return nil, err
+		}
+	}
+	apiCache[goroot] = api
+	return api, nil
+}
+
+func (api *stdlibAPI) read(path, version string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		pkg, key, ok := parseAPILine(scanner.Text())
+		if !ok {
+			continue
+		}
+		api.record(api.packages, pkg, version)
+		if key != "" {
+			api.record(api.symbols, pkg+"."+key, version)
+		}
+	}
+	return scanner.Err()
+}
+
+// record keeps the earliest version a name appears in, since platform
+// specific entries can list the same name again in later releases
+func (api *stdlibAPI) record(m map[string]string, name, version string) {
+	if existing, ok := m[name]; !ok || compareVersions(version, existing) < 0 {
+		m[name] = version
+	}
+}
+
+// parseAPILine extracts the package path and symbol key from an API line such
+// as "pkg net/http, method (*Server) Shutdown(context.Context) error". Keys
+// are "Name" for package-level objects and "Type.Member" for methods, struct
+// fields and interface methods.
+func parseAPILine(line string) (pkg, key string, ok bool) {
+	line, _, _ = strings.Cut(line, " #")
+	rest, found := strings.CutPrefix(line, "pkg ")
+	if !found {
+		return "", "", false
+	}
+	header, decl, found := strings.Cut(rest, ", ")
+	if !found {
+		return "", "", false
+	}
+	// Platform specific entries read "pkg syscall (linux-386), ..."
+	pkg, _, _ = strings.Cut(header, " ")
+
+	switch {
+	case strings.HasPrefix(decl, "func "):
+		return pkg, leadingName(strings.TrimPrefix(decl, "func ")), true
+	case strings.HasPrefix(decl, "method ("):
+		recv, method, found := strings.Cut(strings.TrimPrefix(decl, "method ("), ") ")
+		if !found {
+			return "", "", false
+		}
+		return pkg, leadingName(strings.TrimPrefix(recv, "*")) + "." + leadingName(method), true
+	case strings.HasPrefix(decl, "type "):
+		typeDecl, member, hasMember := strings.Cut(strings.TrimPrefix(decl, "type "), ", ")
+		key = leadingName(typeDecl)
+		if hasMember {
+			key += "." + leadingName(member)
+		}
+		return pkg, key, true
+	case strings.HasPrefix(decl, "const "):
+		return pkg, leadingName(strings.TrimPrefix(decl, "const ")), true
+	case strings.HasPrefix(decl, "var "):
+		return pkg, leadingName(strings.TrimPrefix(decl, "var ")), true
+	}
+	return pkg, "", true
+}
+
+// leadingName returns the identifier at the start of s, stopping at type
+// parameters, parameter lists and types
+func leadingName(s string) string {
+	if i := strings.IndexAny(s, " ([,"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
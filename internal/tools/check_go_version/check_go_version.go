@@ -0,0 +1,278 @@
+package check_go_version
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"go/version"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "CheckGoVersion",
+		Description: "Report uses of language features, standard library packages and standard library APIs that are newer than the go directive of the module's go.mod (e.g. generics before go1.18, the slices package before go1.21), with their locations. Use it to keep edits compatible with the declared Go version.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Go file or package directory to check, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"recursive": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Check every package under the directory",
+					"default":     false,
+				},
+			},
+		},
+	}
+}
+
+type violation struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Kind     string `json:"kind"` // language, package or api
+	Feature  string `json:"feature"`
+	Requires string `json:"requires"`
+	Allowed  string `json:"allowed"`
+}
+
+type packageReport struct {
+	Package    string      `json:"package"`
+	GoVersion  string      `json:"goVersion"`
+	Violations []violation `json:"violations"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path := manager.ResolvePath(request.GetString("path", ""))
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		dir, file, pattern := path, "", "."
+		if !info.IsDir() {
+			dir, file = filepath.Dir(path), path
+		} else if request.GetBool("recursive", false) {
+			pattern = "./..."
+		}
+
+		pkgs, err := typecheck.Load(ctx, dir, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(pkgs) == 0 {
+			return nil, fmt.Errorf("no packages found in %s", dir)
+		}
+		api, err := loadAPI(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		reports := make([]packageReport, 0, len(pkgs))
+		total := 0
+		for _, pkg := range pkgs {
+			if pkg.GoVersion == "" {
+				continue
+			}
+			found := check(pkg, api, file)
+			total += len(found)
+			reports = append(reports, packageReport{
+				Package:    pkg.ImportPath,
+				GoVersion:  pkg.GoVersion,
+				Violations: found,
+			})
+		}
+		if len(reports) == 0 {
+			return nil, fmt.Errorf("no go directive found for the packages in %s", dir)
+		}
+
+		output, _ := json.MarshalIndent(reports, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d use(s) of features newer than the go directive in %d package(s):\n%s", total, len(reports), output)), nil
+	}
+}
+
+// requiresVersion matches the go/types errors for language features that
+// are newer than the configured Go version
+var requiresVersion = regexp.MustCompile(`requires (go1[0-9.]*) or later`)
+
+// check reports the violations in pkg, limited to file when it is set
+func check(pkg *typecheck.Package, api *stdlibAPI, file string) []violation {
+	moduleVersion := "go" + pkg.GoVersion
+	found := make([]violation, 0)
+
+	// typecheck checks packages against their module's version, so language
+	// features arrive as type errors
+	for _, msg := range pkg.Errors {
+		m := requiresVersion.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		posn, text, ok := strings.Cut(msg, ": ")
+		if !ok {
+			continue
+		}
+		filename, line, column := gocmd.SplitPosition(posn)
+		// go list repeats the compiler's errors with relative paths; the
+		// type checker's own copy is enough
+		if !filepath.IsAbs(filename) || (file != "" && filename != file) {
+			continue
+		}
+		found = append(found, violation{
+			File:     filename,
+			Line:     line,
+			Column:   column,
+			Kind:     "language",
+			Feature:  strings.TrimRight(text[:strings.Index(text, m[0])], " :"),
+			Requires: m[1],
+			Allowed:  moduleVersion,
+		})
+	}
+
+	fields := make(map[*types.Var]string)
+	for _, astFile := range pkg.Files {
+		filename := pkg.Fset.File(astFile.Pos()).Name()
+		if file != "" && filename != file {
+			continue
+		}
+		// A //go:build go1.N constraint raises the version a file may use
+		allowed := pkg.Info.FileVersions[astFile]
+		if allowed == "" {
+			allowed = moduleVersion
+		}
+		report := func(pos ast.Node, kind, feature, requires string) {
+			position := pkg.Fset.Position(pos.Pos())
+			found = append(found, violation{
+				File:     position.Filename,
+				Line:     position.Line,
+				Column:   position.Column,
+				Kind:     kind,
+				Feature:  feature,
+				Requires: requires,
+				Allowed:  allowed,
+			})
+		}
+
+		tooNew := make(map[string]bool)
+		for _, spec := range astFile.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			if v, ok := api.packages[importPath]; ok && compareVersions(v, allowed) > 0 {
+				tooNew[importPath] = true
+				report(spec, "package", importPath, v)
+			}
+		}
+
+		ast.Inspect(astFile, func(n ast.Node) bool {
+			ident, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			obj := pkg.Info.Uses[ident]
+			if obj == nil || obj.Pkg() == nil || obj.Pkg() == pkg.Types || tooNew[obj.Pkg().Path()] {
+				return true
+			}
+			key := apiKey(obj, fields)
+			if key == "" {
+				return true
+			}
+			if v, ok := api.symbols[key]; ok && compareVersions(v, allowed) > 0 {
+				report(ident, "api", key, v)
+			}
+			return true
+		})
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].File != found[j].File {
+			return found[i].File < found[j].File
+		}
+		if found[i].Line != found[j].Line {
+			return found[i].Line < found[j].Line
+		}
+		return found[i].Column < found[j].Column
+	})
+	return found
+}
+
+// apiKey returns the key the API files use for obj: "path.Name" for
+// package-level objects and "path.Type.Member" for methods and fields
+func apiKey(obj types.Object, fields map[*types.Var]string) string {
+	prefix := obj.Pkg().Path() + "."
+	switch obj := obj.(type) {
+	case *types.Func:
+		obj = obj.Origin()
+		if recv := obj.Type().(*types.Signature).Recv(); recv != nil {
+			if named := namedOf(recv.Type()); named != nil {
+				return prefix + named.Obj().Name() + "." + obj.Name()
+			}
+			return ""
+		}
+	case *types.Var:
+		if obj.IsField() {
+			obj = obj.Origin()
+			owner, ok := fields[obj]
+			if !ok {
+				indexFields(obj.Pkg(), fields)
+				owner = fields[obj]
+				fields[obj] = owner
+			}
+			if owner == "" {
+				return ""
+			}
+			return prefix + owner + "." + obj.Name()
+		}
+	}
+	if obj.Pkg().Scope().Lookup(obj.Name()) != obj {
+		return ""
+	}
+	return prefix + obj.Name()
+}
+
+// indexFields records the struct type declaring each field of the named
+// struct types in pkg
+func indexFields(pkg *types.Package, fields map[*types.Var]string) {
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		if st, ok := typeName.Type().Underlying().(*types.Struct); ok {
+			for i := 0; i < st.NumFields(); i++ {
+				fields[st.Field(i)] = name
+			}
+		}
+	}
+}
+
+func namedOf(t types.Type) *types.Named {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
+
+// compareVersions compares Go versions such as "go1.21", treating the
+// original "go1" release as the oldest
+func compareVersions(a, b string) int {
+	return version.Compare(version.Lang(a), version.Lang(b))
+}
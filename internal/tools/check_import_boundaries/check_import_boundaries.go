@@ -0,0 +1,310 @@
+package check_import_boundaries
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+// defaultConfig is the boundary config read from the workspace root when
+// neither rules nor config are given
+const defaultConfig = ".import-boundaries.json"
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "CheckImportBoundaries",
+		Description: "Find imports that break Go's internal/ visibility rule or architectural import boundaries, such as \"package api must not import storage\", and report each violating import site",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory to scan, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"rules": map[string]interface{}{
+					"type":        "array",
+					"description": "Import boundaries. Packages matching from must not import packages matching deny, except those matching allow. Patterns are import paths where \"...\" matches any string, as in go list; a pattern starting with \"./\" is relative to the import path of the workspace root.",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"from":   map[string]interface{}{"type": "string", "description": "Pattern of the importing packages, e.g. ./api/..."},
+							"deny":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Patterns of the packages they must not import"},
+							"allow":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Exceptions to deny"},
+							"reason": map[string]interface{}{"type": "string", "description": "Why the boundary exists, reported with each violation"},
+						},
+						"required": []string{"from", "deny"},
+					},
+				},
+				"config": map[string]interface{}{
+					"type":        "string",
+					"description": "JSON file holding {\"rules\": [...]} in the form of the rules argument. Defaults to " + defaultConfig + " in the workspace root when it exists and no rules are given.",
+				},
+				"checkInternal": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Report imports of internal packages from outside the tree rooted at the internal directory's parent",
+					"default":     true,
+				},
+				"includeTests": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also scan _test.go files",
+					"default":     false,
+				},
+			},
+		},
+	}
+}
+
+// rule is one import boundary
+type rule struct {
+	From   string   `json:"from"`
+	Deny   []string `json:"deny"`
+	Allow  []string `json:"allow,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+
+	from  *regexp.Regexp
+	deny  []*regexp.Regexp
+	allow []*regexp.Regexp
+}
+
+type config struct {
+	Rules []rule `json:"rules"`
+}
+
+type violation struct {
+	// Rule is "internal" for the internal/ rule, or the from pattern of the
+	// boundary that was crossed
+	Rule     string `json:"rule"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Importer string `json:"importer"`
+	Import   string `json:"import"`
+	Reason   string `json:"reason"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		root := manager.ResolvePath(request.GetString("path", ""))
+		checkInternal := request.GetBool("checkInternal", true)
+
+		rules, err := loadRules(manager, request)
+		if err != nil {
+			return nil, err
+		}
+		if len(rules) > 0 {
+			base, err := codegen.ImportPath(manager.WorkspaceRoot())
+			if err != nil {
+				return nil, fmt.Errorf("relative patterns need the workspace import path: %w", err)
+			}
+			for i := range rules {
+				if err := rules[i].compile(base); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if !checkInternal && len(rules) == 0 {
+			return nil, fmt.Errorf("nothing to check: checkInternal is off and no rules are given")
+		}
+
+		importPaths := make(map[string]string)
+		violations := make([]violation, 0)
+		err = astscan.Walk(root, astscan.Options{IncludeTests: request.GetBool("includeTests", false)}, func(f *astscan.File) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			dir := filepath.Dir(f.Path)
+			importer, ok := importPaths[dir]
+			if !ok {
+				importer, _ = codegen.ImportPath(dir)
+				importPaths[dir] = importer
+			}
+			if importer == "" {
+				return nil
+			}
+
+			for _, spec := range f.AST.Imports {
+				imported, err := strconv.Unquote(spec.Path.Value)
+				if err != nil {
+					continue
+				}
+				line, column := f.Position(spec.Pos())
+				add := func(rule, reason string) {
+					violations = append(violations, violation{
+						Rule:     rule,
+						File:     filepath.Clean(f.Path),
+						Line:     line,
+						Column:   column,
+						Importer: importer,
+						Import:   imported,
+						Reason:   reason,
+					})
+				}
+				if checkInternal {
+					if parent, ok := internalParent(imported); ok && !canImportInternal(importer, parent) {
+						add("internal", internalReason(parent))
+					}
+				}
+				for _, r := range rules {
+					if r.denies(importer, imported) {
+						reason := r.Reason
+						if reason == "" {
+							reason = fmt.Sprintf("%s must not import %s", r.From, strings.Join(r.Deny, ", "))
+						}
+						add(r.From, reason)
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(violations) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No import boundary violations found in %s", root)), nil
+		}
+
+		counts := make(map[string]int)
+		for _, v := range violations {
+			counts[v.Rule]++
+		}
+		names := make([]string, 0, len(counts))
+		for name := range counts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		summary := make([]string, 0, len(names))
+		for _, name := range names {
+			summary = append(summary, fmt.Sprintf("%s: %d", name, counts[name]))
+		}
+
+		result, _ := json.MarshalIndent(violations, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d violation(s) (%s):\n%s", len(violations), strings.Join(summary, ", "), string(result))), nil
+	}
+}
+
+// loadRules returns the rules given inline, those of the config file, or
+// those of the default config when it exists
+func loadRules(manager *gopls.Manager, request mcp.CallToolRequest) ([]rule, error) {
+	var rules []rule
+	if raw, ok := request.GetArguments()["rules"]; ok && raw != nil {
+		data, _ := json.Marshal(raw)
+		if err := json.Unmarshal(data, &rules); err != nil {
+			return nil, fmt.Errorf("invalid rules: %w", err)
+		}
+	}
+
+	path := request.GetString("config", "")
+	if path == "" {
+		if len(rules) > 0 {
+			return rules, nil
+		}
+		path = filepath.Join(manager.WorkspaceRoot(), defaultConfig)
+		if _, err := os.Stat(path); err != nil {
+			return nil, nil
+		}
+	}
+	data, err := os.ReadFile(manager.ResolvePath(path))
+	if err != nil {
+		return nil, err
+	}
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return append(rules, cfg.Rules...), nil
+}
+
+// compile resolves the rule's patterns against the workspace import path
+func (r *rule) compile(base string) error {
+	if r.From == "" || len(r.Deny) == 0 {
+		return fmt.Errorf("rule %q needs from and deny patterns", r.From)
+	}
+	r.from = compilePattern(base, r.From)
+	r.deny, r.allow = nil, nil
+	for _, p := range r.Deny {
+		r.deny = append(r.deny, compilePattern(base, p))
+	}
+	for _, p := range r.Allow {
+		r.allow = append(r.allow, compilePattern(base, p))
+	}
+	return nil
+}
+
+// denies reports whether the rule forbids importer to import imported
+func (r *rule) denies(importer, imported string) bool {
+	if !r.from.MatchString(importer) || !matchAny(r.deny, imported) {
+		return false
+	}
+	return !matchAny(r.allow, imported)
+}
+
+func matchAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePattern turns a go list style pattern into a regexp. As in go list,
+// a trailing "/..." also matches the path without it.
+func compilePattern(base, pattern string) *regexp.Regexp {
+	if rel, ok := strings.CutPrefix(pattern, "./"); ok {
+		pattern = base + "/" + rel
+	} else if pattern == "." {
+		pattern = base
+	}
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\.\.\.`, `.*`)
+	if strings.HasSuffix(expr, `/.*`) {
+		expr = strings.TrimSuffix(expr, `/.*`) + `(/.*)?`
+	}
+	return regexp.MustCompile("^" + expr + "$")
+}
+
+// internalParent returns the path of the directory an internal package's
+// last internal element is in, as the go command checks it
+func internalParent(path string) (string, bool) {
+	switch {
+	case strings.HasSuffix(path, "/internal"):
+		return strings.TrimSuffix(path, "/internal"), true
+	case path == "internal" || strings.HasPrefix(path, "internal/"):
+		return "", true
+	}
+	if i := strings.LastIndex(path, "/internal/"); i >= 0 {
+		return path[:i], true
+	}
+	return "", false
+}
+
+// canImportInternal reports whether a package may import an internal package
+// under parent. The standard library's top-level internal packages are only
+// visible to the standard library.
+func canImportInternal(importer, parent string) bool {
+	if parent == "" {
+		first, _, _ := strings.Cut(importer, "/")
+		return !strings.Contains(first, ".")
+	}
+	return importer == parent || strings.HasPrefix(importer, parent+"/")
+}
+
+func internalReason(parent string) string {
+	if parent == "" {
+		return "internal packages of the standard library can only be imported by the standard library"
+	}
+	return fmt.Sprintf("internal packages under %s can only be imported from within %s", parent, parent)
+}
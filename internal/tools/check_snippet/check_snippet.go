@@ -0,0 +1,194 @@
+package check_snippet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "CheckSnippet",
+		Description: "Compile-check a Go snippet against the workspace and its dependencies without writing any files, returning the errors with lines relative to the snippet. The snippet can be a whole file, top-level declarations, or statements (which are checked inside a function). Use it to validate generated code before inserting it.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"code": map[string]interface{}{
+					"type":        "string",
+					"description": "The Go code to check",
+				},
+				"imports": map[string]interface{}{
+					"type":        "array",
+					"description": "Import paths the snippet needs, e.g. [\"fmt\", \"github.com/acme/app/internal/store\"]. Not needed when the snippet has its own imports.",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"package": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory of a workspace package to check the snippet as part of, so it can use the package's unexported identifiers. Without it the snippet is checked in an empty package.",
+				},
+				"reportUnused": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Report unused variables and imports, which are usually noise for a fragment",
+					"default":     false,
+				},
+			},
+			Required: []string{"code"},
+		},
+	}
+}
+
+type compileError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+type result struct {
+	OK            bool           `json:"ok"`
+	Kind          string         `json:"kind"`
+	Package       string         `json:"package"`
+	Errors        []compileError `json:"errors"`
+	PackageErrors []string       `json:"packageErrors,omitempty"`
+}
+
+// snippetFile is the name the snippet gets in the package it is checked in
+const snippetFile = "zz_check_snippet.go"
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		code, err := request.RequireString("code")
+		if err != nil {
+			return nil, err
+		}
+		imports := request.GetStringSlice("imports", nil)
+		reportUnused := request.GetBool("reportUnused", false)
+
+		root := manager.WorkspaceRoot()
+		dir := filepath.Join(root, "zz_check_snippet")
+		name := "snippet"
+		if pkgDir := request.GetString("package", ""); pkgDir != "" {
+			dir = manager.ResolvePath(pkgDir)
+			name, err = gocmd.Output(ctx, dir, "list", "-f", "{{.Name}}", ".")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		src, kind, offset, err := wrap(code, name, imports)
+		if err != nil {
+			return nil, err
+		}
+
+		scratch, err := os.MkdirTemp("", "check-snippet-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(scratch)
+		srcPath := filepath.Join(scratch, snippetFile)
+		if err := os.WriteFile(srcPath, []byte(src), 0644); err != nil {
+			return nil, err
+		}
+		// The overlay adds the snippet to the package without touching the
+		// workspace
+		overlay, _ := json.Marshal(map[string]interface{}{
+			"Replace": map[string]string{filepath.Join(dir, snippetFile): srcPath},
+		})
+		overlayPath := filepath.Join(scratch, "overlay.json")
+		if err := os.WriteFile(overlayPath, overlay, 0644); err != nil {
+			return nil, err
+		}
+
+		build, err := gocmd.Run(ctx, root, nil, "build", "-gcflags=-e", "-o", os.DevNull, "-overlay", overlayPath, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		res := result{Kind: kind, Package: name, Errors: make([]compileError, 0)}
+		buildErrors := gocmd.BuildErrors(build.Stderr)
+		for _, e := range buildErrors {
+			if filepath.Base(e.File) != snippetFile {
+				res.PackageErrors = append(res.PackageErrors, e.Text)
+				continue
+			}
+			if !reportUnused && (strings.Contains(e.Message, "declared and not used") || strings.Contains(e.Message, "imported and not used")) {
+				continue
+			}
+			lineNo, column, msg := e.Line, e.Column, e.Message
+			if lineNo <= offset {
+				// Errors in the lines wrap added, such as unknown imports
+				lineNo, column, msg = offset, 0, "in the added imports: "+msg
+			}
+			res.Errors = append(res.Errors, compileError{Line: lineNo - offset, Column: column, Message: msg})
+		}
+		// Failures without positions, such as unknown imports, are not
+		// compile errors of the snippet
+		if build.ExitCode != 0 && len(buildErrors) == 0 {
+			return nil, fmt.Errorf("go build failed: %s", strings.TrimSpace(build.Stderr))
+		}
+		res.OK = len(res.Errors) == 0
+
+		output, _ := json.MarshalIndent(res, "", "  ")
+		summary := "The snippet compiles"
+		if !res.OK {
+			summary = fmt.Sprintf("The snippet has %d compile error(s)", len(res.Errors))
+		}
+		return mcp.NewToolResultText(summary + ":\n" + string(output)), nil
+	}
+}
+
+// wrap turns code into a complete file in package name, returning the file,
+// what kind of snippet it was, and how many lines were added before the code
+func wrap(code, name string, imports []string) (string, string, int, error) {
+	fset := token.NewFileSet()
+	if _, err := parser.ParseFile(fset, "", code, parser.PackageClauseOnly); err == nil {
+		if len(imports) > 0 {
+			return "", "", 0, fmt.Errorf("imports cannot be added to a snippet with its own package clause")
+		}
+		return code, "file", 0, nil
+	}
+
+	var header strings.Builder
+	fmt.Fprintf(&header, "package %s\n", name)
+	for _, path := range imports {
+		fmt.Fprintf(&header, "import %q\n", path)
+	}
+	offset := strings.Count(header.String(), "\n")
+
+	decls := header.String() + code + "\n"
+	_, declErr := parser.ParseFile(fset, "", decls, parser.AllErrors)
+	if declErr == nil {
+		return decls, "declarations", offset, nil
+	}
+
+	stmts := header.String() + "func _() {\n" + code + "\n}\n"
+	if _, err := parser.ParseFile(fset, "", stmts, parser.AllErrors); err == nil {
+		return stmts, "statements", offset + 1, nil
+	}
+	// Report the syntax errors of the declarations reading, with lines
+	// relative to the snippet
+	return "", "", 0, fmt.Errorf("the snippet does not parse as declarations or statements: %v", relativeLines(declErr, offset))
+}
+
+// relativeLines renumbers the lines of parse errors by subtracting offset
+func relativeLines(err error, offset int) string {
+	list, ok := err.(scanner.ErrorList)
+	if !ok {
+		return err.Error()
+	}
+	msgs := make([]string, 0, len(list))
+	for _, e := range list {
+		msgs = append(msgs, fmt.Sprintf("%d:%d: %s", e.Pos.Line-offset, e.Pos.Column, e.Msg))
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,186 @@
+package check_workspace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/resultfilter"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "CheckWorkspace",
+		Description: "Check whether the workspace is healthy: wait for gopls to finish loading, collect the diagnostics of every package and report pass or fail with the errors found. Run it after a batch of edits.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Only check files under this directory, absolute or relative to the workspace root (defaults to the whole workspace)",
+				},
+				"failOnWarnings": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Fail when there are warnings as well as errors",
+					"default":     false,
+				},
+				"timeoutSeconds": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum time to wait for gopls to finish loading and diagnosing",
+					"default":     60,
+				},
+				"settleMs": map[string]interface{}{
+					"type":        "number",
+					"description": "Time gopls must go without publishing diagnostics before they are considered complete",
+					"default":     1000,
+				},
+				"maxResults": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum number of diagnostics to list",
+					"default":     50,
+				},
+				"includeVendor": resultfilter.IncludeVendorProperty(manager),
+			},
+		},
+	}
+}
+
+type problem struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+type report struct {
+	Passed    bool      `json:"passed"`
+	Errors    int       `json:"errors"`
+	Warnings  int       `json:"warnings"`
+	Files     int       `json:"files"`
+	Busy      []string  `json:"busy,omitempty"`
+	Problems  []problem `json:"problems"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := manager.ResolvePath(request.GetString("path", ""))
+		failOnWarnings := request.GetBool("failOnWarnings", false)
+		timeout := time.Duration(request.GetInt("timeoutSeconds", 60)) * time.Second
+		settle := time.Duration(request.GetInt("settleMs", 1000)) * time.Millisecond
+		maxResults := request.GetInt("maxResults", 50)
+
+		client, err := manager.GetClient()
+		if err != nil {
+			return nil, err
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		err = client.WaitForDiagnostics(waitCtx, settle)
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+
+		result := report{Problems: make([]problem, 0)}
+		if err != nil {
+			// Report what gopls has diagnosed so far, but never pass
+			result.Busy = client.WorkInProgress()
+			if len(result.Busy) == 0 {
+				result.Busy = []string{"diagnostics still changing"}
+			}
+		}
+
+		filter := resultfilter.FromRequest(manager, request)
+		files := make(map[string]bool)
+		for uri, diagnostics := range client.AllDiagnostics().Diagnostics {
+			file, err := manager.URIToPath(uri)
+			if err != nil || !within(file, dir) || !filter.Keep(file) {
+				continue
+			}
+			for _, diag := range diagnostics {
+				p := toProblem(file, diag)
+				switch p.Severity {
+				case "error":
+					result.Errors++
+				case "warning":
+					result.Warnings++
+				default:
+					continue
+				}
+				files[file] = true
+				result.Problems = append(result.Problems, p)
+			}
+		}
+		result.Files = len(files)
+		result.Passed = len(result.Busy) == 0 && result.Errors == 0 && (!failOnWarnings || result.Warnings == 0)
+
+		sort.Slice(result.Problems, func(i, j int) bool {
+			a, b := result.Problems[i], result.Problems[j]
+			if (a.Severity == "error") != (b.Severity == "error") {
+				return a.Severity == "error"
+			}
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			return a.Line < b.Line
+		})
+		if maxResults >= 0 && len(result.Problems) > maxResults {
+			result.Problems = result.Problems[:maxResults]
+			result.Truncated = true
+		}
+
+		output, _ := json.MarshalIndent(result, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("%s\n%s", summary(result), output)), nil
+	}
+}
+
+func summary(r report) string {
+	switch {
+	case len(r.Busy) > 0:
+		return fmt.Sprintf("FAIL: gopls did not finish in time (%s); %d error(s) and %d warning(s) so far", strings.Join(r.Busy, ", "), r.Errors, r.Warnings)
+	case r.Passed:
+		return fmt.Sprintf("PASS: no errors (%d warning(s))", r.Warnings)
+	}
+	return fmt.Sprintf("FAIL: %d error(s) and %d warning(s) in %d file(s)", r.Errors, r.Warnings, r.Files)
+}
+
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func toProblem(file string, diag lsp.Diagnostic) problem {
+	line, column := utils.ConvertToUserPosition(diag.Range.Start)
+
+	severity := "error"
+	switch diag.Severity {
+	case lsp.DiagnosticSeverityWarning:
+		severity = "warning"
+	case lsp.DiagnosticSeverityInformation:
+		severity = "information"
+	case lsp.DiagnosticSeverityHint:
+		severity = "hint"
+	}
+
+	return problem{
+		File:     file,
+		Line:     line,
+		Column:   column,
+		Severity: severity,
+		Source:   diag.Source,
+		Message:  diag.Message,
+	}
+}
@@ -0,0 +1,179 @@
+package create_package
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "CreatePackage",
+		Description: "Create a new package directory with a doc.go, optional starter types and test file, register the files with gopls and check that the package builds",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory of the new package, absolute or relative to the workspace root. It must not contain Go files yet.",
+				},
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Package name (defaults to the directory name made into a valid identifier)",
+				},
+				"doc": map[string]interface{}{
+					"type":        "string",
+					"description": "Package documentation for doc.go, e.g. 'parses configuration files'. It is prefixed with 'Package <name>' when it does not start with it.",
+				},
+				"types": map[string]interface{}{
+					"type":        "array",
+					"description": "Names of starter struct types to declare in <name>.go",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"test": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Create a <name>_test.go file with a skipped test for each starter type",
+					"default":     false,
+				},
+				"verify": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Run go build (and go vet when a test file is created) on the new package",
+					"default":     true,
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir, err := request.RequireString("path")
+		if err != nil {
+			return nil, err
+		}
+		name := request.GetString("name", codegen.SanitizePackageName(filepath.Base(dir)))
+		if !token.IsIdentifier(name) {
+			return nil, fmt.Errorf("%q is not a valid package name", name)
+		}
+		typeNames := request.GetStringSlice("types", nil)
+		for _, typeName := range typeNames {
+			if !token.IsIdentifier(typeName) {
+				return nil, fmt.Errorf("%q is not a valid type name", typeName)
+			}
+		}
+		withTest := request.GetBool("test", false)
+
+		if existing, _ := filepath.Glob(filepath.Join(dir, "*.go")); len(existing) > 0 {
+			return nil, fmt.Errorf("%s already contains Go files", dir)
+		}
+		if _, err := codegen.ImportPath(dir); err != nil {
+			return nil, err
+		}
+
+		files := map[string][]byte{
+			filepath.Join(dir, "doc.go"): docFile(name, request.GetString("doc", "")),
+		}
+		if len(typeNames) > 0 {
+			files[filepath.Join(dir, name+".go")] = typesFile(name, typeNames)
+		}
+		if withTest {
+			files[filepath.Join(dir, name+"_test.go")] = testFile(name, typeNames)
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create package directory: %w", err)
+		}
+		var created []string
+		for path, src := range files {
+			if err := codegen.WriteFile(manager.FileHeader(), path, src); err != nil {
+				return nil, err
+			}
+			created = append(created, path)
+		}
+
+		var msg strings.Builder
+		fmt.Fprintf(&msg, "Created package %s in %s:\n", name, dir)
+		sort.Strings(created)
+		for _, path := range created {
+			fmt.Fprintf(&msg, "  - %s\n", path)
+			if err := manager.NotifyFileWritten(ctx, path, true); err != nil {
+				fmt.Fprintf(&msg, "    Note: gopls was not notified of the new file: %v\n", err)
+			}
+		}
+
+		if request.GetBool("verify", true) {
+			checks := [][]string{{"build", "."}}
+			if withTest {
+				checks = append(checks, []string{"vet", "."})
+			}
+			for _, args := range checks {
+				result, err := gocmd.Run(ctx, dir, nil, args...)
+				if err != nil {
+					return nil, err
+				}
+				if result.ExitCode != 0 {
+					fmt.Fprintf(&msg, "\ngo %s failed:\n%s", strings.Join(args, " "), strings.TrimSpace(result.Stderr))
+					return mcp.NewToolResultText(msg.String()), nil
+				}
+			}
+			msg.WriteString("\nThe package builds")
+			if withTest {
+				msg.WriteString(" and passes go vet")
+			}
+		}
+		return mcp.NewToolResultText(msg.String()), nil
+	}
+}
+
+func docFile(name, doc string) []byte {
+	doc = strings.TrimSpace(doc)
+	prefix := "Package " + name
+	switch {
+	case doc == "":
+		doc = prefix + " TODO: describe the package."
+	case !strings.HasPrefix(doc, prefix+" "):
+		doc = prefix + " " + doc
+	}
+	if !strings.HasSuffix(doc, ".") {
+		doc += "."
+	}
+
+	var b strings.Builder
+	for _, line := range strings.Split(doc, "\n") {
+		b.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+	fmt.Fprintf(&b, "package %s\n", name)
+	return []byte(b.String())
+}
+
+func typesFile(name string, typeNames []string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s\n", name)
+	for _, typeName := range typeNames {
+		fmt.Fprintf(&b, "\n// %s TODO: describe the type.\ntype %s struct {\n}\n", typeName, typeName)
+	}
+	return []byte(b.String())
+}
+
+func testFile(name string, typeNames []string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s\n\nimport \"testing\"\n", name)
+	if len(typeNames) == 0 {
+		typeNames = []string{"Package"}
+	}
+	for _, typeName := range typeNames {
+		fmt.Fprintf(&b, "\nfunc Test%s(t *testing.T) {\n\tt.Skip(\"not implemented\")\n}\n", strings.ToUpper(typeName[:1])+typeName[1:])
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,195 @@
+package delete_symbol
+
+import (
+	"context"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "DeleteSymbol",
+		Description: "Delete top-level declarations after checking that nothing outside them refers to them, also deleting the methods of deleted types and the imports left unused",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file declaring the symbols",
+				},
+				"symbols": map[string]interface{}{
+					"type":        "array",
+					"description": "Names of the declarations to delete: functions, types, variables and constants, with methods written as Type.Method",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"includeMethods": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Delete the methods of deleted types along with them",
+					"default":     true,
+				},
+				"force": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Delete even when the symbols are still referenced, leaving the references to be fixed",
+					"default":     false,
+				},
+				"dryRun": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Report the changes as a unified diff without modifying any files",
+					"default":     false,
+				},
+			},
+			Required: []string{"file", "symbols"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		symbols, err := request.RequireStringSlice("symbols")
+		if err != nil {
+			return nil, err
+		}
+		if len(symbols) == 0 {
+			return nil, fmt.Errorf("symbols cannot be empty")
+		}
+		force := request.GetBool("force", false)
+		dryRun := request.GetBool("dryRun", false)
+
+		file, err = filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		content, err := manager.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fset := token.NewFileSet()
+		astFile, err := parser.ParseFile(fset, file, content, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+
+		deletion, err := edits.Delete(fset, astFile, content, symbols, request.GetBool("includeMethods", true))
+		if err != nil {
+			return nil, err
+		}
+
+		client, err := manager.GetClient()
+		if err != nil {
+			return nil, err
+		}
+		uri, err := utils.PathToURI(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.OpenDocument(ctx, uri, string(content)); err != nil {
+			return nil, err
+		}
+		defer client.CloseDocument(ctx, uri)
+
+		refs, err := outsideReferences(ctx, manager, client, fset, file, string(content), deletion)
+		if err != nil {
+			return nil, err
+		}
+		if len(refs) > 0 && !force {
+			return mcp.NewToolResultError(fmt.Sprintf("Not deleting %s: still referenced from %d place(s):\n  - %s\nRemove the references first or set force to delete anyway.",
+				strings.Join(deletion.Removed, ", "), len(refs), strings.Join(refs, "\n  - "))), nil
+		}
+
+		updated, err := edits.Apply(string(content), deletion.Edits)
+		if err != nil {
+			return nil, err
+		}
+		// Removing declarations can leave runs of blank lines behind
+		if formatted, err := format.Source([]byte(updated)); err == nil {
+			updated = string(formatted)
+		}
+
+		if dryRun {
+			return mcp.NewToolResultText(fmt.Sprintf("Would delete %s\n\n%s",
+				strings.Join(deletion.Removed, ", "), edits.Unified(edits.Label(manager.WorkspaceRoot(), file), string(content), updated))), nil
+		}
+
+		if err := manager.WriteFiles(map[string][]byte{file: []byte(updated)}); err != nil {
+			return nil, err
+		}
+
+		msg := fmt.Sprintf("Deleted %s from %s", strings.Join(deletion.Removed, ", "), file)
+		if len(refs) > 0 {
+			msg += fmt.Sprintf("\nThese references are now broken:\n  - %s", strings.Join(refs, "\n  - "))
+		}
+		if err := manager.NotifyFileWritten(ctx, file, false); err != nil {
+			msg += fmt.Sprintf("\nNote: gopls was not notified of the change: %v", err)
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+// outsideReferences finds the references to the deleted names that lie
+// outside the deleted declarations, formatted as file:line:column
+func outsideReferences(ctx context.Context, manager *gopls.Manager, client *lsp.Client, fset *token.FileSet, file, content string, deletion *edits.Deletion) ([]string, error) {
+	uri, err := utils.PathToURI(file)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var refs []string
+	for _, name := range deletion.Names {
+		if name.Name == "_" {
+			continue
+		}
+		position, err := utils.OffsetToPosition(content, fset.Position(name.Pos()).Offset)
+		if err != nil {
+			return nil, err
+		}
+		locations, err := client.References(ctx, uri, position, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find references to %s: %w", name.Name, err)
+		}
+		for _, loc := range locations {
+			path, err := manager.URIToPath(loc.URI)
+			if err != nil {
+				continue
+			}
+			if path == file && insideDeletion(content, loc.Range.Start, deletion) {
+				continue
+			}
+			line, column := utils.ConvertToUserPosition(loc.Range.Start)
+			ref := fmt.Sprintf("%s:%d:%d (%s)", path, line, column, name.Name)
+			if !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs, nil
+}
+
+func insideDeletion(content string, position lsp.Position, deletion *edits.Deletion) bool {
+	offset, err := utils.CalculateOffset(content, position)
+	if err != nil {
+		return false
+	}
+	for _, span := range deletion.Spans {
+		if offset >= span[0] && offset < span[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,500 @@
+package deprecate_function
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "DeprecateFunction",
+		Description: "Mark a function or method as deprecated in its doc comment and optionally rewrite its call sites to a replacement with an identical signature, reporting the sites that need manual updates",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file declaring the function",
+				},
+				"line": map[string]interface{}{
+					"type":        "number",
+					"description": "Line number (1-indexed) of the function name",
+				},
+				"column": map[string]interface{}{
+					"type":        "number",
+					"description": "Column number (1-indexed) of the function name",
+				},
+				"replacement": map[string]interface{}{
+					"type":        "string",
+					"description": "Replacement function: a name in the same package, an import-path-qualified name (e.g. example.com/pkg.NewFunc), or for methods another method of the same type",
+				},
+				"message": map[string]interface{}{
+					"type":        "string",
+					"description": "Deprecation message (defaults to 'Use <replacement> instead.')",
+				},
+				"rewriteCallers": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Rewrite call sites to use the replacement when its signature is identical",
+					"default":     false,
+				},
+				"organizeImports": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Organize imports in every changed file before writing it",
+					"default":     false,
+				},
+				"dryRun": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Report the changes as a unified diff without modifying any files",
+					"default":     false,
+				},
+			},
+			Required: []string{"file", "line", "column"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return nil, err
+		}
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return nil, err
+		}
+		replacement := request.GetString("replacement", "")
+		message := request.GetString("message", "")
+		rewrite := request.GetBool("rewriteCallers", false)
+		dryRun := request.GetBool("dryRun", false)
+
+		if message == "" && replacement == "" {
+			return nil, fmt.Errorf("either message or replacement is required")
+		}
+		if rewrite && replacement == "" {
+			return nil, fmt.Errorf("rewriteCallers requires a replacement")
+		}
+
+		file, err = filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+
+		pkgs, err := typecheck.Load(ctx, filepath.Dir(file), ".")
+		if err != nil {
+			return nil, err
+		}
+		if len(pkgs) == 0 {
+			return nil, fmt.Errorf("no package found for %s", file)
+		}
+		pkg := pkgs[0]
+
+		astFile := pkg.File(file)
+		if astFile == nil {
+			return nil, fmt.Errorf("%s is not part of package %s for the current build configuration", file, pkg.ImportPath)
+		}
+		content, err := manager.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		declFile := &sourceFile{path: file, fset: pkg.Fset, ast: astFile, content: content}
+
+		fn := funcAt(pkg.Fset, astFile, line, column)
+		if fn == nil {
+			return nil, fmt.Errorf("no function declaration at %s:%d:%d", file, line, column)
+		}
+		old, ok := pkg.Info.Defs[fn.Name].(*types.Func)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a function", fn.Name.Name)
+		}
+
+		var repl *types.Func
+		if replacement != "" {
+			repl, err = resolveReplacement(ctx, pkg, old, replacement)
+			if err != nil {
+				return nil, err
+			}
+			if message == "" {
+				message = fmt.Sprintf("Use %s instead.", displayName(pkg, repl))
+			}
+		}
+
+		marked := markDeprecated(declFile, fn, message)
+
+		files := map[string]*sourceFile{file: declFile}
+		var rewritten int
+		var manual []string
+		if rewrite {
+			if signature(old) != signature(repl) {
+				return nil, fmt.Errorf("cannot rewrite callers: %s has signature %s but %s has %s",
+					old.Name(), signature(old), repl.Name(), signature(repl))
+			}
+
+			client, err := manager.GetClient()
+			if err != nil {
+				return nil, err
+			}
+			uri, err := utils.PathToURI(file)
+			if err != nil {
+				return nil, err
+			}
+			if err := client.OpenDocument(ctx, uri, string(content)); err != nil {
+				return nil, err
+			}
+			defer client.CloseDocument(ctx, uri)
+
+			namePos := pkg.Fset.Position(fn.Name.Pos())
+			refs, err := client.References(ctx, uri, lsp.Position{Line: namePos.Line - 1, Character: namePos.Column - 1}, false)
+			if err != nil {
+				return nil, err
+			}
+
+			rw := &rewriter{manager: manager, old: old, repl: repl, files: files}
+			for _, ref := range refs {
+				if err := rw.rewrite(ref); err != nil {
+					return nil, err
+				}
+			}
+			rw.dropUnusedImports()
+			rewritten, manual = rw.rewritten, rw.manual
+		}
+
+		var b strings.Builder
+		if !marked {
+			fmt.Fprintf(&b, "%s is already marked as deprecated", fn.Name.Name)
+		} else if dryRun {
+			fmt.Fprintf(&b, "Would mark %s as deprecated", fn.Name.Name)
+		} else {
+			fmt.Fprintf(&b, "Marked %s as deprecated", fn.Name.Name)
+		}
+		if rewrite {
+			fmt.Fprintf(&b, "\nRewrote %d call site(s) to %s", rewritten, displayName(pkg, repl))
+			if len(manual) > 0 {
+				fmt.Fprintf(&b, "\nCall sites that could not be rewritten automatically:\n  %s", strings.Join(manual, "\n  "))
+			}
+		}
+
+		diff, err := apply(ctx, manager, files, dryRun, request.GetBool("organizeImports", false))
+		if err != nil {
+			return nil, err
+		}
+		if dryRun && diff != "" {
+			b.WriteString("\n\n" + diff)
+		}
+		return mcp.NewToolResultText(b.String()), nil
+	}
+}
+
+// sourceFile is a parsed file along with the edits planned for it
+type sourceFile struct {
+	path    string
+	fset    *token.FileSet
+	ast     *ast.File
+	content []byte
+	edits   []lsp.TextEdit
+}
+
+func (f *sourceFile) replace(node ast.Node, text string) {
+	start, end := f.fset.Position(node.Pos()), f.fset.Position(node.End())
+	f.edits = append(f.edits, lsp.TextEdit{
+		Range: lsp.Range{
+			Start: lsp.Position{Line: start.Line - 1, Character: start.Column - 1},
+			End:   lsp.Position{Line: end.Line - 1, Character: end.Column - 1},
+		},
+		NewText: text,
+	})
+}
+
+// funcAt returns the function declaration whose name contains line:column
+func funcAt(fset *token.FileSet, file *ast.File, line, column int) *ast.FuncDecl {
+	tokFile := fset.File(file.Pos())
+	if line < 1 || line > tokFile.LineCount() {
+		return nil
+	}
+	pos := tokFile.LineStart(line) + token.Pos(column-1)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Pos() <= pos && pos <= fn.Name.End() {
+			return fn
+		}
+	}
+	return nil
+}
+
+// resolveReplacement finds the replacement function named by name. Methods
+// are replaced by another method of the same receiver type.
+func resolveReplacement(ctx context.Context, pkg *typecheck.Package, old *types.Func, name string) (*types.Func, error) {
+	if recv := old.Type().(*types.Signature).Recv(); recv != nil {
+		obj, _, _ := types.LookupFieldOrMethod(recv.Type(), true, pkg.Types, name)
+		method, ok := obj.(*types.Func)
+		if !ok {
+			return nil, fmt.Errorf("%s has no method %s", recv.Type(), name)
+		}
+		return method, nil
+	}
+
+	scope := pkg.Types.Scope()
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		path := name[:dot]
+		name = name[dot+1:]
+		if path != pkg.ImportPath {
+			// The replacement's package need not be a dependency of the
+			// deprecated function's package, so load it separately
+			loaded, err := typecheck.Load(ctx, pkg.Dir, path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load %s: %w", path, err)
+			}
+			if len(loaded) == 0 || loaded[0].Types == nil {
+				return nil, fmt.Errorf("package %s not found", path)
+			}
+			scope = loaded[0].Types.Scope()
+		}
+	}
+	fn, ok := scope.Lookup(name).(*types.Func)
+	if !ok {
+		return nil, fmt.Errorf("replacement %s is not a function", name)
+	}
+	return fn, nil
+}
+
+// signature renders fn's parameter and result types, ignoring names and the
+// receiver, qualified by import path so functions loaded separately compare
+func signature(fn *types.Func) string {
+	qualifier := func(p *types.Package) string { return p.Path() }
+	sig := fn.Type().(*types.Signature)
+	list := func(tuple *types.Tuple, variadic bool) string {
+		parts := make([]string, tuple.Len())
+		for i := range parts {
+			t := tuple.At(i).Type()
+			if variadic && i == len(parts)-1 {
+				parts[i] = "..." + types.TypeString(t.(*types.Slice).Elem(), qualifier)
+			} else {
+				parts[i] = types.TypeString(t, qualifier)
+			}
+		}
+		return "(" + strings.Join(parts, ", ") + ")"
+	}
+	return "func" + list(sig.Params(), sig.Variadic()) + " " + list(sig.Results(), false)
+}
+
+// displayName renders fn as it would be referred to from pkg
+func displayName(pkg *typecheck.Package, fn *types.Func) string {
+	if fn.Pkg() == nil || fn.Pkg() == pkg.Types || fn.Type().(*types.Signature).Recv() != nil {
+		return fn.Name()
+	}
+	return fn.Pkg().Name() + "." + fn.Name()
+}
+
+// markDeprecated appends a Deprecated paragraph to fn's doc comment, adding
+// the comment if there is none. It reports false if fn is already deprecated.
+func markDeprecated(f *sourceFile, fn *ast.FuncDecl, message string) bool {
+	notice := "// Deprecated: " + message + "\n"
+	if fn.Doc == nil {
+		pos := f.fset.Position(fn.Pos())
+		at := lsp.Position{Line: pos.Line - 1, Character: 0}
+		f.edits = append(f.edits, lsp.TextEdit{Range: lsp.Range{Start: at, End: at}, NewText: notice})
+		return true
+	}
+	for _, comment := range fn.Doc.List {
+		if strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(comment.Text, "//")), "Deprecated:") {
+			return false
+		}
+	}
+	// Insert after the last line of the doc comment
+	end := f.fset.Position(fn.Doc.End())
+	at := lsp.Position{Line: end.Line, Character: 0}
+	f.edits = append(f.edits, lsp.TextEdit{Range: lsp.Range{Start: at, End: at}, NewText: "//\n" + notice})
+	return true
+}
+
+// rewriter retargets call sites of old to repl
+type rewriter struct {
+	manager *gopls.Manager
+	old     *types.Func
+	repl    *types.Func
+	files   map[string]*sourceFile
+
+	rewritten int
+	manual    []string
+	// dropped counts the qualified references removed from each file per
+	// package qualifier, to detect imports left unused
+	dropped map[*sourceFile]map[string]int
+}
+
+func (rw *rewriter) rewrite(ref lsp.Location) error {
+	path, err := rw.manager.URIToPath(ref.URI)
+	if err != nil {
+		return err
+	}
+	f := rw.files[path]
+	if f == nil {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, content, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		f = &sourceFile{path: path, fset: fset, ast: file, content: content}
+		rw.files[path] = f
+	}
+
+	offset, err := utils.CalculateOffset(string(f.content), ref.Range.Start)
+	if err != nil {
+		return err
+	}
+	pos := f.fset.File(f.ast.Pos()).Pos(offset)
+	location := fmt.Sprintf("%s:%d:%d", path, ref.Range.Start.Line+1, ref.Range.Start.Character+1)
+
+	nodes := astscan.PathTo(f.ast, pos)
+	if len(nodes) < 2 || astscan.CalleeOf(nodes) == nil {
+		rw.manual = append(rw.manual, location+" (not a call)")
+		return nil
+	}
+	ident := nodes[len(nodes)-1].(*ast.Ident)
+
+	// Methods and replacements in the same package keep the same qualifier
+	if rw.repl.Type().(*types.Signature).Recv() != nil || rw.repl.Pkg() == rw.old.Pkg() {
+		f.replace(ident, rw.repl.Name())
+		rw.rewritten++
+		return nil
+	}
+
+	// Otherwise replace the whole callee, qualifying it for this file
+	var callee ast.Node = ident
+	var qualifier string
+	if sel, ok := nodes[len(nodes)-2].(*ast.SelectorExpr); ok && sel.Sel == ident {
+		x, ok := sel.X.(*ast.Ident)
+		if !ok {
+			rw.manual = append(rw.manual, location+" (unsupported callee expression)")
+			return nil
+		}
+		callee, qualifier = sel, x.Name
+	}
+
+	filePkg, err := codegen.ImportPath(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	text := rw.repl.Name()
+	if filePkg != rw.repl.Pkg().Path() {
+		name := edits.ImportName(f.ast, rw.repl.Pkg().Path())
+		if name == "" {
+			name = rw.repl.Pkg().Name()
+			if !rw.hasImportEdit(f) {
+				f.edits = append(f.edits, edits.AddImport(f.fset, f.ast, rw.repl.Pkg().Path()))
+			}
+		}
+		text = name + "." + text
+	}
+	f.replace(callee, text)
+	rw.rewritten++
+
+	if qualifier != "" {
+		if rw.dropped == nil {
+			rw.dropped = make(map[*sourceFile]map[string]int)
+		}
+		if rw.dropped[f] == nil {
+			rw.dropped[f] = make(map[string]int)
+		}
+		rw.dropped[f][qualifier]++
+	}
+	return nil
+}
+
+// hasImportEdit reports whether an import of the replacement's package has
+// already been planned for f
+func (rw *rewriter) hasImportEdit(f *sourceFile) bool {
+	quoted := `"` + rw.repl.Pkg().Path() + `"`
+	for _, edit := range f.edits {
+		if strings.Contains(edit.NewText, quoted) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropUnusedImports removes the old package's import from files where every
+// qualified reference to it was rewritten
+func (rw *rewriter) dropUnusedImports() {
+	for f, byQualifier := range rw.dropped {
+		for qualifier, dropped := range byQualifier {
+			uses := 0
+			ast.Inspect(f.ast, func(n ast.Node) bool {
+				if sel, ok := n.(*ast.SelectorExpr); ok {
+					if x, ok := sel.X.(*ast.Ident); ok && x.Name == qualifier {
+						uses++
+					}
+				}
+				return true
+			})
+			if uses > dropped {
+				continue
+			}
+			if edit, ok := edits.RemoveImport(f.fset, f.ast, rw.old.Pkg().Path()); ok {
+				f.edits = append(f.edits, edit)
+			}
+		}
+	}
+}
+
+// apply writes the planned edits, returning them as a diff for a dry run
+func apply(ctx context.Context, manager *gopls.Manager, files map[string]*sourceFile, dryRun, organize bool) (string, error) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	updates := make(map[string][]byte)
+	for _, path := range paths {
+		f := files[path]
+		if len(f.edits) == 0 {
+			continue
+		}
+		updated, err := edits.Apply(string(f.content), f.edits)
+		if err != nil {
+			return "", fmt.Errorf("failed to update %s: %w", path, err)
+		}
+		updates[path] = []byte(updated)
+	}
+	if organize && len(updates) > 0 {
+		if err := manager.OrganizeImports(ctx, updates); err != nil {
+			return "", err
+		}
+	}
+
+	if !dryRun {
+		return "", manager.WriteFiles(updates)
+	}
+	var diffs strings.Builder
+	for _, path := range paths {
+		if updated, ok := updates[path]; ok {
+			diffs.WriteString(edits.Unified(edits.Label(manager.WorkspaceRoot(), path), string(files[path].content), string(updated)))
+		}
+	}
+	return diffs.String(), nil
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
 	"github.com/yantrio/mcp-gopls/internal/utils"
 )
 
@@ -23,6 +26,23 @@ func NewTool(manager *gopls.Manager) mcp.Tool {
 					"type":        "string",
 					"description": "Absolute path to the Go source file",
 				},
+				"severity": map[string]interface{}{
+					"type":        "string",
+					"description": "Minimum severity to report: 'error' reports errors only, 'hint' reports everything",
+					"enum":        []string{"error", "warning", "information", "hint"},
+					"default":     "hint",
+				},
+				"sources": map[string]interface{}{
+					"type":        "array",
+					"description": "Only report diagnostics from these sources: 'compiler' (type checking, syntax and go list errors), 'staticcheck', 'vet' (the other analyzers), or an exact source or analyzer name such as 'printf'",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"sortBy": map[string]interface{}{
+					"type":        "string",
+					"description": "Order of the results: 'none' keeps gopls's order, 'line' sorts by position, 'severity' puts the most severe first and then sorts by position",
+					"enum":        []string{"none", "line", "severity"},
+					"default":     "none",
+				},
 			},
 			Required: []string{"file"},
 		},
@@ -35,6 +55,15 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 		if err != nil {
 			return nil, err
 		}
+		minSeverity, ok := severities[request.GetString("severity", "hint")]
+		if !ok {
+			return nil, fmt.Errorf("invalid severity %q: must be 'error', 'warning', 'information' or 'hint'", request.GetString("severity", ""))
+		}
+		sources := request.GetStringSlice("sources", nil)
+		sortBy := request.GetString("sortBy", "none")
+		if sortBy != "none" && sortBy != "line" && sortBy != "severity" {
+			return nil, fmt.Errorf("invalid sortBy %q: must be 'none', 'line' or 'severity'", sortBy)
+		}
 
 		client, err := manager.GetClient()
 		if err != nil {
@@ -44,7 +73,7 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 		if err != nil {
 			return nil, err
 		}
-		content, err := os.ReadFile(file)
+		content, err := manager.ReadFile(file)
 		if err != nil {
 			return nil, err
 		}
@@ -55,7 +84,8 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 		}
 		defer client.CloseDocument(ctx, uri)
 
-		lspDiagnostics := client.GetDiagnostics(uri)
+		lspDiagnostics := filterDiagnostics(client.GetDiagnostics(uri), minSeverity, sources)
+		sortDiagnostics(lspDiagnostics, sortBy)
 
 		diagnostics := make([]map[string]interface{}, 0)
 		for _, diag := range lspDiagnostics {
@@ -74,17 +104,143 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 				severity = "hint"
 			}
 
-			diagnostics = append(diagnostics, map[string]interface{}{
+			entry := map[string]interface{}{
 				"severity":  severity,
 				"message":   diag.Message,
 				"line":      startLine,
 				"column":    startColumn,
 				"endLine":   endLine,
 				"endColumn": endColumn,
-			})
+			}
+			if diag.Source != "" {
+				entry["source"] = diag.Source
+			}
+			if diag.Code != nil {
+				entry["code"] = diag.Code
+			}
+			if diag.CodeDescription != nil {
+				entry["codeDescription"] = diag.CodeDescription.Href
+			}
+			if len(diag.Tags) > 0 {
+				tags := make([]string, len(diag.Tags))
+				for i, tag := range diag.Tags {
+					tags[i] = tag.String()
+				}
+				entry["tags"] = tags
+			}
+			if len(diag.RelatedInformation) > 0 {
+				entry["related"] = relatedInformation(manager, diag.RelatedInformation)
+			}
+
+			diagnostics = append(diagnostics, entry)
 		}
 
 		result, _ := json.MarshalIndent(diagnostics, "", "  ")
 		return mcp.NewToolResultText(fmt.Sprintf("Found %d diagnostic(s):\n%s", len(diagnostics), string(result))), nil
 	}
-}
\ No newline at end of file
+}
+
+var severities = map[string]lsp.DiagnosticSeverity{
+	"error":       lsp.DiagnosticSeverityError,
+	"warning":     lsp.DiagnosticSeverityWarning,
+	"information": lsp.DiagnosticSeverityInformation,
+	"hint":        lsp.DiagnosticSeverityHint,
+}
+
+// compilerSources are the sources gopls uses for diagnostics that come from
+// loading, parsing and type checking rather than from an analyzer
+var compilerSources = map[string]bool{
+	"compiler":  true,
+	"syntax":    true,
+	"go list":   true,
+	"typecheck": true,
+}
+
+var staticcheckCode = regexp.MustCompile(`^(SA|S|ST|QF)[0-9]+$`)
+
+// sourceGroup returns the group a diagnostic's source belongs to: compiler,
+// staticcheck or vet
+func sourceGroup(diag lsp.Diagnostic) string {
+	switch {
+	case diag.Source == "" || compilerSources[diag.Source]:
+		return "compiler"
+	case staticcheckCode.MatchString(diag.Source) || staticcheckCode.MatchString(fmt.Sprint(diag.Code)):
+		return "staticcheck"
+	}
+	return "vet"
+}
+
+// filterDiagnostics keeps the diagnostics at least as severe as minSeverity
+// whose source or source group is one of sources. An empty sources list
+// keeps every source.
+func filterDiagnostics(diagnostics []lsp.Diagnostic, minSeverity lsp.DiagnosticSeverity, sources []string) []lsp.Diagnostic {
+	var kept []lsp.Diagnostic
+	for _, diag := range diagnostics {
+		if effectiveSeverity(diag) > minSeverity {
+			continue
+		}
+		if len(sources) > 0 && !matchesSource(diag, sources) {
+			continue
+		}
+		kept = append(kept, diag)
+	}
+	return kept
+}
+
+func matchesSource(diag lsp.Diagnostic, sources []string) bool {
+	group := sourceGroup(diag)
+	for _, source := range sources {
+		if strings.EqualFold(source, group) || strings.EqualFold(source, diag.Source) {
+			return true
+		}
+	}
+	return false
+}
+
+// effectiveSeverity treats a missing severity as an error, as the output does
+func effectiveSeverity(diag lsp.Diagnostic) lsp.DiagnosticSeverity {
+	if diag.Severity == 0 {
+		return lsp.DiagnosticSeverityError
+	}
+	return diag.Severity
+}
+
+func sortDiagnostics(diagnostics []lsp.Diagnostic, sortBy string) {
+	if sortBy == "none" {
+		return
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if sortBy == "severity" && effectiveSeverity(a) != effectiveSeverity(b) {
+			return effectiveSeverity(a) < effectiveSeverity(b)
+		}
+		if a.Range.Start.Line != b.Range.Start.Line {
+			return a.Range.Start.Line < b.Range.Start.Line
+		}
+		return a.Range.Start.Character < b.Range.Start.Character
+	})
+}
+
+// relatedInformation lists the other locations a diagnostic refers to, such
+// as a conflicting declaration, with a preview of each location's line
+func relatedInformation(manager *gopls.Manager, related []lsp.DiagnosticRelatedInformation) []map[string]interface{} {
+	entries := make([]map[string]interface{}, 0, len(related))
+	for _, info := range related {
+		path, err := manager.URIToPath(info.Location.URI)
+		if err != nil {
+			continue
+		}
+		line, column := utils.ConvertToUserPosition(info.Location.Range.Start)
+		entry := map[string]interface{}{
+			"file":    path,
+			"line":    line,
+			"column":  column,
+			"message": info.Message,
+		}
+		if preview, err := utils.ReadLine(path, line); err == nil {
+			entry["preview"] = strings.TrimSpace(preview)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
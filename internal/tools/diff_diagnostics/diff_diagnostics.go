@@ -0,0 +1,208 @@
+package diff_diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/resultfilter"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "DiffDiagnostics",
+		Description: "Save a named checkpoint of the workspace's diagnostics, or report which diagnostics appeared and disappeared since one, to check that an edit fixed its target without introducing errors elsewhere",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"action": map[string]interface{}{
+					"type":        "string",
+					"description": "'checkpoint' saves the current diagnostics, 'diff' compares the current diagnostics with the checkpoint",
+					"enum":        []string{"checkpoint", "diff"},
+					"default":     "diff",
+				},
+				"checkpoint": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the checkpoint",
+					"default":     "default",
+				},
+				"update": map[string]interface{}{
+					"type":        "boolean",
+					"description": "After a diff, move the checkpoint to the current diagnostics",
+					"default":     false,
+				},
+				"settleMs": map[string]interface{}{
+					"type":        "number",
+					"description": "Wait until gopls has published no diagnostics for this many milliseconds before reading them, so recent edits are reflected",
+					"default":     500,
+				},
+				"includeVendor": resultfilter.IncludeVendorProperty(manager),
+			},
+		},
+	}
+}
+
+type entry struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+type diffResult struct {
+	Checkpoint  string  `json:"checkpoint"`
+	Since       string  `json:"since"`
+	Appeared    []entry `json:"appeared"`
+	Disappeared []entry `json:"disappeared"`
+	Unchanged   int     `json:"unchanged"`
+	NewErrors   int     `json:"newErrors"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		action := request.GetString("action", "diff")
+		if action != "checkpoint" && action != "diff" {
+			return nil, fmt.Errorf("invalid action %q: must be 'checkpoint' or 'diff'", action)
+		}
+		name := request.GetString("checkpoint", "default")
+		update := request.GetBool("update", false)
+		settle := time.Duration(request.GetInt("settleMs", 500)) * time.Millisecond
+
+		client, err := manager.GetClient()
+		if err != nil {
+			return nil, err
+		}
+		if err := client.WaitForDiagnostics(ctx, settle); err != nil {
+			return nil, err
+		}
+
+		if action == "checkpoint" {
+			snapshot := client.SaveDiagnosticsCheckpoint(name)
+			count := 0
+			for _, diagnostics := range snapshot.Diagnostics {
+				count += len(diagnostics)
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Saved checkpoint %q with %d diagnostic(s) in %d file(s)", name, count, len(snapshot.Diagnostics))), nil
+		}
+
+		before, err := client.DiagnosticsCheckpoint(name)
+		if err != nil {
+			return nil, err
+		}
+		after := client.AllDiagnostics()
+		if update {
+			after = client.SaveDiagnosticsCheckpoint(name)
+		}
+
+		filter := resultfilter.FromRequest(manager, request)
+		result := diff(manager, filtered(before, filter), filtered(after, filter))
+		result.Checkpoint = name
+		result.Since = before.Taken.Format(time.RFC3339)
+
+		output, _ := json.MarshalIndent(result, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("%d diagnostic(s) appeared (%d error(s)), %d disappeared:\n%s",
+			len(result.Appeared), result.NewErrors, len(result.Disappeared), output)), nil
+	}
+}
+
+// filtered returns snapshot without the files filter leaves out
+func filtered(snapshot lsp.DiagnosticsSnapshot, filter resultfilter.Filter) lsp.DiagnosticsSnapshot {
+	kept := snapshot
+	kept.Diagnostics = make(map[string][]lsp.Diagnostic, len(snapshot.Diagnostics))
+	for uri, diagnostics := range snapshot.Diagnostics {
+		if filter.KeepURI(uri) {
+			kept.Diagnostics[uri] = diagnostics
+		}
+	}
+	return kept
+}
+
+// diff matches diagnostics by file, severity, source and message, ignoring
+// their positions so that diagnostics moved by an edit are not reported as
+// new. Repeated identical diagnostics are matched by count.
+func diff(manager *gopls.Manager, before, after lsp.DiagnosticsSnapshot) diffResult {
+	result := diffResult{Appeared: make([]entry, 0), Disappeared: make([]entry, 0)}
+
+	remaining := make(map[string][]entry)
+	for uri, diagnostics := range before.Diagnostics {
+		for _, diag := range diagnostics {
+			e := toEntry(manager, uri, diag)
+			remaining[key(e)] = append(remaining[key(e)], e)
+		}
+	}
+	for uri, diagnostics := range after.Diagnostics {
+		for _, diag := range diagnostics {
+			e := toEntry(manager, uri, diag)
+			k := key(e)
+			if len(remaining[k]) > 0 {
+				remaining[k] = remaining[k][1:]
+				result.Unchanged++
+				continue
+			}
+			result.Appeared = append(result.Appeared, e)
+			if e.Severity == "error" {
+				result.NewErrors++
+			}
+		}
+	}
+	for _, entries := range remaining {
+		result.Disappeared = append(result.Disappeared, entries...)
+	}
+
+	sortEntries(result.Appeared)
+	sortEntries(result.Disappeared)
+	return result
+}
+
+func key(e entry) string {
+	return e.File + "\x00" + e.Severity + "\x00" + e.Source + "\x00" + e.Message
+}
+
+func toEntry(manager *gopls.Manager, uri string, diag lsp.Diagnostic) entry {
+	file, err := manager.URIToPath(uri)
+	if err != nil {
+		file = uri
+	}
+	line, column := utils.ConvertToUserPosition(diag.Range.Start)
+
+	severity := "error"
+	switch diag.Severity {
+	case lsp.DiagnosticSeverityWarning:
+		severity = "warning"
+	case lsp.DiagnosticSeverityInformation:
+		severity = "information"
+	case lsp.DiagnosticSeverityHint:
+		severity = "hint"
+	}
+
+	return entry{
+		File:     file,
+		Line:     line,
+		Column:   column,
+		Severity: severity,
+		Source:   diag.Source,
+		Message:  diag.Message,
+	}
+}
+
+func sortEntries(entries []entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
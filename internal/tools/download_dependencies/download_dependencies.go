@@ -0,0 +1,154 @@
+package download_dependencies
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "DownloadDependencies",
+		Description: "Run 'go mod download' in the workspace and report which modules were fetched and which failed, to unblock gopls when packages fail to load",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"tidyDiff": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Run 'go mod tidy -diff' first and include the changes it would make to go.mod/go.sum",
+					"default":     false,
+				},
+			},
+		},
+	}
+}
+
+// downloadedModule is the JSON object printed by 'go mod download -json'
+type downloadedModule struct {
+	Path    string
+	Version string
+	Error   string
+}
+
+// zipFetch matches the module zip requests logged by 'go mod download -x'
+var zipFetch = regexp.MustCompile(`^# get \S+?/(\S+)/@v/(\S+)\.zip: 200`)
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		root := manager.WorkspaceRoot()
+		var sections []string
+
+		if request.GetBool("tidyDiff", false) {
+			tidy, err := gocmd.Run(ctx, root, nil, "mod", "tidy", "-diff")
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case tidy.ExitCode == 0:
+				sections = append(sections, "go mod tidy: go.mod and go.sum are tidy")
+			case strings.TrimSpace(tidy.Stdout) != "":
+				sections = append(sections, fmt.Sprintf("go mod tidy would make these changes:\n%s", strings.TrimSpace(tidy.Stdout)))
+			default:
+				sections = append(sections, fmt.Sprintf("go mod tidy -diff failed:\n%s", strings.TrimSpace(tidy.Stderr)))
+			}
+		}
+
+		download, err := gocmd.Run(ctx, root, nil, "mod", "download", "-json", "-x")
+		if err != nil {
+			return nil, err
+		}
+
+		failed := make([]map[string]interface{}, 0)
+		total := 0
+		decoder := json.NewDecoder(strings.NewReader(download.Stdout))
+		for {
+			var mod downloadedModule
+			if err := decoder.Decode(&mod); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to parse go mod download output: %w", err)
+			}
+			total++
+			if mod.Error != "" {
+				failed = append(failed, map[string]interface{}{
+					"module": mod.Path + "@" + mod.Version,
+					"error":  mod.Error,
+					"cause":  classifyError(mod.Error),
+				})
+			}
+		}
+
+		fetched := make([]string, 0)
+		for _, line := range strings.Split(download.Stderr, "\n") {
+			if m := zipFetch.FindStringSubmatch(line); m != nil {
+				fetched = append(fetched, unescapeModulePath(m[1])+"@"+unescapeModulePath(m[2]))
+			}
+		}
+
+		report := map[string]interface{}{
+			"modules": total,
+			"fetched": fetched,
+			"failed":  failed,
+		}
+		// Errors that prevent go from reading go.mod are only printed to stderr
+		if download.ExitCode != 0 && len(failed) == 0 {
+			report["error"] = strings.TrimSpace(lastLines(download.Stderr, 20))
+		}
+
+		result, _ := json.MarshalIndent(report, "", "  ")
+		sections = append(sections, fmt.Sprintf("Downloaded %d module(s), %d failed:\n%s", len(fetched), len(failed), string(result)))
+		return mcp.NewToolResultText(strings.Join(sections, "\n\n")), nil
+	}
+}
+
+// classifyError gives a short hint for common module download failures
+func classifyError(msg string) string {
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "terminal prompts disabled"),
+		strings.Contains(lower, "could not read username"),
+		strings.Contains(lower, "401 unauthorized"),
+		strings.Contains(lower, "403 forbidden"):
+		return "authentication: configure git credentials or GOPRIVATE/GONOSUMDB for private modules"
+	case strings.Contains(lower, "410 gone"), strings.Contains(lower, "404 not found"):
+		return "not found: the module or version does not exist on the proxy; check the path or GOPRIVATE"
+	case strings.Contains(lower, "proxyconnect"), strings.Contains(lower, "no such host"),
+		strings.Contains(lower, "connection refused"), strings.Contains(lower, "i/o timeout"):
+		return "network: the module proxy could not be reached; check GOPROXY and network access"
+	case strings.Contains(lower, "checksum mismatch"), strings.Contains(lower, "verifying module"):
+		return "checksum: go.sum or the checksum database disagrees with the downloaded module"
+	default:
+		return "unknown"
+	}
+}
+
+// unescapeModulePath reverses the module proxy case encoding ("!a" -> "A")
+func unescapeModulePath(escaped string) string {
+	var b strings.Builder
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] == '!' && i+1 < len(escaped) {
+			i++
+			b.WriteString(strings.ToUpper(string(escaped[i])))
+			continue
+		}
+		b.WriteByte(escaped[i])
+	}
+	return b.String()
+}
+
+// lastLines returns at most n trailing lines of s
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
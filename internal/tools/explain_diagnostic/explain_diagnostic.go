@@ -0,0 +1,378 @@
+package explain_diagnostic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "ExplainDiagnostic",
+		Description: "Gather the context needed to fix a diagnostic in one call: the diagnostic, the offending code, the definitions and hover information of the symbols involved, and the quick fixes gopls offers with their diffs",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Path to the Go source file",
+				},
+				"index": map[string]interface{}{
+					"type":        "number",
+					"description": "Index of the diagnostic in the order GetDiagnostics lists them, starting at 0",
+				},
+				"message": map[string]interface{}{
+					"type":        "string",
+					"description": "Text contained in the diagnostic's message, used to pick the diagnostic instead of index",
+				},
+				"line": map[string]interface{}{
+					"type":        "number",
+					"description": "Only consider diagnostics starting on this line (1-indexed)",
+				},
+				"contextLines": map[string]interface{}{
+					"type":        "number",
+					"description": "Number of lines of code to show around the diagnostic",
+					"default":     3,
+				},
+				"maxSymbols": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum number of symbols to look up definitions for",
+					"default":     5,
+				},
+			},
+			Required: []string{"file"},
+		},
+	}
+}
+
+type location struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message,omitempty"`
+	Preview string `json:"preview,omitempty"`
+}
+
+type symbol struct {
+	Name        string     `json:"name"`
+	Line        int        `json:"line"`
+	Column      int        `json:"column"`
+	Hover       string     `json:"hover,omitempty"`
+	Definitions []location `json:"definitions,omitempty"`
+}
+
+type quickFix struct {
+	Title   string `json:"title"`
+	Command string `json:"command,omitempty"`
+	Diff    string `json:"diff,omitempty"`
+}
+
+type explanation struct {
+	Severity   string     `json:"severity"`
+	Source     string     `json:"source,omitempty"`
+	Code       string     `json:"code,omitempty"`
+	Message    string     `json:"message"`
+	Tags       []string   `json:"tags,omitempty"`
+	Related    []location `json:"related,omitempty"`
+	Line       int        `json:"line"`
+	Column     int        `json:"column"`
+	EndLine    int        `json:"endLine"`
+	EndColumn  int        `json:"endColumn"`
+	Snippet    string     `json:"snippet"`
+	Symbols    []symbol   `json:"symbols"`
+	QuickFixes []quickFix `json:"quickFixes"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		index := request.GetInt("index", -1)
+		message := request.GetString("message", "")
+		line := request.GetInt("line", 0)
+		contextLines := request.GetInt("contextLines", 3)
+		maxSymbols := request.GetInt("maxSymbols", 5)
+
+		client, err := manager.GetClient()
+		if err != nil {
+			return nil, err
+		}
+		uri, err := utils.PathToURI(file)
+		if err != nil {
+			return nil, err
+		}
+		content, err := manager.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		text := string(content)
+
+		if err := client.OpenDocument(ctx, uri, text); err != nil {
+			return nil, err
+		}
+		defer client.CloseDocument(ctx, uri)
+
+		diagnostic, err := selectDiagnostic(client.GetDiagnostics(uri), index, message, line)
+		if err != nil {
+			return nil, err
+		}
+
+		startLine, startColumn := utils.ConvertToUserPosition(diagnostic.Range.Start)
+		endLine, endColumn := utils.ConvertToUserPosition(diagnostic.Range.End)
+		result := explanation{
+			Severity:   severityName(diagnostic.Severity),
+			Source:     diagnostic.Source,
+			Message:    diagnostic.Message,
+			Line:       startLine,
+			Column:     startColumn,
+			EndLine:    endLine,
+			EndColumn:  endColumn,
+			Snippet:    snippet(text, startLine, endLine, contextLines),
+			Symbols:    make([]symbol, 0),
+			QuickFixes: make([]quickFix, 0),
+		}
+		if diagnostic.Code != nil {
+			result.Code = fmt.Sprint(diagnostic.Code)
+		}
+		for _, tag := range diagnostic.Tags {
+			result.Tags = append(result.Tags, tag.String())
+		}
+		for _, info := range diagnostic.RelatedInformation {
+			for _, loc := range definitions(manager, []lsp.Location{info.Location}) {
+				loc.Message = info.Message
+				result.Related = append(result.Related, loc)
+			}
+		}
+
+		for _, ident := range involvedIdentifiers(text, diagnostic, maxSymbols) {
+			sym := symbol{Name: ident.name}
+			sym.Line, sym.Column = utils.ConvertToUserPosition(ident.pos)
+			if hover, err := client.Hover(ctx, uri, ident.pos); err == nil && hover != nil {
+				sym.Hover = strings.TrimSpace(hover.Contents.Value)
+			}
+			if locations, err := client.Definition(ctx, uri, ident.pos); err == nil {
+				sym.Definitions = definitions(manager, locations)
+			}
+			result.Symbols = append(result.Symbols, sym)
+		}
+
+		actions, err := client.QuickFixes(ctx, uri, diagnostic)
+		if err != nil {
+			return nil, err
+		}
+		for _, action := range actions {
+			if resolved, err := client.ResolveCodeAction(ctx, action); err == nil {
+				action = resolved
+			}
+			fix := quickFix{Title: action.Title}
+			if action.Command != nil {
+				fix.Command = action.Command.Command
+			}
+			if action.Edit != nil {
+				fix.Diff, err = previewEdit(manager, action.Edit)
+				if err != nil {
+					return nil, fmt.Errorf("failed to preview quick fix %q: %w", action.Title, err)
+				}
+			}
+			result.QuickFixes = append(result.QuickFixes, fix)
+		}
+
+		output, _ := json.MarshalIndent(result, "", "  ")
+		return mcp.NewToolResultText(string(output)), nil
+	}
+}
+
+// selectDiagnostic picks the diagnostic by message, falling back to index,
+// among those starting on line when line is set. With a single candidate
+// neither message nor index is needed.
+func selectDiagnostic(diagnostics []lsp.Diagnostic, index int, message string, line int) (lsp.Diagnostic, error) {
+	candidates := diagnostics
+	if line > 0 {
+		candidates = nil
+		for _, diag := range diagnostics {
+			if diag.Range.Start.Line+1 == line {
+				candidates = append(candidates, diag)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return lsp.Diagnostic{}, fmt.Errorf("no diagnostics found")
+	}
+
+	switch {
+	case message != "":
+		for _, diag := range candidates {
+			if strings.Contains(diag.Message, message) {
+				return diag, nil
+			}
+		}
+		return lsp.Diagnostic{}, fmt.Errorf("no diagnostic message contains %q", message)
+	case index >= 0:
+		if index >= len(candidates) {
+			return lsp.Diagnostic{}, fmt.Errorf("diagnostic index %d out of range: found %d diagnostic(s)", index, len(candidates))
+		}
+		return candidates[index], nil
+	case len(candidates) == 1:
+		return candidates[0], nil
+	}
+	return lsp.Diagnostic{}, fmt.Errorf("found %d diagnostics: specify index or message", len(candidates))
+}
+
+func severityName(severity lsp.DiagnosticSeverity) string {
+	switch severity {
+	case lsp.DiagnosticSeverityWarning:
+		return "warning"
+	case lsp.DiagnosticSeverityInformation:
+		return "information"
+	case lsp.DiagnosticSeverityHint:
+		return "hint"
+	}
+	return "error"
+}
+
+// snippet returns the lines from startLine to endLine with context lines on
+// either side, each prefixed with its line number and the diagnostic's lines
+// marked with '>'
+func snippet(text string, startLine, endLine, contextLines int) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	first := max(startLine-contextLines, 1)
+	last := min(endLine+contextLines, len(lines))
+
+	var b strings.Builder
+	for n := first; n <= last; n++ {
+		marker := " "
+		if n >= startLine && n <= endLine {
+			marker = ">"
+		}
+		fmt.Fprintf(&b, "%s%5d | %s\n", marker, n, lines[n-1])
+	}
+	return b.String()
+}
+
+type identifier struct {
+	name string
+	pos  lsp.Position
+}
+
+// involvedIdentifiers returns the identifiers on the diagnostic's lines,
+// those the message mentions first. An identifier is only returned once.
+func involvedIdentifiers(text string, diagnostic lsp.Diagnostic, limit int) []identifier {
+	lines := strings.SplitAfter(text, "\n")
+	startLine := diagnostic.Range.Start.Line
+	endLine := min(diagnostic.Range.End.Line, len(lines)-1)
+	if startLine > endLine {
+		return nil
+	}
+	offset := 0
+	for _, l := range lines[:startLine] {
+		offset += len(l)
+	}
+	src := []byte(strings.Join(lines[startLine:endLine+1], ""))
+
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", -1, len(src)), src, nil, 0)
+
+	mentioned := mentionedWords(diagnostic.Message)
+	seen := make(map[string]bool)
+	var inMessage, others []identifier
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.IDENT || lit == "_" || seen[lit] {
+			continue
+		}
+		seen[lit] = true
+		position, err := utils.OffsetToPosition(text, offset+fset.Position(pos).Offset)
+		if err != nil {
+			continue
+		}
+		ident := identifier{name: lit, pos: position}
+		if mentioned[lit] {
+			inMessage = append(inMessage, ident)
+		} else {
+			others = append(others, ident)
+		}
+	}
+
+	idents := append(inMessage, others...)
+	if limit >= 0 && len(idents) > limit {
+		idents = idents[:limit]
+	}
+	return idents
+}
+
+var wordPattern = regexp.MustCompile(`[\p{L}_][\p{L}\p{N}_]*`)
+
+func mentionedWords(message string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range wordPattern.FindAllString(message, -1) {
+		words[word] = true
+	}
+	return words
+}
+
+func definitions(manager *gopls.Manager, locations []lsp.Location) []location {
+	var defs []location
+	for _, loc := range locations {
+		path, err := manager.URIToPath(loc.URI)
+		if err != nil {
+			continue
+		}
+		line, column := utils.ConvertToUserPosition(loc.Range.Start)
+		def := location{File: path, Line: line, Column: column}
+		if content, err := os.ReadFile(path); err == nil {
+			lines := strings.Split(string(content), "\n")
+			if line <= len(lines) {
+				def.Preview = strings.TrimSpace(lines[line-1])
+			}
+		}
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// previewEdit renders a quick fix's workspace edit as a unified diff without
+// applying it, labelled with paths relative to the workspace root
+func previewEdit(manager *gopls.Manager, edit *lsp.WorkspaceEdit) (string, error) {
+	fileEdits, err := edits.FileEdits(edit, manager.URIToPath)
+	if err != nil {
+		return "", err
+	}
+	paths := make([]string, 0, len(fileEdits))
+	for path := range fileEdits {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var diff strings.Builder
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		updated, err := edits.Apply(string(content), fileEdits[path])
+		if err != nil {
+			return "", err
+		}
+		diff.WriteString(edits.Unified(edits.Label(manager.WorkspaceRoot(), path), string(content), updated))
+	}
+	return diff.String(), nil
+}
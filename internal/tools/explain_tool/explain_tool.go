@@ -0,0 +1,133 @@
+package explain_tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/tools"
+)
+
+// Entry is a tool as clients see it, named as exposed, with its guidance
+type Entry struct {
+	Tool  mcp.Tool
+	Guide tools.Guide
+}
+
+type argument struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Enum        interface{} `json:"enum,omitempty"`
+	Description string      `json:"description,omitempty"`
+}
+
+type explanation struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description"`
+	Arguments   []argument      `json:"arguments"`
+	Examples    []tools.Example `json:"examples,omitempty"`
+	Mistakes    []string        `json:"commonMistakes,omitempty"`
+	Related     []string        `json:"related,omitempty"`
+}
+
+type overview struct {
+	Name  string `json:"name"`
+	Title string `json:"title,omitempty"`
+}
+
+func NewTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "ExplainTool",
+		Description: "Explain how to use a tool: what it does, its arguments with their types and defaults, example calls, common mistakes such as 0- instead of 1-indexed positions, and related tools. Without a tool, lists the tools. Use it when unsure which arguments a tool needs or after a call failed.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"tool": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the tool to explain, as listed by the server",
+				},
+			},
+		},
+	}
+}
+
+// NewHandler explains the tools returned by entries, which is called on
+// every request so it sees the names the tools are finally exposed under
+func NewHandler(entries func() []Entry) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name := strings.TrimSpace(request.GetString("tool", ""))
+		all := entries()
+
+		if name == "" {
+			list := make([]overview, len(all))
+			for i, entry := range all {
+				list[i] = overview{Name: entry.Tool.Name, Title: entry.Guide.Title}
+			}
+			output, _ := json.MarshalIndent(list, "", "  ")
+			return mcp.NewToolResultText(fmt.Sprintf("%d tools. Pass tool to explain one.\n%s", len(list), output)), nil
+		}
+
+		var names []string
+		for _, entry := range all {
+			if entry.Tool.Name == name {
+				output, _ := json.MarshalIndent(explain(entry), "", "  ")
+				return mcp.NewToolResultText(string(output)), nil
+			}
+			if strings.EqualFold(entry.Tool.Name, name) || strings.Contains(strings.ToLower(entry.Tool.Name), strings.ToLower(name)) {
+				names = append(names, entry.Tool.Name)
+			}
+		}
+		if len(names) > 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("No tool named %s. Did you mean: %s?", name, strings.Join(names, ", "))), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("No tool named %s. Call ExplainTool without arguments to list the tools.", name)), nil
+	}
+}
+
+// explain turns an entry into its explanation, with the arguments sorted
+// required first
+func explain(entry Entry) explanation {
+	required := make(map[string]bool)
+	for _, name := range entry.Tool.InputSchema.Required {
+		required[name] = true
+	}
+	var args []argument
+	for name, raw := range entry.Tool.InputSchema.Properties {
+		arg := argument{Name: name, Required: required[name]}
+		if schema, ok := raw.(map[string]interface{}); ok {
+			arg.Type, _ = schema["type"].(string)
+			arg.Description, _ = schema["description"].(string)
+			arg.Default = schema["default"]
+			arg.Enum = schema["enum"]
+			if items, ok := schema["items"].(map[string]interface{}); ok && arg.Type == "array" {
+				if itemType, ok := items["type"].(string); ok {
+					arg.Type = "array of " + itemType
+				}
+			}
+		}
+		args = append(args, arg)
+	}
+	sort.Slice(args, func(i, j int) bool {
+		if args[i].Required != args[j].Required {
+			return args[i].Required
+		}
+		return args[i].Name < args[j].Name
+	})
+
+	return explanation{
+		Name:        entry.Tool.Name,
+		Title:       entry.Guide.Title,
+		Description: entry.Tool.Description,
+		Arguments:   args,
+		Examples:    entry.Guide.Examples,
+		Mistakes:    entry.Guide.Mistakes,
+		Related:     entry.Guide.Related,
+	}
+}
@@ -0,0 +1,117 @@
+package export_index
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/index"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/resultfilter"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "ExportIndex",
+		Description: "Export the workspace's package graph, package-level symbols and current gopls diagnostics to a JSON file, for offline tooling or for ImportIndex to answer symbol lookups and compare diagnostics before gopls has loaded a huge repository",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"output": map[string]interface{}{
+					"type":        "string",
+					"description": "File to write the index to, absolute or relative to the workspace root",
+				},
+				"includeTests": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Include _test.go files and their symbols",
+					"default":     false,
+				},
+				"includeDiagnostics": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Include the diagnostics gopls has published, waiting for it to finish loading first",
+					"default":     true,
+				},
+				"timeoutSeconds": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum time to wait for gopls to finish diagnosing; diagnostics published so far are exported after it",
+					"default":     60,
+				},
+				"includeVendor": resultfilter.IncludeVendorProperty(manager),
+			},
+			Required: []string{"output"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		output, err := request.RequireString("output")
+		if err != nil {
+			return nil, err
+		}
+		output = manager.ResolvePath(output)
+		root := manager.WorkspaceRoot()
+		filter := resultfilter.FromRequest(manager, request)
+
+		opts := astscan.Options{
+			IncludeTests:  request.GetBool("includeTests", false),
+			IncludeVendor: request.GetBool("includeVendor", manager.IncludeVendor()),
+		}
+		snapshot, err := index.Build(ctx, root, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		note := ""
+		if request.GetBool("includeDiagnostics", true) {
+			client, err := manager.GetClient()
+			if err != nil {
+				return nil, err
+			}
+			timeout := time.Duration(request.GetInt("timeoutSeconds", 60)) * time.Second
+			waitCtx, cancel := context.WithTimeout(ctx, timeout)
+			err = client.WaitForDiagnostics(waitCtx, time.Second)
+			cancel()
+			if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+				return nil, err
+			}
+			if err != nil {
+				note = "; gopls had not finished diagnosing, so the diagnostics may be incomplete"
+			}
+
+			byFile := make(map[string][]lsp.Diagnostic)
+			for uri, diagnostics := range client.AllDiagnostics().Diagnostics {
+				if path, err := manager.URIToPath(uri); err == nil && filter.Keep(path) {
+					byFile[path] = diagnostics
+				}
+			}
+			snapshot.SetDiagnostics(byFile)
+		}
+
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode index: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+			return nil, err
+		}
+		if err := manager.WriteFiles(map[string][]byte{output: data}); err != nil {
+			return nil, err
+		}
+
+		summary := fmt.Sprintf("Exported %d package(s) and %d symbol(s)", len(snapshot.Packages), len(snapshot.Symbols))
+		if snapshot.Diagnostics != nil {
+			summary += fmt.Sprintf(" with %d error(s) and %d warning(s) in %d file(s)",
+				snapshot.Diagnostics.Errors, snapshot.Diagnostics.Warnings, len(snapshot.Diagnostics.Files))
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%s to %s (%d bytes)%s", summary, output, len(data), note)), nil
+	}
+}
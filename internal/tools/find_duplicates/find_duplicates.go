@@ -0,0 +1,310 @@
+package find_duplicates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"hash/fnv"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "FindDuplicates",
+		Description: "Find structurally similar functions and blocks across the workspace by hashing their syntax trees, reporting clone groups with locations as candidates for deduplication",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory to scan, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"minLines": map[string]interface{}{
+					"type":        "number",
+					"description": "Minimum number of lines for a function or block to be compared",
+					"default":     6,
+				},
+				"matchIdentifiers": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Require identifiers and literal values to match too; by default code that differs only in names and constants is reported as a clone",
+					"default":     false,
+				},
+				"includeTests": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also scan _test.go files",
+					"default":     false,
+				},
+				"limit": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum number of clone groups to return, largest first",
+					"default":     50,
+				},
+			},
+		},
+	}
+}
+
+type instance struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	EndLine  int    `json:"endLine"`
+	Function string `json:"function,omitempty"`
+}
+
+type group struct {
+	Kind      string     `json:"kind"`
+	Lines     int        `json:"lines"`
+	Instances []instance `json:"instances"`
+}
+
+// candidate is a function or block considered for cloning
+type candidate struct {
+	hash   uint64
+	kind   string
+	lines  int
+	node   ast.Node
+	parent *candidate
+	inst   instance
+	cloned bool
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		root := manager.ResolvePath(request.GetString("path", ""))
+		minLines := request.GetInt("minLines", 6)
+		if minLines < 2 {
+			return nil, fmt.Errorf("minLines must be at least 2")
+		}
+		matchIdents := request.GetBool("matchIdentifiers", false)
+		limit := request.GetInt("limit", 50)
+
+		var candidates []*candidate
+		err := astscan.Walk(root, astscan.Options{IncludeTests: request.GetBool("includeTests", false)}, func(f *astscan.File) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if ast.IsGenerated(f.AST) {
+				return nil
+			}
+			candidates = append(candidates, collect(f, minLines, matchIdents)...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		byHash := make(map[uint64][]*candidate)
+		for _, c := range candidates {
+			byHash[c.hash] = append(byHash[c.hash], c)
+		}
+		for _, members := range byHash {
+			if len(members) > 1 {
+				for _, c := range members {
+					c.cloned = true
+				}
+			}
+		}
+
+		// Report only the outermost clones: a block inside a function that is
+		// itself duplicated adds nothing
+		var groups []group
+		for _, members := range byHash {
+			if len(members) < 2 {
+				continue
+			}
+			var instances []instance
+			for _, c := range members {
+				if !insideClone(c) {
+					instances = append(instances, c.inst)
+				}
+			}
+			if len(instances) < 2 {
+				continue
+			}
+			sort.Slice(instances, func(i, j int) bool {
+				if instances[i].File != instances[j].File {
+					return instances[i].File < instances[j].File
+				}
+				return instances[i].Line < instances[j].Line
+			})
+			groups = append(groups, group{Kind: members[0].kind, Lines: members[0].lines, Instances: instances})
+		}
+
+		if len(groups) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No duplicated functions or blocks of %d or more lines found in %s", minLines, root)), nil
+		}
+
+		sort.Slice(groups, func(i, j int) bool {
+			wi, wj := groups[i].Lines*(len(groups[i].Instances)-1), groups[j].Lines*(len(groups[j].Instances)-1)
+			if wi != wj {
+				return wi > wj
+			}
+			a, b := groups[i].Instances[0], groups[j].Instances[0]
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			return a.Line < b.Line
+		})
+
+		header := fmt.Sprintf("Found %d clone group(s)", len(groups))
+		if limit > 0 && len(groups) > limit {
+			header += fmt.Sprintf(", showing the largest %d", limit)
+			groups = groups[:limit]
+		}
+		result, _ := json.MarshalIndent(groups, "", "  ")
+		return mcp.NewToolResultText(header + ":\n" + string(result)), nil
+	}
+}
+
+func insideClone(c *candidate) bool {
+	for p := c.parent; p != nil; p = p.parent {
+		if p.cloned {
+			return true
+		}
+	}
+	return false
+}
+
+// collect fingerprints every function body and nested block in f that spans
+// at least minLines lines
+func collect(f *astscan.File, minLines int, matchIdents bool) []*candidate {
+	var result []*candidate
+	var stack []*candidate
+	var nodes []ast.Node
+
+	ast.Inspect(f.AST, func(n ast.Node) bool {
+		if n == nil {
+			if last := nodes[len(nodes)-1]; len(stack) > 0 && stack[len(stack)-1].node == last {
+				stack = stack[:len(stack)-1]
+			}
+			nodes = nodes[:len(nodes)-1]
+			return true
+		}
+		nodes = append(nodes, n)
+
+		var body *ast.BlockStmt
+		kind := "block"
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			body, kind = n.Body, "function"
+		case *ast.FuncLit:
+			body, kind = n.Body, "function"
+		case *ast.BlockStmt:
+			// Function bodies are fingerprinted with their function
+			if len(nodes) > 1 {
+				switch nodes[len(nodes)-2].(type) {
+				case *ast.FuncDecl, *ast.FuncLit:
+					return true
+				}
+			}
+			body = n
+		default:
+			return true
+		}
+		if body == nil || len(body.List) == 0 {
+			return true
+		}
+
+		line, _ := f.Position(n.Pos())
+		endLine, _ := f.Position(n.End())
+		if endLine-line+1 < minLines {
+			return true
+		}
+
+		// Compare functions by signature and body but not by name
+		h := newHasher(matchIdents)
+		if fn, ok := n.(*ast.FuncDecl); ok {
+			if fn.Recv != nil {
+				h.walk(fn.Recv)
+			}
+			h.walk(fn.Type)
+			h.walk(fn.Body)
+		} else {
+			h.walk(n)
+		}
+
+		c := &candidate{
+			hash:  h.sum(),
+			kind:  kind,
+			lines: endLine - line + 1,
+			inst: instance{
+				File:     filepath.Clean(f.Path),
+				Line:     line,
+				EndLine:  endLine,
+				Function: f.EnclosingFunc(n.Pos()),
+			},
+			node: n,
+		}
+		if len(stack) > 0 {
+			c.parent = stack[len(stack)-1]
+		}
+		stack = append(stack, c)
+		result = append(result, c)
+		return true
+	})
+	return result
+}
+
+// hasher fingerprints syntax trees by node kind and operator, optionally
+// including identifier names and literal values
+type hasher struct {
+	b           strings.Builder
+	matchIdents bool
+}
+
+func newHasher(matchIdents bool) *hasher {
+	return &hasher{matchIdents: matchIdents}
+}
+
+func (h *hasher) walk(root ast.Node) {
+	ast.Inspect(root, func(n ast.Node) bool {
+		if n == nil {
+			// Close each node so different nestings do not hash alike
+			h.b.WriteByte(')')
+			return true
+		}
+		fmt.Fprintf(&h.b, "(%T", n)
+		switch n := n.(type) {
+		case *ast.Ident:
+			if h.matchIdents {
+				h.b.WriteString(" " + n.Name)
+			}
+		case *ast.BasicLit:
+			h.b.WriteString(" " + n.Kind.String())
+			if h.matchIdents {
+				h.b.WriteString(" " + n.Value)
+			}
+		case *ast.BinaryExpr:
+			h.b.WriteString(" " + n.Op.String())
+		case *ast.UnaryExpr:
+			h.b.WriteString(" " + n.Op.String())
+		case *ast.AssignStmt:
+			h.b.WriteString(" " + n.Tok.String())
+		case *ast.IncDecStmt:
+			h.b.WriteString(" " + n.Tok.String())
+		case *ast.BranchStmt:
+			h.b.WriteString(" " + n.Tok.String())
+		case *ast.RangeStmt:
+			h.b.WriteString(" " + n.Tok.String())
+		case *ast.GenDecl:
+			h.b.WriteString(" " + n.Tok.String())
+		case *ast.ChanType:
+			fmt.Fprintf(&h.b, " %d", n.Dir)
+		}
+		return true
+	})
+}
+
+func (h *hasher) sum() uint64 {
+	sum := fnv.New64a()
+	sum.Write([]byte(h.b.String()))
+	return sum.Sum64()
+}
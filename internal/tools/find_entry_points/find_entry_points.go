@@ -0,0 +1,183 @@
+package find_entry_points
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/buildtags"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/resultfilter"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "FindEntryPoints",
+		Description: "List the runnable entry points of the workspace: every main package with the location of its main function, the binary it builds and the go run command for it, plus the TestMain functions that wrap a package's tests. Useful in repositories that build several binaries.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory tree to scan, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"includeTestMain": map[string]interface{}{
+					"type":        "boolean",
+					"description": "List TestMain functions too",
+					"default":     true,
+				},
+				"includeVendor": resultfilter.IncludeVendorProperty(manager),
+			},
+		},
+	}
+}
+
+// entryPoint is a main function, or a TestMain function
+type entryPoint struct {
+	ImportPath string `json:"importPath,omitempty"`
+	Dir        string `json:"dir"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Column     int    `json:"column"`
+	// Binary is the name go build gives the executable of a main package
+	Binary string `json:"binary,omitempty"`
+	// BuildConstraint is the //go:build line of the file, if any
+	BuildConstraint string `json:"buildConstraint,omitempty"`
+	// Command runs the entry point from the workspace root
+	Command string `json:"command"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		root := manager.ResolvePath(request.GetString("path", ""))
+		includeTestMain := request.GetBool("includeTestMain", true)
+		opts := astscan.Options{
+			IncludeTests:  includeTestMain,
+			IncludeVendor: request.GetBool("includeVendor", manager.IncludeVendor()),
+		}
+
+		workspace := manager.WorkspaceRoot()
+		mains := make([]*entryPoint, 0)
+		testMains := make([]*entryPoint, 0)
+		err := astscan.Walk(root, opts, func(f *astscan.File) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			test := strings.HasSuffix(f.Path, "_test.go")
+			if !test && f.AST.Name.Name != "main" {
+				return nil
+			}
+			for _, decl := range f.AST.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv != nil || fn.Body == nil || fn.Type.TypeParams != nil {
+					continue
+				}
+				switch {
+				case !test && fn.Name.Name == "main":
+					mains = append(mains, newEntryPoint(f, fn))
+				case test && fn.Name.Name == "TestMain":
+					testMains = append(testMains, newEntryPoint(f, fn))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range mains {
+			e.Binary = path.Base(e.ImportPath)
+			if e.ImportPath == "" {
+				e.Binary = filepath.Base(e.Dir)
+			}
+			e.Command = "go run " + runTarget(workspace, e)
+		}
+		for _, e := range testMains {
+			e.Command = "go test " + packageTarget(workspace, e.Dir)
+		}
+		sortEntryPoints(mains)
+		sortEntryPoints(testMains)
+
+		if len(mains) == 0 && len(testMains) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No main packages found in %s", root)), nil
+		}
+
+		result := map[string]interface{}{"mainPackages": mains}
+		summary := fmt.Sprintf("Found %d main function(s)", len(mains))
+		if includeTestMain {
+			result["testMains"] = testMains
+			summary += fmt.Sprintf(" and %d TestMain function(s)", len(testMains))
+		}
+		output, _ := json.MarshalIndent(result, "", "  ")
+		return mcp.NewToolResultText(summary + ":\n" + string(output)), nil
+	}
+}
+
+// newEntryPoint returns the entry point declared by fn in f
+func newEntryPoint(f *astscan.File, fn *ast.FuncDecl) *entryPoint {
+	dir := filepath.Dir(f.Path)
+	e := &entryPoint{Dir: dir, File: filepath.Clean(f.Path)}
+	e.ImportPath, _ = codegen.ImportPath(dir)
+	e.Line, e.Column = f.Position(fn.Name.Pos())
+	if expr := buildtags.FileConstraint(f.AST); expr != nil {
+		e.BuildConstraint = expr.String()
+	}
+	return e
+}
+
+// runTarget returns what to pass to go run for a main function: its
+// package, or its file for scripts excluded from the build with the ignore
+// tag, which go run builds when they are named
+func runTarget(workspace string, e *entryPoint) string {
+	if e.BuildConstraint != "" && usesTag(e.BuildConstraint, "ignore") {
+		if rel, err := filepath.Rel(workspace, e.File); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+		return e.File
+	}
+	return packageTarget(workspace, e.Dir)
+}
+
+// packageTarget returns the package in dir as a go command argument,
+// relative to the workspace root when it is inside it
+func packageTarget(workspace, dir string) string {
+	rel, err := filepath.Rel(workspace, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return dir
+	}
+	if rel == "." {
+		return "."
+	}
+	return "./" + filepath.ToSlash(rel)
+}
+
+// usesTag reports whether a build constraint mentions tag
+func usesTag(constraint, tag string) bool {
+	for _, field := range strings.FieldsFunc(constraint, func(r rune) bool {
+		return strings.ContainsRune(" !&|()", r)
+	}) {
+		if field == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// sortEntryPoints sorts entry points by file and line
+func sortEntryPoints(entries []*entryPoint) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].File != entries[j].File {
+			return entries[i].File < entries[j].File
+		}
+		return entries[i].Line < entries[j].Line
+	})
+}
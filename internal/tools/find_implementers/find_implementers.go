@@ -4,11 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/resultfilter"
 	"github.com/yantrio/mcp-gopls/internal/utils"
 )
 
@@ -31,6 +31,7 @@ func NewTool(manager *gopls.Manager) mcp.Tool {
 					"type":        "number",
 					"description": "Column number (1-indexed)",
 				},
+				"includeVendor": resultfilter.IncludeVendorProperty(manager),
 			},
 			Required: []string{"file", "line", "column"},
 		},
@@ -62,7 +63,7 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 			return nil, err
 		}
 
-		content, err := os.ReadFile(file)
+		content, err := manager.ReadFile(file)
 		if err != nil {
 			return nil, err
 		}
@@ -83,45 +84,32 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 		}
 
 		// Convert locations to human-readable format
+		filter := resultfilter.FromRequest(manager, request)
 		results := make([]map[string]interface{}, 0)
 		for _, loc := range locations {
-			locPath, err := utils.URIToPath(loc.URI)
-			if err != nil {
+			locPath, err := manager.URIToPath(loc.URI)
+			if err != nil || !filter.Keep(locPath) {
 				continue
 			}
 
 			startLine, startColumn := utils.ConvertToUserPosition(loc.Range.Start)
 			
-			// Read the line to get context
-			fileContent, err := os.ReadFile(locPath)
-			if err != nil {
-				continue
-			}
-			
-			lines := string(fileContent)
-			lineText := ""
-			currentLine := 1
-			lineStart := 0
-			for i, ch := range lines {
-				if ch == '\n' {
-					if currentLine == startLine {
-						lineText = lines[lineStart:i]
-						break
-					}
-					currentLine++
-					lineStart = i + 1
-				}
-			}
-			if currentLine == startLine && lineText == "" {
-				lineText = lines[lineStart:]
-			}
+			// Read the line to get context; a line too long to preview is
+			// left empty
+			lineText, _ := utils.ReadLine(locPath, startLine)
 
-			results = append(results, map[string]interface{}{
+			implementation := map[string]interface{}{
 				"file":    locPath,
 				"line":    startLine,
 				"column":  startColumn,
 				"preview": lineText,
-			})
+			}
+			// Servers answering with links also give the whole declaration
+			if loc.DeclarationRange != nil {
+				implementation["selectionRange"] = utils.ConvertToUserRange(loc.Range)
+				implementation["declarationRange"] = utils.ConvertToUserRange(*loc.DeclarationRange)
+			}
+			results = append(results, implementation)
 		}
 
 		// Format as JSON
@@ -0,0 +1,275 @@
+package find_import_cycles
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/index"
+	"github.com/yantrio/mcp-gopls/internal/resultfilter"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "FindImportCycles",
+		Description: "Find import cycles between workspace packages, or check before writing code whether package from importing package to would create one. With only from, lists the packages from cannot import; with only to, the packages that cannot import to.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"from": map[string]interface{}{
+					"type":        "string",
+					"description": "Package that would add the import: an import path, or a directory absolute or relative to the workspace root",
+				},
+				"to": map[string]interface{}{
+					"type":        "string",
+					"description": "Package that would be imported, in the same forms as from",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory whose packages make up the import graph, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"includeVendor": resultfilter.IncludeVendorProperty(manager),
+			},
+		},
+	}
+}
+
+// graph is the import graph of the scanned packages, keyed by import path.
+// Imports of packages outside the scan are left out, as they cannot lead
+// back into it.
+type graph struct {
+	imports map[string][]string
+	dirs    map[string]string
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		root := manager.ResolvePath(request.GetString("path", ""))
+		snapshot, err := index.Build(ctx, root, astscan.Options{IncludeVendor: request.GetBool("includeVendor", manager.IncludeVendor())})
+		if err != nil {
+			return nil, err
+		}
+		g := newGraph(snapshot)
+
+		var from, to string
+		if arg := request.GetString("from", ""); arg != "" {
+			if from, err = g.lookup(manager, root, arg); err != nil {
+				return nil, err
+			}
+		}
+		if arg := request.GetString("to", ""); arg != "" {
+			if to, err = g.lookup(manager, root, arg); err != nil {
+				return nil, err
+			}
+		}
+
+		switch {
+		case from != "" && to != "":
+			return mcp.NewToolResultText(g.whatIf(from, to)), nil
+		case from != "":
+			blocked := g.dependents(from)
+			if len(blocked) == 0 {
+				return mcp.NewToolResultText(fmt.Sprintf("No workspace package depends on %s, so it can import any of them", from)), nil
+			}
+			result, _ := json.MarshalIndent(blocked, "", "  ")
+			return mcp.NewToolResultText(fmt.Sprintf("%s cannot import %d package(s) that depend on it:\n%s", from, len(blocked), result)), nil
+		case to != "":
+			blocked := g.dependencies(to)
+			if len(blocked) == 0 {
+				return mcp.NewToolResultText(fmt.Sprintf("%s imports no workspace packages, so any of them can import it", to)), nil
+			}
+			result, _ := json.MarshalIndent(blocked, "", "  ")
+			return mcp.NewToolResultText(fmt.Sprintf("%d package(s) that %s depends on cannot import it:\n%s", len(blocked), to, result)), nil
+		}
+
+		cycles := g.cycles()
+		if len(cycles) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No import cycles among the %d package(s) in %s", len(g.imports), root)), nil
+		}
+		result, _ := json.MarshalIndent(cycles, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d import cycle(s):\n%s", len(cycles), result)), nil
+	}
+}
+
+func newGraph(snapshot *index.Snapshot) *graph {
+	g := &graph{imports: make(map[string][]string), dirs: make(map[string]string)}
+	for _, pkg := range snapshot.Packages {
+		if pkg.ImportPath != "" {
+			g.imports[pkg.ImportPath] = nil
+			g.dirs[pkg.ImportPath] = pkg.Dir
+		}
+	}
+	for _, pkg := range snapshot.Packages {
+		if pkg.ImportPath == "" {
+			continue
+		}
+		for _, imp := range pkg.Imports {
+			if _, ok := g.imports[imp]; ok {
+				g.imports[pkg.ImportPath] = append(g.imports[pkg.ImportPath], imp)
+			}
+		}
+	}
+	return g
+}
+
+// lookup finds a package by import path or directory
+func (g *graph) lookup(manager *gopls.Manager, root, arg string) (string, error) {
+	if _, ok := g.imports[arg]; ok {
+		return arg, nil
+	}
+	dir := manager.ResolvePath(arg)
+	for path, rel := range g.dirs {
+		if filepath.Join(root, filepath.FromSlash(rel)) == dir {
+			return path, nil
+		}
+	}
+	if strings.Contains(arg, ".") && !strings.HasPrefix(arg, ".") && !filepath.IsAbs(arg) {
+		// An import path outside the workspace, which has no edges into it
+		return arg, nil
+	}
+	return "", fmt.Errorf("no package %s in %s", arg, root)
+}
+
+// path returns the shortest import chain from one package to another, or
+// nil when there is none
+func (g *graph) path(from, to string) []string {
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		if pkg == to {
+			chain := []string{to}
+			for p := prev[to]; p != ""; p = prev[p] {
+				chain = append([]string{p}, chain...)
+			}
+			return chain
+		}
+		for _, imp := range g.imports[pkg] {
+			if _, seen := prev[imp]; !seen {
+				prev[imp] = pkg
+				queue = append(queue, imp)
+			}
+		}
+	}
+	return nil
+}
+
+// whatIf reports whether from importing to would close a cycle
+func (g *graph) whatIf(from, to string) string {
+	if from == to {
+		return fmt.Sprintf("%s cannot import itself", from)
+	}
+	for _, imp := range g.imports[from] {
+		if imp == to {
+			return fmt.Sprintf("%s already imports %s", from, to)
+		}
+	}
+	chain := g.path(to, from)
+	if chain == nil {
+		return fmt.Sprintf("%s can import %s without creating an import cycle", from, to)
+	}
+	return fmt.Sprintf("Importing %s from %s would create an import cycle, because %s already depends on %s:\n%s",
+		to, from, to, from, strings.Join(append(chain, to), " -> "))
+}
+
+// dependents returns the packages that import pkg directly or indirectly
+func (g *graph) dependents(pkg string) []string {
+	var result []string
+	for other := range g.imports {
+		if other != pkg && g.path(other, pkg) != nil {
+			result = append(result, other)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// dependencies returns the packages pkg imports directly or indirectly
+func (g *graph) dependencies(pkg string) []string {
+	var result []string
+	for other := range g.imports {
+		if other != pkg && g.path(pkg, other) != nil {
+			result = append(result, other)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// cycles returns one cycle through each strongly connected component of the
+// graph, found with Tarjan's algorithm
+func (g *graph) cycles() [][]string {
+	nodes := make([]string, 0, len(g.imports))
+	for pkg := range g.imports {
+		nodes = append(nodes, pkg)
+	}
+	sort.Strings(nodes)
+
+	order := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var visit func(pkg string)
+	visit = func(pkg string) {
+		order[pkg] = len(order)
+		low[pkg] = order[pkg]
+		stack = append(stack, pkg)
+		onStack[pkg] = true
+		for _, imp := range g.imports[pkg] {
+			if _, seen := order[imp]; !seen {
+				visit(imp)
+				low[pkg] = min(low[pkg], low[imp])
+			} else if onStack[imp] {
+				low[pkg] = min(low[pkg], order[imp])
+			}
+		}
+		if low[pkg] != order[pkg] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == pkg {
+				break
+			}
+		}
+		if len(component) > 1 {
+			components = append(components, component)
+		}
+	}
+	for _, pkg := range nodes {
+		if _, seen := order[pkg]; !seen {
+			visit(pkg)
+		}
+	}
+
+	cycles := make([][]string, 0, len(components))
+	for _, component := range components {
+		sort.Strings(component)
+		start := component[0]
+		// The shortest way back to start through one of its imports in the
+		// component
+		var best []string
+		for _, imp := range g.imports[start] {
+			if chain := g.path(imp, start); chain != nil && (best == nil || len(chain) < len(best)) {
+				best = chain
+			}
+		}
+		cycles = append(cycles, append([]string{start}, best...))
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
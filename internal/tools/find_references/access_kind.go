@@ -0,0 +1,267 @@
+package find_references
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+)
+
+const (
+	accessRead        = "read"
+	accessWrite       = "write"
+	accessDeclaration = "declaration"
+)
+
+// accessClassifier determines how identifiers are used by parsing each
+// referenced file once and inspecting the syntax around the reference. The
+// few cases syntax cannot settle, keys of composite literals and names on
+// the left of a multi-assign :=, are decided by type-checking the file's
+// package, which is done at most once per directory.
+type accessClassifier struct {
+	fset     *token.FileSet
+	files    map[string]*ast.File
+	packages map[string]*typecheck.Package
+}
+
+func newAccessClassifier() *accessClassifier {
+	return &accessClassifier{
+		fset:     token.NewFileSet(),
+		files:    make(map[string]*ast.File),
+		packages: make(map[string]*typecheck.Package),
+	}
+}
+
+// classify returns the access kind of the identifier starting at offset in
+// the file at path, defaulting to read when the file cannot be parsed
+func (c *accessClassifier) classify(ctx context.Context, path string, content []byte, offset int) string {
+	file, ok := c.files[path]
+	if !ok {
+		file, _ = parser.ParseFile(c.fset, path, content, parser.SkipObjectResolution)
+		c.files[path] = file
+	}
+	if file == nil {
+		return accessRead
+	}
+
+	// Collect the chain of nodes enclosing the identifier
+	var stack, found []ast.Node
+	ast.Inspect(file, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+		if ident, ok := n.(*ast.Ident); ok && c.fset.Position(ident.Pos()).Offset == offset {
+			found = append([]ast.Node(nil), stack...)
+			return false
+		}
+		return true
+	})
+	if len(found) < 2 {
+		return accessRead
+	}
+
+	typed := func() *typedFile { return c.typed(ctx, path) }
+	return accessKindOf(found, typed)
+}
+
+// typed returns the type-checked syntax of the file at path, or nil when
+// its package does not type-check or does not include the file, as with
+// test files
+func (c *accessClassifier) typed(ctx context.Context, path string) *typedFile {
+	dir := filepath.Dir(path)
+	pkg, ok := c.packages[dir]
+	if !ok {
+		if pkgs, err := typecheck.Load(ctx, dir, "."); err == nil && len(pkgs) == 1 {
+			pkg = pkgs[0]
+		}
+		c.packages[dir] = pkg
+	}
+	if pkg == nil {
+		return nil
+	}
+	file := pkg.File(path)
+	if file == nil {
+		return nil
+	}
+	return &typedFile{pkg: pkg, file: file, fset: c.fset}
+}
+
+// typedFile is a file of a type-checked package. Its syntax tree is
+// separate from the classifier's, whose file set is fset.
+type typedFile struct {
+	pkg  *typecheck.Package
+	file *ast.File
+	fset *token.FileSet
+}
+
+// at returns the node of type T in the type-checked syntax starting at the
+// same offset as n in the classifier's
+func at[T ast.Node](t *typedFile, n T) (T, bool) {
+	offset := t.fset.Position(n.Pos()).Offset
+	var found T
+	ok := false
+	ast.Inspect(t.file, func(node ast.Node) bool {
+		if ok || node == nil {
+			return false
+		}
+		if candidate, match := node.(T); match && t.pkg.Fset.Position(node.Pos()).Offset == offset {
+			found, ok = candidate, true
+			return false
+		}
+		return true
+	})
+	return found, ok
+}
+
+// accessKindOf classifies the identifier at the end of path based on its
+// parents, calling typed for the type information syntax cannot provide
+func accessKindOf(path []ast.Node, typed func() *typedFile) string {
+	node := path[len(path)-1]
+	i := len(path) - 2
+
+	// Climb through expressions that still denote the same storage location
+	for ; i >= 0; i-- {
+		switch parent := path[i].(type) {
+		case *ast.SelectorExpr:
+			if parent.Sel != node {
+				return accessRead
+			}
+		case *ast.ParenExpr, *ast.StarExpr:
+		case *ast.IndexExpr:
+			if parent.X != node {
+				return accessRead
+			}
+		default:
+			var grandparent ast.Node
+			if i > 0 {
+				grandparent = path[i-1]
+			}
+			return accessKindInParent(path[i], grandparent, node, typed)
+		}
+		node = path[i]
+	}
+	return accessRead
+}
+
+// accessKindInParent classifies node given its immediate non-expression parent
+// and the parent's own parent
+func accessKindInParent(parent, grandparent, node ast.Node, typed func() *typedFile) string {
+	switch p := parent.(type) {
+	case *ast.AssignStmt:
+		for _, lhs := range p.Lhs {
+			if lhs != node {
+				continue
+			}
+			if p.Tok != token.DEFINE {
+				return accessWrite
+			}
+			// A multi-assign := may assign variables that already exist
+			if ident, ok := node.(*ast.Ident); ok && len(p.Lhs) > 1 {
+				return defineKind(ident, typed)
+			}
+			return accessDeclaration
+		}
+	case *ast.IncDecStmt:
+		if p.X == node {
+			return accessWrite
+		}
+	case *ast.RangeStmt:
+		if p.Key == node || p.Value == node {
+			if p.Tok == token.DEFINE {
+				return accessDeclaration
+			}
+			return accessWrite
+		}
+	case *ast.KeyValueExpr:
+		// Keys in struct literals initialise the field; keys of maps, slices
+		// and arrays are read
+		if lit, ok := grandparent.(*ast.CompositeLit); ok && p.Key == node && isStructLiteral(lit, typed) {
+			return accessWrite
+		}
+	case *ast.ValueSpec, *ast.Field, *ast.TypeSpec, *ast.ImportSpec, *ast.LabeledStmt:
+		return declarationIfName(p, node)
+	case *ast.FuncDecl:
+		if p.Name == node {
+			return accessDeclaration
+		}
+	}
+	return accessRead
+}
+
+// defineKind classifies a name on the left of := as a declaration when the
+// statement declares it and as a write when it assigns an existing variable.
+// Without type information the name is taken to be declared.
+func defineKind(ident *ast.Ident, typed func() *typedFile) string {
+	t := typed()
+	if t == nil {
+		return accessDeclaration
+	}
+	typedIdent, ok := at(t, ident)
+	if !ok || t.pkg.Info.Defs[typedIdent] != nil {
+		return accessDeclaration
+	}
+	return accessWrite
+}
+
+// isStructLiteral reports whether lit has struct type. Literals whose type
+// syntax does not tell are looked up in the type information, and taken to
+// be structs without it, since keyed literals of named types mostly are.
+func isStructLiteral(lit *ast.CompositeLit, typed func() *typedFile) bool {
+	switch lit.Type.(type) {
+	case *ast.MapType, *ast.ArrayType:
+		return false
+	case *ast.StructType:
+		return true
+	}
+	t := typed()
+	if t == nil {
+		return true
+	}
+	typedLit, ok := at(t, lit)
+	if !ok {
+		return true
+	}
+	tv, ok := t.pkg.Info.Types[typedLit]
+	if !ok || tv.Type == nil {
+		return true
+	}
+	typ := tv.Type.Underlying()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		// &T{...} elided in a slice of pointers
+		typ = ptr.Elem().Underlying()
+	}
+	_, ok = typ.(*types.Struct)
+	return ok
+}
+
+// declarationIfName reports a declaration when node is one of the names declared by spec
+func declarationIfName(spec, node ast.Node) string {
+	var names []*ast.Ident
+	switch s := spec.(type) {
+	case *ast.ValueSpec:
+		names = s.Names
+	case *ast.Field:
+		names = s.Names
+	case *ast.TypeSpec:
+		names = []*ast.Ident{s.Name}
+	case *ast.ImportSpec:
+		names = []*ast.Ident{s.Name}
+	case *ast.LabeledStmt:
+		names = []*ast.Ident{s.Label}
+	}
+	for _, name := range names {
+		if name == node {
+			return accessDeclaration
+		}
+	}
+	return accessRead
+}
@@ -0,0 +1,66 @@
+package find_references
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const accessSource = `package p
+
+type T struct{ Name string }
+
+type Names map[string]int
+
+var Key = "k"
+
+func f() (int, error) { return 0, nil }
+
+func g() {
+	m := map[string]int{Key: 1}
+	n := Names{Key: 2}
+	t := T{Name: Key}
+	x, err := f()
+	y, err := f()
+	_, _, _, _, _ = m, n, t, x, y
+}
+`
+
+func TestClassify(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "p.go")
+	files := map[string]string{"go.mod": "module example.com/p\n\ngo 1.22\n", "p.go": accessSource}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		// at is the text starting with the identifier
+		at   string
+		want string
+	}{
+		{"map literal key", "Key: 1", accessRead},
+		{"named map literal key", "Key: 2", accessRead},
+		{"struct literal key", "Name: Key", accessWrite},
+		{"new variable in multi-assign", "x, err", accessDeclaration},
+		{"first err", "err := f()\n\ty", accessDeclaration},
+		{"reassigned err", "err := f()\n\t_", accessWrite},
+		{"single define", "m := map", accessDeclaration},
+	}
+	c := newAccessClassifier()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset := strings.Index(accessSource, tt.at)
+			if offset < 0 {
+				t.Fatalf("%q not in source", tt.at)
+			}
+			if got := c.classify(t.Context(), path, []byte(accessSource), offset); got != tt.want {
+				t.Errorf("classify(%q) = %s, want %s", tt.at, got, tt.want)
+			}
+		})
+	}
+}
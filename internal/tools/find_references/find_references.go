@@ -5,11 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/positions"
+	"github.com/yantrio/mcp-gopls/internal/resultfilter"
 	"github.com/yantrio/mcp-gopls/internal/utils"
 )
 
@@ -22,7 +28,7 @@ func NewTool(manager *gopls.Manager) mcp.Tool {
 			Properties: map[string]interface{}{
 				"file": map[string]interface{}{
 					"type":        "string",
-					"description": "Absolute path to the Go source file",
+					"description": "Absolute path to the Go source file (required unless positions is given)",
 				},
 				"line": map[string]interface{}{
 					"type":        "number",
@@ -37,75 +43,207 @@ func NewTool(manager *gopls.Manager) mcp.Tool {
 					"description": "Include the declaration in results",
 					"default":     false,
 				},
+				"accessKind": map[string]interface{}{
+					"type":        "string",
+					"description": "Only return references with this access kind: 'read', 'write' or 'declaration'. Filtering on declarations includes them regardless of includeDeclaration.",
+					"enum":        []string{accessRead, accessWrite, accessDeclaration},
+				},
+				"aggregate": map[string]interface{}{
+					"type":        "string",
+					"description": "'none' for the flat list, 'summary' for counts grouped by package and file, or 'both' for the summary followed by the list",
+					"enum":        []string{"none", "summary", "both"},
+					"default":     "none",
+				},
+				"includeVendor": resultfilter.IncludeVendorProperty(manager),
+				"positions":     positions.Property(),
 			},
-			Required: []string{"file", "line", "column"},
 		},
 	}
 }
 
 func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		file, err := request.RequireString("file")
+		queries, multi, err := positions.FromRequest(request)
 		if err != nil {
 			return nil, err
 		}
-		line, err := request.RequireInt("line")
-		if err != nil {
-			return nil, err
+		includeDeclaration := request.GetBool("includeDeclaration", false)
+		accessFilter := request.GetString("accessKind", "")
+		switch accessFilter {
+		case "", accessRead, accessWrite:
+		case accessDeclaration:
+			// gopls leaves declarations out unless asked for them
+			includeDeclaration = true
+		default:
+			return nil, fmt.Errorf("invalid accessKind %q: must be 'read', 'write' or 'declaration'", accessFilter)
 		}
-		column, err := request.RequireInt("column")
-		if err != nil {
-			return nil, err
+		aggregate := request.GetString("aggregate", "none")
+		if aggregate != "none" && aggregate != "summary" && aggregate != "both" {
+			return nil, fmt.Errorf("invalid aggregate %q: must be 'none', 'summary' or 'both'", aggregate)
 		}
-		includeDeclaration := request.GetBool("includeDeclaration", false)
 
 		client, err := manager.GetClient()
 		if err != nil {
 			return nil, err
 		}
-		uri, err := utils.PathToURI(file)
+		uris, closeAll, err := positions.Open(ctx, manager, client, queries)
 		if err != nil {
 			return nil, err
 		}
-		content, err := os.ReadFile(file)
-		if err != nil {
-			return nil, err
+		defer closeAll()
+
+		finder := &referenceFinder{
+			manager:            manager,
+			client:             client,
+			filter:             resultfilter.FromRequest(manager, request),
+			classifier:         newAccessClassifier(),
+			includeDeclaration: includeDeclaration,
+			accessFilter:       accessFilter,
 		}
 
-		err = client.OpenDocument(ctx, uri, string(content))
-		if err != nil {
-			return nil, err
+		if !multi {
+			references, err := finder.find(ctx, uris[queries[0].File], queries[0])
+			if err != nil {
+				return nil, err
+			}
+			if aggregate != "none" {
+				summary := summarize(referencePaths(references), manager.WorkspaceRoot())
+				if aggregate == "summary" {
+					return mcp.NewToolResultText(summary), nil
+				}
+				result, _ := json.MarshalIndent(references, "", "  ")
+				return mcp.NewToolResultText(fmt.Sprintf("%s\n\nReferences:\n%s", summary, string(result))), nil
+			}
+
+			result, _ := json.MarshalIndent(references, "", "  ")
+			return mcp.NewToolResultText(fmt.Sprintf("Found %d reference(s):\n%s", len(references), string(result))), nil
 		}
-		defer client.CloseDocument(ctx, uri)
 
-		position := utils.ConvertPosition(line, column)
-		locations, err := client.References(ctx, uri, position, includeDeclaration)
-		if err != nil {
-			return nil, err
+		results := make([]positions.Result, len(queries))
+		for i, query := range queries {
+			references, err := finder.find(ctx, uris[query.File], query)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+			switch aggregate {
+			case "summary":
+				results[i].Result = summarize(referencePaths(references), manager.WorkspaceRoot())
+			case "both":
+				results[i].Result = map[string]interface{}{
+					"summary":    summarize(referencePaths(references), manager.WorkspaceRoot()),
+					"references": references,
+				}
+			default:
+				results[i].Result = references
+			}
 		}
+		return positions.Keyed(queries, results), nil
+	}
+}
 
-		references := make([]map[string]interface{}, 0)
-		for _, loc := range locations {
-			refPath, _ := utils.URIToPath(loc.URI)
-			refLine, refColumn := utils.ConvertToUserPosition(loc.Range.Start)
+// referenceFinder looks up references with the settings of one call. The
+// access classifier caches parsed files across the positions of the call.
+type referenceFinder struct {
+	manager            *gopls.Manager
+	client             *lsp.Client
+	filter             resultfilter.Filter
+	classifier         *accessClassifier
+	includeDeclaration bool
+	accessFilter       string
+}
 
-			preview := ""
-			if refContent, err := os.ReadFile(refPath); err == nil {
-				lines := strings.Split(string(refContent), "\n")
-				if refLine <= len(lines) {
-					preview = strings.TrimSpace(lines[refLine-1])
-				}
+// find returns the references to the symbol at a position, with a preview
+// and the access kind of each
+func (f *referenceFinder) find(ctx context.Context, uri string, query positions.Position) ([]map[string]interface{}, error) {
+	locations, err := f.client.References(ctx, uri, query.LSP(), f.includeDeclaration)
+	if err != nil {
+		return nil, err
+	}
+
+	references := make([]map[string]interface{}, 0)
+	for _, loc := range locations {
+		if !f.filter.KeepURI(loc.URI) {
+			continue
+		}
+		refPath, _ := f.manager.URIToPath(loc.URI)
+		refLine, refColumn := utils.ConvertToUserPosition(loc.Range.Start)
+
+		preview := ""
+		access := accessRead
+		if refContent, err := os.ReadFile(refPath); err == nil && utf8.Valid(refContent) {
+			lines := strings.Split(string(refContent), "\n")
+			if refLine <= len(lines) {
+				preview = strings.TrimSpace(lines[refLine-1])
 			}
+			if offset, err := utils.CalculateOffset(string(refContent), loc.Range.Start); err == nil {
+				access = f.classifier.classify(ctx, refPath, refContent, offset)
+			}
+		}
 
-			references = append(references, map[string]interface{}{
-				"file":    refPath,
-				"line":    refLine,
-				"column":  refColumn,
-				"preview": preview,
-			})
+		if f.accessFilter != "" && access != f.accessFilter {
+			continue
 		}
 
-		result, _ := json.MarshalIndent(references, "", "  ")
-		return mcp.NewToolResultText(fmt.Sprintf("Found %d reference(s):\n%s", len(references), string(result))), nil
+		references = append(references, map[string]interface{}{
+			"file":       refPath,
+			"line":       refLine,
+			"column":     refColumn,
+			"preview":    preview,
+			"accessKind": access,
+		})
+	}
+	return references, nil
+}
+
+// referencePaths returns the file of each reference
+func referencePaths(references []map[string]interface{}) []string {
+	paths := make([]string, 0, len(references))
+	for _, ref := range references {
+		paths = append(paths, ref["file"].(string))
+	}
+	return paths
+}
+
+// summarize renders reference counts as a package -> file tree, with package
+// directories shown relative to the workspace root where possible
+func summarize(paths []string, workspaceRoot string) string {
+	counts := make(map[string]map[string]int)
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		if rel, err := filepath.Rel(workspaceRoot, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			dir = rel
+		}
+		if counts[dir] == nil {
+			counts[dir] = make(map[string]int)
+		}
+		counts[dir][filepath.Base(path)]++
+	}
+
+	packages := make([]string, 0, len(counts))
+	for pkg := range counts {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+
+	lines := []string{fmt.Sprintf("Found %d reference(s) in %d package(s):", len(paths), len(packages))}
+	for _, pkg := range packages {
+		files := make([]string, 0, len(counts[pkg]))
+		total := 0
+		for file, count := range counts[pkg] {
+			files = append(files, file)
+			total += count
+		}
+		sort.Strings(files)
+
+		lines = append(lines, fmt.Sprintf("%s (%d)", pkg, total))
+		for i, file := range files {
+			treeChar := "├── "
+			if i == len(files)-1 {
+				treeChar = "└── "
+			}
+			lines = append(lines, fmt.Sprintf("%s%s (%d)", treeChar, file, counts[pkg][file]))
+		}
 	}
+	return strings.Join(lines, "\n")
 }
\ No newline at end of file
@@ -0,0 +1,217 @@
+package find_shadowed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "FindShadowedVariables",
+		Description: "Report variables that shadow a variable of the same name from an enclosing scope, with the positions of both declarations and whether the outer variable is used after the shadowing scope (the usual sign of a bug, e.g. a lost err)",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file",
+				},
+				"line": map[string]interface{}{
+					"type":        "number",
+					"description": "Only check the function containing this line (1-indexed); checks the whole file when omitted",
+				},
+				"column": map[string]interface{}{
+					"type":        "number",
+					"description": "Column number (1-indexed) used with line",
+					"default":     1,
+				},
+				"includePackageLevel": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also report local variables that shadow package-level variables",
+					"default":     false,
+				},
+				"strict": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Only report shadowing where both variables have the same type and the outer one is used after the shadowing scope",
+					"default":     false,
+				},
+			},
+			Required: []string{"file"},
+		},
+	}
+}
+
+type declaration struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Type   string `json:"type"`
+}
+
+type shadowing struct {
+	Name           string      `json:"name"`
+	Function       string      `json:"function"`
+	Inner          declaration `json:"inner"`
+	Outer          declaration `json:"outer"`
+	SameType       bool        `json:"sameType"`
+	OuterUsedAfter bool        `json:"outerUsedAfter"`
+	PackageLevel   bool        `json:"packageLevel,omitempty"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		line := request.GetInt("line", 0)
+		column := request.GetInt("column", 1)
+		includePackageLevel := request.GetBool("includePackageLevel", false)
+		strict := request.GetBool("strict", false)
+
+		file, err = filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		pkgs, err := typecheck.Load(ctx, filepath.Dir(file), ".")
+		if err != nil {
+			return nil, err
+		}
+		if len(pkgs) == 0 {
+			return nil, fmt.Errorf("no package found for %s", file)
+		}
+		pkg := pkgs[0]
+		astFile := pkg.File(file)
+		if astFile == nil {
+			return nil, fmt.Errorf("%s is not part of package %s for the current build configuration", file, pkg.ImportPath)
+		}
+
+		funcs := functions(astFile)
+		if line > 0 {
+			tokFile := pkg.Fset.File(astFile.Pos())
+			if line > tokFile.LineCount() {
+				return nil, fmt.Errorf("line %d out of range", line)
+			}
+			pos := tokFile.LineStart(line) + token.Pos(column-1)
+			var selected []*ast.FuncDecl
+			for _, fn := range funcs {
+				if fn.Pos() <= pos && pos <= fn.End() {
+					selected = append(selected, fn)
+				}
+			}
+			if len(selected) == 0 {
+				return nil, fmt.Errorf("no function at %s:%d:%d", file, line, column)
+			}
+			funcs = selected
+		}
+
+		c := &checker{pkg: pkg, uses: usesByObject(pkg.Info)}
+		results := make([]shadowing, 0)
+		for _, fn := range funcs {
+			for _, s := range c.check(fn) {
+				if s.PackageLevel && !includePackageLevel {
+					continue
+				}
+				if strict && !(s.SameType && s.OuterUsedAfter) {
+					continue
+				}
+				results = append(results, s)
+			}
+		}
+		sort.SliceStable(results, func(i, j int) bool { return results[i].Inner.Line < results[j].Inner.Line })
+
+		output, _ := json.MarshalIndent(results, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d shadowed variable(s):\n%s", len(results), output)), nil
+	}
+}
+
+func functions(file *ast.File) []*ast.FuncDecl {
+	var funcs []*ast.FuncDecl
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			funcs = append(funcs, fn)
+		}
+	}
+	return funcs
+}
+
+// usesByObject inverts Info.Uses so the uses of a variable can be found
+func usesByObject(info *types.Info) map[types.Object][]token.Pos {
+	uses := make(map[types.Object][]token.Pos)
+	for ident, obj := range info.Uses {
+		if _, ok := obj.(*types.Var); ok {
+			uses[obj] = append(uses[obj], ident.Pos())
+		}
+	}
+	return uses
+}
+
+type checker struct {
+	pkg  *typecheck.Package
+	uses map[types.Object][]token.Pos
+}
+
+// check finds the variables declared in fn, including in its function
+// literals, that shadow a variable from an enclosing scope
+func (c *checker) check(fn *ast.FuncDecl) []shadowing {
+	var found []shadowing
+	ast.Inspect(fn, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok || ident.Name == "_" {
+			return true
+		}
+		inner, ok := c.pkg.Info.Defs[ident].(*types.Var)
+		if !ok || inner.IsField() || inner.Parent() == nil || inner.Parent().Parent() == nil {
+			return true
+		}
+		_, obj := inner.Parent().Parent().LookupParent(ident.Name, ident.Pos())
+		outer, ok := obj.(*types.Var)
+		if !ok || outer.Parent() == types.Universe {
+			return true
+		}
+
+		found = append(found, shadowing{
+			Name:           ident.Name,
+			Function:       astscan.FuncName(fn),
+			Inner:          c.declaration(inner),
+			Outer:          c.declaration(outer),
+			SameType:       types.Identical(inner.Type(), outer.Type()),
+			OuterUsedAfter: c.usedBetween(outer, inner.Parent().End(), fn.End()),
+			PackageLevel:   outer.Parent() == c.pkg.Types.Scope(),
+		})
+		return true
+	})
+	return found
+}
+
+func (c *checker) declaration(v *types.Var) declaration {
+	position := c.pkg.Fset.Position(v.Pos())
+	return declaration{
+		File:   position.Filename,
+		Line:   position.Line,
+		Column: position.Column,
+		Type:   types.TypeString(v.Type(), types.RelativeTo(c.pkg.Types)),
+	}
+}
+
+// usedBetween reports whether v is used after from and before to
+func (c *checker) usedBetween(v *types.Var, from, to token.Pos) bool {
+	for _, use := range c.uses[v] {
+		if use > from && use < to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package find_tests
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+)
+
+// block is a covered range of lines from a coverage profile
+type block struct {
+	start, end int
+}
+
+// confirmCoverage runs the tests found for funcs one at a time with coverage
+// of file's package, recording whether each executes its function
+func confirmCoverage(ctx context.Context, file string, fset *token.FileSet, funcs []*ast.FuncDecl, results []functionTests, maxRuns int) error {
+	importPath, err := gocmd.Output(ctx, filepath.Dir(file), "list", "-f", "{{.ImportPath}}", ".")
+	if err != nil {
+		return err
+	}
+	scratch, err := os.MkdirTemp("", "find-tests-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratch)
+
+	profileFile := importPath + "/" + filepath.Base(file)
+	covered := make(map[string][]block)
+	runs := 0
+	for i, fn := range funcs {
+		start, end := fset.Position(fn.Pos()).Line, fset.Position(fn.End()).Line
+		for _, test := range results[i].Tests {
+			key := test.File + ":" + test.Name
+			blocks, ok := covered[key]
+			if !ok {
+				if runs >= maxRuns {
+					continue
+				}
+				runs++
+				profile := filepath.Join(scratch, fmt.Sprintf("cover%d.out", runs))
+				args := []string{"test", "-count=1", "-coverpkg=" + importPath, "-coverprofile=" + profile}
+				if strings.HasPrefix(test.Name, "Benchmark") {
+					args = append(args, "-run=^$", "-bench=^"+test.Name+"$", "-benchtime=1x")
+				} else {
+					args = append(args, "-run=^"+test.Name+"$")
+				}
+				if _, err := gocmd.Run(ctx, filepath.Dir(test.File), nil, append(args, ".")...); err != nil {
+					return err
+				}
+				// A failing test still writes its profile
+				blocks = readProfile(profile, profileFile)
+				covered[key] = blocks
+			}
+			covers := false
+			for _, b := range blocks {
+				if b.start <= end && b.end >= start {
+					covers = true
+					break
+				}
+			}
+			test.Covers = &covers
+		}
+	}
+	return nil
+}
+
+// readProfile returns the executed blocks of file in a coverage profile,
+// where file is written as import path and base name
+func readProfile(path, file string) []block {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var blocks []block
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// name.go:startLine.startCol,endLine.endCol statements count
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || name != file {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) != 3 || fields[2] == "0" {
+			continue
+		}
+		from, to, _ := strings.Cut(fields[0], ",")
+		startLine, _ := strconv.Atoi(strings.Split(from, ".")[0])
+		endLine, _ := strconv.Atoi(strings.Split(to, ".")[0])
+		blocks = append(blocks, block{start: startLine, end: endLine})
+	}
+	return blocks
+}
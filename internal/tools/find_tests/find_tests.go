@@ -0,0 +1,285 @@
+package find_tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "FindTestsFor",
+		Description: "Find the tests that exercise a function or the functions of a file, by test naming convention and references from _test.go files (following test helpers), optionally confirmed with coverage. Returns the go test commands to run after editing it.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file",
+				},
+				"line": map[string]interface{}{
+					"type":        "number",
+					"description": "Line (1-indexed) inside the function to find tests for; every function in the file when omitted",
+				},
+				"helperDepth": map[string]interface{}{
+					"type":        "number",
+					"description": "How many levels of non-test helper functions in _test.go files to follow from a reference to a test",
+					"default":     2,
+				},
+				"coverage": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Run each test found with coverage to confirm it executes the function. Slower; at most maxCoverageRuns tests are run.",
+					"default":     false,
+				},
+				"maxCoverageRuns": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum number of tests to run when coverage is set",
+					"default":     10,
+				},
+			},
+			Required: []string{"file"},
+		},
+	}
+}
+
+type foundTest struct {
+	Name    string   `json:"name"`
+	File    string   `json:"file"`
+	Line    int      `json:"line"`
+	Reasons []string `json:"reasons"`
+	Covers  *bool    `json:"coversFunction,omitempty"`
+}
+
+type functionTests struct {
+	Function string       `json:"function"`
+	Line     int          `json:"line"`
+	Tests    []*foundTest `json:"tests"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		line := request.GetInt("line", 0)
+		helperDepth := request.GetInt("helperDepth", 2)
+
+		content, err := manager.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fset := token.NewFileSet()
+		astFile, err := parser.ParseFile(fset, file, content, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		var funcs []*ast.FuncDecl
+		for _, decl := range astFile.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			if line == 0 || (fset.Position(fn.Pos()).Line <= line && line <= fset.Position(fn.End()).Line) {
+				funcs = append(funcs, fn)
+			}
+		}
+		if len(funcs) == 0 {
+			if line > 0 {
+				return nil, fmt.Errorf("no function at %s:%d", file, line)
+			}
+			return nil, fmt.Errorf("%s declares no functions", file)
+		}
+
+		client, err := manager.GetClient()
+		if err != nil {
+			return nil, err
+		}
+		uri, err := utils.PathToURI(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.OpenDocument(ctx, uri, string(content)); err != nil {
+			return nil, err
+		}
+		defer client.CloseDocument(ctx, uri)
+
+		f := &finder{manager: manager, client: client, tests: newTestIndex()}
+		results := make([]functionTests, 0, len(funcs))
+		for _, fn := range funcs {
+			found := make(map[string]*foundTest)
+			f.byName(filepath.Dir(file), fn, found)
+			position, err := utils.OffsetToPosition(string(content), fset.Position(fn.Name.Pos()).Offset)
+			if err != nil {
+				return nil, err
+			}
+			if err := f.byReference(ctx, uri, position, astscan.FuncName(fn), "", helperDepth, found, make(map[string]bool)); err != nil {
+				return nil, err
+			}
+			start := fset.Position(fn.Pos())
+			results = append(results, functionTests{
+				Function: astscan.FuncName(fn),
+				Line:     start.Line,
+				Tests:    sortTests(found),
+			})
+		}
+
+		var note string
+		if request.GetBool("coverage", false) {
+			if err := confirmCoverage(ctx, file, fset, funcs, results, request.GetInt("maxCoverageRuns", 10)); err != nil {
+				note = fmt.Sprintf("\nNote: coverage was not checked: %v", err)
+			}
+		}
+		return textResult(results, note), nil
+	}
+}
+
+func textResult(results []functionTests, note string) *mcp.CallToolResult {
+	total := make(map[string]bool)
+	byDir := make(map[string][]string)
+	for _, fn := range results {
+		for _, test := range fn.Tests {
+			key := filepath.Dir(test.File) + "\x00" + test.Name
+			if total[key] {
+				continue
+			}
+			total[key] = true
+			byDir[filepath.Dir(test.File)] = append(byDir[filepath.Dir(test.File)], test.Name)
+		}
+	}
+
+	var commands []string
+	for dir, names := range byDir {
+		sort.Strings(names)
+		commands = append(commands, fmt.Sprintf("cd %s && go test -run '^(%s)$' .", dir, strings.Join(names, "|")))
+	}
+	sort.Strings(commands)
+
+	output, _ := json.MarshalIndent(map[string]interface{}{
+		"functions": results,
+		"commands":  commands,
+	}, "", "  ")
+	return mcp.NewToolResultText(fmt.Sprintf("Found %d test(s) for %d function(s):\n%s%s", len(total), len(results), output, note))
+}
+
+// finder looks up the tests related to functions
+type finder struct {
+	manager *gopls.Manager
+	client  *lsp.Client
+	tests   *testIndex
+}
+
+// byName adds the tests in dir named after fn: TestF, TestF_case, TestT_M,
+// TestTM and the matching benchmarks, fuzz tests and examples
+func (f *finder) byName(dir string, fn *ast.FuncDecl, found map[string]*foundTest) {
+	name := fn.Name.Name
+	stems := []string{upperFirst(name)}
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		recv := upperFirst(astscan.ReceiverType(fn.Recv.List[0].Type))
+		stems = []string{recv + "_" + name, recv + upperFirst(name)}
+	}
+
+	for _, test := range f.tests.inDir(dir) {
+		if !test.isTest {
+			continue
+		}
+		rest := strings.TrimPrefix(test.name, test.kind)
+		for _, stem := range stems {
+			if rest == stem || strings.HasPrefix(rest, stem+"_") {
+				add(found, test, "named after the function")
+				break
+			}
+		}
+	}
+}
+
+// byReference adds the tests that refer to the symbol at position, following
+// helpers declared in test files up to depth levels. via names the helper
+// the references lead back to target through.
+func (f *finder) byReference(ctx context.Context, uri string, position lsp.Position, target, via string, depth int, found map[string]*foundTest, visited map[string]bool) error {
+	locations, err := f.client.References(ctx, uri, position, false)
+	if err != nil {
+		return fmt.Errorf("failed to find references to %s: %w", target, err)
+	}
+	for _, loc := range locations {
+		path, err := f.manager.URIToPath(loc.URI)
+		if err != nil || !strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		caller := f.tests.enclosing(path, loc.Range.Start.Line+1)
+		if caller == nil {
+			continue
+		}
+		if caller.isTest {
+			reason := "calls " + target
+			if via != "" {
+				reason += " through helper " + via
+			}
+			add(found, caller, reason)
+			continue
+		}
+
+		key := caller.file + ":" + caller.name
+		if depth <= 0 || visited[key] {
+			continue
+		}
+		visited[key] = true
+		callerURI, err := utils.PathToURI(caller.file)
+		if err != nil {
+			continue
+		}
+		if err := f.byReference(ctx, callerURI, caller.namePos, target, caller.name, depth-1, found, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func add(found map[string]*foundTest, test *testFunc, reason string) {
+	key := test.file + ":" + test.name
+	t, ok := found[key]
+	if !ok {
+		t = &foundTest{Name: test.name, File: test.file, Line: test.line}
+		found[key] = t
+	}
+	for _, r := range t.Reasons {
+		if r == reason {
+			return
+		}
+	}
+	t.Reasons = append(t.Reasons, reason)
+}
+
+func sortTests(found map[string]*foundTest) []*foundTest {
+	tests := make([]*foundTest, 0, len(found))
+	for _, t := range found {
+		tests = append(tests, t)
+	}
+	sort.Slice(tests, func(i, j int) bool {
+		if tests[i].File != tests[j].File {
+			return tests[i].File < tests[j].File
+		}
+		return tests[i].Line < tests[j].Line
+	})
+	return tests
+}
+
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,117 @@
+package find_tests
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// testFunc is a function declared in a _test.go file: a test, benchmark,
+// fuzz test or example, or a helper
+type testFunc struct {
+	name    string
+	kind    string // Test, Benchmark, Fuzz or Example; empty for helpers
+	isTest  bool
+	file    string
+	line    int
+	endLine int
+	namePos lsp.Position
+}
+
+// testIndex parses _test.go files on demand and remembers their functions
+type testIndex struct {
+	files map[string][]*testFunc
+}
+
+func newTestIndex() *testIndex {
+	return &testIndex{files: make(map[string][]*testFunc)}
+}
+
+// inDir returns the functions of every _test.go file in dir
+func (x *testIndex) inDir(dir string) []*testFunc {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	var funcs []*testFunc
+	for _, path := range paths {
+		funcs = append(funcs, x.file(path)...)
+	}
+	return funcs
+}
+
+// enclosing returns the function in the test file at path containing line
+func (x *testIndex) enclosing(path string, line int) *testFunc {
+	for _, fn := range x.file(path) {
+		if fn.line <= line && line <= fn.endLine {
+			return fn
+		}
+	}
+	return nil
+}
+
+func (x *testIndex) file(path string) []*testFunc {
+	if funcs, ok := x.files[path]; ok {
+		return funcs
+	}
+	var funcs []*testFunc
+	defer func() { x.files[path] = funcs }()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.SkipObjectResolution)
+	if file == nil {
+		return nil
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		namePos, err := utils.OffsetToPosition(string(content), fset.Position(fn.Name.Pos()).Offset)
+		if err != nil {
+			continue
+		}
+		kind := testKind(fn)
+		funcs = append(funcs, &testFunc{
+			name:    fn.Name.Name,
+			kind:    kind,
+			isTest:  kind != "",
+			file:    path,
+			line:    fset.Position(fn.Pos()).Line,
+			endLine: fset.Position(fn.End()).Line,
+			namePos: namePos,
+		})
+	}
+	return funcs
+}
+
+// testKind returns the prefix that makes fn a test, benchmark, fuzz test or
+// example for go test, or an empty string if it is none of them
+func testKind(fn *ast.FuncDecl) string {
+	if fn.Recv != nil {
+		return ""
+	}
+	for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+		rest, ok := strings.CutPrefix(fn.Name.Name, prefix)
+		if !ok {
+			continue
+		}
+		// TestMain is not a test, and Testing is not a test name
+		if prefix == "Test" && rest == "Main" {
+			return ""
+		}
+		if r, _ := utf8.DecodeRuneInString(rest); rest == "" || !unicode.IsLower(r) {
+			return prefix
+		}
+	}
+	return ""
+}
@@ -0,0 +1,270 @@
+package find_untested
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+// allKinds are the kinds of test function a function is checked for
+var allKinds = []string{"test", "example", "benchmark"}
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "FindUntestedFunctions",
+		Description: "List the exported functions and methods of a package that no test, example or benchmark names or refers to, ranked by how many of them are missing and then by complexity, so the riskiest gaps come first. References are matched by name in the package's _test.go files.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Package directory, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"kinds": map[string]interface{}{
+					"type":        "array",
+					"description": "What a function must have to count as covered: test, example and/or benchmark. A function is listed when it lacks any of them (defaults to all three).",
+					"items":       map[string]interface{}{"type": "string", "enum": []string{"test", "example", "benchmark"}},
+					"default":     allKinds,
+				},
+				"includeMethods": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also check the exported methods of exported types",
+					"default":     true,
+				},
+			},
+		},
+	}
+}
+
+type function struct {
+	Name       string   `json:"name"`
+	File       string   `json:"file"`
+	Line       int      `json:"line"`
+	Complexity int      `json:"complexity"`
+	Priority   string   `json:"priority"`
+	Missing    []string `json:"missing"`
+	Test       bool     `json:"test"`
+	Example    bool     `json:"example"`
+	Benchmark  bool     `json:"benchmark"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := manager.ResolvePath(request.GetString("path", ""))
+		kinds := request.GetStringSlice("kinds", allKinds)
+		for _, kind := range kinds {
+			if kind != "test" && kind != "example" && kind != "benchmark" {
+				return nil, fmt.Errorf("unknown kind %q: use test, example or benchmark", kind)
+			}
+		}
+		includeMethods := request.GetBool("includeMethods", true)
+
+		paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			return nil, err
+		}
+		fset := token.NewFileSet()
+		var sources, tests []*ast.File
+		for _, path := range paths {
+			file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+			if err != nil {
+				continue
+			}
+			if strings.HasSuffix(path, "_test.go") {
+				tests = append(tests, file)
+			} else {
+				sources = append(sources, file)
+			}
+		}
+		if len(sources) == 0 {
+			return nil, fmt.Errorf("no Go source files in %s", dir)
+		}
+
+		coverage := indexTests(tests)
+		var all []function
+		for _, file := range sources {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || !fn.Name.IsExported() {
+					continue
+				}
+				recv := ""
+				if fn.Recv != nil && len(fn.Recv.List) > 0 {
+					recv = astscan.ReceiverType(fn.Recv.List[0].Type)
+					if !includeMethods || !token.IsExported(recv) {
+						continue
+					}
+				}
+				position := fset.Position(fn.Pos())
+				f := function{
+					Name:       astscan.FuncName(fn),
+					File:       position.Filename,
+					Line:       position.Line,
+					Complexity: complexity(fn),
+				}
+				f.Test, f.Example, f.Benchmark = coverage.has(recv, fn.Name.Name)
+				has := map[string]bool{"test": f.Test, "example": f.Example, "benchmark": f.Benchmark}
+				for _, kind := range kinds {
+					if !has[kind] {
+						f.Missing = append(f.Missing, kind)
+					}
+				}
+				if len(f.Missing) == 0 {
+					continue
+				}
+				f.Priority = priority(f)
+				all = append(all, f)
+			}
+		}
+
+		// Functions missing the most kinds come first
+		rank := map[string]int{"high": 0, "medium": 1, "low": 2}
+		sort.SliceStable(all, func(i, j int) bool {
+			if len(all[i].Missing) != len(all[j].Missing) {
+				return len(all[i].Missing) > len(all[j].Missing)
+			}
+			if rank[all[i].Priority] != rank[all[j].Priority] {
+				return rank[all[i].Priority] < rank[all[j].Priority]
+			}
+			return all[i].Complexity > all[j].Complexity
+		})
+
+		if all == nil {
+			all = []function{}
+		}
+		output, _ := json.MarshalIndent(all, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("%d exported function(s) in %s lack a %s:\n%s", len(all), dir, strings.Join(kinds, " or "), output)), nil
+	}
+}
+
+// priority ranks an uncovered function: untested functions with branching
+// logic first, then other untested functions, then tested functions that
+// only lack examples or benchmarks
+func priority(f function) string {
+	switch {
+	case !f.Test && f.Complexity >= 5:
+		return "high"
+	case !f.Test:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// complexity is the cyclomatic complexity of fn: one plus the number of
+// branch points
+func complexity(fn *ast.FuncDecl) int {
+	n := 1
+	if fn.Body == nil {
+		return n
+	}
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			n++
+		case *ast.CaseClause:
+			if node.List != nil {
+				n++
+			}
+		case *ast.CommClause:
+			if node.Comm != nil {
+				n++
+			}
+		case *ast.BinaryExpr:
+			if node.Op == token.LAND || node.Op == token.LOR {
+				n++
+			}
+		}
+		return true
+	})
+	return n
+}
+
+// testCoverage records the names tests, examples and benchmarks are named
+// after and the names they refer to
+type testCoverage struct {
+	named map[string]map[string]bool // kind -> name stems such as "F" or "T_M"
+	refs  map[string]map[string]bool // kind -> referenced identifiers
+}
+
+func indexTests(files []*ast.File) *testCoverage {
+	c := &testCoverage{
+		named: map[string]map[string]bool{"test": {}, "example": {}, "benchmark": {}},
+		refs:  map[string]map[string]bool{"test": {}, "example": {}, "benchmark": {}},
+	}
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Body == nil {
+				continue
+			}
+			kind, stem := classify(fn.Name.Name)
+			if kind == "" {
+				continue
+			}
+			c.named[kind][stem] = true
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.Ident:
+					c.refs[kind][n.Name] = true
+				case *ast.SelectorExpr:
+					c.refs[kind]["."+n.Sel.Name] = true
+				}
+				return true
+			})
+		}
+	}
+	return c
+}
+
+// classify returns the kind of a test function name and the rest of the
+// name after the prefix, e.g. "test" and "Parse_empty" for TestParse_empty
+func classify(name string) (kind, stem string) {
+	for prefix, kind := range map[string]string{"Test": "test", "Fuzz": "test", "Example": "example", "Benchmark": "benchmark"} {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		if r, _ := utf8.DecodeRuneInString(rest); rest != "" && unicode.IsLower(r) {
+			continue
+		}
+		return kind, rest
+	}
+	return "", ""
+}
+
+// has reports whether a test, example and benchmark name or refer to the
+// function name, or the method recv.name when recv is set
+func (c *testCoverage) has(recv, name string) (test, example, benchmark bool) {
+	check := func(kind string) bool {
+		stems := []string{name}
+		ref := name
+		if recv != "" {
+			stems = []string{recv + "_" + name, recv + name}
+			ref = "." + name
+		}
+		for stem := range c.named[kind] {
+			for _, want := range stems {
+				// Examples are named ExampleF_suffix, tests TestF_case
+				if stem == want || strings.HasPrefix(stem, want+"_") {
+					return true
+				}
+			}
+		}
+		return c.refs[kind][ref]
+	}
+	return check("test"), check("example"), check("benchmark")
+}
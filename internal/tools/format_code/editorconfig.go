@@ -0,0 +1,159 @@
+package format_code
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// editorConfig reads the .editorconfig properties that apply to path. Files
+// are read from the file's directory upwards until one declares root = true;
+// properties from closer files and later sections take precedence.
+func editorConfig(path string) map[string]string {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+
+	// Collect the files nearest first, then apply them outermost first
+	var configs []string
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		config := filepath.Join(dir, ".editorconfig")
+		if _, err := os.Stat(config); err == nil {
+			configs = append(configs, config)
+			if isRoot(config) {
+				break
+			}
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	props := make(map[string]string)
+	for i := len(configs) - 1; i >= 0; i-- {
+		applyConfig(configs[i], path, props)
+	}
+	return props
+}
+
+// isRoot reports whether an .editorconfig file sets root = true in its
+// preamble
+func isRoot(config string) bool {
+	f, err := os.Open(config)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			return false
+		}
+		if key, value, ok := splitProperty(line); ok && key == "root" {
+			return strings.EqualFold(value, "true")
+		}
+	}
+	return false
+}
+
+// applyConfig copies the properties of every section in config matching path
+// into props
+func applyConfig(config, path string, props map[string]string) {
+	f, err := os.Open(config)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	rel, err := filepath.Rel(filepath.Dir(config), path)
+	if err != nil {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+
+	matched := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			matched = matchSection(line[1:len(line)-1], rel)
+			continue
+		}
+		if key, value, ok := splitProperty(line); ok && matched {
+			props[key] = strings.ToLower(value)
+		}
+	}
+}
+
+func splitProperty(line string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(line, "=")
+	return strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value), ok
+}
+
+// matchSection reports whether a section glob matches rel, a slash-separated
+// path relative to the .editorconfig file. Globs without a slash match the
+// file name in any directory.
+func matchSection(glob, rel string) bool {
+	if !strings.Contains(glob, "/") {
+		glob = "**/" + glob
+	}
+	glob = strings.TrimPrefix(glob, "/")
+	re, err := regexp.Compile("^" + globToRegexp(glob) + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(rel) || (strings.HasPrefix(glob, "**/") && re.MatchString("/"+rel))
+}
+
+// globToRegexp translates EditorConfig glob syntax: *, **, ?, [chars],
+// [!chars] and {a,b}
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		case c == '{':
+			braces++
+			b.WriteString("(?:")
+		case c == '}' && braces > 0:
+			braces--
+			b.WriteString(")")
+		case c == ',' && braces > 0:
+			b.WriteString("|")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -3,7 +3,7 @@ package format_code
 import (
 	"context"
 	"fmt"
-	"os"
+	"strconv"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -15,7 +15,7 @@ import (
 func NewTool(manager *gopls.Manager) mcp.Tool {
 	return mcp.Tool{
 		Name:        "FormatCode",
-		Description: "Format Go source code according to gofmt standards",
+		Description: "Format Go source code according to gofmt standards. Formatting options default to the nearest .editorconfig; gopls follows gofmt where they conflict with it",
 		InputSchema: mcp.ToolInputSchema{
 			Type: "object",
 			Properties: map[string]interface{}{
@@ -23,6 +23,31 @@ func NewTool(manager *gopls.Manager) mcp.Tool {
 					"type":        "string",
 					"description": "Absolute path to the Go source file to format",
 				},
+				"tabSize": map[string]interface{}{
+					"type":        "number",
+					"description": "Size of a tab in spaces (defaults to .editorconfig or 4)",
+				},
+				"insertSpaces": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Prefer spaces over tabs (defaults to .editorconfig or false)",
+				},
+				"trimTrailingWhitespace": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Trim trailing whitespace on each line (defaults to .editorconfig or false)",
+				},
+				"insertFinalNewline": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Insert a newline at the end of the file if missing (defaults to .editorconfig or false)",
+				},
+				"trimFinalNewlines": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Trim all newlines after the final newline (defaults to false)",
+				},
+				"useEditorConfig": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Read defaults for the options above from .editorconfig files",
+					"default":     true,
+				},
 			},
 			Required: []string{"file"},
 		},
@@ -46,7 +71,7 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 			return nil, err
 		}
 
-		content, err := os.ReadFile(file)
+		content, err := manager.ReadFile(file)
 		if err != nil {
 			return nil, err
 		}
@@ -57,7 +82,7 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 		defer client.CloseDocument(ctx, uri)
 
 		// Request formatting from gopls
-		textEdits, err := client.DocumentFormatting(ctx, uri)
+		textEdits, err := client.DocumentFormatting(ctx, uri, formattingOptions(file, request))
 		if err != nil {
 			return nil, fmt.Errorf("formatting request failed: %w", err)
 		}
@@ -67,7 +92,7 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 		}
 
 		// Apply the formatting edits to the file
-		if err := applyTextEdits(file, textEdits); err != nil {
+		if err := manager.ApplyToFile(file, textEdits); err != nil {
 			return nil, fmt.Errorf("failed to apply formatting: %w", err)
 		}
 
@@ -75,41 +100,45 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 	}
 }
 
-// applyTextEdits applies LSP text edits to a file
-func applyTextEdits(filePath string, edits []lsp.TextEdit) error {
-	// Read the file
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
-
-	// For formatting, typically there's only one edit that replaces the entire content
-	// But let's handle the general case
-	text := string(content)
-	
-	// Apply edits in reverse order to avoid offset issues
-	for i := len(edits) - 1; i >= 0; i-- {
-		edit := edits[i]
-		
-		// Calculate offsets
-		startOffset, err := utils.CalculateOffset(text, edit.Range.Start)
-		if err != nil {
-			return fmt.Errorf("failed to calculate start offset: %w", err)
+// formattingOptions resolves the formatting options for file: explicit tool
+// arguments win over .editorconfig properties, which win over Go's defaults
+func formattingOptions(file string, request mcp.CallToolRequest) lsp.FormattingOptions {
+	options := lsp.FormattingOptions{TabSize: 4}
+
+	if request.GetBool("useEditorConfig", true) {
+		props := editorConfig(file)
+		switch props["indent_style"] {
+		case "space":
+			options.InsertSpaces = true
+		case "tab":
+			options.InsertSpaces = false
 		}
-		
-		endOffset, err := utils.CalculateOffset(text, edit.Range.End)
-		if err != nil {
-			return fmt.Errorf("failed to calculate end offset: %w", err)
+		// Tabs are sized by tab_width and spaces by indent_size, each
+		// falling back to the other
+		sizes := []string{props["tab_width"], props["indent_size"]}
+		if options.InsertSpaces {
+			sizes[0], sizes[1] = sizes[1], sizes[0]
+		}
+		size := sizes[0]
+		if size == "" || size == "tab" {
+			size = sizes[1]
+		}
+		if n, err := strconv.Atoi(size); err == nil && n > 0 {
+			options.TabSize = n
 		}
-		
-		// Apply the edit
-		text = text[:startOffset] + edit.NewText + text[endOffset:]
+		options.TrimTrailingWhitespace = props["trim_trailing_whitespace"] == "true"
+		options.InsertFinalNewline = props["insert_final_newline"] == "true"
 	}
 
-	// Write back to file
-	if err := os.WriteFile(filePath, []byte(text), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+	args := request.GetArguments()
+	if _, ok := args["tabSize"]; ok {
+		if n := request.GetInt("tabSize", options.TabSize); n > 0 {
+			options.TabSize = n
+		}
 	}
-
-	return nil
-}
\ No newline at end of file
+	options.InsertSpaces = request.GetBool("insertSpaces", options.InsertSpaces)
+	options.TrimTrailingWhitespace = request.GetBool("trimTrailingWhitespace", options.TrimTrailingWhitespace)
+	options.InsertFinalNewline = request.GetBool("insertFinalNewline", options.InsertFinalNewline)
+	options.TrimFinalNewlines = request.GetBool("trimFinalNewlines", options.TrimFinalNewlines)
+	return options
+}
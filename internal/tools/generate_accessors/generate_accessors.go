@@ -0,0 +1,219 @@
+package generate_accessors
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "GenerateAccessors",
+		Description: "Generate getters, setters or a builder type for a struct and insert them after the type declaration",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file declaring the struct",
+				},
+				"type": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the struct type",
+				},
+				"kind": map[string]interface{}{
+					"type":        "string",
+					"description": "What to generate: 'getters', 'setters', 'both' or 'builder'",
+					"enum":        []string{"getters", "setters", "both", "builder"},
+					"default":     "both",
+				},
+				"fields": map[string]interface{}{
+					"type":        "array",
+					"description": "Only generate accessors for these fields (defaults to all fields)",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+			},
+			Required: []string{"file", "type"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		typeName, err := request.RequireString("type")
+		if err != nil {
+			return nil, err
+		}
+		kind := request.GetString("kind", "both")
+		if kind != "getters" && kind != "setters" && kind != "both" && kind != "builder" {
+			return nil, fmt.Errorf("invalid kind %q: must be 'getters', 'setters', 'both' or 'builder'", kind)
+		}
+
+		target, err := codegen.FindType(ctx, file, typeName)
+		if err != nil {
+			return nil, err
+		}
+		st, err := target.Struct()
+		if err != nil {
+			return nil, err
+		}
+
+		fields, err := selectFields(st, request.GetStringSlice("fields", nil))
+		if err != nil {
+			return nil, err
+		}
+
+		var code string
+		var skipped []string
+		if kind == "builder" {
+			code, err = builder(target, fields)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			code, skipped = accessors(target, fields, kind != "setters", kind != "getters")
+		}
+
+		if strings.TrimSpace(code) == "" {
+			return mcp.NewToolResultText(fmt.Sprintf("Nothing to generate for %s; all accessors already exist", typeName)), nil
+		}
+
+		if err := target.InsertAfterDecl(code); err != nil {
+			return nil, err
+		}
+
+		msg := fmt.Sprintf("Generated code in %s:\n\n%s", target.Path, code)
+		if len(skipped) > 0 {
+			msg += fmt.Sprintf("\nSkipped existing or conflicting methods: %s", strings.Join(skipped, ", "))
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+// selectFields returns the named struct fields in declaration order
+func selectFields(st *types.Struct, names []string) ([]*types.Var, error) {
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	fields := make([]*types.Var, 0, st.NumFields())
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		if v.Name() == "_" || (len(wanted) > 0 && !wanted[v.Name()]) {
+			continue
+		}
+		delete(wanted, v.Name())
+		fields = append(fields, v)
+	}
+
+	for name := range wanted {
+		return nil, fmt.Errorf("struct has no field %s", name)
+	}
+	return fields, nil
+}
+
+// getterName follows the Go convention of naming getters after the field,
+// using a Get prefix for exported fields since a method cannot share the
+// field's name
+func getterName(field *types.Var) string {
+	if field.Exported() {
+		return "Get" + field.Name()
+	}
+	return utils.ToPascal(field.Name())
+}
+
+func accessors(target *codegen.Target, fields []*types.Var, getters, setters bool) (string, []string) {
+	typeName := target.Obj.Name()
+	_, typeArgs := target.TypeParams()
+	recv := target.ReceiverName()
+
+	var b strings.Builder
+	var skipped []string
+	for _, field := range fields {
+		typ := target.TypeString(field.Type())
+		param := utils.LowerFirst(field.Name())
+		if param == recv {
+			param = "v"
+		}
+
+		if getters {
+			name := getterName(field)
+			if target.HasMethod(name) || hasField(fields, name) {
+				skipped = append(skipped, name)
+			} else {
+				fmt.Fprintf(&b, "// %s returns the %s field.\n", name, field.Name())
+				fmt.Fprintf(&b, "func (%s *%s%s) %s() %s {\n\treturn %s.%s\n}\n\n", recv, typeName, typeArgs, name, typ, recv, field.Name())
+			}
+		}
+
+		if setters {
+			name := "Set" + utils.ToPascal(field.Name())
+			if target.HasMethod(name) || hasField(fields, name) {
+				skipped = append(skipped, name)
+			} else {
+				fmt.Fprintf(&b, "// %s sets the %s field.\n", name, field.Name())
+				fmt.Fprintf(&b, "func (%s *%s%s) %s(%s %s) {\n\t%s.%s = %s\n}\n\n", recv, typeName, typeArgs, name, param, typ, recv, field.Name(), param)
+			}
+		}
+	}
+	return b.String(), skipped
+}
+
+func builder(target *codegen.Target, fields []*types.Var) (string, error) {
+	typeName := target.Obj.Name()
+	typeParams, typeArgs := target.TypeParams()
+	builderName := typeName + "Builder"
+	constructor := "New" + utils.ToPascal(builderName)
+	for _, name := range []string{builderName, constructor} {
+		if target.Exists(name) {
+			return "", fmt.Errorf("%s is already declared in package %s", name, target.Pkg.Name)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s builds a %s step by step.\n", builderName, typeName)
+	fmt.Fprintf(&b, "type %s%s struct {\n\tvalue %s%s\n}\n\n", builderName, typeParams, typeName, typeArgs)
+	fmt.Fprintf(&b, "// %s returns a builder for %s.\n", constructor, typeName)
+	fmt.Fprintf(&b, "func %s%s() *%s%s {\n\treturn &%s%s{}\n}\n\n", constructor, typeParams, builderName, typeArgs, builderName, typeArgs)
+
+	for _, field := range fields {
+		typ := target.TypeString(field.Type())
+		method := utils.ToPascal(field.Name())
+		if method == "Build" {
+			method = "With" + method
+		}
+		param := utils.LowerFirst(field.Name())
+		if param == "b" {
+			param = "v"
+		}
+		fmt.Fprintf(&b, "// %s sets the %s field.\n", method, field.Name())
+		fmt.Fprintf(&b, "func (b *%s%s) %s(%s %s) *%s%s {\n\tb.value.%s = %s\n\treturn b\n}\n\n",
+			builderName, typeArgs, method, param, typ, builderName, typeArgs, field.Name(), param)
+	}
+
+	fmt.Fprintf(&b, "// Build returns the configured %s.\n", typeName)
+	fmt.Fprintf(&b, "func (b *%s%s) Build() *%s%s {\n\tvalue := b.value\n\treturn &value\n}\n", builderName, typeArgs, typeName, typeArgs)
+	return b.String(), nil
+}
+
+// hasField reports whether name clashes with one of the struct's fields
+func hasField(fields []*types.Var, name string) bool {
+	for _, field := range fields {
+		if field.Name() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package generate_constructor
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "GenerateConstructor",
+		Description: "Generate a NewX constructor for a struct type and insert it after the type declaration",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file declaring the struct",
+				},
+				"type": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the struct type",
+				},
+				"mode": map[string]interface{}{
+					"type": "string",
+					"description": "'all' takes every field as a parameter, 'required' only fields without a natural default " +
+						"(skipping pointer, slice, map, chan, func and interface fields and fields tagged default:\"...\"), " +
+						"'options' generates the functional options pattern",
+					"enum":    []string{"all", "required", "options"},
+					"default": "all",
+				},
+			},
+			Required: []string{"file", "type"},
+		},
+	}
+}
+
+// field is a struct field the constructor sets
+type field struct {
+	name  string
+	param string
+	typ   string
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		typeName, err := request.RequireString("type")
+		if err != nil {
+			return nil, err
+		}
+		mode := request.GetString("mode", "all")
+		if mode != "all" && mode != "required" && mode != "options" {
+			return nil, fmt.Errorf("invalid mode %q: must be 'all', 'required' or 'options'", mode)
+		}
+
+		target, err := codegen.FindType(ctx, file, typeName)
+		if err != nil {
+			return nil, err
+		}
+		st, err := target.Struct()
+		if err != nil {
+			return nil, err
+		}
+
+		constructor := "New" + utils.ToPascal(typeName)
+		if target.Exists(constructor) {
+			return nil, fmt.Errorf("%s is already declared in package %s", constructor, target.Pkg.Name)
+		}
+
+		fields := structFields(target, st, mode == "required")
+
+		var code string
+		if mode == "options" {
+			optionType := typeName + "Option"
+			if target.Exists(optionType) {
+				return nil, fmt.Errorf("%s is already declared in package %s", optionType, target.Pkg.Name)
+			}
+			code = optionsConstructor(target, constructor, optionType, fields)
+		} else {
+			code = plainConstructor(target, constructor, fields)
+		}
+
+		if err := target.InsertAfterDecl(code); err != nil {
+			return nil, err
+		}
+
+		return mcp.NewToolResultText(fmt.Sprintf("Generated %s in %s:\n\n%s", constructor, target.Path, code)), nil
+	}
+}
+
+// structFields lists the fields a constructor should set, optionally
+// skipping fields that have a usable default
+func structFields(target *codegen.Target, st *types.Struct, requiredOnly bool) []field {
+	fields := make([]field, 0, st.NumFields())
+	for i := 0; i < st.NumFields(); i++ {
+		v := st.Field(i)
+		if v.Name() == "_" {
+			continue
+		}
+		if requiredOnly && hasDefault(v, reflect.StructTag(st.Tag(i))) {
+			continue
+		}
+		fields = append(fields, field{
+			name:  v.Name(),
+			param: paramName(v.Name()),
+			typ:   target.TypeString(v.Type()),
+		})
+	}
+	return fields
+}
+
+// hasDefault reports whether a field can be left at its zero value
+func hasDefault(v *types.Var, tag reflect.StructTag) bool {
+	if _, ok := tag.Lookup("default"); ok {
+		return true
+	}
+	switch v.Type().Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return true
+	}
+	return false
+}
+
+// paramName derives a parameter name from a field name, avoiding keywords
+func paramName(fieldName string) string {
+	name := utils.LowerFirst(fieldName)
+	if isKeyword(name) {
+		return name + "_"
+	}
+	return name
+}
+
+func isKeyword(name string) bool {
+	switch name {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough",
+		"for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range",
+		"return", "select", "struct", "switch", "type", "var":
+		return true
+	}
+	return false
+}
+
+func plainConstructor(target *codegen.Target, constructor string, fields []field) string {
+	typeName := target.Obj.Name()
+	typeParams, typeArgs := target.TypeParams()
+
+	params := make([]string, 0, len(fields))
+	assigns := make([]string, 0, len(fields))
+	for _, f := range fields {
+		params = append(params, f.param+" "+f.typ)
+		assigns = append(assigns, fmt.Sprintf("\t\t%s: %s,", f.name, f.param))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s creates a new %s.\n", constructor, typeName)
+	fmt.Fprintf(&b, "func %s%s(%s) *%s%s {\n", constructor, typeParams, strings.Join(params, ", "), typeName, typeArgs)
+	if len(assigns) == 0 {
+		fmt.Fprintf(&b, "\treturn &%s%s{}\n}\n", typeName, typeArgs)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\treturn &%s%s{\n%s\n\t}\n}\n", typeName, typeArgs, strings.Join(assigns, "\n"))
+	return b.String()
+}
+
+func optionsConstructor(target *codegen.Target, constructor, optionType string, fields []field) string {
+	typeName := target.Obj.Name()
+	typeParams, typeArgs := target.TypeParams()
+	receiver := strings.ToLower(typeName[:1])
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s configures a %s created by %s.\n", optionType, typeName, constructor)
+	fmt.Fprintf(&b, "type %s%s func(*%s%s)\n\n", optionType, typeParams, typeName, typeArgs)
+
+	for _, f := range fields {
+		option := "With" + utils.ToPascal(f.name)
+		if target.Exists(option) {
+			continue
+		}
+		fmt.Fprintf(&b, "// %s sets the %s field.\n", option, f.name)
+		fmt.Fprintf(&b, "func %s%s(%s %s) %s%s {\n", option, typeParams, f.param, f.typ, optionType, typeArgs)
+		fmt.Fprintf(&b, "\treturn func(%s *%s%s) {\n\t\t%s.%s = %s\n\t}\n}\n\n", receiver, typeName, typeArgs, receiver, f.name, f.param)
+	}
+
+	fmt.Fprintf(&b, "// %s creates a new %s configured by opts.\n", constructor, typeName)
+	fmt.Fprintf(&b, "func %s%s(opts ...%s%s) *%s%s {\n", constructor, typeParams, optionType, typeArgs, typeName, typeArgs)
+	fmt.Fprintf(&b, "\t%s := &%s%s{}\n", receiver, typeName, typeArgs)
+	fmt.Fprintf(&b, "\tfor _, opt := range opts {\n\t\topt(%s)\n\t}\n", receiver)
+	fmt.Fprintf(&b, "\treturn %s\n}\n", receiver)
+	return b.String()
+}
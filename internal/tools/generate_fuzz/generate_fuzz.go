@@ -0,0 +1,344 @@
+package generate_fuzz
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "GenerateFuzzTarget",
+		Description: "Generate a FuzzXxx target for a function whose parameters are types go test -fuzz supports (string, []byte, bool, integers, floats, runes and named types based on them), with a seed corpus entry. Run it with RunFuzz.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file declaring the function",
+				},
+				"function": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the package-level function to fuzz",
+				},
+				"seeds": map[string]interface{}{
+					"type":        "array",
+					"description": "Seed corpus entries, each the comma-separated Go arguments of one f.Add call, e.g. [\"\\\"a,b\\\", 2\"]. Defaults to one entry of zero values.",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"output": map[string]interface{}{
+					"type":        "string",
+					"description": "Test file to add the target to (defaults to <file>_fuzz_test.go next to the source file). An existing file is appended to.",
+				},
+			},
+			Required: []string{"file", "function"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		name, err := request.RequireString("function")
+		if err != nil {
+			return nil, err
+		}
+		file, err = filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+
+		pkgs, err := typecheck.Load(ctx, filepath.Dir(file), ".")
+		if err != nil {
+			return nil, err
+		}
+		if len(pkgs) == 0 || pkgs[0].Types == nil {
+			return nil, fmt.Errorf("no package found for %s", file)
+		}
+		pkg := pkgs[0]
+		fn, ok := pkg.Types.Scope().Lookup(name).(*types.Func)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a package-level function of %s", name, pkg.ImportPath)
+		}
+		sig := fn.Type().(*types.Signature)
+		if sig.TypeParams().Len() > 0 {
+			return nil, fmt.Errorf("%s is generic; fuzz an instantiation through a wrapper function instead", name)
+		}
+
+		output := request.GetString("output", "")
+		if output == "" {
+			output = strings.TrimSuffix(file, ".go") + "_fuzz_test.go"
+		}
+		output = manager.ResolvePath(output)
+		if filepath.Dir(output) != filepath.Dir(file) {
+			return nil, fmt.Errorf("output must be in %s, next to the function it fuzzes", filepath.Dir(file))
+		}
+
+		target := "Fuzz" + upperFirst(name)
+		if path := declaredInTests(filepath.Dir(output), target); path != "" {
+			return nil, fmt.Errorf("%s is already declared in %s", target, path)
+		}
+
+		imports := codegen.NewImports(pkg.ImportPath)
+		imports.Add("testing", "testing")
+		body, err := fuzzTarget(target, fn, sig, imports, request.GetStringSlice("seeds", nil))
+		if err != nil {
+			return nil, err
+		}
+
+		existing, err := os.ReadFile(output)
+		created := os.IsNotExist(err)
+		var src []byte
+		switch {
+		case created:
+			src = codegen.NewFile("", pkg.Name, imports, body)
+		case err != nil:
+			return nil, err
+		default:
+			src, err = appendTarget(existing, pkg.Name, imports, body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to add to %s: %w", output, err)
+			}
+		}
+		if err := codegen.WriteFile(manager.FileHeader(), output, src); err != nil {
+			return nil, err
+		}
+
+		msg := fmt.Sprintf("Generated %s in %s. Run it with RunFuzz or: cd %s && go test -run '^$' -fuzz '^%s$' -fuzztime 30s .",
+			target, output, filepath.Dir(output), target)
+		if err := manager.NotifyFileWritten(ctx, output, created); err != nil {
+			msg += fmt.Sprintf("\nNote: gopls was not notified of the change: %v", err)
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+// fuzzTarget renders the fuzz function. Parameters of named types are fuzzed
+// as their underlying type and converted in the call.
+func fuzzTarget(target string, fn *types.Func, sig *types.Signature, imports *codegen.Imports, seeds []string) (string, error) {
+	params := sig.Params()
+	var fuzzParams, args, zeros, unsupported []string
+	taken := map[string]bool{"t": true, "f": true}
+	for i := 0; i < params.Len(); i++ {
+		param := params.At(i)
+		basic, ok := fuzzType(param.Type())
+		if !ok || (sig.Variadic() && i == params.Len()-1) {
+			unsupported = append(unsupported, fmt.Sprintf("%s %s", param.Name(), types.TypeString(param.Type(), imports.Qualifier)))
+			continue
+		}
+		name := param.Name()
+		if name == "" || name == "_" || taken[name] {
+			name = fmt.Sprintf("p%d", i)
+		}
+		taken[name] = true
+		fuzzParams = append(fuzzParams, name+" "+basic)
+		arg := name
+		if types.TypeString(param.Type(), nil) != basic {
+			arg = fmt.Sprintf("%s(%s)", types.TypeString(param.Type(), imports.Qualifier), name)
+		}
+		args = append(args, arg)
+		zeros = append(zeros, zeroValue(basic))
+	}
+	if len(unsupported) > 0 {
+		return "", fmt.Errorf("go test -fuzz cannot generate %s; fuzz a wrapper that builds them from supported types", strings.Join(unsupported, ", "))
+	}
+	if len(fuzzParams) == 0 {
+		return "", fmt.Errorf("%s takes no parameters, so there is nothing to fuzz", fn.Name())
+	}
+
+	if len(seeds) == 0 {
+		seeds = []string{strings.Join(zeros, ", ")}
+	}
+	for _, seed := range seeds {
+		if _, err := parser.ParseExpr("f(" + seed + ")"); err != nil {
+			return "", fmt.Errorf("seed %q is not a list of Go arguments: %w", seed, err)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "func %s(f *testing.F) {\n", target)
+	for _, seed := range seeds {
+		fmt.Fprintf(&b, "\tf.Add(%s)\n", seed)
+	}
+	fmt.Fprintf(&b, "\tf.Fuzz(func(t *testing.T, %s) {\n", strings.Join(fuzzParams, ", "))
+	call := fmt.Sprintf("%s(%s)", fn.Name(), strings.Join(args, ", "))
+	results := sig.Results()
+	if n := results.Len(); n > 0 && types.Identical(results.At(n-1).Type(), types.Universe.Lookup("error").Type()) {
+		// Inputs the function rejects are fine; check what it accepts
+		vars := make([]string, n)
+		for i := range vars {
+			vars[i] = "_"
+		}
+		vars[n-1] = "err"
+		if n > 1 {
+			vars[0] = "got"
+		}
+		fmt.Fprintf(&b, "\t\t%s := %s\n\t\tif err != nil {\n\t\t\treturn\n\t\t}\n", strings.Join(vars, ", "), call)
+		if n > 1 {
+			b.WriteString("\t\t_ = got // TODO: check properties that must hold for every accepted input\n")
+		}
+	} else {
+		fmt.Fprintf(&b, "\t\t%s\n", call)
+	}
+	b.WriteString("\t})\n}\n")
+	return b.String(), nil
+}
+
+// fuzzType returns the type the fuzzing engine generates for typ: the
+// underlying basic type, or []byte
+func fuzzType(typ types.Type) (string, bool) {
+	switch u := typ.Underlying().(type) {
+	case *types.Basic:
+		switch u.Kind() {
+		case types.String, types.Bool,
+			types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
+			types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64,
+			types.Float32, types.Float64:
+			return u.Name(), true
+		}
+	case *types.Slice:
+		if elem, ok := u.Elem().Underlying().(*types.Basic); ok && elem.Kind() == types.Byte {
+			return "[]byte", true
+		}
+	}
+	return "", false
+}
+
+func zeroValue(basic string) string {
+	switch basic {
+	case "string":
+		return `""`
+	case "bool":
+		return "false"
+	case "[]byte":
+		return "[]byte{}"
+	case "int":
+		return "0"
+	case "float64":
+		return "0.0"
+	}
+	// f.Add needs the exact type of each parameter
+	return basic + "(0)"
+}
+
+// declaredInTests returns the test file in dir declaring name, if any
+func declaredInTests(dir, name string) string {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	for _, path := range paths {
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == name {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// appendTarget adds body to the end of an existing test file, with an import
+// declaration for the packages it does not import yet
+func appendTarget(existing []byte, pkgName string, imports *codegen.Imports, body string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", existing, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+	// The target calls the function unqualified
+	if file.Name.Name != pkgName {
+		return nil, fmt.Errorf("it is in package %s rather than %s", file.Name.Name, pkgName)
+	}
+	missing := codegen.NewImports("")
+	for importPath, name := range imports.Names() {
+		if !imported(file, importPath, name) {
+			missing.Add(importPath, name)
+		}
+	}
+
+	names := missing.Names()
+	paths := make([]string, 0, len(names))
+	for importPath := range names {
+		paths = append(paths, importPath)
+	}
+	sort.Strings(paths)
+	var specs strings.Builder
+	for _, importPath := range paths {
+		if name := names[importPath]; name != path.Base(importPath) {
+			fmt.Fprintf(&specs, "\t%s %q\n", name, importPath)
+		} else {
+			fmt.Fprintf(&specs, "\t%q\n", importPath)
+		}
+	}
+
+	// New imports join the last import declaration, or follow the package
+	// clause when there is none
+	var b bytes.Buffer
+	at := fset.Position(file.Name.End()).Offset
+	var last *ast.GenDecl
+	if n := len(file.Decls); n > 0 {
+		last = file.Decls[n-1].(*ast.GenDecl)
+	}
+	switch {
+	case specs.Len() == 0:
+		b.Write(existing[:at])
+	case last == nil:
+		b.Write(existing[:at])
+		b.WriteString("\n\nimport (\n" + specs.String() + ")")
+	case last.Lparen.IsValid():
+		at = fset.Position(last.Rparen).Offset
+		b.Write(existing[:at])
+		b.WriteString(specs.String())
+	default:
+		// Turn import "x" into a block holding the new imports too
+		start := fset.Position(last.Pos()).Offset
+		at = fset.Position(last.End()).Offset
+		b.Write(existing[:start])
+		b.WriteString("import (\n\t" + string(existing[fset.Position(last.Specs[0].Pos()).Offset:at]) + "\n" + specs.String() + ")")
+	}
+	b.Write(existing[at:])
+	if !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	b.WriteString("\n" + body)
+	return format.Source(b.Bytes())
+}
+
+func imported(file *ast.File, importPath, name string) bool {
+	for _, spec := range file.Imports {
+		if strings.Trim(spec.Path.Value, `"`) != importPath {
+			continue
+		}
+		if spec.Name == nil || spec.Name.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,205 @@
+package generate_mock
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// generatedHeader marks files written by the built-in generator so they can
+// be safely regenerated
+const generatedHeader = "// Code generated by mcp-gopls GenerateMock; DO NOT EDIT."
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "GenerateMock",
+		Description: "Generate a mock implementation of an interface, either as a struct with function fields or by running mockgen or moq, and write it to a package",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file declaring the interface",
+				},
+				"interface": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the interface to mock",
+				},
+				"outputDir": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory of the package to write the mock into (defaults to the interface's package; relative paths are resolved against the workspace root)",
+				},
+				"mockName": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the generated mock type (defaults to <Interface>Mock, or whatever the external generator chooses)",
+				},
+				"generator": map[string]interface{}{
+					"type":        "string",
+					"description": "'funcs' for a built-in struct with function fields, or 'mockgen' or 'moq' to run that tool if installed",
+					"enum":        []string{"funcs", "mockgen", "moq"},
+					"default":     "funcs",
+				},
+			},
+			Required: []string{"file", "interface"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		ifaceName, err := request.RequireString("interface")
+		if err != nil {
+			return nil, err
+		}
+		generator := request.GetString("generator", "funcs")
+		if generator != "funcs" && generator != "mockgen" && generator != "moq" {
+			return nil, fmt.Errorf("invalid generator %q: must be 'funcs', 'mockgen' or 'moq'", generator)
+		}
+
+		target, err := codegen.FindType(ctx, file, ifaceName)
+		if err != nil {
+			return nil, err
+		}
+		iface, err := target.Interface()
+		if err != nil {
+			return nil, err
+		}
+
+		outputDir := filepath.Dir(target.Path)
+		if dir := request.GetString("outputDir", ""); dir != "" {
+			outputDir = manager.ResolvePath(dir)
+		}
+		pkgPath, pkgName, err := codegen.OutputPackage(outputDir, target)
+		if err != nil {
+			return nil, err
+		}
+
+		output := filepath.Join(outputDir, utils.ToSnake(ifaceName)+"_mock.go")
+		existed := false
+		if existing, err := os.ReadFile(output); err == nil {
+			if generator == "funcs" && !bytes.Contains(existing, []byte(generatedHeader)) {
+				return nil, fmt.Errorf("%s already exists and was not generated by GenerateMock", output)
+			}
+			existed = true
+		}
+
+		mockName := request.GetString("mockName", "")
+		switch generator {
+		case "funcs":
+			if mockName == "" {
+				mockName = ifaceName + "Mock"
+			}
+			if pkgPath == target.Pkg.ImportPath && !existed && target.Exists(mockName) {
+				return nil, fmt.Errorf("%s is already declared in package %s", mockName, target.Pkg.Name)
+			}
+			src, err := generate(target, iface, pkgPath, pkgName, mockName)
+			if err != nil {
+				return nil, err
+			}
+			if err := codegen.WriteFile(manager.FileHeader(), output, src); err != nil {
+				return nil, err
+			}
+		case "mockgen":
+			args := []string{"-destination", output, "-package", pkgName}
+			if mockName != "" {
+				args = append(args, "-mock_names", ifaceName+"="+mockName)
+			}
+			args = append(args, target.Pkg.ImportPath, ifaceName)
+			if err := runGenerator(ctx, target.Pkg.Dir, "mockgen", args...); err != nil {
+				return nil, err
+			}
+		case "moq":
+			args := []string{"-out", output, "-pkg", pkgName, target.Pkg.Dir}
+			if mockName != "" {
+				args = append(args, ifaceName+":"+mockName)
+			} else {
+				args = append(args, ifaceName)
+			}
+			if err := runGenerator(ctx, outputDir, "moq", args...); err != nil {
+				return nil, err
+			}
+		}
+
+		msg := fmt.Sprintf("Generated mock of %s using %s in %s (package %s)", ifaceName, generator, output, pkgName)
+		if err := manager.NotifyFileWritten(ctx, output, !existed); err != nil {
+			msg += fmt.Sprintf("\nNote: gopls was not notified of the new file: %v", err)
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+// generate renders a mock with one function field per method, each method
+// forwarding to its field and panicking if it has not been set
+func generate(target *codegen.Target, iface *types.Interface, pkgPath, pkgName, mockName string) ([]byte, error) {
+	imports := codegen.NewImports(pkgPath)
+	samePkg := pkgPath == target.Pkg.ImportPath
+
+	methods := codegen.InterfaceMethods(iface, imports.Qualifier, "m")
+	for _, method := range methods {
+		if !method.Exported && !samePkg {
+			return nil, fmt.Errorf("%s has unexported method %s and can only be mocked in package %s", target.Obj.Name(), method.Name, target.Pkg.ImportPath)
+		}
+	}
+
+	named := target.Obj.Type().(*types.Named)
+	typeParams, typeArgs := codegen.TypeParamList(named, imports.Qualifier)
+	ifaceRef := target.Obj.Name()
+	if !samePkg {
+		ifaceRef = imports.Qualifier(target.Pkg.Types) + "." + ifaceRef
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "// %s is a mock implementation of %s.\n", mockName, ifaceRef)
+	fmt.Fprintf(&body, "type %s%s struct {\n", mockName, typeParams)
+	for _, method := range methods {
+		fmt.Fprintf(&body, "\t%sFunc func%s\n", method.Name, method.Signature())
+	}
+	body.WriteString("}\n\n")
+
+	if typeParams == "" {
+		fmt.Fprintf(&body, "var _ %s = (*%s)(nil)\n\n", ifaceRef, mockName)
+	}
+
+	for _, method := range methods {
+		fmt.Fprintf(&body, "// %s calls %sFunc.\n", method.Name, method.Name)
+		fmt.Fprintf(&body, "func (m *%s%s) %s%s {\n", mockName, typeArgs, method.Name, method.Signature())
+		fmt.Fprintf(&body, "\tif m.%sFunc == nil {\n\t\tpanic(\"%s.%s called but %sFunc is not set\")\n\t}\n", method.Name, mockName, method.Name, method.Name)
+		if len(method.Results) > 0 {
+			fmt.Fprintf(&body, "\treturn m.%sFunc(%s)\n}\n\n", method.Name, method.Args())
+		} else {
+			fmt.Fprintf(&body, "\tm.%sFunc(%s)\n}\n\n", method.Name, method.Args())
+		}
+	}
+
+	return codegen.NewFile(generatedHeader, pkgName, imports, body.String()), nil
+}
+
+// runGenerator runs an external mock generator, reporting its output when it fails
+func runGenerator(ctx context.Context, dir, name string, args ...string) error {
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		return fmt.Errorf("%s is not installed or not in PATH; use generator 'funcs' or install it", name)
+	}
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w\n%s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
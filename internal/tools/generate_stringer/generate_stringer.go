@@ -0,0 +1,186 @@
+package generate_stringer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// generatedHeader marks files written by this tool so they can be safely regenerated
+const generatedHeader = "// Code generated by mcp-gopls GenerateStringer; DO NOT EDIT."
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "GenerateStringer",
+		Description: "Generate a String() method (and optionally a parse function) for an enum-like integer type in a separate <type>_string.go file, like the stringer tool",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file declaring the type",
+				},
+				"type": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the enum type",
+				},
+				"trimPrefix": map[string]interface{}{
+					"type":        "string",
+					"description": "Prefix to remove from constant names in the generated strings",
+				},
+				"parse": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also generate a Parse<Type>(string) function that maps names back to values",
+					"default":     false,
+				},
+				"output": map[string]interface{}{
+					"type":        "string",
+					"description": "Output file path (defaults to <type>_string.go next to the declaring file)",
+				},
+			},
+			Required: []string{"file", "type"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		typeName, err := request.RequireString("type")
+		if err != nil {
+			return nil, err
+		}
+
+		target, err := codegen.FindType(ctx, file, typeName)
+		if err != nil {
+			return nil, err
+		}
+
+		basic, ok := target.Obj.Type().Underlying().(*types.Basic)
+		if !ok || basic.Info()&types.IsInteger == 0 {
+			return nil, fmt.Errorf("%s is not an integer type", typeName)
+		}
+		if target.HasMethod("String") {
+			return nil, fmt.Errorf("%s already has a String method", typeName)
+		}
+
+		parse := request.GetBool("parse", false)
+		parseName := "Parse" + utils.ToPascal(typeName)
+		if parse && target.Exists(parseName) {
+			return nil, fmt.Errorf("%s is already declared in package %s", parseName, target.Pkg.Name)
+		}
+
+		values := enumValues(target)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("no constants of type %s found in package %s", typeName, target.Pkg.Name)
+		}
+
+		output := request.GetString("output", "")
+		if output == "" {
+			output = filepath.Join(filepath.Dir(target.Path), strings.ToLower(typeName)+"_string.go")
+		}
+		output = manager.ResolvePath(output)
+
+		existed := false
+		if existing, err := os.ReadFile(output); err == nil {
+			if !bytes.Contains(existing, []byte(generatedHeader)) {
+				return nil, fmt.Errorf("%s already exists and was not generated by GenerateStringer", output)
+			}
+			existed = true
+		}
+
+		src := generate(target, values, request.GetString("trimPrefix", ""), basic.Info()&types.IsUnsigned != 0, parse)
+		if err := codegen.WriteFile(manager.FileHeader(), output, src); err != nil {
+			return nil, err
+		}
+
+		msg := fmt.Sprintf("Generated String() for %s with %d value(s) in %s", typeName, len(values), output)
+		if parse {
+			msg += fmt.Sprintf(" (including %s)", parseName)
+		}
+		if err := manager.NotifyFileWritten(ctx, output, !existed); err != nil {
+			msg += fmt.Sprintf("\nNote: gopls was not notified of the new file: %v", err)
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+// enumValues returns the target's constants in value order. Aliases that
+// repeat a value cannot share a case, so only the first declared is kept
+func enumValues(target *codegen.Target) []typecheck.EnumValue {
+	key := target.Pkg.ImportPath + "." + target.Obj.Name()
+	var values []typecheck.EnumValue
+	for _, enum := range typecheck.FindEnums([]*typecheck.Package{target.Pkg}, target.Obj.Name(), false) {
+		if enum.Type != key {
+			continue
+		}
+		index := make(map[string]int)
+		for _, value := range enum.Values {
+			if value.Name == "_" {
+				continue
+			}
+			i, seen := index[value.Value]
+			if !seen {
+				index[value.Value] = len(values)
+				values = append(values, value)
+			} else if declaredBefore(value, values[i]) {
+				values[i] = value
+			}
+		}
+	}
+	return values
+}
+
+func declaredBefore(a, b typecheck.EnumValue) bool {
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	return a.Line < b.Line
+}
+
+func generate(target *codegen.Target, values []typecheck.EnumValue, trimPrefix string, unsigned, parse bool) []byte {
+	typeName := target.Obj.Name()
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\n\npackage %s\n\n", generatedHeader, target.Pkg.Name)
+	if parse {
+		b.WriteString("import (\n\t\"fmt\"\n\t\"strconv\"\n)\n\n")
+	} else {
+		b.WriteString("import \"strconv\"\n\n")
+	}
+
+	fmt.Fprintf(&b, "func (i %s) String() string {\n\tswitch i {\n", typeName)
+	for _, value := range values {
+		fmt.Fprintf(&b, "\tcase %s:\n\t\treturn %q\n", value.Name, strings.TrimPrefix(value.Name, trimPrefix))
+	}
+	if unsigned {
+		fmt.Fprintf(&b, "\tdefault:\n\t\treturn \"%s(\" + strconv.FormatUint(uint64(i), 10) + \")\"\n\t}\n}\n", typeName)
+	} else {
+		fmt.Fprintf(&b, "\tdefault:\n\t\treturn \"%s(\" + strconv.FormatInt(int64(i), 10) + \")\"\n\t}\n}\n", typeName)
+	}
+
+	if parse {
+		parseName := "Parse" + utils.ToPascal(typeName)
+		fmt.Fprintf(&b, "\n// %s returns the %s whose String() is s.\n", parseName, typeName)
+		fmt.Fprintf(&b, "func %s(s string) (%s, error) {\n\tswitch s {\n", parseName, typeName)
+		for _, value := range values {
+			fmt.Fprintf(&b, "\tcase %q:\n\t\treturn %s, nil\n", strings.TrimPrefix(value.Name, trimPrefix), value.Name)
+		}
+		fmt.Fprintf(&b, "\t}\n\treturn 0, fmt.Errorf(\"invalid %s %%q\", s)\n}\n", typeName)
+	}
+	return b.Bytes()
+}
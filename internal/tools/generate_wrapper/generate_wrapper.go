@@ -0,0 +1,141 @@
+package generate_wrapper
+
+import (
+	"context"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "GenerateWrapper",
+		Description: "Generate a decorator type for an interface that embeds an instance of it and forwards every method, ready for adding logging, metrics or other cross-cutting behaviour",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file declaring the interface",
+				},
+				"interface": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the interface to wrap",
+				},
+				"wrapperName": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the generated wrapper type (defaults to <Interface>Wrapper)",
+				},
+				"outputDir": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory of the package to write the wrapper into (defaults to the interface's package; relative paths are resolved against the workspace root)",
+				},
+			},
+			Required: []string{"file", "interface"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		ifaceName, err := request.RequireString("interface")
+		if err != nil {
+			return nil, err
+		}
+
+		target, err := codegen.FindType(ctx, file, ifaceName)
+		if err != nil {
+			return nil, err
+		}
+		iface, err := target.Interface()
+		if err != nil {
+			return nil, err
+		}
+
+		outputDir := filepath.Dir(target.Path)
+		if dir := request.GetString("outputDir", ""); dir != "" {
+			outputDir = manager.ResolvePath(dir)
+		}
+		pkgPath, pkgName, err := codegen.OutputPackage(outputDir, target)
+		if err != nil {
+			return nil, err
+		}
+
+		wrapperName := request.GetString("wrapperName", ifaceName+"Wrapper")
+		if pkgPath == target.Pkg.ImportPath && target.Exists(wrapperName) {
+			return nil, fmt.Errorf("%s is already declared in package %s", wrapperName, target.Pkg.Name)
+		}
+
+		// The wrapper is meant to be edited by hand, so never overwrite a file
+		output := filepath.Join(outputDir, utils.ToSnake(wrapperName)+".go")
+		if _, err := os.Stat(output); err == nil {
+			return nil, fmt.Errorf("%s already exists", output)
+		}
+
+		src, skipped := generate(target, iface, pkgPath, pkgName, wrapperName)
+		if err := codegen.WriteFile(manager.FileHeader(), output, src); err != nil {
+			return nil, err
+		}
+
+		msg := fmt.Sprintf("Generated %s wrapping %s in %s (package %s)", wrapperName, ifaceName, output, pkgName)
+		if len(skipped) > 0 {
+			msg += fmt.Sprintf("\nUnexported methods are promoted from the embedded %s rather than forwarded: %s", ifaceName, strings.Join(skipped, ", "))
+		}
+		if err := manager.NotifyFileWritten(ctx, output, true); err != nil {
+			msg += fmt.Sprintf("\nNote: gopls was not notified of the new file: %v", err)
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+// generate renders the wrapper type, its constructor and one forwarding
+// method per interface method. Unexported methods of an interface in another
+// package cannot be declared there and are left to promotion via embedding.
+func generate(target *codegen.Target, iface *types.Interface, pkgPath, pkgName, wrapperName string) ([]byte, []string) {
+	imports := codegen.NewImports(pkgPath)
+	samePkg := pkgPath == target.Pkg.ImportPath
+
+	named := target.Obj.Type().(*types.Named)
+	typeParams, typeArgs := codegen.TypeParamList(named, imports.Qualifier)
+	ifaceRef := target.Obj.Name()
+	if !samePkg {
+		ifaceRef = imports.Qualifier(target.Pkg.Types) + "." + ifaceRef
+	}
+	field := target.Obj.Name()
+	constructor := "New" + utils.ToPascal(wrapperName)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s wraps a %s, forwarding every call to it.\n", wrapperName, ifaceRef)
+	fmt.Fprintf(&b, "type %s%s struct {\n\t%s%s\n}\n\n", wrapperName, typeParams, ifaceRef, typeArgs)
+	fmt.Fprintf(&b, "// %s returns a %s that forwards to next.\n", constructor, wrapperName)
+	fmt.Fprintf(&b, "func %s%s(next %s%s) *%s%s {\n\treturn &%s%s{%s: next}\n}\n\n",
+		constructor, typeParams, ifaceRef, typeArgs, wrapperName, typeArgs, wrapperName, typeArgs, field)
+
+	var skipped []string
+	for _, method := range codegen.InterfaceMethods(iface, imports.Qualifier, "w") {
+		if !method.Exported && !samePkg {
+			skipped = append(skipped, method.Name)
+			continue
+		}
+		fmt.Fprintf(&b, "func (w *%s%s) %s%s {\n", wrapperName, typeArgs, method.Name, method.Signature())
+		if len(method.Results) > 0 {
+			fmt.Fprintf(&b, "\treturn w.%s.%s(%s)\n}\n\n", field, method.Name, method.Args())
+		} else {
+			fmt.Fprintf(&b, "\tw.%s.%s(%s)\n}\n\n", field, method.Name, method.Args())
+		}
+	}
+
+	return codegen.NewFile("", pkgName, imports, b.String()), skipped
+}
@@ -0,0 +1,117 @@
+package go_env
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "GoEnv",
+		Description: "Report the Go environment for the workspace: go version, go env, module/workspace files and whether dependencies still need downloading",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"full": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Include the complete 'go env' output instead of the most relevant variables",
+					"default":     false,
+				},
+			},
+		},
+	}
+}
+
+// relevantEnv lists the go env variables most often behind gopls load failures
+var relevantEnv = []string{
+	"GOROOT", "GOPATH", "GOMODCACHE", "GOFLAGS", "GOOS", "GOARCH",
+	"GOPROXY", "GOPRIVATE", "GONOSUMDB", "GOWORK", "GOMOD", "CGO_ENABLED", "GOTOOLCHAIN",
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		full := request.GetBool("full", false)
+		root := manager.WorkspaceRoot()
+
+		report := map[string]interface{}{
+			"workspaceRoot": root,
+		}
+
+		if version, err := gocmd.Output(ctx, root, "version"); err == nil {
+			report["goVersion"] = version
+		} else {
+			report["goVersionError"] = err.Error()
+		}
+
+		envJSON, err := gocmd.Output(ctx, root, "env", "-json")
+		if err != nil {
+			report["goEnvError"] = err.Error()
+		} else {
+			var env map[string]string
+			if err := json.Unmarshal([]byte(envJSON), &env); err != nil {
+				report["goEnvError"] = err.Error()
+			} else if full {
+				report["goEnv"] = env
+			} else {
+				selected := make(map[string]string)
+				for _, key := range relevantEnv {
+					if value, ok := env[key]; ok {
+						selected[key] = value
+					}
+				}
+				report["goEnv"] = selected
+			}
+		}
+
+		report["hasGoMod"] = fileExists(filepath.Join(root, "go.mod"))
+		report["hasGoWork"] = fileExists(filepath.Join(root, "go.work"))
+
+		if report["hasGoMod"].(bool) || report["hasGoWork"].(bool) {
+			missing, err := missingModules(ctx, root)
+			if err != nil {
+				report["moduleStatusError"] = err.Error()
+			} else {
+				report["missingModules"] = missing
+				report["downloadNeeded"] = len(missing) > 0
+			}
+		}
+
+		result, _ := json.MarshalIndent(report, "", "  ")
+		return mcp.NewToolResultText(string(result)), nil
+	}
+}
+
+// missingModules lists modules providing packages the workspace imports that
+// are not in the module cache, without touching the network
+func missingModules(ctx context.Context, root string) ([]string, error) {
+	result, err := gocmd.Run(ctx, root, []string{"GOPROXY=off"},
+		"list", "-e", "-deps", "-f", "{{with .Module}}{{if not .Main}}{{.Path}}@{{.Version}} {{.Dir}}{{end}}{{end}}", "./...")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	missing := make([]string, 0)
+	for _, line := range strings.Split(result.Stdout, "\n") {
+		fields := strings.Fields(line)
+		// No directory means the module has not been downloaded
+		if len(fields) == 1 && !seen[fields[0]] {
+			seen[fields[0]] = true
+			missing = append(missing, fields[0])
+		}
+	}
+	return missing, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
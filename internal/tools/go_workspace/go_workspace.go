@@ -0,0 +1,179 @@
+package go_workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/analysis"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "GoWorkspace",
+		Description: "Orient yourself in the workspace in one call: its root, modules and their go directives, the Go toolchain version, package count, main packages, packages with tests, top-level directories and gopls's status. Call it first in an unfamiliar repository.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"maxPackages": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum number of main packages and of test packages to list (0 lists all)",
+					"default":     50,
+				},
+				"includeVendor": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Include vendor directories",
+					"default":     false,
+				},
+			},
+		},
+	}
+}
+
+// summary describes the workspace
+type summary struct {
+	Root         string       `json:"root"`
+	GoVersion    string       `json:"goVersion,omitempty"`
+	Modules      []module     `json:"modules"`
+	PackageCount int          `json:"packageCount"`
+	MainPackages []pkg        `json:"mainPackages"`
+	TestPackages []pkg        `json:"testPackages"`
+	Directories  []directory  `json:"directories"`
+	Gopls        gopls.Status `json:"gopls"`
+	// Errors are the parts of the summary that could not be assembled
+	Errors []string `json:"errors,omitempty"`
+}
+
+// module is a module of the workspace, several with a go.work
+type module struct {
+	Path      string `json:"path"`
+	Dir       string `json:"dir"`
+	GoVersion string `json:"goVersion,omitempty"`
+}
+
+type pkg struct {
+	ImportPath string `json:"importPath,omitempty"`
+	Dir        string `json:"dir"`
+	TestFiles  int    `json:"testFiles,omitempty"`
+}
+
+// directory is a top-level directory of the workspace with the number of
+// packages under it
+type directory struct {
+	Name     string `json:"name"`
+	Packages int    `json:"packages"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		root := manager.WorkspaceRoot()
+		maxPackages := request.GetInt("maxPackages", 50)
+		opts := astscan.Options{IncludeVendor: request.GetBool("includeVendor", false)}
+
+		s := summary{
+			Root:         root,
+			Modules:      []module{},
+			MainPackages: []pkg{},
+			TestPackages: []pkg{},
+			Directories:  []directory{},
+			Gopls:        manager.Status(),
+		}
+
+		if version, err := gocmd.Output(ctx, root, "version"); err == nil {
+			s.GoVersion = strings.TrimPrefix(version, "go version ")
+		} else {
+			s.Errors = append(s.Errors, err.Error())
+		}
+
+		if modules, err := listModules(ctx, root); err == nil {
+			s.Modules = modules
+		} else {
+			s.Errors = append(s.Errors, err.Error())
+		}
+
+		report, err := analysis.BuildReport(ctx, root, opts, 0)
+		if err != nil {
+			return nil, err
+		}
+		s.PackageCount = report.PackageCount
+		byTopDir := make(map[string]int)
+		for _, p := range report.Packages {
+			if p.Name == "main" && p.Files > 0 {
+				s.MainPackages = append(s.MainPackages, pkg{ImportPath: p.ImportPath, Dir: p.Dir})
+			}
+			if p.TestFiles > 0 {
+				s.TestPackages = append(s.TestPackages, pkg{ImportPath: p.ImportPath, Dir: p.Dir, TestFiles: p.TestFiles})
+			}
+			if rel, err := filepath.Rel(root, p.Dir); err == nil && rel != "." {
+				byTopDir[strings.Split(filepath.ToSlash(rel), "/")[0]]++
+			}
+		}
+		sortPackages(s.MainPackages)
+		sortPackages(s.TestPackages)
+
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+				s.Directories = append(s.Directories, directory{Name: entry.Name(), Packages: byTopDir[entry.Name()]})
+			}
+		}
+
+		header := fmt.Sprintf("%d module(s) and %d package(s), %d of them main and %d with tests",
+			len(s.Modules), s.PackageCount, len(s.MainPackages), len(s.TestPackages))
+		if maxPackages > 0 && (len(s.MainPackages) > maxPackages || len(s.TestPackages) > maxPackages) {
+			header += fmt.Sprintf("; listing at most %d of each", maxPackages)
+			s.MainPackages = s.MainPackages[:min(maxPackages, len(s.MainPackages))]
+			s.TestPackages = s.TestPackages[:min(maxPackages, len(s.TestPackages))]
+		}
+		if !s.Gopls.Initialized {
+			header += "; gopls is not running"
+		} else if s.Gopls.Problem != "" {
+			header += "; the workspace has a problem: " + s.Gopls.Problem
+		}
+
+		output, _ := json.MarshalIndent(s, "", "  ")
+		return mcp.NewToolResultText(header + ":\n" + string(output)), nil
+	}
+}
+
+// listModules returns the main modules of the workspace: the module of
+// root, or the modules used by its go.work
+func listModules(ctx context.Context, root string) ([]module, error) {
+	output, err := gocmd.Output(ctx, root, "list", "-m", "-json")
+	if err != nil {
+		return nil, err
+	}
+
+	modules := []module{}
+	decoder := json.NewDecoder(strings.NewReader(output))
+	for {
+		var m module
+		if err := decoder.Decode(&m); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list -m output: %w", err)
+		}
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+// sortPackages sorts packages by directory
+func sortPackages(pkgs []pkg) {
+	sort.Slice(pkgs, func(i, j int) bool {
+		return pkgs[i].Dir < pkgs[j].Dir
+	})
+}
@@ -3,12 +3,13 @@ package goto_definition
 import (
 	"context"
 	"encoding/json"
-	"os"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/positions"
 	"github.com/yantrio/mcp-gopls/internal/utils"
 )
 
@@ -21,7 +22,7 @@ func NewTool(manager *gopls.Manager) mcp.Tool {
 			Properties: map[string]interface{}{
 				"file": map[string]interface{}{
 					"type":        "string",
-					"description": "Absolute path to the Go source file",
+					"description": "Absolute path to the Go source file (required unless positions is given)",
 				},
 				"line": map[string]interface{}{
 					"type":        "number",
@@ -31,8 +32,8 @@ func NewTool(manager *gopls.Manager) mcp.Tool {
 					"type":        "number",
 					"description": "Column number (1-indexed)",
 				},
+				"positions": positions.Property(),
 			},
-			Required: []string{"file", "line", "column"},
 		},
 	}
 }
@@ -40,15 +41,7 @@ func NewTool(manager *gopls.Manager) mcp.Tool {
 func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Parse arguments
-		file, err := request.RequireString("file")
-		if err != nil {
-			return nil, err
-		}
-		line, err := request.RequireInt("line")
-		if err != nil {
-			return nil, err
-		}
-		column, err := request.RequireInt("column")
+		queries, multi, err := positions.FromRequest(request)
 		if err != nil {
 			return nil, err
 		}
@@ -58,53 +51,68 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 			return nil, err
 		}
 
-		uri, err := utils.PathToURI(file)
-		if err != nil {
-			return nil, err
-		}
-
-		content, err := os.ReadFile(file)
+		uris, closeAll, err := positions.Open(ctx, manager, client, queries)
 		if err != nil {
 			return nil, err
 		}
+		defer closeAll()
 
-		if err := client.OpenDocument(ctx, uri, string(content)); err != nil {
-			return nil, err
-		}
-		defer client.CloseDocument(ctx, uri)
-
-		position := utils.ConvertPosition(line, column)
-		locations, err := client.Definition(ctx, uri, position)
-		if err != nil {
-			return nil, err
+		if !multi {
+			definitions, err := findDefinitions(ctx, manager, client, uris[queries[0].File], queries[0])
+			if err != nil {
+				return nil, err
+			}
+			result, _ := json.MarshalIndent(definitions, "", "  ")
+			return mcp.NewToolResultText(string(result)), nil
 		}
 
-		definitions := make([]map[string]interface{}, 0)
-		for _, loc := range locations {
-			defPath, err := utils.URIToPath(loc.URI)
+		results := make([]positions.Result, len(queries))
+		for i, query := range queries {
+			definitions, err := findDefinitions(ctx, manager, client, uris[query.File], query)
 			if err != nil {
+				results[i].Error = err.Error()
 				continue
 			}
+			results[i].Result = definitions
+		}
+		return positions.Keyed(queries, results), nil
+	}
+}
+
+// findDefinitions returns the definitions of the symbol at a position, with
+// a preview of each definition's line
+func findDefinitions(ctx context.Context, manager *gopls.Manager, client *lsp.Client, uri string, query positions.Position) ([]map[string]interface{}, error) {
+	locations, err := client.Definition(ctx, uri, query.LSP())
+	if err != nil {
+		return nil, err
+	}
 
-			defLine, defColumn := utils.ConvertToUserPosition(loc.Range.Start)
+	definitions := make([]map[string]interface{}, 0)
+	for _, loc := range locations {
+		defPath, err := manager.URIToPath(loc.URI)
+		if err != nil {
+			continue
+		}
 
-			preview := ""
-			if defContent, err := os.ReadFile(defPath); err == nil {
-				lines := strings.Split(string(defContent), "\n")
-				if defLine <= len(lines) {
-					preview = strings.TrimSpace(lines[defLine-1])
-				}
-			}
+		defLine, defColumn := utils.ConvertToUserPosition(loc.Range.Start)
 
-			definitions = append(definitions, map[string]interface{}{
-				"file":    defPath,
-				"line":    defLine,
-				"column":  defColumn,
-				"preview": preview,
-			})
+		preview := ""
+		if line, err := utils.ReadLine(defPath, defLine); err == nil {
+			preview = strings.TrimSpace(line)
 		}
 
-		result, _ := json.MarshalIndent(definitions, "", "  ")
-		return mcp.NewToolResultText(string(result)), nil
+		definition := map[string]interface{}{
+			"file":    defPath,
+			"line":    defLine,
+			"column":  defColumn,
+			"preview": preview,
+		}
+		// Servers answering with links also give the whole declaration
+		if loc.DeclarationRange != nil {
+			definition["selectionRange"] = utils.ConvertToUserRange(loc.Range)
+			definition["declarationRange"] = utils.ConvertToUserRange(*loc.DeclarationRange)
+		}
+		definitions = append(definitions, definition)
 	}
-}
\ No newline at end of file
+	return definitions, nil
+}
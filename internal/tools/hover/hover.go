@@ -2,11 +2,18 @@ package hover
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/positions"
 	"github.com/yantrio/mcp-gopls/internal/utils"
 )
 
@@ -19,7 +26,7 @@ func NewTool(manager *gopls.Manager) mcp.Tool {
 			Properties: map[string]interface{}{
 				"file": map[string]interface{}{
 					"type":        "string",
-					"description": "Absolute path to the Go source file",
+					"description": "Absolute path to the Go source file (required unless positions is given)",
 				},
 				"line": map[string]interface{}{
 					"type":        "number",
@@ -29,56 +36,314 @@ func NewTool(manager *gopls.Manager) mcp.Tool {
 					"type":        "number",
 					"description": "Column number (1-indexed)",
 				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"description": "Output format: 'text' (gopls's markdown), 'plaintext' (markdown and links removed) or 'json'",
+					"enum":        []string{"text", "plaintext", "json"},
+					"default":     "text",
+				},
+				"stripLinks": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Remove pkg.go.dev links from markdown contents",
+					"default":     false,
+				},
+				"concise": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Return only the signature, without documentation",
+					"default":     false,
+				},
+				"docOnly": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Return only the documentation, without the signature",
+					"default":     false,
+				},
+				"positions": positions.Property(),
 			},
-			Required: []string{"file", "line", "column"},
 		},
 	}
 }
 
 func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		file, err := request.RequireString("file")
+		queries, multi, err := positions.FromRequest(request)
 		if err != nil {
 			return nil, err
 		}
-		line, err := request.RequireInt("line")
-		if err != nil {
-			return nil, err
+		opts := options{
+			format:     request.GetString("format", "text"),
+			stripLinks: request.GetBool("stripLinks", false),
+			concise:    request.GetBool("concise", false),
+			docOnly:    request.GetBool("docOnly", false),
 		}
-		column, err := request.RequireInt("column")
-		if err != nil {
-			return nil, err
+		if opts.format != "text" && opts.format != "plaintext" && opts.format != "json" {
+			return nil, fmt.Errorf("invalid format %q: must be 'text', 'plaintext' or 'json'", opts.format)
+		}
+		if opts.concise && opts.docOnly {
+			return nil, fmt.Errorf("concise and docOnly cannot both be set")
 		}
 
 		client, err := manager.GetClient()
 		if err != nil {
 			return nil, err
 		}
-		uri, err := utils.PathToURI(file)
+		uris, closeAll, err := positions.Open(ctx, manager, client, queries)
 		if err != nil {
 			return nil, err
 		}
-		content, err := os.ReadFile(file)
-		if err != nil {
-			return nil, err
+		defer closeAll()
+
+		if !multi {
+			info, err := lookup(ctx, manager, client, uris[queries[0].File], queries[0].LSP(), opts)
+			if err != nil {
+				return nil, err
+			}
+			return mcp.NewToolResultText(opts.render(info)), nil
 		}
 
-		err = client.OpenDocument(ctx, uri, string(content))
-		if err != nil {
-			return nil, err
+		results := make([]positions.Result, len(queries))
+		for i, query := range queries {
+			info, err := lookup(ctx, manager, client, uris[query.File], query.LSP(), opts)
+			switch {
+			case err != nil:
+				results[i].Error = err.Error()
+			case info != nil && opts.format == "json":
+				results[i].Result = info
+			default:
+				results[i].Result = opts.render(info)
+			}
 		}
-		defer client.CloseDocument(ctx, uri)
+		return positions.Keyed(queries, results), nil
+	}
+}
 
-		position := utils.ConvertPosition(line, column)
-		hover, err := client.Hover(ctx, uri, position)
-		if err != nil {
-			return nil, err
+// options are the output settings of a hover call
+type options struct {
+	format     string
+	stripLinks bool
+	concise    bool
+	docOnly    bool
+}
+
+// text reports whether the result is text rather than JSON
+func (o options) text() bool {
+	return o.format != "json"
+}
+
+// trimmed reports whether only part of the hover contents is returned
+func (o options) trimmed() bool {
+	return o.concise || o.docOnly
+}
+
+// lookup returns the hover information at a position, or nil when gopls
+// has none
+func lookup(ctx context.Context, manager *gopls.Manager, client *lsp.Client, uri string, position lsp.Position, opts options) (*symbolInfo, error) {
+	hover, err := client.Hover(ctx, uri, position)
+	if err != nil {
+		return nil, err
+	}
+
+	if hover == nil || hover.Contents.Value == "" {
+		return nil, nil
+	}
+
+	contents := hover.Contents.Value
+	switch {
+	case opts.concise:
+		contents = signature(contents)
+	case opts.docOnly:
+		contents = documentation(contents)
+	}
+
+	switch {
+	case opts.format == "plaintext":
+		contents = utils.MarkdownToPlainText(contents)
+	case opts.stripLinks:
+		contents = utils.StripLinks(contents)
+	}
+
+	info := &symbolInfo{
+		Contents: contents,
+		Kind:     symbolKind(hover.Contents.Value),
+	}
+
+	// In text mode the trimmed variants are returned as-is to keep output small
+	if opts.text() && opts.trimmed() {
+		return info, nil
+	}
+
+	// Resolve the definition to report where the symbol lives and its name
+	if locations, err := client.Definition(ctx, uri, position); err == nil && len(locations) > 0 {
+		loc := locations[0]
+		if defPath, err := manager.URIToPath(loc.URI); err == nil {
+			defLine, defColumn := utils.ConvertToUserPosition(loc.Range.Start)
+			info.Definition = &definitionInfo{File: defPath, Line: defLine, Column: defColumn}
+			info.Name = identifierAt(defPath, loc.Range)
+			if info.Name != "" {
+				exported := isExported(info.Name)
+				info.Exported = &exported
+			}
+		}
+	}
+	return info, nil
+}
+
+// render formats hover information as the tool's text result
+func (o options) render(info *symbolInfo) string {
+	if info == nil || (o.text() && o.trimmed() && info.Contents == "") {
+		return "No hover information available"
+	}
+	if o.text() && o.trimmed() {
+		return info.Contents
+	}
+	if o.format == "json" {
+		result, _ := json.MarshalIndent(info, "", "  ")
+		return string(result)
+	}
+	return info.String()
+}
+
+// symbolInfo is the enriched hover result
+type symbolInfo struct {
+	Name       string          `json:"name,omitempty"`
+	Kind       string          `json:"kind,omitempty"`
+	Exported   *bool           `json:"exported,omitempty"`
+	Definition *definitionInfo `json:"definition,omitempty"`
+	Contents   string          `json:"contents"`
+}
+
+type definitionInfo struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// String renders the hover contents followed by a short summary of the symbol
+func (s symbolInfo) String() string {
+	var b strings.Builder
+	b.WriteString(s.Contents)
+
+	var details []string
+	if s.Kind != "" {
+		details = append(details, fmt.Sprintf("Kind: %s", s.Kind))
+	}
+	if s.Definition != nil {
+		details = append(details, fmt.Sprintf("Definition: %s:%d:%d", s.Definition.File, s.Definition.Line, s.Definition.Column))
+	}
+	if s.Exported != nil {
+		details = append(details, fmt.Sprintf("Exported: %t", *s.Exported))
+	}
+	if len(details) > 0 {
+		b.WriteString("\n\n")
+		b.WriteString(strings.Join(details, "\n"))
+	}
+	return b.String()
+}
+
+// symbolKind infers the kind of symbol from the signature in gopls hover contents
+func symbolKind(contents string) string {
+	signature := signatureLine(contents)
+	fields := strings.Fields(signature)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	switch fields[0] {
+	case "func":
+		if strings.HasPrefix(signature, "func (") {
+			return "method"
+		}
+		return "function"
+	case "type":
+		if len(fields) >= 3 {
+			switch {
+			case strings.HasPrefix(fields[2], "struct"):
+				return "struct"
+			case strings.HasPrefix(fields[2], "interface"):
+				return "interface"
+			}
 		}
+		return "type"
+	case "var":
+		return "variable"
+	case "const":
+		return "constant"
+	case "field":
+		return "field"
+	case "package":
+		return "package"
+	default:
+		return ""
+	}
+}
+
+// signatureLine returns the first line of the first go code block in hover contents
+func signatureLine(contents string) string {
+	lines := strings.Split(contents, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") && i+1 < len(lines) {
+			return strings.TrimSpace(lines[i+1])
+		}
+	}
+	if len(lines) > 0 {
+		return strings.TrimSpace(lines[0])
+	}
+	return ""
+}
+
+// signature returns the contents of the first code block in hover contents
+func signature(contents string) string {
+	lines := strings.Split(contents, "\n")
+	start := -1
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "```") {
+			continue
+		}
+		if start < 0 {
+			start = i + 1
+			continue
+		}
+		return strings.TrimSpace(strings.Join(lines[start:i], "\n"))
+	}
+	// No code block, fall back to the first line
+	return signatureLine(contents)
+}
 
-		if hover == nil {
-			return mcp.NewToolResultText("No hover information available"), nil
+// documentation returns hover contents with the leading signature code block removed
+func documentation(contents string) string {
+	lines := strings.Split(contents, "\n")
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "```") {
+		return strings.TrimSpace(contents)
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "```") {
+			return strings.TrimSpace(strings.Join(lines[i+1:], "\n"))
 		}
+	}
+	return ""
+}
 
-		return mcp.NewToolResultText(hover.Contents.Value), nil
+// identifierAt reads the identifier covered by a range in a file
+func identifierAt(path string, r lsp.Range) string {
+	if r.Start.Line != r.End.Line {
+		return ""
 	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(content), "\n")
+	if r.Start.Line >= len(lines) {
+		return ""
+	}
+	line := lines[r.Start.Line]
+	if r.Start.Character > r.End.Character || r.End.Character > len(line) {
+		return ""
+	}
+	return line[r.Start.Character:r.End.Character]
+}
+
+// isExported reports whether a Go identifier is exported
+func isExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
 }
\ No newline at end of file
@@ -0,0 +1,209 @@
+package import_index
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/index"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+)
+
+// maxStale is the number of changed files listed by name
+const maxStale = 20
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "ImportIndex",
+		Description: "Load a workspace index written by ExportIndex. Reports which files changed since the export, restores its diagnostics as a DiffDiagnostics checkpoint, and answers symbol and package lookups from the index without waiting for gopls to load the workspace.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Index file written by ExportIndex, absolute or relative to the workspace root",
+				},
+				"checkpoint": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the DiffDiagnostics checkpoint to restore the index's diagnostics as; empty to skip",
+					"default":     "index",
+				},
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "List the symbols whose name contains this text, case-insensitively",
+				},
+				"kind": map[string]interface{}{
+					"type":        "string",
+					"description": "Only list symbols of this kind",
+					"enum":        []string{"function", "method", "struct", "interface", "type", "constant", "variable"},
+				},
+				"package": map[string]interface{}{
+					"type":        "string",
+					"description": "Show the package with this import path or directory, with its imports and the workspace packages that import it",
+				},
+				"maxResults": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum number of symbols to list",
+					"default":     50,
+				},
+			},
+			Required: []string{"file"},
+		},
+	}
+}
+
+type symbolResult struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Receiver string `json:"receiver,omitempty"`
+	Package  string `json:"package"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+type packageResult struct {
+	Dir        string   `json:"dir"`
+	ImportPath string   `json:"importPath,omitempty"`
+	Name       string   `json:"name"`
+	Files      int      `json:"files"`
+	Imports    []string `json:"imports"`
+	ImportedBy []string `json:"importedBy"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		snapshot, err := index.Read(manager.ResolvePath(file))
+		if err != nil {
+			return nil, err
+		}
+		root := manager.WorkspaceRoot()
+
+		var sections []string
+		summary := fmt.Sprintf("Index of %s from %s: %d package(s), %d symbol(s)",
+			snapshot.Root, snapshot.CreatedAt.Format("2006-01-02 15:04:05 MST"), len(snapshot.Packages), len(snapshot.Symbols))
+		if snapshot.Diagnostics != nil {
+			summary += fmt.Sprintf(", %d error(s) and %d warning(s)", snapshot.Diagnostics.Errors, snapshot.Diagnostics.Warnings)
+		}
+		sections = append(sections, summary)
+
+		if stale := snapshot.Stale(root); len(stale) > 0 {
+			listed := stale
+			if len(listed) > maxStale {
+				listed = listed[:maxStale]
+			}
+			text := fmt.Sprintf("%d file(s) changed or were deleted since the export, so their entries may be out of date: %s", len(stale), strings.Join(listed, ", "))
+			if len(stale) > len(listed) {
+				text += ", ..."
+			}
+			sections = append(sections, text)
+		}
+
+		if name := request.GetString("checkpoint", "index"); name != "" && snapshot.Diagnostics != nil {
+			// gopls may still be starting; the checkpoint is optional
+			if client, err := manager.GetClient(); err == nil {
+				client.RestoreDiagnosticsCheckpoints(map[string]lsp.DiagnosticsSnapshot{name: snapshot.Checkpoint(root)})
+				sections = append(sections, fmt.Sprintf("Restored the index's diagnostics as checkpoint %q; DiffDiagnostics with that checkpoint compares the current diagnostics with them", name))
+			} else {
+				sections = append(sections, fmt.Sprintf("The diagnostics checkpoint was not restored: %v", err))
+			}
+		}
+
+		if pkg := request.GetString("package", ""); pkg != "" {
+			result, err := findPackage(snapshot, root, pkg)
+			if err != nil {
+				return nil, err
+			}
+			output, _ := json.MarshalIndent(result, "", "  ")
+			sections = append(sections, "Package:\n"+string(output))
+		}
+
+		query, kind := request.GetString("query", ""), request.GetString("kind", "")
+		if query != "" || kind != "" {
+			maxResults := request.GetInt("maxResults", 50)
+			matches := make([]symbolResult, 0)
+			total := 0
+			for _, symbol := range snapshot.Symbols {
+				if kind != "" && symbol.Kind != kind {
+					continue
+				}
+				if query != "" && !strings.Contains(strings.ToLower(symbol.Name), strings.ToLower(query)) {
+					continue
+				}
+				total++
+				if maxResults > 0 && len(matches) >= maxResults {
+					continue
+				}
+				matches = append(matches, symbolResult{
+					Name:     symbol.Name,
+					Kind:     symbol.Kind,
+					Receiver: symbol.Receiver,
+					Package:  symbol.Package,
+					File:     filepath.Join(root, filepath.FromSlash(symbol.File)),
+					Line:     symbol.Line,
+					Column:   symbol.Column,
+				})
+			}
+			output, _ := json.MarshalIndent(matches, "", "  ")
+			header := fmt.Sprintf("%d matching symbol(s)", total)
+			if total > len(matches) {
+				header += fmt.Sprintf(", showing %d", len(matches))
+			}
+			sections = append(sections, header+":\n"+string(output))
+		}
+
+		return mcp.NewToolResultText(strings.Join(sections, "\n\n")), nil
+	}
+}
+
+// findPackage looks a package up by import path or directory and finds the
+// workspace packages that import it
+func findPackage(snapshot *index.Snapshot, root, name string) (*packageResult, error) {
+	dir := name
+	if filepath.IsAbs(dir) {
+		if rel, err := filepath.Rel(root, dir); err == nil {
+			dir = rel
+		}
+	}
+	dir = filepath.ToSlash(filepath.Clean(dir))
+
+	var found *index.Package
+	for _, pkg := range snapshot.Packages {
+		if pkg.ImportPath == name || pkg.Dir == dir {
+			found = pkg
+			break
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no package %s in the index", name)
+	}
+
+	result := &packageResult{
+		Dir:        found.Dir,
+		ImportPath: found.ImportPath,
+		Name:       found.Name,
+		Files:      len(found.Files),
+		Imports:    append([]string{}, found.Imports...),
+		ImportedBy: make([]string, 0),
+	}
+	if found.ImportPath != "" {
+		for _, pkg := range snapshot.Packages {
+			for _, path := range pkg.Imports {
+				if path == found.ImportPath {
+					result.ImportedBy = append(result.ImportedBy, pkg.ImportPath)
+					break
+				}
+			}
+		}
+	}
+	return result, nil
+}
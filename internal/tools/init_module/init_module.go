@@ -0,0 +1,158 @@
+package init_module
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "InitModule",
+		Description: "Create a go.mod with go mod init for a workspace that has none, which gopls needs to load packages. Without confirm it only reports the command it would run and a suggested module path; call it again with confirm set to create the module.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"module": map[string]interface{}{
+					"type":        "string",
+					"description": "Module path, e.g. github.com/acme/app. Defaults to one derived from the git remote origin, or the directory name.",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory to create go.mod in (defaults to the workspace root)",
+				},
+				"tidy": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Run go mod tidy afterwards to add the requirements of existing imports",
+					"default":     false,
+				},
+				"confirm": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Create the module. When false the tool only reports what it would do.",
+					"default":     false,
+				},
+			},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := manager.ResolvePath(request.GetString("path", ""))
+		goMod := filepath.Join(dir, "go.mod")
+		if _, err := os.Stat(goMod); err == nil {
+			return nil, fmt.Errorf("%s already exists", goMod)
+		}
+
+		module := request.GetString("module", "")
+		suggested := module == ""
+		if suggested {
+			module = suggestModulePath(dir)
+		}
+
+		if !request.GetBool("confirm", false) {
+			msg := fmt.Sprintf("Would run `go mod init %s` in %s, creating %s.", module, dir, goMod)
+			if suggested {
+				msg += " The module path was derived from the repository; pass module to choose another."
+			}
+			msg += " Call InitModule again with confirm set to true to create it."
+			return mcp.NewToolResultText(msg), nil
+		}
+
+		run, err := gocmd.Run(ctx, dir, nil, "mod", "init", module)
+		if err != nil {
+			return nil, err
+		}
+		if run.ExitCode != 0 {
+			return nil, fmt.Errorf("go mod init failed: %s", strings.TrimSpace(run.Stderr))
+		}
+
+		msg := fmt.Sprintf("Created %s for module %s", goMod, module)
+		if request.GetBool("tidy", false) {
+			tidy, err := gocmd.Run(ctx, dir, nil, "mod", "tidy")
+			switch {
+			case err != nil:
+				msg += fmt.Sprintf("\nNote: go mod tidy did not run: %v", err)
+			case tidy.ExitCode != 0:
+				msg += fmt.Sprintf("\nNote: go mod tidy failed: %s", strings.TrimSpace(tidy.Stderr))
+			default:
+				msg += " and ran go mod tidy"
+			}
+		}
+		if err := manager.NotifyFileWritten(ctx, goMod, true); err != nil {
+			msg += fmt.Sprintf("\nNote: gopls was not notified of the new go.mod: %v", err)
+		}
+		if problem := manager.RecheckWorkspace(); problem != "" {
+			msg += "\nNote: " + problem
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+// suggestModulePath derives a module path for dir from the origin remote of
+// the enclosing git repository, e.g. github.com/acme/app/tools for the tools
+// directory of a clone of git@github.com:acme/app.git, falling back to the
+// directory name
+func suggestModulePath(dir string) string {
+	for root := dir; ; root = filepath.Dir(root) {
+		if remote := originURL(filepath.Join(root, ".git", "config")); remote != "" {
+			path := remotePath(remote)
+			if rel, err := filepath.Rel(root, dir); err == nil && rel != "." {
+				path += "/" + filepath.ToSlash(rel)
+			}
+			return path
+		}
+		if _, err := os.Stat(filepath.Join(root, ".git")); err == nil || filepath.Dir(root) == root {
+			break
+		}
+	}
+	return strings.ToLower(strings.ReplaceAll(filepath.Base(dir), " ", "-"))
+}
+
+// originURL reads the url of the origin remote from a git config file
+func originURL(config string) string {
+	f, err := os.Open(config)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	inOrigin := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inOrigin = line == `[remote "origin"]`
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); inOrigin && ok && strings.TrimSpace(key) == "url" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// remotePath turns a git remote URL into a module path:
+// https://github.com/acme/app.git and git@github.com:acme/app.git both
+// become github.com/acme/app
+func remotePath(remote string) string {
+	remote = strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git")
+	if scheme, rest, ok := strings.Cut(remote, "://"); ok && scheme != "" {
+		remote = rest
+	} else if host, path, ok := strings.Cut(remote, ":"); ok {
+		remote = host + "/" + path
+	}
+	// Drop credentials such as git@ or user:token@
+	if at := strings.LastIndexByte(remote, '@'); at >= 0 && !strings.Contains(remote[:at], "/") {
+		remote = remote[at+1:]
+	}
+	return remote
+}
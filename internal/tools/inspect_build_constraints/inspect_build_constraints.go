@@ -0,0 +1,353 @@
+package inspect_build_constraints
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/buildtags"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+// maxSymbols is the number of declarations listed per excluded file
+const maxSymbols = 20
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "InspectBuildConstraints",
+		Description: "Explain which files of a package are compiled: report each file's //go:build constraint, GOOS/GOARCH file name suffix and cgo use, whether it is included under the workspace's configuration or the one given, and why excluded files are left out, with the declarations they hold. Use it when a symbol is not found because the file declaring it is excluded.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Package directory, or a file in it, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"goos": map[string]interface{}{
+					"type":        "string",
+					"description": "Target operating system (defaults to GOOS of go env)",
+				},
+				"goarch": map[string]interface{}{
+					"type":        "string",
+					"description": "Target architecture (defaults to GOARCH of go env)",
+				},
+				"tags": map[string]interface{}{
+					"type":        "array",
+					"description": "Build tags to set (defaults to the -tags of GOFLAGS)",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"cgo": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Whether cgo is enabled (defaults to CGO_ENABLED of go env)",
+				},
+			},
+		},
+	}
+}
+
+type fileReport struct {
+	File string `json:"file"`
+	// Constraint is the //go:build expression, or the // +build lines of
+	// older files combined into one
+	Constraint string `json:"constraint,omitempty"`
+	GOOS       string `json:"goosSuffix,omitempty"`
+	GOARCH     string `json:"goarchSuffix,omitempty"`
+	Cgo        bool   `json:"cgo,omitempty"`
+	Test       bool   `json:"test,omitempty"`
+	Included   bool   `json:"included"`
+	Reason     string `json:"reason,omitempty"`
+	// Symbols are the package-level declarations of an excluded file
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+type configuration struct {
+	GOOS   string   `json:"goos"`
+	GOARCH string   `json:"goarch"`
+	Tags   []string `json:"tags"`
+	Cgo    bool     `json:"cgo"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := manager.ResolvePath(request.GetString("path", ""))
+		if info, err := os.Stat(dir); err != nil {
+			return nil, err
+		} else if !info.IsDir() {
+			dir = filepath.Dir(dir)
+		}
+
+		cfg := defaultConfiguration(ctx, manager.WorkspaceRoot())
+		args := request.GetArguments()
+		if goos := request.GetString("goos", ""); goos != "" {
+			cfg.GOOS = goos
+		}
+		if goarch := request.GetString("goarch", ""); goarch != "" {
+			cfg.GOARCH = goarch
+		}
+		if _, ok := args["tags"]; ok {
+			cfg.Tags = request.GetStringSlice("tags", nil)
+		}
+		if _, ok := args["cgo"]; ok {
+			cfg.Cgo = request.GetBool("cgo", cfg.Cgo)
+		}
+		if !buildtags.KnownOS[cfg.GOOS] {
+			return nil, fmt.Errorf("unknown GOOS %q", cfg.GOOS)
+		}
+		if !buildtags.KnownArch[cfg.GOARCH] {
+			return nil, fmt.Errorf("unknown GOARCH %q", cfg.GOARCH)
+		}
+
+		ctxt := build.Default
+		ctxt.GOOS, ctxt.GOARCH, ctxt.CgoEnabled, ctxt.BuildTags = cfg.GOOS, cfg.GOARCH, cfg.Cgo, cfg.Tags
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		files := make([]fileReport, 0)
+		included := 0
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+				continue
+			}
+			report, err := inspect(&ctxt, cfg, dir, entry.Name())
+			if err != nil {
+				return nil, err
+			}
+			if report.Included {
+				included++
+			}
+			files = append(files, report)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no Go files in %s", dir)
+		}
+
+		result, _ := json.MarshalIndent(map[string]interface{}{
+			"configuration": cfg,
+			"files":         files,
+		}, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("%d of %d file(s) in %s are included for %s/%s:\n%s",
+			included, len(files), dir, cfg.GOOS, cfg.GOARCH, string(result))), nil
+	}
+}
+
+// defaultConfiguration reads the target and build tags the go command uses
+// in the workspace, falling back to those of the running binary
+func defaultConfiguration(ctx context.Context, root string) configuration {
+	cfg := configuration{GOOS: build.Default.GOOS, GOARCH: build.Default.GOARCH, Cgo: build.Default.CgoEnabled, Tags: make([]string, 0)}
+	out, err := gocmd.Output(ctx, root, "env", "-json", "GOOS", "GOARCH", "CGO_ENABLED", "GOFLAGS")
+	if err != nil {
+		return cfg
+	}
+	var env map[string]string
+	if json.Unmarshal([]byte(out), &env) != nil {
+		return cfg
+	}
+	if env["GOOS"] != "" {
+		cfg.GOOS = env["GOOS"]
+	}
+	if env["GOARCH"] != "" {
+		cfg.GOARCH = env["GOARCH"]
+	}
+	cfg.Cgo = env["CGO_ENABLED"] == "1"
+	fields := strings.Fields(env["GOFLAGS"])
+	for i, flag := range fields {
+		value, ok := strings.CutPrefix(strings.TrimPrefix(flag, "-"), "-tags=")
+		if !ok {
+			value, ok = strings.CutPrefix(strings.TrimPrefix(flag, "-"), "tags=")
+		}
+		if !ok && (flag == "-tags" || flag == "--tags") && i+1 < len(fields) {
+			value, ok = fields[i+1], true
+		}
+		if ok {
+			cfg.Tags = strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+		}
+	}
+	return cfg
+}
+
+// inspect reports on one file of dir
+func inspect(ctxt *build.Context, cfg configuration, dir, name string) (fileReport, error) {
+	path := filepath.Join(dir, name)
+	report := fileReport{File: path, Test: strings.HasSuffix(name, "_test.go")}
+	report.GOOS, report.GOARCH = buildtags.NameSuffix(name)
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return report, err
+	}
+	fset := token.NewFileSet()
+	file, parseErr := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	var expr constraint.Expr
+	if file != nil {
+		expr = buildtags.FileConstraint(file)
+		for _, spec := range file.Imports {
+			if spec.Path.Value == strconv.Quote("C") {
+				report.Cgo = true
+			}
+		}
+	}
+	if expr != nil {
+		report.Constraint = expr.String()
+	}
+
+	report.Included, err = ctxt.MatchFile(dir, name)
+	if report.Cgo && !cfg.Cgo {
+		// MatchFile leaves cgo files to the package loader, which ignores
+		// them without cgo
+		report.Included = false
+	}
+	if err != nil {
+		report.Reason = err.Error()
+	} else if !report.Included {
+		report.Reason = exclusionReason(cfg, name, report, expr)
+	} else if report.Test {
+		report.Reason = "only compiled by go test"
+	}
+
+	switch {
+	case !report.Included && file != nil:
+		report.Symbols = declarations(file)
+	case report.Included && file == nil:
+		report.Reason = fmt.Sprintf("included, but does not parse: %v", parseErr)
+	}
+	return report, nil
+}
+
+// exclusionReason explains why the go command leaves a file out
+func exclusionReason(cfg configuration, name string, report fileReport, expr constraint.Expr) string {
+	var reasons []string
+	if strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+		reasons = append(reasons, "file names starting with _ or . are ignored")
+	}
+	if report.GOOS != "" && !matchOS(report.GOOS, cfg.GOOS) {
+		reasons = append(reasons, fmt.Sprintf("the _%s file name suffix requires GOOS=%s", report.GOOS, report.GOOS))
+	}
+	if report.GOARCH != "" && report.GOARCH != cfg.GOARCH {
+		reasons = append(reasons, fmt.Sprintf("the _%s file name suffix requires GOARCH=%s", report.GOARCH, report.GOARCH))
+	}
+	if expr != nil && !expr.Eval(func(tag string) bool { return matchTag(cfg, tag) }) {
+		var unmet []string
+		for _, tag := range tags(expr) {
+			state := "unset"
+			if matchTag(cfg, tag) {
+				state = "set"
+			}
+			unmet = append(unmet, fmt.Sprintf("%s is %s", tag, state))
+		}
+		reasons = append(reasons, fmt.Sprintf("the constraint %q is not satisfied (%s)", expr.String(), strings.Join(unmet, ", ")))
+	}
+	if report.Cgo && !cfg.Cgo {
+		reasons = append(reasons, `the file imports "C" and cgo is disabled`)
+	}
+	if len(reasons) == 0 {
+		return "excluded by the go command"
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// matchTag reports whether a build tag is satisfied, following go/build
+func matchTag(cfg configuration, tag string) bool {
+	switch {
+	case tag == cfg.GOOS || tag == cfg.GOARCH:
+		return true
+	case tag == "unix":
+		return buildtags.UnixOS[cfg.GOOS]
+	case tag == "cgo":
+		return cfg.Cgo
+	case tag == "gc" || tag == "gccgo":
+		return tag == build.Default.Compiler
+	case matchOS(tag, cfg.GOOS) && tag != cfg.GOOS:
+		return true
+	}
+	return slices.Contains(cfg.Tags, tag) || slices.Contains(build.Default.ReleaseTags, tag)
+}
+
+// matchOS reports whether a GOOS name in a file name or tag is satisfied by
+// goos, which also satisfies the OS it derives from
+func matchOS(name, goos string) bool {
+	switch {
+	case name == goos:
+		return true
+	case name == "linux":
+		return goos == "android"
+	case name == "solaris":
+		return goos == "illumos"
+	case name == "darwin":
+		return goos == "ios"
+	}
+	return false
+}
+
+// tags lists the tags of a constraint in order of appearance
+func tags(expr constraint.Expr) []string {
+	var result []string
+	var walk func(constraint.Expr)
+	walk = func(e constraint.Expr) {
+		switch e := e.(type) {
+		case *constraint.TagExpr:
+			if !slices.Contains(result, e.Tag) {
+				result = append(result, e.Tag)
+			}
+		case *constraint.NotExpr:
+			walk(e.X)
+		case *constraint.AndExpr:
+			walk(e.X)
+			walk(e.Y)
+		case *constraint.OrExpr:
+			walk(e.X)
+			walk(e.Y)
+		}
+	}
+	walk(expr)
+	return result
+}
+
+// declarations lists the package-level names a file declares
+func declarations(file *ast.File) []string {
+	var names []string
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			name := decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				if recv := astscan.ReceiverType(decl.Recv.List[0].Type); recv != "" {
+					name = recv + "." + name
+				}
+			}
+			names = append(names, name)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, spec.Name.Name)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						if name.Name != "_" {
+							names = append(names, name.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(names) > maxSymbols {
+		names = append(names[:maxSymbols], fmt.Sprintf("... and %d more", len(names)-maxSymbols))
+	}
+	return names
+}
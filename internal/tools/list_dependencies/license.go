@@ -0,0 +1,70 @@
+package list_dependencies
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// licenseFiles matches the file names modules commonly keep their license in
+var licenseFiles = regexp.MustCompile(`(?i)^(licen[cs]e|copying|unlicense)([.\-_].*)?$`)
+
+// licensePatterns identifies a license from distinctive phrases in its text.
+// More specific licenses come before the ones whose text they contain.
+var licensePatterns = []struct {
+	id      string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"gnu affero general public license"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"CC0-1.0", []string{"creative commons", "cc0"}},
+}
+
+// detectLicense looks for a license file in the root of a module directory
+// and returns the identifier of the license it contains, the file's name, and
+// "unknown" when the text does not match a known license
+func detectLicense(dir string) (id, file string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", ""
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !licenseFiles.MatchString(entry.Name()) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		return classifyLicense(string(content)), entry.Name()
+	}
+	return "", ""
+}
+
+func classifyLicense(text string) string {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, pattern := range licensePatterns {
+		matched := true
+		for _, phrase := range pattern.phrases {
+			if !strings.Contains(text, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return pattern.id
+		}
+	}
+	return "unknown"
+}
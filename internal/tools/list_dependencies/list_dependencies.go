@@ -0,0 +1,139 @@
+package list_dependencies
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "ListDependencies",
+		Description: "List the modules the workspace depends on with their versions, whether each is a direct or indirect requirement, replacements, and optionally the license detected in the module cache",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"directOnly": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Only list the modules required directly by the main module",
+					"default":     false,
+				},
+				"filter": map[string]interface{}{
+					"type":        "string",
+					"description": "Only list modules whose path contains this text",
+				},
+				"licenses": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Detect each module's license from the license file in its module cache directory. Modules that are not downloaded have no license listed.",
+					"default":     false,
+				},
+			},
+		},
+	}
+}
+
+// listedModule is the JSON object printed by 'go list -m -json'
+type listedModule struct {
+	Path      string
+	Version   string
+	Main      bool
+	Indirect  bool
+	Dir       string
+	GoVersion string
+	Replace   *listedModule
+	Error     *struct{ Err string }
+}
+
+type dependency struct {
+	Path        string `json:"path"`
+	Version     string `json:"version,omitempty"`
+	Direct      bool   `json:"direct"`
+	GoVersion   string `json:"goVersion,omitempty"`
+	Replace     string `json:"replace,omitempty"`
+	Downloaded  bool   `json:"downloaded"`
+	License     string `json:"license,omitempty"`
+	LicenseFile string `json:"licenseFile,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		directOnly := request.GetBool("directOnly", false)
+		filter := request.GetString("filter", "")
+		licenses := request.GetBool("licenses", false)
+
+		result, err := gocmd.Run(ctx, manager.WorkspaceRoot(), nil, "list", "-m", "-e", "-json", "all")
+		if err != nil {
+			return nil, err
+		}
+		if result.ExitCode != 0 && strings.TrimSpace(result.Stdout) == "" {
+			return nil, fmt.Errorf("go list -m failed: %s", strings.TrimSpace(result.Stderr))
+		}
+
+		deps := make([]dependency, 0)
+		var main []string
+		direct, indirect := 0, 0
+		decoder := json.NewDecoder(strings.NewReader(result.Stdout))
+		for {
+			var mod listedModule
+			if err := decoder.Decode(&mod); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to parse go list output: %w", err)
+			}
+			if mod.Main {
+				main = append(main, mod.Path)
+				continue
+			}
+			if mod.Indirect {
+				indirect++
+			} else {
+				direct++
+			}
+			if (directOnly && mod.Indirect) || !strings.Contains(mod.Path, filter) {
+				continue
+			}
+
+			dep := dependency{
+				Path:      mod.Path,
+				Version:   mod.Version,
+				Direct:    !mod.Indirect,
+				GoVersion: mod.GoVersion,
+			}
+			dir := mod.Dir
+			if mod.Replace != nil {
+				dep.Replace = mod.Replace.Path
+				if mod.Replace.Version != "" {
+					dep.Replace += "@" + mod.Replace.Version
+				}
+				dir = mod.Replace.Dir
+			}
+			dep.Downloaded = dir != ""
+			if mod.Error != nil {
+				dep.Error = mod.Error.Err
+			}
+			if licenses && dir != "" {
+				dep.License, dep.LicenseFile = detectLicense(dir)
+				if dep.License == "" {
+					dep.License = "none found"
+				}
+			}
+			deps = append(deps, dep)
+		}
+
+		output, _ := json.MarshalIndent(map[string]interface{}{
+			"main":         main,
+			"direct":       direct,
+			"indirect":     indirect,
+			"dependencies": deps,
+		}, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("%d direct and %d indirect module dependencies (%d listed):\n%s", direct, indirect, len(deps), output)), nil
+	}
+}
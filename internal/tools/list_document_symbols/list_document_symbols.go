@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -16,13 +19,24 @@ import (
 func NewTool(manager *gopls.Manager) mcp.Tool {
 	return mcp.Tool{
 		Name:        "ListDocumentSymbols",
-		Description: "Get an outline of symbols defined in the current file",
+		Description: "Get an outline of symbols defined in a file, or in every file of a package when given a directory",
 		InputSchema: mcp.ToolInputSchema{
 			Type: "object",
 			Properties: map[string]interface{}{
 				"file": map[string]interface{}{
 					"type":        "string",
-					"description": "Absolute path to the Go source file",
+					"description": "Absolute path to the Go source file, or to a package directory",
+				},
+				"groupBy": map[string]interface{}{
+					"type":        "string",
+					"description": "How to group package symbols: 'file' or 'kind' (only used for directories)",
+					"enum":        []string{"file", "kind"},
+					"default":     "file",
+				},
+				"includeTests": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Include _test.go files when listing a package directory",
+					"default":     false,
 				},
 			},
 			Required: []string{"file"},
@@ -42,12 +56,24 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 			return nil, err
 		}
 
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			groupBy := request.GetString("groupBy", "file")
+			if groupBy != "file" && groupBy != "kind" {
+				return nil, fmt.Errorf("invalid groupBy %q: must be 'file' or 'kind'", groupBy)
+			}
+			return packageSymbols(ctx, manager, client, file, groupBy, request.GetBool("includeTests", false))
+		}
+
 		uri, err := utils.PathToURI(file)
 		if err != nil {
 			return nil, err
 		}
 
-		content, err := os.ReadFile(file)
+		content, err := manager.ReadFile(file)
 		if err != nil {
 			return nil, err
 		}
@@ -75,6 +101,155 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 	}
 }
 
+// fileSymbols holds the document symbols of a single file in a package
+type fileSymbols struct {
+	path    string
+	symbols []lsp.DocumentSymbol
+	err     error
+}
+
+// packageSymbols collects document symbols for every Go file in dir and
+// merges them into a single outline grouped by file or by kind
+func packageSymbols(ctx context.Context, manager *gopls.Manager, client *lsp.Client, dir, groupBy string, includeTests bool) (*mcp.CallToolResult, error) {
+	files, err := packageFiles(dir, includeTests)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No Go files found in %s", dir)), nil
+	}
+
+	// Request symbols for all files in parallel
+	results := make([]fileSymbols, len(files))
+	var wg sync.WaitGroup
+	for i, path := range files {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			symbols, err := documentSymbols(ctx, manager, client, path)
+			results[i] = fileSymbols{path: path, symbols: symbols, err: err}
+		}(i, path)
+	}
+	wg.Wait()
+
+	var lines []string
+	var errors []string
+	for _, r := range results {
+		if r.err != nil {
+			errors = append(errors, fmt.Sprintf("  - %s: %v", filepath.Base(r.path), r.err))
+		}
+	}
+
+	if groupBy == "kind" {
+		lines = groupSymbolsByKind(results)
+	} else {
+		for _, r := range results {
+			if r.err != nil || len(r.symbols) == 0 {
+				continue
+			}
+			lines = append(lines, filepath.Base(r.path))
+			formatSymbols(r.symbols, "", &lines)
+			lines = append(lines, "")
+		}
+	}
+
+	if len(lines) == 0 && len(errors) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("No symbols found in package %s", dir)), nil
+	}
+
+	resultMsg := fmt.Sprintf("Document symbols for package %s (%d file(s)):\n\n%s", dir, len(files), strings.TrimRight(strings.Join(lines, "\n"), "\n"))
+	if len(errors) > 0 {
+		resultMsg += "\n\nErrors:\n" + strings.Join(errors, "\n")
+	}
+	return mcp.NewToolResultText(resultMsg), nil
+}
+
+// packageFiles returns the sorted Go source files directly inside dir
+func packageFiles(dir string, includeTests bool) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if !includeTests && strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// documentSymbols opens a single file and requests its document symbols
+func documentSymbols(ctx context.Context, manager *gopls.Manager, client *lsp.Client, path string) ([]lsp.DocumentSymbol, error) {
+	uri, err := utils.PathToURI(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := manager.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.OpenDocument(ctx, uri, string(content)); err != nil {
+		return nil, err
+	}
+	defer client.CloseDocument(ctx, uri)
+
+	return client.DocumentSymbols(ctx, uri)
+}
+
+// groupSymbolsByKind flattens top-level symbols from all files and groups them by kind
+func groupSymbolsByKind(results []fileSymbols) []string {
+	type entry struct {
+		name string
+		file string
+		line int
+	}
+
+	byKind := make(map[lsp.SymbolKind][]entry)
+	for _, r := range results {
+		for _, symbol := range r.symbols {
+			startLine, _ := utils.ConvertToUserPosition(symbol.Range.Start)
+			byKind[symbol.Kind] = append(byKind[symbol.Kind], entry{
+				name: symbol.Name,
+				file: filepath.Base(r.path),
+				line: startLine,
+			})
+		}
+	}
+
+	kinds := make([]lsp.SymbolKind, 0, len(byKind))
+	for kind := range byKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+
+	var lines []string
+	for _, kind := range kinds {
+		entries := byKind[kind]
+		sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+		lines = append(lines, fmt.Sprintf("%s (%d)", getSymbolIcon(kind), len(entries)))
+		for i, e := range entries {
+			treeChar := "├── "
+			if i == len(entries)-1 {
+				treeChar = "└── "
+			}
+			lines = append(lines, fmt.Sprintf("%s%s [%s:%d]", treeChar, e.name, e.file, e.line))
+		}
+		lines = append(lines, "")
+	}
+	return lines
+}
+
 // formatSymbols recursively formats document symbols into a tree structure
 func formatSymbols(symbols []lsp.DocumentSymbol, indent string, results *[]string) {
 	for i, symbol := range symbols {
@@ -0,0 +1,64 @@
+package list_enum_values
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "ListEnumValues",
+		Description: "List all constants of a named type (enum-like values), with their values and iota pattern",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"type": map[string]interface{}{
+					"type":        "string",
+					"description": "Type name, optionally qualified by package name or import path, e.g. 'SymbolKind', 'lsp.SymbolKind' or 'net/http.ConnState'",
+				},
+				"package": map[string]interface{}{
+					"type":        "string",
+					"description": "Package pattern to search, relative to the workspace root (defaults to ./...)",
+					"default":     "./...",
+				},
+				"includeDependencies": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also search packages imported by the workspace, including the standard library",
+					"default":     false,
+				},
+			},
+			Required: []string{"type"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		typeName, err := request.RequireString("type")
+		if err != nil {
+			return nil, err
+		}
+		if typeName == "" {
+			return nil, fmt.Errorf("type cannot be empty")
+		}
+
+		pkgs, err := typecheck.Load(ctx, manager.WorkspaceRoot(), request.GetString("package", "./..."))
+		if err != nil {
+			return nil, err
+		}
+
+		enums := typecheck.FindEnums(pkgs, typeName, request.GetBool("includeDependencies", false))
+		if len(enums) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No constants found for type %s", typeName)), nil
+		}
+
+		result, _ := json.MarshalIndent(enums, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d matching type(s):\n%s", len(enums), string(result))), nil
+	}
+}
@@ -0,0 +1,187 @@
+package map_grpc_services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/resultfilter"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "MapGRPCServices",
+		Description: "Map the gRPC services of protoc-generated code in the workspace to the servers implementing them: for each service, its generated server interface, the types implementing it (found through gopls's implementation search and the embedded Unimplemented server) and, per RPC method, the location of each server's implementation and which servers leave it unimplemented.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Only map services generated under this directory, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"service": map[string]interface{}{
+					"type":        "string",
+					"description": "Only map the service with this name, either fully qualified as in the .proto file (helloworld.Greeter) or not (Greeter)",
+				},
+				"includeTests": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Include servers declared in test files, such as fakes",
+					"default":     false,
+				},
+				"includeVendor": resultfilter.IncludeVendorProperty(manager),
+			},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		root := manager.ResolvePath(request.GetString("path", ""))
+		name := request.GetString("service", "")
+		opts := astscan.Options{
+			IncludeTests:  request.GetBool("includeTests", false),
+			IncludeVendor: request.GetBool("includeVendor", manager.IncludeVendor()),
+		}
+
+		// Servers may be declared anywhere in the workspace
+		w := newWorkspace()
+		err := astscan.Walk(manager.WorkspaceRoot(), opts, func(f *astscan.File) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			w.scan(f)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		services := make([]*service, 0)
+		for _, s := range w.services {
+			iface := w.types[s.dir][s.Interface]
+			if iface == nil || !iface.iface || !within(root, s.dir) {
+				continue
+			}
+			if name != "" && s.Name != name && !strings.HasSuffix(s.Name, "."+name) {
+				continue
+			}
+			s.DefinedAt = iface.location
+			services = append(services, s)
+		}
+		if len(services) == 0 {
+			if name != "" {
+				return mcp.NewToolResultText(fmt.Sprintf("No gRPC service %s found in %s", name, root)), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("No generated gRPC services found in %s", root)), nil
+		}
+
+		note := ""
+		client, err := manager.GetClient()
+		if err != nil {
+			note = fmt.Sprintf("\nOnly servers embedding the generated Unimplemented server were found: %v", err)
+		}
+		servers := 0
+		for _, s := range services {
+			found := w.embedders(s)
+			if client != nil {
+				implementers, err := w.implementers(ctx, manager, client, s)
+				if err != nil {
+					note += "\n" + err.Error()
+				}
+				found = append(found, implementers...)
+			}
+			s.Servers = dedupe(found)
+			servers += len(s.Servers)
+
+			for _, m := range s.Methods {
+				for _, srv := range s.Servers {
+					if loc, ok := w.methods[srv.dir][srv.Type][m.Name]; ok {
+						m.Implementations = append(m.Implementations, &implementation{Server: srv.Type, location: loc})
+					} else {
+						m.NotImplementedBy = append(m.NotImplementedBy, srv.Type)
+					}
+				}
+			}
+		}
+		sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+
+		output, _ := json.MarshalIndent(services, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d gRPC service(s) with %d server implementation(s):\n%s%s", len(services), servers, output, note)), nil
+	}
+}
+
+// implementers returns the types gopls finds implementing the server
+// interface of s, leaving out generated code and interfaces
+func (w *workspace) implementers(ctx context.Context, manager *gopls.Manager, client *lsp.Client, s *service) ([]*implementer, error) {
+	uri, err := utils.PathToURI(s.DefinedAt.File)
+	if err != nil {
+		return nil, err
+	}
+	content, err := manager.ReadFile(s.DefinedAt.File)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.OpenDocument(ctx, uri, string(content)); err != nil {
+		return nil, err
+	}
+	defer client.CloseDocument(ctx, uri)
+
+	locations, err := client.Implementation(ctx, uri, utils.ConvertPosition(s.DefinedAt.Line, s.DefinedAt.Column))
+	if err != nil {
+		return nil, fmt.Errorf("implementation request failed for %s: %w", s.Interface, err)
+	}
+
+	var servers []*implementer
+	for _, loc := range locations {
+		path, err := manager.URIToPath(loc.URI)
+		if err != nil || isGenerated(path) {
+			continue
+		}
+		line, column := utils.ConvertToUserPosition(loc.Range.Start)
+		dir := filepath.Dir(path)
+		for name, t := range w.types[dir] {
+			if !t.iface && t.File == path && t.Line == line && t.Column == column {
+				servers = append(servers, &implementer{Type: name, location: t.location, dir: dir})
+				break
+			}
+		}
+	}
+	return servers, nil
+}
+
+// dedupe drops servers found twice and sorts the rest by location
+func dedupe(servers []*implementer) []*implementer {
+	seen := make(map[location]bool)
+	unique := make([]*implementer, 0, len(servers))
+	for _, srv := range servers {
+		if seen[srv.location] {
+			continue
+		}
+		seen[srv.location] = true
+		srv.ImportPath, _ = codegen.ImportPath(srv.dir)
+		unique = append(unique, srv)
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		if unique[i].File != unique[j].File {
+			return unique[i].File < unique[j].File
+		}
+		return unique[i].Line < unique[j].Line
+	})
+	return unique
+}
+
+// within reports whether dir is root or below it
+func within(root, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,296 @@
+package map_grpc_services
+
+import (
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+)
+
+// grpcPath is the import path of the gRPC package generated code uses
+const grpcPath = "google.golang.org/grpc"
+
+// service is a gRPC service of generated code and the servers implementing
+// it in the workspace
+type service struct {
+	// Name is the full name of the service in its .proto file
+	Name      string         `json:"service"`
+	Interface string         `json:"interface"`
+	Package   string         `json:"package,omitempty"`
+	DefinedAt location       `json:"definedAt"`
+	Servers   []*implementer `json:"servers"`
+	Methods   []*method      `json:"methods"`
+
+	dir string
+}
+
+type method struct {
+	Name string `json:"name"`
+	// Kind is unary, server-streaming, client-streaming or bidi-streaming
+	Kind            string            `json:"kind"`
+	Implementations []*implementation `json:"implementations"`
+	// NotImplementedBy lists the servers declaring no method of this name,
+	// which leaves it to an embedded type, normally the generated
+	// Unimplemented server answering codes.Unimplemented
+	NotImplementedBy []string `json:"notImplementedBy,omitempty"`
+}
+
+// implementer is a type implementing the server interface of a service
+type implementer struct {
+	Type       string `json:"type"`
+	ImportPath string `json:"importPath,omitempty"`
+	location
+
+	dir string
+}
+
+type implementation struct {
+	Server string `json:"server"`
+	location
+}
+
+type location struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// typeDecl is a type declared in the workspace
+type typeDecl struct {
+	location
+	iface bool
+	// embeds are the embedded fields of a struct, as written
+	embeds []ast.Expr
+	file   *astscan.File
+}
+
+// workspace is what the scan of the workspace found
+type workspace struct {
+	services []*service
+	// types and methods are the types declared in each directory and the
+	// methods of each receiver type, by name
+	types   map[string]map[string]*typeDecl
+	methods map[string]map[string]map[string]location
+}
+
+func newWorkspace() *workspace {
+	return &workspace{
+		types:   make(map[string]map[string]*typeDecl),
+		methods: make(map[string]map[string]map[string]location),
+	}
+}
+
+// scan records the declarations of f, and the services it describes if it
+// is generated gRPC code
+func (w *workspace) scan(f *astscan.File) {
+	dir := filepath.Dir(f.Path)
+	if w.types[dir] == nil {
+		w.types[dir] = make(map[string]*typeDecl)
+		w.methods[dir] = make(map[string]map[string]location)
+	}
+	for _, decl := range f.AST.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil || len(decl.Recv.List) == 0 {
+				continue
+			}
+			recv := astscan.ReceiverType(decl.Recv.List[0].Type)
+			if w.methods[dir][recv] == nil {
+				w.methods[dir][recv] = make(map[string]location)
+			}
+			w.methods[dir][recv][decl.Name.Name] = at(f, decl.Name.Pos())
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				t := &typeDecl{location: at(f, spec.Name.Pos()), file: f}
+				switch typ := spec.Type.(type) {
+				case *ast.InterfaceType:
+					t.iface = true
+				case *ast.StructType:
+					for _, field := range typ.Fields.List {
+						if len(field.Names) == 0 {
+							t.embeds = append(t.embeds, field.Type)
+						}
+					}
+				}
+				w.types[dir][spec.Name.Name] = t
+			}
+		}
+	}
+
+	grpcName := f.ImportName(grpcPath)
+	if grpcName == "" {
+		return
+	}
+	ast.Inspect(f.AST, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok || !isSelector(lit.Type, grpcName, "ServiceDesc") {
+			return true
+		}
+		if s := serviceOf(lit, dir); s != nil {
+			w.services = append(w.services, s)
+		}
+		return false
+	})
+}
+
+// serviceOf returns the service a grpc.ServiceDesc literal describes, with
+// the server interface named by its HandlerType
+func serviceOf(lit *ast.CompositeLit, dir string) *service {
+	s := &service{dir: dir, Servers: make([]*implementer, 0), Methods: make([]*method, 0)}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, _ := kv.Key.(*ast.Ident)
+		if key == nil {
+			continue
+		}
+		switch key.Name {
+		case "ServiceName":
+			s.Name = stringValue(kv.Value)
+		case "HandlerType":
+			// (*GreeterServer)(nil)
+			if call, ok := kv.Value.(*ast.CallExpr); ok {
+				if paren, ok := call.Fun.(*ast.ParenExpr); ok {
+					if star, ok := paren.X.(*ast.StarExpr); ok {
+						if id, ok := star.X.(*ast.Ident); ok {
+							s.Interface = id.Name
+						}
+					}
+				}
+			}
+		case "Methods", "Streams":
+			list, ok := kv.Value.(*ast.CompositeLit)
+			if !ok {
+				continue
+			}
+			for _, elt := range list.Elts {
+				if desc, ok := elt.(*ast.CompositeLit); ok {
+					if m := methodOf(desc); m != nil {
+						s.Methods = append(s.Methods, m)
+					}
+				}
+			}
+		}
+	}
+	if s.Name == "" || s.Interface == "" {
+		return nil
+	}
+	s.Package, _ = codegen.ImportPath(dir)
+	return s
+}
+
+// methodOf returns the method a grpc.MethodDesc or grpc.StreamDesc literal
+// describes
+func methodOf(desc *ast.CompositeLit) *method {
+	m := &method{Kind: "unary", Implementations: make([]*implementation, 0)}
+	var serverStreams, clientStreams bool
+	for _, elt := range desc.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, _ := kv.Key.(*ast.Ident)
+		if key == nil {
+			continue
+		}
+		switch key.Name {
+		case "MethodName", "StreamName":
+			m.Name = stringValue(kv.Value)
+		case "ServerStreams":
+			serverStreams = isTrue(kv.Value)
+		case "ClientStreams":
+			clientStreams = isTrue(kv.Value)
+		}
+	}
+	switch {
+	case serverStreams && clientStreams:
+		m.Kind = "bidi-streaming"
+	case serverStreams:
+		m.Kind = "server-streaming"
+	case clientStreams:
+		m.Kind = "client-streaming"
+	}
+	if m.Name == "" {
+		return nil
+	}
+	return m
+}
+
+// embedders returns the struct types that embed the generated
+// Unimplemented or Unsafe server of s, which gRPC requires of servers
+func (w *workspace) embedders(s *service) []*implementer {
+	wanted := map[string]bool{"Unimplemented" + s.Interface: true, "Unsafe" + s.Interface: true}
+	var servers []*implementer
+	for dir, types := range w.types {
+		for name, t := range types {
+			if isGenerated(t.File) {
+				continue
+			}
+			for _, embed := range t.embeds {
+				if star, ok := embed.(*ast.StarExpr); ok {
+					embed = star.X
+				}
+				switch embed := embed.(type) {
+				case *ast.Ident:
+					// Declared next to the generated code
+					if dir != s.dir || !wanted[embed.Name] {
+						continue
+					}
+				case *ast.SelectorExpr:
+					pkg, ok := embed.X.(*ast.Ident)
+					if !ok || !wanted[embed.Sel.Name] || s.Package == "" || t.file.ImportName(s.Package) != pkg.Name {
+						continue
+					}
+				default:
+					continue
+				}
+				servers = append(servers, &implementer{Type: name, location: t.location, dir: dir})
+				break
+			}
+		}
+	}
+	return servers
+}
+
+// isGenerated reports whether path is protoc output
+func isGenerated(path string) bool {
+	return strings.HasSuffix(path, ".pb.go")
+}
+
+func at(f *astscan.File, pos token.Pos) location {
+	line, column := f.Position(pos)
+	return location{File: f.Path, Line: line, Column: column}
+}
+
+func isSelector(expr ast.Expr, pkg, name string) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == pkg && sel.Sel.Name == name
+}
+
+func stringValue(expr ast.Expr) string {
+	if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		if value, err := strconv.Unquote(lit.Value); err == nil {
+			return value
+		}
+	}
+	return ""
+}
+
+func isTrue(expr ast.Expr) bool {
+	id, ok := expr.(*ast.Ident)
+	return ok && id.Name == "true"
+}
@@ -0,0 +1,169 @@
+package map_http_routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"path/filepath"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/resultfilter"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "MapHTTPRoutes",
+		Description: "Map the HTTP routes registered in the workspace to their handlers: routes of net/http's ServeMux (including Go 1.22 method patterns), chi, gin and echo, with route groups and chi sub-routers resolved to full paths. Each route has its method, path, the handler expression and the location of the handler function. Use it instead of grepping for HandleFunc to find the code serving an endpoint.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory tree to scan, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"framework": map[string]interface{}{
+					"type":        "string",
+					"description": "Only list routes of one framework",
+					"enum":        []string{"net/http", "chi", "gin", "echo"},
+				},
+				"resolveHandlers": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Ask gopls for the location of handlers that are methods or functions of other packages; functions of the registering package are found without it",
+					"default":     true,
+				},
+				"includeVendor": resultfilter.IncludeVendorProperty(manager),
+			},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		root := manager.ResolvePath(request.GetString("path", ""))
+		framework := request.GetString("framework", "")
+		opts := astscan.Options{IncludeVendor: request.GetBool("includeVendor", manager.IncludeVendor())}
+
+		routes := make([]*route, 0)
+		// The functions of each package, to find handlers by name
+		funcs := make(map[string]map[string]location)
+		err := astscan.Walk(root, opts, func(f *astscan.File) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			dir := filepath.Dir(f.Path)
+			if funcs[dir] == nil {
+				funcs[dir] = make(map[string]location)
+			}
+			for _, decl := range f.AST.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+					line, column := f.Position(fn.Name.Pos())
+					funcs[dir][fn.Name.Name] = location{File: f.Path, Line: line, Column: column}
+				}
+			}
+			for _, r := range scanFile(f) {
+				if framework == "" || r.Framework == framework {
+					routes = append(routes, r)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(routes) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No HTTP routes found in %s", root)), nil
+		}
+
+		var unresolved []*route
+		for _, r := range routes {
+			if r.HandlerAt != nil || r.resolveAt == nil {
+				continue
+			}
+			if at, ok := funcs[filepath.Dir(r.RegisteredAt.File)][r.funcName]; ok && r.funcName != "" {
+				r.HandlerAt = &at
+				continue
+			}
+			unresolved = append(unresolved, r)
+		}
+		note := ""
+		if len(unresolved) > 0 && request.GetBool("resolveHandlers", true) {
+			if err := resolve(ctx, manager, unresolved); err != nil {
+				note = fmt.Sprintf("\nHandlers that are methods or in other packages were not located: %v", err)
+			}
+		}
+
+		sort.SliceStable(routes, func(i, j int) bool {
+			a, b := routes[i], routes[j]
+			if a.Path != b.Path {
+				return a.Path < b.Path
+			}
+			if a.Method != b.Method {
+				return a.Method < b.Method
+			}
+			if a.RegisteredAt.File != b.RegisteredAt.File {
+				return a.RegisteredAt.File < b.RegisteredAt.File
+			}
+			return a.RegisteredAt.Line < b.RegisteredAt.Line
+		})
+		files := make(map[string]bool)
+		for _, r := range routes {
+			files[r.RegisteredAt.File] = true
+		}
+
+		output, _ := json.MarshalIndent(routes, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d route(s) registered in %d file(s):\n%s%s", len(routes), len(files), output, note)), nil
+	}
+}
+
+// resolve sets the handler location of routes from the definition gopls
+// finds for their handler expression
+func resolve(ctx context.Context, manager *gopls.Manager, routes []*route) error {
+	client, err := manager.GetClient()
+	if err != nil {
+		return err
+	}
+
+	uris := make(map[string]string)
+	defer func() {
+		for _, uri := range uris {
+			client.CloseDocument(ctx, uri)
+		}
+	}()
+	for _, r := range routes {
+		file := r.resolveAt.File
+		uri, ok := uris[file]
+		if !ok {
+			uri, err = utils.PathToURI(file)
+			if err != nil {
+				return err
+			}
+			content, err := manager.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if err := client.OpenDocument(ctx, uri, string(content)); err != nil {
+				return err
+			}
+			uris[file] = uri
+		}
+
+		position := utils.ConvertPosition(r.resolveAt.Line, r.resolveAt.Column)
+		locations, err := client.Definition(ctx, uri, position)
+		if err != nil || len(locations) == 0 {
+			continue
+		}
+		path, err := manager.URIToPath(locations[0].URI)
+		if err != nil {
+			continue
+		}
+		line, column := utils.ConvertToUserPosition(locations[0].Range.Start)
+		r.HandlerAt = &location{File: path, Line: line, Column: column}
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+package map_http_routes
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+)
+
+// route is an HTTP route registered with a router
+type route struct {
+	// Method is the HTTP method, or empty when the route matches any
+	Method    string `json:"method,omitempty"`
+	Path      string `json:"path"`
+	Framework string `json:"framework"`
+	// Handler is the handler expression as written
+	Handler string `json:"handler"`
+	// Mount is set for sub-routers mounted under Path
+	Mount        bool      `json:"mount,omitempty"`
+	RegisteredAt location  `json:"registeredAt"`
+	HandlerAt    *location `json:"handlerAt,omitempty"`
+
+	// funcName is the function of the same package the handler names;
+	// otherwise resolveAt is where to ask gopls for the handler's
+	// definition
+	funcName  string
+	resolveAt *location
+}
+
+type location struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+var (
+	chiPath  = regexp.MustCompile(`^github\.com/go-chi/chi(/v[0-9]+)?$`)
+	echoPath = regexp.MustCompile(`^github\.com/labstack/echo(/v[0-9]+)?$`)
+	// majorVersion matches the last element of a major version import path
+	majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+)
+
+// frameworkOf returns the routing framework an import path provides
+func frameworkOf(path string) string {
+	switch {
+	case path == "net/http":
+		return "net/http"
+	case chiPath.MatchString(path):
+		return "chi"
+	case path == "github.com/gin-gonic/gin":
+		return "gin"
+	case echoPath.MatchString(path):
+		return "echo"
+	}
+	return ""
+}
+
+// chiMethods maps the route methods of chi.Router to HTTP methods
+var chiMethods = map[string]string{
+	"Get": "GET", "Post": "POST", "Put": "PUT", "Patch": "PATCH", "Delete": "DELETE",
+	"Head": "HEAD", "Options": "OPTIONS", "Connect": "CONNECT", "Trace": "TRACE",
+}
+
+// upperMethods are the route methods of gin and echo, named after the HTTP
+// method they match, or Any
+var upperMethods = map[string]bool{
+	"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true,
+	"HEAD": true, "OPTIONS": true, "CONNECT": true, "TRACE": true, "Any": true,
+}
+
+// adapters are the functions of each framework's package that wrap a
+// handler, with the index of the wrapped argument
+var adapters = map[string]map[string]int{
+	"net/http": {"HandlerFunc": 0, "StripPrefix": 1, "TimeoutHandler": 0, "MaxBytesHandler": 0},
+	"gin":      {"WrapF": 0, "WrapH": 0},
+	"echo":     {"WrapHandler": 0},
+}
+
+// fileScanner finds the routes registered in one file
+type fileScanner struct {
+	f *astscan.File
+	// frameworks are the routing frameworks the file imports, by the local
+	// name of their package
+	frameworks map[string]string
+	imported   map[string]bool
+	routes     []*route
+}
+
+func scanFile(f *astscan.File) []*route {
+	s := &fileScanner{f: f, frameworks: make(map[string]string), imported: make(map[string]bool)}
+	for _, imp := range f.AST.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		framework := frameworkOf(path)
+		if framework == "" {
+			continue
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if majorVersion.MatchString(name) {
+			// chi/v5 and echo/v4 are imported as chi and echo
+			trimmed := strings.TrimSuffix(path, "/"+name)
+			name = trimmed[strings.LastIndex(trimmed, "/")+1:]
+		}
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		s.frameworks[name] = framework
+		s.imported[framework] = true
+	}
+	if len(s.imported) == 0 {
+		return nil
+	}
+
+	for _, decl := range s.f.AST.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			s.scan(fn.Body, make(map[string]string))
+		}
+	}
+	return s.routes
+}
+
+// scan records the routes registered in node. prefixes holds the path
+// prefix of the routers in scope that are groups, by expression.
+func (s *fileScanner) scan(node ast.Node, prefixes map[string]string) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) == 1 && len(n.Rhs) == 1 {
+				if prefix, ok := s.groupPrefix(n.Rhs[0], prefixes); ok {
+					prefixes[types.ExprString(n.Lhs[0])] = prefix
+				}
+			}
+		case *ast.ValueSpec:
+			if len(n.Names) == 1 && len(n.Values) == 1 {
+				if prefix, ok := s.groupPrefix(n.Values[0], prefixes); ok {
+					prefixes[n.Names[0].Name] = prefix
+				}
+			}
+		case *ast.CallExpr:
+			return s.call(n, prefixes)
+		}
+		return true
+	})
+}
+
+// call records the route call registers, if any, and reports whether its
+// arguments remain to be scanned
+func (s *fileScanner) call(call *ast.CallExpr, prefixes map[string]string) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return true
+	}
+	name, args := sel.Sel.Name, call.Args
+	if id, ok := sel.X.(*ast.Ident); ok && s.frameworks[id.Name] != "" {
+		// A package-level function such as http.HandleFunc, which
+		// registers with the default mux
+		if s.frameworks[id.Name] == "net/http" && (name == "Handle" || name == "HandleFunc") && len(args) == 2 {
+			s.add("net/http", "", "", args[0], args[1], call, false)
+		}
+		return true
+	}
+
+	prefix := s.prefixOf(sel.X, prefixes)
+	switch {
+	case s.imported["chi"] && (name == "Route" || name == "Group"):
+		// Routes of the sub-router built by the function literal are under
+		// the prefix of the route, as seen through its parameter
+		if len(args) == 0 {
+			return true
+		}
+		lit, ok := args[len(args)-1].(*ast.FuncLit)
+		if !ok || lit.Type.Params.NumFields() != 1 || len(lit.Type.Params.List[0].Names) != 1 {
+			return true
+		}
+		inner := make(map[string]string, len(prefixes)+1)
+		for k, v := range prefixes {
+			inner[k] = v
+		}
+		if name == "Route" && len(args) == 2 {
+			prefix = join(prefix, s.pattern(args[0]))
+		}
+		inner[lit.Type.Params.List[0].Names[0].Name] = prefix
+		s.scan(lit.Body, inner)
+		return false
+	case s.imported["chi"] && chiMethods[name] != "" && len(args) == 2:
+		s.add("chi", chiMethods[name], prefix, args[0], args[1], call, false)
+	case s.imported["chi"] && (name == "Method" || name == "MethodFunc") && len(args) == 3:
+		s.add("chi", s.method(args[0]), prefix, args[1], args[2], call, false)
+	case s.imported["chi"] && name == "Mount" && len(args) == 2:
+		s.add("chi", "", prefix, args[0], args[1], call, true)
+	case (name == "Handle" || name == "HandleFunc") && len(args) == 2:
+		framework := "net/http"
+		if s.imported["chi"] {
+			framework = "chi"
+		}
+		s.add(framework, "", prefix, args[0], args[1], call, false)
+	case s.imported["gin"] && upperMethods[name] && len(args) >= 2:
+		// The last handler serves the request; the others are middleware
+		s.add("gin", anyMethod(name), prefix, args[0], args[len(args)-1], call, false)
+	case s.imported["gin"] && name == "Handle" && len(args) >= 3:
+		s.add("gin", s.method(args[0]), prefix, args[1], args[len(args)-1], call, false)
+	case s.imported["echo"] && upperMethods[name] && len(args) >= 2:
+		// Middleware follows the handler
+		s.add("echo", anyMethod(name), prefix, args[0], args[1], call, false)
+	case s.imported["echo"] && name == "Add" && len(args) >= 3:
+		s.add("echo", s.method(args[0]), prefix, args[1], args[2], call, false)
+	}
+	return true
+}
+
+// groupPrefix returns the path prefix of the router group expr creates,
+// if it is a call of Group of gin or echo
+func (s *fileScanner) groupPrefix(expr ast.Expr, prefixes map[string]string) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || !(s.imported["gin"] || s.imported["echo"]) {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Group" || len(call.Args) == 0 {
+		return "", false
+	}
+	return join(s.prefixOf(sel.X, prefixes), s.pattern(call.Args[0])), true
+}
+
+// prefixOf returns the path prefix of a router expression
+func (s *fileScanner) prefixOf(expr ast.Expr, prefixes map[string]string) string {
+	if prefix, ok := s.groupPrefix(expr, prefixes); ok {
+		return prefix
+	}
+	if call, ok := expr.(*ast.CallExpr); ok {
+		// chi's r.With(middleware) routes like r
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "With" {
+			return s.prefixOf(sel.X, prefixes)
+		}
+		return ""
+	}
+	return prefixes[types.ExprString(expr)]
+}
+
+// add records a route to handler registered by call under the prefix of
+// its group. A Go 1.22 ServeMux pattern such as "GET /items/{id}" carries
+// its method. Literal patterns without a slash are not routes, but calls of
+// methods with the same name, such as Get of a cache.
+func (s *fileScanner) add(framework, method, prefix string, pattern, handler ast.Expr, call *ast.CallExpr, mount bool) {
+	path := s.pattern(pattern)
+	if lit, ok := pattern.(*ast.BasicLit); ok && (lit.Kind != token.STRING || path != "" && !strings.Contains(path, "/")) {
+		return
+	}
+	if (framework == "net/http" || framework == "chi") && method == "" && !mount {
+		// Only a ServeMux takes methods in patterns, even in files that
+		// use chi too
+		if before, after, ok := strings.Cut(path, " "); ok && before != "" && strings.ToUpper(before) == before {
+			framework, method, path = "net/http", before, strings.TrimSpace(after)
+		}
+	}
+	path = join(prefix, path)
+	r := &route{Method: method, Path: path, Framework: framework, Mount: mount}
+	r.RegisteredAt = s.location(call.Pos())
+
+	handler = s.unwrap(handler)
+	r.Handler = types.ExprString(handler)
+	switch h := handler.(type) {
+	case *ast.FuncLit:
+		r.Handler = "func literal"
+		at := s.location(h.Pos())
+		r.HandlerAt = &at
+	case *ast.Ident:
+		r.funcName = h.Name
+		at := s.location(h.Pos())
+		r.resolveAt = &at
+	case *ast.SelectorExpr:
+		at := s.location(h.Sel.Pos())
+		r.resolveAt = &at
+	case *ast.CallExpr:
+		// A function building the handler, reported in its place
+		switch fun := h.Fun.(type) {
+		case *ast.Ident:
+			r.funcName = fun.Name
+			at := s.location(fun.Pos())
+			r.resolveAt = &at
+		case *ast.SelectorExpr:
+			at := s.location(fun.Sel.Pos())
+			r.resolveAt = &at
+		}
+	}
+	s.routes = append(s.routes, r)
+}
+
+// unwrap returns the handler that adapters such as http.HandlerFunc wrap
+func (s *fileScanner) unwrap(expr ast.Expr) ast.Expr {
+	for {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			return expr
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return expr
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return expr
+		}
+		index, ok := adapters[s.frameworks[pkg.Name]][sel.Sel.Name]
+		if !ok || index >= len(call.Args) {
+			return expr
+		}
+		expr = call.Args[index]
+	}
+}
+
+// pattern returns the value of a string literal route pattern, or the
+// expression as written
+func (s *fileScanner) pattern(expr ast.Expr) string {
+	if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		if value, err := strconv.Unquote(lit.Value); err == nil {
+			return value
+		}
+	}
+	return types.ExprString(expr)
+}
+
+// method returns the HTTP method an argument names, such as "GET" for
+// http.MethodGet
+func (s *fileScanner) method(expr ast.Expr) string {
+	if sel, ok := expr.(*ast.SelectorExpr); ok && strings.HasPrefix(sel.Sel.Name, "Method") {
+		return strings.ToUpper(strings.TrimPrefix(sel.Sel.Name, "Method"))
+	}
+	return s.pattern(expr)
+}
+
+func (s *fileScanner) location(pos token.Pos) location {
+	line, column := s.f.Position(pos)
+	return location{File: s.f.Path, Line: line, Column: column}
+}
+
+// anyMethod returns the HTTP method of a gin or echo route method, or empty
+// for Any
+func anyMethod(name string) string {
+	if name == "Any" {
+		return ""
+	}
+	return name
+}
+
+// join appends a route pattern to the prefix of its group
+func join(prefix, pattern string) string {
+	switch {
+	case prefix == "":
+		return pattern
+	case pattern == "":
+		return prefix
+	case !strings.HasPrefix(pattern, "/"):
+		pattern = "/" + pattern
+	}
+	return strings.TrimSuffix(prefix, "/") + pattern
+}
@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ToolStats are the call statistics for one tool
+type ToolStats struct {
+	Calls         int           `json:"calls"`
+	Errors        int           `json:"errors"`
+	TotalDuration time.Duration `json:"totalDuration"`
+	MaxDuration   time.Duration `json:"maxDuration"`
+}
+
+// Metrics counts tool calls, failures and time spent per tool
+type Metrics struct {
+	mu    sync.Mutex
+	tools map[string]*ToolStats
+}
+
+// NewMetrics returns an empty metrics collector
+func NewMetrics() *Metrics {
+	return &Metrics{tools: make(map[string]*ToolStats)}
+}
+
+// Middleware records every call made through it. Calls that return an error
+// or an error result both count as errors.
+func (m *Metrics) Middleware() Middleware {
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+			m.record(tool.Name, time.Since(start), err != nil || (result != nil && result.IsError))
+			return result, err
+		}
+	}
+}
+
+func (m *Metrics) record(name string, elapsed time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.tools[name]
+	if stats == nil {
+		stats = &ToolStats{}
+		m.tools[name] = stats
+	}
+	stats.Calls++
+	if failed {
+		stats.Errors++
+	}
+	stats.TotalDuration += elapsed
+	if elapsed > stats.MaxDuration {
+		stats.MaxDuration = elapsed
+	}
+}
+
+// Snapshot returns a copy of the statistics of every tool called so far
+func (m *Metrics) Snapshot() map[string]ToolStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]ToolStats, len(m.tools))
+	for name, stats := range m.tools {
+		snapshot[name] = *stats
+	}
+	return snapshot
+}
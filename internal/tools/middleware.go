@@ -0,0 +1,395 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// Middleware wraps a tool handler with behavior shared across tools. It is
+// given the tool's definition so it can act on the tool's input schema.
+type Middleware func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc
+
+// Chain wraps handler in middleware, the first listed being the outermost
+func Chain(tool mcp.Tool, handler server.ToolHandlerFunc, middleware ...Middleware) server.ToolHandlerFunc {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](tool, handler)
+	}
+	return handler
+}
+
+// pathArguments are the argument names that hold paths of files and
+// directories gopls analyzes
+var pathArguments = map[string]bool{
+	"file":    true,
+	"path":    true,
+	"newPath": true,
+}
+
+// outputArguments are the argument names that hold paths a tool writes to,
+// or reads settings from, which need not be in gopls's workspace
+var outputArguments = map[string]bool{
+	"output":    true,
+	"outputDir": true,
+	"config":    true,
+}
+
+// withArguments returns a copy of request with its arguments replaced
+func withArguments(request mcp.CallToolRequest, args map[string]any) mcp.CallToolRequest {
+	request.Params.Arguments = args
+	return request
+}
+
+// copyArguments returns a shallow copy of the request's arguments that is
+// safe to modify
+func copyArguments(request mcp.CallToolRequest) map[string]any {
+	args := make(map[string]any)
+	for key, value := range request.GetArguments() {
+		args[key] = value
+	}
+	return args
+}
+
+// NormalizeArguments checks that required arguments are present and coerces
+// arguments to the types the tool's schema declares, accepting numbers and
+// booleans sent as strings and a single value where an array is expected.
+// Path arguments have surrounding whitespace trimmed.
+func NormalizeArguments() Middleware {
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := copyArguments(request)
+			for _, name := range tool.InputSchema.Required {
+				if value, ok := args[name]; !ok || value == nil {
+					return nil, fmt.Errorf("missing required argument %q", name)
+				}
+			}
+
+			for name, value := range args {
+				schema, ok := tool.InputSchema.Properties[name].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				kind, _ := schema["type"].(string)
+				normalized, err := normalize(kind, value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid argument %q: %w", name, err)
+				}
+				if s, ok := normalized.(string); ok && (pathArguments[name] || outputArguments[name]) {
+					normalized = strings.TrimSpace(s)
+				}
+				args[name] = normalized
+			}
+			return next(ctx, withArguments(request, args))
+		}
+	}
+}
+
+func normalize(kind string, value any) (any, error) {
+	s, isString := value.(string)
+	switch kind {
+	case "number", "integer":
+		if isString {
+			n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("expected a number, got %q", s)
+			}
+			return n, nil
+		}
+	case "boolean":
+		if isString {
+			b, err := strconv.ParseBool(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("expected a boolean, got %q", s)
+			}
+			return b, nil
+		}
+	case "array":
+		if isString {
+			var items []any
+			if trimmed := strings.TrimSpace(s); strings.HasPrefix(trimmed, "[") && json.Unmarshal([]byte(trimmed), &items) == nil {
+				return items, nil
+			}
+			return []any{s}, nil
+		}
+		switch value.(type) {
+		case float64, bool:
+			return []any{value}, nil
+		}
+	}
+	return value, nil
+}
+
+// SandboxPaths resolves relative path arguments, and the files of a
+// positions argument, against the workspace root and rejects paths that
+// gopls cannot see, as Manager.ValidateFile does. Output and settings paths
+// are resolved the same way but only have to be a place a file can be
+// written to, see checkOutputPath.
+func SandboxPaths(manager *gopls.Manager) Middleware {
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := copyArguments(request)
+			for name, value := range args {
+				path, ok := value.(string)
+				if !ok || path == "" || !pathArguments[name] && !outputArguments[name] {
+					continue
+				}
+				if _, declared := tool.InputSchema.Properties[name]; !declared {
+					continue
+				}
+				path = manager.ResolvePath(path)
+				if outputArguments[name] {
+					if err := checkOutputPath(name, path); err != nil {
+						return nil, err
+					}
+				} else if err := manager.ValidateFile(ctx, path); err != nil {
+					return nil, err
+				}
+				args[name] = path
+			}
+
+			// Each entry of a positions argument names a file too
+			_, declared := tool.InputSchema.Properties["positions"]
+			if positions, ok := args["positions"].([]any); ok && declared {
+				resolved := make([]any, len(positions))
+				for i, item := range positions {
+					resolved[i] = item
+					position, ok := item.(map[string]any)
+					if !ok {
+						continue
+					}
+					path, ok := position["file"].(string)
+					if !ok || path == "" {
+						continue
+					}
+					path = manager.ResolvePath(strings.TrimSpace(path))
+					if err := manager.ValidateFile(ctx, path); err != nil {
+						return nil, err
+					}
+					copied := make(map[string]any, len(position))
+					for key, value := range position {
+						copied[key] = value
+					}
+					copied["file"] = path
+					resolved[i] = copied
+				}
+				args["positions"] = resolved
+			}
+			return next(ctx, withArguments(request, args))
+		}
+	}
+}
+
+// checkOutputPath rejects an output argument naming a place a tool cannot
+// write to: an output file that is a directory or read-only, or an output
+// directory that is a file. A settings file is only read, so any path will
+// do.
+func checkOutputPath(name, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		// Created by the tool
+		return nil
+	}
+	switch name {
+	case "output":
+		if info.IsDir() {
+			return fmt.Errorf("output %s is a directory, not a file", path)
+		}
+		return edits.CheckWritable(path)
+	case "outputDir":
+		if !info.IsDir() {
+			return fmt.Errorf("outputDir %s is a file, not a directory", path)
+		}
+	}
+	return nil
+}
+
+// Timeout cancels a tool call that runs longer than d. A zero or negative
+// duration leaves calls unbounded.
+func Timeout(d time.Duration) Middleware {
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		if d <= 0 {
+			return next
+		}
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			result, err := next(ctx, request)
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%s timed out after %s: %w", tool.Name, d, err)
+			}
+			return result, err
+		}
+	}
+}
+
+// Logging logs every tool call with its duration and outcome
+func Logging(logger *log.Logger) Middleware {
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, request)
+			elapsed := time.Since(start).Round(time.Millisecond)
+			switch {
+			case err != nil:
+				logger.Printf("%s failed after %s: %v", tool.Name, elapsed, err)
+			case result != nil && result.IsError:
+				logger.Printf("%s returned an error result after %s", tool.Name, elapsed)
+			default:
+				logger.Printf("%s completed in %s", tool.Name, elapsed)
+			}
+			return result, err
+		}
+	}
+}
+
+// DryRunByDefault makes tools that support a dryRun argument preview their
+// changes unless the caller explicitly sets dryRun to false
+func DryRunByDefault() Middleware {
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		if _, ok := tool.InputSchema.Properties["dryRun"]; !ok {
+			return next
+		}
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := copyArguments(request)
+			if _, ok := args["dryRun"]; !ok {
+				args["dryRun"] = true
+			}
+			return next(ctx, withArguments(request, args))
+		}
+	}
+}
+
+// WorkspaceGuidance explains a workspace gopls cannot analyze, such as one
+// without a go.mod. Tools degrade to empty results there rather than fail,
+// so the explanation is added to every error and to the first result. The
+// tools named in except report the problem themselves.
+func WorkspaceGuidance(manager *gopls.Manager, except ...string) Middleware {
+	var warned atomic.Bool
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		for _, name := range except {
+			if tool.Name == name {
+				return next
+			}
+		}
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			problem := manager.WorkspaceProblem()
+			if problem == "" {
+				return result, err
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w\nNote: %s", err, problem)
+			}
+			if result != nil && warned.CompareAndSwap(false, true) {
+				result.Content = append(result.Content, mcp.NewTextContent("Warning: "+problem))
+			}
+			return result, err
+		}
+	}
+}
+
+// RelativePaths rewrites absolute paths and file URIs under the workspace
+// root in tool results to paths relative to the root, which is reported
+// once at the start of the result. Callers choose with the relativePaths
+// argument; enabled is the default.
+func RelativePaths(manager *gopls.Manager, enabled bool) Middleware {
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil || result == nil || !request.GetBool("relativePaths", enabled) {
+				return result, err
+			}
+
+			root := manager.WorkspaceRoot()
+			rewritten := false
+			for i, content := range result.Content {
+				text, ok := content.(mcp.TextContent)
+				if !ok {
+					continue
+				}
+				changed := false
+				// URIs straight from gopls have symbolic links resolved
+				for _, dir := range []string{root, utils.CanonicalPath(root)} {
+					var c bool
+					text.Text, c = relativize(text.Text, dir)
+					changed = changed || c
+				}
+				if changed {
+					result.Content[i] = text
+					rewritten = true
+				}
+			}
+			if rewritten {
+				note := mcp.NewTextContent("Paths are relative to the workspace root " + root)
+				result.Content = append([]mcp.Content{note}, result.Content...)
+			}
+			return result, nil
+		}
+	}
+}
+
+// relativize strips root and the separator after it from the paths and file
+// URIs in text that start with it, including paths in JSON strings where
+// backslashes are escaped. An occurrence only counts when it starts a path,
+// so /src/app is not stripped from /other/src/app/x.go.
+func relativize(text, root string) (string, bool) {
+	sep := string(filepath.Separator)
+	prefixes := []string{
+		"file://" + strings.TrimSuffix("/"+strings.TrimPrefix(filepath.ToSlash(root), "/"), "/") + "/",
+		strings.TrimSuffix(root, sep) + sep,
+	}
+	if sep == `\` {
+		prefixes = append(prefixes, strings.ReplaceAll(prefixes[1], `\`, `\\`))
+		// gopls and editors spell the drive of a URI as C:, c: or c%3A
+		if uri := prefixes[0]; len(uri) > len("file:///C:") && uri[len("file:///")+1] == ':' {
+			drive, rest := uri[len("file:///"):len("file:///")+1], uri[len("file:///C:"):]
+			for _, d := range []string{strings.ToUpper(drive), strings.ToLower(drive)} {
+				prefixes = append(prefixes, "file:///"+d+":"+rest, "file:///"+d+"%3A"+rest)
+			}
+		}
+	}
+
+	changed := false
+	for _, prefix := range prefixes {
+		var b strings.Builder
+		rest := text
+		for {
+			i := strings.Index(rest, prefix)
+			if i < 0 {
+				break
+			}
+			b.WriteString(rest[:i])
+			if i > 0 && isPathByte(rest[i-1]) {
+				b.WriteString(prefix)
+			} else {
+				changed = true
+			}
+			rest = rest[i+len(prefix):]
+		}
+		b.WriteString(rest)
+		text = b.String()
+	}
+	return text, changed
+}
+
+// isPathByte reports whether c can be part of a path, so a root found right
+// after it is the middle of a longer path
+func isPathByte(c byte) bool {
+	return c == '/' || c == '\\' || c == '.' || c == '-' || c == '_' || c == ':' ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yantrio/mcp-gopls/internal/tools/export_index"
+	"github.com/yantrio/mcp-gopls/internal/tools/hover"
+)
+
+func TestSandboxPathsOutsideWorkspace(t *testing.T) {
+	manager, _ := newTestManager(t, testWorkspace)
+	outside := t.TempDir()
+
+	// An output file may be written anywhere
+	exportTool := export_index.NewTool(manager)
+	export := Chain(exportTool, export_index.NewHandler(manager), SandboxPaths(manager))
+	output := filepath.Join(outside, "idx.json")
+	if _, err := export(t.Context(), newRequest(exportTool.Name, map[string]interface{}{"output": output})); err != nil {
+		t.Fatalf("ExportIndex to %s: %v", output, err)
+	}
+	if _, err := os.Stat(output); err != nil {
+		t.Errorf("the index was not written: %v", err)
+	}
+
+	// but not over a directory
+	_, err := export(t.Context(), newRequest(exportTool.Name, map[string]interface{}{"output": outside}))
+	if err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("ExportIndex to a directory: got error %v", err)
+	}
+
+	// A file to analyze must still be one gopls sees
+	source := filepath.Join(outside, "a.go")
+	if err := os.WriteFile(source, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hoverTool := hover.NewTool(manager)
+	hoverHandler := Chain(hoverTool, hover.NewHandler(manager), SandboxPaths(manager))
+	_, err = hoverHandler(t.Context(), newRequest(hoverTool.Name, map[string]interface{}{"file": source, "line": 1, "column": 9}))
+	if err == nil || !strings.Contains(err.Error(), "outside the workspace root") {
+		t.Errorf("Hover outside the workspace: got error %v", err)
+	}
+}
+
+func TestCheckOutputPath(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "out.json")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	readOnly := filepath.Join(dir, "readonly.json")
+	if err := os.WriteFile(readOnly, nil, 0444); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"output", filepath.Join(dir, "new.json"), false},
+		{"output", file, false},
+		{"output", dir, true},
+		{"output", readOnly, true},
+		{"outputDir", dir, false},
+		{"outputDir", filepath.Join(dir, "new"), false},
+		{"outputDir", file, true},
+		{"config", file, false},
+		{"config", readOnly, false},
+	}
+	for _, tt := range tests {
+		err := checkOutputPath(tt.name, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkOutputPath(%q, %q) = %v, want error: %v", tt.name, tt.path, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package move_file
+
+import (
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "MoveFile",
+		Description: "Rename or move a Go file or package directory within the workspace, applying the edits gopls reports as needed (import paths, package clauses) and telling gopls about the move",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "File or directory to move, absolute or relative to the workspace root",
+				},
+				"newPath": map[string]interface{}{
+					"type":        "string",
+					"description": "New location, absolute or relative to the workspace root. It must not exist yet.",
+				},
+				"dryRun": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Report the move and a diff of the edits without changing any files",
+					"default":     false,
+				},
+			},
+			Required: []string{"path", "newPath"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		src, err := request.RequireString("path")
+		if err != nil {
+			return nil, err
+		}
+		dst, err := request.RequireString("newPath")
+		if err != nil {
+			return nil, err
+		}
+		dryRun := request.GetBool("dryRun", false)
+
+		info, err := os.Stat(src)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(dst); err == nil {
+			return nil, fmt.Errorf("%s already exists", dst)
+		}
+		if !info.IsDir() && filepath.Ext(src) == ".go" && filepath.Ext(dst) != ".go" {
+			return nil, fmt.Errorf("newPath %s must keep the .go extension", dst)
+		}
+
+		client, err := manager.GetClient()
+		if err != nil {
+			return nil, err
+		}
+		rename, err := fileRename(src, dst)
+		if err != nil {
+			return nil, err
+		}
+		edit, err := client.WillRenameFiles(ctx, []lsp.FileRename{rename})
+		if err != nil {
+			return nil, err
+		}
+
+		// Edits refer to the files at their current locations
+		original := make(map[string][]byte)
+		updated := make(map[string][]byte)
+		if edit != nil {
+			fileEdits, err := edits.FileEdits(edit, manager.URIToPath)
+			if err != nil {
+				return nil, err
+			}
+			for path, textEdits := range fileEdits {
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return nil, err
+				}
+				text, err := edits.Apply(string(content), textEdits)
+				if err != nil {
+					return nil, fmt.Errorf("failed to apply edits to %s: %w", path, err)
+				}
+				original[path] = content
+				updated[path] = []byte(text)
+			}
+		}
+
+		// gopls only updates package clauses when a whole package moves, so
+		// give a single file the package of the directory it moves into
+		if !info.IsDir() && filepath.Dir(src) != filepath.Dir(dst) {
+			content, ok := updated[src]
+			if !ok {
+				if content, err = os.ReadFile(src); err != nil {
+					return nil, err
+				}
+				original[src] = content
+			}
+			if fixed, changed := adoptPackage(content, src, filepath.Dir(dst)); changed {
+				updated[src] = fixed
+			}
+		}
+
+		moved := func(path string) string {
+			if rel, err := filepath.Rel(src, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return filepath.Join(dst, rel)
+			}
+			return path
+		}
+
+		paths := make([]string, 0, len(updated))
+		for path := range updated {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		var diff strings.Builder
+		for _, path := range paths {
+			diff.WriteString(edits.Unified(edits.Label(manager.WorkspaceRoot(), moved(path)), string(original[path]), string(updated[path])))
+		}
+
+		summary := fmt.Sprintf("Moved %s to %s, updating %d file(s)", src, dst, len(updated))
+		if dryRun {
+			summary = fmt.Sprintf("Would move %s to %s, updating %d file(s)", src, dst, len(updated))
+		}
+		if diff.Len() > 0 {
+			summary += ":\n" + diff.String()
+		}
+		if dryRun {
+			return mcp.NewToolResultText(summary), nil
+		}
+
+		oldFiles, err := astscan.Paths(src, astscan.Options{IncludeTests: true})
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(src, dst); err != nil {
+			return nil, err
+		}
+
+		files := make(map[string][]byte, len(updated))
+		for path, content := range updated {
+			files[moved(path)] = content
+		}
+		if err := manager.WriteFiles(files); err != nil {
+			return nil, fmt.Errorf("moved %s to %s but failed to write the updated files: %w", src, dst, err)
+		}
+
+		if err := client.DidRenameFiles(ctx, []lsp.FileRename{rename}); err != nil {
+			return nil, err
+		}
+		var events []lsp.FileEvent
+		for _, path := range oldFiles {
+			oldURI, _ := utils.PathToURI(path)
+			newURI, _ := utils.PathToURI(moved(path))
+			events = append(events,
+				lsp.FileEvent{URI: oldURI, Type: lsp.FileChangeDeleted},
+				lsp.FileEvent{URI: newURI, Type: lsp.FileChangeCreated})
+		}
+		for _, path := range paths {
+			if moved(path) != path {
+				continue
+			}
+			if uri, err := utils.PathToURI(path); err == nil {
+				events = append(events, lsp.FileEvent{URI: uri, Type: lsp.FileChangeChanged})
+			}
+		}
+		if len(events) > 0 {
+			if err := client.DidChangeWatchedFiles(ctx, events); err != nil {
+				return nil, err
+			}
+		}
+
+		return mcp.NewToolResultText(summary), nil
+	}
+}
+
+func fileRename(src, dst string) (lsp.FileRename, error) {
+	oldURI, err := utils.PathToURI(src)
+	if err != nil {
+		return lsp.FileRename{}, err
+	}
+	newURI, err := utils.PathToURI(dst)
+	if err != nil {
+		return lsp.FileRename{}, err
+	}
+	return lsp.FileRename{OldURI: oldURI, NewURI: newURI}, nil
+}
+
+// adoptPackage rewrites the package clause of a file moving into dir to the
+// package the Go files already in dir declare. External test files keep their
+// _test suffix. A directory without Go files leaves the clause unchanged.
+func adoptPackage(content []byte, path, dir string) ([]byte, bool) {
+	target := packageIn(dir)
+	if target == "" {
+		return content, false
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.PackageClauseOnly)
+	if err != nil {
+		return content, false
+	}
+	current := file.Name.Name
+	if strings.HasSuffix(current, "_test") && strings.HasSuffix(path, "_test.go") {
+		target += "_test"
+	}
+	if current == target {
+		return content, false
+	}
+
+	start := fset.Position(file.Name.Pos()).Offset
+	end := fset.Position(file.Name.End()).Offset
+	fixed := make([]byte, 0, len(content)+len(target)-len(current))
+	fixed = append(fixed, content[:start]...)
+	fixed = append(fixed, target...)
+	fixed = append(fixed, content[end:]...)
+	return fixed, true
+}
+
+// packageIn returns the package name declared by the non-test Go files in
+// dir, or an empty string if there are none
+func packageIn(dir string) string {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	fset := token.NewFileSet()
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.PackageClauseOnly)
+		if err == nil {
+			return file.Name.Name
+		}
+	}
+	return ""
+}
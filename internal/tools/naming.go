@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// Naming profiles: the case tool names are exposed in
+const (
+	// NamingPascal keeps the built-in names, e.g. GoToDefinition
+	NamingPascal = "pascal"
+	// NamingSnake exposes go_to_definition
+	NamingSnake = "snake"
+	// NamingCamel exposes goToDefinition
+	NamingCamel = "camel"
+	// NamingKebab exposes go-to-definition
+	NamingKebab = "kebab"
+)
+
+// validToolName matches the names MCP clients accept
+var validToolName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Naming maps the built-in tool names to the names clients see. Handlers,
+// middleware, metrics and logs keep using the built-in names.
+type Naming struct {
+	// Profile is one of the Naming* profiles, empty for NamingPascal
+	Profile string
+	// Prefix is put before every name, e.g. "go_" to keep the tools apart
+	// from those of other servers
+	Prefix string
+	// MaxLength shortens longer names, keeping them unique with a hash
+	// suffix. Zero means no limit.
+	MaxLength int
+	// Aliases give single tools a name of their own, keyed by built-in
+	// name. An alias is used as is, without profile, prefix or limit.
+	Aliases map[string]string
+}
+
+// Names returns the exposed name of each tool, keyed by built-in name. It
+// fails on unknown profiles, aliases of unknown tools, invalid names and
+// names that collide.
+func (n Naming) Names(tools []string) (map[string]string, error) {
+	convert := func(name string) string { return name }
+	switch n.Profile {
+	case "", NamingPascal:
+	case NamingSnake:
+		convert = utils.ToSnake
+	case NamingCamel:
+		convert = utils.ToCamel
+	case NamingKebab:
+		convert = utils.ToKebab
+	default:
+		return nil, fmt.Errorf("invalid tool naming profile %q: must be '%s', '%s', '%s' or '%s'", n.Profile, NamingPascal, NamingSnake, NamingCamel, NamingKebab)
+	}
+	if n.MaxLength < 0 {
+		return nil, fmt.Errorf("invalid maximum tool name length %d", n.MaxLength)
+	}
+
+	known := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		known[tool] = true
+	}
+	aliased := make([]string, 0, len(n.Aliases))
+	for tool := range n.Aliases {
+		aliased = append(aliased, tool)
+	}
+	sort.Strings(aliased)
+	for _, tool := range aliased {
+		if !known[tool] {
+			return nil, fmt.Errorf("alias %q is for unknown tool %s", n.Aliases[tool], tool)
+		}
+	}
+
+	names := make(map[string]string, len(tools))
+	owner := make(map[string]string, len(tools))
+	for _, tool := range tools {
+		name, ok := n.Aliases[tool]
+		if !ok {
+			name = n.shorten(n.Prefix + convert(tool))
+		}
+		if !validToolName.MatchString(name) {
+			return nil, fmt.Errorf("invalid name %q for tool %s: names may only contain letters, digits, _ and -", name, tool)
+		}
+		if other, taken := owner[name]; taken {
+			return nil, fmt.Errorf("tools %s and %s would both be named %s", other, tool, name)
+		}
+		names[tool] = name
+		owner[name] = tool
+	}
+	return names, nil
+}
+
+// shorten cuts a name to MaxLength, ending it with a hash of the full name
+// so names sharing a long prefix stay distinct
+func (n Naming) shorten(name string) string {
+	if n.MaxLength == 0 || len(name) <= n.MaxLength {
+		return name
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	suffix := fmt.Sprintf("%06x", h.Sum32()&0xffffff)
+	if n.MaxLength <= len(suffix) {
+		return suffix[:n.MaxLength]
+	}
+	return name[:n.MaxLength-len(suffix)] + suffix
+}
@@ -0,0 +1,272 @@
+package notes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/state"
+)
+
+// stateFile is the name of the workspace state file notes are kept in
+const stateFile = "notes.json"
+
+// maxNoteSize keeps notes to plans and checklists rather than file contents
+const maxNoteSize = 64 << 10
+
+// previewLength is how much of a note ListNotes shows
+const previewLength = 80
+
+type note struct {
+	Value   string    `json:"value"`
+	Updated time.Time `json:"updated"`
+}
+
+type store struct {
+	Notes map[string]note `json:"notes"`
+}
+
+type listed struct {
+	Key     string    `json:"key"`
+	Preview string    `json:"preview"`
+	Size    int       `json:"size"`
+	Updated time.Time `json:"updated"`
+}
+
+func keyProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": description,
+	}
+}
+
+func NewSetNoteTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "SetNote",
+		Description: "Store a note under a key for this workspace, such as a refactoring plan or a checklist of affected files. Notes are kept on disk outside the workspace, so they survive reconnects and restarts; read them back with GetNote and ListNotes.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"key": keyProperty("Key of the note, e.g. 'rename-plan'. Use a common prefix such as 'plan/' to group notes."),
+				"value": map[string]interface{}{
+					"type":        "string",
+					"description": "Text of the note",
+				},
+				"append": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Add value as a new line at the end of the note instead of replacing it",
+					"default":     false,
+				},
+			},
+			Required: []string{"key", "value"},
+		},
+	}
+}
+
+func NewSetNoteHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		key, err := requireKey(request)
+		if err != nil {
+			return nil, err
+		}
+		value, err := request.RequireString("value")
+		if err != nil {
+			return nil, err
+		}
+		appendValue := request.GetBool("append", false)
+
+		var notes store
+		size := 0
+		err = state.Update(manager.WorkspaceRoot(), stateFile, &notes, func() (bool, error) {
+			if notes.Notes == nil {
+				notes.Notes = make(map[string]note)
+			}
+			if existing, ok := notes.Notes[key]; ok && appendValue && existing.Value != "" {
+				value = strings.TrimRight(existing.Value, "\n") + "\n" + value
+			}
+			if len(value) > maxNoteSize {
+				return false, fmt.Errorf("note %q would be %d bytes, more than the limit of %d", key, len(value), maxNoteSize)
+			}
+			notes.Notes[key] = note{Value: value, Updated: time.Now().UTC().Truncate(time.Second)}
+			size = len(value)
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("Saved note %q (%d bytes)", key, size)), nil
+	}
+}
+
+func NewGetNoteTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "GetNote",
+		Description: "Read a note stored with SetNote in this workspace",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"key": keyProperty("Key of the note"),
+			},
+			Required: []string{"key"},
+		},
+	}
+}
+
+func NewGetNoteHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		key, err := requireKey(request)
+		if err != nil {
+			return nil, err
+		}
+		notes, err := load(manager)
+		if err != nil {
+			return nil, err
+		}
+		n, ok := notes.Notes[key]
+		if !ok {
+			return mcp.NewToolResultError(missing(notes, key)), nil
+		}
+		return mcp.NewToolResultText(n.Value), nil
+	}
+}
+
+func NewListNotesTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "ListNotes",
+		Description: "List the notes stored with SetNote in this workspace, with the start of each and when it was last changed",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"prefix": keyProperty("Only list notes whose key starts with this prefix"),
+			},
+		},
+	}
+}
+
+func NewListNotesHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		prefix := request.GetString("prefix", "")
+		notes, err := load(manager)
+		if err != nil {
+			return nil, err
+		}
+
+		list := make([]listed, 0, len(notes.Notes))
+		for key, n := range notes.Notes {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			list = append(list, listed{Key: key, Preview: preview(n.Value), Size: len(n.Value), Updated: n.Updated})
+		}
+		if len(list) == 0 {
+			if prefix != "" {
+				return mcp.NewToolResultText(fmt.Sprintf("No notes with keys starting with %q", prefix)), nil
+			}
+			return mcp.NewToolResultText("No notes stored in this workspace. Store one with SetNote."), nil
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+		output, _ := json.MarshalIndent(list, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("%d note(s):\n%s", len(list), output)), nil
+	}
+}
+
+func NewDeleteNoteTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "DeleteNote",
+		Description: "Delete a note stored with SetNote in this workspace, or all notes whose key starts with a prefix",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"key": keyProperty("Key of the note to delete"),
+				"prefix": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Delete every note whose key starts with key, e.g. all of 'plan/'",
+					"default":     false,
+				},
+			},
+			Required: []string{"key"},
+		},
+	}
+}
+
+func NewDeleteNoteHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		key, err := requireKey(request)
+		if err != nil {
+			return nil, err
+		}
+		byPrefix := request.GetBool("prefix", false)
+
+		var notes store
+		var deleted []string
+		err = state.Update(manager.WorkspaceRoot(), stateFile, &notes, func() (bool, error) {
+			for existing := range notes.Notes {
+				if existing == key || byPrefix && strings.HasPrefix(existing, key) {
+					delete(notes.Notes, existing)
+					deleted = append(deleted, existing)
+				}
+			}
+			return len(deleted) > 0, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(deleted) == 0 {
+			return mcp.NewToolResultError(missing(notes, key)), nil
+		}
+		sort.Strings(deleted)
+		return mcp.NewToolResultText(fmt.Sprintf("Deleted %d note(s): %s", len(deleted), strings.Join(deleted, ", "))), nil
+	}
+}
+
+func requireKey(request mcp.CallToolRequest) (string, error) {
+	key, err := request.RequireString("key")
+	if err != nil {
+		return "", err
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", fmt.Errorf("key cannot be empty")
+	}
+	return key, nil
+}
+
+func load(manager *gopls.Manager) (store, error) {
+	var notes store
+	err := state.Load(manager.WorkspaceRoot(), stateFile, &notes)
+	return notes, err
+}
+
+// missing explains that there is no note with key, naming the keys that
+// contain it
+func missing(notes store, key string) string {
+	var similar []string
+	for existing := range notes.Notes {
+		if strings.Contains(strings.ToLower(existing), strings.ToLower(key)) {
+			similar = append(similar, existing)
+		}
+	}
+	if len(similar) == 0 {
+		return fmt.Sprintf("No note with key %q. ListNotes lists the stored notes.", key)
+	}
+	sort.Strings(similar)
+	return fmt.Sprintf("No note with key %q. Did you mean: %s?", key, strings.Join(similar, ", "))
+}
+
+// preview returns the first line of a note, cut to previewLength
+func preview(value string) string {
+	line, _, more := strings.Cut(strings.TrimSpace(value), "\n")
+	if runes := []rune(line); len(runes) > previewLength {
+		return string(runes[:previewLength]) + "..."
+	}
+	if more {
+		return line + " ..."
+	}
+	return line
+}
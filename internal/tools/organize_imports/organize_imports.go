@@ -3,12 +3,11 @@ package organize_imports
 import (
 	"context"
 	"fmt"
-	"os"
-	"sort"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/edits"
 	"github.com/yantrio/mcp-gopls/internal/gopls"
 	"github.com/yantrio/mcp-gopls/internal/lsp"
 	"github.com/yantrio/mcp-gopls/internal/utils"
@@ -48,7 +47,7 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 			return nil, err
 		}
 
-		content, err := os.ReadFile(file)
+		content, err := manager.ReadFile(file)
 		if err != nil {
 			return nil, err
 		}
@@ -83,9 +82,16 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 			return mcp.NewToolResultText(fmt.Sprintf("No import organization needed for %s", file)), nil
 		}
 
+		// gopls may leave the edit out until the action is resolved
+		resolved, err := client.ResolveCodeAction(ctx, *organizeImportsAction)
+		if err != nil {
+			return nil, err
+		}
+		organizeImportsAction = &resolved
+
 		// Apply the workspace edit if available
 		if organizeImportsAction.Edit != nil {
-			if err := applyWorkspaceEdit(file, organizeImportsAction.Edit); err != nil {
+			if err := applyWorkspaceEdit(manager, file, organizeImportsAction.Edit); err != nil {
 				return nil, fmt.Errorf("failed to apply import organization: %w", err)
 			}
 			return mcp.NewToolResultText(fmt.Sprintf("Successfully organized imports in %s", file)), nil
@@ -100,34 +106,16 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 	}
 }
 
-// applyWorkspaceEdit applies a workspace edit to files
-func applyWorkspaceEdit(targetFile string, edit *lsp.WorkspaceEdit) error {
-	// Handle document changes format
-	if len(edit.DocumentChanges) > 0 {
-		for _, docEdit := range edit.DocumentChanges {
-			filePath, err := utils.URIToPath(docEdit.TextDocument.URI)
-			if err != nil {
-				return fmt.Errorf("failed to parse URI: %w", err)
-			}
-			
-			if filePath == targetFile {
-				if err := applyTextEdits(filePath, docEdit.Edits); err != nil {
-					return err
-				}
-			}
-		}
-		return nil
+// applyWorkspaceEdit applies the parts of a workspace edit that touch targetFile
+func applyWorkspaceEdit(manager *gopls.Manager, targetFile string, edit *lsp.WorkspaceEdit) error {
+	fileEdits, err := edits.FileEdits(edit, manager.URIToPath)
+	if err != nil {
+		return err
 	}
 
-	// Handle changes format
-	for fileURI, edits := range edit.Changes {
-		filePath, err := utils.URIToPath(fileURI)
-		if err != nil {
-			return fmt.Errorf("failed to parse URI: %w", err)
-		}
-		
+	for filePath, textEdits := range fileEdits {
 		if filePath == targetFile {
-			if err := applyTextEdits(filePath, edits); err != nil {
+			if err := manager.ApplyToFile(filePath, textEdits); err != nil {
 				return err
 			}
 		}
@@ -135,73 +123,3 @@ func applyWorkspaceEdit(targetFile string, edit *lsp.WorkspaceEdit) error {
 
 	return nil
 }
-
-// applyTextEdits applies LSP text edits to a file
-func applyTextEdits(filePath string, edits []lsp.TextEdit) error {
-	// Read the file
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
-
-	// Convert to lines for easier manipulation
-	lines := strings.Split(string(content), "\n")
-
-	// Sort edits in reverse order (from end to beginning) to avoid offset issues
-	sortedEdits := make([]lsp.TextEdit, len(edits))
-	copy(sortedEdits, edits)
-	sort.Slice(sortedEdits, func(i, j int) bool {
-		if sortedEdits[i].Range.Start.Line != sortedEdits[j].Range.Start.Line {
-			return sortedEdits[i].Range.Start.Line > sortedEdits[j].Range.Start.Line
-		}
-		return sortedEdits[i].Range.Start.Character > sortedEdits[j].Range.Start.Character
-	})
-
-	// Apply edits
-	for _, edit := range sortedEdits {
-		startLine := edit.Range.Start.Line
-		startChar := edit.Range.Start.Character
-		endLine := edit.Range.End.Line
-		endChar := edit.Range.End.Character
-
-		// Validate line numbers
-		if startLine >= len(lines) || endLine >= len(lines) {
-			return fmt.Errorf("invalid line number: start=%d, end=%d, total=%d", startLine, endLine, len(lines))
-		}
-
-		// Handle single-line edit
-		if startLine == endLine {
-			line := lines[startLine]
-			if startChar > len(line) || endChar > len(line) {
-				return fmt.Errorf("invalid character position: line=%d, start=%d, end=%d, length=%d", startLine, startChar, endChar, len(line))
-			}
-			lines[startLine] = line[:startChar] + edit.NewText + line[endChar:]
-		} else {
-			// Multi-line edit
-			startLineContent := lines[startLine]
-			endLineContent := lines[endLine]
-			
-			if startChar > len(startLineContent) || endChar > len(endLineContent) {
-				return fmt.Errorf("invalid character position in multi-line edit")
-			}
-
-			// Create new content
-			newContent := startLineContent[:startChar] + edit.NewText + endLineContent[endChar:]
-			
-			// Replace the lines
-			newLines := append(lines[:startLine], newContent)
-			if endLine+1 < len(lines) {
-				newLines = append(newLines, lines[endLine+1:]...)
-			}
-			lines = newLines
-		}
-	}
-
-	// Write back to file
-	newContent := strings.Join(lines, "\n")
-	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
-}
\ No newline at end of file
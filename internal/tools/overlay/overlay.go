@@ -0,0 +1,216 @@
+package overlay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name: "Overlay",
+		Description: "Stage edits to Go files in gopls's memory without writing them, run any analysis tool against the staged state (GetDiagnostics, CheckWorkspace, FindReferences, Hover...), then commit all staged files to disk as one change or discard them. " +
+			"Use it to try a speculative change safely. Staged changes are lost when the server stops. Refactoring tools that write files work on the files on disk, so commit or discard first.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"action": map[string]interface{}{
+					"type":        "string",
+					"description": "'stage' sets the content of file, 'list' shows the staged files, 'diff' shows the staged changes, 'commit' writes them and 'discard' drops them",
+					"enum":        []string{"stage", "list", "diff", "commit", "discard"},
+				},
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "File to stage, absolute or relative to the workspace root. It may not exist yet.",
+				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "New content of the whole file",
+				},
+				"edits": map[string]interface{}{
+					"type":        "array",
+					"description": "Replacements applied in order to the file's current content, staged or on disk, instead of content. Each oldText must occur exactly once.",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"oldText": map[string]interface{}{"type": "string", "description": "Text to replace, with enough context to be unique"},
+							"newText": map[string]interface{}{"type": "string", "description": "Replacement text"},
+						},
+						"required": []string{"oldText", "newText"},
+					},
+				},
+				"files": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Staged files to diff, commit or discard, absolute or relative to the workspace root; all of them by default",
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		action, err := request.RequireString("action")
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, file := range request.GetStringSlice("files", nil) {
+			path := manager.ResolvePath(strings.TrimSpace(file))
+			if err := manager.ValidateFile(ctx, path); err != nil {
+				return nil, err
+			}
+			files = append(files, path)
+		}
+
+		switch action {
+		case "stage":
+			return stage(ctx, manager, request)
+		case "list", "diff":
+			if len(files) == 0 {
+				files = manager.StagedFiles()
+			}
+			if len(files) == 0 {
+				return mcp.NewToolResultText("No staged changes"), nil
+			}
+			var b strings.Builder
+			for _, path := range files {
+				staged, ok := manager.Staged(path)
+				if !ok {
+					return nil, fmt.Errorf("%s has no staged changes", path)
+				}
+				before, created, err := onDisk(path)
+				if err != nil {
+					return nil, err
+				}
+				if action == "list" {
+					added, removed := lineCounts(before, string(staged))
+					state := ""
+					if created {
+						state = ", new file"
+					}
+					fmt.Fprintf(&b, "  - %s (+%d -%d%s)\n", path, added, removed, state)
+					continue
+				}
+				b.WriteString(edits.Unified(edits.Label(manager.WorkspaceRoot(), path), before, string(staged)))
+			}
+			if action == "list" {
+				return mcp.NewToolResultText(fmt.Sprintf("%d staged file(s):\n%s", len(files), b.String())), nil
+			}
+			return mcp.NewToolResultText(b.String()), nil
+		case "commit":
+			written, err := manager.Commit(ctx, files...)
+			if err != nil {
+				return nil, err
+			}
+			if len(written) == 0 {
+				return mcp.NewToolResultText("No staged changes to commit"), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Wrote %d file(s):\n  - %s", len(written), strings.Join(written, "\n  - "))), nil
+		case "discard":
+			discarded, err := manager.Discard(ctx, files...)
+			if err != nil {
+				return nil, err
+			}
+			if len(discarded) == 0 {
+				return mcp.NewToolResultText("No staged changes to discard"), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Discarded the staged changes of %d file(s):\n  - %s", len(discarded), strings.Join(discarded, "\n  - "))), nil
+		default:
+			return nil, fmt.Errorf("invalid action %q: must be 'stage', 'list', 'diff', 'commit' or 'discard'", action)
+		}
+	}
+}
+
+// stage sets the staged content of the file argument from content or edits
+func stage(ctx context.Context, manager *gopls.Manager, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	file, err := request.RequireString("file")
+	if err != nil {
+		return nil, err
+	}
+	path := manager.ResolvePath(file)
+	args := request.GetArguments()
+	content, hasContent := args["content"].(string)
+	replacements, hasEdits := args["edits"].([]interface{})
+	if hasContent == hasEdits {
+		return nil, fmt.Errorf("stage takes either content or edits")
+	}
+
+	if hasEdits {
+		current, err := manager.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		content = string(current)
+		for i, item := range replacements {
+			replacement, _ := item.(map[string]interface{})
+			oldText, _ := replacement["oldText"].(string)
+			newText, _ := replacement["newText"].(string)
+			if oldText == "" {
+				return nil, fmt.Errorf("edit %d has no oldText", i+1)
+			}
+			switch count := strings.Count(content, oldText); count {
+			case 1:
+				content = strings.Replace(content, oldText, newText, 1)
+			case 0:
+				return nil, fmt.Errorf("edit %d: oldText does not occur in %s", i+1, file)
+			default:
+				return nil, fmt.Errorf("edit %d: oldText occurs %d times in %s; add surrounding lines to make it unique", i+1, count, file)
+			}
+		}
+	}
+	if offset, _ := utils.FindNonText([]byte(content)); offset >= 0 {
+		return nil, fmt.Errorf("content is not UTF-8 text (invalid byte at offset %d)", offset)
+	}
+
+	if err := manager.Stage(ctx, path, []byte(content)); err != nil {
+		return nil, err
+	}
+	before, _, err := onDisk(path)
+	if err != nil {
+		return nil, err
+	}
+	diff := edits.Unified(edits.Label(manager.WorkspaceRoot(), path), before, content)
+	if diff == "" {
+		diff = "(identical to the file on disk)\n"
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Staged %s; %d file(s) staged. Staged changes against disk:\n%s", path, len(manager.StagedFiles()), diff)), nil
+}
+
+// onDisk returns the content of path on disk, empty for a file that does
+// not exist yet
+func onDisk(path string) (string, bool, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", true, nil
+	}
+	return string(content), false, err
+}
+
+// lineCounts returns the lines added and removed between before and after
+func lineCounts(before, after string) (added, removed int) {
+	lines := strings.Split(edits.Unified("", before, after), "\n")
+	if len(lines) < 2 {
+		return 0, 0
+	}
+	// Skip the --- and +++ header
+	for _, line := range lines[2:] {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,345 @@
+package overlay_checkpoints
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// current names the overlay as it is now in DiffCheckpoints
+const current = "current"
+
+func settleProperty() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "number",
+		"description": "Wait until gopls has published no diagnostics for this many milliseconds before reading them, so the staged changes are reflected",
+		"default":     1000,
+	}
+}
+
+func NewCreateCheckpointTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name: "CreateCheckpoint",
+		Description: "Save the files staged with Overlay, and gopls's diagnostics of them, as a named checkpoint. " +
+			"Checkpoint before trying a refactoring strategy, RestoreCheckpoint to go back and try another, and DiffCheckpoints to compare the results before committing one.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the checkpoint, e.g. 'before' or 'strategy-a'. An existing checkpoint with the name is replaced.",
+				},
+				"settleMs": settleProperty(),
+			},
+			Required: []string{"name"},
+		},
+	}
+}
+
+func NewCreateCheckpointHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := requireName(request, "name")
+		if err != nil {
+			return nil, err
+		}
+		diagnostics, err := settled(ctx, manager, request)
+		if err != nil {
+			return nil, err
+		}
+		checkpoint := manager.SaveOverlayCheckpoint(name, diagnostics)
+		errs, warnings := counts(diagnostics)
+		return mcp.NewToolResultText(fmt.Sprintf("Saved checkpoint %q with %d staged file(s); the workspace has %d error(s) and %d warning(s)",
+			name, len(checkpoint.Files), errs, warnings)), nil
+	}
+}
+
+func NewRestoreCheckpointTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "RestoreCheckpoint",
+		Description: "Put the Overlay back to a checkpoint saved with CreateCheckpoint: the checkpoint's files are staged again with their content then, and files staged since are discarded. Nothing is written to disk.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the checkpoint to restore",
+				},
+			},
+			Required: []string{"name"},
+		},
+	}
+}
+
+func NewRestoreCheckpointHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, err := requireName(request, "name")
+		if err != nil {
+			return nil, err
+		}
+		checkpoint, err := manager.GetOverlayCheckpoint(name)
+		if err != nil {
+			return nil, err
+		}
+		var discarded []string
+		for _, path := range manager.StagedFiles() {
+			if _, ok := checkpoint.Files[path]; !ok {
+				discarded = append(discarded, path)
+			}
+		}
+		if err := manager.RestoreOverlay(ctx, checkpoint); err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Restored checkpoint %q from %s: %d file(s) staged", name, checkpoint.Taken.Format(time.RFC3339), len(checkpoint.Files))
+		if len(discarded) > 0 {
+			fmt.Fprintf(&b, ", discarded the staged changes of:\n  - %s", strings.Join(discarded, "\n  - "))
+		}
+		return mcp.NewToolResultText(b.String()), nil
+	}
+}
+
+func NewDiffCheckpointsTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "DiffCheckpoints",
+		Description: "Compare two overlay checkpoints saved with CreateCheckpoint, or a checkpoint with the overlay as it is now: the differences between their files and the diagnostics that appeared and disappeared",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"from": map[string]interface{}{
+					"type":        "string",
+					"description": "Checkpoint to compare from",
+				},
+				"to": map[string]interface{}{
+					"type":        "string",
+					"description": "Checkpoint to compare to, or 'current' for the overlay as it is now",
+					"default":     current,
+				},
+				"settleMs": settleProperty(),
+			},
+			Required: []string{"from"},
+		},
+	}
+}
+
+func NewDiffCheckpointsHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		fromName, err := requireName(request, "from")
+		if err != nil {
+			return nil, err
+		}
+		toName := strings.TrimSpace(request.GetString("to", current))
+		from, err := manager.GetOverlayCheckpoint(fromName)
+		if err != nil {
+			return nil, err
+		}
+		to, err := checkpointOrCurrent(ctx, manager, request, toName)
+		if err != nil {
+			return nil, err
+		}
+
+		var diffs strings.Builder
+		changed := 0
+		for _, path := range union(from.Files, to.Files) {
+			before, err := content(from.Files, path)
+			if err != nil {
+				return nil, err
+			}
+			after, err := content(to.Files, path)
+			if err != nil {
+				return nil, err
+			}
+			if diff := edits.Unified(edits.Label(manager.WorkspaceRoot(), path), before, after); diff != "" {
+				diffs.WriteString(diff)
+				changed++
+			}
+		}
+
+		appeared, disappeared := compare(manager, from.Diagnostics, to.Diagnostics)
+		fromErrors, _ := counts(from.Diagnostics)
+		toErrors, _ := counts(to.Diagnostics)
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "%q -> %q: %d file(s) differ; errors %d -> %d\n", fromName, toName, changed, fromErrors, toErrors)
+		writeDiagnostics(&b, "Appeared", appeared)
+		writeDiagnostics(&b, "Disappeared", disappeared)
+		if changed > 0 {
+			b.WriteString("\n")
+			b.WriteString(diffs.String())
+		}
+		return mcp.NewToolResultText(b.String()), nil
+	}
+}
+
+// checkpointOrCurrent returns the checkpoint named name, or for 'current'
+// the overlay and its diagnostics as they are now
+func checkpointOrCurrent(ctx context.Context, manager *gopls.Manager, request mcp.CallToolRequest, name string) (gopls.OverlayCheckpoint, error) {
+	if name != current {
+		return manager.GetOverlayCheckpoint(name)
+	}
+	diagnostics, err := settled(ctx, manager, request)
+	if err != nil {
+		return gopls.OverlayCheckpoint{}, err
+	}
+	now := gopls.OverlayCheckpoint{Name: current, Files: make(map[string][]byte), Diagnostics: diagnostics, Taken: time.Now()}
+	for _, path := range manager.StagedFiles() {
+		if staged, ok := manager.Staged(path); ok {
+			now.Files[path] = staged
+		}
+	}
+	return now, nil
+}
+
+// settled waits for gopls to finish diagnosing and returns its diagnostics
+func settled(ctx context.Context, manager *gopls.Manager, request mcp.CallToolRequest) (lsp.DiagnosticsSnapshot, error) {
+	client, err := manager.GetClient()
+	if err != nil {
+		return lsp.DiagnosticsSnapshot{}, err
+	}
+	settle := time.Duration(request.GetInt("settleMs", 1000)) * time.Millisecond
+	if err := client.WaitForDiagnostics(ctx, settle); err != nil {
+		return lsp.DiagnosticsSnapshot{}, err
+	}
+	return client.AllDiagnostics(), nil
+}
+
+func requireName(request mcp.CallToolRequest, argument string) (string, error) {
+	name, err := request.RequireString(argument)
+	if err != nil {
+		return "", err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%s cannot be empty", argument)
+	}
+	if name == current {
+		return "", fmt.Errorf("%q names the overlay as it is now and cannot be a checkpoint", current)
+	}
+	return name, nil
+}
+
+// union returns the paths staged in either a or b, sorted
+func union(a, b map[string][]byte) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var paths []string
+	for _, files := range []map[string][]byte{a, b} {
+		for path := range files {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// content returns the staged content of path in files, or its content on
+// disk when it was not staged
+func content(files map[string][]byte, path string) (string, error) {
+	if staged, ok := files[path]; ok {
+		return string(staged), nil
+	}
+	onDisk, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return string(onDisk), err
+}
+
+// compare returns the diagnostics of after that before does not have and
+// the other way round, as lines, matched by file, severity and message so
+// that diagnostics moved by an edit are not reported. Repeated identical
+// diagnostics are matched by count.
+func compare(manager *gopls.Manager, before, after lsp.DiagnosticsSnapshot) (appeared, disappeared []string) {
+	remaining := make(map[string][]lsp.Diagnostic)
+	for uri, diagnostics := range before.Diagnostics {
+		for _, diag := range diagnostics {
+			k := key(uri, diag)
+			remaining[k] = append(remaining[k], diag)
+		}
+	}
+	for uri, diagnostics := range after.Diagnostics {
+		for _, diag := range diagnostics {
+			k := key(uri, diag)
+			if len(remaining[k]) > 0 {
+				remaining[k] = remaining[k][1:]
+				continue
+			}
+			appeared = append(appeared, format(manager, uri, diag))
+		}
+	}
+	for k, diagnostics := range remaining {
+		uri, _, _ := strings.Cut(k, "\x00")
+		for _, diag := range diagnostics {
+			disappeared = append(disappeared, format(manager, uri, diag))
+		}
+	}
+	sort.Strings(appeared)
+	sort.Strings(disappeared)
+	return appeared, disappeared
+}
+
+func key(uri string, diag lsp.Diagnostic) string {
+	return uri + "\x00" + severity(diag) + "\x00" + diag.Message
+}
+
+func format(manager *gopls.Manager, uri string, diag lsp.Diagnostic) string {
+	file, err := manager.URIToPath(uri)
+	if err != nil {
+		file = uri
+	}
+	line, column := utils.ConvertToUserPosition(diag.Range.Start)
+	return fmt.Sprintf("%s:%d:%d: %s: %s", file, line, column, severity(diag), diag.Message)
+}
+
+// severity names the severity of diag; a missing severity counts as an
+// error, as in DiffDiagnostics
+func severity(diag lsp.Diagnostic) string {
+	switch diag.Severity {
+	case lsp.DiagnosticSeverityWarning:
+		return "warning"
+	case lsp.DiagnosticSeverityInformation:
+		return "information"
+	case lsp.DiagnosticSeverityHint:
+		return "hint"
+	default:
+		return "error"
+	}
+}
+
+// counts returns the number of errors and warnings in snapshot
+func counts(snapshot lsp.DiagnosticsSnapshot) (errs, warnings int) {
+	for _, diagnostics := range snapshot.Diagnostics {
+		for _, diag := range diagnostics {
+			switch severity(diag) {
+			case "error":
+				errs++
+			case "warning":
+				warnings++
+			}
+		}
+	}
+	return errs, warnings
+}
+
+func writeDiagnostics(b *strings.Builder, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "%s (%d):\n", title, len(lines))
+	for _, line := range lines {
+		fmt.Fprintf(b, "  - %s\n", line)
+	}
+}
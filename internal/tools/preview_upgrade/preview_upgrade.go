@@ -0,0 +1,341 @@
+package preview_upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "PreviewUpgrade",
+		Description: "Preview the impact of upgrading a dependency before doing it: type-check the packages of the module the workspace uses at the target version and list the workspace call sites of symbols that were removed or whose signatures changed. The workspace's go.mod is not modified.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"module": map[string]interface{}{
+					"type":        "string",
+					"description": "Module path of the dependency, e.g. github.com/spf13/cobra",
+				},
+				"version": map[string]interface{}{
+					"type":        "string",
+					"description": "Target version, e.g. v1.9.0, or a query such as latest",
+					"default":     "latest",
+				},
+				"maxSites": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum number of call sites to list per changed symbol",
+					"default":     10,
+				},
+			},
+			Required: []string{"module"},
+		},
+	}
+}
+
+type change struct {
+	Symbol string   `json:"symbol"`
+	Kind   string   `json:"kind"` // removed, changed or package removed
+	Before string   `json:"before,omitempty"`
+	After  string   `json:"after,omitempty"`
+	Uses   int      `json:"uses"`
+	Sites  []string `json:"sites"`
+}
+
+type impact struct {
+	Module   string   `json:"module"`
+	Current  string   `json:"current"`
+	Target   string   `json:"target"`
+	Packages []string `json:"packages"`
+	Symbols  int      `json:"symbolsUsed"`
+	Changes  []change `json:"changes"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		module, err := request.RequireString("module")
+		if err != nil {
+			return nil, err
+		}
+		target := request.GetString("version", "latest")
+		maxSites := request.GetInt("maxSites", 10)
+		root := manager.WorkspaceRoot()
+
+		current, err := gocmd.Output(ctx, root, "list", "-m", "-f", "{{.Version}}", module)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a dependency of the workspace: %w", module, err)
+		}
+
+		pkgs, err := typecheck.Load(ctx, root, "./...")
+		if err != nil {
+			return nil, err
+		}
+		uses := usesOf(pkgs, module)
+		if len(uses) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("The workspace does not use any symbols from %s", module)), nil
+		}
+
+		var paths []string
+		seen := make(map[string]bool)
+		for _, use := range uses {
+			if !seen[use.pkg] {
+				seen[use.pkg] = true
+				paths = append(paths, use.pkg)
+			}
+		}
+		sort.Strings(paths)
+
+		resolved, upgraded, err := loadVersion(ctx, root, module, target, paths)
+		if err != nil {
+			return nil, err
+		}
+
+		report := impact{
+			Module:   module,
+			Current:  current,
+			Target:   resolved,
+			Packages: paths,
+			Symbols:  len(uses),
+			Changes:  make([]change, 0),
+		}
+		for _, key := range sortedKeys(uses) {
+			use := uses[key]
+			before := describe(use.obj)
+			c := change{Symbol: key, Before: before}
+			newPkg := upgraded[use.pkg]
+			switch after := lookup(newPkg, use); {
+			case newPkg == nil:
+				c.Kind, c.Before = "package removed", ""
+			case after == nil:
+				c.Kind = "removed"
+			case describe(after) != before:
+				c.Kind, c.After = "changed", describe(after)
+			default:
+				continue
+			}
+			c.Uses = len(use.sites)
+			c.Sites = use.sites
+			if len(c.Sites) > maxSites {
+				c.Sites = c.Sites[:maxSites]
+			}
+			report.Changes = append(report.Changes, c)
+		}
+
+		output, _ := json.MarshalIndent(report, "", "  ")
+		summary := fmt.Sprintf("Upgrading %s from %s to %s affects %d of the %d symbol(s) the workspace uses", module, current, resolved, len(report.Changes), len(uses))
+		if current == resolved {
+			summary = fmt.Sprintf("%s is already at %s", module, resolved)
+		}
+		return mcp.NewToolResultText(summary + ":\n" + string(output)), nil
+	}
+}
+
+// symbolUse is a symbol of the dependency and where the workspace uses it
+type symbolUse struct {
+	pkg   string // import path of the declaring package
+	typ   string // receiver or struct type for methods and fields
+	name  string
+	obj   types.Object
+	sites []string
+}
+
+// usesOf collects the package-level symbols, methods and fields of module's
+// packages that pkgs refer to, keyed by "path.Name" or "path.Type.Name"
+func usesOf(pkgs []*typecheck.Package, module string) map[string]*symbolUse {
+	uses := make(map[string]*symbolUse)
+	for _, pkg := range pkgs {
+		for ident, obj := range pkg.Info.Uses {
+			if obj.Pkg() == nil || !inModule(obj.Pkg().Path(), module) {
+				continue
+			}
+			use := newUse(obj)
+			if use == nil {
+				continue
+			}
+			key := use.pkg + "." + use.name
+			if use.typ != "" {
+				key = use.pkg + "." + use.typ + "." + use.name
+			}
+			if existing, ok := uses[key]; ok {
+				use = existing
+			} else {
+				uses[key] = use
+			}
+			position := pkg.Fset.Position(ident.Pos())
+			use.sites = append(use.sites, fmt.Sprintf("%s:%d:%d", position.Filename, position.Line, position.Column))
+		}
+	}
+	for _, use := range uses {
+		sort.Strings(use.sites)
+	}
+	return uses
+}
+
+func newUse(obj types.Object) *symbolUse {
+	use := &symbolUse{pkg: obj.Pkg().Path(), name: obj.Name(), obj: obj}
+	switch obj := obj.(type) {
+	case *types.Func:
+		if recv := obj.Type().(*types.Signature).Recv(); recv != nil {
+			named := namedOf(recv.Type())
+			if named == nil {
+				return nil
+			}
+			use.typ = named.Obj().Name()
+			use.obj = obj.Origin()
+			return use
+		}
+	case *types.Var:
+		if obj.IsField() {
+			owner := typecheck.FieldOwner(obj.Origin())
+			if owner == "" {
+				return nil
+			}
+			use.typ = owner
+			use.obj = obj.Origin()
+			return use
+		}
+	}
+	if obj.Pkg().Scope().Lookup(obj.Name()) != obj {
+		return nil
+	}
+	return use
+}
+
+// lookup finds the symbol matching use in pkg, the dependency package at the
+// target version
+func lookup(pkg *types.Package, use *symbolUse) types.Object {
+	if pkg == nil {
+		return nil
+	}
+	if use.typ == "" {
+		return pkg.Scope().Lookup(use.name)
+	}
+	typeName, ok := pkg.Scope().Lookup(use.typ).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	if _, isField := use.obj.(*types.Var); isField {
+		if st, ok := typeName.Type().Underlying().(*types.Struct); ok {
+			for i := 0; i < st.NumFields(); i++ {
+				if st.Field(i).Name() == use.name {
+					return st.Field(i)
+				}
+			}
+		}
+		return nil
+	}
+	// Look through a pointer so methods with pointer receivers are found
+	t := typeName.Type()
+	if !types.IsInterface(t) {
+		t = types.NewPointer(t)
+	}
+	obj, _, _ := types.LookupFieldOrMethod(t, true, pkg, use.name)
+	if fn, ok := obj.(*types.Func); ok {
+		return fn
+	}
+	return nil
+}
+
+// describe renders the parts of obj's declaration that callers depend on,
+// qualified by package path so that objects from the two versions compare
+func describe(obj types.Object) string {
+	qualifier := func(p *types.Package) string { return p.Path() }
+	if typeName, ok := obj.(*types.TypeName); ok {
+		under := typeName.Type().Underlying()
+		switch under.(type) {
+		case *types.Struct:
+			// Fields are compared where they are used
+			return "type " + typeName.Name() + " struct"
+		default:
+			return "type " + typeName.Name() + " " + types.TypeString(under, qualifier)
+		}
+	}
+	return types.ObjectString(obj, qualifier)
+}
+
+// loadVersion type-checks paths at the target version of module in a scratch
+// copy of the workspace's go.mod, returning the resolved version and the
+// packages that still exist
+func loadVersion(ctx context.Context, root, module, target string, paths []string) (string, map[string]*types.Package, error) {
+	modFile, err := gocmd.Output(ctx, root, "env", "GOMOD")
+	if err != nil {
+		return "", nil, err
+	}
+	if modFile == "" || modFile == os.DevNull {
+		return "", nil, fmt.Errorf("the workspace is not in a module")
+	}
+
+	scratch, err := os.MkdirTemp("", "preview-upgrade-")
+	if err != nil {
+		return "", nil, err
+	}
+	defer os.RemoveAll(scratch)
+	for _, name := range []string{"go.mod", "go.sum"} {
+		content, err := os.ReadFile(filepath.Join(filepath.Dir(modFile), name))
+		if err != nil {
+			if name == "go.sum" && os.IsNotExist(err) {
+				continue
+			}
+			return "", nil, err
+		}
+		if err := os.WriteFile(filepath.Join(scratch, name), content, 0644); err != nil {
+			return "", nil, err
+		}
+	}
+
+	get, err := gocmd.Run(ctx, scratch, []string{"GOWORK=off"}, "get", module+"@"+target)
+	if err != nil {
+		return "", nil, err
+	}
+	if get.ExitCode != 0 {
+		return "", nil, fmt.Errorf("go get %s@%s failed: %s", module, target, strings.TrimSpace(get.Stderr))
+	}
+	resolved, err := gocmd.Output(ctx, scratch, "list", "-m", "-f", "{{.Version}}", module)
+	if err != nil {
+		return "", nil, err
+	}
+
+	pkgs, err := typecheck.Load(ctx, scratch, paths...)
+	if err != nil {
+		return "", nil, err
+	}
+	upgraded := make(map[string]*types.Package)
+	for _, pkg := range pkgs {
+		if len(pkg.Files) > 0 && pkg.Types != nil {
+			upgraded[pkg.ImportPath] = pkg.Types
+		}
+	}
+	return resolved, upgraded, nil
+}
+
+func inModule(path, module string) bool {
+	return path == module || strings.HasPrefix(path, module+"/")
+}
+
+func namedOf(t types.Type) *types.Named {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
+
+func sortedKeys(uses map[string]*symbolUse) []string {
+	keys := make([]string, 0, len(uses))
+	for key := range uses {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
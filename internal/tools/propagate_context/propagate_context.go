@@ -0,0 +1,404 @@
+package propagate_context
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "PropagateContext",
+		Description: "Add a context.Context first parameter to a function and update its call sites across the workspace, passing the caller's context where it has one and context.TODO() otherwise",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file declaring the function",
+				},
+				"line": map[string]interface{}{
+					"type":        "number",
+					"description": "Line number (1-indexed) of the function name",
+				},
+				"column": map[string]interface{}{
+					"type":        "number",
+					"description": "Column number (1-indexed) of the function name",
+				},
+				"paramName": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the new parameter",
+					"default":     "ctx",
+				},
+				"organizeImports": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Organize imports in every changed file before writing it",
+					"default":     false,
+				},
+				"dryRun": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Report the changes as a unified diff without modifying any files",
+					"default":     false,
+				},
+			},
+			Required: []string{"file", "line", "column"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		line, err := request.RequireInt("line")
+		if err != nil {
+			return nil, err
+		}
+		column, err := request.RequireInt("column")
+		if err != nil {
+			return nil, err
+		}
+		paramName := request.GetString("paramName", "ctx")
+		if !token.IsIdentifier(paramName) {
+			return nil, fmt.Errorf("invalid parameter name %q", paramName)
+		}
+		dryRun := request.GetBool("dryRun", false)
+
+		client, err := manager.GetClient()
+		if err != nil {
+			return nil, err
+		}
+
+		file, err = filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		declFile, err := parseFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		fn := declFile.funcAt(line, column)
+		if fn == nil {
+			return nil, fmt.Errorf("no function declaration at %s:%d:%d", file, line, column)
+		}
+		if declFile.contextParam(fn.Type) != "" {
+			return nil, fmt.Errorf("%s already takes a context.Context", fn.Name.Name)
+		}
+
+		uri, err := utils.PathToURI(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.OpenDocument(ctx, uri, string(declFile.content)); err != nil {
+			return nil, err
+		}
+		defer client.CloseDocument(ctx, uri)
+
+		namePos := declFile.fset.Position(fn.Name.Pos())
+		refs, err := client.References(ctx, uri, lsp.Position{Line: namePos.Line - 1, Character: namePos.Column - 1}, false)
+		if err != nil {
+			return nil, err
+		}
+
+		// Group the references by file, parsing each file once
+		files := map[string]*sourceFile{file: declFile}
+		offsets := make(map[string][]int)
+		for _, ref := range refs {
+			path, err := manager.URIToPath(ref.URI)
+			if err != nil {
+				return nil, err
+			}
+			if files[path] == nil {
+				f, err := parseFile(path)
+				if err != nil {
+					return nil, err
+				}
+				files[path] = f
+			}
+			offset, err := utils.CalculateOffset(string(files[path].content), ref.Range.Start)
+			if err != nil {
+				return nil, err
+			}
+			offsets[path] = append(offsets[path], offset)
+		}
+
+		plan := &plan{fn: fn, declFile: declFile, paramName: paramName}
+		plan.declaration()
+		for path, fileOffsets := range offsets {
+			plan.callSites(files[path], fileOffsets)
+		}
+
+		return plan.apply(ctx, manager, files, dryRun, request.GetBool("organizeImports", false))
+	}
+}
+
+// sourceFile is a parsed file along with the edits planned for it
+type sourceFile struct {
+	path    string
+	fset    *token.FileSet
+	ast     *ast.File
+	content []byte
+
+	edits       []lsp.TextEdit
+	needContext bool
+}
+
+func parseFile(path string) (*sourceFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &sourceFile{path: path, fset: fset, ast: file, content: content}, nil
+}
+
+// funcAt returns the function declaration whose name contains line:column
+func (f *sourceFile) funcAt(line, column int) *ast.FuncDecl {
+	tokFile := f.fset.File(f.ast.Pos())
+	if line < 1 || line > tokFile.LineCount() {
+		return nil
+	}
+	pos := tokFile.LineStart(line) + token.Pos(column-1)
+	for _, decl := range f.ast.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Pos() <= pos && pos <= fn.Name.End() {
+			return fn
+		}
+	}
+	return nil
+}
+
+// contextName is the name the file refers to the context package by, or
+// "context" if it does not import it yet
+func (f *sourceFile) contextName() string {
+	if name := edits.ImportName(f.ast, "context"); name != "" {
+		return name
+	}
+	return "context"
+}
+
+// contextParam returns the name of a context.Context parameter of fn
+func (f *sourceFile) contextParam(fn *ast.FuncType) string {
+	name := edits.ImportName(f.ast, "context")
+	if name == "" || fn.Params == nil {
+		return ""
+	}
+	for _, field := range fn.Params.List {
+		sel, ok := field.Type.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Context" {
+			continue
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && x.Name == name {
+			if len(field.Names) == 0 || field.Names[0].Name == "_" {
+				// An unnamed context cannot be passed on
+				return ""
+			}
+			return field.Names[0].Name
+		}
+	}
+	return ""
+}
+
+func (f *sourceFile) insert(pos token.Pos, text string) {
+	p := f.fset.Position(pos)
+	at := lsp.Position{Line: p.Line - 1, Character: p.Column - 1}
+	f.edits = append(f.edits, lsp.TextEdit{Range: lsp.Range{Start: at, End: at}, NewText: text})
+}
+
+func (f *sourceFile) replace(node ast.Node, text string) {
+	start, end := f.fset.Position(node.Pos()), f.fset.Position(node.End())
+	f.edits = append(f.edits, lsp.TextEdit{
+		Range: lsp.Range{
+			Start: lsp.Position{Line: start.Line - 1, Character: start.Column - 1},
+			End:   lsp.Position{Line: end.Line - 1, Character: end.Column - 1},
+		},
+		NewText: text,
+	})
+}
+
+// plan accumulates the edits that thread a context through fn
+type plan struct {
+	fn        *ast.FuncDecl
+	declFile  *sourceFile
+	paramName string
+
+	updatedCalls int
+	todoCalls    int
+	replaced     int
+	manual       []string
+}
+
+// declaration adds the parameter and uses it in place of context.TODO() and
+// context.Background() calls in the function body
+func (p *plan) declaration() {
+	f := p.declFile
+	params := p.fn.Type.Params
+	param := p.paramName + " " + f.contextName() + ".Context"
+	if len(params.List) > 0 {
+		param += ", "
+	}
+	f.insert(params.Opening+1, param)
+	f.needContext = true
+
+	if p.fn.Body == nil {
+		return
+	}
+	ctxName := edits.ImportName(f.ast, "context")
+	ast.Inspect(p.fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 0 || ctxName == "" {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (sel.Sel.Name != "TODO" && sel.Sel.Name != "Background") {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && x.Name == ctxName {
+			f.replace(call, p.paramName)
+			p.replaced++
+			return false
+		}
+		return true
+	})
+}
+
+// callSites adds the context argument to each call of fn referenced at the
+// given offsets in f, recording references that are not calls
+func (p *plan) callSites(f *sourceFile, offsets []int) {
+	tokFile := f.fset.File(f.ast.Pos())
+	for _, offset := range offsets {
+		path := astscan.PathTo(f.ast, tokFile.Pos(offset))
+		if len(path) < 2 {
+			continue
+		}
+		call := astscan.CalleeOf(path)
+		if call == nil {
+			pos := f.fset.Position(tokFile.Pos(offset))
+			p.manual = append(p.manual, fmt.Sprintf("%s:%d:%d", pos.Filename, pos.Line, pos.Column))
+			continue
+		}
+
+		arg := p.enclosingContext(f, path)
+		if arg == "" {
+			arg = f.contextName() + ".TODO()"
+			f.needContext = true
+			p.todoCalls++
+		}
+		if len(call.Args) > 0 {
+			arg += ", "
+		}
+		f.insert(call.Lparen+1, arg)
+		p.updatedCalls++
+	}
+}
+
+// enclosingContext returns the name of a context parameter of the innermost
+// function enclosing the call, including the one about to be added to fn
+func (p *plan) enclosingContext(f *sourceFile, path []ast.Node) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		switch fn := path[i].(type) {
+		case *ast.FuncLit:
+			if name := f.contextParam(fn.Type); name != "" {
+				return name
+			}
+		case *ast.FuncDecl:
+			if f == p.declFile && fn == p.fn {
+				return p.paramName
+			}
+			return f.contextParam(fn.Type)
+		}
+	}
+	return ""
+}
+
+// apply writes the planned edits, or renders them as a diff for a dry run
+func (p *plan) apply(ctx context.Context, manager *gopls.Manager, files map[string]*sourceFile, dryRun, organize bool) (*mcp.CallToolResult, error) {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	updates := make(map[string][]byte)
+	for _, path := range paths {
+		f := files[path]
+		if len(f.edits) == 0 {
+			continue
+		}
+		if f.needContext && edits.ImportName(f.ast, "context") == "" {
+			f.edits = append(f.edits, edits.AddImport(f.fset, f.ast, "context"))
+		}
+
+		updated, err := edits.Apply(string(f.content), f.edits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update %s: %w", path, err)
+		}
+		updates[path] = []byte(updated)
+	}
+	if organize {
+		if err := manager.OrganizeImports(ctx, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	var diffs strings.Builder
+	var written []string
+	for _, path := range paths {
+		updated, ok := updates[path]
+		if !ok {
+			continue
+		}
+		rel := edits.Label(manager.WorkspaceRoot(), path)
+		if dryRun {
+			diffs.WriteString(edits.Unified(rel, string(files[path].content), string(updated)))
+		}
+		written = append(written, rel)
+	}
+	if !dryRun {
+		if err := manager.WriteFiles(updates); err != nil {
+			return nil, err
+		}
+	}
+
+	var b strings.Builder
+	verb := "Updated"
+	if dryRun {
+		verb = "Would update"
+	}
+	fmt.Fprintf(&b, "%s %s to take %s and %d call site(s) in %d file(s)", verb, p.fn.Name.Name, p.paramName, p.updatedCalls, len(written))
+	if p.todoCalls > 0 {
+		fmt.Fprintf(&b, "\n%d call site(s) had no context in scope and pass context.TODO()", p.todoCalls)
+	}
+	if p.replaced > 0 {
+		fmt.Fprintf(&b, "\nReplaced %d context.TODO()/context.Background() call(s) in the body with %s", p.replaced, p.paramName)
+	}
+	if len(p.manual) > 0 {
+		fmt.Fprintf(&b, "\nReferences that are not calls and need updating by hand:\n  %s", strings.Join(p.manual, "\n  "))
+	}
+	if dryRun {
+		b.WriteString("\n\n" + diffs.String())
+	} else {
+		fmt.Fprintf(&b, "\nFiles:\n  %s", strings.Join(written, "\n  "))
+	}
+	return mcp.NewToolResultText(b.String()), nil
+}
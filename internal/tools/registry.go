@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+// Registry holds tool definitions with their handlers and the middleware
+// every handler is wrapped in
+type Registry struct {
+	tools      []mcp.Tool
+	handlers   map[string]server.ToolHandlerFunc
+	middleware []Middleware
+	properties map[string]interface{}
+	adapters   []func(mcp.Tool) mcp.Tool
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]server.ToolHandlerFunc)}
+}
+
+// RegisterBuiltins adds every tool that ships with mcp-gopls to r
+func RegisterBuiltins(r *Registry, manager *gopls.Manager) error {
+	handlers := GetToolHandlers(manager)
+	for _, tool := range GetTools(manager) {
+		handler, ok := handlers[tool.Name]
+		if !ok {
+			return fmt.Errorf("tool %s has no handler", tool.Name)
+		}
+		if err := r.Register(tool, handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Register adds a tool. Tool names must be unique.
+func (r *Registry) Register(tool mcp.Tool, handler server.ToolHandlerFunc) error {
+	if tool.Name == "" {
+		return fmt.Errorf("tool name cannot be empty")
+	}
+	if handler == nil {
+		return fmt.Errorf("tool %s has no handler", tool.Name)
+	}
+	if _, exists := r.handlers[tool.Name]; exists {
+		return fmt.Errorf("tool %s is already registered", tool.Name)
+	}
+	r.tools = append(r.tools, tool)
+	r.handlers[tool.Name] = handler
+	return nil
+}
+
+// Use appends middleware. The first middleware added is the outermost, so it
+// sees each call first and its result last.
+func (r *Registry) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// AddProperty adds an argument every tool accepts, such as one read by
+// middleware, to the input schema of each tool that does not define an
+// argument with that name itself
+func (r *Registry) AddProperty(name string, schema map[string]interface{}) {
+	if r.properties == nil {
+		r.properties = make(map[string]interface{})
+	}
+	r.properties[name] = schema
+}
+
+// AdaptSchemas changes the definition of every tool with fn after the
+// properties added by AddProperty, e.g. to add arguments only some tools
+// accept
+func (r *Registry) AdaptSchemas(fn func(mcp.Tool) mcp.Tool) {
+	r.adapters = append(r.adapters, fn)
+}
+
+// Tools returns the registered tool definitions in registration order
+func (r *Registry) Tools() []mcp.Tool {
+	tools := make([]mcp.Tool, len(r.tools))
+	for i, tool := range r.tools {
+		tools[i] = r.withProperties(tool)
+	}
+	return tools
+}
+
+// withProperties returns tool with the properties added by AddProperty and
+// the changes of AdaptSchemas
+func (r *Registry) withProperties(tool mcp.Tool) mcp.Tool {
+	if len(r.properties) > 0 {
+		properties := make(map[string]interface{}, len(tool.InputSchema.Properties)+len(r.properties))
+		for name, schema := range r.properties {
+			properties[name] = schema
+		}
+		for name, schema := range tool.InputSchema.Properties {
+			properties[name] = schema
+		}
+		tool.InputSchema.Properties = properties
+	}
+	for _, adapt := range r.adapters {
+		tool = adapt(tool)
+	}
+	return tool
+}
+
+// Handler returns the named tool's handler wrapped in the registry's
+// middleware
+func (r *Registry) Handler(name string) (server.ToolHandlerFunc, bool) {
+	handler, ok := r.handlers[name]
+	if !ok {
+		return nil, false
+	}
+	for _, tool := range r.tools {
+		if tool.Name == name {
+			return Chain(r.withProperties(tool), handler, r.middleware...), true
+		}
+	}
+	return nil, false
+}
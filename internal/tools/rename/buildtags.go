@@ -0,0 +1,400 @@
+package rename
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/buildtags"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+)
+
+// buildConfig is a target and build tags to load packages under
+type buildConfig struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string
+	Cgo    bool
+}
+
+func (c buildConfig) String() string {
+	s := c.GOOS + "/" + c.GOARCH
+	if len(c.Tags) > 0 {
+		s += " -tags=" + strings.Join(c.Tags, ",")
+	}
+	if c.Cgo {
+		s += " cgo"
+	}
+	return s
+}
+
+func (c buildConfig) env() []string {
+	cgo := "0"
+	if c.Cgo {
+		cgo = "1"
+	}
+	return []string{"GOOS=" + c.GOOS, "GOARCH=" + c.GOARCH, "CGO_ENABLED=" + cgo}
+}
+
+func (c buildConfig) flags() []string {
+	if len(c.Tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(c.Tags, ",")}
+}
+
+// excludedFile is a file the workspace's build configuration leaves out
+// that mentions the renamed identifier
+type excludedFile struct {
+	path   string
+	config *buildConfig
+	reason string // why the file could not be renamed, if it was not
+}
+
+// untouchedFile is an excluded file mentioning the old name that the
+// rename could not check, with the reason
+type untouchedFile struct {
+	File   string
+	Reason string
+}
+
+func (f untouchedFile) String() string {
+	return fmt.Sprintf("%s: %s", f.File, f.Reason)
+}
+
+// renameInExcludedFiles finds the files of root that build constraints
+// leave out of gopls's view and that mention oldName, type-checks them
+// under a build configuration that includes them and renames the
+// references to the symbol declared at file:line:column in them. It returns
+// the new contents of the files changed and the files it could not check.
+func renameInExcludedFiles(ctx context.Context, root, file string, line, column int, oldName, newName string) (map[string][]byte, []untouchedFile, error) {
+	candidates, err := excludedFilesMentioning(root, oldName)
+	if err != nil || len(candidates) == 0 {
+		return nil, nil, err
+	}
+
+	target, err := targetKey(ctx, root, file, line, column)
+	if err != nil {
+		return nil, nil, err
+	}
+	if target == "" {
+		// A local name, which files of other configurations cannot refer to
+		return nil, nil, nil
+	}
+
+	// Load the files needing the same configuration together
+	byConfig := make(map[string][]*excludedFile)
+	configs := make(map[string]buildConfig)
+	var untouched []untouchedFile
+	for _, candidate := range candidates {
+		if candidate.config == nil {
+			untouched = append(untouched, untouchedFile{File: candidate.path, Reason: candidate.reason})
+			continue
+		}
+		key := candidate.config.String()
+		configs[key] = *candidate.config
+		byConfig[key] = append(byConfig[key], candidate)
+	}
+
+	updated := make(map[string][]byte)
+	keys := make([]string, 0, len(byConfig))
+	for key := range byConfig {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		files := byConfig[key]
+		config := configs[key]
+		dirs := make(map[string]bool)
+		var patterns []string
+		for _, f := range files {
+			dir := filepath.Dir(f.path)
+			if !dirs[dir] {
+				dirs[dir] = true
+				patterns = append(patterns, dir)
+			}
+		}
+		pkgs, err := typecheck.LoadWith(ctx, root, config.env(), config.flags(), patterns...)
+		if err != nil {
+			for _, f := range files {
+				untouched = append(untouched, untouchedFile{File: f.path, Reason: fmt.Sprintf("could not load it for %s: %v", config, err)})
+			}
+			continue
+		}
+		for _, f := range files {
+			pkg, syntax := findFile(pkgs, f.path)
+			if syntax == nil {
+				untouched = append(untouched, untouchedFile{File: f.path, Reason: fmt.Sprintf("not loaded for %s", config)})
+				continue
+			}
+			content, err := os.ReadFile(f.path)
+			if err != nil {
+				return nil, nil, err
+			}
+			renamed, count := renameIdents(pkg, syntax, content, target, oldName, newName)
+			if count == 0 && len(pkg.Errors) > 0 {
+				untouched = append(untouched, untouchedFile{File: f.path, Reason: fmt.Sprintf("does not type-check for %s: %s", config, pkg.Errors[0])})
+				continue
+			}
+			if count > 0 {
+				updated[f.path] = renamed
+			}
+		}
+	}
+	sort.Slice(untouched, func(i, j int) bool { return untouched[i].File < untouched[j].File })
+	return updated, untouched, nil
+}
+
+// excludedFilesMentioning walks root for Go files the default build
+// configuration excludes that have an identifier named name, with a
+// configuration that includes each
+func excludedFilesMentioning(root, name string) ([]*excludedFile, error) {
+	var found []*excludedFile
+	err := astscan.Walk(root, astscan.Options{IncludeTests: true}, func(f *astscan.File) error {
+		dir, base := filepath.Dir(f.Path), filepath.Base(f.Path)
+		// The go command ignores these files under any configuration
+		if strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_") {
+			return nil
+		}
+		if included, err := build.Default.MatchFile(dir, base); err != nil || included {
+			return nil
+		}
+		if !hasIdent(f.AST, name) {
+			return nil
+		}
+
+		candidate := &excludedFile{path: f.Path}
+		switch {
+		case strings.HasSuffix(base, "_test.go"):
+			candidate.reason = "test files of other build configurations are not checked; rename in it by hand if needed"
+		default:
+			candidate.config, candidate.reason = configIncluding(dir, base, f.AST)
+		}
+		found = append(found, candidate)
+		return nil
+	})
+	return found, err
+}
+
+// hasIdent reports whether file has an identifier named name
+func hasIdent(file *ast.File, name string) bool {
+	found := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && ident.Name == name {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// configIncluding finds a build configuration under which the go command
+// compiles the file: its file name suffix and the tags of its constraint
+// decide the target, and the constraint's other tags are tried set and
+// unset. It returns the reason when there is none.
+func configIncluding(dir, name string, file *ast.File) (*buildConfig, string) {
+	var tags []string
+	cgo := false
+	if expr := buildtags.FileConstraint(file); expr != nil {
+		tags = constraintTags(expr.String())
+	}
+	for _, spec := range file.Imports {
+		if spec.Path.Value == strconv.Quote("C") {
+			cgo = true
+		}
+	}
+
+	suffixOS, suffixArch := buildtags.NameSuffix(name)
+	goosOptions := []string{build.Default.GOOS}
+	goarchOptions := []string{build.Default.GOARCH}
+	var other []string
+	for _, tag := range tags {
+		switch {
+		case buildtags.KnownOS[tag]:
+			goosOptions = append(goosOptions, tag)
+		case buildtags.KnownArch[tag]:
+			goarchOptions = append(goarchOptions, tag)
+		case tag == "unix":
+			goosOptions = append(goosOptions, "linux")
+		case tag == "cgo":
+			cgo = true
+		case tag == "gc" || tag == "gccgo" || strings.HasPrefix(tag, "go1."):
+		default:
+			other = append(other, tag)
+		}
+	}
+	// Constraints such as !linux are met by any other target, so try the
+	// common ones too
+	goosOptions = append(goosOptions, "linux", "windows", "darwin")
+	goarchOptions = append(goarchOptions, "amd64", "arm64")
+	if suffixOS != "" {
+		goosOptions = []string{suffixOS}
+	}
+	if suffixArch != "" {
+		goarchOptions = []string{suffixArch}
+	}
+	tagOptions := [][]string{other, nil}
+	if len(other) == 0 {
+		tagOptions = tagOptions[1:]
+	}
+
+	for _, goos := range goosOptions {
+		for _, goarch := range goarchOptions {
+			for _, tagSet := range tagOptions {
+				ctxt := build.Default
+				ctxt.GOOS, ctxt.GOARCH, ctxt.BuildTags, ctxt.CgoEnabled = goos, goarch, tagSet, cgo
+				if ok, err := ctxt.MatchFile(dir, name); err == nil && ok {
+					if cgo && (goos != build.Default.GOOS || goarch != build.Default.GOARCH) {
+						return nil, fmt.Sprintf("uses cgo and is only compiled for %s/%s, which cgo cannot target from here", goos, goarch)
+					}
+					return &buildConfig{GOOS: goos, GOARCH: goarch, Tags: tagSet, Cgo: cgo}, ""
+				}
+			}
+		}
+	}
+	return nil, "no build configuration that includes it was found"
+}
+
+// constraintTags lists the tags of a constraint expression
+func constraintTags(expr string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, field := range strings.FieldsFunc(expr, func(r rune) bool {
+		return strings.ContainsRune("()!&| ", r)
+	}) {
+		if !seen[field] {
+			seen[field] = true
+			tags = append(tags, field)
+		}
+	}
+	return tags
+}
+
+// targetKey loads the package of file under the workspace's configuration
+// and returns the key of the symbol at line and column, see objectKey
+func targetKey(ctx context.Context, root, file string, line, column int) (string, error) {
+	pkgs, err := typecheck.Load(ctx, root, filepath.Dir(file))
+	if err != nil {
+		return "", err
+	}
+	pkg, syntax := findFile(pkgs, file)
+	if syntax == nil {
+		return "", fmt.Errorf("%s is not part of a package loaded from %s", file, root)
+	}
+	tokenFile := pkg.Fset.File(syntax.Pos())
+	if line < 1 || line > tokenFile.LineCount() {
+		return "", fmt.Errorf("line %d is outside %s", line, file)
+	}
+	pos := tokenFile.LineStart(line) + token.Pos(column-1)
+
+	var key string
+	ast.Inspect(syntax, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok || key != "" {
+			return key == ""
+		}
+		if ident.Pos() <= pos && pos <= ident.End() {
+			if obj := pkg.Info.Defs[ident]; obj != nil {
+				key = objectKey(obj)
+			} else if obj := pkg.Info.Uses[ident]; obj != nil {
+				key = objectKey(obj)
+			}
+		}
+		return true
+	})
+	return key, nil
+}
+
+// objectKey names a package-level object, method or field by its package
+// path, type and name, so the same symbol can be recognized in packages
+// type-checked separately. Local objects have no key.
+func objectKey(obj types.Object) string {
+	if obj.Pkg() == nil {
+		return ""
+	}
+	prefix := obj.Pkg().Path() + "."
+	switch obj := obj.(type) {
+	case *types.Func:
+		if recv := obj.Type().(*types.Signature).Recv(); recv != nil {
+			if named := namedOf(recv.Type()); named != nil {
+				return prefix + named.Obj().Name() + "." + obj.Name()
+			}
+			return ""
+		}
+	case *types.Var:
+		if obj.IsField() {
+			if owner := typecheck.FieldOwner(obj.Origin()); owner != "" {
+				return prefix + owner + "." + obj.Name()
+			}
+			return ""
+		}
+	case *types.PkgName:
+		return ""
+	}
+	if obj.Parent() != obj.Pkg().Scope() {
+		return ""
+	}
+	return prefix + obj.Name()
+}
+
+func namedOf(t types.Type) *types.Named {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
+
+// findFile returns the loaded package holding the file at path, with the
+// file's syntax
+func findFile(pkgs []*typecheck.Package, path string) (*typecheck.Package, *ast.File) {
+	for _, pkg := range pkgs {
+		if file := pkg.File(path); file != nil {
+			return pkg, file
+		}
+	}
+	return nil, nil
+}
+
+// renameIdents replaces the identifiers of file that refer to the object
+// with key target, returning the new content and the number replaced
+func renameIdents(pkg *typecheck.Package, file *ast.File, content []byte, target, oldName, newName string) ([]byte, int) {
+	var offsets []int
+	tokenFile := pkg.Fset.File(file.Pos())
+	ast.Inspect(file, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok || ident.Name != oldName {
+			return true
+		}
+		obj := pkg.Info.Defs[ident]
+		if obj == nil {
+			obj = pkg.Info.Uses[ident]
+		}
+		if obj != nil && objectKey(obj) == target {
+			offsets = append(offsets, tokenFile.Offset(ident.Pos()))
+		}
+		return true
+	})
+	if len(offsets) == 0 {
+		return content, 0
+	}
+	sort.Ints(offsets)
+	var b strings.Builder
+	last := 0
+	for _, offset := range offsets {
+		b.Write(content[last:offset])
+		b.WriteString(newName)
+		last = offset + len(oldName)
+	}
+	b.Write(content[last:])
+	return []byte(b.String()), len(offsets)
+}
@@ -0,0 +1,49 @@
+package rename
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExcludedFilesMentioning(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":          "module example.com/p\n\ngo 1.22\n",
+		"p.go":            "package p\n\nfunc Target() {}\n",
+		"extra.go":        "//go:build extra\n\npackage p\n\nfunc use() { Target() }\n",
+		"other.go":        "//go:build extra\n\npackage p\n\nfunc unrelated() {}\n",
+		"_ignored.go":     "//go:build extra\n\npackage p\n\nfunc ignored() { Target() }\n",
+		"extra_test.go":   "//go:build extra\n\npackage p\n\nfunc helper() { Target() }\n",
+		"testdata/old.go": "//go:build extra\n\npackage old\n\nfunc old() { Target() }\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found, err := excludedFilesMentioning(root, "Target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]*excludedFile)
+	for _, f := range found {
+		rel, _ := filepath.Rel(root, f.path)
+		got[rel] = f
+	}
+	if len(got) != 2 || got["extra.go"] == nil || got["extra_test.go"] == nil {
+		t.Fatalf("excludedFilesMentioning found %v, want extra.go and extra_test.go", got)
+	}
+	if config := got["extra.go"].config; config == nil || !reflect.DeepEqual(config.Tags, []string{"extra"}) {
+		t.Errorf("extra.go config = %+v, want the extra tag", config)
+	}
+	if got["extra_test.go"].config != nil {
+		t.Errorf("extra_test.go got a configuration, want it left to the user")
+	}
+}
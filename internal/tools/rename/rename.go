@@ -4,11 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"sort"
-	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/edits"
 	"github.com/yantrio/mcp-gopls/internal/gopls"
 	"github.com/yantrio/mcp-gopls/internal/lsp"
 	"github.com/yantrio/mcp-gopls/internal/utils"
@@ -37,6 +36,31 @@ func NewTool(manager *gopls.Manager) mcp.Tool {
 					"type":        "string",
 					"description": "New name for the symbol",
 				},
+				"organizeImports": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Organize imports in every changed file before writing it",
+					"default":     false,
+				},
+				"updateComments": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also replace the old name as a whole word in comments and doc comments of the affected packages. This is a text replacement, listed separately in the result.",
+					"default":     false,
+				},
+				"updateStrings": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also replace the old name as a whole word in string literals of the affected packages. This is a text replacement, listed separately in the result.",
+					"default":     false,
+				},
+				"buildConfigurations": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also rename in files that build constraints leave out of gopls's configuration, such as _windows.go files on Linux, by type-checking them under a configuration that includes them. Excluded files that could not be checked are listed.",
+					"default":     true,
+				},
+				"verify": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Give gopls the renamed files as unsaved changes first and write them only if the rename introduces no compile errors; otherwise the errors are returned and nothing is written",
+					"default":     false,
+				},
 			},
 			Required: []string{"file", "line", "column", "newName"},
 		},
@@ -76,7 +100,7 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 			return nil, err
 		}
 
-		content, err := os.ReadFile(file)
+		content, err := manager.ReadFile(file)
 		if err != nil {
 			return nil, err
 		}
@@ -88,11 +112,16 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 
 		position := utils.ConvertPosition(line, column)
 		
-		// First, check if rename is possible at this location
-		prepareResult, prepareErr := client.PrepareRename(ctx, uri, position)
-		if prepareErr != nil {
-			// If prepareRename fails, it might mean rename is not supported at this location
-			// Let's still try the rename operation
+		// First, check if rename is possible at this location, if the
+		// server supports asking
+		var prepareResult *lsp.PrepareRenameResult
+		if client.CanPrepareRename() {
+			var prepareErr error
+			prepareResult, prepareErr = client.PrepareRename(ctx, uri, position)
+			if prepareErr != nil {
+				// If prepareRename fails, it might mean rename is not supported at this location
+				// Let's still try the rename operation
+			}
 		}
 		
 		// Debug info
@@ -114,54 +143,124 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 			return mcp.NewToolResultText(fmt.Sprintf("No changes needed for rename\n%s", debugInfo)), nil
 		}
 
-		// Apply the edits to files
+		fileEdits, err := edits.FileEdits(workspaceEdit, manager.URIToPath)
+		if err != nil {
+			return nil, err
+		}
+		// gopls computed the edits against any staged content, so they
+		// cannot be applied to the files on disk
+		for filePath := range fileEdits {
+			if err := manager.CheckNotStaged(filePath); err != nil {
+				return nil, err
+			}
+		}
+
+		// Compute every file's new content first so the rename is written as
+		// one change
 		filesModified := make(map[string]bool)
 		var errors []string
+		updated := make(map[string][]byte)
+		for filePath, textEdits := range fileEdits {
+			original, err := os.ReadFile(filePath)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("Failed to read %s: %v", filePath, err))
+				continue
+			}
+			text, err := edits.Apply(string(original), textEdits)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("Failed to apply edits to %s: %v", filePath, err))
+				continue
+			}
+			updated[filePath] = []byte(text)
+		}
 
-		// Handle both changes and documentChanges formats
-		if len(workspaceEdit.DocumentChanges) > 0 {
-			// Process documentChanges
-			for _, docEdit := range workspaceEdit.DocumentChanges {
-				filePath, err := utils.URIToPath(docEdit.TextDocument.URI)
-				if err != nil {
-					errors = append(errors, fmt.Sprintf("Failed to parse URI %s: %v", docEdit.TextDocument.URI, err))
-					continue
-				}
-
-				if err := applyEditsToFile(filePath, docEdit.Edits); err != nil {
-					errors = append(errors, fmt.Sprintf("Failed to apply edits to %s: %v", filePath, err))
-					continue
-				}
-				filesModified[filePath] = true
+		oldName := identAt(string(content), position.Line, position.Character)
+		if prepareResult != nil && prepareResult.Placeholder != "" {
+			oldName = prepareResult.Placeholder
+		}
+		var excluded map[string][]byte
+		var untouched []untouchedFile
+		if request.GetBool("buildConfigurations", true) && oldName != "" && oldName != newName {
+			excluded, untouched, err = renameInExcludedFiles(ctx, manager.WorkspaceRoot(), file, line, column, oldName, newName)
+			if err != nil {
+				errors = append(errors, fmt.Sprintf("Failed to check the files excluded by build constraints: %v", err))
 			}
-		} else {
-			// Process regular changes
-			for fileURI, edits := range workspaceEdit.Changes {
-				filePath, err := utils.URIToPath(fileURI)
-				if err != nil {
-					errors = append(errors, fmt.Sprintf("Failed to parse URI %s: %v", fileURI, err))
-					continue
+			for filePath, content := range excluded {
+				if _, ok := updated[filePath]; !ok {
+					updated[filePath] = content
 				}
+			}
+		}
+		updateComments := request.GetBool("updateComments", false)
+		updateStrings := request.GetBool("updateStrings", false)
+		var textChanges []textChange
+		if (updateComments || updateStrings) && oldName != "" && oldName != newName {
+			textChanges, err = renameInPackages(updated, oldName, newName, updateComments, updateStrings)
+			if err != nil {
+				return nil, err
+			}
+		}
 
-				if err := applyEditsToFile(filePath, edits); err != nil {
-					errors = append(errors, fmt.Sprintf("Failed to apply edits to %s: %v", filePath, err))
-					continue
+		if request.GetBool("organizeImports", false) {
+			if err := manager.OrganizeImports(ctx, updated); err != nil {
+				return nil, err
+			}
+		}
+		verified := false
+		if request.GetBool("verify", false) {
+			introduced, err := verifyInOverlay(ctx, manager, client, updated)
+			if err != nil {
+				return nil, err
+			}
+			if len(introduced) > 0 {
+				msg := fmt.Sprintf("Rename of '%s' to '%s' not applied: it would introduce %d compile error(s):\n", oldName, newName, len(introduced))
+				for _, e := range introduced {
+					msg += fmt.Sprintf("  - %s\n", e)
 				}
-				filesModified[filePath] = true
+				return mcp.NewToolResultError(msg), nil
 			}
+			verified = true
+		}
+		if err := manager.WriteFiles(updated); err != nil {
+			return nil, err
+		}
+		for filePath := range updated {
+			filesModified[filePath] = true
 		}
 
 		// Prepare result message
 		var resultMsg string
 		if len(filesModified) > 0 {
-			resultMsg = fmt.Sprintf("Successfully renamed '%s' to '%s' in %d file(s):\n", prepareResult.Placeholder, newName, len(filesModified))
+			resultMsg = fmt.Sprintf("Successfully renamed '%s' to '%s' in %d file(s):\n", oldName, newName, len(filesModified))
 			for file := range filesModified {
 				resultMsg += fmt.Sprintf("  - %s\n", file)
 			}
+			if len(excluded) > 0 {
+				resultMsg += fmt.Sprintf("\nOf these, %d file(s) are excluded by build constraints and were type-checked under another configuration:\n", len(excluded))
+				for file := range excluded {
+					resultMsg += fmt.Sprintf("  - %s\n", file)
+				}
+			}
+			if len(textChanges) > 0 {
+				resultMsg += fmt.Sprintf("\nText replacements in comments and strings (%d line(s), review these):\n", len(textChanges))
+				for _, change := range textChanges {
+					resultMsg += fmt.Sprintf("  - %s\n", change)
+				}
+			}
+			if verified {
+				resultMsg += "\nVerified before writing: the rename introduces no compile errors.\n"
+			}
 		} else {
 			resultMsg = "No files were modified"
 		}
 
+		if len(untouched) > 0 {
+			resultMsg += fmt.Sprintf("\nFiles excluded by build constraints that mention '%s' but could not be checked, review them by hand:\n", oldName)
+			for _, skipped := range untouched {
+				resultMsg += fmt.Sprintf("  - %s\n", skipped)
+			}
+		}
+
 		if len(errors) > 0 {
 			resultMsg += "\nErrors:\n"
 			for _, err := range errors {
@@ -172,73 +271,3 @@ func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 		return mcp.NewToolResultText(resultMsg), nil
 	}
 }
-
-// applyEditsToFile applies text edits to a file
-func applyEditsToFile(filePath string, edits []lsp.TextEdit) error {
-	// Read the file
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
-	}
-
-	// Convert content to lines
-	lines := strings.Split(string(content), "\n")
-
-	// Sort edits in reverse order (from end to beginning) to avoid offset issues
-	sortedEdits := make([]lsp.TextEdit, len(edits))
-	copy(sortedEdits, edits)
-	sort.Slice(sortedEdits, func(i, j int) bool {
-		if sortedEdits[i].Range.Start.Line != sortedEdits[j].Range.Start.Line {
-			return sortedEdits[i].Range.Start.Line > sortedEdits[j].Range.Start.Line
-		}
-		return sortedEdits[i].Range.Start.Character > sortedEdits[j].Range.Start.Character
-	})
-
-	// Apply edits
-	for _, edit := range sortedEdits {
-		startLine := edit.Range.Start.Line
-		startChar := edit.Range.Start.Character
-		endLine := edit.Range.End.Line
-		endChar := edit.Range.End.Character
-
-		// Validate line numbers
-		if startLine >= len(lines) || endLine >= len(lines) {
-			return fmt.Errorf("invalid line number: start=%d, end=%d, total=%d", startLine, endLine, len(lines))
-		}
-
-		// Handle single-line edit
-		if startLine == endLine {
-			line := lines[startLine]
-			if startChar > len(line) || endChar > len(line) {
-				return fmt.Errorf("invalid character position: line=%d, start=%d, end=%d, length=%d", startLine, startChar, endChar, len(line))
-			}
-			lines[startLine] = line[:startChar] + edit.NewText + line[endChar:]
-		} else {
-			// Multi-line edit
-			startLineContent := lines[startLine]
-			endLineContent := lines[endLine]
-			
-			if startChar > len(startLineContent) || endChar > len(endLineContent) {
-				return fmt.Errorf("invalid character position in multi-line edit")
-			}
-
-			// Create new content
-			newContent := startLineContent[:startChar] + edit.NewText + endLineContent[endChar:]
-			
-			// Replace the lines
-			newLines := append(lines[:startLine], newContent)
-			if endLine+1 < len(lines) {
-				newLines = append(newLines, lines[endLine+1:]...)
-			}
-			lines = newLines
-		}
-	}
-
-	// Write back to file
-	newContent := strings.Join(lines, "\n")
-	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
-}
\ No newline at end of file
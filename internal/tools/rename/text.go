@@ -0,0 +1,185 @@
+package rename
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// textChange is a rename of the old name in a comment or string literal,
+// which gopls leaves alone because it is not an identifier
+type textChange struct {
+	file   string
+	line   int
+	before string
+	after  string
+}
+
+// renameInText replaces whole-word occurrences of oldName with newName in the
+// comments and, when inStrings is set, the string literals of src
+func renameInText(src []byte, oldName, newName string, inComments, inStrings bool) ([]byte, []int) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	var out []byte
+	var lines []int
+	last := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		switch {
+		case tok == token.COMMENT && inComments:
+		case (tok == token.STRING || tok == token.CHAR) && inStrings:
+		default:
+			continue
+		}
+		replaced, at := replaceWord(lit, oldName, newName)
+		if len(at) == 0 {
+			continue
+		}
+		offset := file.Offset(pos)
+		out = append(out, src[last:offset]...)
+		out = append(out, replaced...)
+		last = offset + len(lit)
+		for _, i := range at {
+			lines = append(lines, file.Line(pos)+strings.Count(lit[:i], "\n"))
+		}
+	}
+	if lines == nil {
+		return src, nil
+	}
+	return append(out, src[last:]...), lines
+}
+
+// replaceWord replaces occurrences of word in text that are not part of a
+// longer identifier, returning the new text and the offsets in text of the
+// replaced occurrences
+func replaceWord(text, word, replacement string) (string, []int) {
+	var b strings.Builder
+	var at []int
+	for start := 0; ; {
+		i := strings.Index(text[start:], word)
+		if i < 0 {
+			b.WriteString(text[start:])
+			return b.String(), at
+		}
+		i += start
+		end := i + len(word)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		b.WriteString(text[start:i])
+		if (i > 0 && isIdentRune(before)) || (end < len(text) && isIdentRune(after)) {
+			b.WriteString(word)
+		} else {
+			b.WriteString(replacement)
+			at = append(at, i)
+		}
+		start = end
+	}
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// renameInPackages updates comments and string literals in every Go file of
+// the packages the rename touched. Files already changed by the rename are
+// read from updated, and any file changed here is added to it.
+func renameInPackages(updated map[string][]byte, oldName, newName string, inComments, inStrings bool) ([]textChange, error) {
+	dirs := make(map[string]bool)
+	for path := range updated {
+		dirs[filepath.Dir(path)] = true
+	}
+
+	var changes []textChange
+	for dir := range dirs {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			content, ok := updated[path]
+			if !ok {
+				if content, err = os.ReadFile(path); err != nil {
+					return nil, err
+				}
+			}
+			renamed, lines := renameInText(content, oldName, newName, inComments, inStrings)
+			if len(lines) == 0 {
+				continue
+			}
+			beforeLines := strings.Split(string(content), "\n")
+			afterLines := strings.Split(string(renamed), "\n")
+			for _, line := range uniqueLines(lines) {
+				if line > len(beforeLines) || line > len(afterLines) {
+					continue
+				}
+				changes = append(changes, textChange{
+					file:   path,
+					line:   line,
+					before: strings.TrimSpace(beforeLines[line-1]),
+					after:  strings.TrimSpace(afterLines[line-1]),
+				})
+			}
+			updated[path] = renamed
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].file != changes[j].file {
+			return changes[i].file < changes[j].file
+		}
+		return changes[i].line < changes[j].line
+	})
+	return changes, nil
+}
+
+// uniqueLines drops repeated line numbers from an ascending list
+func uniqueLines(lines []int) []int {
+	var unique []int
+	for i, line := range lines {
+		if i == 0 || line != lines[i-1] {
+			unique = append(unique, line)
+		}
+	}
+	return unique
+}
+
+func (c textChange) String() string {
+	return fmt.Sprintf("%s:%d: %s  =>  %s", c.file, c.line, c.before, c.after)
+}
+
+// identAt returns the identifier at a 0-indexed line and byte column, or an
+// empty string if there is none
+func identAt(content string, line, column int) string {
+	lines := strings.Split(content, "\n")
+	if line < 0 || line >= len(lines) || column < 0 || column > len(lines[line]) {
+		return ""
+	}
+	text := lines[line]
+	start, end := column, column
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:start])
+		if !isIdentRune(r) {
+			break
+		}
+		start -= size
+	}
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !isIdentRune(r) {
+			break
+		}
+		end += size
+	}
+	return text[start:end]
+}
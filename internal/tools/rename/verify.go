@@ -0,0 +1,137 @@
+package rename
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// verifySettle is how long gopls must be quiet before diagnostics count as
+// complete, and verifyTimeout how long verification may take in all
+const (
+	verifySettle  = time.Second
+	verifyTimeout = time.Minute
+)
+
+// compileError is an error diagnostic the rename introduced
+type compileError struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func (e compileError) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// verifyInOverlay hands the renamed contents to gopls as unsaved changes,
+// waits for it to diagnose them and returns the errors that were not
+// reported before. The files on disk are not touched and gopls is given
+// their contents back before returning.
+func verifyInOverlay(ctx context.Context, manager *gopls.Manager, client *lsp.Client, updated map[string][]byte) ([]compileError, error) {
+	ctx, cancel := context.WithTimeout(ctx, verifyTimeout)
+	defer cancel()
+
+	if err := client.WaitForDiagnostics(ctx, verifySettle); err != nil {
+		return nil, unfinished(err)
+	}
+	before := client.AllDiagnostics()
+
+	originals := make(map[string]string, len(updated))
+	defer func() {
+		// Restore with a fresh context, verification may have timed out
+		restore := context.Background()
+		for uri, content := range originals {
+			client.ChangeDocument(restore, uri, content)
+			client.CloseDocument(restore, uri)
+		}
+	}()
+	for path, content := range updated {
+		uri, err := utils.PathToURI(path)
+		if err != nil {
+			return nil, err
+		}
+		original, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := client.OpenDocument(ctx, uri, string(original)); err != nil {
+			return nil, err
+		}
+		originals[uri] = string(original)
+		if err := client.ChangeDocument(ctx, uri, string(content)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := client.WaitForDiagnostics(ctx, verifySettle); err != nil {
+		return nil, unfinished(err)
+	}
+	return introducedErrors(manager, before, client.AllDiagnostics()), nil
+}
+
+func unfinished(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("gopls did not finish diagnosing within %s, so the rename could not be verified; retry, or rename without verify", verifyTimeout)
+	}
+	return err
+}
+
+// introducedErrors returns the error diagnostics of after that before does
+// not have, matched by file and message so that errors moved by the rename
+// are not reported. Repeated identical errors are matched by count.
+func introducedErrors(manager *gopls.Manager, before, after lsp.DiagnosticsSnapshot) []compileError {
+	remaining := make(map[string]int)
+	for uri, diagnostics := range before.Diagnostics {
+		for _, diag := range diagnostics {
+			if isError(diag) {
+				remaining[uri+"\x00"+diag.Message]++
+			}
+		}
+	}
+
+	var introduced []compileError
+	for uri, diagnostics := range after.Diagnostics {
+		for _, diag := range diagnostics {
+			if !isError(diag) {
+				continue
+			}
+			key := uri + "\x00" + diag.Message
+			if remaining[key] > 0 {
+				remaining[key]--
+				continue
+			}
+			file, err := manager.URIToPath(uri)
+			if err != nil {
+				file = uri
+			}
+			line, column := utils.ConvertToUserPosition(diag.Range.Start)
+			introduced = append(introduced, compileError{File: file, Line: line, Column: column, Message: diag.Message})
+		}
+	}
+	sort.Slice(introduced, func(i, j int) bool {
+		a, b := introduced[i], introduced[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return introduced
+}
+
+// isError reports whether diag is an error; a missing severity counts as
+// one, as in DiffDiagnostics
+func isError(diag lsp.Diagnostic) bool {
+	return diag.Severity == 0 || diag.Severity == lsp.DiagnosticSeverityError
+}
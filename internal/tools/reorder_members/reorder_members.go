@@ -0,0 +1,336 @@
+package reorder_members
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "ReorderMembers",
+		Description: "Reorder a struct's fields (embedded first, then exported, then unexported, grouped by type) or its methods to follow an interface's declaration order, keeping comments attached",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file declaring the type",
+				},
+				"type": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the type to reorder",
+				},
+				"mode": map[string]interface{}{
+					"type":        "string",
+					"description": "'fields' to sort struct fields or 'methods' to order methods like an interface",
+					"enum":        []string{"fields", "methods"},
+					"default":     "fields",
+				},
+				"interface": map[string]interface{}{
+					"type":        "string",
+					"description": "Interface whose method order to follow in 'methods' mode: a name in the same package or an import-path-qualified name (e.g. io.ReadWriteCloser)",
+				},
+				"dryRun": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Report the changes as a unified diff without modifying any files",
+					"default":     false,
+				},
+			},
+			Required: []string{"file", "type"},
+		},
+	}
+}
+
+// chunk is a source range moved as a unit, such as a field or method together
+// with its comments
+type chunk struct {
+	start, end int
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		typeName, err := request.RequireString("type")
+		if err != nil {
+			return nil, err
+		}
+		mode := request.GetString("mode", "fields")
+		if mode != "fields" && mode != "methods" {
+			return nil, fmt.Errorf("invalid mode %q: must be 'fields' or 'methods'", mode)
+		}
+		ifaceName := request.GetString("interface", "")
+		if mode == "methods" && ifaceName == "" {
+			return nil, fmt.Errorf("interface is required in 'methods' mode")
+		}
+		dryRun := request.GetBool("dryRun", false)
+
+		target, err := codegen.FindType(ctx, file, typeName)
+		if err != nil {
+			return nil, err
+		}
+
+		var updates map[string]string
+		if mode == "fields" {
+			updates, err = reorderFields(target)
+		} else {
+			updates, err = reorderMethods(target, ifaceName)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(updates) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("The %s of %s are already in order", mode, typeName)), nil
+		}
+
+		paths := make([]string, 0, len(updates))
+		for path := range updates {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		var b strings.Builder
+		verb := "Reordered"
+		if dryRun {
+			verb = "Would reorder"
+		}
+		fmt.Fprintf(&b, "%s the %s of %s in %d file(s)", verb, mode, typeName, len(paths))
+		for _, path := range paths {
+			if dryRun {
+				original, err := os.ReadFile(path)
+				if err != nil {
+					return nil, err
+				}
+				b.WriteString("\n\n" + edits.Unified(edits.Label(manager.WorkspaceRoot(), path), string(original), updates[path]))
+				continue
+			}
+			if err := manager.WriteFiles(map[string][]byte{path: []byte(updates[path])}); err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&b, "\n  %s", path)
+		}
+		return mcp.NewToolResultText(b.String()), nil
+	}
+}
+
+// reorderFields sorts the struct's fields and returns the updated file
+// content, or nothing if the order does not change
+func reorderFields(target *codegen.Target) (map[string]string, error) {
+	st, ok := target.Spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct type", target.Obj.Name())
+	}
+	fields := st.Fields.List
+	if len(fields) < 2 {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(target.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rank fields by section, then group fields of the same type in the
+	// order their type first appears within the section
+	type ranked struct {
+		index   int
+		section int
+		group   int
+	}
+	groups := make(map[string]int)
+	ranks := make([]ranked, len(fields))
+	for i, field := range fields {
+		section := 2
+		if len(field.Names) == 0 {
+			section = 0
+		} else if field.Names[0].IsExported() {
+			section = 1
+		}
+		key := fmt.Sprintf("%d %s", section, types.ExprString(field.Type))
+		if _, ok := groups[key]; !ok {
+			groups[key] = len(groups)
+		}
+		ranks[i] = ranked{index: i, section: section, group: groups[key]}
+	}
+	sort.SliceStable(ranks, func(i, j int) bool {
+		if ranks[i].section != ranks[j].section {
+			return ranks[i].section < ranks[j].section
+		}
+		return ranks[i].group < ranks[j].group
+	})
+
+	order := make([]int, len(ranks))
+	changed := false
+	for i, r := range ranks {
+		order[i] = r.index
+		changed = changed || r.index != i
+	}
+	if !changed {
+		return nil, nil
+	}
+
+	fset := target.Pkg.Fset
+	slots := make([]chunk, len(fields))
+	for i, field := range fields {
+		slots[i] = nodeChunk(fset, field, field.Doc, field.Comment)
+	}
+
+	// Rebuild the declaration and gofmt it on its own so field alignment is
+	// recomputed without touching the rest of the file
+	declStart := fset.Position(target.Decl.Pos()).Offset
+	declEnd := fset.Position(target.Decl.End()).Offset
+	decl := fill(content, declStart, declEnd, slots, order)
+	formatted, err := format.Source([]byte("package p\n\n" + decl))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format reordered struct: %w", err)
+	}
+	decl = strings.TrimSuffix(strings.TrimPrefix(string(formatted), "package p\n\n"), "\n")
+
+	updated := string(content[:declStart]) + decl + string(content[declEnd:])
+	return map[string]string{target.Path: updated}, nil
+}
+
+// reorderMethods moves the type's methods that belong to the interface into
+// the interface's declaration order. Within each file the interface methods
+// trade places, so other declarations stay where they are.
+func reorderMethods(target *codegen.Target, ifaceName string) (map[string]string, error) {
+	iface, err := lookupInterface(target.Pkg, ifaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := make([]*types.Func, iface.NumMethods())
+	for i := range methods {
+		methods[i] = iface.Method(i)
+	}
+	// Positions follow declaration order within the interface's file
+	sort.SliceStable(methods, func(i, j int) bool { return methods[i].Pos() < methods[j].Pos() })
+	rank := make(map[string]int)
+	for i, m := range methods {
+		rank[m.Name()] = i
+	}
+
+	updates := make(map[string]string)
+	fset := target.Pkg.Fset
+	for _, file := range target.Pkg.Files {
+		var decls []*ast.FuncDecl
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+				continue
+			}
+			if astscan.ReceiverType(fn.Recv.List[0].Type) != target.Obj.Name() {
+				continue
+			}
+			if _, ok := rank[fn.Name.Name]; ok {
+				decls = append(decls, fn)
+			}
+		}
+		if len(decls) < 2 {
+			continue
+		}
+
+		order := make([]int, len(decls))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return rank[decls[order[i]].Name.Name] < rank[decls[order[j]].Name.Name]
+		})
+		changed := false
+		for i, o := range order {
+			changed = changed || o != i
+		}
+		if !changed {
+			continue
+		}
+
+		path := fset.Position(file.Pos()).Filename
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		slots := make([]chunk, len(decls))
+		for i, fn := range decls {
+			slots[i] = nodeChunk(fset, fn, fn.Doc, nil)
+		}
+		updates[path] = fill(content, 0, len(content), slots, order)
+	}
+
+	if len(updates) == 0 {
+		for _, m := range methods {
+			if !target.HasMethod(m.Name()) {
+				return nil, fmt.Errorf("%s does not implement %s: missing method %s", target.Obj.Name(), ifaceName, m.Name())
+			}
+		}
+	}
+	return updates, nil
+}
+
+// lookupInterface resolves an interface name in pkg or a package it imports
+func lookupInterface(pkg *typecheck.Package, name string) (*types.Interface, error) {
+	scope := pkg.Types.Scope()
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		imported, err := pkg.Import(name[:dot])
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", name[:dot], err)
+		}
+		scope = imported.Scope()
+		name = name[dot+1:]
+	}
+	obj, ok := scope.Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("type %s not found", name)
+	}
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an interface type", name)
+	}
+	return iface, nil
+}
+
+// nodeChunk returns the byte range of node extended to cover its doc and
+// trailing comments
+func nodeChunk(fset *token.FileSet, node ast.Node, doc, comment *ast.CommentGroup) chunk {
+	start, end := node.Pos(), node.End()
+	if doc != nil {
+		start = doc.Pos()
+	}
+	if comment != nil && comment.End() > end {
+		end = comment.End()
+	}
+	return chunk{start: fset.Position(start).Offset, end: fset.Position(end).Offset}
+}
+
+// fill rebuilds content[from:to], putting the chunk originally at
+// slots[order[i]] into slot i and keeping the text between slots
+func fill(content []byte, from, to int, slots []chunk, order []int) string {
+	var b strings.Builder
+	pos := from
+	for i, slot := range slots {
+		b.Write(content[pos:slot.start])
+		src := slots[order[i]]
+		b.Write(content[src.start:src.end])
+		pos = slot.end
+	}
+	b.Write(content[pos:to])
+	return b.String()
+}
@@ -0,0 +1,223 @@
+package replace_text
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "ReplaceText",
+		Description: "Regex replace across Go files, restricted to a syntactic context (identifiers, string literals, comments, or code outside strings and comments). It returns a diff without changing files unless dryRun is false, and refuses to write a file the replacement would leave unparsable.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "Regular expression in Go RE2 syntax",
+				},
+				"replacement": map[string]interface{}{
+					"type":        "string",
+					"description": "Replacement text; $1 or ${name} expand to submatches",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Go file or directory to search recursively, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"context": map[string]interface{}{
+					"type":        "string",
+					"description": "Where matches may occur: identifiers (within a single identifier), strings (within string and rune literals), comments, code (anywhere outside strings and comments) or any",
+					"enum":        []string{"identifiers", "strings", "comments", "code", "any"},
+					"default":     "code",
+				},
+				"includeTests": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also replace in _test.go files",
+					"default":     true,
+				},
+				"dryRun": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Only report the diff. Review it, then call again with dryRun false to write the files.",
+					"default":     true,
+				},
+			},
+			Required: []string{"pattern", "replacement"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		pattern, err := request.RequireString("pattern")
+		if err != nil {
+			return nil, err
+		}
+		replacement, err := request.RequireString("replacement")
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		scope := request.GetString("context", "code")
+		switch scope {
+		case "identifiers", "strings", "comments", "code", "any":
+		default:
+			return nil, fmt.Errorf("context must be one of identifiers, strings, comments, code or any, got %q", scope)
+		}
+		dryRun := request.GetBool("dryRun", true)
+
+		files, err := astscan.Paths(manager.ResolvePath(request.GetString("path", "")), astscan.Options{IncludeTests: request.GetBool("includeTests", true)})
+		if err != nil {
+			return nil, err
+		}
+
+		updated := make(map[string][]byte)
+		var diff strings.Builder
+		var rejected []string
+		total := 0
+		for _, path := range files {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			out, n := replace(src, re, replacement, scope)
+			if n == 0 || bytes.Equal(out, src) {
+				continue
+			}
+			// Only guard files that parsed before the replacement
+			fset := token.NewFileSet()
+			if _, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution); err == nil {
+				if _, err := parser.ParseFile(fset, path, out, parser.SkipObjectResolution); err != nil {
+					rejected = append(rejected, fmt.Sprintf("%s: %v", path, err))
+					continue
+				}
+			}
+			total += n
+			updated[path] = out
+			diff.WriteString(edits.Unified(edits.Label(manager.WorkspaceRoot(), path), string(src), string(out)))
+		}
+
+		var msg strings.Builder
+		verb := "Replaced"
+		if dryRun {
+			verb = "Would replace"
+		}
+		fmt.Fprintf(&msg, "%s %d match(es) in %d file(s) (context: %s)", verb, total, len(updated), scope)
+		if len(rejected) > 0 {
+			fmt.Fprintf(&msg, "\n\nSkipped %d file(s) the replacement would leave with syntax errors:\n  - %s", len(rejected), strings.Join(rejected, "\n  - "))
+		}
+		if diff.Len() > 0 {
+			msg.WriteString("\n\n" + diff.String())
+		}
+		if dryRun || len(updated) == 0 {
+			return mcp.NewToolResultText(msg.String()), nil
+		}
+
+		if err := manager.WriteFiles(updated); err != nil {
+			return nil, err
+		}
+		for path := range updated {
+			if err := manager.NotifyFileWritten(ctx, path, false); err != nil {
+				fmt.Fprintf(&msg, "\nNote: gopls was not notified of the change to %s: %v", path, err)
+			}
+		}
+		return mcp.NewToolResultText(msg.String()), nil
+	}
+}
+
+// replace applies re to the parts of src that scope allows, returning the
+// new source and the number of matches replaced
+func replace(src []byte, re *regexp.Regexp, replacement, scope string) ([]byte, int) {
+	if scope == "any" {
+		n := len(re.FindAllIndex(src, -1))
+		return re.ReplaceAll(src, []byte(replacement)), n
+	}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	var out []byte
+	n := 0
+	last := 0
+	// apply replaces in src[start:end] and copies everything before it
+	apply := func(start, end int) {
+		segment := src[start:end]
+		if matches := len(re.FindAllIndex(segment, -1)); matches > 0 {
+			n += matches
+			segment = re.ReplaceAll(segment, []byte(replacement))
+		}
+		out = append(out, src[last:start]...)
+		out = append(out, segment...)
+		last = end
+	}
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		start := file.Offset(pos)
+		end := literalEnd(src, start, lit)
+		isText := tok == token.COMMENT || tok == token.STRING || tok == token.CHAR
+		switch {
+		case scope == "identifiers" && tok == token.IDENT,
+			scope == "strings" && (tok == token.STRING || tok == token.CHAR),
+			scope == "comments" && tok == token.COMMENT:
+			apply(start, end)
+		case scope == "code" && isText:
+			// Code runs up to the start of each comment or literal
+			apply(last, start)
+			last = end
+			out = append(out, src[start:end]...)
+		}
+	}
+	if scope == "code" {
+		apply(last, len(src))
+	}
+	if n == 0 {
+		return src, 0
+	}
+	return append(out, src[last:]...), n
+}
+
+// literalEnd returns the offset just past the token lit starting at start.
+// The scanner drops carriage returns from comments and raw strings, so their
+// ends are found in src rather than from the literal's length.
+func literalEnd(src []byte, start int, lit string) int {
+	rest := src[start:]
+	switch {
+	case strings.HasPrefix(lit, "//"):
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			if i > 0 && rest[i-1] == '\r' {
+				i--
+			}
+			return start + i
+		}
+		return len(src)
+	case strings.HasPrefix(lit, "/*"):
+		if i := bytes.Index(rest[2:], []byte("*/")); i >= 0 {
+			return start + 2 + i + 2
+		}
+	case strings.HasPrefix(lit, "`"):
+		if i := bytes.IndexByte(rest[1:], '`'); i >= 0 {
+			return start + 1 + i + 1
+		}
+	}
+	return start + len(lit)
+}
@@ -0,0 +1,342 @@
+package report_init_order
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/codegen"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/resultfilter"
+)
+
+// maxInitializer is the length initializer expressions are cut to
+const maxInitializer = 120
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "ReportInitOrder",
+		Description: "List the package-level variables with initializers and the init functions of a package or the workspace, in the order Go runs them per file, with the functions they call and the other packages they reach into. Flags initialization that depends on another workspace package's global state, a common cause of startup-order bugs.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Package directory or directory tree to scan, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"recursive": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Scan the packages below path too; when false only the package in path is reported",
+					"default":     true,
+				},
+				"crossPackageOnly": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Only report initializers and init functions that depend on other workspace packages",
+					"default":     false,
+				},
+				"includeVendor": resultfilter.IncludeVendorProperty(manager),
+			},
+		},
+	}
+}
+
+// initializer is a package-level variable with an initializer expression, or
+// an init function
+type initializer struct {
+	Kind string `json:"kind"`
+	// Name is the variable name, or "init" for init functions
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	// Expression is the initializer of a variable
+	Expression string `json:"expression,omitempty"`
+	// Calls are the functions called while initializing
+	Calls []string `json:"calls,omitempty"`
+	// Uses are the imported identifiers read, as importpath.Name
+	Uses []string `json:"uses,omitempty"`
+	// Dependencies are the workspace packages whose initialized variables
+	// are among Uses, or which have init functions, so their initialization
+	// must run first
+	Dependencies []string `json:"dependsOnInitOf,omitempty"`
+}
+
+type packageReport struct {
+	ImportPath   string         `json:"importPath"`
+	Dir          string         `json:"dir"`
+	Initializers []*initializer `json:"initializers"`
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		root := manager.ResolvePath(request.GetString("path", ""))
+		opts := astscan.Options{
+			SingleDir:     !request.GetBool("recursive", true),
+			IncludeVendor: request.GetBool("includeVendor", manager.IncludeVendor()),
+		}
+
+		byDir := make(map[string]*packageReport)
+		err := astscan.Walk(root, opts, func(f *astscan.File) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			dir := filepath.Dir(f.Path)
+			pkg := byDir[dir]
+			if pkg == nil {
+				importPath, _ := codegen.ImportPath(dir)
+				pkg = &packageReport{ImportPath: importPath, Dir: dir, Initializers: make([]*initializer, 0)}
+				byDir[dir] = pkg
+			}
+			pkg.Initializers = append(pkg.Initializers, scanFile(f)...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// The initialization of each package, which runs before that of the
+		// packages importing it. Imports may lead outside the scanned tree, so
+		// the rest of the workspace is checked too.
+		states := make(map[string]*initState)
+		for _, pkg := range byDir {
+			states[pkg.ImportPath] = newInitState(states[pkg.ImportPath], pkg.Initializers)
+		}
+		if workspace := manager.WorkspaceRoot(); root != workspace || opts.SingleDir {
+			err := astscan.Walk(workspace, astscan.Options{IncludeVendor: opts.IncludeVendor}, func(f *astscan.File) error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if _, scanned := byDir[filepath.Dir(f.Path)]; scanned {
+					return nil
+				}
+				if importPath, err := codegen.ImportPath(filepath.Dir(f.Path)); err == nil {
+					states[importPath] = newInitState(states[importPath], scanFile(f))
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		crossPackageOnly := request.GetBool("crossPackageOnly", false)
+		packages := make([]*packageReport, 0, len(byDir))
+		variables, inits, dependent := 0, 0, 0
+		for _, pkg := range byDir {
+			kept := make([]*initializer, 0, len(pkg.Initializers))
+			for _, init := range pkg.Initializers {
+				seen := make(map[string]bool)
+				for _, use := range init.Uses {
+					dot := strings.LastIndex(use, ".")
+					path, name := use[:dot], use[dot+1:]
+					if path != pkg.ImportPath && states[path].dependsOn(name) && !seen[path] {
+						seen[path] = true
+						init.Dependencies = append(init.Dependencies, path)
+					}
+				}
+				if crossPackageOnly && len(init.Dependencies) == 0 {
+					continue
+				}
+				kept = append(kept, init)
+				if init.Kind == "init" {
+					inits++
+				} else {
+					variables++
+				}
+				if len(init.Dependencies) > 0 {
+					dependent++
+				}
+			}
+			if len(kept) > 0 {
+				pkg.Initializers = kept
+				packages = append(packages, pkg)
+			}
+		}
+		sort.Slice(packages, func(i, j int) bool { return packages[i].Dir < packages[j].Dir })
+
+		if len(packages) == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No package-level initialization found in %s", root)), nil
+		}
+		result, _ := json.MarshalIndent(packages, "", "  ")
+		return mcp.NewToolResultText(fmt.Sprintf("Found %d initialized variable(s) and %d init function(s) in %d package(s); %d depend on the initialization of other workspace packages. Within a package, variables are initialized in dependency order and init functions run afterwards in file name order.\n%s",
+			variables, inits, len(packages), dependent, string(result))), nil
+	}
+}
+
+// initState is what a package initializes
+type initState struct {
+	variables map[string]bool
+	hasInit   bool
+}
+
+// newInitState adds initializers to a package's state, which may be nil
+func newInitState(state *initState, initializers []*initializer) *initState {
+	if state == nil {
+		state = &initState{variables: make(map[string]bool)}
+	}
+	for _, init := range initializers {
+		if init.Kind == "init" {
+			state.hasInit = true
+		} else {
+			state.variables[init.Name] = true
+		}
+	}
+	return state
+}
+
+// dependsOn reports whether using a package member depends on the package's
+// initialization
+func (s *initState) dependsOn(name string) bool {
+	return s != nil && (s.hasInit || s.variables[name])
+}
+
+// scanFile returns a file's initialized package-level variables and init
+// functions in source order
+func scanFile(f *astscan.File) []*initializer {
+	imports := importNames(f.AST)
+	var result []*initializer
+	for _, decl := range f.AST.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			if decl.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if len(vs.Values) == 0 {
+					continue
+				}
+				for i, name := range vs.Names {
+					// x, y = f() shares one initializer between the names
+					value := vs.Values[0]
+					if len(vs.Values) == len(vs.Names) {
+						value = vs.Values[i]
+					}
+					init := &initializer{Kind: "variable", Name: name.Name, Expression: expression(f.Fset, value)}
+					init.File = filepath.Clean(f.Path)
+					init.Line, init.Column = f.Position(name.Pos())
+					init.Calls, init.Uses = references(value, imports)
+					result = append(result, init)
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv != nil || decl.Name.Name != "init" || decl.Body == nil {
+				continue
+			}
+			init := &initializer{Kind: "init", Name: "init", File: filepath.Clean(f.Path)}
+			init.Line, init.Column = f.Position(decl.Name.Pos())
+			init.Calls, init.Uses = references(decl.Body, imports)
+			result = append(result, init)
+		}
+	}
+	return result
+}
+
+// references returns the functions node calls and the identifiers of
+// imported packages it uses, skipping function literals, which only run if
+// called
+func references(node ast.Node, imports map[string]string) (calls, uses []string) {
+	seenCalls := make(map[string]bool)
+	seenUses := make(map[string]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			if name := calleeName(n.Fun); name != "" && !seenCalls[name] {
+				seenCalls[name] = true
+				calls = append(calls, name)
+			}
+		case *ast.SelectorExpr:
+			if ident, ok := n.X.(*ast.Ident); ok {
+				if path, ok := imports[ident.Name]; ok {
+					use := path + "." + n.Sel.Name
+					if !seenUses[use] {
+						seenUses[use] = true
+						uses = append(uses, use)
+					}
+				}
+			}
+		}
+		return true
+	})
+	return calls, uses
+}
+
+// calleeName renders the function of a call, or "" for calls of
+// expressions such as conversions to composite types
+func calleeName(fun ast.Expr) string {
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		if x := calleeName(fun.X); x != "" {
+			return x + "." + fun.Sel.Name
+		}
+		return fun.Sel.Name
+	case *ast.IndexExpr:
+		return calleeName(fun.X)
+	case *ast.IndexListExpr:
+		return calleeName(fun.X)
+	case *ast.CallExpr:
+		if name := calleeName(fun.Fun); name != "" {
+			return name + "()"
+		}
+	}
+	return ""
+}
+
+// majorVersion matches the /vN suffix of a module path, which is not part of
+// the package name
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// importNames maps the names a file refers to its imports by to their paths.
+// Without an explicit name the package name is assumed to be the last path
+// element.
+func importNames(file *ast.File) map[string]string {
+	names := make(map[string]string)
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if spec.Name != nil {
+			if spec.Name.Name != "_" && spec.Name.Name != "." {
+				names[spec.Name.Name] = path
+			}
+			continue
+		}
+		elems := strings.Split(path, "/")
+		name := elems[len(elems)-1]
+		if majorVersion.MatchString(name) && len(elems) > 1 {
+			name = elems[len(elems)-2]
+		}
+		name = strings.TrimPrefix(name, "go-")
+		name = strings.ReplaceAll(name, "-", "_")
+		name = strings.TrimSuffix(strings.TrimSuffix(name, ".go"), "_go")
+		names[name] = path
+	}
+	return names
+}
+
+// expression prints an initializer on one line, cut to maxInitializer
+func expression(fset *token.FileSet, expr ast.Expr) string {
+	var b strings.Builder
+	printer.Fprint(&b, fset, expr)
+	text := strings.Join(strings.Fields(b.String()), " ")
+	if len(text) > maxInitializer {
+		text = text[:maxInitializer] + "..."
+	}
+	return text
+}
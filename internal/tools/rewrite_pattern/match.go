@@ -0,0 +1,229 @@
+package rewrite_pattern
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ruleguardVar matches the $name wildcards of ruleguard patterns. They are
+// rewritten to identifiers with wildcardPrefix so the pattern parses as Go.
+var ruleguardVar = regexp.MustCompile(`\$(\*?)([A-Za-z_][A-Za-z0-9_]*)`)
+
+const wildcardPrefix = "rw__"
+
+// rule is a parsed "pattern -> replacement" rewrite
+type rule struct {
+	pattern     ast.Expr
+	replacement string // source of the replacement expression, "" when only searching
+	ruleguard   bool   // wildcards are $name rather than single lowercase letters
+}
+
+func parseRule(text string) (*rule, error) {
+	patternSrc, replacementSrc, rewrite := strings.Cut(text, "->")
+	r := &rule{ruleguard: strings.Contains(text, "$")}
+	if r.ruleguard {
+		if strings.Contains(text, "$*") {
+			return nil, fmt.Errorf("variadic $* wildcards are not supported")
+		}
+		patternSrc = ruleguardVar.ReplaceAllString(patternSrc, wildcardPrefix+"$2")
+		replacementSrc = ruleguardVar.ReplaceAllString(replacementSrc, wildcardPrefix+"$2")
+	}
+
+	pattern, err := parser.ParseExpr(strings.TrimSpace(patternSrc))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", strings.TrimSpace(patternSrc), err)
+	}
+	if ident, ok := pattern.(*ast.Ident); ok && r.isWildcard(ident.Name) {
+		return nil, fmt.Errorf("a pattern that is a single wildcard would match every expression")
+	}
+	r.pattern = pattern
+	if !rewrite {
+		return r, nil
+	}
+
+	r.replacement = strings.TrimSpace(replacementSrc)
+	replacement, err := parser.ParseExpr(r.replacement)
+	if err != nil {
+		return nil, fmt.Errorf("invalid replacement %q: %w", r.replacement, err)
+	}
+	bound := make(map[string]bool)
+	ast.Inspect(pattern, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && r.isWildcard(ident.Name) {
+			bound[ident.Name] = true
+		}
+		return true
+	})
+	var unbound []string
+	ast.Inspect(replacement, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && r.isWildcard(ident.Name) && !bound[ident.Name] {
+			unbound = append(unbound, r.displayName(ident.Name))
+		}
+		return true
+	})
+	if len(unbound) > 0 {
+		return nil, fmt.Errorf("the replacement uses wildcards the pattern does not bind: %s", strings.Join(unbound, ", "))
+	}
+	return r, nil
+}
+
+// isWildcard reports whether name is a wildcard: a single lowercase letter in
+// gofmt -r rules, or a $name in ruleguard rules
+func (r *rule) isWildcard(name string) bool {
+	if r.ruleguard {
+		return strings.HasPrefix(name, wildcardPrefix)
+	}
+	c, size := utf8.DecodeRuneInString(name)
+	return size == len(name) && unicode.IsLower(c)
+}
+
+func (r *rule) displayName(name string) string {
+	if r.ruleguard {
+		return "$" + strings.TrimPrefix(name, wildcardPrefix)
+	}
+	return name
+}
+
+var (
+	identType    = reflect.TypeOf((*ast.Ident)(nil))
+	objectType   = reflect.TypeOf((*ast.Object)(nil))
+	positionType = reflect.TypeOf(token.NoPos)
+)
+
+// match reports whether val matches pattern, recording what each wildcard
+// binds in m. A wildcard used more than once must bind equal expressions.
+// This follows the matcher of gofmt -r.
+func (r *rule) match(m map[string]reflect.Value, pattern, val reflect.Value) bool {
+	if m != nil && pattern.IsValid() && pattern.Type() == identType {
+		name := pattern.Interface().(*ast.Ident).Name
+		if r.isWildcard(name) && val.IsValid() {
+			if _, ok := val.Interface().(ast.Expr); ok && !val.IsNil() {
+				if name == wildcardPrefix+"_" {
+					return true
+				}
+				if old, ok := m[name]; ok {
+					return r.match(nil, old, val)
+				}
+				m[name] = val
+				return true
+			}
+		}
+	}
+
+	if !pattern.IsValid() || !val.IsValid() {
+		return !pattern.IsValid() && !val.IsValid()
+	}
+	if pattern.Type() != val.Type() {
+		return false
+	}
+	switch pattern.Type() {
+	case identType:
+		return pattern.Interface().(*ast.Ident).Name == val.Interface().(*ast.Ident).Name
+	case objectType, positionType:
+		return true
+	}
+
+	p := reflect.Indirect(pattern)
+	v := reflect.Indirect(val)
+	if !p.IsValid() || !v.IsValid() {
+		return !p.IsValid() && !v.IsValid()
+	}
+	switch p.Kind() {
+	case reflect.Slice:
+		if p.Len() != v.Len() {
+			return false
+		}
+		for i := 0; i < p.Len(); i++ {
+			if !r.match(m, p.Index(i), v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := 0; i < p.NumField(); i++ {
+			if !r.match(m, p.Field(i), v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Interface:
+		return r.match(m, p.Elem(), v.Elem())
+	}
+	return p.Interface() == v.Interface()
+}
+
+// substitute renders the replacement with each wildcard replaced by the
+// source text of the expression it bound, parenthesized where the
+// surrounding replacement would otherwise change its meaning
+func (r *rule) substitute(m map[string]reflect.Value, fset *token.FileSet, src []byte) (string, error) {
+	replacement, err := parser.ParseExpr(r.replacement)
+	if err != nil {
+		return "", err
+	}
+	var stack []ast.Node
+	ast.Inspect(replacement, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		if ident, ok := n.(*ast.Ident); ok && r.isWildcard(ident.Name) {
+			bound := m[ident.Name].Interface().(ast.Expr)
+			text := string(src[fset.Position(bound.Pos()).Offset:fset.Position(bound.End()).Offset])
+			if len(stack) > 0 && needsParens(stack[len(stack)-1], ident, bound) {
+				text = "(" + text + ")"
+			}
+			ident.Name = text
+		}
+		stack = append(stack, n)
+		return true
+	})
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), replacement); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// needsParens reports whether bound, substituted for the wildcard ident in
+// parent, must be parenthesized to keep its meaning
+func needsParens(parent ast.Node, ident *ast.Ident, bound ast.Expr) bool {
+	switch bound := bound.(type) {
+	case *ast.BinaryExpr:
+		switch parent := parent.(type) {
+		case *ast.BinaryExpr:
+			outer, inner := parent.Op.Precedence(), bound.Op.Precedence()
+			return inner < outer || (inner == outer && parent.Y == ident)
+		case *ast.UnaryExpr, *ast.StarExpr:
+			return true
+		}
+	case *ast.UnaryExpr, *ast.StarExpr:
+		if _, ok := parent.(*ast.StarExpr); ok {
+			return false
+		}
+	default:
+		return false
+	}
+	// Binary and unary expressions used as an operand of a postfix operator
+	switch parent := parent.(type) {
+	case *ast.SelectorExpr:
+		return parent.X == ident
+	case *ast.IndexExpr:
+		return parent.X == ident
+	case *ast.SliceExpr:
+		return parent.X == ident
+	case *ast.TypeAssertExpr:
+		return parent.X == ident
+	case *ast.CallExpr:
+		return parent.Fun == ident
+	}
+	return false
+}
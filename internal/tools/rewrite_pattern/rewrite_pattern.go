@@ -0,0 +1,220 @@
+package rewrite_pattern
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "RewritePattern",
+		Description: "Find or rewrite Go expressions by syntax pattern across the workspace, like gofmt -r: 'a[b:len(a)] -> a[b:]' where single lowercase letters are wildcards, or ruleguard style '$x == nil -> $x.IsZero()' with $name wildcards. Without '->' the matches are only listed.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"rule": map[string]interface{}{
+					"type":        "string",
+					"description": "'pattern -> replacement', or just 'pattern' to search. Both sides must be Go expressions. A wildcard used twice in the pattern must match the same expression both times; $_ matches anything without binding.",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Go file or directory to search recursively, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"includeTests": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also search _test.go files",
+					"default":     true,
+				},
+				"organizeImports": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Organize imports in every changed file, for replacements that use new packages",
+					"default":     false,
+				},
+				"dryRun": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Report the rewrite as a unified diff without modifying any files",
+					"default":     false,
+				},
+			},
+			Required: []string{"rule"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		text, err := request.RequireString("rule")
+		if err != nil {
+			return nil, err
+		}
+		r, err := parseRule(text)
+		if err != nil {
+			return nil, err
+		}
+		dryRun := request.GetBool("dryRun", false)
+
+		files, err := astscan.Paths(manager.ResolvePath(request.GetString("path", "")), astscan.Options{IncludeTests: request.GetBool("includeTests", true)})
+		if err != nil {
+			return nil, err
+		}
+
+		var matches []string
+		var rejected []string
+		original := make(map[string][]byte)
+		updated := make(map[string][]byte)
+		for _, path := range files {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+			if err != nil {
+				continue
+			}
+			found, textEdits, err := r.apply(fset, file, src)
+			if err != nil {
+				return nil, err
+			}
+			matches = append(matches, found...)
+			if len(textEdits) == 0 {
+				continue
+			}
+			out, err := edits.Apply(string(src), textEdits)
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewrite %s: %w", path, err)
+			}
+			if out == string(src) {
+				continue
+			}
+			if _, err := parser.ParseFile(token.NewFileSet(), path, out, parser.SkipObjectResolution); err != nil {
+				rejected = append(rejected, fmt.Sprintf("%s: %v", path, err))
+				continue
+			}
+			original[path] = src
+			updated[path] = []byte(out)
+		}
+
+		if r.replacement == "" {
+			return mcp.NewToolResultText(fmt.Sprintf("Found %d match(es) of %s:\n  - %s", len(matches), strings.TrimSpace(text), strings.Join(matches, "\n  - "))), nil
+		}
+
+		if request.GetBool("organizeImports", false) && len(updated) > 0 {
+			if err := manager.OrganizeImports(ctx, updated); err != nil {
+				return nil, err
+			}
+		}
+
+		paths := make([]string, 0, len(updated))
+		for path := range updated {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		var diff strings.Builder
+		for _, path := range paths {
+			diff.WriteString(edits.Unified(edits.Label(manager.WorkspaceRoot(), path), string(original[path]), string(updated[path])))
+		}
+
+		verb := "Rewrote"
+		if dryRun {
+			verb = "Would rewrite"
+		}
+		msg := fmt.Sprintf("%s %d match(es) in %d file(s)", verb, len(matches), len(updated))
+		if len(rejected) > 0 {
+			msg += fmt.Sprintf("\n\nSkipped %d file(s) the rewrite would leave with syntax errors:\n  - %s", len(rejected), strings.Join(rejected, "\n  - "))
+		}
+		if diff.Len() > 0 {
+			msg += "\n\n" + diff.String()
+		}
+		if dryRun || len(updated) == 0 {
+			return mcp.NewToolResultText(msg), nil
+		}
+
+		if err := manager.WriteFiles(updated); err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			if err := manager.NotifyFileWritten(ctx, path, false); err != nil {
+				msg += fmt.Sprintf("\nNote: gopls was not notified of the change to %s: %v", path, err)
+			}
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+// apply finds the outermost expressions in file matching the rule's pattern,
+// returning their positions and, when the rule rewrites, the edits replacing
+// them. A rewrite leaves out matches the replacement would not change.
+func (r *rule) apply(fset *token.FileSet, file *ast.File, src []byte) ([]string, []lsp.TextEdit, error) {
+	var found []string
+	var textEdits []lsp.TextEdit
+	var failure error
+	pattern := reflect.ValueOf(r.pattern)
+	ast.Inspect(file, func(n ast.Node) bool {
+		expr, ok := n.(ast.Expr)
+		if !ok || failure != nil {
+			return failure == nil
+		}
+		bindings := make(map[string]reflect.Value)
+		if !r.match(bindings, pattern, reflect.ValueOf(expr)) {
+			return true
+		}
+
+		start, end := fset.Position(expr.Pos()), fset.Position(expr.End())
+		match := fmt.Sprintf("%s:%d:%d: %s", start.Filename, start.Line, start.Column, firstLine(src[start.Offset:end.Offset]))
+		if r.replacement == "" {
+			found = append(found, match)
+			return false
+		}
+
+		replacement, err := r.substitute(bindings, fset, src)
+		if err != nil {
+			failure = fmt.Errorf("failed to build the replacement at %s: %w", start, err)
+			return false
+		}
+		if replacement == string(src[start.Offset:end.Offset]) {
+			// Already in the rewritten form
+			return false
+		}
+		found = append(found, match)
+		startPos, err := utils.OffsetToPosition(string(src), start.Offset)
+		if err != nil {
+			failure = err
+			return false
+		}
+		endPos, err := utils.OffsetToPosition(string(src), end.Offset)
+		if err != nil {
+			failure = err
+			return false
+		}
+		textEdits = append(textEdits, lsp.TextEdit{
+			Range:   lsp.Range{Start: startPos, End: endPos},
+			NewText: replacement,
+		})
+		return false
+	})
+	return found, textEdits, failure
+}
+
+func firstLine(b []byte) string {
+	line, _, cut := strings.Cut(string(b), "\n")
+	if cut {
+		line += " ..."
+	}
+	return line
+}
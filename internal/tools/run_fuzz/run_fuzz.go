@@ -0,0 +1,253 @@
+package run_fuzz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "RunFuzz",
+		Description: "Run a fuzz target with go test -fuzz for a bounded time. A failure is returned as the minimized input that triggers it, the failure message and where in the workspace it happened, with the command to reproduce it. Failing inputs are kept in testdata/fuzz, so later runs of go test replay them.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Package directory, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"target": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the fuzz target, e.g. FuzzParse. Optional when the package has only one.",
+				},
+				"seconds": map[string]interface{}{
+					"type":        "number",
+					"description": "How long to fuzz for (at most 600)",
+					"default":     30,
+				},
+				"minimizeSeconds": map[string]interface{}{
+					"type":        "number",
+					"description": "How long to spend minimizing a failing input",
+					"default":     30,
+				},
+				"workers": map[string]interface{}{
+					"type":        "number",
+					"description": "Number of fuzzing processes; defaults to GOMAXPROCS",
+				},
+			},
+		},
+	}
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Location string `json:"location"`
+}
+
+type crasher struct {
+	Entry    string   `json:"entry"`
+	Input    string   `json:"inputFile,omitempty"`
+	Values   []string `json:"values,omitempty"`
+	Seed     bool     `json:"seedCorpus"`
+	Message  string   `json:"message"`
+	Location string   `json:"location,omitempty"`
+	Stack    []frame  `json:"stack,omitempty"`
+	Rerun    string   `json:"rerun"`
+}
+
+type result struct {
+	Target  string   `json:"target"`
+	Package string   `json:"package"`
+	Passed  bool     `json:"passed"`
+	Stats   string   `json:"stats,omitempty"`
+	Crasher *crasher `json:"crasher,omitempty"`
+}
+
+const maxFuzzTime = 600 * time.Second
+
+var (
+	// written matches the line naming the file a new failing input was saved to
+	written = regexp.MustCompile(`^\s*Failing input written to (\S+)$`)
+	// seedFailure matches the line naming a failing seed corpus entry
+	seedFailure = regexp.MustCompile(`^failure while testing seed corpus entry: (\S+)$`)
+	// stackFrame matches the file:line line of a stack frame
+	stackFrame = regexp.MustCompile(`^\s+(\S+\.go):(\d+)`)
+	// logPrefix matches the file:line: prefix of a test log message
+	logPrefix = regexp.MustCompile(`^(\S+\.go):(\d+): (.*)$`)
+)
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := manager.ResolvePath(request.GetString("path", ""))
+		target := request.GetString("target", "")
+		if target == "" {
+			targets := fuzzTargets(dir)
+			if len(targets) != 1 {
+				return nil, fmt.Errorf("target is required: %s has %d fuzz targets %s", dir, len(targets), strings.Join(targets, ", "))
+			}
+			target = targets[0]
+		}
+		fuzzTime := time.Duration(request.GetInt("seconds", 30)) * time.Second
+		if fuzzTime <= 0 || fuzzTime > maxFuzzTime {
+			fuzzTime = maxFuzzTime
+		}
+		minimizeTime := time.Duration(request.GetInt("minimizeSeconds", 30)) * time.Second
+
+		args := []string{"test", "-run", "^$", "-fuzz", "^" + regexp.QuoteMeta(target) + "$",
+			"-fuzztime", fuzzTime.String(), "-fuzzminimizetime", minimizeTime.String()}
+		if workers := request.GetInt("workers", 0); workers > 0 {
+			args = append(args, "-parallel", strconv.Itoa(workers))
+		}
+		args = append(args, ".")
+		// Leave time to build the test binary and gather baseline coverage
+		runCtx, cancel := context.WithTimeout(ctx, fuzzTime+minimizeTime+2*time.Minute)
+		defer cancel()
+		run, err := gocmd.Run(runCtx, dir, nil, args...)
+		if err != nil {
+			return nil, err
+		}
+		output := run.Stdout + "\n" + run.Stderr
+
+		res := result{Target: target, Package: dir, Passed: run.ExitCode == 0}
+		for _, line := range strings.Split(output, "\n") {
+			if strings.HasPrefix(line, "fuzz: elapsed:") && strings.Contains(line, "execs:") {
+				res.Stats = strings.TrimPrefix(line, "fuzz: ")
+			}
+		}
+		if !res.Passed {
+			res.Crasher = parseFailure(output, dir, manager.WorkspaceRoot(), target)
+			if res.Crasher == nil {
+				return nil, fmt.Errorf("go test -fuzz failed: %s", strings.TrimSpace(output))
+			}
+		}
+
+		summary := fmt.Sprintf("%s passed after fuzzing for %s", target, fuzzTime)
+		if res.Crasher != nil {
+			summary = fmt.Sprintf("%s failed: %s", target, res.Crasher.Message)
+		}
+		out, _ := json.MarshalIndent(res, "", "  ")
+		return mcp.NewToolResultText(summary + "\n" + string(out)), nil
+	}
+}
+
+// fuzzTargets lists the fuzz targets declared in the test files of dir
+func fuzzTargets(dir string) []string {
+	var targets []string
+	paths, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	for _, path := range paths {
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && strings.HasPrefix(fn.Name.Name, "Fuzz") {
+				targets = append(targets, fn.Name.Name)
+			}
+		}
+	}
+	return targets
+}
+
+// parseFailure extracts the failing input, message and workspace stack
+// frames from go test -fuzz output, or returns nil when the output holds no
+// fuzz failure (a build error, for example)
+func parseFailure(output, dir, root, target string) *crasher {
+	lines := strings.Split(output, "\n")
+	c := &crasher{}
+	inFailure := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if m := seedFailure.FindStringSubmatch(trimmed); m != nil {
+			c.Entry, c.Seed = m[1], true
+		}
+		if m := written.FindStringSubmatch(line); m != nil {
+			c.Input = m[1]
+			c.Entry = target + "/" + filepath.Base(m[1])
+		}
+		if strings.HasPrefix(trimmed, "--- FAIL:") {
+			inFailure = true
+			continue
+		}
+		if !inFailure || trimmed == "" {
+			continue
+		}
+		if c.Message == "" {
+			c.Message = trimmed
+			if m := logPrefix.FindStringSubmatch(trimmed); m != nil {
+				c.Message = m[3]
+				// Messages logged by the test itself point at the failing check
+				if m[1] != "testing.go" {
+					c.Location = filepath.Join(dir, m[1]) + ":" + m[2]
+				}
+			}
+			continue
+		}
+		m := stackFrame.FindStringSubmatch(line)
+		if m == nil || i == 0 || !strings.HasPrefix(m[1], root+string(filepath.Separator)) {
+			continue
+		}
+		function := strings.TrimSpace(lines[i-1])
+		if paren := strings.LastIndexByte(function, '('); paren > 0 {
+			function = function[:paren]
+		}
+		c.Stack = append(c.Stack, frame{Function: function, Location: m[1] + ":" + m[2]})
+	}
+	if !inFailure || c.Message == "" {
+		return nil
+	}
+	if len(c.Stack) > 0 {
+		c.Location = c.Stack[0].Location
+	}
+
+	if c.Input == "" && c.Entry != "" {
+		// A failing seed corpus entry is a file unless it was added with f.Add
+		if path := filepath.Join(dir, "testdata", "fuzz", filepath.FromSlash(c.Entry)); fileExists(path) {
+			c.Input = filepath.Join("testdata", "fuzz", filepath.FromSlash(c.Entry))
+		}
+	}
+	if c.Input != "" {
+		c.Values = readCorpusFile(filepath.Join(dir, c.Input))
+	}
+	c.Rerun = fmt.Sprintf("cd %s && go test -run '^%s$' .", dir, target)
+	if c.Entry != "" {
+		c.Rerun = fmt.Sprintf("cd %s && go test -run '%s' .", dir, c.Entry)
+	}
+	return c
+}
+
+// readCorpusFile returns the values of a corpus file, one Go expression per
+// argument, e.g. string("x00")
+func readCorpusFile(path string) []string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var values []string
+	for i, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		// The first line is the "go test fuzz v1" header
+		if i > 0 {
+			values = append(values, line)
+		}
+	}
+	return values
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,244 @@
+package run_snippet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "RunSnippet",
+		Description: "Compile and run a self-contained Go snippet in a temporary directory with a strict timeout, returning its output and exit code. The snippet can be a main package, declarations including func main, or statements (which become the body of main). Only the standard library is available.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"code": map[string]interface{}{
+					"type":        "string",
+					"description": "The Go code to run",
+				},
+				"imports": map[string]interface{}{
+					"type":        "array",
+					"description": "Standard library packages the snippet needs, e.g. [\"fmt\", \"strings\"]. Not needed when the snippet has its own imports.",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"stdin": map[string]interface{}{
+					"type":        "string",
+					"description": "Text to pass to the program on standard input",
+				},
+				"args": map[string]interface{}{
+					"type":        "array",
+					"description": "Command line arguments for the program",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"timeoutSeconds": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum time the program may run before it is killed (at most 60)",
+					"default":     10,
+				},
+				"maxOutputBytes": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum bytes of stdout and of stderr to return",
+					"default":     65536,
+				},
+			},
+			Required: []string{"code"},
+		},
+	}
+}
+
+type compileError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+type result struct {
+	Kind          string         `json:"kind"`
+	Compiled      bool           `json:"compiled"`
+	CompileErrors []compileError `json:"compileErrors,omitempty"`
+	ExitCode      int            `json:"exitCode"`
+	TimedOut      bool           `json:"timedOut,omitempty"`
+	Duration      string         `json:"duration,omitempty"`
+	Stdout        string         `json:"stdout"`
+	Stderr        string         `json:"stderr"`
+	Truncated     bool           `json:"truncated,omitempty"`
+}
+
+const maxTimeout = 60 * time.Second
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		code, err := request.RequireString("code")
+		if err != nil {
+			return nil, err
+		}
+		timeout := time.Duration(request.GetInt("timeoutSeconds", 10)) * time.Second
+		if timeout <= 0 || timeout > maxTimeout {
+			timeout = maxTimeout
+		}
+		maxOutput := request.GetInt("maxOutputBytes", 65536)
+
+		src, kind, offset, err := wrap(code, request.GetStringSlice("imports", nil))
+		if err != nil {
+			return nil, err
+		}
+
+		dir, err := os.MkdirTemp("", "run-snippet-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		// Without a go directive the module would get the language version
+		// of Go 1.16, so use the toolchain's own
+		goMod := "module snippet\n"
+		if version, err := gocmd.Output(ctx, dir, "env", "GOVERSION"); err == nil && strings.HasPrefix(version, "go1") {
+			goMod += "\ngo " + strings.TrimPrefix(version, "go") + "\n"
+		}
+		files := map[string]string{
+			"go.mod":  goMod,
+			"main.go": src,
+		}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				return nil, err
+			}
+		}
+
+		res := result{Kind: kind}
+		binary := filepath.Join(dir, "snippet")
+		build, err := gocmd.Run(ctx, dir, []string{"GOWORK=off", "GOFLAGS="}, "build", "-o", binary, ".")
+		if err != nil {
+			return nil, err
+		}
+		if build.ExitCode != 0 {
+			for _, e := range gocmd.BuildErrors(build.Stderr) {
+				res.CompileErrors = append(res.CompileErrors, compileError{Line: e.Line - offset, Column: e.Column, Message: e.Message})
+			}
+			if len(res.CompileErrors) == 0 {
+				return nil, fmt.Errorf("go build failed: %s", strings.TrimSpace(build.Stderr))
+			}
+			output, _ := json.MarshalIndent(res, "", "  ")
+			return mcp.NewToolResultText(fmt.Sprintf("The snippet failed to compile with %d error(s):\n%s", len(res.CompileErrors), output)), nil
+		}
+		res.Compiled = true
+
+		runCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		cmd := exec.CommandContext(runCtx, binary, request.GetStringSlice("args", nil)...)
+		cmd.Dir = dir
+		cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir, "TMPDIR=" + dir}
+		cmd.Stdin = strings.NewReader(request.GetString("stdin", ""))
+		stdout := &limitedBuffer{max: maxOutput}
+		stderr := &limitedBuffer{max: maxOutput}
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		// Don't wait forever for output pipes held open by child processes
+		cmd.WaitDelay = time.Second
+
+		start := time.Now()
+		err = cmd.Run()
+		res.Duration = time.Since(start).Round(time.Millisecond).String()
+		var exitErr *exec.ExitError
+		switch {
+		case runCtx.Err() == context.DeadlineExceeded:
+			res.TimedOut, res.ExitCode = true, -1
+		case errors.As(err, &exitErr):
+			res.ExitCode = exitErr.ExitCode()
+		case err != nil:
+			return nil, fmt.Errorf("failed to run the snippet: %w", err)
+		}
+		res.Stdout, res.Stderr = stdout.String(), stderr.String()
+		res.Truncated = stdout.truncated || stderr.truncated
+
+		output, _ := json.MarshalIndent(res, "", "  ")
+		summary := fmt.Sprintf("The snippet exited with code %d after %s", res.ExitCode, res.Duration)
+		if res.TimedOut {
+			summary = fmt.Sprintf("The snippet was killed after the %s timeout", timeout)
+		}
+		return mcp.NewToolResultText(summary + ":\n" + string(output)), nil
+	}
+}
+
+// wrap turns code into a main package, returning the file, what kind of
+// snippet it was, and how many lines were added before the code
+func wrap(code string, imports []string) (string, string, int, error) {
+	fset := token.NewFileSet()
+	if file, err := parser.ParseFile(fset, "", code, parser.PackageClauseOnly); err == nil {
+		if file.Name.Name != "main" {
+			return "", "", 0, fmt.Errorf("the snippet must be package main, not package %s", file.Name.Name)
+		}
+		if len(imports) > 0 {
+			return "", "", 0, fmt.Errorf("imports cannot be added to a snippet with its own package clause")
+		}
+		return code, "file", 0, nil
+	}
+
+	var header strings.Builder
+	header.WriteString("package main\n")
+	for _, path := range imports {
+		fmt.Fprintf(&header, "import %q\n", path)
+	}
+	offset := strings.Count(header.String(), "\n")
+
+	decls := header.String() + code + "\n"
+	file, declErr := parser.ParseFile(fset, "", decls, parser.AllErrors)
+	if declErr == nil {
+		if !hasMain(file) {
+			return "", "", 0, fmt.Errorf("the snippet declares no func main; pass statements to run them as the body of main")
+		}
+		return decls, "declarations", offset, nil
+	}
+
+	stmts := header.String() + "func main() {\n" + code + "\n}\n"
+	if _, err := parser.ParseFile(fset, "", stmts, parser.AllErrors); err == nil {
+		return stmts, "statements", offset + 1, nil
+	}
+	return "", "", 0, fmt.Errorf("the snippet does not parse as declarations or statements: %v", declErr)
+}
+
+func hasMain(file *ast.File) bool {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" {
+			return true
+		}
+	}
+	return false
+}
+
+// limitedBuffer keeps the first max bytes written to it and discards the
+// rest, so a chatty program cannot exhaust memory
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
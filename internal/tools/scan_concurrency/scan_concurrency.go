@@ -0,0 +1,184 @@
+package scan_concurrency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+// concurrencyAnalyzers are the go vet analyzers that catch concurrency bugs
+var concurrencyAnalyzers = []string{"copylocks", "loopclosure", "lostcancel", "atomic"}
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "ScanConcurrency",
+		Description: "Scan for concurrency hazards with the copylocks, loopclosure, lostcancel and atomic vet analyzers, and optionally run tests with the race detector mapping data races back to source",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"package": map[string]interface{}{
+					"type":        "string",
+					"description": "Package pattern relative to the workspace root (defaults to ./...)",
+					"default":     "./...",
+				},
+				"mode": map[string]interface{}{
+					"type":        "string",
+					"description": "'vet' runs the analyzers, 'race' runs 'go test -race', 'both' runs both",
+					"enum":        []string{"vet", "race", "both"},
+					"default":     "vet",
+				},
+				"run": map[string]interface{}{
+					"type":        "string",
+					"description": "Regular expression selecting the tests to run in race mode",
+				},
+			},
+		},
+	}
+}
+
+// raceAccess is one memory access or goroutine creation site in a race report
+type raceAccess struct {
+	Kind      string `json:"kind"`
+	Goroutine string `json:"goroutine,omitempty"`
+	Function  string `json:"function"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+}
+
+type dataRace struct {
+	Accesses []raceAccess `json:"accesses"`
+}
+
+var (
+	// raceSection matches the header of an access or creation section
+	raceSection = regexp.MustCompile(`^((?:Previous )?(?:[Aa]tomic )?(?:[Rr]ead|[Ww]rite)) at 0x[0-9a-f]+ by (main goroutine|goroutine \d+):$|^(Goroutine \d+) \([^)]*\) created at:$`)
+	// raceFrame matches the file:line line of a stack frame
+	raceFrame = regexp.MustCompile(`^\s+(\S+\.go):(\d+)`)
+)
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		pkg := request.GetString("package", "./...")
+		mode := request.GetString("mode", "vet")
+		if mode != "vet" && mode != "race" && mode != "both" {
+			return nil, fmt.Errorf("invalid mode %q: must be 'vet', 'race' or 'both'", mode)
+		}
+		root := manager.WorkspaceRoot()
+
+		var sections []string
+
+		if mode == "vet" || mode == "both" {
+			findings, result, err := gocmd.Vet(ctx, root, concurrencyAnalyzers, pkg)
+			if err != nil {
+				return nil, err
+			}
+			output, _ := json.MarshalIndent(findings, "", "  ")
+			section := fmt.Sprintf("Found %d concurrency issue(s) from vet:\n%s", len(findings), string(output))
+			if result.ExitCode != 0 && len(findings) == 0 {
+				section += "\n\nvet failed:\n" + strings.TrimSpace(result.Stderr)
+			}
+			sections = append(sections, section)
+		}
+
+		if mode == "race" || mode == "both" {
+			args := []string{"test", "-race", "-count=1"}
+			if run := request.GetString("run", ""); run != "" {
+				args = append(args, "-run", run)
+			}
+			args = append(args, pkg)
+
+			result, err := gocmd.Run(ctx, root, nil, args...)
+			if err != nil {
+				return nil, err
+			}
+
+			races := parseRaces(result.Stdout+"\n"+result.Stderr, root)
+			output, _ := json.MarshalIndent(races, "", "  ")
+			section := fmt.Sprintf("Found %d data race(s) with go test -race:\n%s", len(races), string(output))
+			if result.ExitCode != 0 && len(races) == 0 {
+				section += "\n\ngo test failed:\n" + strings.TrimSpace(result.Stdout+"\n"+result.Stderr)
+			}
+			sections = append(sections, section)
+		}
+
+		return mcp.NewToolResultText(strings.Join(sections, "\n\n")), nil
+	}
+}
+
+// parseRaces extracts data race reports from race detector output. For each
+// access the first stack frame inside the workspace is reported, falling
+// back to the top frame.
+func parseRaces(output, workspaceRoot string) []dataRace {
+	races := make([]dataRace, 0)
+	seen := make(map[string]bool)
+
+	var current *dataRace
+	var access *raceAccess
+	var function string
+	inWorkspace := false
+
+	flushAccess := func() {
+		if current != nil && access != nil && access.File != "" {
+			current.Accesses = append(current.Accesses, *access)
+		}
+		access = nil
+		inWorkspace = false
+	}
+	flushRace := func() {
+		flushAccess()
+		if current != nil && len(current.Accesses) > 0 {
+			// The same race is reported once per test that triggers it
+			key, _ := json.Marshal(current.Accesses)
+			if !seen[string(key)] {
+				seen[string(key)] = true
+				races = append(races, *current)
+			}
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "WARNING: DATA RACE"):
+			flushRace()
+			current = &dataRace{}
+		case strings.HasPrefix(line, "=================="):
+			flushRace()
+		case current == nil:
+			continue
+		case raceSection.MatchString(line):
+			flushAccess()
+			m := raceSection.FindStringSubmatch(line)
+			if m[3] != "" {
+				access = &raceAccess{Kind: "goroutine created", Goroutine: strings.ToLower(m[3])}
+			} else {
+				access = &raceAccess{Kind: strings.ToLower(m[1]), Goroutine: m[2]}
+			}
+		case access != nil && raceFrame.MatchString(line):
+			m := raceFrame.FindStringSubmatch(line)
+			if inWorkspace {
+				continue
+			}
+			isWorkspace := strings.HasPrefix(m[1], workspaceRoot)
+			if access.File == "" || isWorkspace {
+				access.File = m[1]
+				fmt.Sscanf(m[2], "%d", &access.Line)
+				access.Function = function
+				inWorkspace = isWorkspace
+			}
+		case access != nil && strings.TrimSpace(line) != "":
+			// Function lines precede their file:line line
+			function = strings.TrimSpace(line)
+		}
+	}
+	flushRace()
+
+	return races
+}
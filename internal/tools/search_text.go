@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+// maxListedMatches is how many matches an ambiguous searchText error lists
+const maxListedMatches = 10
+
+// addressedByPosition reports whether tool takes a position as file, line
+// and column
+func addressedByPosition(tool mcp.Tool) bool {
+	for _, name := range []string{"file", "line", "column"} {
+		if _, ok := tool.InputSchema.Properties[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchTextSchema adds the searchText and occurrence arguments to tools
+// addressed by file, line and column, which then no longer require line
+// and column. Use it with Registry.AdaptSchemas.
+func SearchTextSchema(tool mcp.Tool) mcp.Tool {
+	if !addressedByPosition(tool) {
+		return tool
+	}
+	properties := make(map[string]interface{}, len(tool.InputSchema.Properties)+2)
+	for name, schema := range tool.InputSchema.Properties {
+		properties[name] = schema
+	}
+	properties["searchText"] = map[string]interface{}{
+		"type": "string",
+		"description": "Instead of line and column: a snippet of the file starting with the identifier to target, e.g. 'Serve(ctx, addr)'. " +
+			"The position is its first character, found in the file as it is now, so it stays right after edits move lines. Whitespace may differ.",
+	}
+	properties["occurrence"] = map[string]interface{}{
+		"type":        "number",
+		"description": "Which match of searchText to use, counting from 1, when it occurs more than once",
+	}
+	tool.InputSchema.Properties = properties
+
+	var required []string
+	for _, name := range tool.InputSchema.Required {
+		if name != "line" && name != "column" {
+			required = append(required, name)
+		}
+	}
+	tool.InputSchema.Required = required
+	return tool
+}
+
+// SearchTextPositions sets the line and column arguments of calls that
+// address a position by searchText, from where the snippet occurs in the
+// file, as staged in gopls or on disk
+func SearchTextPositions(manager *gopls.Manager) Middleware {
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		if _, ok := tool.InputSchema.Properties["searchText"]; !ok || !addressedByPosition(tool) {
+			return next
+		}
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			args := copyArguments(request)
+			searchText, _ := args["searchText"].(string)
+			if searchText == "" {
+				return next(ctx, request)
+			}
+			file, _ := args["file"].(string)
+			if file == "" {
+				return nil, fmt.Errorf("missing required argument %q", "file")
+			}
+			content, err := manager.ReadFile(manager.ResolvePath(file))
+			if err != nil {
+				return nil, err
+			}
+
+			occurrence := 0
+			if n, ok := args["occurrence"].(float64); ok {
+				occurrence = int(n)
+			}
+			line, column, err := locate(string(content), searchText, occurrence)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			args["line"] = float64(line)
+			args["column"] = float64(column)
+			return next(ctx, withArguments(request, args))
+		}
+	}
+}
+
+// locate returns the 1-indexed line and column of the start of the given
+// occurrence of searchText in content, or of its only occurrence when
+// occurrence is zero. searchText is matched exactly, or failing that with
+// any whitespace where it has whitespace.
+func locate(content, searchText string, occurrence int) (int, int, error) {
+	var offsets []int
+	for start := 0; ; {
+		i := strings.Index(content[start:], searchText)
+		if i < 0 {
+			break
+		}
+		offsets = append(offsets, start+i)
+		start += i + 1
+	}
+	if len(offsets) == 0 {
+		if fields := strings.Fields(searchText); len(fields) > 0 {
+			quoted := make([]string, len(fields))
+			for i, field := range fields {
+				quoted[i] = regexp.QuoteMeta(field)
+			}
+			pattern := regexp.MustCompile(strings.Join(quoted, `\s+`))
+			for _, match := range pattern.FindAllStringIndex(content, -1) {
+				offsets = append(offsets, match[0])
+			}
+		}
+	}
+
+	switch {
+	case len(offsets) == 0:
+		return 0, 0, fmt.Errorf("searchText %q does not occur in the file", searchText)
+	case occurrence < 0 || occurrence > len(offsets):
+		return 0, 0, fmt.Errorf("searchText %q occurs %d time(s), so there is no occurrence %d", searchText, len(offsets), occurrence)
+	case occurrence == 0 && len(offsets) > 1:
+		lines := make([]string, 0, maxListedMatches)
+		for i, offset := range offsets {
+			if i == maxListedMatches {
+				lines = append(lines, "...")
+				break
+			}
+			line, _ := lineColumn(content, offset)
+			lines = append(lines, fmt.Sprintf("%d: line %d", i+1, line))
+		}
+		return 0, 0, fmt.Errorf("searchText %q occurs %d times (%s); set occurrence or make it longer", searchText, len(offsets), strings.Join(lines, ", "))
+	case occurrence == 0:
+		occurrence = 1
+	}
+	line, column := lineColumn(content, offsets[occurrence-1])
+	return line, column, nil
+}
+
+// lineColumn returns the 1-indexed line and column of offset in content,
+// counting columns in bytes as the position arguments do
+func lineColumn(content string, offset int) (int, int) {
+	line := strings.Count(content[:offset], "\n") + 1
+	column := offset - (strings.LastIndex(content[:offset], "\n") + 1) + 1
+	return line, column
+}
@@ -0,0 +1,38 @@
+package server_status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "ServerStatus",
+		Description: "Report the workspace gopls is running in: its root, the module or go.work it belongs to, extra workspace folders, gopls's memory use and restarts, and any problem that keeps gopls from analyzing it, such as a missing go.mod. Check it first when tools return empty results.",
+		InputSchema: mcp.ToolInputSchema{
+			Type:       "object",
+			Properties: map[string]interface{}{},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		status := manager.Status()
+		output, _ := json.MarshalIndent(status, "", "  ")
+
+		summary := "The workspace is ready"
+		switch {
+		case !status.Initialized:
+			summary = "gopls is not running"
+		case status.Problem != "":
+			summary = "The workspace has a problem: " + status.Problem
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%s\n%s", summary, output)), nil
+	}
+}
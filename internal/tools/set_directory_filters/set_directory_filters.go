@@ -0,0 +1,87 @@
+package set_directory_filters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "SetDirectoryFilters",
+		Description: "Change which directories gopls analyzes, using its directoryFilters setting, to focus on part of a large repository and cut gopls's memory use and latency. gopls reloads the workspace afterwards. Pass focus to analyze only some directories, filters for full control, or neither to analyze everything again.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"focus": map[string]interface{}{
+					"type":        "array",
+					"description": "Directories, relative to the workspace root, to analyze exclusively, e.g. [\"services/api\", \"libs/common\"]",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"filters": map[string]interface{}{
+					"type":        "array",
+					"description": "Raw gopls directoryFilters applied after focus, each + or - followed by a directory relative to the workspace root, e.g. [\"-services/api/testdata\"]. Later filters override earlier ones; ** matches any number of directories.",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+			},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		root := manager.WorkspaceRoot()
+		var filters []string
+		if focus := request.GetStringSlice("focus", nil); len(focus) > 0 {
+			// Exclude everything, then include each focused directory
+			filters = append(filters, "-")
+			for _, dir := range focus {
+				rel, err := relativeDir(root, dir)
+				if err != nil {
+					return nil, err
+				}
+				filters = append(filters, "+"+rel)
+			}
+		}
+		filters = append(filters, request.GetStringSlice("filters", nil)...)
+
+		effective, err := manager.SetDirectoryFilters(ctx, filters)
+		if err != nil {
+			return nil, err
+		}
+
+		output, _ := json.MarshalIndent(effective, "", "  ")
+		summary := "gopls now analyzes the whole workspace except the default exclusions"
+		if len(filters) > 0 {
+			summary = fmt.Sprintf("gopls now analyzes the workspace with %d configured filter(s)", len(filters))
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%s; it is reloading the workspace, so results may be incomplete for a moment. Effective directoryFilters:\n%s", summary, output)), nil
+	}
+}
+
+// relativeDir turns a focused directory into the slash-separated path
+// relative to the workspace root that directory filters use
+func relativeDir(root, dir string) (string, error) {
+	path := dir
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the workspace root %s", dir, root)
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	if rel == "." {
+		return "", fmt.Errorf("focusing on the workspace root is the same as passing no focus")
+	}
+	return filepath.ToSlash(rel), nil
+}
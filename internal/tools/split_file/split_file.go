@@ -0,0 +1,180 @@
+package split_file
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "SplitFile",
+		Description: "Move top-level declarations from a file into a new file in the same package, copying the imports they need and removing imports left unused",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"file": map[string]interface{}{
+					"type":        "string",
+					"description": "Absolute path to the Go source file to split",
+				},
+				"symbols": map[string]interface{}{
+					"type":        "array",
+					"description": "Names of the declarations to move: functions, types, variables and constants, with methods written as Type.Method",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"newFile": map[string]interface{}{
+					"type":        "string",
+					"description": "Name of the new file, created in the same directory",
+				},
+				"includeMethods": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Move the methods of moved types along with them",
+					"default":     true,
+				},
+				"organizeImports": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Run gopls's organize imports on both files before writing them",
+					"default":     false,
+				},
+				"dryRun": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Report the changes as a unified diff without modifying any files",
+					"default":     false,
+				},
+			},
+			Required: []string{"file", "symbols", "newFile"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		file, err := request.RequireString("file")
+		if err != nil {
+			return nil, err
+		}
+		symbols, err := request.RequireStringSlice("symbols")
+		if err != nil {
+			return nil, err
+		}
+		if len(symbols) == 0 {
+			return nil, fmt.Errorf("symbols cannot be empty")
+		}
+		newFile, err := request.RequireString("newFile")
+		if err != nil {
+			return nil, err
+		}
+		includeMethods := request.GetBool("includeMethods", true)
+		dryRun := request.GetBool("dryRun", false)
+
+		file, err = filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		if filepath.Base(newFile) != newFile || !strings.HasSuffix(newFile, ".go") {
+			return nil, fmt.Errorf("newFile must be a .go file name without a directory")
+		}
+		target := filepath.Join(filepath.Dir(file), newFile)
+		if _, err := os.Stat(target); err == nil {
+			return nil, fmt.Errorf("%s already exists", target)
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fset := token.NewFileSet()
+		astFile, err := parser.ParseFile(fset, file, content, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+
+		moved, err := edits.SelectDecls(fset, astFile, content, symbols, includeMethods)
+		if err != nil {
+			return nil, err
+		}
+
+		newSrc, err := newFileSource(astFile, moved)
+		if err != nil {
+			return nil, err
+		}
+		newSrc = manager.FileHeader().Stamp(target, newSrc)
+		updated, err := edits.Apply(string(content), moved.RemovalEdits())
+		if err != nil {
+			return nil, err
+		}
+		// Removing declarations can leave runs of blank lines behind
+		if formatted, err := format.Source([]byte(updated)); err == nil {
+			updated = string(formatted)
+		}
+
+		updates := map[string][]byte{file: []byte(updated), target: newSrc}
+		if request.GetBool("organizeImports", false) {
+			if err := manager.OrganizeImports(ctx, updates); err != nil {
+				return nil, err
+			}
+		}
+
+		if dryRun {
+			return mcp.NewToolResultText(fmt.Sprintf("Would move %s to %s\n\n%s%s",
+				strings.Join(moved.Selected, ", "), newFile,
+				edits.Unified(edits.Label(manager.WorkspaceRoot(), file), string(content), string(updates[file])),
+				edits.Unified(edits.Label(manager.WorkspaceRoot(), target), "", string(updates[target])))), nil
+		}
+
+		if err := manager.WriteFiles(updates); err != nil {
+			return nil, err
+		}
+
+		msg := fmt.Sprintf("Moved %s from %s to %s", strings.Join(moved.Selected, ", "), filepath.Base(file), target)
+		for path, created := range map[string]bool{file: false, target: true} {
+			if err := manager.NotifyFileWritten(ctx, path, created); err != nil {
+				msg += fmt.Sprintf("\nNote: gopls was not notified of %s: %v", path, err)
+				break
+			}
+		}
+		return mcp.NewToolResultText(msg), nil
+	}
+}
+
+// newFileSource renders the new file: build constraints, package clause,
+// the imports the moved code uses and the moved declarations
+func newFileSource(file *ast.File, moved *edits.Decls) ([]byte, error) {
+	var b strings.Builder
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, c := range group.List {
+			if strings.HasPrefix(c.Text, "//go:build") || strings.HasPrefix(c.Text, "// +build") {
+				b.WriteString(c.Text + "\n")
+			}
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "package %s\n\n", file.Name.Name)
+
+	if imports := moved.Imports(); len(imports) > 0 {
+		b.WriteString("import (\n\t" + strings.Join(imports, "\n\t") + "\n)\n\n")
+	}
+	b.WriteString(strings.Join(moved.Source(), "\n\n") + "\n")
+
+	formatted, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format the new file: %w", err)
+	}
+	return formatted, nil
+}
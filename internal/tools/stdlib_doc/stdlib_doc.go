@@ -0,0 +1,92 @@
+package stdlib_doc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "StdlibDoc",
+		Description: "Get documentation for a Go standard library package or symbol by name, e.g. 'net/http.Server.Shutdown' or 'strings'",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"symbol": map[string]interface{}{
+					"type":        "string",
+					"description": "Package path optionally followed by a symbol, e.g. 'fmt', 'fmt.Println' or 'net/http.Server.Shutdown'",
+				},
+				"all": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Show documentation for all exported symbols when looking up a package",
+					"default":     false,
+				},
+				"short": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Show a one-line summary for each symbol when looking up a package",
+					"default":     false,
+				},
+			},
+			Required: []string{"symbol"},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		symbol, err := request.RequireString("symbol")
+		if err != nil {
+			return nil, err
+		}
+		symbol = strings.TrimSpace(symbol)
+		if symbol == "" {
+			return nil, fmt.Errorf("symbol cannot be empty")
+		}
+
+		pkg := packagePath(symbol)
+		if !isStdlibPath(pkg) {
+			return nil, fmt.Errorf("%s is not a standard library package", pkg)
+		}
+
+		args := []string{"doc"}
+		if request.GetBool("all", false) {
+			args = append(args, "-all")
+		}
+		if request.GetBool("short", false) {
+			args = append(args, "-short")
+		}
+		args = append(args, symbol)
+
+		result, err := gocmd.Run(ctx, manager.WorkspaceRoot(), nil, args...)
+		if err != nil {
+			return nil, err
+		}
+		if result.ExitCode != 0 {
+			return nil, fmt.Errorf("no documentation found for %s: %s", symbol, strings.TrimSpace(result.Stderr))
+		}
+
+		return mcp.NewToolResultText(strings.TrimSpace(result.Stdout)), nil
+	}
+}
+
+// packagePath extracts the import path from a "path/pkg.Symbol.Method" query
+func packagePath(symbol string) string {
+	lastSlash := strings.LastIndex(symbol, "/")
+	if dot := strings.Index(symbol[lastSlash+1:], "."); dot >= 0 {
+		return symbol[:lastSlash+1+dot]
+	}
+	return symbol
+}
+
+// isStdlibPath reports whether an import path belongs to the standard
+// library, whose first path element never contains a dot
+func isStdlibPath(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return first != "" && !strings.Contains(first, ".")
+}
@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+// StructuredContent moves the unified diffs and fenced code blocks in text
+// results into embedded resources, so clients that render MCP content
+// natively can show and apply them. A diff's resource is named by the file
+// URI of the file it changes and has MIME type text/x-diff; a code block's
+// is named after the tool and typed by the block's language. The prose
+// around them stays as text content, in order.
+func StructuredContent(manager *gopls.Manager, enabled bool) Middleware {
+	return func(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		if !enabled {
+			return next
+		}
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, request)
+			if err != nil || result == nil || result.IsError {
+				return result, err
+			}
+
+			var content []mcp.Content
+			snippets := 0
+			for _, item := range result.Content {
+				text, ok := item.(mcp.TextContent)
+				if !ok {
+					content = append(content, item)
+					continue
+				}
+				for _, segment := range splitCode(text.Text) {
+					switch {
+					case segment.kind == "":
+						if strings.TrimSpace(segment.text) != "" {
+							content = append(content, mcp.NewTextContent(strings.TrimRight(segment.text, "\n")))
+						}
+					case segment.kind == "diff":
+						content = append(content, mcp.NewEmbeddedResource(mcp.TextResourceContents{
+							URI:      diffURI(manager, segment.path),
+							MIMEType: "text/x-diff",
+							Text:     segment.text,
+						}))
+					default:
+						snippets++
+						content = append(content, mcp.NewEmbeddedResource(mcp.TextResourceContents{
+							URI:      fmt.Sprintf("mcp-gopls://%s/snippet/%d", tool.Name, snippets),
+							MIMEType: languageMIMEType(segment.kind),
+							Text:     segment.text,
+						}))
+					}
+				}
+			}
+			result.Content = content
+			return result, nil
+		}
+	}
+}
+
+// codeSegment is a run of prose, a unified diff of path (kind "diff") or a
+// code block in a language (kind "code:<language>")
+type codeSegment struct {
+	kind string
+	path string
+	text string
+}
+
+// splitCode splits text into prose, unified diffs as written by
+// edits.Unified and git, and fenced code blocks
+func splitCode(text string) []codeSegment {
+	lines := strings.SplitAfter(text, "\n")
+	var segments []codeSegment
+	prose := func(line string) {
+		if n := len(segments); n > 0 && segments[n-1].kind == "" {
+			segments[n-1].text += line
+			return
+		}
+		segments = append(segments, codeSegment{text: line})
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if end, path := diffEnd(lines, i); end > i {
+			segments = append(segments, codeSegment{kind: "diff", path: path, text: strings.Join(lines[i:end], "")})
+			i = end
+			continue
+		}
+		if fence := strings.TrimSpace(line); strings.HasPrefix(fence, "```") {
+			closing := -1
+			for j := i + 1; j < len(lines); j++ {
+				if strings.TrimSpace(lines[j]) == "```" {
+					closing = j
+					break
+				}
+			}
+			if closing > i {
+				language := strings.TrimSpace(strings.TrimPrefix(fence, "```"))
+				segments = append(segments, codeSegment{kind: "code:" + language, text: strings.Join(lines[i+1:closing], "")})
+				i = closing + 1
+				continue
+			}
+		}
+		prose(line)
+		i++
+	}
+	return segments
+}
+
+// diffEnd returns the end of the diff of one file starting at lines[start],
+// with the path it changes, or start when no diff starts there
+func diffEnd(lines []string, start int) (int, string) {
+	if start+2 >= len(lines) || !strings.HasPrefix(lines[start], "--- ") || !strings.HasPrefix(lines[start+1], "+++ ") ||
+		!strings.HasPrefix(lines[start+2], "@@") {
+		return start, ""
+	}
+	path := strings.TrimSpace(strings.TrimPrefix(lines[start+1], "+++ "))
+	if path == "/dev/null" {
+		path = strings.TrimSpace(strings.TrimPrefix(lines[start], "--- "))
+	}
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "a/"), "b/")
+
+	end := start + 2
+	for end < len(lines) {
+		line := lines[end]
+		if strings.HasPrefix(line, "--- ") && end+1 < len(lines) && strings.HasPrefix(lines[end+1], "+++ ") {
+			break
+		}
+		if line == "" || !strings.ContainsAny(line[:1], " +-@\\") {
+			break
+		}
+		end++
+	}
+	return end, path
+}
+
+// diffURI names the diff of path by the file URI of path
+func diffURI(manager *gopls.Manager, path string) string {
+	uri, err := utils.PathToURI(manager.ResolvePath(path))
+	if err != nil {
+		return "file:///" + strings.TrimPrefix(path, "/")
+	}
+	return uri
+}
+
+// languageMIMEType returns the MIME type of a code block of kind
+// "code:<language>"
+func languageMIMEType(kind string) string {
+	switch language := strings.ToLower(strings.TrimPrefix(kind, "code:")); language {
+	case "":
+		return "text/plain"
+	case "json":
+		return "application/json"
+	case "diff", "patch":
+		return "text/x-diff"
+	case "golang":
+		return "text/x-go"
+	default:
+		return "text/x-" + language
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/yantrio/mcp-gopls/internal/gopls"
 	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/resultfilter"
 	"github.com/yantrio/mcp-gopls/internal/utils"
 )
 
@@ -51,6 +52,7 @@ func NewSearchSymbolTool(manager *gopls.Manager) mcp.Tool {
 					"type":        "string",
 					"description": "Symbol name to search for (supports partial matching)",
 				},
+				"includeVendor": resultfilter.IncludeVendorProperty(manager),
 			},
 			Required: []string{"query"},
 		},
@@ -78,10 +80,11 @@ func NewSearchSymbolHandler(manager *gopls.Manager) server.ToolHandlerFunc {
 			return nil, fmt.Errorf("workspace symbol search failed: %w", err)
 		}
 
+		filter := resultfilter.FromRequest(manager, request)
 		results := make([]map[string]interface{}, 0)
 		for _, symbol := range symbols {
-			symPath, err := utils.URIToPath(symbol.Location.URI)
-			if err != nil {
+			symPath, err := manager.URIToPath(symbol.Location.URI)
+			if err != nil || !filter.Keep(symPath) {
 				continue
 			}
 
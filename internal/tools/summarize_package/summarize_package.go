@@ -0,0 +1,284 @@
+package summarize_package
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "SummarizePackage",
+		Description: "Produce a compact summary of a package for context: its purpose from the package doc, exported API grouped by kind with one-line docs, the dependencies it uses most and its tests",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Package directory, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"coverage": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Run the package's tests with -cover to report statement coverage (slower)",
+					"default":     false,
+				},
+				"maxDependencies": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum number of key dependencies to list, ranked by how often the package refers to them",
+					"default":     10,
+				},
+			},
+		},
+	}
+}
+
+var coveragePattern = regexp.MustCompile(`coverage: ([0-9.]+)% of statements`)
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		dir := manager.ResolvePath(request.GetString("path", ""))
+		maxDeps := request.GetInt("maxDependencies", 10)
+
+		pkgs, err := typecheck.Load(ctx, dir, ".")
+		if err != nil {
+			return nil, err
+		}
+		if len(pkgs) == 0 || pkgs[0].Types == nil {
+			return nil, fmt.Errorf("no Go package found in %s", dir)
+		}
+		pkg := pkgs[0]
+
+		docPkg, err := doc.NewFromFiles(pkg.Fset, pkg.Files, pkg.ImportPath, doc.PreserveAST)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract documentation: %w", err)
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "package %s // import %q\n", pkg.Name, pkg.ImportPath)
+		if synopsis := docPkg.Synopsis(docPkg.Doc); synopsis != "" {
+			fmt.Fprintf(&b, "Purpose: %s\n", synopsis)
+		} else {
+			b.WriteString("Purpose: (no package documentation)\n")
+		}
+		if len(pkg.Errors) > 0 {
+			fmt.Fprintf(&b, "Note: the package has %d type error(s), so the summary may be incomplete\n", len(pkg.Errors))
+		}
+
+		writeAPI(&b, docPkg, pkg.Types)
+
+		modulePath, _ := gocmd.Output(ctx, dir, "list", "-f", "{{with .Module}}{{.Path}}{{end}}", ".")
+		writeDependencies(&b, pkg, modulePath, maxDeps)
+
+		if err := writeTests(ctx, &b, dir, request.GetBool("coverage", false)); err != nil {
+			return nil, err
+		}
+
+		return mcp.NewToolResultText(strings.TrimRight(b.String(), "\n")), nil
+	}
+}
+
+// writeAPI lists the exported declarations grouped by kind, each with the
+// first sentence of its documentation
+func writeAPI(b *strings.Builder, docPkg *doc.Package, pkg *types.Package) {
+	qualifier := func(other *types.Package) string {
+		if other == pkg {
+			return ""
+		}
+		return other.Name()
+	}
+	line := func(indent, signature, docText string) {
+		fmt.Fprintf(b, "%s%s", indent, signature)
+		if synopsis := docPkg.Synopsis(docText); synopsis != "" {
+			fmt.Fprintf(b, " // %s", synopsis)
+		}
+		b.WriteString("\n")
+	}
+	object := func(name string) types.Object { return pkg.Scope().Lookup(name) }
+
+	values := func(title string, groups []*doc.Value) {
+		if len(groups) == 0 {
+			return
+		}
+		fmt.Fprintf(b, "\n%s:\n", title)
+		for _, group := range groups {
+			line("  ", strings.Join(group.Names, ", "), group.Doc)
+		}
+	}
+
+	values("Constants", docPkg.Consts)
+	values("Variables", docPkg.Vars)
+
+	if len(docPkg.Funcs) > 0 {
+		b.WriteString("\nFunctions:\n")
+		for _, fn := range docPkg.Funcs {
+			if obj := object(fn.Name); obj != nil {
+				line("  ", types.ObjectString(obj, qualifier), fn.Doc)
+			}
+		}
+	}
+
+	if len(docPkg.Types) > 0 {
+		b.WriteString("\nTypes:\n")
+		for _, t := range docPkg.Types {
+			obj, ok := object(t.Name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			line("  ", fmt.Sprintf("type %s %s", t.Name, kindOf(obj)), t.Doc)
+			for _, value := range append(append([]*doc.Value{}, t.Consts...), t.Vars...) {
+				line("    ", strings.Join(value.Names, ", "), value.Doc)
+			}
+			for _, fn := range t.Funcs {
+				if ctor := object(fn.Name); ctor != nil {
+					line("    ", types.ObjectString(ctor, qualifier), fn.Doc)
+				}
+			}
+			for _, m := range t.Methods {
+				method, _, _ := types.LookupFieldOrMethod(obj.Type(), true, pkg, m.Name)
+				if fn, ok := method.(*types.Func); ok {
+					line("    ", types.ObjectString(fn, qualifier), m.Doc)
+				}
+			}
+		}
+	}
+}
+
+// kindOf describes a named type briefly, such as "struct" or "interface"
+func kindOf(obj *types.TypeName) string {
+	switch u := obj.Type().Underlying().(type) {
+	case *types.Struct:
+		return "struct"
+	case *types.Interface:
+		return "interface"
+	case *types.Signature:
+		return "func"
+	default:
+		return types.TypeString(u, func(other *types.Package) string {
+			if other == obj.Pkg() {
+				return ""
+			}
+			return other.Name()
+		})
+	}
+}
+
+// writeDependencies lists imports grouped by origin, the most referenced
+// first within each group
+func writeDependencies(b *strings.Builder, pkg *typecheck.Package, modulePath string, limit int) {
+	uses := make(map[string]int)
+	for _, obj := range pkg.Info.Uses {
+		if name, ok := obj.(*types.PkgName); ok {
+			uses[name.Imported().Path()]++
+		}
+	}
+
+	var std, local, external []string
+	for _, imp := range pkg.Types.Imports() {
+		path := imp.Path()
+		switch {
+		case isStdlibPath(path):
+			std = append(std, path)
+		case modulePath != "" && (path == modulePath || strings.HasPrefix(path, modulePath+"/")):
+			local = append(local, path)
+		default:
+			external = append(external, path)
+		}
+	}
+	if len(std)+len(local)+len(external) == 0 {
+		return
+	}
+
+	b.WriteString("\nDependencies:\n")
+	for _, group := range []struct {
+		title string
+		paths []string
+	}{{"module", local}, {"external", external}, {"stdlib", std}} {
+		if len(group.paths) == 0 {
+			continue
+		}
+		sort.Slice(group.paths, func(i, j int) bool {
+			if uses[group.paths[i]] != uses[group.paths[j]] {
+				return uses[group.paths[i]] > uses[group.paths[j]]
+			}
+			return group.paths[i] < group.paths[j]
+		})
+		paths := group.paths
+		more := ""
+		if limit > 0 && len(paths) > limit {
+			more = fmt.Sprintf(" (+%d more)", len(paths)-limit)
+			paths = paths[:limit]
+		}
+		counted := make([]string, len(paths))
+		for i, path := range paths {
+			counted[i] = fmt.Sprintf("%s (%d)", path, uses[path])
+		}
+		fmt.Fprintf(b, "  %s: %s%s\n", group.title, strings.Join(counted, ", "), more)
+	}
+}
+
+func isStdlibPath(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return first != "" && !strings.Contains(first, ".")
+}
+
+// writeTests counts the package's tests, benchmarks, fuzz targets and
+// examples, and reports coverage when requested
+func writeTests(ctx context.Context, b *strings.Builder, dir string, coverage bool) error {
+	counts := make(map[string]int)
+	files := 0
+	err := astscan.Walk(dir, astscan.Options{IncludeTests: true, SingleDir: true}, func(f *astscan.File) error {
+		if !strings.HasSuffix(f.Path, "_test.go") {
+			return nil
+		}
+		files++
+		for _, decl := range f.AST.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil {
+				continue
+			}
+			for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+				if strings.HasPrefix(fn.Name.Name, prefix) && fn.Name.Name != "TestMain" {
+					counts[prefix]++
+					break
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	b.WriteString("\nTests: ")
+	if files == 0 {
+		b.WriteString("none\n")
+		return nil
+	}
+	fmt.Fprintf(b, "%d file(s), %d test(s), %d benchmark(s), %d fuzz target(s), %d example(s)\n",
+		files, counts["Test"], counts["Benchmark"], counts["Fuzz"], counts["Example"])
+
+	if coverage {
+		result, err := gocmd.Run(ctx, dir, nil, "test", "-cover", ".")
+		if err != nil {
+			return err
+		}
+		if m := coveragePattern.FindStringSubmatch(result.Stdout); m != nil {
+			fmt.Fprintf(b, "Coverage: %s%% of statements\n", m[1])
+		} else if result.ExitCode != 0 {
+			b.WriteString("Coverage: unavailable, the tests failed\n")
+		}
+	}
+	return nil
+}
@@ -4,16 +4,75 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/tools/add_test_case"
+	"github.com/yantrio/mcp-gopls/internal/tools/analyze_diff"
+	"github.com/yantrio/mcp-gopls/internal/tools/audit_struct_tags"
+	"github.com/yantrio/mcp-gopls/internal/tools/audit_unsafe"
+	"github.com/yantrio/mcp-gopls/internal/tools/bookmarks"
+	"github.com/yantrio/mcp-gopls/internal/tools/check_dependency_updates"
+	"github.com/yantrio/mcp-gopls/internal/tools/check_exhaustive_switch"
+	"github.com/yantrio/mcp-gopls/internal/tools/check_go_version"
+	"github.com/yantrio/mcp-gopls/internal/tools/check_import_boundaries"
+	"github.com/yantrio/mcp-gopls/internal/tools/check_snippet"
+	"github.com/yantrio/mcp-gopls/internal/tools/check_workspace"
+	"github.com/yantrio/mcp-gopls/internal/tools/create_package"
+	"github.com/yantrio/mcp-gopls/internal/tools/delete_symbol"
+	"github.com/yantrio/mcp-gopls/internal/tools/deprecate_function"
 	"github.com/yantrio/mcp-gopls/internal/tools/diagnostics"
+	"github.com/yantrio/mcp-gopls/internal/tools/diff_diagnostics"
+	"github.com/yantrio/mcp-gopls/internal/tools/download_dependencies"
+	"github.com/yantrio/mcp-gopls/internal/tools/explain_diagnostic"
+	"github.com/yantrio/mcp-gopls/internal/tools/export_index"
+	"github.com/yantrio/mcp-gopls/internal/tools/find_duplicates"
+	"github.com/yantrio/mcp-gopls/internal/tools/find_entry_points"
 	"github.com/yantrio/mcp-gopls/internal/tools/find_implementers"
+	"github.com/yantrio/mcp-gopls/internal/tools/find_import_cycles"
 	"github.com/yantrio/mcp-gopls/internal/tools/find_references"
+	"github.com/yantrio/mcp-gopls/internal/tools/find_shadowed"
+	"github.com/yantrio/mcp-gopls/internal/tools/find_tests"
+	"github.com/yantrio/mcp-gopls/internal/tools/find_untested"
 	"github.com/yantrio/mcp-gopls/internal/tools/format_code"
+	"github.com/yantrio/mcp-gopls/internal/tools/generate_accessors"
+	"github.com/yantrio/mcp-gopls/internal/tools/generate_constructor"
+	"github.com/yantrio/mcp-gopls/internal/tools/generate_fuzz"
+	"github.com/yantrio/mcp-gopls/internal/tools/generate_mock"
+	"github.com/yantrio/mcp-gopls/internal/tools/generate_stringer"
+	"github.com/yantrio/mcp-gopls/internal/tools/generate_wrapper"
+	"github.com/yantrio/mcp-gopls/internal/tools/go_env"
+	"github.com/yantrio/mcp-gopls/internal/tools/go_workspace"
 	"github.com/yantrio/mcp-gopls/internal/tools/goto_definition"
 	"github.com/yantrio/mcp-gopls/internal/tools/hover"
+	"github.com/yantrio/mcp-gopls/internal/tools/import_index"
+	"github.com/yantrio/mcp-gopls/internal/tools/init_module"
+	"github.com/yantrio/mcp-gopls/internal/tools/inspect_build_constraints"
+	"github.com/yantrio/mcp-gopls/internal/tools/list_dependencies"
 	"github.com/yantrio/mcp-gopls/internal/tools/list_document_symbols"
+	"github.com/yantrio/mcp-gopls/internal/tools/list_enum_values"
+	"github.com/yantrio/mcp-gopls/internal/tools/map_grpc_services"
+	"github.com/yantrio/mcp-gopls/internal/tools/map_http_routes"
+	"github.com/yantrio/mcp-gopls/internal/tools/move_file"
+	"github.com/yantrio/mcp-gopls/internal/tools/notes"
 	"github.com/yantrio/mcp-gopls/internal/tools/organize_imports"
+	"github.com/yantrio/mcp-gopls/internal/tools/overlay"
+	"github.com/yantrio/mcp-gopls/internal/tools/overlay_checkpoints"
+	"github.com/yantrio/mcp-gopls/internal/tools/preview_upgrade"
+	"github.com/yantrio/mcp-gopls/internal/tools/propagate_context"
 	"github.com/yantrio/mcp-gopls/internal/tools/rename"
+	"github.com/yantrio/mcp-gopls/internal/tools/reorder_members"
+	"github.com/yantrio/mcp-gopls/internal/tools/replace_text"
+	"github.com/yantrio/mcp-gopls/internal/tools/report_init_order"
+	"github.com/yantrio/mcp-gopls/internal/tools/rewrite_pattern"
+	"github.com/yantrio/mcp-gopls/internal/tools/run_fuzz"
+	"github.com/yantrio/mcp-gopls/internal/tools/run_snippet"
+	"github.com/yantrio/mcp-gopls/internal/tools/scan_concurrency"
+	"github.com/yantrio/mcp-gopls/internal/tools/server_status"
+	"github.com/yantrio/mcp-gopls/internal/tools/set_directory_filters"
+	"github.com/yantrio/mcp-gopls/internal/tools/split_file"
+	"github.com/yantrio/mcp-gopls/internal/tools/stdlib_doc"
 	"github.com/yantrio/mcp-gopls/internal/tools/stubs"
+	"github.com/yantrio/mcp-gopls/internal/tools/summarize_package"
+	"github.com/yantrio/mcp-gopls/internal/tools/workspace_report"
+	"github.com/yantrio/mcp-gopls/internal/tools/wrap_errors"
 )
 
 // GetTools returns all available tools
@@ -29,21 +88,151 @@ func GetTools(manager *gopls.Manager) []mcp.Tool {
 		stubs.NewSearchSymbolTool(manager),
 		format_code.NewTool(manager),
 		organize_imports.NewTool(manager),
+		go_env.NewTool(manager),
+		download_dependencies.NewTool(manager),
+		stdlib_doc.NewTool(manager),
+		audit_unsafe.NewTool(manager),
+		scan_concurrency.NewTool(manager),
+		list_enum_values.NewTool(manager),
+		check_exhaustive_switch.NewTool(manager),
+		audit_struct_tags.NewTool(manager),
+		generate_constructor.NewTool(manager),
+		generate_accessors.NewTool(manager),
+		generate_stringer.NewTool(manager),
+		generate_mock.NewTool(manager),
+		generate_wrapper.NewTool(manager),
+		wrap_errors.NewTool(manager),
+		propagate_context.NewTool(manager),
+		deprecate_function.NewTool(manager),
+		reorder_members.NewTool(manager),
+		split_file.NewTool(manager),
+		find_duplicates.NewTool(manager),
+		summarize_package.NewTool(manager),
+		workspace_report.NewTool(manager),
+		explain_diagnostic.NewTool(manager),
+		diff_diagnostics.NewTool(manager),
+		check_workspace.NewTool(manager),
+		move_file.NewTool(manager),
+		create_package.NewTool(manager),
+		delete_symbol.NewTool(manager),
+		find_shadowed.NewTool(manager),
+		check_go_version.NewTool(manager),
+		list_dependencies.NewTool(manager),
+		preview_upgrade.NewTool(manager),
+		replace_text.NewTool(manager),
+		rewrite_pattern.NewTool(manager),
+		check_snippet.NewTool(manager),
+		run_snippet.NewTool(manager),
+		find_tests.NewTool(manager),
+		find_untested.NewTool(manager),
+		add_test_case.NewTool(manager),
+		generate_fuzz.NewTool(manager),
+		run_fuzz.NewTool(manager),
+		server_status.NewTool(manager),
+		init_module.NewTool(manager),
+		set_directory_filters.NewTool(manager),
+		export_index.NewTool(manager),
+		import_index.NewTool(manager),
+		analyze_diff.NewTool(manager),
+		check_import_boundaries.NewTool(manager),
+		find_import_cycles.NewTool(manager),
+		report_init_order.NewTool(manager),
+		inspect_build_constraints.NewTool(manager),
+		bookmarks.NewSaveLocationTool(manager),
+		bookmarks.NewListLocationsTool(manager),
+		notes.NewSetNoteTool(manager),
+		notes.NewGetNoteTool(manager),
+		notes.NewListNotesTool(manager),
+		notes.NewDeleteNoteTool(manager),
+		overlay.NewTool(manager),
+		overlay_checkpoints.NewCreateCheckpointTool(manager),
+		overlay_checkpoints.NewRestoreCheckpointTool(manager),
+		overlay_checkpoints.NewDiffCheckpointsTool(manager),
+		check_dependency_updates.NewTool(manager),
+		go_workspace.NewTool(manager),
+		find_entry_points.NewTool(manager),
+		map_http_routes.NewTool(manager),
+		map_grpc_services.NewTool(manager),
 	}
 }
 
 // GetToolHandlers returns all tool handlers
 func GetToolHandlers(manager *gopls.Manager) map[string]server.ToolHandlerFunc {
 	return map[string]server.ToolHandlerFunc{
-		"GoToDefinition":      goto_definition.NewHandler(manager),
-		"FindReferences":      find_references.NewHandler(manager),
-		"GetDiagnostics":      diagnostics.NewHandler(manager),
-		"Hover":               hover.NewHandler(manager),
-		"RenameSymbol":        rename.NewHandler(manager),
-		"FindImplementers":    find_implementers.NewHandler(manager),
-		"ListDocumentSymbols": list_document_symbols.NewHandler(manager),
-		"SearchSymbol":        stubs.NewSearchSymbolHandler(manager),
-		"FormatCode":          format_code.NewHandler(manager),
-		"OrganizeImports":     organize_imports.NewHandler(manager),
+		"GoToDefinition":          goto_definition.NewHandler(manager),
+		"FindReferences":          find_references.NewHandler(manager),
+		"GetDiagnostics":          diagnostics.NewHandler(manager),
+		"Hover":                   hover.NewHandler(manager),
+		"RenameSymbol":            rename.NewHandler(manager),
+		"FindImplementers":        find_implementers.NewHandler(manager),
+		"ListDocumentSymbols":     list_document_symbols.NewHandler(manager),
+		"SearchSymbol":            stubs.NewSearchSymbolHandler(manager),
+		"FormatCode":              format_code.NewHandler(manager),
+		"OrganizeImports":         organize_imports.NewHandler(manager),
+		"GoEnv":                   go_env.NewHandler(manager),
+		"DownloadDependencies":    download_dependencies.NewHandler(manager),
+		"StdlibDoc":               stdlib_doc.NewHandler(manager),
+		"AuditUnsafe":             audit_unsafe.NewHandler(manager),
+		"ScanConcurrency":         scan_concurrency.NewHandler(manager),
+		"ListEnumValues":          list_enum_values.NewHandler(manager),
+		"CheckExhaustiveSwitch":   check_exhaustive_switch.NewHandler(manager),
+		"AuditStructTags":         audit_struct_tags.NewHandler(manager),
+		"GenerateConstructor":     generate_constructor.NewHandler(manager),
+		"GenerateAccessors":       generate_accessors.NewHandler(manager),
+		"GenerateStringer":        generate_stringer.NewHandler(manager),
+		"GenerateMock":            generate_mock.NewHandler(manager),
+		"GenerateWrapper":         generate_wrapper.NewHandler(manager),
+		"WrapErrors":              wrap_errors.NewHandler(manager),
+		"PropagateContext":        propagate_context.NewHandler(manager),
+		"DeprecateFunction":       deprecate_function.NewHandler(manager),
+		"ReorderMembers":          reorder_members.NewHandler(manager),
+		"SplitFile":               split_file.NewHandler(manager),
+		"FindDuplicates":          find_duplicates.NewHandler(manager),
+		"SummarizePackage":        summarize_package.NewHandler(manager),
+		"WorkspaceReport":         workspace_report.NewHandler(manager),
+		"ExplainDiagnostic":       explain_diagnostic.NewHandler(manager),
+		"DiffDiagnostics":         diff_diagnostics.NewHandler(manager),
+		"CheckWorkspace":          check_workspace.NewHandler(manager),
+		"MoveFile":                move_file.NewHandler(manager),
+		"CreatePackage":           create_package.NewHandler(manager),
+		"DeleteSymbol":            delete_symbol.NewHandler(manager),
+		"FindShadowedVariables":   find_shadowed.NewHandler(manager),
+		"CheckGoVersion":          check_go_version.NewHandler(manager),
+		"ListDependencies":        list_dependencies.NewHandler(manager),
+		"PreviewUpgrade":          preview_upgrade.NewHandler(manager),
+		"ReplaceText":             replace_text.NewHandler(manager),
+		"RewritePattern":          rewrite_pattern.NewHandler(manager),
+		"CheckSnippet":            check_snippet.NewHandler(manager),
+		"RunSnippet":              run_snippet.NewHandler(manager),
+		"FindTestsFor":            find_tests.NewHandler(manager),
+		"FindUntestedFunctions":   find_untested.NewHandler(manager),
+		"AddTableTestCase":        add_test_case.NewHandler(manager),
+		"GenerateFuzzTarget":      generate_fuzz.NewHandler(manager),
+		"RunFuzz":                 run_fuzz.NewHandler(manager),
+		"ServerStatus":            server_status.NewHandler(manager),
+		"InitModule":              init_module.NewHandler(manager),
+		"SetDirectoryFilters":     set_directory_filters.NewHandler(manager),
+		"ExportIndex":             export_index.NewHandler(manager),
+		"ImportIndex":             import_index.NewHandler(manager),
+		"AnalyzeDiff":             analyze_diff.NewHandler(manager),
+		"CheckImportBoundaries":   check_import_boundaries.NewHandler(manager),
+		"FindImportCycles":        find_import_cycles.NewHandler(manager),
+		"ReportInitOrder":         report_init_order.NewHandler(manager),
+		"InspectBuildConstraints": inspect_build_constraints.NewHandler(manager),
+		"SaveLocation":            bookmarks.NewSaveLocationHandler(manager),
+		"ListLocations":           bookmarks.NewListLocationsHandler(manager),
+		"SetNote":                 notes.NewSetNoteHandler(manager),
+		"GetNote":                 notes.NewGetNoteHandler(manager),
+		"ListNotes":               notes.NewListNotesHandler(manager),
+		"DeleteNote":              notes.NewDeleteNoteHandler(manager),
+		"Overlay":                 overlay.NewHandler(manager),
+		"CreateCheckpoint":        overlay_checkpoints.NewCreateCheckpointHandler(manager),
+		"RestoreCheckpoint":       overlay_checkpoints.NewRestoreCheckpointHandler(manager),
+		"DiffCheckpoints":         overlay_checkpoints.NewDiffCheckpointsHandler(manager),
+		"CheckDependencyUpdates":  check_dependency_updates.NewHandler(manager),
+		"GoWorkspace":             go_workspace.NewHandler(manager),
+		"FindEntryPoints":         find_entry_points.NewHandler(manager),
+		"MapHTTPRoutes":           map_http_routes.NewHandler(manager),
+		"MapGRPCServices":         map_grpc_services.NewHandler(manager),
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,377 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+// testWorkspace is a module with a type, an interface it implements and a
+// function using both, for the fake gopls to answer about
+var testWorkspace = map[string]string{
+	"go.mod": "module example.com/shapes\n\ngo 1.21\n",
+	"shapes.go": `package shapes
+
+// Shape is anything with an area
+type Shape interface {
+	Area() float64
+}
+
+// Square is a Shape
+type Square struct {
+	Side float64
+}
+
+func (s Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+func Total(shapes []Shape) float64 {
+	total := 0.0
+	for _, s := range shapes {
+		total += s.Area()
+	}
+	return total
+}
+`,
+	"use.go": `package shapes
+
+func Unit() float64 {
+	return Total([]Shape{Square{Side: 1}})
+}
+`,
+	"messy.go": "package shapes\n\nfunc  Messy( ) int {\nreturn 1\n}\n",
+}
+
+// newTestManager writes files to a temporary workspace and starts the fake
+// gopls in it, which the manager talks to through lsp.NewStreamClient
+func newTestManager(t *testing.T, files map[string]string) (*gopls.Manager, string) {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manager, err := gopls.NewManager(gopls.Config{WorkspaceRoot: root, Mock: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { manager.Shutdown(context.Background()) })
+	return manager, manager.WorkspaceRoot()
+}
+
+func newRequest(name string, args map[string]interface{}) mcp.CallToolRequest {
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = args
+	return request
+}
+
+// callTool runs the handler of a tool and returns the text of its result
+func callTool(t *testing.T, manager *gopls.Manager, name string, args map[string]interface{}) string {
+	t.Helper()
+	handler, ok := GetToolHandlers(manager)[name]
+	if !ok {
+		t.Fatalf("no handler for %s", name)
+	}
+	result, err := handler(context.Background(), newRequest(name, args))
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	var text strings.Builder
+	for _, content := range result.Content {
+		if c, ok := content.(mcp.TextContent); ok {
+			text.WriteString(c.Text)
+		}
+	}
+	if result.IsError {
+		t.Fatalf("%s returned an error: %s", name, text.String())
+	}
+	return text.String()
+}
+
+func TestReadTools(t *testing.T) {
+	manager, root := newTestManager(t, testWorkspace)
+	shapes := filepath.Join(root, "shapes.go")
+	use := filepath.Join(root, "use.go")
+
+	tests := []struct {
+		tool string
+		args map[string]interface{}
+		want []string
+	}{
+		{
+			// Total in use.go
+			tool: "GoToDefinition",
+			args: map[string]interface{}{"file": use, "line": 4, "column": 9},
+			want: []string{shapes, `"line": 17`, "func Total(shapes []Shape) float64 {"},
+		},
+		{
+			// Square
+			tool: "FindReferences",
+			args: map[string]interface{}{"file": shapes, "line": 9, "column": 6},
+			want: []string{use},
+		},
+		{
+			tool: "Hover",
+			args: map[string]interface{}{"file": shapes, "line": 4, "column": 6},
+			want: []string{"Shape is anything with an area"},
+		},
+		{
+			tool: "FindImplementers",
+			args: map[string]interface{}{"file": shapes, "line": 4, "column": 6},
+			want: []string{"Square"},
+		},
+		{
+			tool: "ListDocumentSymbols",
+			args: map[string]interface{}{"file": shapes},
+			want: []string{"Shape", "Square", "Area", "Total"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			got := callTool(t, manager, tt.tool, tt.args)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("%s result does not contain %q:\n%s", tt.tool, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRenameSymbol(t *testing.T) {
+	manager, root := newTestManager(t, testWorkspace)
+	shapes := filepath.Join(root, "shapes.go")
+
+	callTool(t, manager, "RenameSymbol", map[string]interface{}{
+		"file":                shapes,
+		"line":                9,
+		"column":              6,
+		"newName":             "Box",
+		"buildConfigurations": false,
+	})
+
+	for _, name := range []string{"shapes.go", "use.go"} {
+		content, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(content), "Square{") || strings.Contains(string(content), "Square)") ||
+			strings.Contains(string(content), "Square struct") {
+			t.Errorf("%s still uses Square after the rename:\n%s", name, content)
+		}
+		if !strings.Contains(string(content), "Box") {
+			t.Errorf("%s does not mention Box after the rename:\n%s", name, content)
+		}
+	}
+}
+
+func TestFormatCode(t *testing.T) {
+	manager, root := newTestManager(t, testWorkspace)
+	messy := filepath.Join(root, "messy.go")
+
+	callTool(t, manager, "FormatCode", map[string]interface{}{"file": messy})
+
+	content, err := os.ReadFile(messy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "package shapes\n\nfunc Messy() int {\n\treturn 1\n}\n"
+	if string(content) != want {
+		t.Errorf("formatted content = %q, want %q", content, want)
+	}
+}
+
+func TestFormatCodeRefusesStagedFile(t *testing.T) {
+	manager, root := newTestManager(t, testWorkspace)
+	messy := filepath.Join(root, "messy.go")
+	before, err := os.ReadFile(messy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Stage(context.Background(), messy, []byte("package shapes\n\n// Staged\nfunc  Messy() int { return 2 }\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := GetToolHandlers(manager)["FormatCode"]
+	_, err = handler(context.Background(), newRequest("FormatCode", map[string]interface{}{"file": messy}))
+	var staged *gopls.StagedFileError
+	if !errors.As(err, &staged) {
+		t.Fatalf("FormatCode on a staged file: err = %v, want a *gopls.StagedFileError", err)
+	}
+	after, err := os.ReadFile(messy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("the file on disk changed to %q", after)
+	}
+}
+
+func TestReplaceTextDiff(t *testing.T) {
+	manager, root := newTestManager(t, testWorkspace)
+
+	got := callTool(t, manager, "ReplaceText", map[string]interface{}{
+		"pattern":     `\bSide\b`,
+		"replacement": "Length",
+		"context":     "identifiers",
+	})
+	if !strings.Contains(got, "--- a/shapes.go\n+++ b/shapes.go\n") {
+		t.Errorf("the diff is not labelled with the workspace-relative path:\n%s", got)
+	}
+	if strings.Contains(got, root) {
+		t.Errorf("the diff mentions the workspace root %s:\n%s", root, got)
+	}
+
+	// A replacement that changes nothing is not reported
+	got = callTool(t, manager, "ReplaceText", map[string]interface{}{
+		"pattern":     `\bSide\b`,
+		"replacement": "Side",
+		"context":     "identifiers",
+	})
+	if !strings.HasPrefix(got, "Would replace 0 match(es) in 0 file(s)") || strings.Contains(got, "---") {
+		t.Errorf("an unchanged replacement was reported:\n%s", got)
+	}
+}
+
+func TestRewritePatternDiff(t *testing.T) {
+	manager, root := newTestManager(t, testWorkspace)
+
+	got := callTool(t, manager, "RewritePattern", map[string]interface{}{
+		"rule":   "s.Side * s.Side -> s.Side * s.Side * 1",
+		"dryRun": true,
+	})
+	if !strings.HasPrefix(got, "Would rewrite 1 match(es) in 1 file(s)") {
+		t.Errorf("unexpected summary:\n%s", got)
+	}
+	if !strings.Contains(got, "--- a/shapes.go\n+++ b/shapes.go\n") || strings.Contains(got, root) {
+		t.Errorf("the diff is not labelled with the workspace-relative path:\n%s", got)
+	}
+
+	// A rule whose replacement is the match rewrites nothing
+	got = callTool(t, manager, "RewritePattern", map[string]interface{}{
+		"rule":   "$x * $x -> $x * $x",
+		"dryRun": true,
+	})
+	if !strings.HasPrefix(got, "Would rewrite 0 match(es) in 0 file(s)") || strings.Contains(got, "---") {
+		t.Errorf("an unchanged rewrite was reported:\n%s", got)
+	}
+}
+
+func TestPropagateContextJoinsImports(t *testing.T) {
+	manager, root := newTestManager(t, map[string]string{
+		"go.mod": "module example.com/greet\n\ngo 1.21\n",
+		"greet.go": `package greet
+
+import "fmt"
+
+func Greet(name string) string {
+	return fmt.Sprintf("hello %s", name)
+}
+
+func Hello() string {
+	return Greet("world")
+}
+`,
+	})
+	greet := filepath.Join(root, "greet.go")
+
+	callTool(t, manager, "PropagateContext", map[string]interface{}{"file": greet, "line": 5, "column": 6})
+
+	content, err := os.ReadFile(greet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "import (\n\t\"context\"\n\t\"fmt\"\n)\n") {
+		t.Errorf("context was not added to the fmt import:\n%s", content)
+	}
+}
+
+func TestFindUntestedRanksByMissingKinds(t *testing.T) {
+	files := map[string]string{
+		"go.mod": "module example.com/calc\n\ngo 1.22\n",
+		"calc.go": `package calc
+
+func Add(a, b int) int { return a + b }
+
+func Sub(a, b int) int { return a - b }
+
+func Mul(a, b int) int { return a * b }
+`,
+		"calc_test.go": `package calc
+
+import "testing"
+
+func TestAdd(t *testing.T) { Add(1, 2) }
+
+func BenchmarkAdd(b *testing.B) { Add(1, 2) }
+
+func TestSub(t *testing.T) { Sub(1, 2) }
+`,
+	}
+	manager, _ := newTestManager(t, files)
+	text := callTool(t, manager, "FindUntestedFunctions", nil)
+
+	// Mul lacks all three kinds, Sub two and Add only an example
+	mul, sub, add := strings.Index(text, `"name": "Mul"`), strings.Index(text, `"name": "Sub"`), strings.Index(text, `"name": "Add"`)
+	if mul < 0 || sub < 0 || add < 0 {
+		t.Fatalf("FindUntestedFunctions left out a function:\n%s", text)
+	}
+	if !(mul < sub && sub < add) {
+		t.Errorf("FindUntestedFunctions did not rank Mul, Sub, Add by missing kinds:\n%s", text)
+	}
+}
+
+func TestHoverFormats(t *testing.T) {
+	manager, root := newTestManager(t, testWorkspace)
+	shapes := filepath.Join(root, "shapes.go")
+
+	markdown := callTool(t, manager, "Hover", map[string]interface{}{"file": shapes, "line": 4, "column": 6})
+	if !strings.Contains(markdown, "```go") {
+		t.Errorf("default Hover output is not gopls's markdown:\n%s", markdown)
+	}
+	plain := callTool(t, manager, "Hover", map[string]interface{}{"file": shapes, "line": 4, "column": 6, "format": "plaintext"})
+	if strings.Contains(plain, "```") || !strings.Contains(plain, "Shape is anything with an area") {
+		t.Errorf("plaintext Hover output is not plain text:\n%s", plain)
+	}
+}
+
+func TestFindReferencesDeclarationFilter(t *testing.T) {
+	manager, root := newTestManager(t, testWorkspace)
+	shapes := filepath.Join(root, "shapes.go")
+
+	// Square, without includeDeclaration
+	text := callTool(t, manager, "FindReferences", map[string]interface{}{"file": shapes, "line": 9, "column": 6, "accessKind": "declaration"})
+	if !strings.Contains(text, "Found 1 reference(s)") || !strings.Contains(text, `"line": 9`) {
+		t.Errorf("FindReferences did not return the declaration of Square:\n%s", text)
+	}
+}
+
+func TestSnippetCompileErrors(t *testing.T) {
+	manager, _ := newTestManager(t, testWorkspace)
+
+	text := callTool(t, manager, "CheckSnippet", map[string]interface{}{"code": "x := 1\nreturn undefinedName"})
+	if !strings.Contains(text, "undefined: undefinedName") || !strings.Contains(text, `"line": 2`) {
+		t.Errorf("CheckSnippet did not report the error on line 2:\n%s", text)
+	}
+	text = callTool(t, manager, "RunSnippet", map[string]interface{}{"code": "println(undefinedName)"})
+	if !strings.Contains(text, "undefined: undefinedName") || !strings.Contains(text, `"line": 1`) {
+		t.Errorf("RunSnippet did not report the error on line 1:\n%s", text)
+	}
+}
@@ -0,0 +1,71 @@
+package workspace_report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/analysis"
+	"github.com/yantrio/mcp-gopls/internal/astscan"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+)
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "WorkspaceReport",
+		Description: "Report the size and shape of the workspace: package count, lines of code and test ratio per package, the largest files, and dependency fan-in/fan-out between workspace packages. Useful for planning large refactors.",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Directory to report on, absolute or relative to the workspace root (defaults to the workspace root)",
+				},
+				"maxPackages": map[string]interface{}{
+					"type":        "number",
+					"description": "Maximum number of packages to list, largest first (0 lists all)",
+					"default":     50,
+				},
+				"maxFiles": map[string]interface{}{
+					"type":        "number",
+					"description": "Number of largest files to list",
+					"default":     10,
+				},
+				"includeVendor": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Include vendor directories",
+					"default":     false,
+				},
+			},
+		},
+	}
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		root := manager.ResolvePath(request.GetString("path", ""))
+		maxPackages := request.GetInt("maxPackages", 50)
+		maxFiles := request.GetInt("maxFiles", 10)
+
+		opts := astscan.Options{IncludeVendor: request.GetBool("includeVendor", false)}
+		report, err := analysis.BuildReport(ctx, root, opts, maxFiles)
+		if err != nil {
+			return nil, err
+		}
+		if report.PackageCount == 0 {
+			return mcp.NewToolResultText(fmt.Sprintf("No Go packages found in %s", root)), nil
+		}
+
+		header := fmt.Sprintf("%d package(s), %d file(s) and %d test file(s) with %d lines of code and %d lines of test code",
+			report.PackageCount, report.FileCount, report.TestFileCount, report.CodeLines, report.TestCodeLines)
+		if maxPackages > 0 && len(report.Packages) > maxPackages {
+			header += fmt.Sprintf("; showing the largest %d packages", maxPackages)
+			report.Packages = report.Packages[:maxPackages]
+		}
+
+		result, _ := json.MarshalIndent(report, "", "  ")
+		return mcp.NewToolResultText(header + ":\n" + string(result)), nil
+	}
+}
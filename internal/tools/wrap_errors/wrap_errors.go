@@ -0,0 +1,436 @@
+package wrap_errors
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/lsp"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+	"github.com/yantrio/mcp-gopls/internal/utils"
+)
+
+var errorType = types.Universe.Lookup("error").Type()
+
+func NewTool(manager *gopls.Manager) mcp.Tool {
+	return mcp.Tool{
+		Name:        "WrapErrors",
+		Description: "Rewrite fmt.Errorf calls that format errors with %v or %s to wrap them with %w, and optionally wrap bare 'return err' statements with context, across a file or package",
+		InputSchema: mcp.ToolInputSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Go source file or package directory to rewrite (relative paths are resolved against the workspace root)",
+				},
+				"mode": map[string]interface{}{
+					"type":        "string",
+					"description": "'errorf' to switch fmt.Errorf verbs to %w, 'returns' to wrap bare returned errors with context, or 'both'",
+					"enum":        []string{"errorf", "returns", "both"},
+					"default":     "errorf",
+				},
+				"organizeImports": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Organize imports in every changed file before writing it",
+					"default":     false,
+				},
+				"dryRun": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Report the changes as a unified diff without modifying any files",
+					"default":     false,
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+// change describes one rewrite for the result listing
+type change struct {
+	line   int
+	before string
+	after  string
+}
+
+func NewHandler(manager *gopls.Manager) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, err := request.RequireString("path")
+		if err != nil {
+			return nil, err
+		}
+		mode := request.GetString("mode", "errorf")
+		if mode != "errorf" && mode != "returns" && mode != "both" {
+			return nil, fmt.Errorf("invalid mode %q: must be 'errorf', 'returns' or 'both'", mode)
+		}
+		dryRun := request.GetBool("dryRun", false)
+
+		path = manager.ResolvePath(path)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		dir, onlyFile := path, ""
+		if !info.IsDir() {
+			dir, onlyFile = filepath.Dir(path), path
+		}
+
+		pkgs, err := typecheck.Load(ctx, dir, ".")
+		if err != nil {
+			return nil, err
+		}
+		if len(pkgs) == 0 {
+			return nil, fmt.Errorf("no package found in %s", dir)
+		}
+		pkg := pkgs[0]
+
+		var files []*ast.File
+		if onlyFile != "" {
+			file := pkg.File(onlyFile)
+			if file == nil {
+				return nil, fmt.Errorf("%s is not part of package %s for the current build configuration", onlyFile, pkg.ImportPath)
+			}
+			files = []*ast.File{file}
+		} else {
+			files = pkg.Files
+		}
+
+		var order []string
+		originals := make(map[string]string)
+		updates := make(map[string][]byte)
+		reports := make(map[string]string)
+		total := 0
+		for _, file := range files {
+			if ast.IsGenerated(file) {
+				continue
+			}
+			filename := pkg.Fset.Position(file.Pos()).Filename
+			content, err := os.ReadFile(filename)
+			if err != nil {
+				return nil, err
+			}
+
+			rw := &rewriter{pkg: pkg, file: file, fmtName: edits.ImportName(file, "fmt")}
+			rw.rewrite(mode)
+			if len(rw.edits) == 0 {
+				continue
+			}
+			if rw.needsFmt {
+				rw.edits = append(rw.edits, edits.AddImport(pkg.Fset, file, "fmt"))
+			}
+
+			updated, err := edits.Apply(string(content), rw.edits)
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewrite %s: %w", filename, err)
+			}
+
+			var report strings.Builder
+			for _, c := range rw.changes {
+				fmt.Fprintf(&report, "  line %d: %s -> %s\n", c.line, c.before, c.after)
+			}
+			order = append(order, filename)
+			originals[filename] = string(content)
+			updates[filename] = []byte(updated)
+			reports[filename] = report.String()
+			total += len(rw.changes)
+		}
+
+		if total > 0 && request.GetBool("organizeImports", false) {
+			if err := manager.OrganizeImports(ctx, updates); err != nil {
+				return nil, err
+			}
+		}
+
+		var result strings.Builder
+		for _, filename := range order {
+			rel := edits.Label(manager.WorkspaceRoot(), filename)
+			fmt.Fprintf(&result, "%s:\n%s", rel, reports[filename])
+			if dryRun {
+				result.WriteString("\n" + edits.Unified(rel, originals[filename], string(updates[filename])) + "\n")
+			}
+		}
+		if !dryRun {
+			if err := manager.WriteFiles(updates); err != nil {
+				return nil, err
+			}
+		}
+		changedFiles := len(order)
+
+		if total == 0 {
+			return mcp.NewToolResultText("No errors to wrap"), nil
+		}
+
+		verb := "Rewrote"
+		if dryRun {
+			verb = "Would rewrite"
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("%s %d error site(s) in %d file(s):\n\n%s", verb, total, changedFiles, result.String())), nil
+	}
+}
+
+// rewriter collects the edits for one file
+type rewriter struct {
+	pkg      *typecheck.Package
+	file     *ast.File
+	fmtName  string
+	needsFmt bool
+	edits    []lsp.TextEdit
+	changes  []change
+}
+
+func (rw *rewriter) rewrite(mode string) {
+	var stack []ast.Node
+	ast.Inspect(rw.file, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if mode != "returns" {
+				rw.rewriteErrorf(n)
+			}
+		case *ast.ReturnStmt:
+			if mode != "errorf" {
+				rw.wrapReturn(n, stack)
+			}
+		}
+		return true
+	})
+}
+
+// rewriteErrorf switches %v and %s verbs to %w where the argument is an error
+func (rw *rewriter) rewriteErrorf(call *ast.CallExpr) {
+	if !rw.isFmtErrorf(call.Fun) || len(call.Args) < 2 {
+		return
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return
+	}
+
+	updated, ok := wrapVerbs(lit.Value, func(i int) bool {
+		if i+1 >= len(call.Args) {
+			return false
+		}
+		return rw.isError(call.Args[i+1])
+	})
+	if !ok || updated == lit.Value {
+		return
+	}
+
+	rw.edits = append(rw.edits, lsp.TextEdit{Range: rw.nodeRange(lit), NewText: updated})
+	rw.changes = append(rw.changes, change{
+		line:   rw.pkg.Fset.Position(lit.Pos()).Line,
+		before: lit.Value,
+		after:  updated,
+	})
+}
+
+// wrapVerbs rewrites the plain %v and %s verbs in a format string literal
+// whose operands are errors, as reported by isErr for each operand index.
+// Formats using explicit argument indexes or * widths are left alone.
+func wrapVerbs(format string, isErr func(int) bool) (string, bool) {
+	var b strings.Builder
+	arg := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		if i+1 < len(format) && format[i+1] == '%' {
+			b.WriteString("%%")
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j < len(format) && strings.IndexByte("+-# 0123456789.", format[j]) >= 0 {
+			j++
+		}
+		if j >= len(format) || format[j] == '*' || format[j] == '[' {
+			return format, false
+		}
+
+		verb := format[j]
+		if j == i+1 && (verb == 'v' || verb == 's') && isErr(arg) {
+			b.WriteString("%w")
+		} else {
+			b.WriteString(format[i : j+1])
+		}
+		arg++
+		i = j
+	}
+	return b.String(), true
+}
+
+// wrapReturn wraps a returned local error variable with fmt.Errorf, using
+// the call that produced the error (or the enclosing function) as context
+func (rw *rewriter) wrapReturn(ret *ast.ReturnStmt, stack []ast.Node) {
+	sig := rw.enclosingSignature(stack)
+	if sig == nil || sig.Results().Len() == 0 || len(ret.Results) != sig.Results().Len() {
+		return
+	}
+	if !types.Identical(sig.Results().At(sig.Results().Len()-1).Type(), errorType) {
+		return
+	}
+
+	ident, ok := ret.Results[len(ret.Results)-1].(*ast.Ident)
+	if !ok {
+		return
+	}
+	obj, ok := rw.pkg.Info.Uses[ident].(*types.Var)
+	if !ok || !types.Identical(obj.Type(), errorType) || obj.Parent() == rw.pkg.Types.Scope() {
+		return
+	}
+
+	message := rw.context(obj, stack)
+	if message == "" {
+		return
+	}
+
+	if rw.fmtName == "" {
+		rw.fmtName = "fmt"
+		rw.needsFmt = true
+	}
+	wrapped := fmt.Sprintf("%s.Errorf(%s, %s)", rw.fmtName, strconv.Quote(message+": %w"), ident.Name)
+	rw.edits = append(rw.edits, lsp.TextEdit{Range: rw.nodeRange(ident), NewText: wrapped})
+	rw.changes = append(rw.changes, change{
+		line:   rw.pkg.Fset.Position(ident.Pos()).Line,
+		before: ident.Name,
+		after:  wrapped,
+	})
+}
+
+// enclosingSignature returns the signature of the innermost function in stack
+func (rw *rewriter) enclosingSignature(stack []ast.Node) *types.Signature {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch fn := stack[i].(type) {
+		case *ast.FuncDecl:
+			if obj, ok := rw.pkg.Info.Defs[fn.Name].(*types.Func); ok {
+				return obj.Type().(*types.Signature)
+			}
+			return nil
+		case *ast.FuncLit:
+			sig, _ := rw.pkg.Info.TypeOf(fn).(*types.Signature)
+			return sig
+		}
+	}
+	return nil
+}
+
+// context describes the operation that produced the error: the call that
+// last assigned it, or failing that the enclosing function's name
+func (rw *rewriter) context(obj *types.Var, stack []ast.Node) string {
+	for i := len(stack) - 1; i > 0; i-- {
+		switch n := stack[i].(type) {
+		case *ast.IfStmt:
+			if name := rw.assigningCall(n.Init, obj); name != "" {
+				return describe(name)
+			}
+			// Look at the statement just before the if
+			if block, ok := stack[i-1].(*ast.BlockStmt); ok {
+				for j, stmt := range block.List {
+					if stmt == n && j > 0 {
+						if name := rw.assigningCall(block.List[j-1], obj); name != "" {
+							return describe(name)
+						}
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			return describe(n.Name.Name)
+		case *ast.FuncLit:
+			// Fall through to the function declaring the literal
+		}
+	}
+	return ""
+}
+
+// assigningCall returns the name of the function called by stmt when it
+// assigns the call's result to obj
+func (rw *rewriter) assigningCall(stmt ast.Stmt, obj *types.Var) string {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || len(assign.Rhs) != 1 {
+		return ""
+	}
+	call, ok := assign.Rhs[0].(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	for _, lhs := range assign.Lhs {
+		ident, ok := lhs.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if rw.pkg.Info.Defs[ident] == obj || rw.pkg.Info.Uses[ident] == obj {
+			return calleeName(call.Fun)
+		}
+	}
+	return ""
+}
+
+func calleeName(fun ast.Expr) string {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f.Name
+	case *ast.SelectorExpr:
+		return f.Sel.Name
+	case *ast.IndexExpr:
+		return calleeName(f.X)
+	case *ast.IndexListExpr:
+		return calleeName(f.X)
+	case *ast.ParenExpr:
+		return calleeName(f.X)
+	}
+	return ""
+}
+
+// describe turns an identifier like ReadFile into an error context like "read file"
+func describe(name string) string {
+	words := utils.SplitWords(name)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return strings.Join(words, " ")
+}
+
+func (rw *rewriter) isFmtErrorf(fun ast.Expr) bool {
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	obj, ok := rw.pkg.Info.Uses[sel.Sel].(*types.Func)
+	return ok && obj.Pkg() != nil && obj.Pkg().Path() == "fmt" && obj.Name() == "Errorf"
+}
+
+func (rw *rewriter) isError(expr ast.Expr) bool {
+	t := rw.pkg.Info.TypeOf(expr)
+	if t == nil {
+		return false
+	}
+	if basic, ok := t.(*types.Basic); ok && basic.Kind() == types.UntypedNil {
+		return false
+	}
+	return types.Implements(t, errorType.Underlying().(*types.Interface))
+}
+
+func (rw *rewriter) nodeRange(n ast.Node) lsp.Range {
+	start := rw.pkg.Fset.Position(n.Pos())
+	end := rw.pkg.Fset.Position(n.End())
+	return lsp.Range{
+		Start: lsp.Position{Line: start.Line - 1, Character: start.Column - 1},
+		End:   lsp.Position{Line: end.Line - 1, Character: end.Column - 1},
+	}
+}
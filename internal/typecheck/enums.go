@@ -0,0 +1,187 @@
+package typecheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// EnumValue is a constant of an enum-like named type
+type EnumValue struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Package string `json:"package"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Expr    string `json:"expr,omitempty"`
+}
+
+// EnumType groups the constants found for one named type
+type EnumType struct {
+	Type    string      `json:"type"`
+	Pattern string      `json:"pattern"`
+	Values  []EnumValue `json:"values"`
+}
+
+// FindEnums collects the constants of every named type matching typeName
+func FindEnums(pkgs []*Package, typeName string, includeDeps bool) []EnumType {
+	byType := make(map[string]*EnumType)
+	add := func(obj *types.Const, value EnumValue) {
+		named, ok := obj.Type().(*types.Named)
+		if !ok || !MatchesType(named, typeName) {
+			return
+		}
+		key := named.Obj().Pkg().Path() + "." + named.Obj().Name()
+		if byType[key] == nil {
+			byType[key] = &EnumType{Type: key}
+		}
+		byType[key].Values = append(byType[key].Values, value)
+	}
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					vs, ok := spec.(*ast.ValueSpec)
+					if !ok {
+						continue
+					}
+					for i, name := range vs.Names {
+						obj, ok := pkg.Info.Defs[name].(*types.Const)
+						if !ok {
+							continue
+						}
+						pos := pkg.Fset.Position(name.Pos())
+						add(obj, EnumValue{
+							Name:    obj.Name(),
+							Value:   obj.Val().ExactString(),
+							Package: pkg.ImportPath,
+							File:    pos.Filename,
+							Line:    pos.Line,
+							Expr:    valueExpr(vs, i),
+						})
+					}
+				}
+			}
+		}
+	}
+
+	if includeDeps {
+		for path, dep := range Dependencies(pkgs) {
+			scope := dep.Scope()
+			for _, name := range scope.Names() {
+				if obj, ok := scope.Lookup(name).(*types.Const); ok {
+					add(obj, EnumValue{Name: name, Value: obj.Val().ExactString(), Package: path})
+				}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(byType))
+	for key := range byType {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	enums := make([]EnumType, 0, len(keys))
+	for _, key := range keys {
+		enum := byType[key]
+		sortValues(enum.Values)
+		enum.Pattern = detectPattern(enum.Values)
+		enums = append(enums, *enum)
+	}
+	return enums
+}
+
+// MatchesType reports whether named is described by query, which may be a
+// bare type name or be qualified by a package name or import path
+func MatchesType(named *types.Named, query string) bool {
+	obj := named.Obj()
+	dot := strings.LastIndex(query, ".")
+	if dot < 0 {
+		return obj.Name() == query
+	}
+	qualifier, name := query[:dot], query[dot+1:]
+	if obj.Name() != name || obj.Pkg() == nil {
+		return false
+	}
+	path := obj.Pkg().Path()
+	return path == qualifier || obj.Pkg().Name() == qualifier || strings.HasSuffix(path, "/"+qualifier)
+}
+
+// valueExpr returns the source expression of the i-th value in a spec,
+// following Go's rule that a spec without values repeats the previous one
+func valueExpr(vs *ast.ValueSpec, i int) string {
+	if len(vs.Values) == 0 {
+		return ""
+	}
+	if i >= len(vs.Values) {
+		i = len(vs.Values) - 1
+	}
+	return types.ExprString(vs.Values[i])
+}
+
+// sortValues orders constants numerically where possible, then by name
+func sortValues(values []EnumValue) {
+	sort.SliceStable(values, func(i, j int) bool {
+		a, aOK := new(big.Int).SetString(values[i].Value, 0)
+		b, bOK := new(big.Int).SetString(values[j].Value, 0)
+		if aOK && bOK && a.Cmp(b) != 0 {
+			return a.Cmp(b) < 0
+		}
+		if values[i].Value != values[j].Value {
+			return values[i].Value < values[j].Value
+		}
+		return values[i].Name < values[j].Name
+	})
+}
+
+// detectPattern describes how the constant values were generated
+func detectPattern(values []EnumValue) string {
+	for _, v := range values {
+		if strings.Contains(v.Expr, "iota") {
+			switch {
+			case strings.Contains(v.Expr, "<<"):
+				return "iota bit flags (" + v.Expr + ")"
+			default:
+				return "iota (" + v.Expr + ")"
+			}
+		}
+	}
+
+	ints := make([]int64, 0, len(values))
+	for _, v := range values {
+		var n int64
+		if _, err := fmt.Sscan(v.Value, &n); err != nil {
+			return "explicit values"
+		}
+		ints = append(ints, n)
+	}
+	if len(ints) < 2 {
+		return "explicit values"
+	}
+	sequential, flags := true, true
+	for i, n := range ints {
+		if i > 0 && n != ints[i-1]+1 {
+			sequential = false
+		}
+		if n <= 0 || n&(n-1) != 0 {
+			flags = false
+		}
+	}
+	switch {
+	case sequential:
+		return "sequential integers"
+	case flags:
+		return "bit flags"
+	default:
+		return "explicit values"
+	}
+}
@@ -0,0 +1,242 @@
+package typecheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yantrio/mcp-gopls/internal/gocmd"
+)
+
+// Package is a type-checked package from the workspace
+type Package struct {
+	ImportPath string
+	Name       string
+	Dir        string
+	GoVersion  string // the go directive of the package's module, e.g. "1.21"
+	Fset       *token.FileSet
+	Files      []*ast.File
+	Types      *types.Package
+	Info       *types.Info
+	Errors     []string
+
+	importer types.Importer
+}
+
+// listedPackage is the subset of 'go list -json' output the loader needs
+type listedPackage struct {
+	ImportPath      string
+	Name            string
+	Dir             string
+	GoFiles         []string
+	CompiledGoFiles []string
+	Export          string
+	DepOnly         bool
+	ImportMap       map[string]string
+	Module          *struct{ GoVersion string }
+	Error           *struct{ Err string }
+}
+
+// importerFunc adapts a function to the types.Importer interface
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
+
+// Load type-checks the packages matching patterns in dir. Dependencies are
+// read from compiler export data produced by 'go list -export', so only the
+// matched packages are parsed from source.
+func Load(ctx context.Context, dir string, patterns ...string) ([]*Package, error) {
+	return LoadWith(ctx, dir, nil, nil, patterns...)
+}
+
+// LoadWith is Load with extra environment variables, such as GOOS, and
+// build flags, such as -tags, for loading the packages under another build
+// configuration
+func LoadWith(ctx context.Context, dir string, env, buildFlags []string, patterns ...string) ([]*Package, error) {
+	args := append([]string{"list", "-e", "-export", "-deps", "-json"}, buildFlags...)
+	args = append(args, patterns...)
+	result, err := gocmd.Run(ctx, dir, env, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var listed []*listedPackage
+	decoder := json.NewDecoder(strings.NewReader(result.Stdout))
+	for {
+		var pkg listedPackage
+		if err := decoder.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse go list output: %w", err)
+		}
+		listed = append(listed, &pkg)
+	}
+	if len(listed) == 0 && result.ExitCode != 0 {
+		return nil, fmt.Errorf("go list failed: %s", strings.TrimSpace(result.Stderr))
+	}
+
+	exports := make(map[string]string)
+	for _, pkg := range listed {
+		if pkg.Export != "" {
+			exports[pkg.ImportPath] = pkg.Export
+		}
+	}
+
+	fset := token.NewFileSet()
+	shared := importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+		export, ok := exports[path]
+		if !ok {
+			return nil, fmt.Errorf("no export data for %s", path)
+		}
+		return os.Open(export)
+	})
+
+	packages := make([]*Package, 0)
+	for _, lp := range listed {
+		if lp.DepOnly {
+			continue
+		}
+		packages = append(packages, check(fset, shared, lp))
+	}
+	return packages, nil
+}
+
+// check parses and type-checks a single listed package
+func check(fset *token.FileSet, shared types.Importer, lp *listedPackage) *Package {
+	pkg := &Package{
+		ImportPath: lp.ImportPath,
+		Name:       lp.Name,
+		Dir:        lp.Dir,
+		Fset:       fset,
+		importer:   shared,
+		Info: &types.Info{
+			Types:        make(map[ast.Expr]types.TypeAndValue),
+			Defs:         make(map[*ast.Ident]types.Object),
+			Uses:         make(map[*ast.Ident]types.Object),
+			Selections:   make(map[*ast.SelectorExpr]*types.Selection),
+			Implicits:    make(map[ast.Node]types.Object),
+			Scopes:       make(map[ast.Node]*types.Scope),
+			FileVersions: make(map[*ast.File]string),
+		},
+	}
+	if lp.Module != nil {
+		pkg.GoVersion = lp.Module.GoVersion
+	}
+	if lp.Error != nil {
+		pkg.Errors = append(pkg.Errors, lp.Error.Err)
+	}
+
+	// Compiled files include cgo output, so prefer them when available
+	files := lp.CompiledGoFiles
+	if len(files) == 0 {
+		files = lp.GoFiles
+	}
+	for _, name := range files {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(lp.Dir, name)
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			pkg.Errors = append(pkg.Errors, err.Error())
+		}
+		if file != nil {
+			pkg.Files = append(pkg.Files, file)
+		}
+	}
+
+	config := &types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if mapped, ok := lp.ImportMap[path]; ok {
+				path = mapped
+			}
+			return shared.Import(path)
+		}),
+		Error: func(err error) {
+			pkg.Errors = append(pkg.Errors, err.Error())
+		},
+	}
+	// Check against the module's language version, as the compiler does, so
+	// newer language features are reported
+	if pkg.GoVersion != "" {
+		config.GoVersion = "go" + pkg.GoVersion
+	}
+	pkg.Types, _ = config.Check(lp.ImportPath, fset, pkg.Files, pkg.Info)
+	return pkg
+}
+
+// Import returns the complete type information for a package imported by
+// pkg, or pkg's own types when path is its import path
+func (p *Package) Import(path string) (*types.Package, error) {
+	if p.Types != nil && p.Types.Path() == path {
+		return p.Types, nil
+	}
+	return p.importer.Import(path)
+}
+
+// File returns the parsed syntax tree for the file at path, or nil if the
+// file is not part of the package
+func (p *Package) File(path string) *ast.File {
+	for _, file := range p.Files {
+		if p.Fset.File(file.Pos()).Name() == path {
+			return file
+		}
+	}
+	return nil
+}
+
+// Dependencies returns every package transitively imported by pkgs, keyed by
+// import path. Export data only describes the parts of a dependency that its
+// importer uses, so each dependency is imported again from its own export
+// data to get its complete scope.
+func Dependencies(pkgs []*Package) map[string]*types.Package {
+	deps := make(map[string]*types.Package)
+	var visit func(*types.Package, types.Importer)
+	visit = func(p *types.Package, imp types.Importer) {
+		for _, dep := range p.Imports() {
+			if _, ok := deps[dep.Path()]; ok {
+				continue
+			}
+			if full, err := imp.Import(dep.Path()); err == nil {
+				dep = full
+			}
+			deps[dep.Path()] = dep
+			visit(dep, imp)
+		}
+	}
+	for _, pkg := range pkgs {
+		if pkg.Types != nil {
+			visit(pkg.Types, pkg.importer)
+		}
+	}
+	return deps
+}
+
+// FieldOwner returns the name of the package-level struct type declaring
+// field, or "" for fields of local or anonymous struct types
+func FieldOwner(field *types.Var) string {
+	scope := field.Pkg().Scope()
+	for _, name := range scope.Names() {
+		typeName, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		if st, ok := typeName.Type().Underlying().(*types.Struct); ok {
+			for i := 0; i < st.NumFields(); i++ {
+				if st.Field(i) == field {
+					return name
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,253 @@
+// Package unidiff reads unified diffs, as produced by git diff, into the
+// lines each file gained, so tools can map a change onto the code it touches
+package unidiff
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// File is the change to one file
+type File struct {
+	// OldPath and NewPath are the paths from the diff headers with their
+	// a/ and b/ prefixes removed; NewPath is empty for a deleted file and
+	// OldPath for an added one
+	OldPath string
+	NewPath string
+	Hunks   []Hunk
+}
+
+// Hunk is one @@ section of a file's diff
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	// Added are the line numbers in the new file of the lines the hunk adds
+	Added []int
+	// Removed counts the lines the hunk deletes
+	Removed int
+	// RemovedAt are the line numbers in the new file of the lines that
+	// follow each run of deleted lines
+	RemovedAt []int
+}
+
+// Changed returns the new-file lines a hunk touches: the lines it adds and
+// the lines that follow the lines it deletes, in order
+func (h Hunk) Changed() []int {
+	lines := append([]int(nil), h.Added...)
+	for _, line := range h.RemovedAt {
+		if !slices.Contains(lines, line) {
+			lines = append(lines, line)
+		}
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+// Lines returns the set of new-file lines the file's hunks touch
+func (f File) Lines() map[int]bool {
+	lines := make(map[int]bool)
+	for _, hunk := range f.Hunks {
+		for _, line := range hunk.Changed() {
+			lines[line] = true
+		}
+	}
+	return lines
+}
+
+// Parse reads a unified diff. Git's extended headers are accepted; binary
+// file changes have no hunks.
+func Parse(diff string) ([]File, error) {
+	var files []File
+	var file *File
+	var hunk *Hunk
+	// The lines of the current hunk still to come, and the new-file number
+	// of the next one
+	oldLeft, newLeft, newLine := 0, 0, 0
+
+	flush := func() {
+		if file == nil {
+			return
+		}
+		if hunk != nil {
+			file.Hunks = append(file.Hunks, *hunk)
+			hunk = nil
+		}
+		files = append(files, *file)
+		file = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				hunk.Added = append(hunk.Added, newLine)
+				newLine++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				if n := len(hunk.RemovedAt); n == 0 || hunk.RemovedAt[n-1] != newLine {
+					hunk.RemovedAt = append(hunk.RemovedAt, newLine)
+				}
+				hunk.Removed++
+				oldLeft--
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file"
+			default:
+				newLine++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			file = &File{}
+			file.OldPath, file.NewPath, _ = gitPaths(strings.TrimPrefix(line, "diff --git "))
+		case strings.HasPrefix(line, "--- "):
+			if file == nil || hunk != nil {
+				flush()
+				file = &File{}
+			}
+			file.OldPath = headerPath(strings.TrimPrefix(line, "--- "), "a/")
+		case strings.HasPrefix(line, "+++ ") && file != nil:
+			file.NewPath = headerPath(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "@@ "):
+			if file == nil {
+				return nil, fmt.Errorf("hunk %q before any file header", line)
+			}
+			if hunk != nil {
+				file.Hunks = append(file.Hunks, *hunk)
+			}
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			hunk = &h
+			oldLeft, newLeft, newLine = h.OldLines, h.NewLines, h.NewStart
+			if h.NewLines == 0 {
+				// A hunk without new lines starts after the line it names
+				newLine++
+			}
+		case strings.HasPrefix(line, "deleted file mode") && file != nil:
+			file.NewPath = ""
+		case strings.HasPrefix(line, "new file mode") && file != nil:
+			file.OldPath = ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read diff: %w", err)
+	}
+	flush()
+	return files, nil
+}
+
+// parseHunkHeader reads "@@ -a,b +c,d @@"
+func parseHunkHeader(line string) (Hunk, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return Hunk{}, fmt.Errorf("invalid hunk header %q", line)
+	}
+	oldStart, oldLines, err1 := parseRange(fields[1][1:])
+	newStart, newLines, err2 := parseRange(fields[2][1:])
+	if err1 != nil || err2 != nil {
+		return Hunk{}, fmt.Errorf("invalid hunk header %q", line)
+	}
+	return Hunk{OldStart: oldStart, OldLines: oldLines, NewStart: newStart, NewLines: newLines}, nil
+}
+
+// parseRange reads "start,count" or "start", where the count defaults to 1
+func parseRange(s string) (start, count int, err error) {
+	startText, countText, hasCount := strings.Cut(s, ",")
+	if start, err = strconv.Atoi(startText); err != nil {
+		return 0, 0, err
+	}
+	count = 1
+	if hasCount {
+		if count, err = strconv.Atoi(countText); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, count, nil
+}
+
+// headerPath strips the prefix and any timestamp from a ---/+++ path, and
+// returns "" for /dev/null
+func headerPath(path, prefix string) string {
+	if i := strings.IndexByte(path, '\t'); i >= 0 {
+		path = path[:i]
+	}
+	if path == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(path, prefix)
+}
+
+// gitPaths splits the "a/x b/y" of a diff --git line
+func gitPaths(s string) (old, new string, ok bool) {
+	i := strings.Index(s, " b/")
+	if !strings.HasPrefix(s, "a/") || i < 0 {
+		return "", "", false
+	}
+	return s[2:i], s[i+3:], true
+}
+
+// Git returns the diff of a revision range in the repository containing
+// dir, as git diff understands it: "main...HEAD" for the changes of a
+// branch, "HEAD~1" for the working tree against a commit, or "" for
+// uncommitted changes. Paths in the diff are relative to the repository
+// root, which is returned too.
+func Git(ctx context.Context, dir, revisions string) (diff, root string, err error) {
+	if root, err = GitRoot(ctx, dir); err != nil {
+		return "", "", err
+	}
+
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--unified=0"}
+	if revisions != "" {
+		if strings.HasPrefix(revisions, "-") {
+			return "", "", fmt.Errorf("invalid revision range %q", revisions)
+		}
+		args = append(args, revisions)
+	} else {
+		args = append(args, "HEAD")
+	}
+	diff, err = git(ctx, dir, append(args, "--")...)
+	if err != nil {
+		return "", "", err
+	}
+	return diff, root, nil
+}
+
+// GitRoot returns the root of the git repository containing dir
+func GitRoot(ctx context.Context, dir string) (string, error) {
+	out, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return filepath.FromSlash(strings.TrimSpace(out)), nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return "", fmt.Errorf("git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+		}
+		return "", fmt.Errorf("failed to run git: %w", err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// markdownLink matches inline links such as [text](url)
+	markdownLink = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]*)\)`)
+	// docLinkLine matches the trailing "on pkg.go.dev" link gopls appends to hover contents
+	docLinkLine = regexp.MustCompile(`^\[[^\]]*on pkg\.go\.dev\]\([^)]*\)$`)
+	// markdownEmphasis matches bold and italic asterisks around text; underscores
+	// are left alone since they are common in identifiers
+	markdownEmphasis = regexp.MustCompile(`\*{1,2}([^*\s][^*]*?)\*{1,2}`)
+	// markdownHeading matches heading markers at the start of a line
+	markdownHeading = regexp.MustCompile(`^#{1,6}\s+`)
+)
+
+// StripLinks removes the pkg.go.dev footer links gopls adds to hover contents
+// and replaces inline markdown links with their link text
+func StripLinks(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if docLinkLine.MatchString(strings.TrimSpace(line)) {
+			continue
+		}
+		kept = append(kept, markdownLink.ReplaceAllString(line, "$1"))
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// MarkdownToPlainText converts gopls markdown into plain text by removing
+// code fences, links, emphasis, headings and markdown escapes
+func MarkdownToPlainText(markdown string) string {
+	lines := strings.Split(StripLinks(markdown), "\n")
+	result := make([]string, 0, len(lines))
+	inCode := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			// Code is already plain text
+			result = append(result, line)
+			continue
+		}
+		line = markdownHeading.ReplaceAllString(line, "")
+		line = strings.ReplaceAll(line, "`", "")
+		line = markdownEmphasis.ReplaceAllString(line, "$1")
+		line = unescapeMarkdown(line)
+		result = append(result, line)
+	}
+	return strings.TrimSpace(strings.Join(result, "\n"))
+}
+
+// unescapeMarkdown removes backslash escapes from markdown punctuation
+func unescapeMarkdown(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_{}[]()#+-.!<>|", s[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SplitWords splits a Go identifier into words, keeping initialisms together
+// (e.g. "HTTPServerID" -> ["HTTP", "Server", "ID"], "user_name" -> ["user", "name"])
+func SplitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i == len(runes) {
+			words = appendWord(words, runes[start:i])
+			break
+		}
+		prev, cur := runes[i-1], runes[i]
+		switch {
+		case cur == '_' || cur == '-':
+			words = appendWord(words, runes[start:i])
+			start = i + 1
+		case unicode.IsLower(prev) && unicode.IsUpper(cur),
+			unicode.IsDigit(prev) != unicode.IsDigit(cur) && unicode.IsUpper(cur):
+			words = appendWord(words, runes[start:i])
+			start = i
+		case unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// End of an initialism followed by a capitalised word
+			words = appendWord(words, runes[start:i])
+			start = i
+		}
+	}
+	return words
+}
+
+func appendWord(words []string, word []rune) []string {
+	if len(word) == 0 {
+		return words
+	}
+	return append(words, string(word))
+}
+
+// ToCamel converts an identifier to lowerCamelCase
+func ToCamel(name string) string {
+	words := SplitWords(name)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+		} else {
+			words[i] = capitalize(word)
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// ToPascal converts an identifier to UpperCamelCase
+func ToPascal(name string) string {
+	words := SplitWords(name)
+	for i, word := range words {
+		words[i] = capitalize(word)
+	}
+	return strings.Join(words, "")
+}
+
+// ToSnake converts an identifier to snake_case
+func ToSnake(name string) string {
+	return strings.ToLower(strings.Join(SplitWords(name), "_"))
+}
+
+// ToKebab converts an identifier to kebab-case
+func ToKebab(name string) string {
+	return strings.ToLower(strings.Join(SplitWords(name), "-"))
+}
+
+// LowerFirst lowercases the leading word of an identifier for use as a local
+// variable or parameter name (e.g. "HTTPClient" -> "httpClient")
+func LowerFirst(name string) string {
+	words := SplitWords(name)
+	if len(words) == 0 {
+		return name
+	}
+	words[0] = strings.ToLower(words[0])
+	return strings.Join(words, "")
+}
+
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	lower := strings.ToLower(word)
+	return strings.ToUpper(lower[:1]) + lower[1:]
+}
@@ -2,9 +2,12 @@ package utils
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
+	"os"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/yantrio/mcp-gopls/internal/lsp"
 )
@@ -22,6 +25,23 @@ func ConvertToUserPosition(pos lsp.Position) (line, column int) {
 	return pos.Line + 1, pos.Character + 1
 }
 
+// UserRange is an LSP range in 1-indexed lines and columns
+type UserRange struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+// ConvertToUserRange converts an LSP 0-indexed range to 1-indexed lines and
+// columns
+func ConvertToUserRange(r lsp.Range) UserRange {
+	var ur UserRange
+	ur.StartLine, ur.StartColumn = ConvertToUserPosition(r.Start)
+	ur.EndLine, ur.EndColumn = ConvertToUserPosition(r.End)
+	return ur
+}
+
 // GetLineContent reads the content of a specific line from a reader
 func GetLineContent(reader io.Reader, lineNumber int) (string, error) {
 	scanner := bufio.NewScanner(reader)
@@ -41,6 +61,45 @@ func GetLineContent(reader io.Reader, lineNumber int) (string, error) {
 	return "", fmt.Errorf("line %d not found", lineNumber)
 }
 
+// ReadLine reads a single line of a file, stopping once it is found rather
+// than loading the whole file. A line that is not UTF-8 text is an error, so
+// previews of binary files are left out rather than garbled.
+func ReadLine(path string, lineNumber int) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	line, err := GetLineContent(f, lineNumber)
+	if err != nil {
+		return "", err
+	}
+	if offset, _ := FindNonText([]byte(line)); offset >= 0 {
+		return "", fmt.Errorf("line %d of %s is not UTF-8 text", lineNumber, path)
+	}
+	return line, nil
+}
+
+// FindNonText returns the offset of the first NUL byte or invalid UTF-8
+// sequence in content, and whether it is a NUL byte, which marks a binary
+// file. The offset is -1 for valid text.
+func FindNonText(content []byte) (offset int, binary bool) {
+	if i := bytes.IndexByte(content, 0); i >= 0 {
+		return i, true
+	}
+	if utf8.Valid(content) {
+		return -1, false
+	}
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRune(content[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i, false
+		}
+		i += size
+	}
+	return -1, false
+}
+
 // GetLinePreview gets a preview of a line with surrounding context
 func GetLinePreview(reader io.Reader, lineNumber int, contextLines int) (string, error) {
 	scanner := bufio.NewScanner(reader)
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// pathAlias maps a directory with symbolic links resolved back to the path
+// the user knows it by
+type pathAlias struct {
+	visible   string
+	canonical string
+}
+
+// PathAliases maps paths with symbolic links resolved, as gopls reports
+// them, back to the paths the user gave. Each server keeps its own, so
+// servers embedded in one program do not see each other's workspaces. The
+// zero value is ready to use, and a nil PathAliases maps nothing.
+type PathAliases struct {
+	mu   sync.RWMutex
+	list []pathAlias
+}
+
+// CanonicalPath resolves symbolic links in path the way gopls and the go
+// command see the file system, so /tmp/app on macOS becomes
+// /private/tmp/app. For a file only its directories are resolved: a
+// symlinked file inside the workspace is part of the workspace under its own
+// name. Parts of the path that do not exist yet are kept as given. On
+// Windows the drive letter is upper-cased, as in the paths gopls reports.
+func CanonicalPath(path string) string {
+	path = canonicalPath(filepath.Clean(path))
+	if runtime.GOOS == "windows" && hasDriveLetter(path) {
+		path = upperDrive(path)
+	}
+	return path
+}
+
+func canonicalPath(path string) string {
+	if info, err := os.Lstat(path); err == nil && !info.IsDir() && info.Mode()&os.ModeSymlink != 0 {
+		if target, err := os.Stat(path); err != nil || !target.IsDir() {
+			return filepath.Join(canonicalPath(filepath.Dir(path)), filepath.Base(path))
+		}
+	}
+
+	// Resolve the longest prefix that exists
+	rest := ""
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		if filepath.Dir(dir) == dir {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
+// Add makes Visible, and so URIToPath, report paths under the canonical
+// form of dir under dir, so results from gopls use the path the user gave.
+// It does nothing if dir contains no symbolic links.
+func (a *PathAliases) Add(dir string) {
+	visible := filepath.Clean(dir)
+	canonical := CanonicalPath(visible)
+	if canonical == visible {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, alias := range a.list {
+		if alias.canonical == canonical {
+			return
+		}
+	}
+	a.list = append(a.list, pathAlias{visible: visible, canonical: canonical})
+}
+
+// Visible maps a path with symbolic links resolved back to the path the
+// user knows it by, for directories registered with Add
+func (a *PathAliases) Visible(path string) string {
+	if a == nil {
+		return path
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, alias := range a.list {
+		if path == alias.canonical {
+			return alias.visible
+		}
+		if rest, ok := strings.CutPrefix(path, alias.canonical+string(filepath.Separator)); ok {
+			return filepath.Join(alias.visible, rest)
+		}
+	}
+	return path
+}
+
+// URIToPath converts a file URI to a file path like the URIToPath function,
+// returning paths under a registered directory under its alias
+func (a *PathAliases) URIToPath(uri string) (string, error) {
+	path, err := URIToPath(uri)
+	if err != nil {
+		return "", err
+	}
+	return a.Visible(path), nil
+}
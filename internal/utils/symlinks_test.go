@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPathAliasesAreIndependent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links needs privileges on Windows")
+	}
+	target := CanonicalPath(t.TempDir())
+	links := t.TempDir()
+	first := filepath.Join(links, "first")
+	second := filepath.Join(links, "second")
+	for _, link := range []string{first, second} {
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var a, b PathAliases
+	a.Add(first)
+	b.Add(second)
+	file := filepath.Join(target, "pkg", "main.go")
+	if got, want := a.Visible(file), filepath.Join(first, "pkg", "main.go"); got != want {
+		t.Errorf("a.Visible(%q) = %q, want %q", file, got, want)
+	}
+	if got, want := b.Visible(file), filepath.Join(second, "pkg", "main.go"); got != want {
+		t.Errorf("b.Visible(%q) = %q, want %q", file, got, want)
+	}
+
+	var none *PathAliases
+	if got := none.Visible(file); got != file {
+		t.Errorf("nil Visible(%q) = %q, want it unchanged", file, got)
+	}
+	uri, err := PathToURI(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := a.URIToPath(uri); err != nil || got != filepath.Join(first, "pkg", "main.go") {
+		t.Errorf("a.URIToPath(%q) = %q, %v", uri, got, err)
+	}
+}
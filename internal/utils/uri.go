@@ -8,33 +8,73 @@ import (
 	"strings"
 )
 
-// PathToURI converts a file path to a file URI
+// PathToURI converts a file path to a file URI. Symbolic links in the
+// directories of the path are resolved, see CanonicalPath.
 func PathToURI(path string) (string, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return "", fmt.Errorf("failed to get absolute path: %w", err)
 	}
+	return pathToURI(CanonicalPath(absPath), runtime.GOOS == "windows"), nil
+}
 
-	// Clean and convert to forward slashes
-	absPath = filepath.ToSlash(absPath)
+// URIToPath converts a file URI to a file path. Paths come back as gopls
+// sees them, with symbolic links resolved; PathAliases.URIToPath maps them
+// back to the paths the user gave.
+func URIToPath(uri string) (string, error) {
+	return uriToPath(uri, runtime.GOOS == "windows")
+}
+
+// IsFileURI checks if a string is a valid file URI
+func IsFileURI(uri string) bool {
+	return strings.HasPrefix(uri, "file://")
+}
 
-	// On Windows, we need to handle drive letters specially
-	if runtime.GOOS == "windows" && len(absPath) > 1 && absPath[1] == ':' {
-		// Convert C:/path to /C:/path for proper URI encoding
-		absPath = "/" + absPath
+// NormalizeURI ensures a URI is properly formatted. On Windows the different
+// spellings of a file URI, such as file:///c%3A/src and file:///C:/src,
+// normalize to the same URI.
+func NormalizeURI(uri string) (string, error) {
+	if !IsFileURI(uri) {
+		// Assume it's a path and convert it
+		return PathToURI(uri)
 	}
 
-	// Encode the path
-	u := &url.URL{
-		Scheme: "file",
-		Path:   absPath,
+	windows := runtime.GOOS == "windows"
+	path, err := uriToPath(uri, windows)
+	if err != nil {
+		return "", err
 	}
+	return pathToURI(path, windows), nil
+}
 
-	return u.String(), nil
+// pathToURI converts an absolute path to a file URI. On Windows, drive paths
+// such as c:\src become file:///C:/src, the form gopls produces itself, and
+// UNC paths such as \\server\share\src become file://server/share/src.
+func pathToURI(path string, windows bool) string {
+	u := &url.URL{Scheme: "file"}
+	if !windows {
+		u.Path = path
+		return u.String()
+	}
+
+	path = strings.ReplaceAll(path, `\`, "/")
+	switch {
+	case strings.HasPrefix(path, "//"):
+		host, rest, _ := strings.Cut(path[2:], "/")
+		u.Host, u.Path = host, "/"+rest
+	case hasDriveLetter(path):
+		u.Path = "/" + upperDrive(path)
+	default:
+		u.Path = path
+	}
+	return u.String()
 }
 
-// URIToPath converts a file URI to a file path
-func URIToPath(uri string) (string, error) {
+// uriToPath converts a file URI to a path. On Windows it accepts the drive
+// letter in either case and the colon after it escaped, as in the
+// file:///c%3A/src URIs some editors send, and file://server/share URIs for
+// UNC paths.
+func uriToPath(uri string, windows bool) (string, error) {
 	u, err := url.Parse(uri)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse URI: %w", err)
@@ -44,42 +84,34 @@ func URIToPath(uri string) (string, error) {
 		return "", fmt.Errorf("expected file URI, got scheme: %s", u.Scheme)
 	}
 
+	// url.Parse has already decoded percent-encoded characters
 	path := u.Path
-
-	// Decode percent-encoded characters
-	path, err = url.PathUnescape(path)
-	if err != nil {
-		return "", fmt.Errorf("failed to unescape path: %w", err)
+	if !windows {
+		return path, nil
 	}
 
-	// On Windows, remove the leading slash before the drive letter
-	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
-		path = path[1:]
+	switch {
+	case u.Host != "" && u.Host != "localhost":
+		path = "//" + u.Host + path
+	case len(path) > 0 && path[0] == '/' && hasDriveLetter(path[1:]):
+		// Remove the leading slash before the drive letter
+		path = upperDrive(path[1:])
 	}
-
-	// Convert to native path separators
-	path = filepath.FromSlash(path)
-
-	return path, nil
+	return strings.ReplaceAll(path, "/", `\`), nil
 }
 
-// IsFileURI checks if a string is a valid file URI
-func IsFileURI(uri string) bool {
-	return strings.HasPrefix(uri, "file://")
-}
-
-// NormalizeURI ensures a URI is properly formatted
-func NormalizeURI(uri string) (string, error) {
-	if !IsFileURI(uri) {
-		// Assume it's a path and convert it
-		return PathToURI(uri)
-	}
-
-	// Parse and re-encode to normalize
-	u, err := url.Parse(uri)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse URI: %w", err)
+// hasDriveLetter reports whether path starts with a Windows drive letter
+// such as C:
+func hasDriveLetter(path string) bool {
+	if len(path) < 2 || path[1] != ':' {
+		return false
 	}
+	c := path[0]
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
 
-	return u.String(), nil
+// upperDrive upper-cases the drive letter path starts with, since Windows
+// treats c: and C: as the same drive but string comparisons do not
+func upperDrive(path string) string {
+	return strings.ToUpper(path[:1]) + path[1:]
 }
@@ -0,0 +1,121 @@
+package utils
+
+import "testing"
+
+func TestPathToURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		windows bool
+		want    string
+	}{
+		{"unix", "/home/user/src/main.go", false, "file:///home/user/src/main.go"},
+		{"unix space", "/home/user/my src/main.go", false, "file:///home/user/my%20src/main.go"},
+		{"unix percent", "/home/user/100%/main.go", false, "file:///home/user/100%25/main.go"},
+		{"drive", `C:\src\main.go`, true, "file:///C:/src/main.go"},
+		{"lower-case drive", `c:\src\main.go`, true, "file:///C:/src/main.go"},
+		{"drive space", `C:\My Projects\main.go`, true, "file:///C:/My%20Projects/main.go"},
+		{"drive percent", `C:\src\50%\main.go`, true, "file:///C:/src/50%25/main.go"},
+		{"unc", `\\server\share\src\main.go`, true, "file://server/share/src/main.go"},
+		{"unc space", `\\server\my share\main.go`, true, "file://server/my%20share/main.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pathToURI(tt.path, tt.windows); got != tt.want {
+				t.Errorf("pathToURI(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURIToPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		windows bool
+		want    string
+	}{
+		{"unix", "file:///home/user/src/main.go", false, "/home/user/src/main.go"},
+		{"unix space", "file:///home/user/my%20src/main.go", false, "/home/user/my src/main.go"},
+		{"unix percent", "file:///home/user/100%25/main.go", false, "/home/user/100%/main.go"},
+		{"drive", "file:///C:/src/main.go", true, `C:\src\main.go`},
+		{"lower-case drive", "file:///c:/src/main.go", true, `C:\src\main.go`},
+		{"escaped colon", "file:///c%3A/src/main.go", true, `C:\src\main.go`},
+		{"escaped colon upper-case", "file:///C%3A/src/main.go", true, `C:\src\main.go`},
+		{"drive space", "file:///C:/My%20Projects/main.go", true, `C:\My Projects\main.go`},
+		{"drive percent", "file:///C:/src/50%25/main.go", true, `C:\src\50%\main.go`},
+		{"unc", "file://server/share/src/main.go", true, `\\server\share\src\main.go`},
+		{"unc space", "file://server/my%20share/main.go", true, `\\server\my share\main.go`},
+		{"localhost", "file://localhost/C:/src/main.go", true, `C:\src\main.go`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := uriToPath(tt.uri, tt.windows)
+			if err != nil {
+				t.Fatalf("uriToPath(%q): %v", tt.uri, err)
+			}
+			if got != tt.want {
+				t.Errorf("uriToPath(%q) = %q, want %q", tt.uri, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURIToPathRejectsOtherSchemes(t *testing.T) {
+	for _, windows := range []bool{false, true} {
+		if _, err := uriToPath("https://example.com/main.go", windows); err == nil {
+			t.Errorf("uriToPath accepted an https URI (windows=%v)", windows)
+		}
+	}
+}
+
+func TestURIRoundTrip(t *testing.T) {
+	paths := []struct {
+		path    string
+		windows bool
+	}{
+		{"/home/user/src/main.go", false},
+		{"/home/user/my src/100%/main.go", false},
+		{"/tmp/a#b/c?d.go", false},
+		{`C:\src\main.go`, true},
+		{`C:\My Projects\50%\main.go`, true},
+		{`\\server\share\src\main.go`, true},
+		{`\\server\my share\100%\main.go`, true},
+	}
+	for _, tt := range paths {
+		uri := pathToURI(tt.path, tt.windows)
+		back, err := uriToPath(uri, tt.windows)
+		if err != nil {
+			t.Errorf("uriToPath(%q): %v", uri, err)
+			continue
+		}
+		if back != tt.path {
+			t.Errorf("path %q became %q and then %q", tt.path, uri, back)
+		}
+	}
+
+	// URIs in the form pathToURI produces survive the other way round, and
+	// the other spellings of a drive normalize to it
+	uris := []struct {
+		uri     string
+		windows bool
+		want    string
+	}{
+		{"file:///home/user/my%20src/main.go", false, "file:///home/user/my%20src/main.go"},
+		{"file:///C:/src/main.go", true, "file:///C:/src/main.go"},
+		{"file:///c:/src/main.go", true, "file:///C:/src/main.go"},
+		{"file:///c%3A/src/main.go", true, "file:///C:/src/main.go"},
+		{"file:///C:/My%20Projects/50%25/main.go", true, "file:///C:/My%20Projects/50%25/main.go"},
+		{"file://server/share/src/main.go", true, "file://server/share/src/main.go"},
+	}
+	for _, tt := range uris {
+		path, err := uriToPath(tt.uri, tt.windows)
+		if err != nil {
+			t.Errorf("uriToPath(%q): %v", tt.uri, err)
+			continue
+		}
+		if got := pathToURI(path, tt.windows); got != tt.want {
+			t.Errorf("URI %q became %q and then %q, want %q", tt.uri, path, got, tt.want)
+		}
+	}
+}
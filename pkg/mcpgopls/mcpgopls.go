@@ -0,0 +1,413 @@
+// Package mcpgopls embeds the mcp-gopls MCP server in other Go programs.
+//
+// A server is built with New and functional options, then served over one
+// of the transports in this package. Additional tools can be added with
+// WithTool, WithPlugin or a plugin package that calls Register from init.
+//
+//	srv, err := mcpgopls.New(
+//		mcpgopls.WithWorkspace("/path/to/module"),
+//		mcpgopls.WithoutTools("RenameSymbol"),
+//		mcpgopls.WithTransport(mcpgopls.SSE(":8080")),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer srv.Close()
+//	return srv.Serve(ctx)
+package mcpgopls
+
+import (
+	"context"
+	"io"
+	"log"
+	"time"
+
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/server"
+	"github.com/yantrio/mcp-gopls/internal/telemetry"
+	"github.com/yantrio/mcp-gopls/internal/tools"
+)
+
+// Option configures a Server
+type Option func(*config)
+
+type config struct {
+	server         server.Config
+	allow          map[string]bool
+	deny           map[string]bool
+	transport      Transport
+	plugins        []Plugin
+	tools          []Tool
+	skipRegistered bool
+	idleExit       time.Duration
+}
+
+// WithWorkspace sets the workspace root gopls is started in. It defaults to
+// the current directory.
+func WithWorkspace(dir string) Option {
+	return func(c *config) { c.server.WorkspaceRoot = dir }
+}
+
+// WithGoplsPath sets the gopls binary to run. It defaults to gopls in PATH.
+func WithGoplsPath(path string) Option {
+	return func(c *config) { c.server.GoplsPath = path }
+}
+
+// WithGoplsRemote shares one gopls daemon between this server and other
+// clients instead of starting a private gopls. "auto" runs gopls
+// -remote=auto, which starts the daemon if none is running; an address such
+// as localhost:37374 or unix;/tmp/gopls.sock connects to a daemon started
+// with gopls -listen.
+func WithGoplsRemote(remote string) Option {
+	return func(c *config) { c.server.GoplsRemote = remote }
+}
+
+// WithMock answers LSP requests with a fake gopls built into the server
+// instead of starting gopls. It resolves identifiers from the syntax of the
+// workspace alone, so results are approximate, but neither gopls nor Go
+// needs to be installed: use it for end-to-end tests and demos.
+func WithMock(enabled bool) Option {
+	return func(c *config) { c.server.Mock = enabled }
+}
+
+// WithLSPRecording writes every LSP message exchanged with gopls, with its
+// time and direction, to a JSON lines file at path, which is truncated
+// first. Attach the file to a bug report so the session can be replayed
+// with WithLSPReplay.
+func WithLSPRecording(path string) Option {
+	return func(c *config) { c.server.RecordLSP = path }
+}
+
+// WithLSPReplay answers LSP requests from a file written with
+// WithLSPRecording instead of starting gopls: each request gets the
+// response gopls gave to the same request, followed by the diagnostics and
+// other notifications gopls sent after it. A recording made in another
+// workspace root is moved to this one.
+func WithLSPReplay(path string) Option {
+	return func(c *config) { c.server.ReplayLSP = path }
+}
+
+// WithAutoAddWorkspaceFolders adds the module of a file outside the
+// workspace as an extra gopls workspace folder instead of rejecting the call
+func WithAutoAddWorkspaceFolders(enabled bool) Option {
+	return func(c *config) { c.server.AutoAddWorkspaceFolders = enabled }
+}
+
+// WithIncludeVendor keeps vendor directories in symbol search, references
+// and diagnostics. They are left out by default.
+func WithIncludeVendor(enabled bool) Option {
+	return func(c *config) { c.server.IncludeVendor = enabled }
+}
+
+// WithDirectoryFilters sets gopls directoryFilters, each + or - followed by
+// a directory relative to the workspace root. Later filters override
+// earlier ones, so "-" followed by "+services/api" analyzes only
+// services/api. The filters can be changed at runtime with the
+// SetDirectoryFilters tool.
+func WithDirectoryFilters(filters ...string) Option {
+	return func(c *config) { c.server.DirectoryFilters = append(c.server.DirectoryFilters, filters...) }
+}
+
+// WithMemoryMode sets gopls's memory mode: "default", or "low" to turn off
+// gopls features that hold large indexes and collect garbage more often
+func WithMemoryMode(mode string) Option {
+	return func(c *config) { c.server.MemoryMode = mode }
+}
+
+// WithMaxGoplsMemory restarts gopls when its resident memory exceeds bytes,
+// replaying its workspace folders, open files and diagnostics checkpoints.
+// Memory is checked every interval, or every 30 seconds when interval is
+// zero.
+func WithMaxGoplsMemory(bytes uint64, interval time.Duration) Option {
+	return func(c *config) {
+		c.server.MaxGoplsMemory = bytes
+		c.server.MemoryCheckInterval = interval
+	}
+}
+
+// WithMaxFileSize sets the largest file, in bytes, that tools open in gopls.
+// Larger files, usually generated code, are refused with an error instead of
+// being sent to gopls. The default is 4MiB; a negative size removes the
+// limit.
+func WithMaxFileSize(bytes int64) Option {
+	return func(c *config) { c.server.MaxFileSize = bytes }
+}
+
+// WithFileHeader stamps the Go files tools create, such as tests, mocks,
+// new packages and split files, with a license or copyright comment. The
+// template is a text/template that can refer to {{.Year}}, {{.File}},
+// {{.Package}} and {{.ImportPath}}; lines that are not comments are
+// commented.
+func WithFileHeader(template string) Option {
+	return func(c *config) { c.server.FileHeader = template }
+}
+
+// WithGeneratedMarker stamps "// Code generated by mcp-gopls." on the Go
+// files tools create, after any WithFileHeader comment. Files that a
+// generator already marks as generated are left alone.
+func WithGeneratedMarker(enabled bool) Option {
+	return func(c *config) { c.server.GeneratedMarker = enabled }
+}
+
+// Tool naming profiles for WithToolNaming
+const (
+	// NamingPascal keeps the built-in names, e.g. GoToDefinition
+	NamingPascal = tools.NamingPascal
+	// NamingSnake exposes tools as go_to_definition
+	NamingSnake = tools.NamingSnake
+	// NamingCamel exposes tools as goToDefinition
+	NamingCamel = tools.NamingCamel
+	// NamingKebab exposes tools as go-to-definition
+	NamingKebab = tools.NamingKebab
+)
+
+// WithToolNaming exposes the tools under names in another case, with an
+// optional prefix. WithTools, WithoutTools and WithToolFilter still take the
+// built-in names.
+func WithToolNaming(profile, prefix string) Option {
+	return func(c *config) {
+		c.server.ToolNaming.Profile = profile
+		c.server.ToolNaming.Prefix = prefix
+	}
+}
+
+// WithMaxToolNameLength shortens tool names longer than n for clients that
+// limit name length. Shortened names end in a hash that keeps them unique.
+func WithMaxToolNameLength(n int) Option {
+	return func(c *config) { c.server.ToolNaming.MaxLength = n }
+}
+
+// WithToolAliases exposes tools under names of their own, keyed by built-in
+// name. Aliases take precedence over WithToolNaming and
+// WithMaxToolNameLength.
+func WithToolAliases(aliases map[string]string) Option {
+	return func(c *config) {
+		if c.server.ToolNaming.Aliases == nil {
+			c.server.ToolNaming.Aliases = make(map[string]string)
+		}
+		for tool, alias := range aliases {
+			c.server.ToolNaming.Aliases[tool] = alias
+		}
+	}
+}
+
+// ParseMemorySize parses a size such as 2GiB, 1536MB or a number of bytes,
+// for WithMaxGoplsMemory and WithMaxFileSize. Units are binary.
+func ParseMemorySize(s string) (uint64, error) {
+	return gopls.ParseMemorySize(s)
+}
+
+// WithTools registers only the named tools. It can be combined with
+// WithoutTools and WithToolFilter; a tool must pass all of them.
+func WithTools(names ...string) Option {
+	return func(c *config) {
+		if c.allow == nil {
+			c.allow = make(map[string]bool)
+		}
+		for _, name := range names {
+			c.allow[name] = true
+		}
+	}
+}
+
+// WithoutTools leaves the named tools out
+func WithoutTools(names ...string) Option {
+	return func(c *config) {
+		if c.deny == nil {
+			c.deny = make(map[string]bool)
+		}
+		for _, name := range names {
+			c.deny[name] = true
+		}
+	}
+}
+
+// WithToolFilter registers only the tools for which keep returns true
+func WithToolFilter(keep func(name string) bool) Option {
+	return func(c *config) {
+		previous := c.server.ToolFilter
+		c.server.ToolFilter = func(name string) bool {
+			return (previous == nil || previous(name)) && keep(name)
+		}
+	}
+}
+
+// WithLogger sets the logger for tool call and transport logs. It defaults
+// to a logger writing to stderr.
+func WithLogger(logger *log.Logger) Option {
+	return func(c *config) { c.server.Logger = logger }
+}
+
+// WithToolCallLogging logs every tool call with its duration and outcome
+func WithToolCallLogging(enabled bool) Option {
+	return func(c *config) { c.server.LogToolCalls = enabled }
+}
+
+// WithTelemetry records the name, duration and error category of every
+// tool call to file as JSON lines, for finding slow and failing tools.
+// Arguments and results are never recorded and nothing leaves the machine.
+// Empty, the default, disables it; see DefaultTelemetryFile.
+func WithTelemetry(file string) Option {
+	return func(c *config) { c.server.TelemetryFile = file }
+}
+
+// DefaultTelemetryFile returns the telemetry file in the user's cache
+// directory
+func DefaultTelemetryFile() (string, error) {
+	return telemetry.DefaultFile()
+}
+
+// ExportTelemetry writes a JSON summary of the calls recorded in file to w:
+// the calls, error categories and latency percentiles of each tool
+func ExportTelemetry(file string, w io.Writer) error {
+	return telemetry.Export(file, w)
+}
+
+// WithToolTimeout bounds each tool call. Zero, the default, means no limit.
+func WithToolTimeout(d time.Duration) Option {
+	return func(c *config) { c.server.ToolTimeout = d }
+}
+
+// WithDryRunByDefault makes tools that support dryRun preview their changes
+// unless a call sets dryRun to false
+func WithDryRunByDefault(enabled bool) Option {
+	return func(c *config) { c.server.DryRunByDefault = enabled }
+}
+
+// WithRelativePaths reports file paths in tool results relative to the
+// workspace root, which is stated once per result, unless a call sets
+// relativePaths to false
+func WithRelativePaths(enabled bool) Option {
+	return func(c *config) { c.server.RelativePaths = enabled }
+}
+
+// WithStructuredContent returns the unified diffs and fenced code blocks in
+// tool results as embedded resources, a diff named by the URI of the file
+// it changes and typed text/x-diff and a code block typed by its language,
+// for clients that render or apply MCP resources natively. The text around
+// them is kept as text content.
+func WithStructuredContent(enabled bool) Option {
+	return func(c *config) { c.server.StructuredContent = enabled }
+}
+
+// WithInstructions replaces the guidance the server gives clients when they
+// connect. The list of enabled tools is appended to it.
+func WithInstructions(text string) Option {
+	return func(c *config) { c.server.Instructions = text }
+}
+
+// WithExtraInstructions appends text to the instructions, e.g. to tell
+// agents about project conventions
+func WithExtraInstructions(text string) Option {
+	return func(c *config) { c.server.ExtraInstructions = text }
+}
+
+// WithTokenBudget summarizes tool results estimated to take more than n
+// tokens: the sizes of their lists and the first items, with a cursor to
+// page through the rest. Calls can set their own tokenBudget. Zero, the
+// default, means no limit.
+func WithTokenBudget(n int) Option {
+	return func(c *config) { c.server.TokenBudget = n }
+}
+
+// WithTransport sets the transport Serve uses. It defaults to Stdio.
+func WithTransport(transport Transport) Option {
+	return func(c *config) { c.transport = transport }
+}
+
+// WithIdleExit makes Serve return after d without MCP requests, for
+// supervisors such as systemd that start the server again on demand. Tool
+// calls still running count as activity.
+func WithIdleExit(d time.Duration) Option {
+	return func(c *config) { c.idleExit = d }
+}
+
+// Server is an embeddable mcp-gopls MCP server
+type Server struct {
+	inner     *server.Server
+	transport Transport
+	idleExit  time.Duration
+}
+
+// New creates a server. gopls is not started until Serve is called.
+func New(opts ...Option) (*Server, error) {
+	c := &config{transport: Stdio()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.allow != nil || c.deny != nil {
+		WithToolFilter(func(name string) bool {
+			return (c.allow == nil || c.allow[name]) && !c.deny[name]
+		})(c)
+	}
+
+	extras, err := c.extraTools()
+	if err != nil {
+		return nil, err
+	}
+	c.server.ExtraTools = extras
+
+	inner, err := server.New(c.server)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{inner: inner, transport: c.transport, idleExit: c.idleExit}, nil
+}
+
+// Tools returns the names of the tools the server exposes
+func (s *Server) Tools() []string {
+	return s.inner.ToolNames()
+}
+
+// Serve starts gopls and serves MCP over the configured transport until ctx
+// is done, the transport fails or the server has been idle for the
+// WithIdleExit duration
+func (s *Server) Serve(ctx context.Context) error {
+	if err := s.Initialize(ctx); err != nil {
+		return err
+	}
+	if s.idleExit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		go s.exitWhenIdle(ctx, cancel)
+	}
+	return s.transport(ctx, s.inner.MCPServer(), s.inner.Logger())
+}
+
+// exitWhenIdle calls cancel once the server has been idle for s.idleExit
+func (s *Server) exitWhenIdle(ctx context.Context, cancel context.CancelFunc) {
+	ticker := time.NewTicker(min(s.idleExit/4, time.Minute))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if idle := s.inner.Idle(); idle >= s.idleExit {
+			s.inner.Logger().Printf("no MCP activity for %s, exiting", idle.Round(time.Second))
+			cancel()
+			return
+		}
+	}
+}
+
+// Initialize starts gopls without serving. Serve calls it; it is only needed
+// when serving MCPServer directly.
+func (s *Server) Initialize(ctx context.Context) error {
+	return s.inner.Initialize(ctx)
+}
+
+// MCPServer returns the underlying MCP server, for serving it over a
+// transport this package does not provide. Call Initialize first so gopls
+// is running.
+func (s *Server) MCPServer() *mcpserver.MCPServer {
+	return s.inner.MCPServer()
+}
+
+// Close shuts down gopls
+func (s *Server) Close() error {
+	return s.inner.Shutdown()
+}
@@ -0,0 +1,119 @@
+package mcpgopls
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/server"
+)
+
+// HandlerFactory builds a tool's handler once the server's workspace exists
+type HandlerFactory func(ws *Workspace) mcpserver.ToolHandlerFunc
+
+// Tool is an additional tool supplied by a plugin or embedding program
+type Tool struct {
+	Definition mcp.Tool
+	Handler    HandlerFactory
+}
+
+// Plugin contributes additional tools. Plugin tools go through the same
+// argument validation, path sandboxing, timeouts and tool filters as the
+// built-in tools.
+type Plugin interface {
+	Name() string
+	Tools() []Tool
+}
+
+var (
+	pluginsMu sync.Mutex
+	plugins   = make(map[string]Plugin)
+)
+
+// Register makes a plugin available to every server created afterwards,
+// typically from the plugin package's init function so that importing it
+// for side effects is enough. It panics if a plugin with the same name is
+// already registered.
+func Register(p Plugin) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	if p == nil {
+		panic("mcpgopls: Register plugin is nil")
+	}
+	if _, dup := plugins[p.Name()]; dup {
+		panic("mcpgopls: Register called twice for plugin " + p.Name())
+	}
+	plugins[p.Name()] = p
+}
+
+// Plugins returns the names of the registered plugins, sorted
+func Plugins() []string {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	return sortedKeys(plugins)
+}
+
+// WithPlugin adds a plugin's tools to this server only, without registering
+// it globally
+func WithPlugin(p Plugin) Option {
+	return func(c *config) { c.plugins = append(c.plugins, p) }
+}
+
+// WithTool adds a single tool to this server
+func WithTool(definition mcp.Tool, handler HandlerFactory) Option {
+	return func(c *config) { c.tools = append(c.tools, Tool{Definition: definition, Handler: handler}) }
+}
+
+// WithoutRegisteredPlugins ignores plugins registered with Register
+func WithoutRegisteredPlugins() Option {
+	return func(c *config) { c.skipRegistered = true }
+}
+
+// extraTools collects the tools of the registered plugins, then those added
+// with WithPlugin and WithTool, in that order
+func (c *config) extraTools() ([]server.ExtraTool, error) {
+	var all []Plugin
+	if !c.skipRegistered {
+		pluginsMu.Lock()
+		for _, name := range sortedKeys(plugins) {
+			all = append(all, plugins[name])
+		}
+		pluginsMu.Unlock()
+	}
+	all = append(all, c.plugins...)
+
+	var tools []Tool
+	for _, p := range all {
+		tools = append(tools, p.Tools()...)
+	}
+	tools = append(tools, c.tools...)
+
+	extras := make([]server.ExtraTool, 0, len(tools))
+	for _, tool := range tools {
+		if tool.Handler == nil {
+			return nil, fmt.Errorf("tool %s has no handler", tool.Definition.Name)
+		}
+		factory := tool.Handler
+		extras = append(extras, server.ExtraTool{
+			Tool: tool.Definition,
+			Handler: func(manager *gopls.Manager) mcpserver.ToolHandlerFunc {
+				return factory(&Workspace{manager: manager})
+			},
+		})
+	}
+	return extras, nil
+}
+
+func sortedKeys(m map[string]Plugin) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
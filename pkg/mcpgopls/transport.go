@@ -0,0 +1,162 @@
+package mcpgopls
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// Transport serves an MCP server until ctx is done or serving fails
+type Transport func(ctx context.Context, s *mcpserver.MCPServer, logger *log.Logger) error
+
+// Stdio serves MCP over the process's stdin and stdout
+func Stdio() Transport {
+	return StdioStreams(os.Stdin, os.Stdout)
+}
+
+// StdioStreams serves MCP over the given streams, such as pipes to a
+// subprocess or an in-memory connection
+func StdioStreams(in io.Reader, out io.Writer) Transport {
+	return func(ctx context.Context, s *mcpserver.MCPServer, logger *log.Logger) error {
+		stdio := mcpserver.NewStdioServer(s)
+		stdio.SetErrorLogger(logger)
+		err := stdio.Listen(ctx, in, out)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return err
+	}
+}
+
+// FileDescriptor serves MCP over an inherited file descriptor that is
+// already connected to the client, such as a socket passed by a supervisor
+// that accepts connections itself (systemd Accept=yes, inetd) or a pipe
+func FileDescriptor(fd uintptr) Transport {
+	return func(ctx context.Context, s *mcpserver.MCPServer, logger *log.Logger) error {
+		f := os.NewFile(fd, fmt.Sprintf("fd %d", fd))
+		if f == nil {
+			return fmt.Errorf("invalid file descriptor %d", fd)
+		}
+		defer f.Close()
+		return StdioStreams(f, f)(ctx, s, logger)
+	}
+}
+
+// ListenerFileDescriptor accepts clients on an inherited listening socket,
+// such as the one systemd socket activation passes as file descriptor 3
+func ListenerFileDescriptor(fd uintptr) Transport {
+	return func(ctx context.Context, s *mcpserver.MCPServer, logger *log.Logger) error {
+		f := os.NewFile(fd, fmt.Sprintf("fd %d", fd))
+		if f == nil {
+			return fmt.Errorf("invalid file descriptor %d", fd)
+		}
+		listener, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("file descriptor %d is not a listening socket: %w", fd, err)
+		}
+		return Listener(listener)(ctx, s, logger)
+	}
+}
+
+// UnixSocket accepts clients on a unix socket created at path, replacing a
+// stale socket left by an earlier run
+func UnixSocket(path string) Transport {
+	return func(ctx context.Context, s *mcpserver.MCPServer, logger *log.Logger) error {
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(path)
+		}
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			return err
+		}
+		// The listener removes the socket file when closed
+		return Listener(listener)(ctx, s, logger)
+	}
+}
+
+// Listener accepts clients on l and speaks the stdio protocol to each. The
+// MCP server has a single stdio session, so clients are served one at a
+// time; later ones wait until the current client disconnects.
+func Listener(l net.Listener) Transport {
+	return func(ctx context.Context, s *mcpserver.MCPServer, logger *log.Logger) error {
+		go func() {
+			<-ctx.Done()
+			l.Close()
+		}()
+
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+			err = serveConn(ctx, conn, s, logger)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				logger.Printf("client %s: %v", conn.RemoteAddr(), err)
+			}
+		}
+	}
+}
+
+// serveConn serves one client until it disconnects or ctx is done
+func serveConn(ctx context.Context, conn net.Conn, s *mcpserver.MCPServer, logger *log.Logger) error {
+	defer conn.Close()
+
+	// Closing the connection ends the read the stdio server is blocked in
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-connCtx.Done()
+		conn.Close()
+	}()
+	return StdioStreams(conn, conn)(connCtx, s, logger)
+}
+
+// SSE serves MCP over HTTP with server-sent events on addr
+func SSE(addr string, opts ...mcpserver.SSEOption) Transport {
+	return func(ctx context.Context, s *mcpserver.MCPServer, logger *log.Logger) error {
+		sse := mcpserver.NewSSEServer(s, opts...)
+		return serveHTTP(ctx, func() error { return sse.Start(addr) }, sse.Shutdown)
+	}
+}
+
+// StreamableHTTP serves MCP over the streamable HTTP transport on addr
+func StreamableHTTP(addr string, opts ...mcpserver.StreamableHTTPOption) Transport {
+	return func(ctx context.Context, s *mcpserver.MCPServer, logger *log.Logger) error {
+		streamable := mcpserver.NewStreamableHTTPServer(s, opts...)
+		return serveHTTP(ctx, func() error { return streamable.Start(addr) }, streamable.Shutdown)
+	}
+}
+
+// serveHTTP runs start until it fails or ctx is done, then shuts the server
+// down gracefully
+func serveHTTP(ctx context.Context, start func() error, shutdown func(context.Context) error) error {
+	errs := make(chan error, 1)
+	go func() { errs <- start() }()
+
+	select {
+	case err := <-errs:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return shutdown(shutdownCtx)
+	}
+}
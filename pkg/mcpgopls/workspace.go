@@ -0,0 +1,104 @@
+package mcpgopls
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/yantrio/mcp-gopls/internal/edits"
+	"github.com/yantrio/mcp-gopls/internal/gopls"
+	"github.com/yantrio/mcp-gopls/internal/typecheck"
+)
+
+// Workspace gives plugin tools access to the gopls instance and the helpers
+// the built-in tools use
+type Workspace struct {
+	manager *gopls.Manager
+}
+
+// Root returns the workspace root directory
+func (w *Workspace) Root() string {
+	return w.manager.WorkspaceRoot()
+}
+
+// ResolvePath makes path absolute, treating relative paths as relative to
+// the workspace root. An empty path resolves to the workspace root.
+func (w *Workspace) ResolvePath(path string) string {
+	return w.manager.ResolvePath(path)
+}
+
+// ValidateFile checks that gopls can see path, adding its module as a
+// workspace folder when the server allows it
+func (w *Workspace) ValidateFile(ctx context.Context, path string) error {
+	return w.manager.ValidateFile(ctx, path)
+}
+
+// Call sends an LSP request to gopls and decodes the response into result
+func (w *Workspace) Call(ctx context.Context, method string, params, result interface{}) error {
+	client, err := w.manager.GetClient()
+	if err != nil {
+		return err
+	}
+	return client.Call(ctx, method, params, result)
+}
+
+// WriteFiles writes several files as one change, replacing the originals only
+// once every file was written
+func (w *Workspace) WriteFiles(files map[string][]byte) error {
+	return w.manager.WriteFiles(files)
+}
+
+// NotifyFileWritten tells gopls that a tool created or changed a file
+func (w *Workspace) NotifyFileWritten(ctx context.Context, path string, created bool) error {
+	return w.manager.NotifyFileWritten(ctx, path, created)
+}
+
+// OrganizeImports runs gopls's organize imports on pending file contents,
+// replacing each entry in files with the organized content
+func (w *Workspace) OrganizeImports(ctx context.Context, files map[string][]byte) error {
+	return w.manager.OrganizeImports(ctx, files)
+}
+
+// Diff returns a unified diff between before and after labelled with path,
+// or an empty string when they are identical
+func (w *Workspace) Diff(path, before, after string) string {
+	return edits.Unified(path, before, after)
+}
+
+// Package is a parsed and type-checked package
+type Package struct {
+	ImportPath string
+	Name       string
+	Dir        string
+	Fset       *token.FileSet
+	Files      []*ast.File
+	Types      *types.Package
+	Info       *types.Info
+	// Errors are the package's load, parse and type errors. A package with
+	// errors still has as much type information as could be computed.
+	Errors []string
+}
+
+// LoadPackages parses and type-checks the packages matching patterns in dir,
+// reading dependencies from compiler export data
+func (w *Workspace) LoadPackages(ctx context.Context, dir string, patterns ...string) ([]*Package, error) {
+	loaded, err := typecheck.Load(ctx, w.manager.ResolvePath(dir), patterns...)
+	if err != nil {
+		return nil, err
+	}
+	pkgs := make([]*Package, len(loaded))
+	for i, p := range loaded {
+		pkgs[i] = &Package{
+			ImportPath: p.ImportPath,
+			Name:       p.Name,
+			Dir:        p.Dir,
+			Fset:       p.Fset,
+			Files:      p.Files,
+			Types:      p.Types,
+			Info:       p.Info,
+			Errors:     p.Errors,
+		}
+	}
+	return pkgs, nil
+}